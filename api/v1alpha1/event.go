@@ -21,6 +21,8 @@ const (
 	ReasonExecute = "Execute"
 	// ReasonGenerate is the reason for generating a workflow
 	ReasonGenerate = "Generate"
+	// ReasonStepWarning is the reason for the warnings of a workflow step
+	ReasonStepWarning = "StepWarning"
 )
 
 const (
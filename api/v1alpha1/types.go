@@ -31,6 +31,7 @@ import (
 // +kubebuilder:resource:categories={oam},shortName={wr}
 // +kubebuilder:subresource:status
 // +kubebuilder:printcolumn:name="PHASE",type=string,JSONPath=`.status.status`
+// +kubebuilder:printcolumn:name="MESSAGE",type=string,JSONPath=`.status.message`
 // +kubebuilder:printcolumn:name="AGE",type=date,JSONPath=".metadata.creationTimestamp"
 // +genclient
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -169,6 +170,8 @@ type WorkflowStep struct {
 // WorkflowStepMeta contains the meta data of a workflow step
 type WorkflowStepMeta struct {
 	Alias string `json:"alias,omitempty"`
+	// Annotations is the annotations of the workflow step
+	Annotations map[string]string `json:"annotations,omitempty"`
 }
 
 // WorkflowStepBase defines the workflow step base
@@ -183,8 +186,16 @@ type WorkflowStepBase struct {
 	If string `json:"if,omitempty"`
 	// Timeout is the timeout of the step
 	Timeout string `json:"timeout,omitempty"`
+	// PollInterval is the interval to poll the step when it is waiting
+	PollInterval string `json:"pollInterval,omitempty"`
+	// PollBackoff is the backoff of the poll interval
+	PollBackoff *PollBackoff `json:"pollBackoff,omitempty"`
+	// Credentials is the short-lived credentials requested for the step, which are used by the providers of the step
+	Credentials *StepCredentials `json:"credentials,omitempty"`
 	// DependsOn is the dependency of the step
 	DependsOn []string `json:"dependsOn,omitempty"`
+	// ConditionalDependsOn is the dependency of the step which only takes effect when its condition is satisfied
+	ConditionalDependsOn []ConditionalDependency `json:"conditionalDependsOn,omitempty"`
 	// Inputs is the inputs of the step
 	Inputs StepInputs `json:"inputs,omitempty"`
 	// Outputs is the outputs of the step
@@ -195,6 +206,34 @@ type WorkflowStepBase struct {
 	Properties *runtime.RawExtension `json:"properties,omitempty"`
 }
 
+// PollBackoff defines the growth of the poll interval of a waiting step
+type PollBackoff struct {
+	// Factor is the multiplier of the poll interval after each poll
+	Factor int `json:"factor,omitempty"`
+	// MaxInterval is the upper limit of the poll interval
+	MaxInterval string `json:"maxInterval,omitempty"`
+}
+
+// ConditionalDependency defines a dependency of the step with a CUE condition, the condition is evaluated
+// against the live context when the step is scheduled and the dependency is ignored if it is false
+type ConditionalDependency struct {
+	// Name is the name of the step to depend on
+	Name string `json:"name"`
+	// If is the condition of the dependency
+	If string `json:"if"`
+}
+
+// StepCredentials defines the bound service account token requested for a step, the token is
+// only used by the kube clients of the providers in the step and discarded after the step execution
+type StepCredentials struct {
+	// ServiceAccountName is the name of the service account in the namespace of the workflow to request the token for
+	ServiceAccountName string `json:"serviceAccountName"`
+	// Audiences are the intended audiences of the token
+	Audiences []string `json:"audiences,omitempty"`
+	// ExpirationSeconds is the requested duration of validity of the token, default to 600
+	ExpirationSeconds *int64 `json:"expirationSeconds,omitempty"`
+}
+
 // WorkflowMode describes the mode of workflow
 type WorkflowMode string
 
@@ -219,6 +258,8 @@ type StepStatus struct {
 	FirstExecuteTime metav1.Time `json:"firstExecuteTime,omitempty"`
 	// LastExecuteTime is the last time this step execution.
 	LastExecuteTime metav1.Time `json:"lastExecuteTime,omitempty"`
+	// Warnings are the non-fatal issues reported by the providers during the step execution.
+	Warnings []string `json:"warnings,omitempty"`
 }
 
 // WorkflowStepStatus record the status of a workflow step, include step status and subStep status
@@ -25,6 +25,21 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConditionalDependency) DeepCopyInto(out *ConditionalDependency) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConditionalDependency.
+func (in *ConditionalDependency) DeepCopy() *ConditionalDependency {
+	if in == nil {
+		return nil
+	}
+	out := new(ConditionalDependency)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *InputItem) DeepCopyInto(out *InputItem) {
 	*out = *in
@@ -93,11 +108,56 @@ func (in StepOutputs) DeepCopy() StepOutputs {
 	return *out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PollBackoff) DeepCopyInto(out *PollBackoff) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PollBackoff.
+func (in *PollBackoff) DeepCopy() *PollBackoff {
+	if in == nil {
+		return nil
+	}
+	out := new(PollBackoff)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StepCredentials) DeepCopyInto(out *StepCredentials) {
+	*out = *in
+	if in.Audiences != nil {
+		in, out := &in.Audiences, &out.Audiences
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ExpirationSeconds != nil {
+		in, out := &in.ExpirationSeconds, &out.ExpirationSeconds
+		*out = new(int64)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StepCredentials.
+func (in *StepCredentials) DeepCopy() *StepCredentials {
+	if in == nil {
+		return nil
+	}
+	out := new(StepCredentials)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *StepStatus) DeepCopyInto(out *StepStatus) {
 	*out = *in
 	in.FirstExecuteTime.DeepCopyInto(&out.FirstExecuteTime)
 	in.LastExecuteTime.DeepCopyInto(&out.LastExecuteTime)
+	if in.Warnings != nil {
+		in, out := &in.Warnings, &out.Warnings
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StepStatus.
@@ -359,13 +419,28 @@ func (in *WorkflowStepBase) DeepCopyInto(out *WorkflowStepBase) {
 	if in.Meta != nil {
 		in, out := &in.Meta, &out.Meta
 		*out = new(WorkflowStepMeta)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PollBackoff != nil {
+		in, out := &in.PollBackoff, &out.PollBackoff
+		*out = new(PollBackoff)
 		**out = **in
 	}
+	if in.Credentials != nil {
+		in, out := &in.Credentials, &out.Credentials
+		*out = new(StepCredentials)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.DependsOn != nil {
 		in, out := &in.DependsOn, &out.DependsOn
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.ConditionalDependsOn != nil {
+		in, out := &in.ConditionalDependsOn, &out.ConditionalDependsOn
+		*out = make([]ConditionalDependency, len(*in))
+		copy(*out, *in)
+	}
 	if in.Inputs != nil {
 		in, out := &in.Inputs, &out.Inputs
 		*out = make(StepInputs, len(*in))
@@ -396,6 +471,13 @@ func (in *WorkflowStepBase) DeepCopy() *WorkflowStepBase {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *WorkflowStepMeta) DeepCopyInto(out *WorkflowStepMeta) {
 	*out = *in
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkflowStepMeta.
@@ -44,6 +44,7 @@ import (
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
 	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
 	crtlwebhook "sigs.k8s.io/controller-runtime/pkg/webhook"
 
@@ -55,9 +56,19 @@ import (
 	"github.com/kubevela/workflow/controllers"
 	"github.com/kubevela/workflow/pkg/backup"
 	"github.com/kubevela/workflow/pkg/common"
+	wfContext "github.com/kubevela/workflow/pkg/context"
+	"github.com/kubevela/workflow/pkg/executor"
 	"github.com/kubevela/workflow/pkg/features"
+	"github.com/kubevela/workflow/pkg/monitor/metrics"
 	"github.com/kubevela/workflow/pkg/monitor/watcher"
 	"github.com/kubevela/workflow/pkg/providers"
+	gitprovider "github.com/kubevela/workflow/pkg/providers/git"
+	httpprovider "github.com/kubevela/workflow/pkg/providers/http"
+	"github.com/kubevela/workflow/pkg/providers/objectstorage"
+	timeprovider "github.com/kubevela/workflow/pkg/providers/time"
+	providertypes "github.com/kubevela/workflow/pkg/providers/types"
+	webhookprovider "github.com/kubevela/workflow/pkg/providers/webhook"
+	"github.com/kubevela/workflow/pkg/sink"
 	"github.com/kubevela/workflow/pkg/types"
 	"github.com/kubevela/workflow/pkg/utils"
 	"github.com/kubevela/workflow/pkg/webhook"
@@ -79,7 +90,7 @@ func init() {
 }
 
 func main() {
-	var metricsAddr, logFilePath, probeAddr, pprofAddr, leaderElectionResourceLock, userAgent, certDir string
+	var metricsAddr, logFilePath, probeAddr, pprofAddr, callbackAddr, leaderElectionResourceLock, userAgent, certDir string
 	var backupStrategy, backupIgnoreStrategy, backupPersistType, groupByLabel, backupConfigSecretName, backupConfigSecretNamespace string
 	var enableLeaderElection, useWebhook, logDebug, backupCleanOnBackup bool
 	var qps float64
@@ -87,6 +98,10 @@ func main() {
 	var burst, webhookPort int
 	var leaseDuration, renewDeadline, retryPeriod, recycleDuration time.Duration
 	var controllerArgs controllers.Args
+	var stepMetricLabels map[string]string
+	var untrustedProviders map[string]string
+	var outputSinkURL string
+	var stepPoolSize int
 
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
@@ -111,8 +126,24 @@ func main() {
 	flag.IntVar(&webhookPort, "webhook-port", 9443, "admission webhook listen address")
 	flag.IntVar(&controllerArgs.ConcurrentReconciles, "concurrent-reconciles", 4, "concurrent-reconciles is the concurrent reconcile number of the controller. The default value is 4")
 	flag.BoolVar(&controllerArgs.IgnoreWorkflowWithoutControllerRequirement, "ignore-workflow-without-controller-requirement", false, "If true, workflow controller will not process the workflowrun without 'workflowrun.oam.dev/controller-version-require' annotation")
+	flag.BoolVar(&controllerArgs.RecordStepWarningEvents, "record-step-warning-events", false, "If true, the warnings reported by the providers of the workflow steps are recorded as the events of the workflowrun")
 	flag.Float64Var(&qps, "kube-api-qps", 50, "the qps for reconcile clients. Low qps may lead to low throughput. High qps may give stress to api-server. Raise this value if concurrent-reconciles is set to be high.")
 	flag.IntVar(&burst, "kube-api-burst", 100, "the burst for reconcile clients. Recommend setting it qps*2.")
+	flag.Float64Var(&controllerArgs.ProviderKubeAPIQPS, "provider-kube-api-qps", 0, "the qps for the kube client used by the providers of each workflowrun, which can be lowered by the annotation 'workflowrun.oam.dev/provider-kube-api-qps' of the workflowrun. The shared client is used if not set. Note that the rate limiter of the http provider is applied separately.")
+	flag.IntVar(&controllerArgs.ProviderKubeAPIBurst, "provider-kube-api-burst", 0, "the burst for the kube client used by the providers of each workflowrun, which can be lowered by the annotation 'workflowrun.oam.dev/provider-kube-api-burst' of the workflowrun. The shared client is used if not set.")
+	flag.StringToStringVar(&untrustedProviders, "untrusted-provider-context", nil, "the context allowlist of the untrusted providers, formatted as <provider>=<path>;<path>, the provider can be a provider name or <provider>.<function>, the paths are like context.name or vars.app.image. The untrusted providers, including the ones of the external packages, can only read the allowed context, and the secrets are excluded unless allowed explicitly. The kube client of the untrusted providers cannot access the secrets unless kube.secrets is allowed.")
+	flag.IntVar(&stepPoolSize, "step-worker-pool-size", 0, "The number of the workers shared across the workflow runs to execute the steps, the pending steps are scheduled fairly across the workflow runs. The default value is 0 which means the steps are executed in the reconcile goroutines.")
+	flag.Int64Var(&httpprovider.DefaultMaxResponseBytes, "http-max-response-bytes", 10<<20, "The default limit in bytes of the response body of the http provider, which can be overridden by the maxResponseBytes of the request.")
+	flag.StringVar(&httpprovider.ResponseBodyDir, "http-response-body-dir", "", "The directory which the http provider can write the response bodies to with the bodyFile of the request. The default value is empty which means writing the bodies to files is disabled.")
+	flag.StringVar(&gitprovider.WorkspaceDir, "git-workspace-dir", gitprovider.WorkspaceDir, "The directory which the git provider clones the repositories into.")
+	flag.Int64Var(&objectstorage.DefaultMaxObjectBytes, "objectstorage-max-object-bytes", 10<<20, "The default limit in bytes of the objects read by the objectstorage provider, which can be overridden by the maxBytes of the get.")
+	flag.DurationVar(&timeprovider.MaxSleepDuration, "time-max-sleep-duration", timeprovider.MaxSleepDuration, "The max duration of the sleep of the time provider, which should be below the 3m reconcile timeout. The sleep blocks the reconciliation of the workflow so suspend is preferred for the long delays.")
+	flag.StringVar(&outputSinkURL, "output-sink-webhook-url", "", "The url of the webhook which receives the outputs of each workflow step once the step is finished. The failures of the webhook do not fail the steps. The default value is empty which means do not stream the outputs.")
+	flag.IntVar(&wfContext.OutputCompressionThreshold, "step-output-compression-threshold", 0, "The size in bytes above which the step outputs are compressed with gzip in the workflow context, the outputs are decompressed transparently for the dependent steps. The default value is 0 which means do not compress the outputs.")
+	flag.StringVar(&controllerArgs.ContextSnapshotCodec, "context-snapshot-codec", "", "The codec to persist the vars of the workflow context as the encoded snapshot, which can be json or gzip+json. The contexts persisted with any codec can be loaded. The default value is empty which means persist the vars as the cue string.")
+	flag.StringToStringVar(&stepMetricLabels, "step-metric-labels", nil, "the allowlist of step annotations to be added as labels of the metric workflowrun_step_labeled_duration_ms, formatted as <annotation>=<label>. Only use the annotations with low cardinality.")
+	flag.StringVar(&callbackAddr, "webhook-callback-bind-address", "", "The address the callback endpoint of the webhook.wait steps binds to. The default value is empty which means do not expose it.")
+	flag.StringVar(&webhookprovider.CallbackBaseURL, "webhook-callback-url", "", "The external base url of the callback endpoint, which is used to generate the callback url for the webhook.wait steps.")
 	flag.StringVar(&userAgent, "user-agent", "vela-workflow", "the user agent of the client.")
 	flag.StringVar(&pprofAddr, "pprof-addr", "", "The address for pprof to use while exporting profiling results. The default value is empty which means do not expose it. Set it to address like :6666 to expose it.")
 	flag.IntVar(&types.MaxWorkflowWaitBackoffTime, "max-workflow-wait-backoff-time", 60, "Set the max workflow wait backoff time, default is 60")
@@ -181,6 +212,40 @@ func main() {
 
 	ctrl.SetLogger(textlogger.NewLogger(textlogger.NewConfig()))
 
+	if len(stepMetricLabels) > 0 {
+		if err := metrics.SetStepLabelAnnotations(stepMetricLabels); err != nil {
+			klog.Error(err, "unable to set step metric labels")
+			os.Exit(1)
+		}
+	}
+
+	if codec := controllerArgs.ContextSnapshotCodec; codec != "" {
+		if err := wfContext.ValidateSnapshotCodec(codec); err != nil {
+			klog.Error(err, "unable to set the context snapshot codec")
+			os.Exit(1)
+		}
+	}
+
+	for provider, paths := range untrustedProviders {
+		controllerArgs.ProviderContextScopes = append(controllerArgs.ProviderContextScopes, providertypes.ContextScope{
+			Provider: provider,
+			Paths:    strings.Split(paths, ";"),
+		})
+	}
+
+	if outputSinkURL != "" {
+		outputSink, err := sink.NewWebhook(outputSinkURL, 0)
+		if err != nil {
+			klog.Error(err, "unable to create the output sink")
+			os.Exit(1)
+		}
+		controllerArgs.OutputSink = outputSink
+	}
+
+	if stepPoolSize > 0 {
+		controllerArgs.StepPool = executor.NewStepPool(stepPoolSize)
+	}
+
 	klog.InfoS("KubeVela Workflow information", "version", version.VelaVersion, "revision", version.GitRevision)
 
 	restConfig := ctrl.GetConfigOrDie()
@@ -230,6 +295,28 @@ func main() {
 		}
 	}
 
+	if pool := controllerArgs.StepPool; pool != nil {
+		// stop the workers with the manager, after the running steps finish
+		if err := mgr.Add(manager.RunnableFunc(func(ctx context.Context) error {
+			<-ctx.Done()
+			pool.Stop()
+			return nil
+		})); err != nil {
+			klog.Error(err, "unable to start the step pool")
+			os.Exit(1)
+		}
+	}
+
+	if callbackAddr != "" {
+		klog.InfoS("Enable webhook callback endpoint", "address", callbackAddr)
+		if err := mgr.Add(manager.RunnableFunc(func(ctx context.Context) error {
+			return startCallbackServer(ctx, callbackAddr, webhookprovider.NewCallbackHandler(kubeClient))
+		})); err != nil {
+			klog.Error(err, "unable to start webhook callback endpoint")
+			os.Exit(1)
+		}
+	}
+
 	if useWebhook {
 		klog.InfoS("Enable webhook", "server port", strconv.Itoa(webhookPort))
 		webhook.Register(mgr, controllerArgs)
@@ -360,3 +447,26 @@ func waitWebhookSecretVolume(certDir string, timeout, interval time.Duration) er
 		}
 	}
 }
+
+// startCallbackServer serves the callback endpoint of the webhook.wait steps until the context is done
+func startCallbackServer(ctx context.Context, addr string, handler http.Handler) error {
+	mux := http.NewServeMux()
+	mux.Handle(webhookprovider.CallbackPath, handler)
+	server := http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			klog.Error(err, "Failed to shutdown webhook callback server")
+		}
+	}()
+	if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
@@ -57,6 +57,20 @@ type Args struct {
 	ConcurrentReconciles int
 	// IgnoreWorkflowWithoutControllerRequirement indicates that workflow controller will not process the workflowrun without 'workflowrun.oam.dev/controller-version-require' annotation.
 	IgnoreWorkflowWithoutControllerRequirement bool
+	// ProviderKubeAPIQPS is the qps of the kube client used by providers, the shared kube client is used if not set
+	ProviderKubeAPIQPS float64
+	// ProviderKubeAPIBurst is the burst of the kube client used by providers, the shared kube client is used if not set
+	ProviderKubeAPIBurst int
+	// RecordStepWarningEvents indicates that the warnings of the workflow steps are recorded as the events of the workflowrun
+	RecordStepWarningEvents bool
+	// ProviderContextScopes are the context allowlist of the untrusted providers
+	ProviderContextScopes []providertypes.ContextScope
+	// OutputSink receives the outputs of the workflow steps once the steps are finished
+	OutputSink types.OutputSink
+	// StepPool is the worker pool shared across the workflow runs to execute the steps, the steps are executed in the reconcile goroutines if not set
+	StepPool *executor.StepPool
+	// ContextSnapshotCodec is the codec to persist the vars of the workflow context as the encoded snapshot, the vars are persisted as the cue string if not set
+	ContextSnapshotCodec string
 }
 
 // WorkflowRunReconciler reconciles a WorkflowRun object
@@ -141,7 +155,24 @@ func (r *WorkflowRunReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		Client: r.Client,
 		run:    run,
 	}
-	executor := executor.New(instance, executor.WithStatusPatcher(patcher.patchStatus))
+	options := []executor.Option{
+		executor.WithStatusPatcher(patcher.patchStatus),
+		executor.WithProviderClientRateLimit(float32(r.ProviderKubeAPIQPS), r.ProviderKubeAPIBurst),
+	}
+	if len(r.ProviderContextScopes) > 0 {
+		options = append(options, executor.WithInterceptors(providertypes.RestrictContext(r.ProviderContextScopes...)))
+	}
+	if r.OutputSink != nil {
+		options = append(options, executor.WithOutputSink(r.OutputSink))
+	}
+	if r.StepPool != nil {
+		options = append(options, executor.WithStepPool(r.StepPool))
+	}
+	if r.ContextSnapshotCodec != "" {
+		options = append(options, executor.WithContextSnapshotCodec(r.ContextSnapshotCodec))
+	}
+	executor := executor.New(instance, options...)
+	recordedWarnings := stepWarnings(run.Status)
 	state, err := executor.ExecuteRunners(logCtx, runners)
 	if err != nil {
 		logCtx.Error(err, "[execute runners]")
@@ -152,6 +183,9 @@ func (r *WorkflowRunReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 	isUpdate = isUpdate && instance.Status.Message == ""
 	run.Status = instance.Status
 	run.Status.Phase = state
+	if r.RecordStepWarningEvents {
+		r.recordStepWarnings(run, recordedWarnings)
+	}
 	switch state {
 	case v1alpha1.WorkflowStateSuspending:
 		logCtx.Info("Workflow return state=Suspend")
@@ -282,6 +316,40 @@ func (r *WorkflowRunReconciler) doWorkflowFinish(wr *v1alpha1.WorkflowRun) {
 	metrics.WorkflowRunFinishedTimeHistogram.WithLabelValues(string(wr.Status.Phase)).Observe(wr.Status.EndTime.Sub(wr.Status.StartTime.Time).Seconds())
 	executor.StepStatusCache.Delete(fmt.Sprintf("%s-%s", wr.Name, wr.Namespace))
 	wfContext.CleanupMemoryStore(wr.Name, wr.Namespace)
+	providertypes.ReleaseKubeClientWithRateLimit(string(wr.UID))
+}
+
+// recordStepWarnings records the warnings of the steps which are not recorded in the previous status as events
+func (r *WorkflowRunReconciler) recordStepWarnings(wr *v1alpha1.WorkflowRun, recorded map[string]bool) {
+	record := func(status v1alpha1.StepStatus) {
+		for _, warning := range status.Warnings {
+			if !recorded[status.Name+"\x00"+warning] {
+				r.Recorder.Event(wr, event.Warning(v1alpha1.ReasonStepWarning, fmt.Errorf("step %s: %s", status.Name, warning)))
+			}
+		}
+	}
+	for _, step := range wr.Status.Steps {
+		record(step.StepStatus)
+		for _, sub := range step.SubStepsStatus {
+			record(sub)
+		}
+	}
+}
+
+func stepWarnings(status v1alpha1.WorkflowRunStatus) map[string]bool {
+	warnings := make(map[string]bool)
+	add := func(status v1alpha1.StepStatus) {
+		for _, warning := range status.Warnings {
+			warnings[status.Name+"\x00"+warning] = true
+		}
+	}
+	for _, step := range status.Steps {
+		add(step.StepStatus)
+		for _, sub := range step.SubStepsStatus {
+			add(sub)
+		}
+	}
+	return warnings
 }
 
 func timeReconcile(wr *v1alpha1.WorkflowRun) func() {
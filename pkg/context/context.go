@@ -53,15 +53,19 @@ var (
 
 // WorkflowContext is workflow context.
 type WorkflowContext struct {
-	store       *corev1.ConfigMap
-	memoryStore *sync.Map
-	vars        cue.Value
-	modified    bool
+	store         *corev1.ConfigMap
+	memoryStore   *sync.Map
+	vars          cue.Value
+	modified      bool
+	snapshotCodec string
 }
 
 // GetVar get variable from workflow context.
 func (wf *WorkflowContext) GetVar(paths ...string) (cue.Value, error) {
-	v := wf.vars.LookupPath(value.FieldPath(paths...))
+	v, err := lookupVar(wf.vars, paths...)
+	if err != nil {
+		return v, errors.WithMessagef(err, "decompress var %s", strings.Join(paths, "."))
+	}
 	if !v.Exists() {
 		return v, fmt.Errorf("var %s not found", strings.Join(paths, "."))
 	}
@@ -145,6 +149,12 @@ func (wf *WorkflowContext) DeleteValueInMemory(paths ...string) {
 	wf.memoryStore.Delete(strings.Join(paths, "."))
 }
 
+// SetSnapshotCodec sets the codec to persist the vars as the encoded snapshot on commit, the vars are
+// persisted as the cue string if the codec is empty.
+func (wf *WorkflowContext) SetSnapshotCodec(codec string) {
+	wf.snapshotCodec = codec
+}
+
 // Commit the workflow context and persist it's content.
 func (wf *WorkflowContext) Commit(ctx context.Context) error {
 	if !wf.modified {
@@ -168,8 +178,21 @@ func (wf *WorkflowContext) writeToStore() error {
 	if wf.store.Data == nil {
 		wf.store.Data = make(map[string]string)
 	}
-
+	if wf.snapshotCodec != "" {
+		// the vars which can not be converted to json, e.g. the incomplete ones, are kept as the cue string
+		if data, err := encodeVars(wf.vars, wf.snapshotCodec); err == nil {
+			if wf.store.BinaryData == nil {
+				wf.store.BinaryData = make(map[string][]byte)
+			}
+			wf.store.BinaryData[ConfigMapKeyVars] = data
+			delete(wf.store.Data, ConfigMapKeyVars)
+			return nil
+		} else if !errors.Is(err, errVarsNotJSON) {
+			return err
+		}
+	}
 	wf.store.Data[ConfigMapKeyVars] = varStr
+	delete(wf.store.BinaryData, ConfigMapKeyVars)
 	return nil
 }
 
@@ -195,9 +218,11 @@ func (wf *WorkflowContext) LoadFromConfigMap(_ context.Context, cm corev1.Config
 	if wf.store == nil {
 		wf.store = &cm
 	}
-	data := cm.Data
-
-	wf.vars = cuecontext.New().CompileString(data[ConfigMapKeyVars])
+	vars, err := LoadVars(&cm)
+	if err != nil {
+		return err
+	}
+	wf.vars = vars
 	return nil
 }
 
@@ -0,0 +1,128 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package context
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"fmt"
+	"io"
+
+	"cuelang.org/go/cue"
+
+	"github.com/kubevela/workflow/pkg/cue/model/sets"
+	"github.com/kubevela/workflow/pkg/cue/model/value"
+)
+
+const (
+	// CompressedVarMarker is the field which marks the var as compressed, the value of the field is the algorithm
+	CompressedVarMarker = "$compressed"
+	// CompressionGzip compresses the var with gzip
+	CompressionGzip = "gzip"
+
+	compressedVarData = "data"
+)
+
+var (
+	// OutputCompressionThreshold is the size in bytes above which the step outputs are compressed in the
+	// workflow context, the compression is disabled if it is not positive
+	OutputCompressionThreshold = 0
+)
+
+// CompressVar returns the compressed form of the var if its size exceeds the OutputCompressionThreshold,
+// the compressed var is decompressed transparently by GetVar.
+func CompressVar(v cue.Value) (cue.Value, error) {
+	if OutputCompressionThreshold <= 0 {
+		return v, nil
+	}
+	str, err := sets.ToString(v)
+	if err != nil {
+		return v, err
+	}
+	if len(str) <= OutputCompressionThreshold {
+		return v, nil
+	}
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write([]byte(str)); err != nil {
+		return v, err
+	}
+	if err := w.Close(); err != nil {
+		return v, err
+	}
+	compressed := v.Context().CompileString("{}")
+	compressed = compressed.FillPath(cue.ParsePath(fmt.Sprintf("%q", CompressedVarMarker)), CompressionGzip)
+	compressed = compressed.FillPath(cue.ParsePath(compressedVarData), base64.StdEncoding.EncodeToString(buf.Bytes()))
+	return compressed, compressed.Err()
+}
+
+// isCompressedVar returns whether the var is compressed by CompressVar
+func isCompressedVar(v cue.Value) bool {
+	if v.IncompleteKind() != cue.StructKind {
+		return false
+	}
+	return v.LookupPath(cue.ParsePath(fmt.Sprintf("%q", CompressedVarMarker))).Exists()
+}
+
+// decompressVar returns the origin var of the compressed var
+func decompressVar(v cue.Value) (cue.Value, error) {
+	algorithm, err := v.LookupPath(cue.ParsePath(fmt.Sprintf("%q", CompressedVarMarker))).String()
+	if err != nil {
+		return v, err
+	}
+	if algorithm != CompressionGzip {
+		return v, fmt.Errorf("unknown compression %s", algorithm)
+	}
+	data, err := v.LookupPath(cue.ParsePath(compressedVarData)).String()
+	if err != nil {
+		return v, err
+	}
+	raw, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return v, err
+	}
+	r, err := gzip.NewReader(bytes.NewReader(raw))
+	if err != nil {
+		return v, err
+	}
+	str, err := io.ReadAll(r)
+	if err != nil {
+		return v, err
+	}
+	decompressed := v.Context().CompileString(string(str))
+	return decompressed, decompressed.Err()
+}
+
+// lookupVar looks up the var at the paths, the compressed vars along the paths are decompressed
+func lookupVar(vars cue.Value, paths ...string) (cue.Value, error) {
+	selectors := value.FieldPath(paths...).Selectors()
+	current := vars
+	for i := 0; ; i++ {
+		if isCompressedVar(current) {
+			decompressed, err := decompressVar(current)
+			if err != nil {
+				return cue.Value{}, err
+			}
+			current = decompressed
+		}
+		if i == len(selectors) || !current.Exists() {
+			return current, nil
+		}
+		current = current.LookupPath(cue.MakePath(selectors[i]))
+	}
+}
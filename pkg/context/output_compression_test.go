@@ -0,0 +1,93 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package context
+
+import (
+	"strings"
+	"testing"
+
+	"cuelang.org/go/cue/cuecontext"
+	"github.com/kubevela/pkg/cue/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOutputCompression(t *testing.T) {
+	origin := OutputCompressionThreshold
+	defer func() { OutputCompressionThreshold = origin }()
+	large := strings.Repeat("a", 1024)
+	output := cuecontext.New().CompileString(`{
+	message: "` + large + `"
+	ready:   true
+	"app.name": "web"
+}`)
+
+	testCases := map[string]struct {
+		threshold  int
+		compressed bool
+	}{
+		"compression disabled": {threshold: 0, compressed: false},
+		"below threshold":      {threshold: 4096, compressed: false},
+		"above threshold":      {threshold: 512, compressed: true},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			r := require.New(t)
+			OutputCompressionThreshold = tc.threshold
+			v, err := CompressVar(output)
+			r.NoError(err)
+			r.Equal(tc.compressed, isCompressedVar(v))
+
+			wfCtx := newContextForTest(t)
+			r.NoError(wfCtx.SetVar(v, "step", "output"))
+			r.NoError(wfCtx.writeToStore())
+			store := wfCtx.GetStore().Data[ConfigMapKeyVars]
+			r.Equal(!tc.compressed, strings.Contains(store, large))
+			r.Equal(tc.compressed, strings.Contains(store, CompressedVarMarker))
+
+			// the compressed output survives reloading from the store
+			loaded := &WorkflowContext{}
+			r.NoError(loaded.LoadFromConfigMap(nil, *wfCtx.GetStore()))
+			got, err := loaded.GetVar("step", "output")
+			r.NoError(err)
+			expected, err := util.ToString(output)
+			r.NoError(err)
+			str, err := util.ToString(got)
+			r.NoError(err)
+			r.Equal(expected, str)
+
+			msg, err := loaded.GetVar("step", "output", "message")
+			r.NoError(err)
+			s, err := msg.String()
+			r.NoError(err)
+			r.Equal(large, s)
+			name, err := loaded.GetVar(`step.output."app.name"`)
+			r.NoError(err)
+			s, err = name.String()
+			r.NoError(err)
+			r.Equal("web", s)
+			_, err = loaded.GetVar("step", "output", "absent")
+			r.Error(err)
+		})
+	}
+
+	t.Run("unknown compression", func(t *testing.T) {
+		r := require.New(t)
+		vars := cuecontext.New().CompileString(`x: {"$compressed": "zstd", data: ""}`)
+		_, err := lookupVar(vars, "x")
+		r.EqualError(err, "unknown compression zstd")
+	})
+}
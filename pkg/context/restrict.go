@@ -0,0 +1,202 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package context
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"cuelang.org/go/cue"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/kubevela/workflow/pkg/cue/model/value"
+)
+
+// restrictedContext is the view of the workflow context in which only the allowed vars and the memory of
+// the step are visible.
+type restrictedContext struct {
+	origin  Context
+	allowed [][]string
+	stepID  string
+}
+
+// Restrict returns the view of the workflow context in which only the vars at the allowed paths can be
+// read or written, the paths are dot-separated like "app.image". The data of the store is not exposed
+// in the view, the mutable and memory values are scoped to the paths under the given step id, and the
+// view cannot commit the context.
+func Restrict(wfCtx Context, allowed []string, stepID string) Context {
+	r := &restrictedContext{origin: wfCtx, stepID: stepID}
+	for _, path := range allowed {
+		if segments := splitPaths(path); len(segments) > 0 {
+			r.allowed = append(r.allowed, segments)
+		}
+	}
+	return r
+}
+
+// GetVar returns the var if it is allowed, or the struct of the allowed vars under the path.
+func (r *restrictedContext) GetVar(paths ...string) (cue.Value, error) {
+	segments := splitPaths(paths...)
+	if r.covered(segments) {
+		return r.origin.GetVar(segments...)
+	}
+	var v cue.Value
+	found := false
+	for _, allowed := range r.allowed {
+		if !hasPrefix(allowed, segments) {
+			continue
+		}
+		sub, err := r.origin.GetVar(allowed...)
+		if err != nil {
+			continue
+		}
+		if !found {
+			v, found = sub.Context().CompileString("{}"), true
+		}
+		v = v.FillPath(value.FieldPath(allowed[len(segments):]...), sub)
+	}
+	if !found {
+		return cue.Value{}, notAllowedErr(segments)
+	}
+	return v, nil
+}
+
+// SetVar sets the var if it is allowed.
+func (r *restrictedContext) SetVar(v cue.Value, paths ...string) error {
+	segments := splitPaths(paths...)
+	if !r.covered(segments) {
+		return notAllowedErr(segments)
+	}
+	return r.origin.SetVar(v, segments...)
+}
+
+// GetStore returns the store without the data.
+func (r *restrictedContext) GetStore() *corev1.ConfigMap {
+	store := r.origin.GetStore()
+	if store == nil {
+		return nil
+	}
+	return &corev1.ConfigMap{TypeMeta: store.TypeMeta, ObjectMeta: *store.ObjectMeta.DeepCopy()}
+}
+
+// GetMutableValue returns the mutable value of the step, it is empty out of the step.
+func (r *restrictedContext) GetMutableValue(paths ...string) string {
+	if !r.inStep(paths) {
+		return ""
+	}
+	return r.origin.GetMutableValue(paths...)
+}
+
+// SetMutableValue sets the mutable value of the step, it is ignored out of the step.
+func (r *restrictedContext) SetMutableValue(data string, paths ...string) {
+	if r.inStep(paths) {
+		r.origin.SetMutableValue(data, paths...)
+	}
+}
+
+// DeleteMutableValue deletes the mutable value of the step, it is ignored out of the step.
+func (r *restrictedContext) DeleteMutableValue(paths ...string) {
+	if r.inStep(paths) {
+		r.origin.DeleteMutableValue(paths...)
+	}
+}
+
+// IncreaseCountValueInMemory increases the count of the step, it is always 0 out of the step.
+func (r *restrictedContext) IncreaseCountValueInMemory(paths ...string) int {
+	if !r.inStep(paths) {
+		return 0
+	}
+	return r.origin.IncreaseCountValueInMemory(paths...)
+}
+
+// SetValueInMemory sets the memory value of the step, it is ignored out of the step.
+func (r *restrictedContext) SetValueInMemory(data interface{}, paths ...string) {
+	if r.inStep(paths) {
+		r.origin.SetValueInMemory(data, paths...)
+	}
+}
+
+// GetValueInMemory returns the memory value of the step, it is absent out of the step.
+func (r *restrictedContext) GetValueInMemory(paths ...string) (interface{}, bool) {
+	if !r.inStep(paths) {
+		return nil, false
+	}
+	return r.origin.GetValueInMemory(paths...)
+}
+
+// DeleteValueInMemory deletes the memory value of the step, it is ignored out of the step.
+func (r *restrictedContext) DeleteValueInMemory(paths ...string) {
+	if r.inStep(paths) {
+		r.origin.DeleteValueInMemory(paths...)
+	}
+}
+
+// Commit is not allowed in the view, the origin context is committed by the workflow.
+func (r *restrictedContext) Commit(_ context.Context) error {
+	return fmt.Errorf("commit is not allowed in the restricted context")
+}
+
+// StoreRef returns the reference of the store.
+func (r *restrictedContext) StoreRef() *corev1.ObjectReference {
+	return r.origin.StoreRef()
+}
+
+// inStep checks if the key of the paths is under the step id, nothing is in the step if the step id is unknown.
+func (r *restrictedContext) inStep(paths []string) bool {
+	return r.stepID != "" && strings.HasPrefix(strings.Join(paths, "."), r.stepID+".")
+}
+
+func (r *restrictedContext) covered(segments []string) bool {
+	for _, allowed := range r.allowed {
+		if hasPrefix(segments, allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+func notAllowedErr(segments []string) error {
+	if len(segments) == 0 {
+		return fmt.Errorf("the root vars are not allowed")
+	}
+	return fmt.Errorf("var %s is not allowed", strings.Join(segments, "."))
+}
+
+func hasPrefix(segments, prefix []string) bool {
+	if len(prefix) > len(segments) {
+		return false
+	}
+	for i := range prefix {
+		if segments[i] != prefix[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func splitPaths(paths ...string) []string {
+	var segments []string
+	for _, path := range paths {
+		for _, segment := range strings.Split(path, ".") {
+			if segment != "" {
+				segments = append(segments, segment)
+			}
+		}
+	}
+	return segments
+}
@@ -0,0 +1,213 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package context
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// RedactedValue replaces the sensitive values in the snapshot. It is a constant marker, so that neither the
+// value nor a hash of it, which can be guessed for the short secrets, is leaked.
+const RedactedValue = "<redacted>"
+
+// DefaultSnapshotRedactKeys are the keys whose values are redacted in the snapshot by default,
+// the keys are matched case-insensitively by substring.
+var DefaultSnapshotRedactKeys = []string{"password", "secret", "token", "credential", "privatekey"}
+
+// Snapshot is a canonical snapshot of the workflow context which is suitable for diffing between runs.
+type Snapshot struct {
+	Vars    map[string]interface{} `json:"vars"`
+	Mutable map[string]interface{} `json:"mutable,omitempty"`
+}
+
+// SnapshotChangeType is the type of the change between two snapshots.
+type SnapshotChangeType string
+
+const (
+	// SnapshotChangeAdded means the field only exists in the new snapshot
+	SnapshotChangeAdded SnapshotChangeType = "added"
+	// SnapshotChangeRemoved means the field only exists in the old snapshot
+	SnapshotChangeRemoved SnapshotChangeType = "removed"
+	// SnapshotChangeModified means the value of the field is changed
+	SnapshotChangeModified SnapshotChangeType = "modified"
+)
+
+// SnapshotChange is a field-level change between two snapshots.
+type SnapshotChange struct {
+	Path string             `json:"path"`
+	Type SnapshotChangeType `json:"type"`
+	Old  interface{}        `json:"old,omitempty"`
+	New  interface{}        `json:"new,omitempty"`
+}
+
+// NewSnapshot produces the snapshot of the vars and the mutable values of the workflow context. The values
+// of the keys matching the redact keys are redacted, DefaultSnapshotRedactKeys is used if no keys are given.
+func NewSnapshot(wfCtx Context, redactKeys ...string) (*Snapshot, error) {
+	if len(redactKeys) == 0 {
+		redactKeys = DefaultSnapshotRedactKeys
+	}
+	snapshot := &Snapshot{Vars: map[string]interface{}{}}
+	if v, err := wfCtx.GetVar(); err == nil {
+		b, err := v.MarshalJSON()
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal the vars of the context: %w", err)
+		}
+		if err := json.Unmarshal(b, &snapshot.Vars); err != nil {
+			return nil, fmt.Errorf("failed to decode the vars of the context: %w", err)
+		}
+	}
+	if store := wfCtx.GetStore(); store != nil {
+		for k, v := range store.Data {
+			if k == ConfigMapKeyVars {
+				continue
+			}
+			if snapshot.Mutable == nil {
+				snapshot.Mutable = map[string]interface{}{}
+			}
+			// the mutable values are strings, decode the json ones to diff the fields
+			var decoded interface{}
+			if err := json.Unmarshal([]byte(v), &decoded); err == nil && decoded != nil {
+				if _, ok := decoded.(map[string]interface{}); ok {
+					snapshot.Mutable[k] = decoded
+					continue
+				}
+			}
+			snapshot.Mutable[k] = v
+		}
+	}
+	snapshot.Vars = redact(snapshot.Vars, redactKeys).(map[string]interface{})
+	if snapshot.Mutable != nil {
+		snapshot.Mutable = redact(snapshot.Mutable, redactKeys).(map[string]interface{})
+	}
+	return snapshot, nil
+}
+
+// Marshal encodes the snapshot into the canonical json with sorted keys.
+func (s *Snapshot) Marshal() ([]byte, error) {
+	buf := &bytes.Buffer{}
+	encoder := json.NewEncoder(buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(s); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func redact(v interface{}, redactKeys []string) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for k, item := range val {
+			if shouldRedact(k, redactKeys) {
+				val[k] = RedactedValue
+				continue
+			}
+			val[k] = redact(item, redactKeys)
+		}
+		return val
+	case []interface{}:
+		for i, item := range val {
+			val[i] = redact(item, redactKeys)
+		}
+		return val
+	default:
+		return v
+	}
+}
+
+func shouldRedact(key string, redactKeys []string) bool {
+	lower := strings.ToLower(key)
+	for _, k := range redactKeys {
+		if strings.Contains(lower, strings.ToLower(k)) {
+			return true
+		}
+	}
+	return false
+}
+
+// DiffSnapshots returns the field-level changes from the base snapshot to the target one, sorted by the paths.
+func DiffSnapshots(base, target *Snapshot) []SnapshotChange {
+	oldFields, newFields := map[string]interface{}{}, map[string]interface{}{}
+	flatten(snapshotFields(base), "", oldFields)
+	flatten(snapshotFields(target), "", newFields)
+	var changes []SnapshotChange
+	for path, o := range oldFields {
+		n, ok := newFields[path]
+		switch {
+		case !ok:
+			changes = append(changes, SnapshotChange{Path: path, Type: SnapshotChangeRemoved, Old: o})
+		case !reflect.DeepEqual(o, n):
+			changes = append(changes, SnapshotChange{Path: path, Type: SnapshotChangeModified, Old: o, New: n})
+		}
+	}
+	for path, n := range newFields {
+		if _, ok := oldFields[path]; !ok {
+			changes = append(changes, SnapshotChange{Path: path, Type: SnapshotChangeAdded, New: n})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Path < changes[j].Path
+	})
+	return changes
+}
+
+func snapshotFields(s *Snapshot) map[string]interface{} {
+	fields := map[string]interface{}{}
+	if s == nil {
+		return fields
+	}
+	if len(s.Vars) > 0 {
+		fields["vars"] = s.Vars
+	}
+	if len(s.Mutable) > 0 {
+		fields["mutable"] = s.Mutable
+	}
+	return fields
+}
+
+// flatten flattens the value into the leaf fields, the empty maps and lists are kept as leaves.
+func flatten(v interface{}, path string, fields map[string]interface{}) {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		if len(val) == 0 && path != "" {
+			fields[path] = val
+			return
+		}
+		for k, item := range val {
+			p := k
+			if path != "" {
+				p = path + "." + k
+			}
+			flatten(item, p, fields)
+		}
+	case []interface{}:
+		if len(val) == 0 {
+			fields[path] = val
+			return
+		}
+		for i, item := range val {
+			flatten(item, fmt.Sprintf("%s[%d]", path, i), fields)
+		}
+	default:
+		fields[path] = v
+	}
+}
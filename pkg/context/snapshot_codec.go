@@ -0,0 +1,196 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package context
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/cuecontext"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// SnapshotCodecJSON is the default codec which encodes the snapshot into the canonical json
+	SnapshotCodecJSON = "json"
+	// SnapshotCodecGzipJSON is the codec which compresses the canonical json with gzip
+	SnapshotCodecGzipJSON = "gzip+json"
+
+	// snapshotCodecHeader declares the codec of the encoded snapshot, it is omitted for the json codec so that
+	// the snapshots encoded before the codecs are introduced can still be decoded
+	snapshotCodecHeader = "#codec="
+)
+
+// SnapshotCodec encodes and decodes the snapshots of the workflow context.
+type SnapshotCodec interface {
+	Encode(s *Snapshot) ([]byte, error)
+	Decode(data []byte) (*Snapshot, error)
+}
+
+var snapshotCodecs sync.Map
+
+func init() {
+	RegisterSnapshotCodec(SnapshotCodecJSON, jsonSnapshotCodec{})
+	RegisterSnapshotCodec(SnapshotCodecGzipJSON, gzipJSONSnapshotCodec{})
+}
+
+// RegisterSnapshotCodec registers the snapshot codec with the name.
+func RegisterSnapshotCodec(name string, codec SnapshotCodec) {
+	snapshotCodecs.Store(name, codec)
+}
+
+// ValidateSnapshotCodec returns the error if the codec is not registered.
+func ValidateSnapshotCodec(name string) error {
+	_, err := getSnapshotCodec(name)
+	return err
+}
+
+func getSnapshotCodec(name string) (SnapshotCodec, error) {
+	codec, ok := snapshotCodecs.Load(name)
+	if !ok {
+		return nil, fmt.Errorf("unknown snapshot codec %s", name)
+	}
+	return codec.(SnapshotCodec), nil
+}
+
+// EncodeSnapshot encodes the snapshot with the codec, the encoded data declares the codec so that
+// DecodeSnapshot picks the right one. The json codec is used if the codec is empty.
+func EncodeSnapshot(s *Snapshot, codecName string) ([]byte, error) {
+	if codecName == "" {
+		codecName = SnapshotCodecJSON
+	}
+	codec, err := getSnapshotCodec(codecName)
+	if err != nil {
+		return nil, err
+	}
+	data, err := codec.Encode(s)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode the snapshot with codec %s: %w", codecName, err)
+	}
+	if codecName == SnapshotCodecJSON {
+		return data, nil
+	}
+	return append([]byte(snapshotCodecHeader+codecName+"\n"), data...), nil
+}
+
+// DecodeSnapshot decodes the snapshot with the codec declared in the data.
+func DecodeSnapshot(data []byte) (*Snapshot, error) {
+	codecName := SnapshotCodecJSON
+	if bytes.HasPrefix(data, []byte(snapshotCodecHeader)) {
+		header, payload, found := bytes.Cut(data[len(snapshotCodecHeader):], []byte("\n"))
+		if !found {
+			return nil, fmt.Errorf("invalid snapshot codec header")
+		}
+		codecName, data = string(header), payload
+	}
+	codec, err := getSnapshotCodec(codecName)
+	if err != nil {
+		return nil, err
+	}
+	s, err := codec.Decode(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode the snapshot with codec %s: %w", codecName, err)
+	}
+	return s, nil
+}
+
+var errVarsNotJSON = errors.New("the vars can not be converted to json")
+
+// encodeVars encodes the vars of the workflow context as the snapshot with the codec, the vars are not redacted
+// since the snapshot is persisted to restore the context.
+func encodeVars(vars cue.Value, codecName string) ([]byte, error) {
+	b, err := vars.MarshalJSON()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", errVarsNotJSON, err.Error())
+	}
+	s := &Snapshot{Vars: map[string]interface{}{}}
+	if err := json.Unmarshal(b, &s.Vars); err != nil {
+		return nil, fmt.Errorf("%w: %s", errVarsNotJSON, err.Error())
+	}
+	return EncodeSnapshot(s, codecName)
+}
+
+// LoadVars loads the vars of the workflow context from the store, the vars are either persisted as the cue
+// string in the data or as the encoded snapshot in the binary data.
+func LoadVars(store *corev1.ConfigMap) (cue.Value, error) {
+	data, ok := store.BinaryData[ConfigMapKeyVars]
+	if !ok {
+		return cuecontext.New().CompileString(store.Data[ConfigMapKeyVars]), nil
+	}
+	s, err := DecodeSnapshot(data)
+	if err != nil {
+		return cue.Value{}, fmt.Errorf("failed to load the vars of the context: %w", err)
+	}
+	b, err := json.Marshal(s.Vars)
+	if err != nil {
+		return cue.Value{}, fmt.Errorf("failed to load the vars of the context: %w", err)
+	}
+	return cuecontext.New().CompileBytes(b), nil
+}
+
+type jsonSnapshotCodec struct{}
+
+func (jsonSnapshotCodec) Encode(s *Snapshot) ([]byte, error) {
+	return s.Marshal()
+}
+
+func (jsonSnapshotCodec) Decode(data []byte) (*Snapshot, error) {
+	s := &Snapshot{}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+type gzipJSONSnapshotCodec struct{}
+
+func (gzipJSONSnapshotCodec) Encode(s *Snapshot) ([]byte, error) {
+	// the compact json is used since the indents are not readable after compression anyway
+	data, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+	buf := &bytes.Buffer{}
+	w := gzip.NewWriter(buf)
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (gzipJSONSnapshotCodec) Decode(data []byte) (*Snapshot, error) {
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	//nolint:errcheck
+	defer r.Close()
+	decoded, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return jsonSnapshotCodec{}.Decode(decoded)
+}
@@ -0,0 +1,158 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package context
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"cuelang.org/go/cue/cuecontext"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/kubevela/pkg/util/singleton"
+)
+
+func newSnapshotForCodecTest(items int) *Snapshot {
+	vars := map[string]interface{}{}
+	for i := 0; i < items; i++ {
+		vars[fmt.Sprintf("component-%d", i)] = map[string]interface{}{
+			"image":    "registry.example.com/app/component:v1.2.3",
+			"replicas": float64(i % 5),
+			"labels":   map[string]interface{}{"app.oam.dev/name": "app", "app.oam.dev/component": fmt.Sprintf("component-%d", i)},
+			"ports":    []interface{}{float64(80), float64(443)},
+		}
+	}
+	return &Snapshot{Vars: vars, Mutable: map[string]interface{}{"step.state": "running"}}
+}
+
+func TestSnapshotCodecs(t *testing.T) {
+	snapshot := newSnapshotForCodecTest(10)
+	for _, codec := range []string{"", SnapshotCodecJSON, SnapshotCodecGzipJSON} {
+		t.Run("codec "+codec, func(t *testing.T) {
+			r := require.New(t)
+			data, err := EncodeSnapshot(snapshot, codec)
+			r.NoError(err)
+			decoded, err := DecodeSnapshot(data)
+			r.NoError(err)
+			r.Equal(snapshot, decoded)
+			r.Empty(DiffSnapshots(snapshot, decoded))
+		})
+	}
+
+	r := require.New(t)
+	// the snapshots encoded by Marshal are decoded as json
+	legacy, err := snapshot.Marshal()
+	r.NoError(err)
+	decoded, err := DecodeSnapshot(legacy)
+	r.NoError(err)
+	r.Equal(snapshot, decoded)
+
+	compressed, err := EncodeSnapshot(snapshot, SnapshotCodecGzipJSON)
+	r.NoError(err)
+	r.True(strings.HasPrefix(string(compressed), "#codec=gzip+json\n"))
+	r.Less(len(compressed), len(legacy))
+
+	_, err = EncodeSnapshot(snapshot, "cbor")
+	r.ErrorContains(err, "unknown snapshot codec cbor")
+	_, err = DecodeSnapshot([]byte("#codec=cbor\n{}"))
+	r.ErrorContains(err, "unknown snapshot codec cbor")
+	_, err = DecodeSnapshot([]byte("#codec=gzip+json\nnot-gzip"))
+	r.Error(err)
+}
+
+func TestCommitWithSnapshotCodec(t *testing.T) {
+	r := require.New(t)
+	ctx := context.Background()
+	cli := fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).Build()
+	singleton.KubeClient.Set(cli)
+	load := func(name string) *corev1.ConfigMap {
+		cm := &corev1.ConfigMap{}
+		r.NoError(cli.Get(ctx, client.ObjectKey{Namespace: "default", Name: name}, cm))
+		return cm
+	}
+
+	c, err := NewContext(ctx, "default", "codec", nil)
+	r.NoError(err)
+	wfCtx := c.(*WorkflowContext)
+	wfCtx.SetSnapshotCodec(SnapshotCodecGzipJSON)
+	r.NoError(wfCtx.SetVar(cuecontext.New().CompileString(`{image: "nginx", replicas: 2}`), "outputs"))
+	wfCtx.SetMutableValue("running", "step")
+	r.NoError(wfCtx.Commit(ctx))
+	cm := load(wfCtx.StoreRef().Name)
+	r.NotContains(cm.Data, ConfigMapKeyVars)
+	r.Equal("running", cm.Data["step"])
+	r.True(strings.HasPrefix(string(cm.BinaryData[ConfigMapKeyVars]), "#codec=gzip+json\n"))
+
+	// the codec is declared in the store, so the context is loaded without setting the codec
+	loaded, err := LoadContext(ctx, "default", "codec", cm.Name)
+	r.NoError(err)
+	image, err := loaded.GetVar("outputs", "image")
+	r.NoError(err)
+	r.Equal(`"nginx"`, fmt.Sprint(image))
+	replicas, err := loaded.GetVar("outputs", "replicas")
+	r.NoError(err)
+	r.Equal("2", fmt.Sprint(replicas))
+
+	// the vars are persisted as the cue string again without the codec
+	r.NoError(loaded.SetVar(cuecontext.New().CompileString(`"done"`), "phase"))
+	r.NoError(loaded.Commit(ctx))
+	cm = load(cm.Name)
+	r.NotContains(cm.BinaryData, ConfigMapKeyVars)
+	r.Contains(cm.Data[ConfigMapKeyVars], `"nginx"`)
+	r.Contains(cm.Data[ConfigMapKeyVars], `phase: "done"`)
+
+	// the incomplete vars are kept as the cue string
+	wfCtx, err = newContext(ctx, "default", "incomplete", nil)
+	r.NoError(err)
+	wfCtx.SetSnapshotCodec(SnapshotCodecJSON)
+	wfCtx.vars = cuecontext.New().CompileString(`{replicas: int}`)
+	r.NoError(wfCtx.Commit(ctx))
+	cm = load(wfCtx.StoreRef().Name)
+	r.NotContains(cm.BinaryData, ConfigMapKeyVars)
+	r.Contains(cm.Data[ConfigMapKeyVars], "replicas: int")
+
+	_, err = LoadVars(&corev1.ConfigMap{BinaryData: map[string][]byte{ConfigMapKeyVars: []byte("#codec=cbor\n{}")}})
+	r.ErrorContains(err, "unknown snapshot codec cbor")
+	r.NoError(ValidateSnapshotCodec(SnapshotCodecGzipJSON))
+	r.Error(ValidateSnapshotCodec("cbor"))
+}
+
+func BenchmarkSnapshotCodecs(b *testing.B) {
+	snapshot := newSnapshotForCodecTest(200)
+	for _, codec := range []string{SnapshotCodecJSON, SnapshotCodecGzipJSON} {
+		b.Run(codec, func(b *testing.B) {
+			var size int
+			for i := 0; i < b.N; i++ {
+				data, err := EncodeSnapshot(snapshot, codec)
+				if err != nil {
+					b.Fatal(err)
+				}
+				if _, err := DecodeSnapshot(data); err != nil {
+					b.Fatal(err)
+				}
+				size = len(data)
+			}
+			b.ReportMetric(float64(size), "bytes/snapshot")
+		})
+	}
+}
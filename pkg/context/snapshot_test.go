@@ -0,0 +1,110 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package context
+
+import (
+	"testing"
+
+	"cuelang.org/go/cue/cuecontext"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshot(t *testing.T) {
+	r := require.New(t)
+	newSnapshot := func(vars string, mutable map[string]string) *Snapshot {
+		wfCtx := newContextForTest(t)
+		r.NoError(wfCtx.SetVar(cuecontext.New().CompileString(vars)))
+		for k, v := range mutable {
+			wfCtx.SetMutableValue(v, k)
+		}
+		snapshot, err := NewSnapshot(wfCtx)
+		r.NoError(err)
+		return snapshot
+	}
+
+	base := newSnapshot(`
+app: {
+	image: "nginx:1.24"
+	replicas: 2
+	ports: [80, 443]
+	auth: {password: "pass-1", user: "admin"}
+}
+removed: true
+`, map[string]string{"step1.state": `{"phase":"running","token":"tok-1"}`, "step1.count": "1"})
+	same := newSnapshot(`
+removed: true
+app: {
+	auth: {user: "admin", password: "pass-1"}
+	ports: [80, 443]
+	replicas: 2
+	image: "nginx:1.24"
+}
+`, map[string]string{"step1.count": "1", "step1.state": `{"token":"tok-1","phase":"running"}`})
+
+	// the snapshot is canonical regardless of the order of the fields
+	b1, err := base.Marshal()
+	r.NoError(err)
+	b2, err := same.Marshal()
+	r.NoError(err)
+	r.Equal(string(b1), string(b2))
+	r.Empty(DiffSnapshots(base, same))
+	// the sensitive values are redacted
+	r.NotContains(string(b1), "pass-1")
+	r.NotContains(string(b1), "tok-1")
+	r.Equal(RedactedValue, base.Vars["app"].(map[string]interface{})["auth"].(map[string]interface{})["password"])
+	r.Equal("1", base.Mutable["step1.count"])
+
+	changed := newSnapshot(`
+app: {
+	image: "nginx:1.25"
+	replicas: 2
+	ports: [80]
+	auth: {password: "pass-2", user: "admin"}
+	env: {}
+}
+`, map[string]string{"step1.state": `{"phase":"succeeded","token":"tok-1"}`, "step2.count": "0"})
+	changes := DiffSnapshots(base, changed)
+	r.Equal([]string{
+		"removed mutable.step1.count",
+		"modified mutable.step1.state.phase",
+		"added mutable.step2.count",
+		"added vars.app.env",
+		"modified vars.app.image",
+		"removed vars.app.ports[1]",
+		"removed vars.removed",
+	}, func() []string {
+		var s []string
+		for _, c := range changes {
+			s = append(s, string(c.Type)+" "+c.Path)
+		}
+		return s
+	}())
+	for _, c := range changes {
+		if c.Path == "vars.app.image" {
+			r.Equal("nginx:1.24", c.Old)
+			r.Equal("nginx:1.25", c.New)
+		}
+	}
+
+	// the custom redact keys
+	wfCtx := newContextForTest(t)
+	r.NoError(wfCtx.SetVar(cuecontext.New().CompileString(`{password: "p", endpoint: "https://example.com"}`)))
+	snapshot, err := NewSnapshot(wfCtx, "endpoint")
+	r.NoError(err)
+	r.Equal("p", snapshot.Vars["password"])
+	r.Equal(RedactedValue, snapshot.Vars["endpoint"])
+}
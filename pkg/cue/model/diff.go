@@ -0,0 +1,115 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+// FieldDiff is a changed concrete leaf field between two instances, Old is nil for an added field and New is
+// nil for a removed field
+type FieldDiff struct {
+	Path string
+	Old  interface{}
+	New  interface{}
+}
+
+// Diff returns the added, removed and changed leaf fields from the instance to the other one sorted by the
+// paths, both instances must be concrete. The paths are formatted as cue paths, e.g. spec.containers[0].image
+func (inst *instance) Diff(other Instance) ([]FieldDiff, error) {
+	oldFields, err := leafFields(inst)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to diff the instance")
+	}
+	newFields, err := leafFields(other)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to diff the other instance")
+	}
+	var diffs []FieldDiff
+	for path, old := range oldFields {
+		n, ok := newFields[path]
+		switch {
+		case !ok:
+			diffs = append(diffs, FieldDiff{Path: path, Old: old})
+		case !reflect.DeepEqual(old, n):
+			diffs = append(diffs, FieldDiff{Path: path, Old: old, New: n})
+		}
+	}
+	for path, n := range newFields {
+		if _, ok := oldFields[path]; !ok {
+			diffs = append(diffs, FieldDiff{Path: path, New: n})
+		}
+	}
+	sort.Slice(diffs, func(i, j int) bool { return diffs[i].Path < diffs[j].Path })
+	return diffs, nil
+}
+
+func leafFields(inst Instance) (map[string]interface{}, error) {
+	b, err := inst.Compile()
+	if err != nil {
+		return nil, err
+	}
+	var v interface{}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return nil, err
+	}
+	fields := map[string]interface{}{}
+	flattenFields("", v, fields)
+	return fields, nil
+}
+
+// flattenFields collects the leaf fields, the empty structs and lists are leaves as well
+func flattenFields(path string, v interface{}, fields map[string]interface{}) {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		if len(val) == 0 && path != "" {
+			fields[path] = val
+		}
+		for k, item := range val {
+			p := fieldLabel(k)
+			if path != "" {
+				p = path + "." + p
+			}
+			flattenFields(p, item, fields)
+		}
+	case []interface{}:
+		if len(val) == 0 {
+			fields[path] = val
+		}
+		for i, item := range val {
+			flattenFields(fmt.Sprintf("%s[%d]", path, i), item, fields)
+		}
+	default:
+		fields[path] = val
+	}
+}
+
+var identifierRegexp = regexp.MustCompile(`^[a-zA-Z_$][a-zA-Z0-9_$]*$`)
+
+func fieldLabel(k string) string {
+	if identifierRegexp.MatchString(k) {
+		return k
+	}
+	return strconv.Quote(k)
+}
@@ -0,0 +1,83 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"testing"
+
+	"cuelang.org/go/cue/cuecontext"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiff(t *testing.T) {
+	r := require.New(t)
+	newInstance := func(s string) Instance {
+		ins, err := NewOther(cuecontext.New().CompileString(s))
+		r.NoError(err)
+		return ins
+	}
+	applied := newInstance(`
+kind: "Deployment"
+metadata: labels: {app: "web", "app.oam.dev/name": "web"}
+spec: {
+	replicas: 1
+	paused:   false
+	containers: [{name: "main", image: "nginx:1.0"}, {name: "sidecar", image: "envoy"}]
+	volumes: []
+}
+`)
+	rendered := newInstance(`
+kind: "Deployment"
+metadata: labels: {app: "web", "app.oam.dev/name": "web-v2", tier: "frontend"}
+spec: {
+	replicas: 3
+	containers: [{name: "main", image: "nginx:1.1"}]
+	volumes: [{name: "data"}]
+	strategy: {}
+}
+`)
+	diffs, err := applied.Diff(rendered)
+	r.NoError(err)
+	r.Equal([]FieldDiff{
+		{Path: `metadata.labels."app.oam.dev/name"`, Old: "web", New: "web-v2"},
+		{Path: "metadata.labels.tier", New: "frontend"},
+		{Path: "spec.containers[0].image", Old: "nginx:1.0", New: "nginx:1.1"},
+		{Path: "spec.containers[1].image", Old: "envoy"},
+		{Path: "spec.containers[1].name", Old: "sidecar"},
+		{Path: "spec.paused", Old: false},
+		{Path: "spec.replicas", Old: float64(1), New: float64(3)},
+		{Path: "spec.strategy", New: map[string]interface{}{}},
+		{Path: "spec.volumes", Old: []interface{}{}},
+		{Path: "spec.volumes[0].name", New: "data"},
+	}, diffs)
+
+	diffs, err = applied.Diff(newInstance(`
+spec: {
+	volumes: []
+	containers: [{image: "nginx:1.0", name: "main"}, {name: "sidecar", image: "envoy"}]
+	paused:   false
+	replicas: 1
+}
+metadata: labels: {"app.oam.dev/name": "web", app: "web"}
+kind: "Deployment"
+`))
+	r.NoError(err)
+	r.Empty(diffs)
+
+	_, err = applied.Diff(newInstance(`replicas: int`))
+	r.Error(err)
+}
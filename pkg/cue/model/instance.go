@@ -17,12 +17,17 @@ limitations under the License.
 package model
 
 import (
+	"strings"
+
 	"cuelang.org/go/cue"
+	cueerrors "cuelang.org/go/cue/errors"
 	"github.com/pkg/errors"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/klog/v2"
+	"sigs.k8s.io/yaml"
 
 	"github.com/kubevela/workflow/pkg/cue/model/sets"
+	"github.com/kubevela/workflow/pkg/cue/model/value"
 )
 
 // Instance defines Model Interface
@@ -33,6 +38,13 @@ type Instance interface {
 	IsBase() bool
 	Unify(other cue.Value, options ...sets.UnifyOption) error
 	Compile() ([]byte, error)
+	LookupByPath(path string) (Instance, error)
+	Merge(other Instance) (Instance, error)
+	Diff(other Instance) ([]FieldDiff, error)
+	ToYAML() ([]byte, error)
+	Validate(schema string) error
+	Elements() ([]Instance, error)
+	SetByPath(path string, v interface{}) (Instance, error)
 }
 
 type instance struct {
@@ -65,6 +77,166 @@ func (inst *instance) Compile() ([]byte, error) {
 	return inst.v.MarshalJSON()
 }
 
+// LookupByPath return the sub-instance at the cue path such as spec.replicas or spec.containers[0],
+// the sub-instance must exist and be concrete
+func (inst *instance) LookupByPath(path string) (Instance, error) {
+	v := inst.v.LookupPath(value.FieldPath(path))
+	if !v.Exists() {
+		return nil, errors.Errorf("path %s not found", path)
+	}
+	if err := v.Validate(cue.Concrete(true)); err != nil {
+		return nil, errors.Wrapf(err, "path %s is not concrete", path)
+	}
+	return &instance{v: v}, nil
+}
+
+// Merge unifies the instance with the other one and returns the merged instance, an error is returned if
+// the fields are conflicting. The instances themselves are not changed.
+func (inst *instance) Merge(other Instance) (Instance, error) {
+	ov := other.Value()
+	if ov.Context() != inst.v.Context() {
+		// the values must be built by the same runtime to be unified
+		s, err := other.String()
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to encode the instance to merge")
+		}
+		ov = inst.v.Context().CompileString(s)
+		if err := ov.Err(); err != nil {
+			return nil, errors.Wrap(err, "failed to compile the instance to merge")
+		}
+	}
+	merged := inst.v.Unify(ov)
+	if err := merged.Validate(); err != nil {
+		return nil, errors.Wrap(err, "failed to merge the instances")
+	}
+	return &instance{v: merged, base: inst.base}, nil
+}
+
+// ToYAML return the instance's yaml format with the keys sorted, the instance must be concrete
+func (inst *instance) ToYAML() ([]byte, error) {
+	if paths := unresolvedPaths(inst.v); len(paths) > 0 {
+		return nil, errors.Errorf("instance is not concrete, unresolved paths: %s", strings.Join(paths, ", "))
+	}
+	b, err := inst.Compile()
+	if err != nil {
+		return nil, err
+	}
+	return yaml.JSONToYAML(b)
+}
+
+func unresolvedPaths(v cue.Value) []string {
+	var paths []string
+	if d, ok := v.Default(); ok {
+		v = d
+	}
+	switch v.IncompleteKind() {
+	case cue.StructKind:
+		iter, err := v.Fields()
+		if err != nil {
+			return []string{v.Path().String()}
+		}
+		for iter.Next() {
+			paths = append(paths, unresolvedPaths(iter.Value())...)
+		}
+	case cue.ListKind:
+		iter, err := v.List()
+		if err != nil {
+			return []string{v.Path().String()}
+		}
+		for iter.Next() {
+			paths = append(paths, unresolvedPaths(iter.Value())...)
+		}
+	default:
+		if !v.IsConcrete() {
+			paths = append(paths, v.Path().String())
+		}
+	}
+	return paths
+}
+
+// Validate validates the instance against the cue schema, all the violations are returned with the paths
+// and the failed constraints
+func (inst *instance) Validate(schema string) error {
+	s := inst.v.Context().CompileString(schema)
+	if err := s.Err(); err != nil {
+		return errors.Wrap(err, "invalid schema")
+	}
+	unified := s.Unify(inst.v)
+	err := unified.Validate(cue.Concrete(true), cue.Final(), cue.All())
+	if err == nil {
+		return nil
+	}
+	var violations []string
+	seen := map[string]bool{}
+	for _, e := range cueerrors.Errors(err) {
+		violations = append(violations, e.Error())
+		seen[e.Error()] = true
+	}
+	// the missing required fields are not reported by cue along with the other errors
+	for _, violation := range missingRequiredFields(unified) {
+		if !seen[violation] {
+			violations = append(violations, violation)
+		}
+	}
+	return errors.Errorf("instance does not match the schema: %s", strings.Join(violations, "; "))
+}
+
+func missingRequiredFields(v cue.Value) []string {
+	var violations []string
+	iter, err := v.Fields(cue.Optional(true))
+	if err != nil {
+		return nil
+	}
+	for iter.Next() {
+		switch iter.Selector().ConstraintType() {
+		case cue.RequiredConstraint:
+			violations = append(violations, iter.Value().Path().String()+": field is required but not present")
+		case cue.OptionalConstraint:
+		default:
+			violations = append(violations, missingRequiredFields(iter.Value())...)
+		}
+	}
+	return violations
+}
+
+// Elements return the elements of the list instance as non-base instances, an error is returned if the
+// instance is not a list
+func (inst *instance) Elements() ([]Instance, error) {
+	v := inst.v
+	if d, ok := v.Default(); ok {
+		v = d
+	}
+	if v.IncompleteKind() != cue.ListKind {
+		return nil, errors.Errorf("instance of kind %s is not a list", v.IncompleteKind())
+	}
+	iter, err := v.List()
+	if err != nil {
+		return nil, err
+	}
+	var elements []Instance
+	for iter.Next() {
+		elements = append(elements, &instance{v: iter.Value()})
+	}
+	return elements, nil
+}
+
+// SetByPath return a new instance with the field at the path set to v, the intermediate structs are created
+// and the existing value is overwritten. The instance itself is not changed.
+func (inst *instance) SetByPath(path string, v interface{}) (Instance, error) {
+	val := inst.v.Context().Encode(v)
+	if err := val.Err(); err != nil {
+		return nil, errors.Wrapf(err, "failed to encode the value of path %s", path)
+	}
+	set, err := value.SetValueByScript(inst.v, val, path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to set path %s", path)
+	}
+	if err := set.Err(); err != nil {
+		return nil, errors.Wrapf(err, "failed to set path %s", path)
+	}
+	return &instance{v: set, base: inst.base}, nil
+}
+
 // Unstructured convert cue values to unstructured.Unstructured
 // TODO(wonderflow): will it be better if we try to decode it to concrete object(such as K8s Deployment) by using runtime.Schema?
 func (inst *instance) Unstructured() (*unstructured.Unstructured, error) {
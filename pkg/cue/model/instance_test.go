@@ -18,6 +18,7 @@ package model
 
 import (
 	"fmt"
+	"os"
 	"testing"
 
 	"cuelang.org/go/cue/cuecontext"
@@ -187,3 +188,268 @@ spec: {
 	r.Nil(o)
 	r.NotNil(err)
 }
+
+func TestLookupByPath(t *testing.T) {
+	r := require.New(t)
+	base, err := NewBase(cuecontext.New().CompileString(`
+spec: {
+	replicas: 3
+	selector: matchLabels: app: "web"
+	containers: [{name: "main", image: "nginx"}, {name: "sidecar", image: "envoy"}]
+	template: labels: "app.oam.dev/name": "web"
+	pending: int
+}
+`))
+	r.NoError(err)
+
+	replicas, err := base.LookupByPath("spec.replicas")
+	r.NoError(err)
+	r.False(replicas.IsBase())
+	s, err := replicas.String()
+	r.NoError(err)
+	r.Equal("3\n", s)
+
+	labels, err := base.LookupByPath("spec.selector.matchLabels")
+	r.NoError(err)
+	b, err := labels.Compile()
+	r.NoError(err)
+	r.Equal(`{"app":"web"}`, string(b))
+
+	image, err := base.LookupByPath("spec.containers[1].image")
+	r.NoError(err)
+	b, err = image.Compile()
+	r.NoError(err)
+	r.Equal(`"envoy"`, string(b))
+
+	label, err := base.LookupByPath(`spec.template.labels."app.oam.dev/name"`)
+	r.NoError(err)
+	b, err = label.Compile()
+	r.NoError(err)
+	r.Equal(`"web"`, string(b))
+
+	for path, msg := range map[string]string{
+		"spec.absent":             "path spec.absent not found",
+		"spec.containers[2].name": "not found",
+		"spec.pending":            "path spec.pending is not concrete",
+	} {
+		_, err = base.LookupByPath(path)
+		r.Error(err, path)
+		r.Contains(err.Error(), msg, path)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	r := require.New(t)
+	cueCtx := cuecontext.New()
+	base, err := NewBase(cueCtx.CompileString(`
+kind: "Deployment"
+spec: {
+	replicas: *1 | int
+	template: metadata: labels: app: "web"
+}
+`))
+	r.NoError(err)
+	patch, err := NewOther(cueCtx.CompileString(`
+spec: template: metadata: labels: version: "v1"
+`))
+	r.NoError(err)
+	merged, err := base.Merge(patch)
+	r.NoError(err)
+	r.True(merged.IsBase())
+	b, err := merged.Compile()
+	r.NoError(err)
+	r.Equal(`{"kind":"Deployment","spec":{"replicas":1,"template":{"metadata":{"labels":{"app":"web","version":"v1"}}}}}`, string(b))
+	b, err = base.Compile()
+	r.NoError(err)
+	r.Equal(`{"kind":"Deployment","spec":{"replicas":1,"template":{"metadata":{"labels":{"app":"web"}}}}}`, string(b))
+
+	// the default is overridden and the instance from another runtime is merged
+	override, err := NewOther(cuecontext.New().CompileString(`spec: replicas: 3`))
+	r.NoError(err)
+	merged, err = base.Merge(override)
+	r.NoError(err)
+	sub, err := merged.LookupByPath("spec.replicas")
+	r.NoError(err)
+	replicas, err := sub.Compile()
+	r.NoError(err)
+	r.Equal("3", string(replicas))
+
+	conflict, err := NewOther(cueCtx.CompileString(`kind: "StatefulSet"`))
+	r.NoError(err)
+	_, err = base.Merge(conflict)
+	r.Error(err)
+	r.Contains(err.Error(), "failed to merge the instances")
+	r.Contains(err.Error(), "conflicting values")
+}
+
+func TestToYAML(t *testing.T) {
+	r := require.New(t)
+	base, err := NewBase(cuecontext.New().CompileString(`
+kind:       "Deployment"
+apiVersion: "apps/v1"
+metadata: {
+	name: "web"
+	labels: "app.oam.dev/name": "web"
+}
+spec: {
+	replicas: *2 | int
+	selector: matchLabels: app: "web"
+	template: {
+		metadata: labels: app: "web"
+		spec: containers: [{
+			name:  "main"
+			image: "nginx:1.25"
+			ports: [{containerPort: 80, protocol: "TCP"}]
+			args: ["--port=80", "--verbose"]
+			env: [{name: "MULTI_LINE", value: "line1\nline2"}]
+		}]
+	}
+}
+`))
+	r.NoError(err)
+	b, err := base.ToYAML()
+	r.NoError(err)
+	golden, err := os.ReadFile("testdata/deployment.yaml")
+	r.NoError(err)
+	r.Equal(string(golden), string(b))
+
+	incomplete, err := NewBase(cuecontext.New().CompileString(`
+metadata: name: string
+spec: {
+	replicas: int
+	containers: [{name: "main", image: string}]
+	selector: {}
+}
+`))
+	r.NoError(err)
+	_, err = incomplete.ToYAML()
+	r.Error(err)
+	r.Equal("instance is not concrete, unresolved paths: metadata.name, spec.replicas, spec.containers[0].image", err.Error())
+}
+
+func TestValidate(t *testing.T) {
+	r := require.New(t)
+	schema := `
+apiVersion: string
+kind:       "Deployment"
+metadata: {
+	name!:   string & =~"^[a-z0-9-]+$"
+	labels?: [string]: string
+}
+spec: replicas: int & >=0 & <=10
+`
+	newBase := func(s string) Instance {
+		ins, err := NewBase(cuecontext.New().CompileString(s))
+		r.NoError(err)
+		return ins
+	}
+	r.NoError(newBase(`
+apiVersion: "apps/v1"
+kind:       "Deployment"
+metadata: {name: "web", labels: app: "web"}
+spec: replicas: 3
+`).Validate(schema))
+
+	err := newBase(`
+apiVersion: "apps/v1"
+kind:       "Deployment"
+metadata: {name: "Web_1", labels: app: 1}
+spec: replicas: "3"
+`).Validate(schema)
+	r.Error(err)
+	for _, msg := range []string{"metadata.name: invalid value \"Web_1\"", "metadata.labels.app: conflicting values", "spec.replicas: conflicting values"} {
+		r.Contains(err.Error(), msg)
+	}
+
+	err = newBase(`
+apiVersion: "apps/v1"
+kind:       "Deployment"
+spec: replicas: 11
+`).Validate(schema)
+	r.Error(err)
+	r.Contains(err.Error(), "metadata.name: field is required but not present")
+	r.Contains(err.Error(), "spec.replicas: invalid value 11 (out of bound <=10)")
+
+	r.Error(newBase(`kind: "Deployment"`).Validate(`kind: `))
+}
+
+func TestElements(t *testing.T) {
+	r := require.New(t)
+	list, err := NewBase(cuecontext.New().CompileString(`[{
+	apiVersion: "v1"
+	kind:       "ConfigMap"
+	metadata: name: "config"
+}, {
+	apiVersion: "apps/v1"
+	kind:       "Deployment"
+	metadata: name: "web"
+}]`))
+	r.NoError(err)
+	elements, err := list.Elements()
+	r.NoError(err)
+	r.Len(elements, 2)
+	var kinds []string
+	for _, element := range elements {
+		r.False(element.IsBase())
+		obj, err := element.Unstructured()
+		r.NoError(err)
+		kinds = append(kinds, obj.GetKind())
+	}
+	r.Equal([]string{"ConfigMap", "Deployment"}, kinds)
+
+	empty, err := NewBase(cuecontext.New().CompileString(`[]`))
+	r.NoError(err)
+	elements, err = empty.Elements()
+	r.NoError(err)
+	r.Empty(elements)
+
+	obj, err := NewBase(cuecontext.New().CompileString(`kind: "Deployment"`))
+	r.NoError(err)
+	_, err = obj.Elements()
+	r.Error(err)
+	r.Equal("instance of kind struct is not a list", err.Error())
+}
+
+func TestSetByPath(t *testing.T) {
+	r := require.New(t)
+	base, err := NewBase(cuecontext.New().CompileString(`
+kind: "Deployment"
+metadata: name: "web"
+spec: {
+	replicas: 1
+	containers: [{name: "main", image: "nginx"}]
+}
+`))
+	r.NoError(err)
+	compile := func(ins Instance) string {
+		b, err := ins.Compile()
+		r.NoError(err)
+		return string(b)
+	}
+	origin := compile(base)
+
+	set, err := base.SetByPath("metadata.namespace", "prod")
+	r.NoError(err)
+	r.True(set.IsBase())
+	r.Equal(`{"kind":"Deployment","metadata":{"name":"web","namespace":"prod"},"spec":{"replicas":1,"containers":[{"name":"main","image":"nginx"}]}}`, compile(set))
+
+	set, err = set.SetByPath(`metadata.labels."app.oam.dev/name"`, "web")
+	r.NoError(err)
+	set, err = set.SetByPath("spec.replicas", 3)
+	r.NoError(err)
+	set, err = set.SetByPath("spec.containers[0].image", "nginx:1.25")
+	r.NoError(err)
+	set, err = set.SetByPath("spec.strategy", map[string]interface{}{"type": "Recreate"})
+	r.NoError(err)
+	r.Equal(`{"kind":"Deployment","metadata":{"name":"web","namespace":"prod","labels":{"app.oam.dev/name":"web"}},"spec":{"replicas":3,"containers":[{"name":"main","image":"nginx:1.25"}],"strategy":{"type":"Recreate"}}}`, compile(set))
+	r.Equal(origin, compile(base))
+
+	set, err = set.SetByPath(`metadata.labels."app.oam.dev/name"`, "web-v2")
+	r.NoError(err)
+	label, err := set.LookupByPath(`metadata.labels."app.oam.dev/name"`)
+	r.NoError(err)
+	r.Equal(`"web-v2"`, compile(label))
+
+	_, err = base.SetByPath("kind.name", "value")
+	r.Error(err)
+}
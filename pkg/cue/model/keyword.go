@@ -41,8 +41,14 @@ const (
 	ContextStepName = "stepName"
 	// ContextStepGroupName  is the name of the stepGroup
 	ContextStepGroupName = "stepGroupName"
+	// ContextStepAttempt is the current attempt of the step, starting from 1 and increasing after each failed execution
+	ContextStepAttempt = "attempt"
 	// ContextSpanID is name for span id.
 	ContextSpanID = "spanID"
+	// ContextParent is the parent workflow run info of a child run, it is absent for the top-level runs
+	ContextParent = "parent"
 	// OutputSecretName is used to store all secret names which are generated by cloud resource components
 	OutputSecretName = "outputSecretName"
+	// ContextSecretVersions is used to store the versions of the required secrets, which change if the data of the secrets change
+	ContextSecretVersions = "secretVersions"
 )
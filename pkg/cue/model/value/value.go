@@ -74,14 +74,19 @@ func setValue(orig ast.Node, expr ast.Expr, selectors []cue.Selector) error {
 		return setValue(x.Elts[key.Index()], expr, selectors)
 	case *ast.StructLit:
 		if len(x.Elts) == 0 || (key.Type() == cue.StringLabel && len(sets.LookUpAll(x, key.String())) == 0) {
+			// the string of the quoted label is quoted again by ast.NewString
+			label := key.String()
+			if key.Type() == cue.StringLabel {
+				label = key.Unquoted()
+			}
 			if len(selectors) == 0 {
 				x.Elts = append(x.Elts, &ast.Field{
-					Label: ast.NewString(key.String()),
+					Label: ast.NewString(label),
 					Value: expr,
 				})
 			} else {
 				x.Elts = append(x.Elts, &ast.Field{
-					Label: ast.NewString(key.String()),
+					Label: ast.NewString(label),
 					Value: ast.NewStruct(),
 				})
 			}
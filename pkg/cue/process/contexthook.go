@@ -45,3 +45,17 @@ type AuxiliaryHookFn func(Context, []Auxiliary) error
 func (fn AuxiliaryHookFn) Exec(ctx Context, auxs []Auxiliary) error {
 	return fn(ctx, auxs)
 }
+
+// OutputHook defines function to be invoked before returning the assembled
+// base and auxiliaries of a process.Context
+type OutputHook interface {
+	Exec(Context, model.Instance, []Auxiliary) error
+}
+
+// OutputHookFn implements OutputHook interface
+type OutputHookFn func(Context, model.Instance, []Auxiliary) error
+
+// Exec will be invoked before returning 'base' and 'auxs' from ctx.OutputWithError
+func (fn OutputHookFn) Exec(ctx Context, base model.Instance, auxs []Auxiliary) error {
+	return fn(ctx, base, auxs)
+}
@@ -49,6 +49,14 @@ func WithSpanID(id string) StepMetaKV {
 	}
 }
 
+// WithAttempt return the current attempt of the step
+func WithAttempt(attempt int) StepMetaKV {
+	return StepMetaKV{
+		Key:   model.ContextStepAttempt,
+		Value: attempt,
+	}
+}
+
 // NewStepRunTimeMeta create step runtime metadata manager
 func NewStepRunTimeMeta() DataManager {
 	return &StepRunTimeMeta{}
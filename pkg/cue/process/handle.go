@@ -18,12 +18,18 @@ package process
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"reflect"
+	"sort"
 	"strings"
+	"sync"
 	"unicode"
+	"unicode/utf8"
 
+	"cuelang.org/go/cue/cuecontext"
 	"github.com/pkg/errors"
 	"k8s.io/klog/v2"
 
@@ -34,15 +40,26 @@ import (
 type Context interface {
 	SetBase(base model.Instance) error
 	AppendAuxiliaries(auxiliaries ...Auxiliary) error
+	RemoveAuxiliary(name string) bool
+	ReplaceAuxiliary(auxiliary Auxiliary) error
 	Output() (model.Instance, []Auxiliary)
+	OutputWithError() (model.Instance, []Auxiliary, error)
 	BaseContextFile() (string, error)
+	BaseContextFileRedacted() (string, error)
 	BaseContextLabels() map[string]string
+	SetName(name string)
+	SetNamespace(namespace string)
 	SetParameters(params map[string]interface{})
+	GetParameters() map[string]interface{}
 	PushData(key string, data interface{})
+	PushDataByPath(path string, data interface{}) error
 	RemoveData(key string)
 	GetData(key string) interface{}
+	LookupData(key string) (interface{}, bool)
+	GetDataByPath(path string) (interface{}, bool)
 	GetCtx() context.Context
 	SetCtx(context.Context)
+	Clone() Context
 }
 
 // Auxiliary are objects rendered by definition template.
@@ -58,11 +75,20 @@ type Auxiliary struct {
 }
 
 type templateContext struct {
+	// mu guards the base, auxiliaries, data and ctx which may be accessed by the parallel steps
+	mu sync.RWMutex
+
 	base        model.Instance
 	auxiliaries []Auxiliary
 
 	baseHooks      []BaseHook
 	auxiliaryHooks []AuxiliaryHook
+	outputHooks    []OutputHook
+
+	extraLabels map[string]string
+	// secretKeys are the keys of the data inserted by InsertSecrets
+	secretKeys map[string]bool
+	maxSize    int
 
 	customData map[string]interface{}
 	data       map[string]interface{}
@@ -91,6 +117,11 @@ type ContextData struct {
 	Data           map[string]interface{}
 	BaseHooks      []BaseHook
 	AuxiliaryHooks []AuxiliaryHook
+	OutputHooks    []OutputHook
+	// ExtraLabels are merged into BaseContextLabels, the built-in labels take precedence
+	ExtraLabels map[string]string
+	// MaxSize is the limit in bytes of the rendered context, the rendering fails if exceeded, 0 means unlimited
+	MaxSize int
 }
 
 // NewContext create render templateContext
@@ -98,11 +129,18 @@ func NewContext(data ContextData) Context {
 	ctx := &templateContext{
 		ctx:            data.Ctx,
 		customData:     data.CustomData,
-		data:           data.Data,
+		data:           make(map[string]interface{}, len(data.Data)),
 		baseHooks:      data.BaseHooks,
 		auxiliaryHooks: data.AuxiliaryHooks,
+		outputHooks:    data.OutputHooks,
+		extraLabels:    data.ExtraLabels,
+		maxSize:        data.MaxSize,
 		auxiliaries:    []Auxiliary{},
 	}
+	// copy the seeded data so that the map of the caller is not mutated by pushing data
+	for k, v := range data.Data {
+		ctx.data[k] = v
+	}
 	ctx.PushData(model.ContextName, data.Name)
 	ctx.PushData(model.ContextNamespace, data.Namespace)
 	ctx.PushData(model.ContextWorkflowName, data.WorkflowName)
@@ -110,11 +148,75 @@ func NewContext(data ContextData) Context {
 	return ctx
 }
 
+// NewContextWithValidation create render templateContext like NewContext, it returns an error if the name
+// is empty, the name, namespace or workflowName are not valid strings, or the rendered context fails to compile
+func NewContextWithValidation(data ContextData) (Context, error) {
+	if data.Name == "" {
+		return nil, errors.New("invalid context: name is required")
+	}
+	for field, v := range map[string]string{
+		model.ContextName:         data.Name,
+		model.ContextNamespace:    data.Namespace,
+		model.ContextWorkflowName: data.WorkflowName,
+	} {
+		if err := validateContextString(v); err != nil {
+			return nil, errors.Wrapf(err, "invalid context %s %q", field, v)
+		}
+	}
+	ctx := NewContext(data)
+	c, err := ctx.BaseContextFile()
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid context")
+	}
+	if v := cuecontext.New().CompileString(c); v.Err() != nil {
+		return nil, errors.Wrap(v.Err(), "invalid context")
+	}
+	return ctx, nil
+}
+
+// validateContextString checks the string can be rendered as is in the context, the invalid UTF-8 would
+// be replaced during rendering and the control characters are not expected in the names
+func validateContextString(s string) error {
+	if !utf8.ValidString(s) {
+		return errors.New("invalid UTF-8 string")
+	}
+	for _, r := range s {
+		if unicode.IsControl(r) {
+			return errors.Errorf("control character %U is not allowed", r)
+		}
+	}
+	return nil
+}
+
+// SetName sets the name of templateContext, it can be called after SetBase and the rendered context
+// and labels use the new name
+func (ctx *templateContext) SetName(name string) {
+	ctx.PushData(model.ContextName, name)
+}
+
+// SetNamespace sets the namespace of templateContext, it can be called after SetBase and the rendered
+// context uses the new namespace
+func (ctx *templateContext) SetNamespace(namespace string) {
+	ctx.PushData(model.ContextNamespace, namespace)
+}
+
 // SetParameters sets templateContext parameters
 func (ctx *templateContext) SetParameters(params map[string]interface{}) {
 	ctx.PushData(model.ParameterFieldName, params)
 }
 
+// GetParameters returns a shallow copy of the templateContext parameters, it is empty if no parameters are set
+func (ctx *templateContext) GetParameters() map[string]interface{} {
+	ctx.mu.RLock()
+	defer ctx.mu.RUnlock()
+	params, _ := ctx.data[model.ParameterFieldName].(map[string]interface{})
+	copied := make(map[string]interface{}, len(params))
+	for k, v := range params {
+		copied[k] = v
+	}
+	return copied
+}
+
 // SetBase set templateContext base model
 func (ctx *templateContext) SetBase(base model.Instance) error {
 	for _, hook := range ctx.baseHooks {
@@ -122,23 +224,124 @@ func (ctx *templateContext) SetBase(base model.Instance) error {
 			return errors.Wrap(err, "cannot set base into context")
 		}
 	}
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
 	ctx.base = base
 	return nil
 }
 
-// AppendAuxiliaries add Assist model to templateContext
+// AppendAuxiliaries add Assist model to templateContext, the duplicates are rejected before the auxiliary
+// hooks are executed
 func (ctx *templateContext) AppendAuxiliaries(auxiliaries ...Auxiliary) error {
+	ctx.mu.RLock()
+	err := ctx.checkDuplicateAuxiliaries(auxiliaries)
+	ctx.mu.RUnlock()
+	if err != nil {
+		return err
+	}
 	for _, hook := range ctx.auxiliaryHooks {
 		if err := hook.Exec(ctx, auxiliaries); err != nil {
 			return errors.Wrap(err, "cannot append auxiliaries into context")
 		}
 	}
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+	// the hooks run without the lock, so the parallel steps may have appended the same auxiliaries meanwhile
+	if err := ctx.checkDuplicateAuxiliaries(auxiliaries); err != nil {
+		return err
+	}
 	ctx.auxiliaries = append(ctx.auxiliaries, auxiliaries...)
 	return nil
 }
 
+// checkDuplicateAuxiliaries must be called with the lock held
+func (ctx *templateContext) checkDuplicateAuxiliaries(auxiliaries []Auxiliary) error {
+	existing := map[string]bool{}
+	for _, auxiliary := range ctx.auxiliaries {
+		existing[auxiliaryKey(auxiliary)] = true
+	}
+	for _, auxiliary := range auxiliaries {
+		key := auxiliaryKey(auxiliary)
+		if existing[key] {
+			if auxiliary.Name == "" {
+				return errors.Errorf("cannot append auxiliaries into context: duplicate main auxiliary of type %q", auxiliary.Type)
+			}
+			return errors.Errorf("cannot append auxiliaries into context: duplicate auxiliary name %q", auxiliary.Name)
+		}
+		existing[key] = true
+	}
+	return nil
+}
+
+// auxiliaryKey identifies the auxiliary by name, the main auxiliaries without name are identified by type
+func auxiliaryKey(auxiliary Auxiliary) string {
+	if auxiliary.Name == "" {
+		return "type/" + auxiliary.Type
+	}
+	return "name/" + auxiliary.Name
+}
+
+// RemoveAuxiliary removes the auxiliary with the name, it returns false if no auxiliary matches. The main
+// auxiliaries without name cannot be removed by name, they can only be swapped by ReplaceAuxiliary.
+func (ctx *templateContext) RemoveAuxiliary(name string) bool {
+	if name == "" {
+		return false
+	}
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+	auxiliaries := make([]Auxiliary, 0, len(ctx.auxiliaries))
+	for _, existing := range ctx.auxiliaries {
+		if existing.Name != name {
+			auxiliaries = append(auxiliaries, existing)
+		}
+	}
+	removed := len(auxiliaries) != len(ctx.auxiliaries)
+	ctx.auxiliaries = auxiliaries
+	if removed && len(auxiliaries) == 0 {
+		// the outputs rendered before are not overridden by BaseContextFile if there is no auxiliary
+		delete(ctx.data, model.OutputsFieldName)
+	}
+	return removed
+}
+
+// ReplaceAuxiliary replaces the auxiliary with the same name, or the main auxiliary of the same type if the
+// name is empty, in place, the auxiliary hooks are executed on the replacement
+func (ctx *templateContext) ReplaceAuxiliary(auxiliary Auxiliary) error {
+	for _, hook := range ctx.auxiliaryHooks {
+		if err := hook.Exec(ctx, []Auxiliary{auxiliary}); err != nil {
+			return errors.Wrap(err, "cannot replace auxiliary in context")
+		}
+	}
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+	key := auxiliaryKey(auxiliary)
+	for i := range ctx.auxiliaries {
+		if auxiliaryKey(ctx.auxiliaries[i]) == key {
+			ctx.auxiliaries[i] = auxiliary
+			return nil
+		}
+	}
+	if auxiliary.Name == "" {
+		return errors.Errorf("main auxiliary of type %s not found in context", auxiliary.Type)
+	}
+	return errors.Errorf("auxiliary %s not found in context", auxiliary.Name)
+}
+
 // BaseContextFile return cue format string of templateContext
 func (ctx *templateContext) BaseContextFile() (string, error) {
+	return ctx.contextFile(false)
+}
+
+// BaseContextFileRedacted return cue format string of templateContext like BaseContextFile, while the
+// values of the required secrets and the output secret name are replaced, it is used for logging
+func (ctx *templateContext) BaseContextFileRedacted() (string, error) {
+	return ctx.contextFile(true)
+}
+
+func (ctx *templateContext) contextFile(redact bool) (string, error) {
+	// the output and custom data are pushed into data, so the write lock is required
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
 	var buff string
 
 	if ctx.base != nil {
@@ -150,12 +353,21 @@ func (ctx *templateContext) BaseContextFile() (string, error) {
 		if err := json.Unmarshal(base, &b); err != nil {
 			return "", err
 		}
-		ctx.PushData(model.OutputFieldName, b)
+		ctx.pushData(model.OutputFieldName, b)
 	}
 
 	if len(ctx.auxiliaries) > 0 {
+		// the lines are keyed by name and marshalled in order, the auxiliaries are sorted so that the
+		// auxiliary sharing the name is picked regardless of the order of appending
+		auxiliaries := append([]Auxiliary{}, ctx.auxiliaries...)
+		sort.SliceStable(auxiliaries, func(i, j int) bool {
+			if auxiliaries[i].Name != auxiliaries[j].Name {
+				return auxiliaries[i].Name < auxiliaries[j].Name
+			}
+			return auxiliaries[i].Type < auxiliaries[j].Type
+		})
 		auxLines := make(map[string]any)
-		for _, auxiliary := range ctx.auxiliaries {
+		for _, auxiliary := range auxiliaries {
 			aux, err := auxiliary.Ins.Value().MarshalJSON()
 			if err != nil {
 				return "", err
@@ -167,51 +379,170 @@ func (ctx *templateContext) BaseContextFile() (string, error) {
 			auxLines[auxiliary.Name] = a
 		}
 		if len(auxLines) > 0 {
-			ctx.PushData(model.OutputsFieldName, auxLines)
+			ctx.pushData(model.OutputsFieldName, auxLines)
 		}
 	}
 
 	for k, v := range ctx.customData {
-		if exist := ctx.GetData(k); exist != nil && !reflect.DeepEqual(exist, v) {
-			klog.Warningf("Built-in value [%s: %s] in context will be overridden", k, exist)
+		// the built-in value may be an explicit null, so check the presence of the key instead of the value
+		if exist, ok := ctx.data[k]; ok && !reflect.DeepEqual(exist, v) {
+			klog.Warningf("Built-in value [%s: %v] in context will be overridden", k, exist)
 		}
-		ctx.PushData(k, v)
+		ctx.pushData(k, v)
 	}
 
 	if ctx.data != nil {
-		d, err := json.Marshal(ctx.data)
+		data := ctx.data
+		if redact {
+			data = ctx.redactedData()
+		}
+		d, err := json.Marshal(data)
 		if err != nil {
 			return "", err
 		}
 		buff += fmt.Sprintf("\n %s", structMarshal(string(d)))
 	}
 
-	return fmt.Sprintf("context: %s", structMarshal(buff)), nil
+	file := fmt.Sprintf("context: %s", structMarshal(buff))
+	if ctx.maxSize > 0 && len(file) > ctx.maxSize {
+		key, size := ctx.largestData()
+		return "", errors.Errorf("the rendered context of %d bytes exceeds the limit of %d bytes, the largest data is %s of %d bytes", len(file), ctx.maxSize, key, size)
+	}
+	return file, nil
+}
+
+// largestData returns the key and the marshalled size of the largest data. It must be called with the lock held.
+func (ctx *templateContext) largestData() (string, int) {
+	var largest string
+	var largestSize int
+	for k, v := range ctx.data {
+		b, err := json.Marshal(v)
+		if err != nil {
+			continue
+		}
+		if len(b) > largestSize || (len(b) == largestSize && k < largest) {
+			largest, largestSize = k, len(b)
+		}
+	}
+	return largest, largestSize
 }
 
+// BaseContextLabels return the labels of templateContext, the extra labels are included
 func (ctx *templateContext) BaseContextLabels() map[string]string {
-	return map[string]string{
-		model.ContextName: fmt.Sprint(ctx.GetData(model.ContextName)),
+	labels := make(map[string]string, len(ctx.extraLabels)+1)
+	for k, v := range ctx.extraLabels {
+		labels[k] = v
 	}
+	labels[model.ContextName] = fmt.Sprint(ctx.GetData(model.ContextName))
+	return labels
 }
 
 // Output return model and auxiliaries of templateContext
 func (ctx *templateContext) Output() (model.Instance, []Auxiliary) {
+	ctx.mu.RLock()
+	defer ctx.mu.RUnlock()
 	return ctx.base, ctx.auxiliaries
 }
 
+// OutputWithError return model and auxiliaries of templateContext after executing the output hooks,
+// the error of the first failed hook is returned
+func (ctx *templateContext) OutputWithError() (model.Instance, []Auxiliary, error) {
+	base, auxiliaries := ctx.Output()
+	for _, hook := range ctx.outputHooks {
+		if err := hook.Exec(ctx, base, auxiliaries); err != nil {
+			return nil, nil, errors.Wrap(err, "cannot output context")
+		}
+	}
+	return base, auxiliaries, nil
+}
+
 // InsertSecrets will add cloud resource secret stuff to context
 func (ctx *templateContext) InsertSecrets(outputSecretName string, requiredSecrets []RequiredSecrets) {
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
 	if outputSecretName != "" {
-		ctx.PushData(model.OutputSecretName, outputSecretName)
+		ctx.pushData(model.OutputSecretName, outputSecretName)
+	}
+	if len(requiredSecrets) == 0 {
+		return
+	}
+	if ctx.secretKeys == nil {
+		ctx.secretKeys = map[string]bool{}
+	}
+	versions, _ := ctx.data[model.ContextSecretVersions].(map[string]interface{})
+	if versions == nil {
+		versions = map[string]interface{}{}
 	}
 	for _, s := range requiredSecrets {
-		ctx.PushData(s.ContextName, s.Data)
+		ctx.secretKeys[s.ContextName] = true
+		ctx.pushData(s.ContextName, s.Data)
+		versions[s.ContextName] = secretVersion(s.Data)
 	}
+	ctx.pushData(model.ContextSecretVersions, versions)
 }
 
-// PushData appends arbitrary extension data to context
+// redactedData returns a copy of data with the secret values redacted, the keys of the secrets are kept.
+// It must be called with the lock held.
+func (ctx *templateContext) redactedData() map[string]interface{} {
+	data := make(map[string]interface{}, len(ctx.data))
+	for k, v := range ctx.data {
+		switch {
+		case ctx.secretKeys[k]:
+			data[k] = redactValue(v)
+		case k == model.OutputSecretName:
+			data[k] = redacted
+		case k == model.ContextSecretVersions:
+			// the versions are hashes of the secret data which could be used to verify the guesses of the secrets
+			continue
+		default:
+			data[k] = v
+		}
+	}
+	return data
+}
+
+const redacted = "***"
+
+func redactValue(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(val))
+		for k, item := range val {
+			m[k] = redactValue(item)
+		}
+		return m
+	case []interface{}:
+		l := make([]interface{}, len(val))
+		for i, item := range val {
+			l[i] = redactValue(item)
+		}
+		return l
+	case nil:
+		return nil
+	default:
+		return redacted
+	}
+}
+
+// secretVersion returns the hash of the secret data, the keys are sorted by json marshal so it is stable
+func secretVersion(data map[string]interface{}) string {
+	b, err := json.Marshal(data)
+	if err != nil {
+		b = []byte(fmt.Sprint(data))
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:8])
+}
+
+// PushData appends arbitrary extension data to context, a nil data is rendered as an explicit null
+// in the context while the data removed by RemoveData is absent
 func (ctx *templateContext) PushData(key string, data interface{}) {
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+	ctx.pushData(key, data)
+}
+
+func (ctx *templateContext) pushData(key string, data interface{}) {
 	if ctx.data == nil {
 		ctx.data = map[string]interface{}{key: data}
 		return
@@ -219,16 +550,144 @@ func (ctx *templateContext) PushData(key string, data interface{}) {
 	ctx.data[key] = data
 }
 
+// PushDataByPath pushes the data into the nested structs of context by the dotted path, e.g. the path
+// a.b.c is rendered as a: b: c: data. The maps along the path are copied before setting the data, an
+// error is returned if the path has an empty segment or a segment is not a struct
+func (ctx *templateContext) PushDataByPath(path string, data interface{}) error {
+	segments, err := splitDataPath(path)
+	if err != nil {
+		return err
+	}
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+	if len(segments) == 1 {
+		ctx.pushData(path, data)
+		return nil
+	}
+	var parent map[string]interface{}
+	if v, ok := ctx.data[segments[0]]; ok && v != nil {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return errors.Errorf("cannot push data by path %s: %s is not a struct", path, segments[0])
+		}
+		parent = m
+	}
+	root := copyMap(parent)
+	current := root
+	for i, segment := range segments[1 : len(segments)-1] {
+		var child map[string]interface{}
+		if v, ok := current[segment]; ok && v != nil {
+			m, ok := v.(map[string]interface{})
+			if !ok {
+				return errors.Errorf("cannot push data by path %s: %s is not a struct", path, strings.Join(segments[:i+2], "."))
+			}
+			child = m
+		}
+		child = copyMap(child)
+		current[segment] = child
+		current = child
+	}
+	current[segments[len(segments)-1]] = data
+	ctx.pushData(segments[0], root)
+	return nil
+}
+
+// GetDataByPath gets the data from the nested structs of context by the dotted path
+func (ctx *templateContext) GetDataByPath(path string) (interface{}, bool) {
+	segments, err := splitDataPath(path)
+	if err != nil {
+		return nil, false
+	}
+	ctx.mu.RLock()
+	defer ctx.mu.RUnlock()
+	var current interface{} = ctx.data
+	for _, segment := range segments {
+		m, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if current, ok = m[segment]; !ok {
+			return nil, false
+		}
+	}
+	return copyData(current), true
+}
+
+func splitDataPath(path string) ([]string, error) {
+	segments := strings.Split(path, ".")
+	for _, segment := range segments {
+		if segment == "" {
+			return nil, errors.Errorf("invalid data path %q: empty segment", path)
+		}
+	}
+	return segments, nil
+}
+
+func copyMap(m map[string]interface{}) map[string]interface{} {
+	copied := make(map[string]interface{}, len(m)+1)
+	for k, v := range m {
+		copied[k] = v
+	}
+	return copied
+}
+
+// RemoveData removes the data pushed into context, it does nothing if the key is absent
 func (ctx *templateContext) RemoveData(key string) {
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
 	delete(ctx.data, key)
 }
 
 // GetData get data from context
 func (ctx *templateContext) GetData(key string) interface{} {
+	ctx.mu.RLock()
+	defer ctx.mu.RUnlock()
 	return ctx.data[key]
 }
 
+// LookupData get data from context and whether the key exists, the maps and slices are copied so
+// that the data in context cannot be mutated by the caller
+func (ctx *templateContext) LookupData(key string) (interface{}, bool) {
+	ctx.mu.RLock()
+	defer ctx.mu.RUnlock()
+	data, ok := ctx.data[key]
+	if !ok {
+		return nil, false
+	}
+	return copyData(data), true
+}
+
+// copyData copies the maps and slices so that the origin data cannot be mutated through the copy
+func copyData(data interface{}) interface{} {
+	switch d := data.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(d))
+		for k, v := range d {
+			m[k] = copyData(v)
+		}
+		return m
+	case []interface{}:
+		l := make([]interface{}, len(d))
+		for i, v := range d {
+			l[i] = copyData(v)
+		}
+		return l
+	case map[string]string:
+		m := make(map[string]string, len(d))
+		for k, v := range d {
+			m[k] = v
+		}
+		return m
+	case []string:
+		return append([]string{}, d...)
+	default:
+		return data
+	}
+}
+
 func (ctx *templateContext) GetCtx() context.Context {
+	ctx.mu.RLock()
+	defer ctx.mu.RUnlock()
 	if ctx.ctx != nil {
 		return ctx.ctx
 	}
@@ -236,9 +695,100 @@ func (ctx *templateContext) GetCtx() context.Context {
 }
 
 func (ctx *templateContext) SetCtx(newContext context.Context) {
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
 	ctx.ctx = newContext
 }
 
+// Clone returns a copy of the context which can be rendered independently, the data and auxiliaries are
+// copied while the ctx and hooks are shared
+func (ctx *templateContext) Clone() Context {
+	ctx.mu.RLock()
+	defer ctx.mu.RUnlock()
+	clone := &templateContext{
+		base:           ctx.base,
+		auxiliaries:    append([]Auxiliary{}, ctx.auxiliaries...),
+		baseHooks:      ctx.baseHooks,
+		auxiliaryHooks: ctx.auxiliaryHooks,
+		outputHooks:    ctx.outputHooks,
+		extraLabels:    ctx.extraLabels,
+		maxSize:        ctx.maxSize,
+		customData:     ctx.customData,
+		ctx:            ctx.ctx,
+	}
+	if ctx.data != nil {
+		clone.data, _ = copyData(ctx.data).(map[string]interface{})
+	}
+	if ctx.secretKeys != nil {
+		clone.secretKeys = make(map[string]bool, len(ctx.secretKeys))
+		for k := range ctx.secretKeys {
+			clone.secretKeys[k] = true
+		}
+	}
+	return clone
+}
+
+// MergeContext merges the parameters, data and auxiliaries of src into dst. The auxiliaries are appended
+// to dst with its hooks and the duplicate names are rejected, nothing is merged in that case. The
+// parameters and data of src override the keys of dst, including the configs, while the name, namespace,
+// workflowName and publishVersion of dst are kept and the outputs are rendered from the auxiliaries.
+func MergeContext(dst, src Context) error {
+	source, ok := src.(*templateContext)
+	if !ok {
+		return errors.Errorf("cannot merge context of type %T", src)
+	}
+	_, auxiliaries := src.Output()
+	if len(auxiliaries) > 0 {
+		if err := dst.AppendAuxiliaries(auxiliaries...); err != nil {
+			return errors.Wrap(err, "cannot merge context")
+		}
+	}
+
+	source.mu.RLock()
+	data, _ := copyData(source.data).(map[string]interface{})
+	var secretKeys []string
+	for k := range source.secretKeys {
+		secretKeys = append(secretKeys, k)
+	}
+	source.mu.RUnlock()
+	for _, key := range []string{model.ContextName, model.ContextNamespace, model.ContextWorkflowName,
+		model.ContextPublishVersion, model.OutputFieldName, model.OutputsFieldName} {
+		delete(data, key)
+	}
+	if params, ok := data[model.ParameterFieldName].(map[string]interface{}); ok {
+		merged := dst.GetParameters()
+		for k, v := range params {
+			merged[k] = v
+		}
+		dst.SetParameters(merged)
+	}
+	delete(data, model.ParameterFieldName)
+	if versions, ok := data[model.ContextSecretVersions].(map[string]interface{}); ok {
+		merged, _ := copyData(dst.GetData(model.ContextSecretVersions)).(map[string]interface{})
+		if merged == nil {
+			merged = map[string]interface{}{}
+		}
+		for k, v := range versions {
+			merged[k] = v
+		}
+		data[model.ContextSecretVersions] = merged
+	}
+	for k, v := range data {
+		dst.PushData(k, v)
+	}
+	if target, ok := dst.(*templateContext); ok && len(secretKeys) > 0 {
+		target.mu.Lock()
+		defer target.mu.Unlock()
+		if target.secretKeys == nil {
+			target.secretKeys = map[string]bool{}
+		}
+		for _, k := range secretKeys {
+			target.secretKeys[k] = true
+		}
+	}
+	return nil
+}
+
 func structMarshal(v string) string {
 	skip := false
 	v = strings.TrimFunc(v, func(r rune) bool {
@@ -17,8 +17,13 @@ limitations under the License.
 package process
 
 import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"sync"
 	"testing"
 
+	"cuelang.org/go/cue"
 	"cuelang.org/go/cue/cuecontext"
 	"github.com/stretchr/testify/require"
 
@@ -133,3 +138,741 @@ image: "myserver"
 	r.Equal(nil, err)
 	r.Equal("{\"bool\":false,\"int\":10,\"map\":{\"key\":\"value\"},\"slice\":[\"str1\",\"str2\",\"str3\"],\"string\":\"mytxt\"}", string(arbitraryData))
 }
+
+func TestSecretVersions(t *testing.T) {
+	r := require.New(t)
+	versionOf := func(data map[string]interface{}) string {
+		ctx := NewContext(ContextData{Name: "app"}).(*templateContext)
+		ctx.InsertSecrets("", []RequiredSecrets{
+			{Name: "db", ContextName: "db", Data: data},
+			{Name: "token", ContextName: "token", Data: map[string]interface{}{"token": "abc"}},
+		})
+		c, err := ctx.BaseContextFile()
+		r.NoError(err)
+		v := cuecontext.New().CompileString(c)
+		version, err := v.LookupPath(value.FieldPath("context", model.ContextSecretVersions, "db")).String()
+		r.NoError(err)
+		r.True(v.LookupPath(value.FieldPath("context", model.ContextSecretVersions, "token")).Exists())
+		return version
+	}
+	origin := versionOf(map[string]interface{}{"user": "admin", "password": "pwd"})
+	r.NotEmpty(origin)
+	r.Equal(origin, versionOf(map[string]interface{}{"password": "pwd", "user": "admin"}))
+	r.NotEqual(origin, versionOf(map[string]interface{}{"user": "admin", "password": "rotated"}))
+}
+
+func TestContextNull(t *testing.T) {
+	r := require.New(t)
+	base, err := model.NewBase(cuecontext.New().CompileString(`
+image:    "myserver"
+replicas: null
+`))
+	r.NoError(err)
+	ctx := NewContext(ContextData{Name: "myrun", CustomData: map[string]interface{}{"custom": nil}})
+	r.NoError(ctx.SetBase(base))
+	ctx.PushData("unset", nil)
+	ctx.PushData("nested", map[string]interface{}{"unset": nil, "value": "v"})
+	ctx.PushData("removed", "value")
+	ctx.RemoveData("removed")
+
+	c, err := ctx.BaseContextFile()
+	r.NoError(err)
+	v := cuecontext.New().CompileString(c + `
+isNull: {
+	unset:    context.unset == null
+	custom:   context.custom == null
+	nested:   context.nested.unset == null
+	replicas: context.output.replicas == null
+	name:     context.name == null
+}
+isAbsent: {
+	unset:   context.unset == _|_
+	absent:  context.absent == _|_
+	removed: context.removed == _|_
+	nested:  context.nested.absent == _|_
+	name:    context.name == _|_
+}
+`)
+	r.NoError(v.Err())
+	isNull, err := v.LookupPath(value.FieldPath("isNull")).MarshalJSON()
+	r.NoError(err)
+	r.Equal(`{"unset":true,"custom":true,"nested":true,"replicas":true,"name":false}`, string(isNull))
+	isAbsent, err := v.LookupPath(value.FieldPath("isAbsent")).MarshalJSON()
+	r.NoError(err)
+	r.Equal(`{"unset":false,"absent":true,"removed":true,"nested":true,"name":false}`, string(isAbsent))
+}
+
+func TestLookupData(t *testing.T) {
+	r := require.New(t)
+	ctx := &templateContext{}
+	_, ok := ctx.LookupData("absent")
+	r.False(ok)
+
+	ctx.PushData("unset", nil)
+	ctx.PushData("config", map[string]interface{}{
+		"labels": map[string]string{"app": "myapp"},
+		"hosts":  []interface{}{"a.example.com"},
+		"nested": map[string]interface{}{"key": "value"},
+	})
+	ctx.PushData("images", []string{"nginx"})
+	v, ok := ctx.LookupData("unset")
+	r.True(ok)
+	r.Nil(v)
+	_, ok = ctx.LookupData("absent")
+	r.False(ok)
+
+	v, ok = ctx.LookupData("config")
+	r.True(ok)
+	config := v.(map[string]interface{})
+	config["added"] = true
+	config["labels"].(map[string]string)["app"] = "mutated"
+	config["hosts"].([]interface{})[0] = "mutated"
+	config["nested"].(map[string]interface{})["key"] = "mutated"
+	v, ok = ctx.LookupData("images")
+	r.True(ok)
+	v.([]string)[0] = "mutated"
+
+	r.Equal(map[string]interface{}{
+		"labels": map[string]string{"app": "myapp"},
+		"hosts":  []interface{}{"a.example.com"},
+		"nested": map[string]interface{}{"key": "value"},
+	}, ctx.GetData("config"))
+	r.Equal([]string{"nginx"}, ctx.GetData("images"))
+}
+
+func TestRemoveData(t *testing.T) {
+	r := require.New(t)
+	ctx := &templateContext{}
+	ctx.RemoveData("absent")
+	_, ok := ctx.LookupData("absent")
+	r.False(ok)
+
+	ctx.PushData("manifests", map[string]interface{}{"deployment": map[string]interface{}{"kind": "Deployment"}})
+	ctx.PushData("name", "myrun")
+	ctx.RemoveData("manifests")
+	ctx.RemoveData("absent")
+	_, ok = ctx.LookupData("manifests")
+	r.False(ok)
+
+	c, err := ctx.BaseContextFile()
+	r.NoError(err)
+	v := cuecontext.New().CompileString(c)
+	r.NoError(v.Err())
+	r.False(v.LookupPath(value.FieldPath("context", "manifests")).Exists())
+	name, err := v.LookupPath(value.FieldPath("context", "name")).String()
+	r.NoError(err)
+	r.Equal("myrun", name)
+}
+
+func TestContextConcurrency(t *testing.T) {
+	r := require.New(t)
+	ctx := NewContext(ContextData{Name: "myrun", Namespace: "default"})
+	base, err := model.NewBase(cuecontext.New().CompileString(`image: "myserver"`))
+	r.NoError(err)
+	r.NoError(ctx.SetBase(base))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(3)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				ins, err := model.NewOther(cuecontext.New().CompileString(`kind: "ConfigMap"`))
+				r.NoError(err)
+				r.NoError(ctx.AppendAuxiliaries(Auxiliary{Ins: ins, Name: fmt.Sprintf("aux-%d-%d", i, j)}))
+			}
+		}(i)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				ctx.PushData(fmt.Sprintf("data-%d", i), j)
+				ctx.SetParameters(map[string]interface{}{"index": j})
+				ctx.(*templateContext).InsertSecrets("", []RequiredSecrets{{ContextName: fmt.Sprintf("secret-%d", i), Data: map[string]interface{}{"index": j}}})
+				_, _ = ctx.LookupData(fmt.Sprintf("data-%d", i))
+			}
+		}(i)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				_, err := ctx.BaseContextFile()
+				r.NoError(err)
+				ctx.Output()
+				ctx.BaseContextLabels()
+				Restrict(ctx, []string{RestrictAll})
+			}
+		}()
+	}
+	wg.Wait()
+
+	c, err := ctx.BaseContextFile()
+	r.NoError(err)
+	v := cuecontext.New().CompileString(c)
+	r.NoError(v.Err())
+	_, auxiliaries := ctx.Output()
+	r.Len(auxiliaries, 200)
+	for i := 0; i < 10; i++ {
+		index, err := v.LookupPath(value.FieldPath("context", fmt.Sprintf("data-%d", i))).Int64()
+		r.NoError(err)
+		r.Equal(int64(19), index)
+	}
+}
+
+func TestContextSpecialCharacters(t *testing.T) {
+	r := require.New(t)
+	ctx := NewContext(ContextData{
+		Name:           `foo"bar`,
+		Namespace:      "multi\nline\\namespace",
+		WorkflowName:   "{workflow: \"}",
+		PublishVersion: "\t v1",
+	})
+	c, err := ctx.BaseContextFile()
+	r.NoError(err)
+	v := cuecontext.New().CompileString(c)
+	r.NoError(v.Err())
+	for k, expected := range map[string]string{
+		model.ContextName:           `foo"bar`,
+		model.ContextNamespace:      "multi\nline\\namespace",
+		model.ContextWorkflowName:   "{workflow: \"}",
+		model.ContextPublishVersion: "\t v1",
+	} {
+		s, err := v.LookupPath(value.FieldPath("context", k)).String()
+		r.NoError(err)
+		r.Equal(expected, s)
+	}
+}
+
+func TestReplaceAuxiliary(t *testing.T) {
+	r := require.New(t)
+	newAux := func(name, tmpl string) Auxiliary {
+		ins, err := model.NewOther(cuecontext.New().CompileString(tmpl))
+		r.NoError(err)
+		return Auxiliary{Ins: ins, Name: name}
+	}
+	var hooked []string
+	ctx := NewContext(ContextData{
+		Name: "myrun",
+		AuxiliaryHooks: []AuxiliaryHook{AuxiliaryHookFn(func(_ Context, auxiliaries []Auxiliary) error {
+			for _, aux := range auxiliaries {
+				if aux.Name == "invalid" {
+					return fmt.Errorf("invalid auxiliary")
+				}
+				hooked = append(hooked, aux.Name)
+			}
+			return nil
+		})},
+	})
+	r.NoError(ctx.AppendAuxiliaries(newAux("service", `kind: "Service"`), newAux("ingress", `kind: "Ingress"`)))
+
+	r.NoError(ctx.ReplaceAuxiliary(newAux("service", `kind: "Service", port: 80`)))
+	r.Equal([]string{"service", "ingress", "service"}, hooked)
+	err := ctx.ReplaceAuxiliary(newAux("absent", `kind: "ConfigMap"`))
+	r.Error(err)
+	r.Contains(err.Error(), "auxiliary absent not found")
+	r.Error(ctx.ReplaceAuxiliary(newAux("invalid", `kind: "ConfigMap"`)))
+
+	_, auxiliaries := ctx.Output()
+	r.Len(auxiliaries, 2)
+	r.Equal("service", auxiliaries[0].Name)
+	c, err := ctx.BaseContextFile()
+	r.NoError(err)
+	v := cuecontext.New().CompileString(c)
+	port, err := v.LookupPath(value.FieldPath("context", "outputs", "service", "port")).Int64()
+	r.NoError(err)
+	r.Equal(int64(80), port)
+
+	r.True(ctx.RemoveAuxiliary("service"))
+	r.False(ctx.RemoveAuxiliary("service"))
+	r.False(ctx.RemoveAuxiliary("absent"))
+	_, auxiliaries = ctx.Output()
+	r.Len(auxiliaries, 1)
+	r.Equal("ingress", auxiliaries[0].Name)
+	r.Error(ctx.ReplaceAuxiliary(newAux("service", `kind: "Service"`)))
+
+	r.True(ctx.RemoveAuxiliary("ingress"))
+	c, err = ctx.BaseContextFile()
+	r.NoError(err)
+	v = cuecontext.New().CompileString(c)
+	r.False(v.LookupPath(value.FieldPath("context", "outputs")).Exists())
+}
+
+func TestReplaceAuxiliaryWithoutName(t *testing.T) {
+	r := require.New(t)
+	newAux := func(typ, tmpl string) Auxiliary {
+		ins, err := model.NewOther(cuecontext.New().CompileString(tmpl))
+		r.NoError(err)
+		return Auxiliary{Ins: ins, Type: typ}
+	}
+	ctx := NewContext(ContextData{Name: "myrun"})
+	r.NoError(ctx.AppendAuxiliaries(newAux("trait-a", `kind: "Service"`), newAux("trait-b", `kind: "Ingress"`)))
+
+	r.NoError(ctx.ReplaceAuxiliary(newAux("trait-b", `kind: "Gateway"`)))
+	_, auxiliaries := ctx.Output()
+	r.Len(auxiliaries, 2)
+	kind, err := auxiliaries[0].Ins.Value().LookupPath(value.FieldPath("kind")).String()
+	r.NoError(err)
+	r.Equal("Service", kind)
+	kind, err = auxiliaries[1].Ins.Value().LookupPath(value.FieldPath("kind")).String()
+	r.NoError(err)
+	r.Equal("Gateway", kind)
+	err = ctx.ReplaceAuxiliary(newAux("trait-c", `kind: "ConfigMap"`))
+	r.Error(err)
+	r.Contains(err.Error(), "main auxiliary of type trait-c not found")
+
+	// the main auxiliaries cannot be removed by name
+	r.False(ctx.RemoveAuxiliary(""))
+	_, auxiliaries = ctx.Output()
+	r.Len(auxiliaries, 2)
+}
+
+func TestAuxiliariesOrder(t *testing.T) {
+	r := require.New(t)
+	var auxiliaries []Auxiliary
+	for _, aux := range []struct{ name, typ, tmpl string }{
+		{"service", "", `kind: "Service"`},
+		{"ingress", "", `kind: "Ingress"`},
+		{"", "trait-a", `kind: "ConfigMap", data: a: "a"`},
+		{"", "trait-b", `kind: "ConfigMap", data: b: "b"`},
+		{"", "", `kind: "Deployment"`},
+	} {
+		ins, err := model.NewOther(cuecontext.New().CompileString(aux.tmpl))
+		r.NoError(err)
+		auxiliaries = append(auxiliaries, Auxiliary{Ins: ins, Name: aux.name, Type: aux.typ})
+	}
+	render := func(auxiliaries []Auxiliary) string {
+		ctx := NewContext(ContextData{Name: "myrun"})
+		r.NoError(ctx.AppendAuxiliaries(auxiliaries...))
+		c, err := ctx.BaseContextFile()
+		r.NoError(err)
+		_, output := ctx.Output()
+		r.Equal(auxiliaries, output)
+		return c
+	}
+	expected := render(auxiliaries)
+	v := cuecontext.New().CompileString(expected)
+	r.True(v.LookupPath(value.FieldPath("context", "outputs", "", "data", "b")).Exists())
+	for i := 0; i < 20; i++ {
+		shuffled := append([]Auxiliary{}, auxiliaries...)
+		rand.Shuffle(len(shuffled), func(i, j int) { shuffled[i], shuffled[j] = shuffled[j], shuffled[i] })
+		r.Equal(expected, render(shuffled))
+	}
+}
+
+func TestSeedData(t *testing.T) {
+	r := require.New(t)
+	seed := map[string]interface{}{"runID": "run-1", "trigger": map[string]interface{}{"source": "webhook"}}
+	ctx := NewContext(ContextData{Name: "myrun", Data: seed})
+	seed["runID"] = "mutated"
+	seed["added"] = true
+	ctx.PushData("pushed", "value")
+	r.NotContains(seed, "pushed")
+
+	c, err := ctx.BaseContextFile()
+	r.NoError(err)
+	v := cuecontext.New().CompileString(c)
+	r.NoError(v.Err())
+	runID, err := v.LookupPath(value.FieldPath("context", "runID")).String()
+	r.NoError(err)
+	r.Equal("run-1", runID)
+	source, err := v.LookupPath(value.FieldPath("context", "trigger", "source")).String()
+	r.NoError(err)
+	r.Equal("webhook", source)
+	r.False(v.LookupPath(value.FieldPath("context", "added")).Exists())
+	name, err := v.LookupPath(value.FieldPath("context", model.ContextName)).String()
+	r.NoError(err)
+	r.Equal("myrun", name)
+}
+
+func TestClone(t *testing.T) {
+	r := require.New(t)
+	newAux := func(name string) Auxiliary {
+		ins, err := model.NewOther(cuecontext.New().CompileString(`kind: "ConfigMap"`))
+		r.NoError(err)
+		return Auxiliary{Ins: ins, Name: name}
+	}
+	origin := NewContext(ContextData{Name: "myrun"})
+	origin.SetParameters(map[string]interface{}{"replicas": 1})
+	origin.PushData("shared", map[string]interface{}{"key": "value"})
+	r.NoError(origin.AppendAuxiliaries(newAux("shared")))
+
+	clone := origin.Clone()
+	r.Equal(origin.GetCtx(), clone.GetCtx())
+	origin.PushData("origin", true)
+	clone.PushData("clone", true)
+	clone.GetData("shared").(map[string]interface{})["key"] = "mutated"
+	clone.GetData(model.ParameterFieldName).(map[string]interface{})["replicas"] = 2
+	r.NoError(origin.AppendAuxiliaries(newAux("origin")))
+	r.NoError(clone.AppendAuxiliaries(newAux("clone")))
+
+	render := func(ctx Context) cue.Value {
+		c, err := ctx.BaseContextFile()
+		r.NoError(err)
+		v := cuecontext.New().CompileString(c)
+		r.NoError(v.Err())
+		return v
+	}
+	o, c := render(origin), render(clone)
+	for _, path := range []string{"context.origin", "context.outputs.origin", "context.outputs.shared"} {
+		r.True(o.LookupPath(value.FieldPath(path)).Exists(), path)
+	}
+	for _, path := range []string{"context.clone", "context.outputs.clone"} {
+		r.False(o.LookupPath(value.FieldPath(path)).Exists(), path)
+		r.True(c.LookupPath(value.FieldPath(path)).Exists(), path)
+	}
+	r.False(c.LookupPath(value.FieldPath("context.outputs.origin")).Exists())
+	key, err := o.LookupPath(value.FieldPath("context.shared.key")).String()
+	r.NoError(err)
+	r.Equal("value", key)
+	key, err = c.LookupPath(value.FieldPath("context.shared.key")).String()
+	r.NoError(err)
+	r.Equal("mutated", key)
+	replicas, err := o.LookupPath(value.FieldPath("context.parameter.replicas")).Int64()
+	r.NoError(err)
+	r.Equal(int64(1), replicas)
+}
+
+func TestGetParameters(t *testing.T) {
+	r := require.New(t)
+	ctx := NewContext(ContextData{Name: "myrun"})
+	params := ctx.GetParameters()
+	r.NotNil(params)
+	r.Empty(params)
+
+	ctx.SetParameters(map[string]interface{}{"image": "nginx", "replicas": 1})
+	params = ctx.GetParameters()
+	r.Equal(map[string]interface{}{"image": "nginx", "replicas": 1}, params)
+	params["image"] = "mutated"
+	r.Equal("nginx", ctx.GetParameters()["image"])
+}
+
+func TestOutputHooks(t *testing.T) {
+	r := require.New(t)
+	base, err := model.NewBase(cuecontext.New().CompileString(`image: "myserver"`))
+	r.NoError(err)
+	ins, err := model.NewOther(cuecontext.New().CompileString(`kind: "Service"`))
+	r.NoError(err)
+	var executed []string
+	hook := func(name string, err error) OutputHook {
+		return OutputHookFn(func(ctx Context, b model.Instance, auxiliaries []Auxiliary) error {
+			executed = append(executed, name)
+			r.Equal(base, b)
+			r.Len(auxiliaries, 1)
+			return err
+		})
+	}
+
+	ctx := NewContext(ContextData{Name: "myrun", OutputHooks: []OutputHook{hook("first", nil), hook("second", nil)}})
+	r.NoError(ctx.SetBase(base))
+	r.NoError(ctx.AppendAuxiliaries(Auxiliary{Ins: ins, Name: "service"}))
+	b, auxiliaries, err := ctx.OutputWithError()
+	r.NoError(err)
+	r.Equal(base, b)
+	r.Len(auxiliaries, 1)
+	r.Equal([]string{"first", "second"}, executed)
+
+	executed = nil
+	ctx = NewContext(ContextData{Name: "myrun", OutputHooks: []OutputHook{hook("first", fmt.Errorf("policy violated")), hook("second", nil)}})
+	r.NoError(ctx.SetBase(base))
+	r.NoError(ctx.AppendAuxiliaries(Auxiliary{Ins: ins, Name: "service"}))
+	_, _, err = ctx.OutputWithError()
+	r.Error(err)
+	r.Contains(err.Error(), "policy violated")
+	r.Equal([]string{"first"}, executed)
+	b, _ = ctx.Output()
+	r.Equal(base, b)
+}
+
+func TestDuplicateAuxiliaries(t *testing.T) {
+	r := require.New(t)
+	newAux := func(name, typ string) Auxiliary {
+		ins, err := model.NewOther(cuecontext.New().CompileString(`kind: "ConfigMap"`))
+		r.NoError(err)
+		return Auxiliary{Ins: ins, Name: name, Type: typ}
+	}
+	var hooked []string
+	ctx := NewContext(ContextData{
+		Name: "myrun",
+		AuxiliaryHooks: []AuxiliaryHook{AuxiliaryHookFn(func(_ Context, auxiliaries []Auxiliary) error {
+			for _, aux := range auxiliaries {
+				hooked = append(hooked, aux.Name)
+			}
+			return nil
+		})},
+	})
+	r.NoError(ctx.AppendAuxiliaries(newAux("service", "trait-a"), newAux("", "trait-a"), newAux("", "trait-b")))
+	r.NoError(ctx.AppendAuxiliaries(newAux("ingress", "trait-a"), newAux("", "")))
+
+	err := ctx.AppendAuxiliaries(newAux("service", "trait-b"))
+	r.Error(err)
+	r.Contains(err.Error(), `duplicate auxiliary name "service"`)
+	err = ctx.AppendAuxiliaries(newAux("config", "trait-c"), newAux("config", "trait-c"))
+	r.Error(err)
+	r.Contains(err.Error(), `duplicate auxiliary name "config"`)
+	err = ctx.AppendAuxiliaries(newAux("", "trait-b"))
+	r.Error(err)
+	r.Contains(err.Error(), `duplicate main auxiliary of type "trait-b"`)
+
+	// the hooks are not executed on the rejected auxiliaries
+	r.Equal([]string{"service", "", "", "ingress", ""}, hooked)
+
+	_, auxiliaries := ctx.Output()
+	r.Len(auxiliaries, 5)
+	r.True(ctx.RemoveAuxiliary("service"))
+	r.NoError(ctx.AppendAuxiliaries(newAux("service", "trait-b")))
+}
+
+func TestDataByPath(t *testing.T) {
+	r := require.New(t)
+	ctx := NewContext(ContextData{Name: "myrun"})
+	trigger := map[string]interface{}{"source": "webhook"}
+	ctx.PushData("trigger", trigger)
+	ctx.PushData("scalar", "value")
+
+	r.NoError(ctx.PushDataByPath("a.b.c", "v"))
+	r.NoError(ctx.PushDataByPath("a.b.d", 1))
+	r.NoError(ctx.PushDataByPath("a.e", true))
+	r.NoError(ctx.PushDataByPath("trigger.event.id", "123"))
+	r.NoError(ctx.PushDataByPath("flat", "flat"))
+	r.Equal(map[string]interface{}{"source": "webhook"}, trigger)
+
+	for path, err := range map[string]string{
+		"scalar.key": "scalar is not a struct",
+		"a.b.c.d":    "a.b.c is not a struct",
+		"a..b":       "empty segment",
+		"":           "empty segment",
+		"a.b.":       "empty segment",
+	} {
+		e := ctx.PushDataByPath(path, "v")
+		r.Error(e, path)
+		r.Contains(e.Error(), err)
+	}
+
+	v, ok := ctx.GetDataByPath("a.b")
+	r.True(ok)
+	r.Equal(map[string]interface{}{"c": "v", "d": 1}, v)
+	v, ok = ctx.GetDataByPath("trigger.source")
+	r.True(ok)
+	r.Equal("webhook", v)
+	for _, path := range []string{"a.absent", "scalar.key", "a..b", "absent"} {
+		_, ok = ctx.GetDataByPath(path)
+		r.False(ok, path)
+	}
+
+	c, err := ctx.BaseContextFile()
+	r.NoError(err)
+	val := cuecontext.New().CompileString(c)
+	r.NoError(val.Err())
+	out, err := val.LookupPath(value.FieldPath("context", "a")).MarshalJSON()
+	r.NoError(err)
+	r.Equal(`{"b":{"c":"v","d":1},"e":true}`, string(out))
+	out, err = val.LookupPath(value.FieldPath("context", "trigger")).MarshalJSON()
+	r.NoError(err)
+	r.Equal(`{"event":{"id":"123"},"source":"webhook"}`, string(out))
+}
+
+func TestRequiredSecretsRenderedOnce(t *testing.T) {
+	r := require.New(t)
+	ctx := NewContext(ContextData{Name: "myrun"}).(*templateContext)
+	secrets := []RequiredSecrets{
+		{Name: "db", ContextName: "dbConn", Data: map[string]interface{}{"password": "pwd"}},
+		{Name: "token", ContextName: "apiToken", Data: map[string]interface{}{"token": "abc"}},
+	}
+	ctx.InsertSecrets("", secrets)
+	// inserting again updates the secrets in place, the secret versions are keyed by the context names as well
+	ctx.InsertSecrets("", secrets)
+	c, err := ctx.BaseContextFile()
+	r.NoError(err)
+	r.Equal(1, strings.Count(c, `"dbConn":{`))
+	r.Equal(1, strings.Count(c, `"apiToken":{`))
+	v := cuecontext.New().CompileString(c)
+	r.NoError(v.Err())
+	password, err := v.LookupPath(value.FieldPath("context", "dbConn", "password")).String()
+	r.NoError(err)
+	r.Equal("pwd", password)
+}
+
+func TestBaseContextLabels(t *testing.T) {
+	r := require.New(t)
+	r.Equal(map[string]string{model.ContextName: "myrun"}, NewContext(ContextData{Name: "myrun"}).BaseContextLabels())
+
+	extra := map[string]string{"env": "prod", "tenant": "team-a", model.ContextName: "overridden"}
+	ctx := NewContext(ContextData{Name: "myrun", ExtraLabels: extra})
+	labels := ctx.BaseContextLabels()
+	r.Equal(map[string]string{"env": "prod", "tenant": "team-a", model.ContextName: "myrun"}, labels)
+	labels["env"] = "mutated"
+	r.Equal("prod", ctx.BaseContextLabels()["env"])
+	r.Equal("prod", extra["env"])
+}
+
+func TestMergeContext(t *testing.T) {
+	r := require.New(t)
+	newAux := func(name string) Auxiliary {
+		ins, err := model.NewOther(cuecontext.New().CompileString(`kind: "ConfigMap"`))
+		r.NoError(err)
+		return Auxiliary{Ins: ins, Name: name}
+	}
+	var hooked []string
+	dst := NewContext(ContextData{
+		Name:      "parent",
+		Namespace: "default",
+		AuxiliaryHooks: []AuxiliaryHook{AuxiliaryHookFn(func(_ Context, auxiliaries []Auxiliary) error {
+			for _, aux := range auxiliaries {
+				hooked = append(hooked, aux.Name)
+			}
+			return nil
+		})},
+	})
+	dst.SetParameters(map[string]interface{}{"image": "nginx", "replicas": 1})
+	dst.PushData("dstOnly", "dst")
+	dst.PushData("shared", "dst")
+	r.NoError(dst.AppendAuxiliaries(newAux("service")))
+	dst.(*templateContext).InsertSecrets("", []RequiredSecrets{{ContextName: "dstSecret", Data: map[string]interface{}{"k": "dst"}}})
+
+	src := NewContext(ContextData{Name: "template", Namespace: "other"})
+	src.SetParameters(map[string]interface{}{"replicas": 3, "port": 80})
+	src.PushData("srcOnly", "src")
+	src.PushData("shared", "src")
+	src.PushData(model.ConfigFieldName, map[string]interface{}{"key": "value"})
+	r.NoError(src.AppendAuxiliaries(newAux("ingress")))
+	src.(*templateContext).InsertSecrets("", []RequiredSecrets{{ContextName: "srcSecret", Data: map[string]interface{}{"k": "src"}}})
+
+	r.NoError(MergeContext(dst, src))
+	r.Equal([]string{"service", "ingress"}, hooked)
+	r.Equal(map[string]interface{}{"image": "nginx", "replicas": 3, "port": 80}, dst.GetParameters())
+	r.Equal("dst", dst.GetData("dstOnly"))
+	r.Equal("src", dst.GetData("srcOnly"))
+	r.Equal("src", dst.GetData("shared"))
+	r.Equal("parent", dst.GetData(model.ContextName))
+	r.Equal("default", dst.GetData(model.ContextNamespace))
+	r.Equal(map[string]interface{}{"key": "value"}, dst.GetData(model.ConfigFieldName))
+	r.Len(dst.GetData(model.ContextSecretVersions), 2)
+	_, auxiliaries := dst.Output()
+	r.Len(auxiliaries, 2)
+
+	c, err := dst.BaseContextFile()
+	r.NoError(err)
+	v := cuecontext.New().CompileString(c)
+	r.NoError(v.Err())
+	r.True(v.LookupPath(value.FieldPath("context.outputs.ingress")).Exists())
+	r.True(v.LookupPath(value.FieldPath("context.srcSecret.k")).Exists())
+
+	// the duplicate auxiliaries are rejected and nothing is merged
+	conflict := NewContext(ContextData{Name: "conflict"})
+	conflict.PushData("conflict", true)
+	r.NoError(conflict.AppendAuxiliaries(newAux("service")))
+	err = MergeContext(dst, conflict)
+	r.Error(err)
+	r.Contains(err.Error(), `duplicate auxiliary name "service"`)
+	_, ok := dst.LookupData("conflict")
+	r.False(ok)
+}
+
+func TestNewContextWithValidation(t *testing.T) {
+	r := require.New(t)
+	ctx, err := NewContextWithValidation(ContextData{Name: "my-run", Namespace: "default", WorkflowName: `wf "quoted" \ name`})
+	r.NoError(err)
+	r.Equal("my-run", ctx.GetData(model.ContextName))
+
+	_, err = NewContextWithValidation(ContextData{Name: "my-run", CustomData: map[string]interface{}{"custom": map[string]interface{}{"a": 1}}})
+	r.NoError(err)
+
+	for name, data := range map[string]ContextData{
+		"name is required":      {Namespace: "default"},
+		"control character":     {Name: "my\nrun"},
+		"invalid UTF-8":         {Name: "my-run", Namespace: "de\xfffault"},
+		"control character U+0": {Name: "my-run", WorkflowName: "wf\x00"},
+	} {
+		_, err = NewContextWithValidation(data)
+		r.Error(err, name)
+		r.Contains(err.Error(), name)
+	}
+}
+
+func TestSetNameAndNamespace(t *testing.T) {
+	r := require.New(t)
+	ctx := NewContext(ContextData{Name: "myrun", Namespace: "default"})
+	base, err := model.NewBase(cuecontext.New().CompileString(`image: "myserver"`))
+	r.NoError(err)
+	r.NoError(ctx.SetBase(base))
+	_, err = ctx.BaseContextFile()
+	r.NoError(err)
+
+	ctx.SetName("connection")
+	ctx.SetNamespace("target")
+	c, err := ctx.BaseContextFile()
+	r.NoError(err)
+	v := cuecontext.New().CompileString(c)
+	r.NoError(v.Err())
+	name, err := v.LookupPath(value.FieldPath("context", model.ContextName)).String()
+	r.NoError(err)
+	r.Equal("connection", name)
+	namespace, err := v.LookupPath(value.FieldPath("context", model.ContextNamespace)).String()
+	r.NoError(err)
+	r.Equal("target", namespace)
+	r.True(v.LookupPath(value.FieldPath("context", "output", "image")).Exists())
+	r.Equal("connection", ctx.BaseContextLabels()[model.ContextName])
+}
+
+func TestBaseContextFileRedacted(t *testing.T) {
+	r := require.New(t)
+	ctx := NewContext(ContextData{Name: "myrun"}).(*templateContext)
+	ctx.PushData("plain", "visible")
+	ctx.InsertSecrets("my-output-secret", []RequiredSecrets{
+		{Name: "db", ContextName: "dbConn", Data: map[string]interface{}{"password": "s3cr3t-pwd", "hosts": []interface{}{"db-host-1"}}},
+	})
+
+	c, err := ctx.BaseContextFile()
+	r.NoError(err)
+	r.Contains(c, "s3cr3t-pwd")
+	redactedFile, err := ctx.BaseContextFileRedacted()
+	r.NoError(err)
+	for _, secret := range []string{"s3cr3t-pwd", "db-host-1", "my-output-secret"} {
+		r.NotContains(redactedFile, secret)
+	}
+	v := cuecontext.New().CompileString(redactedFile)
+	r.NoError(v.Err())
+	for path, expected := range map[string]string{
+		"context.dbConn.password":  "***",
+		"context.dbConn.hosts[0]":  "***",
+		"context.outputSecretName": "***",
+		"context.plain":            "visible",
+		"context.name":             "myrun",
+	} {
+		s, err := v.LookupPath(value.FieldPath(path)).String()
+		r.NoError(err, path)
+		r.Equal(expected, s, path)
+	}
+	r.False(v.LookupPath(value.FieldPath("context", model.ContextSecretVersions)).Exists())
+
+	c2, err := ctx.BaseContextFile()
+	r.NoError(err)
+	r.Equal(c, c2)
+	clone, err := ctx.Clone().BaseContextFileRedacted()
+	r.NoError(err)
+	r.Equal(redactedFile, clone)
+}
+
+func TestContextMaxSize(t *testing.T) {
+	r := require.New(t)
+	ctx := NewContext(ContextData{Name: "myrun", MaxSize: 1024})
+	ctx.PushData("small", "value")
+	c, err := ctx.BaseContextFile()
+	r.NoError(err)
+	r.LessOrEqual(len(c), 1024)
+
+	ctx.PushData("manifests", strings.Repeat("x", 2048))
+	_, err = ctx.BaseContextFile()
+	r.Error(err)
+	r.Contains(err.Error(), "exceeds the limit of 1024 bytes")
+	r.Contains(err.Error(), "the largest data is manifests of 2050 bytes")
+	_, err = ctx.BaseContextFileRedacted()
+	r.Error(err)
+
+	ctx.RemoveData("manifests")
+	_, err = ctx.BaseContextFile()
+	r.NoError(err)
+
+	unlimited := NewContext(ContextData{Name: "myrun"})
+	unlimited.PushData("manifests", strings.Repeat("x", 2048))
+	_, err = unlimited.BaseContextFile()
+	r.NoError(err)
+}
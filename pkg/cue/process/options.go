@@ -0,0 +1,131 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package process
+
+import (
+	"context"
+
+	"github.com/kubevela/workflow/pkg/cue/model"
+)
+
+// ContextOption is the option of NewContextWithOptions
+type ContextOption interface {
+	ApplyTo(data *ContextData)
+}
+
+type contextOptionFn func(data *ContextData)
+
+func (fn contextOptionFn) ApplyTo(data *ContextData) {
+	fn(data)
+}
+
+// NewContextWithOptions create render templateContext with the options, the fields not set are empty
+func NewContextWithOptions(opts ...ContextOption) Context {
+	data := ContextData{}
+	for _, opt := range opts {
+		opt.ApplyTo(&data)
+	}
+	return NewContext(data)
+}
+
+// WithContextName set the name of the context
+func WithContextName(name string) ContextOption {
+	return contextOptionFn(func(data *ContextData) {
+		data.Name = name
+	})
+}
+
+// WithContextNamespace set the namespace of the context
+func WithContextNamespace(namespace string) ContextOption {
+	return contextOptionFn(func(data *ContextData) {
+		data.Namespace = namespace
+	})
+}
+
+// WithContextWorkflowName set the workflow name of the context
+func WithContextWorkflowName(workflowName string) ContextOption {
+	return contextOptionFn(func(data *ContextData) {
+		data.WorkflowName = workflowName
+	})
+}
+
+// WithContextPublishVersion set the publish version of the context
+func WithContextPublishVersion(publishVersion string) ContextOption {
+	return contextOptionFn(func(data *ContextData) {
+		data.PublishVersion = publishVersion
+	})
+}
+
+// WithGoContext set the go context of the context
+func WithGoContext(ctx context.Context) ContextOption {
+	return contextOptionFn(func(data *ContextData) {
+		data.Ctx = ctx
+	})
+}
+
+// WithContextParameters set the parameters of the context, it is merged with the data of WithContextData
+func WithContextParameters(params map[string]interface{}) ContextOption {
+	return WithContextData(map[string]interface{}{model.ParameterFieldName: params})
+}
+
+// WithContextData seeds the data of the context, the data of multiple options are merged
+func WithContextData(d map[string]interface{}) ContextOption {
+	return contextOptionFn(func(data *ContextData) {
+		if data.Data == nil {
+			data.Data = make(map[string]interface{}, len(d))
+		}
+		for k, v := range d {
+			data.Data[k] = v
+		}
+	})
+}
+
+// WithContextCustomData set the custom data of the context, which overrides the built-in data when rendering
+func WithContextCustomData(customData map[string]interface{}) ContextOption {
+	return contextOptionFn(func(data *ContextData) {
+		data.CustomData = customData
+	})
+}
+
+// WithContextHooks appends the base and auxiliary hooks of the context
+func WithContextHooks(baseHooks []BaseHook, auxiliaryHooks []AuxiliaryHook) ContextOption {
+	return contextOptionFn(func(data *ContextData) {
+		data.BaseHooks = append(data.BaseHooks, baseHooks...)
+		data.AuxiliaryHooks = append(data.AuxiliaryHooks, auxiliaryHooks...)
+	})
+}
+
+// WithContextOutputHooks appends the output hooks of the context
+func WithContextOutputHooks(hooks ...OutputHook) ContextOption {
+	return contextOptionFn(func(data *ContextData) {
+		data.OutputHooks = append(data.OutputHooks, hooks...)
+	})
+}
+
+// WithContextExtraLabels set the extra labels of the context
+func WithContextExtraLabels(labels map[string]string) ContextOption {
+	return contextOptionFn(func(data *ContextData) {
+		data.ExtraLabels = labels
+	})
+}
+
+// WithContextMaxSize set the size limit in bytes of the rendered context
+func WithContextMaxSize(size int) ContextOption {
+	return contextOptionFn(func(data *ContextData) {
+		data.MaxSize = size
+	})
+}
@@ -0,0 +1,96 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package process
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"cuelang.org/go/cue/cuecontext"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kubevela/workflow/pkg/cue/model"
+	"github.com/kubevela/workflow/pkg/cue/model/value"
+)
+
+func TestNewContextWithOptions(t *testing.T) {
+	r := require.New(t)
+
+	ctx := NewContextWithOptions()
+	r.Equal(context.TODO(), ctx.GetCtx())
+	r.Equal("", ctx.GetData(model.ContextName))
+	r.Empty(ctx.GetParameters())
+	r.Equal(map[string]string{model.ContextName: ""}, ctx.BaseContextLabels())
+	_, err := ctx.BaseContextFile()
+	r.NoError(err)
+
+	type key struct{}
+	goCtx := context.WithValue(context.Background(), key{}, "value")
+	var hooked []string
+	ctx = NewContextWithOptions(
+		WithContextName("myrun"),
+		WithContextNamespace("default"),
+		WithContextWorkflowName("mywf"),
+		WithContextPublishVersion("v1"),
+		WithGoContext(goCtx),
+		WithContextParameters(map[string]interface{}{"image": "nginx"}),
+		WithContextData(map[string]interface{}{"runID": "run-1"}),
+		WithContextData(map[string]interface{}{"trigger": "webhook"}),
+		WithContextExtraLabels(map[string]string{"env": "prod"}),
+		WithContextHooks(nil, []AuxiliaryHook{AuxiliaryHookFn(func(_ Context, auxiliaries []Auxiliary) error {
+			hooked = append(hooked, auxiliaries[0].Name)
+			return nil
+		})}),
+		WithContextOutputHooks(OutputHookFn(func(Context, model.Instance, []Auxiliary) error {
+			return fmt.Errorf("rejected")
+		})),
+	)
+	r.Equal(goCtx, ctx.GetCtx())
+	r.Equal(map[string]interface{}{"image": "nginx"}, ctx.GetParameters())
+	r.Equal(map[string]string{model.ContextName: "myrun", "env": "prod"}, ctx.BaseContextLabels())
+	ins, err := model.NewOther(cuecontext.New().CompileString(`kind: "Service"`))
+	r.NoError(err)
+	r.NoError(ctx.AppendAuxiliaries(Auxiliary{Ins: ins, Name: "service"}))
+	r.Equal([]string{"service"}, hooked)
+	_, _, err = ctx.OutputWithError()
+	r.Error(err)
+
+	c, err := ctx.BaseContextFile()
+	r.NoError(err)
+	v := cuecontext.New().CompileString(c)
+	r.NoError(v.Err())
+	for path, expected := range map[string]string{
+		"context.name":            "myrun",
+		"context.namespace":       "default",
+		"context.workflowName":    "mywf",
+		"context.publishVersion":  "v1",
+		"context.runID":           "run-1",
+		"context.trigger":         "webhook",
+		"context.parameter.image": "nginx",
+	} {
+		s, err := v.LookupPath(value.FieldPath(path)).String()
+		r.NoError(err, path)
+		r.Equal(expected, s, path)
+	}
+
+	ctx = NewContextWithOptions(WithContextName("myrun"), WithContextMaxSize(10), WithContextCustomData(map[string]interface{}{"custom": "data"}))
+	r.Equal(nil, ctx.GetData("custom"))
+	_, err = ctx.BaseContextFile()
+	r.Error(err)
+	r.Contains(err.Error(), "exceeds the limit of 10 bytes")
+}
@@ -0,0 +1,106 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package process
+
+import (
+	"strings"
+
+	"github.com/kubevela/workflow/pkg/cue/model"
+)
+
+// RestrictAll allows all the data in the context except the secrets and their versions.
+const RestrictAll = "*"
+
+// Restrict returns a copy of the context which only contains the data at the allowed paths. The paths
+// are dot-separated like "name" or "parameter.image", and RestrictAll allows all the data. The secrets
+// inserted into the context and their versions are excluded unless their paths are allowed explicitly.
+func Restrict(ctx Context, allowed []string) Context {
+	all := map[string]interface{}{}
+	if tc, ok := ctx.(*templateContext); ok {
+		// the data is copied under the read lock since the parallel steps may push data meanwhile
+		tc.mu.RLock()
+		for k, v := range tc.data {
+			all[k] = copyData(v)
+		}
+		// the custom data overrides the built-in data as in BaseContextFile
+		for k, v := range tc.customData {
+			all[k] = copyData(v)
+		}
+		tc.mu.RUnlock()
+	} else {
+		for _, path := range allowed {
+			if key := strings.Split(path, ".")[0]; key != RestrictAll {
+				if v := ctx.GetData(key); v != nil {
+					all[key] = copyData(v)
+				}
+			}
+		}
+	}
+	secrets := map[string]bool{model.OutputSecretName: true, model.ContextSecretVersions: true}
+	if versions, ok := all[model.ContextSecretVersions].(map[string]interface{}); ok {
+		for name := range versions {
+			secrets[name] = true
+		}
+	}
+
+	data := map[string]interface{}{}
+	for _, path := range allowed {
+		if path == RestrictAll {
+			for k, v := range all {
+				if !secrets[k] {
+					data[k] = v
+				}
+			}
+		}
+	}
+	for _, path := range allowed {
+		if path == RestrictAll {
+			continue
+		}
+		segments := strings.Split(path, ".")
+		if v, ok := lookupData(all, segments); ok {
+			setData(data, segments, v)
+		}
+	}
+	return &templateContext{ctx: ctx.GetCtx(), data: data, auxiliaries: []Auxiliary{}}
+}
+
+func lookupData(data map[string]interface{}, segments []string) (interface{}, bool) {
+	var current interface{} = data
+	for _, segment := range segments {
+		m, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if current, ok = m[segment]; !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+func setData(data map[string]interface{}, segments []string, v interface{}) {
+	for _, segment := range segments[:len(segments)-1] {
+		next, ok := data[segment].(map[string]interface{})
+		if !ok {
+			next = map[string]interface{}{}
+			data[segment] = next
+		}
+		data = next
+	}
+	data[segments[len(segments)-1]] = v
+}
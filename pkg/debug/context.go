@@ -21,6 +21,8 @@ import (
 	"fmt"
 
 	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/token"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/types"
@@ -30,6 +32,15 @@ import (
 	wfTypes "github.com/kubevela/workflow/pkg/types"
 )
 
+const (
+	// SensitiveFieldName is the field marks the returns of the step as sensitive, the returns of the step are
+	// redacted in the debug context if the field is true
+	SensitiveFieldName = "#sensitive"
+
+	returnsFieldName = "$returns"
+	redacted         = "***"
+)
+
 // ContextImpl is workflow debug context interface
 type ContextImpl interface {
 	Set(v cue.Value) error
@@ -41,9 +52,9 @@ type Context struct {
 	id       string
 }
 
-// Set sets debug content into context
+// Set sets debug content into context, the returns marked as sensitive are redacted
 func (d *Context) Set(v cue.Value) error {
-	data, err := util.ToString(v)
+	data, err := util.ToString(redactSensitive(v))
 	if err != nil {
 		return err
 	}
@@ -55,6 +66,76 @@ func (d *Context) Set(v cue.Value) error {
 	return nil
 }
 
+// redactSensitive returns the value with the leaves of the sensitive returns redacted, the value is
+// returned as is if there is nothing to redact
+func redactSensitive(v cue.Value) cue.Value {
+	expr, ok := v.Syntax(cue.Final(), cue.Docs(true), cue.All()).(ast.Expr)
+	if !ok || !redactNode(expr) {
+		return v
+	}
+	return v.Context().BuildExpr(expr)
+}
+
+func redactNode(node ast.Node) bool {
+	found := false
+	ast.Walk(node, func(n ast.Node) bool {
+		s, ok := n.(*ast.StructLit)
+		if !ok || !isSensitive(s) {
+			return true
+		}
+		for _, elt := range s.Elts {
+			if f, ok := elt.(*ast.Field); ok && labelName(f.Label) == returnsFieldName {
+				f.Value = redactExpr(f.Value)
+				found = true
+			}
+		}
+		return true
+	}, nil)
+	return found
+}
+
+func isSensitive(s *ast.StructLit) bool {
+	for _, elt := range s.Elts {
+		if f, ok := elt.(*ast.Field); ok && labelName(f.Label) == SensitiveFieldName {
+			lit, ok := f.Value.(*ast.BasicLit)
+			return ok && lit.Kind == token.TRUE
+		}
+	}
+	return false
+}
+
+func redactExpr(expr ast.Expr) ast.Expr {
+	switch x := expr.(type) {
+	case *ast.StructLit:
+		for _, elt := range x.Elts {
+			if f, ok := elt.(*ast.Field); ok {
+				f.Value = redactExpr(f.Value)
+			}
+		}
+		return x
+	case *ast.ListLit:
+		for i, elt := range x.Elts {
+			x.Elts[i] = redactExpr(elt)
+		}
+		return x
+	case *ast.BasicLit:
+		if x.Kind == token.NULL {
+			return x
+		}
+		return ast.NewString(redacted)
+	default:
+		return x
+	}
+}
+
+func labelName(label ast.Label) string {
+	name, _, err := ast.LabelName(label)
+	if err != nil {
+		return ""
+	}
+	return name
+}
+
 func setStore(ctx context.Context, instance *wfTypes.WorkflowInstance, id, data string) error {
 	cm := &corev1.ConfigMap{}
 	cli := singleton.KubeClient.Get()
@@ -63,6 +63,49 @@ func TestSetContext(t *testing.T) {
 	r.NoError(err)
 }
 
+func TestSetContextRedactSensitive(t *testing.T) {
+	r := require.New(t)
+	var stored string
+	cli := &test.MockClient{
+		MockGet: func(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+			return kerrors.NewNotFound(corev1.Resource("configMap"), key.Name)
+		},
+		MockCreate: func(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+			stored = obj.(*corev1.ConfigMap).Data["debug"]
+			return nil
+		},
+	}
+	singleton.KubeClient.Set(cli)
+	cuectx := cuecontext.New()
+	debugCtx := NewContext(&types.WorkflowInstance{
+		WorkflowMeta: types.WorkflowMeta{
+			Name: "test",
+		},
+	}, "step1")
+	v := cuectx.CompileString(`
+secret: {
+	$params: kind: "Secret"
+	$returns: data: {
+		password: "secret-password"
+		nested: [{token: "secret-token"}]
+	}
+	#sensitive: true
+}
+config: {
+	$params: kind: "ConfigMap"
+	$returns: data: host: "example.com"
+	#sensitive: false
+}
+`)
+	r.NoError(debugCtx.Set(v))
+	r.NotContains(stored, "secret-password")
+	r.NotContains(stored, "secret-token")
+	r.Contains(stored, `password: "***"`)
+	r.Contains(stored, `token: "***"`)
+	r.Contains(stored, `kind: "Secret"`)
+	r.Contains(stored, `host: "example.com"`)
+}
+
 func newCliForTest(wfCm *corev1.ConfigMap) {
 	cli := &test.MockClient{
 		MockGet: func(ctx context.Context, key client.ObjectKey, obj client.Object) error {
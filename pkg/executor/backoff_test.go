@@ -0,0 +1,136 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package executor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kubevela/workflow/api/v1alpha1"
+	wfContext "github.com/kubevela/workflow/pkg/context"
+	"github.com/kubevela/workflow/pkg/types"
+)
+
+type backoffTimesContext struct {
+	wfContext.Context
+	times         map[string]int
+	pollIntervals map[string]time.Duration
+}
+
+func (c *backoffTimesContext) GetValueInMemory(paths ...string) (interface{}, bool) {
+	if len(paths) != 2 {
+		return nil, false
+	}
+	switch paths[0] {
+	case types.ContextPrefixBackoffTimes:
+		v, ok := c.times[paths[1]]
+		return v, ok
+	case types.ContextPrefixPollInterval:
+		v, ok := c.pollIntervals[paths[1]]
+		return v, ok
+	default:
+		return nil, false
+	}
+}
+
+func TestGetBackoffWaitTimeWithPollInterval(t *testing.T) {
+	r := require.New(t)
+	wfCtx := &backoffTimesContext{times: map[string]int{}}
+	running := func(id, name string) v1alpha1.WorkflowStepStatus {
+		return v1alpha1.WorkflowStepStatus{StepStatus: v1alpha1.StepStatus{ID: id, Name: name, Phase: v1alpha1.WorkflowStepPhaseRunning}}
+	}
+	e := &engine{
+		status: &v1alpha1.WorkflowRunStatus{Steps: []v1alpha1.WorkflowStepStatus{running("s1-id", "s1")}},
+		wfCtx:  wfCtx,
+		instance: &types.WorkflowInstance{Steps: []v1alpha1.WorkflowStep{{
+			WorkflowStepBase: v1alpha1.WorkflowStepBase{
+				Name:         "s1",
+				PollInterval: "5s",
+				PollBackoff:  &v1alpha1.PollBackoff{MaxInterval: "30s"},
+			},
+		}}},
+	}
+
+	// the step is not waiting yet
+	r.Equal(minWorkflowBackoffWaitTime, e.getBackoffWaitTime())
+	// the interval grows by the default factor and is limited by the max interval
+	for times, expected := range []int{5, 10, 20, 30, 30} {
+		wfCtx.times["s1-id"] = times
+		r.Equal(expected, e.getBackoffWaitTime(), "backoff times %d", times)
+	}
+
+	e.instance.Steps[0].PollBackoff = &v1alpha1.PollBackoff{Factor: 3}
+	for times, expected := range []int{5, 15, 45, types.MaxWorkflowWaitBackoffTime} {
+		wfCtx.times["s1-id"] = times
+		r.Equal(expected, e.getBackoffWaitTime(), "backoff times %d", times)
+	}
+
+	// the interval is fixed without poll backoff
+	e.instance.Steps[0].PollBackoff = nil
+	wfCtx.times["s1-id"] = 10
+	r.Equal(5, e.getBackoffWaitTime())
+
+	// the shortest interval of the waiting steps is used
+	e.instance.Steps = append(e.instance.Steps, v1alpha1.WorkflowStep{
+		WorkflowStepBase: v1alpha1.WorkflowStepBase{Name: "group"},
+		SubSteps:         []v1alpha1.WorkflowStepBase{{Name: "sub", PollInterval: "2s"}},
+	})
+	group := running("group-id", "group")
+	group.SubStepsStatus = []v1alpha1.StepStatus{group.StepStatus}
+	group.SubStepsStatus[0].ID, group.SubStepsStatus[0].Name = "sub-id", "sub"
+	e.status.Steps = append(e.status.Steps, group)
+	wfCtx.times["sub-id"] = 0
+	r.Equal(2, e.getBackoffWaitTime())
+
+	// the default backoff of other waiting steps is still honored
+	e.instance.Steps[1].SubSteps[0].PollInterval = "10m"
+	wfCtx.times["group-id"] = 8
+	r.Equal(5, e.getBackoffWaitTime())
+	e.instance.Steps[0].PollInterval = "1m"
+	r.Equal(12, e.getBackoffWaitTime())
+
+	// the poll interval is ignored for the pending steps
+	e.status.Steps[0].Phase = v1alpha1.WorkflowStepPhasePending
+	wfCtx.times["s1-id"] = 20
+	r.Equal(12, e.getBackoffWaitTime())
+}
+
+func TestGetBackoffWaitTimeWithProviderPollInterval(t *testing.T) {
+	r := require.New(t)
+	wfCtx := &backoffTimesContext{times: map[string]int{"s1-id": 3}, pollIntervals: map[string]time.Duration{}}
+	e := &engine{
+		status: &v1alpha1.WorkflowRunStatus{Steps: []v1alpha1.WorkflowStepStatus{{
+			StepStatus: v1alpha1.StepStatus{ID: "s1-id", Name: "s1", Phase: v1alpha1.WorkflowStepPhaseRunning},
+		}}},
+		wfCtx:    wfCtx,
+		instance: &types.WorkflowInstance{Steps: []v1alpha1.WorkflowStep{{WorkflowStepBase: v1alpha1.WorkflowStepBase{Name: "s1"}}}},
+	}
+
+	// the default backoff is used if the provider does not set the poll interval
+	r.Equal(minWorkflowBackoffWaitTime, e.getBackoffWaitTime())
+	wfCtx.pollIntervals["s1-id"] = 3 * time.Second
+	r.Equal(3, e.getBackoffWaitTime())
+	// the poll backoff of the step applies to the interval set by the provider
+	e.instance.Steps[0].PollBackoff = &v1alpha1.PollBackoff{MaxInterval: "20s"}
+	r.Equal(20, e.getBackoffWaitTime())
+	// the poll interval of the step takes precedence
+	e.instance.Steps[0].PollBackoff = nil
+	e.instance.Steps[0].PollInterval = "7s"
+	r.Equal(7, e.getBackoffWaitTime())
+}
@@ -0,0 +1,77 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package executor
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	monitorContext "github.com/kubevela/pkg/monitor/context"
+	"github.com/kubevela/pkg/util/singleton"
+
+	"github.com/kubevela/workflow/api/v1alpha1"
+	wfContext "github.com/kubevela/workflow/pkg/context"
+	"github.com/kubevela/workflow/pkg/types"
+	"github.com/kubevela/workflow/pkg/utils"
+)
+
+func TestExecuteRunnersWithContextSnapshotCodec(t *testing.T) {
+	r := require.New(t)
+	utils.SetKubeConfigForTest(t, &rest.Config{Host: "https://kube-api"})
+	cli := fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).Build()
+	singleton.KubeClient.Set(cli)
+	ctx := monitorContext.NewTraceContext(context.Background(), "test")
+	instance := &types.WorkflowInstance{
+		Steps: []v1alpha1.WorkflowStep{
+			{WorkflowStepBase: v1alpha1.WorkflowStepBase{Name: "s1", Type: "success"}},
+			{WorkflowStepBase: v1alpha1.WorkflowStepBase{Name: "s2", Type: "suspend"}},
+		},
+	}
+	instance.Name = "codec"
+	instance.Namespace = "default"
+	runners := []types.TaskRunner{makeRunner(instance.Steps[0], nil), makeRunner(instance.Steps[1], nil)}
+	defer wfContext.CleanupMemoryStore(instance.Name, instance.Namespace)
+
+	state, err := New(instance, WithContextSnapshotCodec(wfContext.SnapshotCodecGzipJSON)).ExecuteRunners(ctx, runners)
+	r.NoError(err)
+	r.Equal(v1alpha1.WorkflowStateSuspending, state)
+	r.NotNil(instance.Status.ContextBackend)
+	cm := &corev1.ConfigMap{}
+	r.NoError(cli.Get(ctx, client.ObjectKey{Namespace: instance.Namespace, Name: instance.Status.ContextBackend.Name}, cm))
+	r.NotContains(cm.Data, wfContext.ConfigMapKeyVars)
+	r.True(strings.HasPrefix(string(cm.BinaryData[wfContext.ConfigMapKeyVars]), "#codec=gzip+json\n"))
+
+	// the context persisted with the codec is loaded in the next reconcile
+	wfCtx, err := wfContext.LoadContext(ctx, instance.Namespace, instance.Name, instance.Status.ContextBackend.Name)
+	r.NoError(err)
+	v, err := wfCtx.GetVar("test")
+	r.NoError(err)
+	s, err := v.String()
+	r.NoError(err)
+	r.Equal("app", s)
+	state, err = New(instance, WithContextSnapshotCodec(wfContext.SnapshotCodecGzipJSON)).ExecuteRunners(ctx, runners)
+	r.NoError(err)
+	r.Equal(v1alpha1.WorkflowStateSuspending, state)
+}
@@ -0,0 +1,71 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package executor
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/kubevela/pkg/util/singleton"
+
+	"github.com/kubevela/workflow/api/v1alpha1"
+	wfContext "github.com/kubevela/workflow/pkg/context"
+	"github.com/kubevela/workflow/pkg/types"
+)
+
+func TestWorkflowMessage(t *testing.T) {
+	r := require.New(t)
+	singleton.KubeClient.Set(fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).Build())
+	ctx := context.Background()
+	wfCtx, err := wfContext.NewContext(ctx, "default", "message", nil)
+	r.NoError(err)
+	status := &v1alpha1.WorkflowRunStatus{}
+	e := &engine{status: status, wfCtx: wfCtx}
+
+	e.finishStep(&types.Operation{})
+	e.checkWorkflowStatusMessage()
+	r.Equal("", status.Message)
+
+	e.finishStep(&types.Operation{WorkflowMessage: "promoted to staging"})
+	e.checkWorkflowStatusMessage()
+	r.Equal("promoted to staging", status.Message)
+
+	// the message is kept in the following reconciles
+	e = &engine{status: status, wfCtx: wfCtx}
+	e.finishStep(&types.Operation{})
+	e.checkWorkflowStatusMessage()
+	r.Equal("promoted to staging", status.Message)
+
+	// the message is kept after the context is reloaded without the memory
+	r.NoError(wfCtx.Commit(ctx))
+	wfContext.CleanupMemoryStore("message", "default")
+	wfCtx, err = wfContext.LoadContext(ctx, "default", "message", wfCtx.StoreRef().Name)
+	r.NoError(err)
+	status = &v1alpha1.WorkflowRunStatus{}
+	e = &engine{status: status, wfCtx: wfCtx}
+	e.finishStep(&types.Operation{})
+	e.checkWorkflowStatusMessage()
+	r.Equal("promoted to staging", status.Message)
+
+	e.finishStep(&types.Operation{WorkflowMessage: "promoted to prod"})
+	e.checkWorkflowStatusMessage()
+	r.Equal("promoted to prod", status.Message)
+}
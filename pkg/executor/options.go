@@ -17,6 +17,7 @@ limitations under the License.
 package executor
 
 import (
+	providertypes "github.com/kubevela/workflow/pkg/providers/types"
 	"github.com/kubevela/workflow/pkg/types"
 )
 
@@ -37,3 +38,72 @@ func (w *withStatusPatcher) ApplyTo(e *workflowExecutor) {
 func WithStatusPatcher(patcher types.StatusPatcher) Option {
 	return &withStatusPatcher{patcher: patcher}
 }
+
+type withProviderClientRateLimit struct {
+	limit providertypes.ClientRateLimit
+}
+
+func (w *withProviderClientRateLimit) ApplyTo(e *workflowExecutor) {
+	e.clientRateLimit = w.limit
+}
+
+// WithProviderClientRateLimit set the qps and burst of the kube client used by providers,
+// which can be overridden by the annotations of the workflow run
+func WithProviderClientRateLimit(qps float32, burst int) Option {
+	return &withProviderClientRateLimit{limit: providertypes.ClientRateLimit{QPS: qps, Burst: burst}}
+}
+
+type withInterceptors struct {
+	interceptors []types.ProviderInterceptor
+}
+
+func (w *withInterceptors) ApplyTo(e *workflowExecutor) {
+	e.interceptors = append(e.interceptors, w.interceptors...)
+}
+
+// WithInterceptors add the interceptors around the provider calls of each step, the interceptors
+// are invoked in the order they are added
+func WithInterceptors(interceptors ...types.ProviderInterceptor) Option {
+	return &withInterceptors{interceptors: interceptors}
+}
+
+type withOutputSink struct {
+	sink types.OutputSink
+}
+
+func (w *withOutputSink) ApplyTo(e *workflowExecutor) {
+	e.outputSink = w.sink
+}
+
+// WithOutputSink set the sink which receives the outputs of each step once the step is finished
+func WithOutputSink(sink types.OutputSink) Option {
+	return &withOutputSink{sink: sink}
+}
+
+type withStepPool struct {
+	pool *StepPool
+}
+
+func (w *withStepPool) ApplyTo(e *workflowExecutor) {
+	e.stepPool = w.pool
+}
+
+// WithStepPool set the worker pool shared across the workflows to run the steps, the steps run in the
+// goroutine of the caller if not set
+func WithStepPool(pool *StepPool) Option {
+	return &withStepPool{pool: pool}
+}
+
+type withContextSnapshotCodec struct {
+	codec string
+}
+
+func (w *withContextSnapshotCodec) ApplyTo(e *workflowExecutor) {
+	e.snapshotCodec = w.codec
+}
+
+// WithContextSnapshotCodec set the codec to persist the vars of the workflow context as the encoded snapshot,
+// the vars are persisted as the cue string if not set
+func WithContextSnapshotCodec(codec string) Option {
+	return &withContextSnapshotCodec{codec: codec}
+}
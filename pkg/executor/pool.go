@@ -0,0 +1,192 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package executor
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"sync"
+
+	"k8s.io/klog/v2"
+)
+
+// StepPool is a bounded pool of workers shared across the workflows to execute the steps. The pending
+// steps are scheduled in a round-robin way across the workflows, so that a workflow with a large number
+// of steps cannot starve the others.
+type StepPool struct {
+	mu     sync.Mutex
+	cond   *sync.Cond
+	queues map[string][]*stepJob
+	// keys are the workflows with pending steps in the scheduling order
+	keys    []string
+	stopped bool
+	wg      sync.WaitGroup
+}
+
+type stepJob struct {
+	fn   func()
+	done chan struct{}
+	// canceled is set if the job is dropped by stopping the pool before it starts, protected by the lock of the pool
+	canceled bool
+	// err is the panic recovered from the fn, it is set before done is closed
+	err error
+}
+
+// NewStepPool creates the pool with the given number of workers, the workers run until the pool is stopped.
+func NewStepPool(size int) *StepPool {
+	if size <= 0 {
+		size = 1
+	}
+	p := &StepPool{queues: map[string][]*stepJob{}}
+	p.cond = sync.NewCond(&p.mu)
+	p.wg.Add(size)
+	for i := 0; i < size; i++ {
+		go p.work()
+	}
+	return p
+}
+
+// Run dispatches the fn of the workflow identified by the key to the pool and waits until it finishes. If
+// the ctx is canceled before the fn starts, the fn is dropped and the error of the ctx is returned. The fn
+// already started is waited for, the cancellation is observed by the fn itself. If the fn panics, the panic
+// is recovered in the worker and returned as the error.
+func (p *StepPool) Run(ctx context.Context, key string, fn func()) error {
+	job := &stepJob{fn: fn, done: make(chan struct{})}
+	p.mu.Lock()
+	if p.stopped {
+		p.mu.Unlock()
+		return context.Canceled
+	}
+	if len(p.queues[key]) == 0 {
+		p.keys = append(p.keys, key)
+	}
+	p.queues[key] = append(p.queues[key], job)
+	p.mu.Unlock()
+	p.cond.Signal()
+
+	select {
+	case <-job.done:
+		return p.result(job)
+	case <-ctx.Done():
+	}
+	p.mu.Lock()
+	pending := p.remove(key, job)
+	p.mu.Unlock()
+	if pending {
+		return ctx.Err()
+	}
+	<-job.done
+	return p.result(job)
+}
+
+func (p *StepPool) result(job *stepJob) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if job.canceled {
+		return context.Canceled
+	}
+	return job.err
+}
+
+// Stop stops the workers after the running steps finish, the pending steps are dropped.
+func (p *StepPool) Stop() {
+	p.mu.Lock()
+	p.stopped = true
+	for _, jobs := range p.queues {
+		for _, job := range jobs {
+			job.canceled = true
+			close(job.done)
+		}
+	}
+	p.queues, p.keys = map[string][]*stepJob{}, nil
+	p.mu.Unlock()
+	p.cond.Broadcast()
+	p.wg.Wait()
+}
+
+func (p *StepPool) work() {
+	defer p.wg.Done()
+	for {
+		p.mu.Lock()
+		for len(p.keys) == 0 && !p.stopped {
+			p.cond.Wait()
+		}
+		if p.stopped {
+			p.mu.Unlock()
+			return
+		}
+		job := p.next()
+		p.mu.Unlock()
+		job.err = execute(job.fn)
+		close(job.done)
+	}
+}
+
+// execute runs the fn and recovers its panic as the error, so that a panic in a provider does not crash
+// the worker and the controller with it
+func execute(fn func()) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			klog.ErrorS(nil, "Observed a panic in the step", "panic", r, "stacktrace", string(debug.Stack()))
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	fn()
+	return nil
+}
+
+// next pops the first pending step of the workflow at the head of the scheduling order, and moves the
+// workflow to the tail if it still has pending steps. It must be called with the lock held.
+func (p *StepPool) next() *stepJob {
+	key := p.keys[0]
+	p.keys = p.keys[1:]
+	jobs := p.queues[key]
+	job := jobs[0]
+	if len(jobs) == 1 {
+		delete(p.queues, key)
+	} else {
+		p.queues[key] = jobs[1:]
+		p.keys = append(p.keys, key)
+	}
+	return job
+}
+
+// remove removes the pending job, it returns false if the job is not pending. It must be called with the
+// lock held.
+func (p *StepPool) remove(key string, job *stepJob) bool {
+	jobs := p.queues[key]
+	for i, j := range jobs {
+		if j != job {
+			continue
+		}
+		jobs = append(jobs[:i:i], jobs[i+1:]...)
+		if len(jobs) > 0 {
+			p.queues[key] = jobs
+			return true
+		}
+		delete(p.queues, key)
+		for k, pending := range p.keys {
+			if pending == key {
+				p.keys = append(p.keys[:k:k], p.keys[k+1:]...)
+				break
+			}
+		}
+		return true
+	}
+	return false
+}
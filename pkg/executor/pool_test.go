@@ -0,0 +1,255 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package executor
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	monitorContext "github.com/kubevela/pkg/monitor/context"
+
+	"github.com/kubevela/workflow/api/v1alpha1"
+	wfContext "github.com/kubevela/workflow/pkg/context"
+	"github.com/kubevela/workflow/pkg/types"
+)
+
+func TestStepPoolBounded(t *testing.T) {
+	r := require.New(t)
+	pool := NewStepPool(3)
+	defer pool.Stop()
+
+	var running, maxRunning, finished int32
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			err := pool.Run(context.Background(), fmt.Sprintf("default/wr-%d", i%5), func() {
+				cur := atomic.AddInt32(&running, 1)
+				for {
+					max := atomic.LoadInt32(&maxRunning)
+					if cur <= max || atomic.CompareAndSwapInt32(&maxRunning, max, cur) {
+						break
+					}
+				}
+				time.Sleep(time.Millisecond)
+				atomic.AddInt32(&running, -1)
+				atomic.AddInt32(&finished, 1)
+			})
+			r.NoError(err)
+		}(i)
+	}
+	wg.Wait()
+	r.Equal(int32(50), finished)
+	r.LessOrEqual(maxRunning, int32(3))
+}
+
+func TestStepPoolFairness(t *testing.T) {
+	r := require.New(t)
+	pool := NewStepPool(1)
+	defer pool.Stop()
+
+	// block the only worker so that the steps below are queued
+	release := make(chan struct{})
+	started := make(chan struct{})
+	go func() {
+		_ = pool.Run(context.Background(), "default/blocker", func() {
+			close(started)
+			<-release
+		})
+	}()
+	<-started
+
+	var mu sync.Mutex
+	var order []string
+	var wg sync.WaitGroup
+	queued := 0
+	submit := func(key string) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.NoError(pool.Run(context.Background(), key, func() {
+				mu.Lock()
+				order = append(order, key)
+				mu.Unlock()
+			}))
+		}()
+		// wait until the step is queued to keep the submission order
+		r.Eventually(func() bool {
+			pool.mu.Lock()
+			defer pool.mu.Unlock()
+			n := 0
+			for _, jobs := range pool.queues {
+				n += len(jobs)
+			}
+			return n == queued+1
+		}, time.Second, time.Millisecond)
+		queued++
+	}
+	for i := 0; i < 3; i++ {
+		submit("default/large")
+	}
+	submit("default/small")
+	close(release)
+	wg.Wait()
+	r.Equal([]string{"default/large", "default/small", "default/large", "default/large"}, order)
+}
+
+func TestStepPoolCancel(t *testing.T) {
+	r := require.New(t)
+	pool := NewStepPool(1)
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	blockerDone := make(chan error)
+	go func() {
+		blockerDone <- pool.Run(context.Background(), "default/blocker", func() {
+			close(started)
+			<-release
+		})
+	}()
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	executed := false
+	err := pool.Run(ctx, "default/wr", func() { executed = true })
+	r.ErrorIs(err, context.DeadlineExceeded)
+	close(release)
+	r.NoError(<-blockerDone)
+	r.False(executed)
+	pool.mu.Lock()
+	r.Empty(pool.queues)
+	r.Empty(pool.keys)
+	pool.mu.Unlock()
+
+	pool.Stop()
+	r.ErrorIs(pool.Run(context.Background(), "default/wr", func() { executed = true }), context.Canceled)
+	r.False(executed)
+}
+
+func TestStepPoolStop(t *testing.T) {
+	r := require.New(t)
+	pool := NewStepPool(1)
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	go func() {
+		_ = pool.Run(context.Background(), "default/blocker", func() {
+			close(started)
+			<-release
+		})
+	}()
+	<-started
+	pending := make(chan error)
+	go func() {
+		pending <- pool.Run(context.Background(), "default/wr", func() {})
+	}()
+	r.Eventually(func() bool {
+		pool.mu.Lock()
+		defer pool.mu.Unlock()
+		return len(pool.queues["default/wr"]) == 1
+	}, time.Second, time.Millisecond)
+
+	stopped := make(chan struct{})
+	go func() {
+		pool.Stop()
+		close(stopped)
+	}()
+	r.ErrorIs(<-pending, context.Canceled)
+	close(release)
+	<-stopped
+}
+
+func TestStepPoolRecover(t *testing.T) {
+	r := require.New(t)
+	pool := NewStepPool(1)
+	defer pool.Stop()
+
+	err := pool.Run(context.Background(), "default/wr", func() { panic("nil map") })
+	r.EqualError(err, "panic: nil map")
+	// the worker survives the panic
+	executed := false
+	r.NoError(pool.Run(context.Background(), "default/wr", func() { executed = true }))
+	r.True(executed)
+}
+
+// poolTaskRunner is the task runner whose run is called by the engine
+type poolTaskRunner struct {
+	types.TaskRunner
+	name string
+	run  func() (v1alpha1.StepStatus, *types.Operation, error)
+}
+
+func (tr *poolTaskRunner) Name() string {
+	return tr.name
+}
+
+func (tr *poolTaskRunner) Run(wfContext.Context, *types.TaskRunOptions) (v1alpha1.StepStatus, *types.Operation, error) {
+	return tr.run()
+}
+
+func TestRunStepWithPool(t *testing.T) {
+	r := require.New(t)
+	pool := NewStepPool(1)
+	defer pool.Stop()
+	ctx := monitorContext.NewTraceContext(context.Background(), "test")
+	e := &engine{stepPool: pool, instance: &types.WorkflowInstance{}}
+	e.instance.Name, e.instance.Namespace = "wr", "default"
+
+	var running int32
+	status, operation, err := e.runStep(ctx, &poolTaskRunner{name: "apply", run: func() (v1alpha1.StepStatus, *types.Operation, error) {
+		atomic.AddInt32(&running, 1)
+		return v1alpha1.StepStatus{Name: "apply", Phase: v1alpha1.WorkflowStepPhaseSucceeded}, &types.Operation{}, nil
+	}}, &types.TaskRunOptions{})
+	r.NoError(err)
+	r.Equal(int32(1), atomic.LoadInt32(&running))
+	r.Equal(v1alpha1.WorkflowStepPhaseSucceeded, status.Phase)
+	r.NotNil(operation)
+
+	// the panic of the step is returned as the error of the step instead of crashing the controller
+	_, operation, err = e.runStep(ctx, &poolTaskRunner{name: "crash", run: func() (v1alpha1.StepStatus, *types.Operation, error) {
+		var m map[string]string
+		m["key"] = "value"
+		return v1alpha1.StepStatus{}, nil, nil
+	}}, &types.TaskRunOptions{})
+	r.EqualError(err, "run step crash: panic: assignment to entry in nil map")
+	r.Nil(operation)
+
+	_, _, err = e.runStep(ctx, &poolTaskRunner{name: "apply", run: func() (v1alpha1.StepStatus, *types.Operation, error) {
+		return v1alpha1.StepStatus{Phase: v1alpha1.WorkflowStepPhaseSucceeded}, &types.Operation{}, nil
+	}}, &types.TaskRunOptions{})
+	r.NoError(err)
+}
+
+func BenchmarkStepPool(b *testing.B) {
+	pool := NewStepPool(8)
+	defer pool.Stop()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			_ = pool.Run(context.Background(), fmt.Sprintf("default/wr-%d", i%16), func() {})
+			i++
+		}
+	})
+}
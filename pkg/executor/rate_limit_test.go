@@ -0,0 +1,97 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package executor
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/rest"
+
+	monitorContext "github.com/kubevela/pkg/monitor/context"
+
+	"github.com/kubevela/workflow/api/v1alpha1"
+	providertypes "github.com/kubevela/workflow/pkg/providers/types"
+	"github.com/kubevela/workflow/pkg/types"
+	"github.com/kubevela/workflow/pkg/utils"
+)
+
+func TestProviderClientRateLimit(t *testing.T) {
+	r := require.New(t)
+	utils.SetKubeConfigForTest(t, &rest.Config{Host: "https://kube-api", QPS: 20, Burst: 40})
+	ctx := monitorContext.NewTraceContext(context.Background(), "test")
+	limitWithError := func(qps float32, burst int, annotations map[string]string) (providertypes.ClientRateLimit, error) {
+		instance := &types.WorkflowInstance{}
+		instance.Annotations = annotations
+		w := New(instance, WithProviderClientRateLimit(qps, burst)).(*workflowExecutor)
+		return w.providerClientRateLimit(ctx)
+	}
+	limit := func(qps float32, burst int, annotations map[string]string) providertypes.ClientRateLimit {
+		l, err := limitWithError(qps, burst, annotations)
+		r.NoError(err)
+		return l
+	}
+
+	r.Equal(providertypes.ClientRateLimit{QPS: 50, Burst: 100}, limit(50, 100, nil))
+	// the annotations can lower the limit of the controller
+	r.Equal(providertypes.ClientRateLimit{QPS: 10, Burst: 20}, limit(50, 100, map[string]string{
+		types.AnnotationProviderKubeAPIQPS:   "10",
+		types.AnnotationProviderKubeAPIBurst: "20",
+	}))
+	// but cannot raise it
+	r.Equal(providertypes.ClientRateLimit{QPS: 50, Burst: 100}, limit(50, 100, map[string]string{
+		types.AnnotationProviderKubeAPIQPS:   "1000",
+		types.AnnotationProviderKubeAPIBurst: "2000",
+	}))
+	// the limit of the default kube client is used for the values not set by the controller
+	r.Equal(providertypes.ClientRateLimit{QPS: 50, Burst: 40}, limit(50, 0, map[string]string{
+		types.AnnotationProviderKubeAPIBurst: "2000",
+	}))
+	r.Equal(providertypes.ClientRateLimit{QPS: 20, Burst: 30}, limit(0, 0, map[string]string{
+		types.AnnotationProviderKubeAPIQPS:   "1000",
+		types.AnnotationProviderKubeAPIBurst: "30",
+	}))
+	// the invalid values are rejected
+	_, err := limitWithError(50, 100, map[string]string{types.AnnotationProviderKubeAPIQPS: "-1"})
+	r.EqualError(err, `invalid annotation `+types.AnnotationProviderKubeAPIQPS+` "-1": must be a positive number`)
+	_, err = limitWithError(50, 100, map[string]string{types.AnnotationProviderKubeAPIBurst: "many"})
+	r.EqualError(err, `invalid annotation `+types.AnnotationProviderKubeAPIBurst+` "many": must be a positive integer`)
+}
+
+func TestExecuteRunnersWithInvalidRateLimit(t *testing.T) {
+	r := require.New(t)
+	ctx := monitorContext.NewTraceContext(context.Background(), "test")
+	instance := &types.WorkflowInstance{
+		Steps: []v1alpha1.WorkflowStep{{WorkflowStepBase: v1alpha1.WorkflowStepBase{Name: "s1", Type: "success"}}},
+	}
+	instance.Annotations = map[string]string{types.AnnotationProviderKubeAPIQPS: "-1"}
+	runners := []types.TaskRunner{makeRunner(instance.Steps[0], nil)}
+	w := New(instance, WithProviderClientRateLimit(50, 100))
+
+	// the workflow fails at once instead of retrying the invalid annotation
+	state, err := w.ExecuteRunners(ctx, runners)
+	r.NoError(err)
+	r.Equal(v1alpha1.WorkflowStateFailed, state)
+	r.True(instance.Status.Terminated)
+	r.Equal(types.MessageInvalidProviderClientRateLimit+`: invalid annotation `+types.AnnotationProviderKubeAPIQPS+` "-1": must be a positive number`, instance.Status.Message)
+	r.Empty(instance.Status.Steps)
+
+	state, err = w.ExecuteRunners(ctx, runners)
+	r.NoError(err)
+	r.Equal(v1alpha1.WorkflowStateFailed, state)
+}
@@ -20,6 +20,7 @@ import (
 	"context"
 	"fmt"
 	"math"
+	"strconv"
 	"sync"
 	"time"
 
@@ -38,6 +39,8 @@ import (
 	"github.com/kubevela/workflow/pkg/hooks"
 	"github.com/kubevela/workflow/pkg/monitor/metrics"
 	"github.com/kubevela/workflow/pkg/providers/legacy/workspace"
+	providertypes "github.com/kubevela/workflow/pkg/providers/types"
+	"github.com/kubevela/workflow/pkg/sink"
 	"github.com/kubevela/workflow/pkg/tasks/custom"
 	"github.com/kubevela/workflow/pkg/types"
 )
@@ -54,17 +57,24 @@ const (
 	minWorkflowBackoffWaitTime = 1
 	// backoffTimeCoefficient is the coefficient of time to wait before reconcile workflow again
 	backoffTimeCoefficient = 0.05
+	// defaultPollBackoffFactor is the default multiplier of the poll interval of the step with poll backoff
+	defaultPollBackoffFactor = 2
 )
 
 type workflowExecutor struct {
-	instance *types.WorkflowInstance
-	wfCtx    wfContext.Context
-	patcher  types.StatusPatcher
+	instance        *types.WorkflowInstance
+	wfCtx           wfContext.Context
+	patcher         types.StatusPatcher
+	clientRateLimit providertypes.ClientRateLimit
+	interceptors    []types.ProviderInterceptor
+	outputSink      types.OutputSink
+	stepPool        *StepPool
+	snapshotCodec   string
 }
 
 // New returns a Workflow Executor implementation.
 func New(instance *types.WorkflowInstance, options ...Option) WorkflowExecutor {
-	executor := &workflowExecutor{instance: instance}
+	executor := &workflowExecutor{instance: instance, outputSink: sink.Noop{}}
 	for _, opt := range options {
 		opt.ApplyTo(executor)
 	}
@@ -114,6 +124,15 @@ func (w *workflowExecutor) ExecuteRunners(ctx monitorContext.Context, taskRunner
 		return v1alpha1.WorkflowStateFailed, nil
 	}
 
+	// the invalid annotations cannot be fixed by retrying, so the workflow is terminated at once
+	limit, err := w.providerClientRateLimit(ctx)
+	if err != nil {
+		ctx.Error(err, "invalid provider kube client rate limit")
+		status.Terminated = true
+		status.Message = fmt.Sprintf("%s: %s", types.MessageInvalidProviderClientRateLimit, err.Error())
+		return v1alpha1.WorkflowStateFailed, nil
+	}
+
 	wfCtx, err := w.makeContext(ctx, w.instance.Name)
 	if err != nil {
 		ctx.Error(err, "make context")
@@ -121,6 +140,15 @@ func (w *workflowExecutor) ExecuteRunners(ctx monitorContext.Context, taskRunner
 	}
 	w.wfCtx = wfCtx
 
+	if !limit.IsZero() {
+		cli, err := providertypes.KubeClientWithRateLimit(string(w.instance.UID), limit)
+		if err != nil {
+			ctx.Error(err, "make provider kube client")
+			return v1alpha1.WorkflowStateExecuting, err
+		}
+		ctx.SetContext(providertypes.WithKubeClient(ctx.GetContext(), cli))
+	}
+
 	if checkWorkflowSuspended(status) {
 		return v1alpha1.WorkflowStateSuspending, nil
 	}
@@ -209,6 +237,9 @@ func newEngine(ctx monitorContext.Context, wfCtx wfContext.Context, w *workflowE
 		stepTimeout:   make(map[string]time.Time),
 		taskRunners:   taskRunners,
 		statusPatcher: w.patcher,
+		interceptors:  w.interceptors,
+		outputSink:    w.outputSink,
+		stepPool:      w.stepPool,
 	}
 }
 
@@ -317,6 +348,53 @@ func checkRunners(taskRunners []types.TaskRunner, status v1alpha1.WorkflowRunSta
 	return true, success
 }
 
+// providerClientRateLimit returns the rate limit of the provider kube client. The annotations of the workflow
+// run can only lower the rate limit of the executor options, or the rate limit of the default kube client for
+// the values not set in the executor options, the higher values are capped. The annotations must be positive numbers.
+func (w *workflowExecutor) providerClientRateLimit(ctx monitorContext.Context) (providertypes.ClientRateLimit, error) {
+	limit := w.clientRateLimit
+	qpsValue, hasQPS := w.instance.Annotations[types.AnnotationProviderKubeAPIQPS]
+	burstValue, hasBurst := w.instance.Annotations[types.AnnotationProviderKubeAPIBurst]
+	if !hasQPS && !hasBurst {
+		return limit, nil
+	}
+	ceiling := limit
+	if ceiling.QPS <= 0 || ceiling.Burst <= 0 {
+		defaultLimit := providertypes.DefaultClientRateLimit()
+		if ceiling.QPS <= 0 {
+			ceiling.QPS = defaultLimit.QPS
+		}
+		if ceiling.Burst <= 0 {
+			ceiling.Burst = defaultLimit.Burst
+		}
+	}
+	if hasQPS {
+		qps, err := strconv.ParseFloat(qpsValue, 32)
+		switch {
+		case err != nil || qps <= 0:
+			return limit, fmt.Errorf("invalid annotation %s %q: must be a positive number", types.AnnotationProviderKubeAPIQPS, qpsValue)
+		case float32(qps) > ceiling.QPS:
+			ctx.Info("provider kube api qps exceeds the limit of the controller, the limit is used", "value", qpsValue, "limit", ceiling.QPS)
+			limit.QPS = ceiling.QPS
+		default:
+			limit.QPS = float32(qps)
+		}
+	}
+	if hasBurst {
+		burst, err := strconv.Atoi(burstValue)
+		switch {
+		case err != nil || burst <= 0:
+			return limit, fmt.Errorf("invalid annotation %s %q: must be a positive integer", types.AnnotationProviderKubeAPIBurst, burstValue)
+		case burst > ceiling.Burst:
+			ctx.Info("provider kube api burst exceeds the limit of the controller, the limit is used", "value", burstValue, "limit", ceiling.Burst)
+			limit.Burst = ceiling.Burst
+		default:
+			limit.Burst = burst
+		}
+	}
+	return limit, nil
+}
+
 func (w *workflowExecutor) makeContext(ctx context.Context, name string) (wfContext.Context, error) {
 	// clear the user info in context
 	ctx = request.WithUser(ctx, nil)
@@ -326,6 +404,7 @@ func (w *workflowExecutor) makeContext(ctx context.Context, name string) (wfCont
 		if err != nil {
 			return nil, errors.WithMessage(err, "load context")
 		}
+		w.setSnapshotCodec(wfCtx)
 		return wfCtx, nil
 	}
 
@@ -333,11 +412,18 @@ func (w *workflowExecutor) makeContext(ctx context.Context, name string) (wfCont
 	if err != nil {
 		return nil, errors.WithMessage(err, "new context")
 	}
+	w.setSnapshotCodec(wfCtx)
 
 	status.ContextBackend = wfCtx.StoreRef()
 	return wfCtx, nil
 }
 
+func (w *workflowExecutor) setSnapshotCodec(wfCtx wfContext.Context) {
+	if c, ok := wfCtx.(*wfContext.WorkflowContext); ok {
+		c.SetSnapshotCodec(w.snapshotCodec)
+	}
+}
+
 func (e *engine) getBackoffTimes(stepID string) int {
 	if v, ok := e.wfCtx.GetValueInMemory(types.ContextPrefixBackoffTimes, stepID); ok {
 		times, ok := v.(int)
@@ -352,40 +438,117 @@ func (e *engine) getBackoffWaitTime() int {
 	// the default value of min times reaches the max workflow backoff wait time
 	minTimes := 15
 	found := false
-	for _, step := range e.status.Steps {
-		if backoffTimes := e.getBackoffTimes(step.ID); backoffTimes > 0 {
+	// the min poll interval of the steps which declare their own poll interval
+	pollInterval := -1
+	handle := func(status v1alpha1.StepStatus) {
+		backoffTimes := e.getBackoffTimes(status.ID)
+		if interval, ok := e.getPollInterval(status, backoffTimes); ok {
+			if pollInterval < 0 || interval < pollInterval {
+				pollInterval = interval
+			}
+			return
+		}
+		if backoffTimes > 0 {
 			found = true
 			if backoffTimes < minTimes {
 				minTimes = backoffTimes
 			}
 		}
-		if step.SubStepsStatus != nil {
-			for _, subStep := range step.SubStepsStatus {
-				if backoffTimes := e.getBackoffTimes(subStep.ID); backoffTimes > 0 {
-					found = true
-					if backoffTimes < minTimes {
-						minTimes = backoffTimes
-					}
-				}
-			}
+	}
+	for _, step := range e.status.Steps {
+		handle(step.StepStatus)
+		for _, subStep := range step.SubStepsStatus {
+			handle(subStep)
 		}
 	}
 
 	if !found {
+		if pollInterval > 0 {
+			return pollInterval
+		}
 		return minWorkflowBackoffWaitTime
 	}
 
 	interval := int(math.Pow(2, float64(minTimes)) * backoffTimeCoefficient)
 	if interval < minWorkflowBackoffWaitTime {
-		return minWorkflowBackoffWaitTime
+		interval = minWorkflowBackoffWaitTime
 	}
 	maxWorkflowBackoffWaitTime := e.getMaxBackoffWaitTime()
 	if interval > maxWorkflowBackoffWaitTime {
-		return maxWorkflowBackoffWaitTime
+		interval = maxWorkflowBackoffWaitTime
+	}
+	if pollInterval > 0 && pollInterval < interval {
+		return pollInterval
 	}
 	return interval
 }
 
+// getPollInterval returns the poll interval in seconds of the waiting step which declares its own poll interval,
+// or whose provider sets the poll interval, the interval grows by the factor of the poll backoff after each poll.
+func (e *engine) getPollInterval(status v1alpha1.StepStatus, backoffTimes int) (int, bool) {
+	if backoffTimes < 0 || e.instance == nil || status.Phase != v1alpha1.WorkflowStepPhaseRunning {
+		return 0, false
+	}
+	step, ok := findStepBase(e.instance.Steps, status.Name)
+	if !ok {
+		return 0, false
+	}
+	var interval time.Duration
+	if v, ok := e.wfCtx.GetValueInMemory(types.ContextPrefixPollInterval, status.ID); ok {
+		interval, _ = v.(time.Duration)
+	}
+	if step.PollInterval != "" {
+		d, err := time.ParseDuration(step.PollInterval)
+		if err != nil || d <= 0 {
+			return 0, false
+		}
+		interval = d
+	}
+	if interval <= 0 {
+		return 0, false
+	}
+	if backoff := step.PollBackoff; backoff != nil {
+		factor := backoff.Factor
+		if factor <= 0 {
+			factor = defaultPollBackoffFactor
+		}
+		maxInterval := time.Duration(e.getMaxBackoffWaitTime()) * time.Second
+		if backoff.MaxInterval != "" {
+			if d, err := time.ParseDuration(backoff.MaxInterval); err == nil {
+				maxInterval = d
+			}
+		}
+		if maxInterval < interval {
+			maxInterval = interval
+		}
+		for i := 0; i < backoffTimes && interval < maxInterval; i++ {
+			interval *= time.Duration(factor)
+		}
+		if interval > maxInterval {
+			interval = maxInterval
+		}
+	}
+	seconds := int(math.Ceil(interval.Seconds()))
+	if seconds < minWorkflowBackoffWaitTime {
+		return minWorkflowBackoffWaitTime, true
+	}
+	return seconds, true
+}
+
+func findStepBase(steps []v1alpha1.WorkflowStep, name string) (v1alpha1.WorkflowStepBase, bool) {
+	for _, step := range steps {
+		if step.Name == name {
+			return step.WorkflowStepBase, true
+		}
+		for _, sub := range step.SubSteps {
+			if sub.Name == name {
+				return sub, true
+			}
+		}
+	}
+	return v1alpha1.WorkflowStepBase{}, false
+}
+
 func (e *engine) getMaxBackoffWaitTime() int {
 	for _, step := range e.status.Steps {
 		if step.Phase == v1alpha1.WorkflowStepPhaseFailed {
@@ -463,6 +626,11 @@ func (e *engine) runAsDAG(ctx monitorContext.Context, taskRunners []types.TaskRu
 				}
 				pendingTasks = append(pendingTasks, tRunner)
 				continue
+			} else if status.Phase == v1alpha1.WorkflowStepPhaseFailed {
+				if err := e.failPendingStep(ctx, status); err != nil {
+					return err
+				}
+				continue
 			} else if status.Phase == v1alpha1.WorkflowStepPhasePending {
 				wfCtx.DeleteValueInMemory(types.ContextPrefixBackoffTimes, stepID)
 			}
@@ -511,7 +679,8 @@ func (e *engine) checkWorkflowStatusMessage() {
 	case !e.waiting && e.failedAfterRetries && feature.DefaultMutableFeatureGate.Enabled(features.EnableSuspendOnFailure):
 		e.status.Message = types.MessageSuspendFailedAfterRetries
 	default:
-		e.status.Message = ""
+		// the message written by the steps is kept until it is overwritten by other steps
+		e.status.Message = e.wfCtx.GetMutableValue(types.ContextKeyWorkflowMessage)
 	}
 }
 
@@ -532,10 +701,18 @@ func (e *engine) steps(ctx monitorContext.Context, taskRunners []types.TaskRunne
 				continue
 			}
 			return nil
+		} else if status.Phase == v1alpha1.WorkflowStepPhaseFailed {
+			if err := e.failPendingStep(ctx, status); err != nil {
+				return err
+			}
+			if !dag && e.needStop() {
+				return nil
+			}
+			continue
 		}
 		options := e.generateRunOptions(ctx, e.findDependPhase(taskRunners, index, dag))
 
-		status, operation, err := runner.Run(wfCtx, options)
+		status, operation, err := e.runStep(ctx, runner, options)
 		if err != nil {
 			return err
 		}
@@ -546,6 +723,11 @@ func (e *engine) steps(ctx monitorContext.Context, taskRunners []types.TaskRunne
 			if err := e.updateStepStatus(ctx, status); err != nil {
 				return err
 			}
+			if operation != nil && operation.PollInterval > 0 {
+				wfCtx.SetValueInMemory(operation.PollInterval, types.ContextPrefixPollInterval, status.ID)
+			} else {
+				wfCtx.DeleteValueInMemory(types.ContextPrefixPollInterval, status.ID)
+			}
 			if err := handleBackoffTimes(ctx, wfCtx, status, false); err != nil {
 				return err
 			}
@@ -572,15 +754,41 @@ func (e *engine) steps(ctx monitorContext.Context, taskRunners []types.TaskRunne
 	return nil
 }
 
+// runStep runs the step on the step pool if configured. The sub-steps run in the worker of their parent
+// step, as waiting for the pool inside a worker may deadlock if all the workers are occupied.
+func (e *engine) runStep(ctx monitorContext.Context, runner types.TaskRunner, options *types.TaskRunOptions) (v1alpha1.StepStatus, *types.Operation, error) {
+	if e.stepPool == nil || e.parentRunner != "" {
+		return runner.Run(e.wfCtx, options)
+	}
+	var (
+		status    v1alpha1.StepStatus
+		operation *types.Operation
+		err       error
+	)
+	key := e.instance.Namespace + "/" + e.instance.Name
+	if poolErr := e.stepPool.Run(ctx, key, func() {
+		status, operation, err = runner.Run(e.wfCtx, options)
+	}); poolErr != nil {
+		return status, nil, errors.WithMessagef(poolErr, "run step %s", runner.Name())
+	}
+	return status, operation, err
+}
+
 func (e *engine) generateRunOptions(ctx monitorContext.Context, dependsOnPhase v1alpha1.WorkflowStepPhase) *types.TaskRunOptions {
 	options := &types.TaskRunOptions{
 		GetTracer: func(id string, stepStatus v1alpha1.WorkflowStep) monitorContext.Context {
 			return ctx.Fork(id, monitorContext.DurationMetric(func(v float64) {
-				metrics.WorkflowRunStepDurationHistogram.WithLabelValues("workflowrun", stepStatus.Type).Observe(v)
+				var annotations map[string]string
+				if stepStatus.Meta != nil {
+					annotations = stepStatus.Meta.Annotations
+				}
+				metrics.ObserveStepDuration("workflowrun", stepStatus.Type, annotations, v)
 			}))
 		},
-		StepStatus: e.stepStatus,
-		Engine:     e,
+		StepStatus:   e.stepStatus,
+		Engine:       e,
+		Interceptors: e.interceptors,
+		OutputSink:   e.outputSink,
 		PreCheckHooks: []types.TaskPreCheckHook{
 			func(step v1alpha1.WorkflowStep, options *types.PreCheckOptions) (*types.PreCheckResult, error) {
 				if feature.DefaultMutableFeatureGate.Enabled(features.EnableSuspendOnFailure) {
@@ -595,7 +803,14 @@ func (e *engine) generateRunOptions(ctx monitorContext.Context, dependsOnPhase v
 				case "always":
 					return &types.PreCheckResult{Skip: false}, nil
 				case "":
-					return &types.PreCheckResult{Skip: skipExecutionOfNextStep(dependsOnPhase, len(step.DependsOn) > 0)}, nil
+					if skipExecutionOfNextStep(dependsOnPhase, len(step.DependsOn) > 0) {
+						return &types.PreCheckResult{Skip: true}, nil
+					}
+					basicVal := cue.Value{}
+					if options != nil {
+						basicVal = options.BasicValue
+					}
+					return &types.PreCheckResult{Skip: e.conditionalDependsOnPhase(step, basicVal) != v1alpha1.WorkflowStepPhaseSucceeded}, nil
 				default:
 					basicVal := cue.Value{}
 					if options != nil {
@@ -656,6 +871,9 @@ type engine struct {
 	stepDependsOn      map[string][]string
 	taskRunners        []types.TaskRunner
 	statusPatcher      types.StatusPatcher
+	interceptors       []types.ProviderInterceptor
+	outputSink         types.OutputSink
+	stepPool           *StepPool
 }
 
 func (e *engine) finishStep(operation *types.Operation) {
@@ -664,6 +882,10 @@ func (e *engine) finishStep(operation *types.Operation) {
 		e.failedAfterRetries = e.failedAfterRetries || operation.FailedAfterRetries
 		e.waiting = e.waiting || operation.Waiting
 		e.suspending = e.suspending || operation.Suspend
+		if operation.WorkflowMessage != "" {
+			// the message is committed with the context so that it survives the restart of the controller
+			e.wfCtx.SetMutableValue(operation.WorkflowMessage, types.ContextKeyWorkflowMessage)
+		}
 	}
 	e.status.Suspend = e.suspending
 	if !e.waiting && e.failedAfterRetries && feature.DefaultMutableFeatureGate.Enabled(features.EnableSuspendOnFailure) {
@@ -674,6 +896,16 @@ func (e *engine) finishStep(operation *types.Operation) {
 	}
 }
 
+// failPendingStep records the step which can never leave the pending phase as failed, e.g. the condition of its
+// dependency is invalid, and terminates the workflow as the invalid inputs of the step do.
+func (e *engine) failPendingStep(ctx monitorContext.Context, status v1alpha1.StepStatus) error {
+	e.finishStep(&types.Operation{Terminated: true})
+	if err := handleBackoffTimes(ctx, e.wfCtx, status, true); err != nil {
+		return err
+	}
+	return e.updateStepStatus(ctx, status)
+}
+
 func (e *engine) updateStepStatus(ctx context.Context, status v1alpha1.StepStatus) error {
 	var (
 		conditionUpdated bool
@@ -806,6 +1038,23 @@ func (e *engine) findDependsOnPhase(name string) v1alpha1.WorkflowStepPhase {
 	return v1alpha1.WorkflowStepPhaseSucceeded
 }
 
+// conditionalDependsOnPhase returns the phase of the conditional dependencies whose conditions are satisfied,
+// the dependencies with unsatisfied or unresolvable conditions are ignored
+func (e *engine) conditionalDependsOnPhase(step v1alpha1.WorkflowStep, basicVal cue.Value) v1alpha1.WorkflowStepPhase {
+	for _, depend := range step.ConditionalDependsOn {
+		if active, err := custom.ValidateDependencyCondition(e.wfCtx, step, depend, e.stepStatus, basicVal); err != nil || !active {
+			continue
+		}
+		if e.stepStatus[depend.Name].Phase != v1alpha1.WorkflowStepPhaseSucceeded {
+			return e.stepStatus[depend.Name].Phase
+		}
+		if result := e.findDependsOnPhase(depend.Name); result != v1alpha1.WorkflowStepPhaseSucceeded {
+			return result
+		}
+	}
+	return v1alpha1.WorkflowStepPhaseSucceeded
+}
+
 // skipExecutionOfNextStep returns true if the next step should be skipped
 func skipExecutionOfNextStep(phase v1alpha1.WorkflowStepPhase, dependsOn bool) bool {
 	if dependsOn {
@@ -818,6 +1067,7 @@ func handleBackoffTimes(ctx context.Context, wfCtx wfContext.Context, status v1a
 	if clear {
 		wfCtx.DeleteValueInMemory(types.ContextPrefixBackoffTimes, status.ID)
 		wfCtx.DeleteValueInMemory(types.ContextPrefixBackoffReason, status.ID)
+		wfCtx.DeleteValueInMemory(types.ContextPrefixPollInterval, status.ID)
 	} else {
 		if val, exists := wfCtx.GetValueInMemory(types.ContextPrefixBackoffReason, status.ID); !exists || val != status.Message {
 			wfCtx.SetValueInMemory(status.Message, types.ContextPrefixBackoffReason, status.ID)
@@ -837,11 +1087,13 @@ func (e *engine) cleanBackoffTimesForTerminated() {
 			if sub.Reason == types.StatusReasonTerminate {
 				e.wfCtx.DeleteValueInMemory(types.ContextPrefixBackoffTimes, sub.ID)
 				e.wfCtx.DeleteValueInMemory(types.ContextPrefixBackoffReason, sub.ID)
+				e.wfCtx.DeleteValueInMemory(types.ContextPrefixPollInterval, sub.ID)
 			}
 		}
 		if ss.Reason == types.StatusReasonTerminate {
 			e.wfCtx.DeleteValueInMemory(types.ContextPrefixBackoffTimes, ss.ID)
 			e.wfCtx.DeleteValueInMemory(types.ContextPrefixBackoffReason, ss.ID)
+			e.wfCtx.DeleteValueInMemory(types.ContextPrefixPollInterval, ss.ID)
 		}
 	}
 }
@@ -1943,6 +1943,44 @@ var _ = Describe("Test Workflow", func() {
 		Expect(state).Should(BeEquivalentTo(v1alpha1.WorkflowStateTerminated))
 	})
 
+	It("test for invalid dependency condition", func() {
+		for _, mode := range []v1alpha1.WorkflowMode{v1alpha1.WorkflowModeStep, v1alpha1.WorkflowModeDAG} {
+			instance, runners := makeTestCase([]v1alpha1.WorkflowStep{
+				{
+					WorkflowStepBase: v1alpha1.WorkflowStepBase{
+						Name: "s1",
+						Type: "success",
+					},
+				},
+				{
+					WorkflowStepBase: v1alpha1.WorkflowStepBase{
+						Name:      "s2",
+						Type:      "invalid-condition",
+						DependsOn: []string{"s1"},
+					},
+				},
+			})
+			instance.Mode = &v1alpha1.WorkflowExecuteMode{Steps: mode}
+			ctx := monitorContext.NewTraceContext(context.Background(), "test-app")
+			wf := New(instance)
+			state, err := wf.ExecuteRunners(ctx, runners)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(state).Should(BeEquivalentTo(v1alpha1.WorkflowStateFailed))
+			Expect(instance.Status.Terminated).Should(BeTrue())
+			Expect(instance.Status.Steps).Should(HaveLen(2))
+			Expect(instance.Status.Steps).Should(ContainElement(HaveField("StepStatus", And(
+				HaveField("Name", "s2"),
+				HaveField("Phase", v1alpha1.WorkflowStepPhaseFailed),
+				HaveField("Reason", types.StatusReasonDependsOn),
+				HaveField("Message", "invalid condition"),
+			))))
+
+			state, err = wf.ExecuteRunners(ctx, runners)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(state).Should(BeEquivalentTo(v1alpha1.WorkflowStateFailed))
+		}
+	})
+
 	It("test for terminate with sub steps", func() {
 
 		By("Test terminate with step group")
@@ -2342,6 +2380,15 @@ func makeRunner(step v1alpha1.WorkflowStep, subTaskRunners []types.TaskRunner) t
 			}
 		},
 		checkPending: func(ctx monitorContext.Context, wfCtx wfContext.Context, stepStatus map[string]v1alpha1.StepStatus) (bool, v1alpha1.StepStatus) {
+			if step.Type == "invalid-condition" {
+				return false, v1alpha1.StepStatus{
+					Phase:   v1alpha1.WorkflowStepPhaseFailed,
+					Reason:  types.StatusReasonDependsOn,
+					Message: "invalid condition",
+					Name:    step.Name,
+					Type:    step.Type,
+				}
+			}
 			if step.Type != "pending" {
 				return false, v1alpha1.StepStatus{}
 			}
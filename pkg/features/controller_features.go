@@ -31,6 +31,8 @@ const (
 	EnablePatchStatusAtOnce featuregate.Feature = "EnablePatchStatusAtOnce"
 	// EnableWatchEventListener enable watch event listener
 	EnableWatchEventListener featuregate.Feature = "EnableWatchEventListener"
+	// EnableTracePropagation enable propagating the step trace context to outgoing provider requests
+	EnableTracePropagation featuregate.Feature = "EnableTracePropagation"
 )
 
 var defaultFeatureGates = map[featuregate.Feature]featuregate.FeatureSpec{
@@ -38,6 +40,7 @@ var defaultFeatureGates = map[featuregate.Feature]featuregate.FeatureSpec{
 	EnableBackupWorkflowRecord: {Default: false, PreRelease: featuregate.Alpha},
 	EnablePatchStatusAtOnce:    {Default: false, PreRelease: featuregate.Alpha},
 	EnableWatchEventListener:   {Default: false, PreRelease: featuregate.Alpha},
+	EnableTracePropagation:     {Default: false, PreRelease: featuregate.Alpha},
 }
 
 func init() {
@@ -20,6 +20,7 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"strings"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/utils/ptr"
@@ -29,6 +30,7 @@ import (
 	"github.com/kubevela/pkg/util/rand"
 
 	"github.com/kubevela/workflow/api/v1alpha1"
+	"github.com/kubevela/workflow/pkg/cue/model"
 	"github.com/kubevela/workflow/pkg/cue/process"
 	"github.com/kubevela/workflow/pkg/executor"
 	"github.com/kubevela/workflow/pkg/monitor/metrics"
@@ -225,5 +227,28 @@ func generateContextDataFromWorkflowRun(instance *types.WorkflowInstance) proces
 		Namespace:  instance.Namespace,
 		CustomData: instance.Context,
 	}
+	if parent := parentContextData(instance.Annotations); parent != nil {
+		data.Data = map[string]interface{}{model.ContextParent: parent}
+	}
 	return data
 }
+
+// parentContextData returns the parent info of a child run from the annotations set by the parent step
+func parentContextData(annotations map[string]string) map[string]interface{} {
+	ref, ok := annotations[types.AnnotationParentWorkflowRun]
+	if !ok || ref == "" {
+		return nil
+	}
+	parent := map[string]interface{}{model.ContextName: ref}
+	if namespace, name, ok := strings.Cut(ref, "/"); ok {
+		parent[model.ContextNamespace] = namespace
+		parent[model.ContextName] = name
+	}
+	if step := annotations[types.AnnotationParentStep]; step != "" {
+		parent[model.ContextStepName] = step
+	}
+	if spanID := annotations[types.AnnotationParentSpanID]; spanID != "" {
+		parent[model.ContextSpanID] = spanID
+	}
+	return parent
+}
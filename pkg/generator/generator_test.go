@@ -19,16 +19,21 @@ package generator
 import (
 	"context"
 	"strconv"
+	"testing"
 	"time"
 
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/cuecontext"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	"github.com/stretchr/testify/require"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	monitorContext "github.com/kubevela/pkg/monitor/context"
 
 	"github.com/kubevela/workflow/api/v1alpha1"
+	"github.com/kubevela/workflow/pkg/cue/process"
 	"github.com/kubevela/workflow/pkg/types"
 )
 
@@ -134,3 +139,28 @@ var _ = Describe("Test workflow step runner generator", func() {
 		Expect(runners[0].Name()).Should(BeEquivalentTo("step-1"))
 	})
 })
+
+func TestParentContext(t *testing.T) {
+	render := func(annotations map[string]string) string {
+		r := require.New(t)
+		instance := &types.WorkflowInstance{WorkflowMeta: types.WorkflowMeta{Name: "child", Namespace: "default", Annotations: annotations}}
+		c, err := process.NewContext(generateContextDataFromWorkflowRun(instance)).BaseContextFile()
+		r.NoError(err)
+		v := cuecontext.New().CompileString(c + `
+parent: *context.parent | "absent"
+`)
+		r.NoError(v.Err())
+		b, err := v.LookupPath(cue.ParsePath("parent")).MarshalJSON()
+		r.NoError(err)
+		return string(b)
+	}
+
+	require.Equal(t, `"absent"`, render(nil))
+	require.Equal(t, `"absent"`, render(map[string]string{types.AnnotationParentStep: "deploy"}))
+	require.Equal(t, `{"name":"parent","namespace":"ns","spanID":"trace.1","stepName":"deploy"}`, render(map[string]string{
+		types.AnnotationParentWorkflowRun: "ns/parent",
+		types.AnnotationParentStep:        "deploy",
+		types.AnnotationParentSpanID:      "trace.1",
+	}))
+	require.Equal(t, `{"name":"parent"}`, render(map[string]string{types.AnnotationParentWorkflowRun: "parent"}))
+}
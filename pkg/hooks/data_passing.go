@@ -72,6 +72,10 @@ func Output(ctx wfContext.Context, taskValue cue.Value, step v1alpha1.WorkflowSt
 			if err != nil || v.Err() != nil {
 				v = taskValue.Context().CompileString("null")
 			}
+			if v, err = wfContext.CompressVar(v); err != nil {
+				errMsg += fmt.Sprintf("failed to compress output %s: %s\n", output.Name, err.Error())
+				continue
+			}
 			if err := ctx.SetVar(v, output.Name); err != nil {
 				errMsg += fmt.Sprintf("failed to set output %s: %s\n", output.Name, err.Error())
 			}
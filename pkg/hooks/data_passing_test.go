@@ -18,6 +18,7 @@ package hooks
 
 import (
 	"context"
+	"strings"
 	"testing"
 
 	"cuelang.org/go/cue"
@@ -116,6 +117,47 @@ func TestOutput(t *testing.T) {
 	r.Equal(stepStatus["mystep"].Phase, v1alpha1.WorkflowStepPhaseSucceeded)
 }
 
+func TestCompressedOutput(t *testing.T) {
+	origin := wfContext.OutputCompressionThreshold
+	wfContext.OutputCompressionThreshold = 64
+	defer func() { wfContext.OutputCompressionThreshold = origin }()
+	wfCtx := mockContext(t)
+	r := require.New(t)
+	cuectx := cuecontext.New()
+	taskValue := cuectx.CompileString(`output: {
+	message: "` + strings.Repeat("x", 128) + `"
+	score:   99
+}`)
+	err := Output(wfCtx, taskValue, v1alpha1.WorkflowStep{
+		WorkflowStepBase: v1alpha1.WorkflowStepBase{
+			Outputs: v1alpha1.StepOutputs{
+				{ValueFrom: "output", Name: "large"},
+				{ValueFrom: "output.score", Name: "small"},
+			},
+		},
+	}, v1alpha1.StepStatus{Phase: v1alpha1.WorkflowStepPhaseSucceeded}, nil)
+	r.NoError(err)
+	large, err := wfCtx.GetVar("large")
+	r.NoError(err)
+	r.False(large.LookupPath(cue.ParsePath(`"$compressed"`)).Exists())
+
+	val, err := Input(wfCtx, cuectx.CompileString(`parameter: {}`), v1alpha1.WorkflowStep{
+		WorkflowStepBase: v1alpha1.WorkflowStepBase{
+			Inputs: v1alpha1.StepInputs{
+				{From: "large.score", ParameterKey: "score"},
+				{From: "small", ParameterKey: "small"},
+			},
+		},
+	})
+	r.NoError(err)
+	score, err := val.LookupPath(cue.ParsePath("parameter.score")).Int64()
+	r.NoError(err)
+	r.Equal(int64(99), score)
+	small, err := val.LookupPath(cue.ParsePath("parameter.small")).Int64()
+	r.NoError(err)
+	r.Equal(int64(99), small)
+}
+
 func mockContext(t *testing.T) wfContext.Context {
 	cli := &test.MockClient{
 		MockCreate: func(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
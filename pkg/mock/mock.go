@@ -16,12 +16,19 @@ limitations under the License.
 
 package mock
 
-import "github.com/kubevela/workflow/api/v1alpha1"
+import (
+	"time"
+
+	"github.com/kubevela/workflow/api/v1alpha1"
+)
 
 // Action ...
 type Action struct {
-	Phase string
-	Msg   string
+	Phase       string
+	Msg         string
+	Warnings    []string
+	WorkflowMsg string
+	Interval    time.Duration
 }
 
 // Suspend makes the step suspend
@@ -76,3 +83,20 @@ func (act *Action) Message(message string) {
 		act.Msg = message
 	}
 }
+
+// WorkflowMessage write message to workflow status
+func (act *Action) WorkflowMessage(message string) {
+	if message != "" {
+		act.WorkflowMsg = message
+	}
+}
+
+// Warn records the warning of the step
+func (act *Action) Warn(message string) {
+	act.Warnings = append(act.Warnings, message)
+}
+
+// PollInterval sets the interval to poll the step
+func (act *Action) PollInterval(interval time.Duration) {
+	act.Interval = interval
+}
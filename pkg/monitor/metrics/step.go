@@ -0,0 +1,96 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	velametrics "github.com/kubevela/pkg/monitor/metrics"
+)
+
+var (
+	// WorkflowRunStepLabeledDurationHistogram report the step execution duration with the labels mapped from the
+	// step annotations, it is only registered if the step label annotations are set.
+	WorkflowRunStepLabeledDurationHistogram *prometheus.HistogramVec
+
+	stepLabelAnnotations []stepLabelAnnotation
+)
+
+type stepLabelAnnotation struct {
+	annotation string
+	label      string
+}
+
+// SetStepLabelAnnotations maps the step annotations to the labels of the step metrics, the key is the
+// annotation and the value is the label name. Only the annotations in the allowlist are added as labels
+// to avoid high cardinality, the label value is empty if the step doesn't have the annotation.
+// It can only be called once before the workflow runs are reconciled.
+func SetStepLabelAnnotations(mappings map[string]string) error {
+	if WorkflowRunStepLabeledDurationHistogram != nil {
+		return fmt.Errorf("step label annotations have already been set")
+	}
+	labels := []string{"controller", "step_type"}
+	var annotations []stepLabelAnnotation
+	existing := map[string]bool{"controller": true, "step_type": true}
+	for anno, label := range mappings {
+		if !model.LabelName(label).IsValid() {
+			return fmt.Errorf("invalid label name %s for step annotation %s", label, anno)
+		}
+		if existing[label] {
+			return fmt.Errorf("duplicate label name %s for step annotation %s", label, anno)
+		}
+		existing[label] = true
+		annotations = append(annotations, stepLabelAnnotation{annotation: anno, label: label})
+	}
+	sort.Slice(annotations, func(i, j int) bool {
+		return annotations[i].label < annotations[j].label
+	})
+	for _, anno := range annotations {
+		labels = append(labels, anno.label)
+	}
+	histogram := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:        "workflowrun_step_labeled_duration_ms",
+		Help:        "workflow run step latency distributions with the labels from step annotations.",
+		Buckets:     velametrics.FineGrainedBuckets,
+		ConstLabels: prometheus.Labels{},
+	}, labels)
+	if err := metrics.Registry.Register(histogram); err != nil {
+		return err
+	}
+	WorkflowRunStepLabeledDurationHistogram = histogram
+	stepLabelAnnotations = annotations
+	return nil
+}
+
+// ObserveStepDuration records the duration of the step, the labeled duration is also recorded
+// with the labels mapped from the step annotations if configured.
+func ObserveStepDuration(controller, stepType string, annotations map[string]string, v float64) {
+	WorkflowRunStepDurationHistogram.WithLabelValues(controller, stepType).Observe(v)
+	if WorkflowRunStepLabeledDurationHistogram == nil {
+		return
+	}
+	values := []string{controller, stepType}
+	for _, anno := range stepLabelAnnotations {
+		values = append(values, annotations[anno.annotation])
+	}
+	WorkflowRunStepLabeledDurationHistogram.WithLabelValues(values...).Observe(v)
+}
@@ -0,0 +1,48 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+func TestObserveStepDurationWithLabels(t *testing.T) {
+	r := require.New(t)
+	r.Error(SetStepLabelAnnotations(map[string]string{"example.com/team": "invalid-label"}))
+	r.Error(SetStepLabelAnnotations(map[string]string{"example.com/team": "step_type"}))
+	r.NoError(SetStepLabelAnnotations(map[string]string{"example.com/team": "team"}))
+	r.Error(SetStepLabelAnnotations(map[string]string{"example.com/team": "team"}))
+
+	ObserveStepDuration("workflowrun", "apply", map[string]string{"example.com/team": "infra", "example.com/other": "ignored"}, 1)
+	families, err := metrics.Registry.Gather()
+	r.NoError(err)
+	var labels map[string]string
+	for _, family := range families {
+		if family.GetName() != "workflowrun_step_labeled_duration_ms" {
+			continue
+		}
+		r.Len(family.GetMetric(), 1)
+		labels = map[string]string{}
+		for _, label := range family.GetMetric()[0].GetLabel() {
+			labels[label.GetName()] = label.GetValue()
+		}
+	}
+	r.Equal(map[string]string{"controller": "workflowrun", "step_type": "apply", "team": "infra"}, labels)
+}
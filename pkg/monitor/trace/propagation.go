@@ -0,0 +1,104 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package trace
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"google.golang.org/grpc/metadata"
+	"k8s.io/apiserver/pkg/util/feature"
+
+	"github.com/kubevela/workflow/pkg/cue/model"
+	"github.com/kubevela/workflow/pkg/cue/process"
+	"github.com/kubevela/workflow/pkg/features"
+)
+
+const (
+	// TraceParentHeader is the W3C trace context header
+	TraceParentHeader = "traceparent"
+	// BaggageHeader is the W3C baggage header
+	BaggageHeader = "baggage"
+)
+
+// InjectHeaders sets the W3C trace context headers derived from the span of the step
+// if trace propagation is enabled. Headers set by the user are left untouched.
+func InjectHeaders(header http.Header, pCtx process.Context) {
+	if !feature.DefaultMutableFeatureGate.Enabled(features.EnableTracePropagation) || pCtx == nil {
+		return
+	}
+	for k, v := range Headers(pCtx) {
+		if header.Get(k) == "" {
+			header.Set(k, v)
+		}
+	}
+}
+
+// AppendToOutgoingContext appends the W3C trace context to the outgoing gRPC metadata of the context
+// if trace propagation is enabled. Metadata set by the user is left untouched.
+func AppendToOutgoingContext(ctx context.Context, pCtx process.Context) context.Context {
+	if !feature.DefaultMutableFeatureGate.Enabled(features.EnableTracePropagation) || pCtx == nil {
+		return ctx
+	}
+	md, _ := metadata.FromOutgoingContext(ctx)
+	for k, v := range Headers(pCtx) {
+		if len(md.Get(k)) == 0 {
+			ctx = metadata.AppendToOutgoingContext(ctx, k, v)
+		}
+	}
+	return ctx
+}
+
+// Headers returns the W3C trace context headers derived from the span of the step
+func Headers(pCtx process.Context) map[string]string {
+	spanID := getString(pCtx, model.ContextSpanID)
+	if spanID == "" {
+		return nil
+	}
+	// the span id of the step is formed as <trace id>.<child id>..., so the root segment identifies the trace
+	traceID := strings.SplitN(spanID, ".", 2)[0]
+	headers := map[string]string{
+		TraceParentHeader: fmt.Sprintf("00-%s-%s-01", hashHex(traceID, 16), hashHex(spanID, 8)),
+	}
+	var members []string
+	for _, key := range []string{model.ContextWorkflowName, model.ContextStepName, model.ContextStepSessionID} {
+		if v := getString(pCtx, key); v != "" {
+			members = append(members, fmt.Sprintf("%s=%s", key, url.QueryEscape(v)))
+		}
+	}
+	if len(members) > 0 {
+		headers[BaggageHeader] = strings.Join(members, ",")
+	}
+	return headers
+}
+
+func getString(pCtx process.Context, key string) string {
+	if v, ok := pCtx.GetData(key).(string); ok {
+		return v
+	}
+	return ""
+}
+
+func hashHex(s string, size int) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:size])
+}
@@ -0,0 +1,51 @@
+// cert.cue
+
+#Generate: {
+	#do:       "generate"
+	#provider: "cert"
+
+	$params: {
+		// +usage=The common name of the certificate
+		commonName?: string
+		// +usage=The organizations of the certificate
+		organization?: [...string]
+		// +usage=The DNS names in the subject alternative names of the certificate
+		dnsNames?: [...string]
+		// +usage=The IP addresses in the subject alternative names of the certificate
+		ipAddresses?: [...string]
+		// +usage=The type of the private key
+		keyType: *"RSA" | "ECDSA"
+		// +usage=The size of the private key, 2048, 3072 or 4096 for RSA and 256, 384 or 521 for ECDSA
+		keySize?: int
+		// +usage=The validity duration of the certificate, default to 8760h
+		duration?: string
+		// +usage=Whether the certificate is a CA certificate
+		isCA: *false | bool
+		// +usage=The secret which contains the tls.crt and tls.key of the CA, the certificate is self-signed if not specified
+		ca?: {
+			// +usage=The name of the secret
+			name: string
+			// +usage=The namespace of the secret, default to the namespace of the workflow
+			namespace?: string
+		}
+		// +usage=The TLS secret to store the certificate and the private key
+		secret: {
+			// +usage=The name of the secret
+			name: string
+			// +usage=The namespace of the secret, default to the namespace of the workflow
+			namespace?: string
+		}
+	}
+
+	$returns?: {
+		// +usage=The SHA-256 fingerprint of the certificate
+		fingerprint: string
+		// +usage=The serial number of the certificate in hex
+		serialNumber: string
+		// +usage=The start time of the validity of the certificate
+		notBefore: string
+		// +usage=The expiry time of the certificate
+		notAfter: string
+	}
+	...
+}
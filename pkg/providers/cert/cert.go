@@ -0,0 +1,322 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cert
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	_ "embed"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	cuexruntime "github.com/kubevela/pkg/cue/cuex/runtime"
+
+	"github.com/kubevela/workflow/pkg/cue/model"
+	providertypes "github.com/kubevela/workflow/pkg/providers/types"
+)
+
+const (
+	// ProviderName is provider name for install.
+	ProviderName = "cert"
+	// KeyTypeRSA is the RSA private key type.
+	KeyTypeRSA = "RSA"
+	// KeyTypeECDSA is the ECDSA private key type.
+	KeyTypeECDSA = "ECDSA"
+	// CAKey is the key of the CA certificate in the generated secret.
+	CAKey = "ca.crt"
+
+	defaultDuration = 365 * 24 * time.Hour
+)
+
+// SecretRef is the reference of a secret.
+type SecretRef struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// GenerateVars .
+type GenerateVars struct {
+	CommonName   string   `json:"commonName,omitempty"`
+	Organization []string `json:"organization,omitempty"`
+	DNSNames     []string `json:"dnsNames,omitempty"`
+	IPAddresses  []string `json:"ipAddresses,omitempty"`
+	KeyType      string   `json:"keyType,omitempty"`
+	KeySize      int      `json:"keySize,omitempty"`
+	Duration     string   `json:"duration,omitempty"`
+	IsCA         bool     `json:"isCA,omitempty"`
+	// CA is the secret which contains the tls.crt and tls.key of the CA, the certificate is self-signed if it is not set
+	CA     *SecretRef `json:"ca,omitempty"`
+	Secret SecretRef  `json:"secret"`
+}
+
+// GenerateReturnVars .
+type GenerateReturnVars struct {
+	Fingerprint  string `json:"fingerprint"`
+	SerialNumber string `json:"serialNumber"`
+	NotBefore    string `json:"notBefore"`
+	NotAfter     string `json:"notAfter"`
+}
+
+// GenerateParams .
+type GenerateParams = providertypes.Params[GenerateVars]
+
+// GenerateReturns .
+type GenerateReturns = providertypes.Returns[GenerateReturnVars]
+
+// Generate issues a self-signed or CA-signed certificate and stores the certificate and the private key
+// in the target TLS secret.
+func Generate(ctx context.Context, params *GenerateParams) (*GenerateReturns, error) {
+	vars := params.Params
+	defaultNamespace := fmt.Sprint(params.ProcessContext.GetData(model.ContextNamespace))
+	certTemplate, err := newTemplate(vars)
+	if err != nil {
+		return nil, err
+	}
+	key, err := generateKey(vars.KeyType, vars.KeySize)
+	if err != nil {
+		return nil, err
+	}
+
+	parent, signer, caPEM := certTemplate, crypto.Signer(key), []byte(nil)
+	if vars.CA != nil {
+		parent, signer, caPEM, err = loadCA(ctx, params.KubeClient, *vars.CA, defaultNamespace)
+		if err != nil {
+			return nil, err
+		}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, certTemplate, parent, key.Public(), signer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the certificate: %w", err)
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	data := map[string][]byte{
+		corev1.TLSCertKey:       pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		corev1.TLSPrivateKeyKey: pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}),
+	}
+	if caPEM != nil {
+		data[CAKey] = caPEM
+	}
+	if err := storeSecret(ctx, params.KubeClient, vars.Secret, defaultNamespace, params.Labels, data); err != nil {
+		return nil, err
+	}
+
+	fingerprint := sha256.Sum256(der)
+	return &GenerateReturns{
+		Returns: GenerateReturnVars{
+			Fingerprint:  hex.EncodeToString(fingerprint[:]),
+			SerialNumber: certTemplate.SerialNumber.Text(16),
+			NotBefore:    certTemplate.NotBefore.Format(time.RFC3339),
+			NotAfter:     certTemplate.NotAfter.Format(time.RFC3339),
+		},
+	}, nil
+}
+
+func newTemplate(vars GenerateVars) (*x509.Certificate, error) {
+	if vars.Secret.Name == "" {
+		return nil, fmt.Errorf("the name of the target secret is required")
+	}
+	if vars.CommonName == "" && len(vars.DNSNames) == 0 && len(vars.IPAddresses) == 0 {
+		return nil, fmt.Errorf("at least one of commonName, dnsNames and ipAddresses is required")
+	}
+	duration := defaultDuration
+	if vars.Duration != "" {
+		d, err := time.ParseDuration(vars.Duration)
+		if err != nil {
+			return nil, fmt.Errorf("invalid duration %s: %w", vars.Duration, err)
+		}
+		if d <= 0 {
+			return nil, fmt.Errorf("invalid duration %s: must be positive", vars.Duration)
+		}
+		duration = d
+	}
+	ips := make([]net.IP, 0, len(vars.IPAddresses))
+	for _, addr := range vars.IPAddresses {
+		ip := net.ParseIP(addr)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid ip address %s", addr)
+		}
+		ips = append(ips, ip)
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().Truncate(time.Second)
+	certTemplate := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: vars.CommonName, Organization: vars.Organization},
+		DNSNames:              vars.DNSNames,
+		IPAddresses:           ips,
+		NotBefore:             now,
+		NotAfter:              now.Add(duration),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  vars.IsCA,
+	}
+	if vars.IsCA {
+		certTemplate.KeyUsage |= x509.KeyUsageCertSign
+	}
+	return certTemplate, nil
+}
+
+func generateKey(keyType string, keySize int) (crypto.Signer, error) {
+	switch strings.ToUpper(keyType) {
+	case "", KeyTypeRSA:
+		switch keySize {
+		case 0:
+			keySize = 2048
+		case 2048, 3072, 4096:
+		default:
+			return nil, fmt.Errorf("invalid RSA key size %d, must be one of 2048, 3072 and 4096", keySize)
+		}
+		return rsa.GenerateKey(rand.Reader, keySize)
+	case KeyTypeECDSA:
+		var curve elliptic.Curve
+		switch keySize {
+		case 0, 256:
+			curve = elliptic.P256()
+		case 384:
+			curve = elliptic.P384()
+		case 521:
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("invalid ECDSA key size %d, must be one of 256, 384 and 521", keySize)
+		}
+		return ecdsa.GenerateKey(curve, rand.Reader)
+	default:
+		return nil, fmt.Errorf("unsupported key type %s, must be RSA or ECDSA", keyType)
+	}
+}
+
+// loadCA loads the CA certificate and private key from the tls.crt and tls.key of the secret.
+func loadCA(ctx context.Context, cli client.Client, ref SecretRef, defaultNamespace string) (*x509.Certificate, crypto.Signer, []byte, error) {
+	namespace := ref.Namespace
+	if namespace == "" {
+		namespace = defaultNamespace
+	}
+	secret := &corev1.Secret{}
+	if err := cli.Get(ctx, client.ObjectKey{Namespace: namespace, Name: ref.Name}, secret); err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to get the CA secret %s/%s: %w", namespace, ref.Name, err)
+	}
+	certBlock, _ := pem.Decode(secret.Data[corev1.TLSCertKey])
+	if certBlock == nil {
+		return nil, nil, nil, fmt.Errorf("no PEM encoded certificate found in %s of the CA secret %s/%s", corev1.TLSCertKey, namespace, ref.Name)
+	}
+	caCert, err := x509.ParseCertificate(certBlock.Bytes)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to parse the CA certificate: %w", err)
+	}
+	if !caCert.IsCA || caCert.KeyUsage&x509.KeyUsageCertSign == 0 {
+		return nil, nil, nil, fmt.Errorf("the certificate in the secret %s/%s is not a CA certificate", namespace, ref.Name)
+	}
+	keyBlock, _ := pem.Decode(secret.Data[corev1.TLSPrivateKeyKey])
+	if keyBlock == nil {
+		return nil, nil, nil, fmt.Errorf("no PEM encoded private key found in %s of the CA secret %s/%s", corev1.TLSPrivateKeyKey, namespace, ref.Name)
+	}
+	caKey, err := parsePrivateKey(keyBlock.Bytes)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to parse the CA private key: %w", err)
+	}
+	return caCert, caKey, pem.EncodeToMemory(certBlock), nil
+}
+
+func parsePrivateKey(der []byte) (crypto.Signer, error) {
+	if key, err := x509.ParsePKCS1PrivateKey(der); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParseECPrivateKey(der); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, err
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported private key type %T", key)
+	}
+	return signer, nil
+}
+
+func storeSecret(ctx context.Context, cli client.Client, ref SecretRef, defaultNamespace string, labels map[string]string, data map[string][]byte) error {
+	namespace := ref.Namespace
+	if namespace == "" {
+		namespace = defaultNamespace
+	}
+	secret := &corev1.Secret{}
+	err := cli.Get(ctx, client.ObjectKey{Namespace: namespace, Name: ref.Name}, secret)
+	if err != nil && !kerrors.IsNotFound(err) {
+		return err
+	}
+	exists := err == nil
+	secret.Name, secret.Namespace = ref.Name, namespace
+	if exists && secret.Type != corev1.SecretTypeTLS {
+		return fmt.Errorf("the secret %s/%s already exists with type %s", namespace, ref.Name, secret.Type)
+	}
+	secret.Type = corev1.SecretTypeTLS
+	secret.Data = data
+	if len(labels) > 0 && secret.Labels == nil {
+		secret.Labels = map[string]string{}
+	}
+	for k, v := range labels {
+		secret.Labels[k] = v
+	}
+	if exists {
+		err = cli.Update(ctx, secret)
+	} else {
+		err = cli.Create(ctx, secret)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to store the certificate in the secret %s/%s: %w", namespace, ref.Name, err)
+	}
+	return nil
+}
+
+//go:embed cert.cue
+var template string
+
+// GetTemplate returns the cue template.
+func GetTemplate() string {
+	return template
+}
+
+// GetProviders returns the cue providers.
+func GetProviders() map[string]cuexruntime.ProviderFn {
+	return map[string]cuexruntime.ProviderFn{
+		"generate": providertypes.GenericProviderFn[GenerateVars, GenerateReturns](Generate),
+	}
+}
@@ -0,0 +1,128 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cert
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/kubevela/workflow/pkg/cue/process"
+	providertypes "github.com/kubevela/workflow/pkg/providers/types"
+)
+
+func TestGenerate(t *testing.T) {
+	ctx := context.Background()
+	cli := fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).Build()
+	pCtx := process.NewContext(process.ContextData{Name: "run", Namespace: "default"})
+	generate := func(vars GenerateVars) (*GenerateReturns, error) {
+		return Generate(ctx, &GenerateParams{
+			Params:        vars,
+			RuntimeParams: providertypes.RuntimeParams{KubeClient: cli, ProcessContext: pCtx},
+		})
+	}
+	loadCert := func(t *testing.T, name string) (*x509.Certificate, *corev1.Secret) {
+		secret := &corev1.Secret{}
+		require.NoError(t, cli.Get(ctx, client.ObjectKey{Namespace: "default", Name: name}, secret))
+		require.Equal(t, corev1.SecretTypeTLS, secret.Type)
+		block, _ := pem.Decode(secret.Data[corev1.TLSCertKey])
+		require.NotNil(t, block)
+		cert, err := x509.ParseCertificate(block.Bytes)
+		require.NoError(t, err)
+		return cert, secret
+	}
+
+	t.Run("self-signed", func(t *testing.T) {
+		r := require.New(t)
+		res, err := generate(GenerateVars{
+			CommonName:  "web",
+			DNSNames:    []string{"web.default.svc", "web.example.com"},
+			IPAddresses: []string{"10.0.0.1"},
+			Duration:    "48h",
+			Secret:      SecretRef{Name: "web-tls"},
+		})
+		r.NoError(err)
+		cert, secret := loadCert(t, "web-tls")
+		r.Equal([]string{"web.default.svc", "web.example.com"}, cert.DNSNames)
+		r.True(cert.IPAddresses[0].Equal(net.ParseIP("10.0.0.1")))
+		r.Equal("web", cert.Subject.CommonName)
+		r.Equal(48*time.Hour, cert.NotAfter.Sub(cert.NotBefore))
+		r.Equal(cert.NotAfter.Format(time.RFC3339), res.Returns.NotAfter)
+		r.Len(res.Returns.Fingerprint, 64)
+		r.NoError(cert.CheckSignature(cert.SignatureAlgorithm, cert.RawTBSCertificate, cert.Signature))
+		_, isRSA := cert.PublicKey.(*rsa.PublicKey)
+		r.True(isRSA)
+		r.NotContains(secret.Data, CAKey)
+	})
+
+	t.Run("ca-signed", func(t *testing.T) {
+		r := require.New(t)
+		_, err := generate(GenerateVars{CommonName: "root", IsCA: true, KeyType: KeyTypeECDSA, Secret: SecretRef{Name: "root-ca"}})
+		r.NoError(err)
+		ca, _ := loadCert(t, "root-ca")
+		r.True(ca.IsCA)
+
+		_, err = generate(GenerateVars{
+			DNSNames: []string{"api.example.com"},
+			KeyType:  KeyTypeECDSA,
+			KeySize:  384,
+			CA:       &SecretRef{Name: "root-ca", Namespace: "default"},
+			Secret:   SecretRef{Name: "api-tls"},
+		})
+		r.NoError(err)
+		cert, secret := loadCert(t, "api-tls")
+		r.Equal([]string{"api.example.com"}, cert.DNSNames)
+		r.Equal(365*24*time.Hour, cert.NotAfter.Sub(cert.NotBefore))
+		_, isECDSA := cert.PublicKey.(*ecdsa.PublicKey)
+		r.True(isECDSA)
+		pool := x509.NewCertPool()
+		r.True(pool.AppendCertsFromPEM(secret.Data[CAKey]))
+		_, err = cert.Verify(x509.VerifyOptions{DNSName: "api.example.com", Roots: pool})
+		r.NoError(err)
+
+		_, err = generate(GenerateVars{CommonName: "leaf", CA: &SecretRef{Name: "api-tls"}, Secret: SecretRef{Name: "leaf-tls"}})
+		r.ErrorContains(err, "is not a CA certificate")
+	})
+
+	t.Run("invalid inputs", func(t *testing.T) {
+		for name, vars := range map[string]GenerateVars{
+			"no subject":     {Secret: SecretRef{Name: "invalid"}},
+			"no secret":      {CommonName: "web"},
+			"invalid ip":     {IPAddresses: []string{"10.0.0"}, Secret: SecretRef{Name: "invalid"}},
+			"invalid expiry": {CommonName: "web", Duration: "-1h", Secret: SecretRef{Name: "invalid"}},
+			"invalid size":   {CommonName: "web", KeySize: 1024, Secret: SecretRef{Name: "invalid"}},
+			"invalid type":   {CommonName: "web", KeyType: "DSA", Secret: SecretRef{Name: "invalid"}},
+			"missing ca":     {CommonName: "web", CA: &SecretRef{Name: "missing"}, Secret: SecretRef{Name: "invalid"}},
+		} {
+			t.Run(name, func(t *testing.T) {
+				_, err := generate(vars)
+				require.Error(t, err)
+			})
+		}
+	})
+}
@@ -0,0 +1,37 @@
+// cluster.cue
+
+#Info: {
+	#do:       "info"
+	#provider: "cluster"
+
+	$params: {
+		// +usage=The apis to check the availability of
+		apis?: [...{
+			apiVersion: string
+			kind:       string
+		}]
+		// +usage=The minimum required version of the api server, e.g. v1.24
+		minVersion?: string
+		// +usage=Whether to fail the step if the version is lower than the min version or any of the apis is unavailable
+		assert: *false | bool
+	}
+
+	$returns?: {
+		// +usage=The version of the api server
+		version: {
+			major:      string
+			minor:      string
+			gitVersion: string
+			platform?:  string
+		}
+		// +usage=The availability of the apis
+		apis: [...{
+			apiVersion: string
+			kind:       string
+			available:  bool
+		}]
+		// +usage=Whether the version is not lower than the min version and all the apis are available
+		satisfied: bool
+	}
+	...
+}
@@ -0,0 +1,179 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"strings"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/version"
+	"k8s.io/client-go/discovery"
+
+	cuexruntime "github.com/kubevela/pkg/cue/cuex/runtime"
+	"github.com/kubevela/pkg/util/singleton"
+
+	"github.com/kubevela/workflow/pkg/errors"
+	providertypes "github.com/kubevela/workflow/pkg/providers/types"
+)
+
+const (
+	// ProviderName is provider name for install.
+	ProviderName = "cluster"
+)
+
+var newDiscoveryClient = func() discovery.DiscoveryInterface {
+	return singleton.StaticClient.Get().Discovery()
+}
+
+// APIQuery is the api to check the availability of.
+type APIQuery struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+}
+
+// InfoVars .
+type InfoVars struct {
+	APIs []APIQuery `json:"apis,omitempty"`
+	// MinVersion is the minimum required version of the api server, e.g. v1.24
+	MinVersion string `json:"minVersion,omitempty"`
+	// Assert fails the step if the version is lower than the min version or any of the apis is unavailable
+	Assert bool `json:"assert,omitempty"`
+}
+
+// ServerVersion is the version of the api server.
+type ServerVersion struct {
+	Major      string `json:"major"`
+	Minor      string `json:"minor"`
+	GitVersion string `json:"gitVersion"`
+	Platform   string `json:"platform,omitempty"`
+}
+
+// APIAvailability is the availability of the api.
+type APIAvailability struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Available  bool   `json:"available"`
+}
+
+// InfoReturnVars .
+type InfoReturnVars struct {
+	Version ServerVersion     `json:"version"`
+	APIs    []APIAvailability `json:"apis"`
+	// Satisfied is true if the version is not lower than the min version and all the apis are available
+	Satisfied bool `json:"satisfied"`
+}
+
+// InfoParams .
+type InfoParams = providertypes.Params[InfoVars]
+
+// InfoReturns .
+type InfoReturns = providertypes.Returns[InfoReturnVars]
+
+// Info returns the version of the api server and the availability of the apis.
+func Info(_ context.Context, params *InfoParams) (*InfoReturns, error) {
+	vars := params.Params
+	var minVersion *version.Version
+	if vars.MinVersion != "" {
+		v, err := version.ParseGeneric(vars.MinVersion)
+		if err != nil {
+			return nil, fmt.Errorf("invalid min version %s: %w", vars.MinVersion, err)
+		}
+		minVersion = v
+	}
+	cli := newDiscoveryClient()
+	info, err := cli.ServerVersion()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the server version: %w", err)
+	}
+	returns := InfoReturnVars{
+		Version: ServerVersion{
+			Major:      info.Major,
+			Minor:      info.Minor,
+			GitVersion: info.GitVersion,
+			Platform:   info.Platform,
+		},
+		APIs:      make([]APIAvailability, 0, len(vars.APIs)),
+		Satisfied: true,
+	}
+	var unsatisfied []string
+	if minVersion != nil {
+		serverVersion, err := version.ParseGeneric(info.GitVersion)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse the server version %s: %w", info.GitVersion, err)
+		}
+		if !serverVersion.AtLeast(minVersion) {
+			unsatisfied = append(unsatisfied, fmt.Sprintf("the server version %s is lower than %s", info.GitVersion, vars.MinVersion))
+		}
+	}
+	for _, api := range vars.APIs {
+		available, err := isAPIAvailable(cli, api)
+		if err != nil {
+			return nil, err
+		}
+		returns.APIs = append(returns.APIs, APIAvailability{APIVersion: api.APIVersion, Kind: api.Kind, Available: available})
+		if !available {
+			unsatisfied = append(unsatisfied, fmt.Sprintf("the api %s %s is unavailable", api.APIVersion, api.Kind))
+		}
+	}
+	if len(unsatisfied) > 0 {
+		returns.Satisfied = false
+		if vars.Assert {
+			params.Action.Fail(fmt.Sprintf("The cluster does not satisfy the requirements: %s", strings.Join(unsatisfied, ", ")))
+			return nil, errors.GenericActionError(errors.ActionTerminate)
+		}
+	}
+	return &InfoReturns{Returns: returns}, nil
+}
+
+func isAPIAvailable(cli discovery.DiscoveryInterface, api APIQuery) (bool, error) {
+	gv, err := schema.ParseGroupVersion(api.APIVersion)
+	if err != nil {
+		return false, fmt.Errorf("invalid api version %s: %w", api.APIVersion, err)
+	}
+	resources, err := cli.ServerResourcesForGroupVersion(gv.String())
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to discover the resources of %s: %w", gv.String(), err)
+	}
+	for _, res := range resources.APIResources {
+		if res.Kind == api.Kind && !strings.Contains(res.Name, "/") {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+//go:embed cluster.cue
+var template string
+
+// GetTemplate returns the cue template.
+func GetTemplate() string {
+	return template
+}
+
+// GetProviders returns the cue providers.
+func GetProviders() map[string]cuexruntime.ProviderFn {
+	return map[string]cuexruntime.ProviderFn{
+		"info": providertypes.GenericProviderFn[InfoVars, InfoReturns](Info),
+	}
+}
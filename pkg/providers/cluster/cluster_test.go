@@ -0,0 +1,94 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/version"
+	"k8s.io/client-go/discovery"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	clienttesting "k8s.io/client-go/testing"
+
+	"github.com/kubevela/workflow/pkg/errors"
+	"github.com/kubevela/workflow/pkg/mock"
+	providertypes "github.com/kubevela/workflow/pkg/providers/types"
+)
+
+func TestInfo(t *testing.T) {
+	fake := &fakediscovery.FakeDiscovery{
+		Fake: &clienttesting.Fake{Resources: []*metav1.APIResourceList{{
+			GroupVersion: "apps/v1",
+			APIResources: []metav1.APIResource{
+				{Name: "deployments", Kind: "Deployment"},
+				{Name: "deployments/scale", Kind: "Scale"},
+			},
+		}}},
+		FakedServerVersion: &version.Info{Major: "1", Minor: "28", GitVersion: "v1.28.3", Platform: "linux/amd64"},
+	}
+	origin := newDiscoveryClient
+	newDiscoveryClient = func() discovery.DiscoveryInterface { return fake }
+	defer func() { newDiscoveryClient = origin }()
+	info := func(act *mock.Action, vars InfoVars) (*InfoReturns, error) {
+		return Info(context.Background(), &InfoParams{Params: vars, RuntimeParams: providertypes.RuntimeParams{Action: act}})
+	}
+
+	t.Run("present and absent apis", func(t *testing.T) {
+		r := require.New(t)
+		res, err := info(&mock.Action{}, InfoVars{
+			APIs: []APIQuery{
+				{APIVersion: "apps/v1", Kind: "Deployment"},
+				{APIVersion: "apps/v1", Kind: "Scale"},
+				{APIVersion: "batch/v1", Kind: "CronJob"},
+			},
+			MinVersion: "v1.24",
+		})
+		r.NoError(err)
+		r.Equal(ServerVersion{Major: "1", Minor: "28", GitVersion: "v1.28.3", Platform: "linux/amd64"}, res.Returns.Version)
+		r.Equal([]APIAvailability{
+			{APIVersion: "apps/v1", Kind: "Deployment", Available: true},
+			{APIVersion: "apps/v1", Kind: "Scale", Available: false},
+			{APIVersion: "batch/v1", Kind: "CronJob", Available: false},
+		}, res.Returns.APIs)
+		r.False(res.Returns.Satisfied)
+	})
+
+	t.Run("satisfied", func(t *testing.T) {
+		r := require.New(t)
+		res, err := info(&mock.Action{}, InfoVars{APIs: []APIQuery{{APIVersion: "apps/v1", Kind: "Deployment"}}, MinVersion: "1.28", Assert: true})
+		r.NoError(err)
+		r.True(res.Returns.Satisfied)
+	})
+
+	t.Run("assert", func(t *testing.T) {
+		r := require.New(t)
+		act := &mock.Action{}
+		_, err := info(act, InfoVars{APIs: []APIQuery{{APIVersion: "batch/v1", Kind: "CronJob"}}, MinVersion: "v1.30", Assert: true})
+		r.Equal(errors.GenericActionError(errors.ActionTerminate), err)
+		r.Equal("The cluster does not satisfy the requirements: the server version v1.28.3 is lower than v1.30, the api batch/v1 CronJob is unavailable", act.Msg)
+	})
+
+	t.Run("invalid inputs", func(t *testing.T) {
+		_, err := info(&mock.Action{}, InfoVars{MinVersion: "latest"})
+		require.Error(t, err)
+		_, err = info(&mock.Action{}, InfoVars{APIs: []APIQuery{{APIVersion: "a/b/c", Kind: "Foo"}}})
+		require.Error(t, err)
+	})
+}
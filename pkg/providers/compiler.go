@@ -19,21 +19,44 @@ package providers
 import (
 	"context"
 
+	"github.com/kubevela/pkg/apis/cue/v1alpha1"
 	"github.com/kubevela/pkg/cue/cuex"
 	cuexruntime "github.com/kubevela/pkg/cue/cuex/runtime"
+	"github.com/kubevela/pkg/util/k8s"
 	"github.com/kubevela/pkg/util/runtime"
 	"github.com/kubevela/pkg/util/singleton"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/tools/cache"
 	"k8s.io/klog/v2"
 
 	"github.com/kubevela/workflow/pkg/providers/builtin"
+	"github.com/kubevela/workflow/pkg/providers/cert"
+	"github.com/kubevela/workflow/pkg/providers/cluster"
+	"github.com/kubevela/workflow/pkg/providers/config"
+	"github.com/kubevela/workflow/pkg/providers/cost"
+	"github.com/kubevela/workflow/pkg/providers/db"
 	"github.com/kubevela/workflow/pkg/providers/email"
+	"github.com/kubevela/workflow/pkg/providers/freeze"
+	"github.com/kubevela/workflow/pkg/providers/git"
+	"github.com/kubevela/workflow/pkg/providers/grpc"
 	"github.com/kubevela/workflow/pkg/providers/http"
+	"github.com/kubevela/workflow/pkg/providers/image"
+	"github.com/kubevela/workflow/pkg/providers/jq"
+	"github.com/kubevela/workflow/pkg/providers/jsonschema"
 	"github.com/kubevela/workflow/pkg/providers/kube"
 	"github.com/kubevela/workflow/pkg/providers/legacy"
+	"github.com/kubevela/workflow/pkg/providers/message"
 	"github.com/kubevela/workflow/pkg/providers/metrics"
+	"github.com/kubevela/workflow/pkg/providers/multicluster"
+	"github.com/kubevela/workflow/pkg/providers/objectstorage"
+	"github.com/kubevela/workflow/pkg/providers/slack"
 	"github.com/kubevela/workflow/pkg/providers/time"
+	providertypes "github.com/kubevela/workflow/pkg/providers/types"
 	"github.com/kubevela/workflow/pkg/providers/util"
+	"github.com/kubevela/workflow/pkg/providers/webhook"
 )
 
 const (
@@ -54,12 +77,28 @@ var compiler = singleton.NewSingletonE[*cuex.Compiler](func() (*cuex.Compiler, e
 		runtime.Must(cuexruntime.NewInternalPackage(LegacyProviderName, legacy.GetLegacyTemplate(), legacy.GetLegacyProviders())),
 
 		// internal packages
+		runtime.Must(cuexruntime.NewInternalPackage("cert", cert.GetTemplate(), cert.GetProviders())),
+		runtime.Must(cuexruntime.NewInternalPackage("cluster", cluster.GetTemplate(), cluster.GetProviders())),
+		runtime.Must(cuexruntime.NewInternalPackage("config", config.GetTemplate(), config.GetProviders())),
+		runtime.Must(cuexruntime.NewInternalPackage("cost", cost.GetTemplate(), cost.GetProviders())),
+		runtime.Must(cuexruntime.NewInternalPackage("db", db.GetTemplate(), db.GetProviders())),
 		runtime.Must(cuexruntime.NewInternalPackage("email", email.GetTemplate(), email.GetProviders())),
+		runtime.Must(cuexruntime.NewInternalPackage("freeze", freeze.GetTemplate(), freeze.GetProviders())),
+		runtime.Must(cuexruntime.NewInternalPackage("git", git.GetTemplate(), git.GetProviders())),
+		runtime.Must(cuexruntime.NewInternalPackage("grpc", grpc.GetTemplate(), grpc.GetProviders())),
 		runtime.Must(cuexruntime.NewInternalPackage("http", http.GetTemplate(), http.GetProviders())),
+		runtime.Must(cuexruntime.NewInternalPackage("image", image.GetTemplate(), image.GetProviders())),
+		runtime.Must(cuexruntime.NewInternalPackage("jq", jq.GetTemplate(), jq.GetProviders())),
+		runtime.Must(cuexruntime.NewInternalPackage("jsonschema", jsonschema.GetTemplate(), jsonschema.GetProviders())),
 		runtime.Must(cuexruntime.NewInternalPackage("kube", kube.GetTemplate(), kube.GetProviders())),
+		runtime.Must(cuexruntime.NewInternalPackage("message", message.GetTemplate(), message.GetProviders())),
 		runtime.Must(cuexruntime.NewInternalPackage("metrics", metrics.GetTemplate(), metrics.GetProviders())),
+		runtime.Must(cuexruntime.NewInternalPackage("multicluster", multicluster.GetTemplate(), multicluster.GetProviders())),
+		runtime.Must(cuexruntime.NewInternalPackage("objectstorage", objectstorage.GetTemplate(), objectstorage.GetProviders())),
+		runtime.Must(cuexruntime.NewInternalPackage("slack", slack.GetTemplate(), slack.GetProviders())),
 		runtime.Must(cuexruntime.NewInternalPackage("time", time.GetTemplate(), time.GetProviders())),
 		runtime.Must(cuexruntime.NewInternalPackage("util", util.GetTemplate(), util.GetProviders())),
+		runtime.Must(cuexruntime.NewInternalPackage("webhook", webhook.GetTemplate(), webhook.GetProviders())),
 		runtime.Must(cuexruntime.NewInternalPackage("builtin", builtin.GetTemplate(), builtin.GetProviders())),
 	), nil
 })
@@ -71,9 +110,80 @@ var DefaultCompiler = singleton.NewSingleton[*cuex.Compiler](func() *cuex.Compil
 		if err := c.LoadExternalPackages(context.Background()); err != nil && !kerrors.IsNotFound(err) {
 			klog.Errorf("failed to load external packages for cuex default compiler: %s", err.Error())
 		}
+		interceptExternalPackages(c)
 	}
 	if EnableExternalPackageWatchForDefaultCompiler {
-		go c.ListenExternalPackages(nil)
+		go listenExternalPackages(c)
 	}
 	return c
 })
+
+// interceptedPackage is the external package whose provider functions are called through the interceptors
+type interceptedPackage struct {
+	cuexruntime.Package
+}
+
+// GetProviderFn returns the provider function which is called through the interceptors
+func (in interceptedPackage) GetProviderFn(do string) cuexruntime.ProviderFn {
+	fn := in.Package.GetProviderFn(do)
+	if fn == nil {
+		return nil
+	}
+	return providertypes.ExternalProviderFn{ProviderFn: fn}
+}
+
+// interceptExternalPackages wraps the loaded external packages so that their provider functions are called
+// through the interceptors
+func interceptExternalPackages(c *cuex.Compiler) {
+	for id, pkg := range c.Externals.Data() {
+		if _, ok := pkg.(interceptedPackage); !ok {
+			c.Externals.Set(id, interceptedPackage{Package: pkg})
+		}
+	}
+}
+
+func externalPackageID(pkg *v1alpha1.Package) string {
+	return "external://" + pkg.GetNamespace() + "/" + pkg.GetName()
+}
+
+func setExternalPackage(c *cuex.Compiler, pkg *v1alpha1.Package) {
+	p, err := cuexruntime.NewExternalPackage(pkg)
+	if err != nil {
+		klog.Errorf("parse external package %s/%s failed: %s", pkg.Namespace, pkg.Name, err.Error())
+		return
+	}
+	c.Externals.Set(externalPackageID(pkg), interceptedPackage{Package: p})
+}
+
+// listenExternalPackages watches the external packages like the ListenExternalPackages of the compiler,
+// the packages are wrapped so that their provider functions are called through the interceptors
+func listenExternalPackages(c *cuex.Compiler) {
+	factory := dynamicinformer.NewDynamicSharedInformerFactory(singleton.DynamicClient.Get(), c.ResyncPeriod)
+	informer := factory.ForResource(v1alpha1.PackageGroupVersionResource).Informer()
+	defer utilruntime.HandleCrash()
+	_, err := informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			if o, err := k8s.AsStructured[v1alpha1.Package](obj.(*unstructured.Unstructured)); err == nil {
+				setExternalPackage(c, o)
+			}
+		},
+		UpdateFunc: func(_, newObj interface{}) {
+			if o, err := k8s.AsStructured[v1alpha1.Package](newObj.(*unstructured.Unstructured)); err == nil {
+				setExternalPackage(c, o)
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			if o, err := k8s.AsStructured[v1alpha1.Package](obj.(*unstructured.Unstructured)); err == nil {
+				c.Externals.Del(externalPackageID(o))
+			}
+		},
+	})
+	if err != nil {
+		klog.Errorf("failed to watch external packages for cuex default compiler: %s", err.Error())
+		return
+	}
+	if c.StopCh == nil {
+		c.StopCh = make(chan struct{})
+	}
+	informer.Run(c.StopCh)
+}
@@ -0,0 +1,165 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package providers
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"cuelang.org/go/cue"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/kubevela/pkg/apis/cue/v1alpha1"
+	"github.com/kubevela/pkg/cue/cuex"
+	cuexruntime "github.com/kubevela/pkg/cue/cuex/runtime"
+
+	"github.com/kubevela/workflow/pkg/cue/model"
+	"github.com/kubevela/workflow/pkg/cue/process"
+	providertypes "github.com/kubevela/workflow/pkg/providers/types"
+	"github.com/kubevela/workflow/pkg/types"
+)
+
+func newEchoPackage(endpoint string) *v1alpha1.Package {
+	return &v1alpha1.Package{
+		ObjectMeta: metav1.ObjectMeta{Name: "ext", Namespace: "vela-system"},
+		Spec: v1alpha1.PackageSpec{
+			Path:     "ext/echo",
+			Provider: &v1alpha1.Provider{Protocol: v1alpha1.ProtocolHTTP, Endpoint: endpoint},
+			Templates: map[string]string{"echo.cue": `
+package echo
+#Echo: {
+	#do:       "echo"
+	#provider: "ext"
+	$params: input: string
+	$returns?: output: string
+}
+`},
+		},
+	}
+}
+
+func newEchoServer(received *[]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		*received = append(*received, string(body))
+		_, _ = fmt.Fprint(w, `{"output": "hello"}`)
+	}))
+}
+
+func TestExternalPackageInterceptors(t *testing.T) {
+	r := require.New(t)
+	var received []string
+	server := newEchoServer(&received)
+	defer server.Close()
+	pkg := newEchoPackage(server.URL)
+	var seen []string
+	ctx := providertypes.WithInterceptors(context.Background(), func(ctx context.Context, value cue.Value, next types.ProviderInvoker) (cue.Value, error) {
+		provider, _ := value.LookupPath(cue.ParsePath("#provider")).String()
+		input, _ := value.LookupPath(cue.ParsePath("$params.input")).String()
+		seen = append(seen, provider)
+		if input == "deny" {
+			return value, fmt.Errorf("%s is denied", provider)
+		}
+		return next(ctx, value)
+	})
+	src := func(input string) string {
+		return fmt.Sprintf(`
+import "ext/echo"
+out: echo.#Echo & {$params: input: %q}
+`, input)
+	}
+
+	for name, set := range map[string]func(c *cuex.Compiler){
+		"loaded": func(c *cuex.Compiler) {
+			p, err := cuexruntime.NewExternalPackage(pkg)
+			r.NoError(err)
+			c.Externals.Set(externalPackageID(pkg), p)
+			interceptExternalPackages(c)
+		},
+		"watched": func(c *cuex.Compiler) {
+			setExternalPackage(c, pkg)
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			r := require.New(t)
+			received, seen = nil, nil
+			c := cuex.NewCompilerWithInternalPackages()
+			set(c)
+			r.Len(c.Externals.Values(), 1)
+			r.IsType(providertypes.ExternalProviderFn{}, c.GetProviders()["ext"].GetProviderFn("echo"))
+
+			v, err := c.CompileString(ctx, src("world"))
+			r.NoError(err)
+			output, err := v.LookupPath(cue.ParsePath("out.$returns.output")).String()
+			r.NoError(err)
+			r.Equal("hello", output)
+			r.Equal([]string{"ext"}, seen)
+			r.Equal([]string{`{"input":"world"}`}, received)
+
+			// the interceptors can short-circuit the calls to the external providers
+			_, err = c.CompileString(ctx, src("deny"))
+			r.ErrorContains(err, "ext is denied")
+			r.Equal([]string{"ext", "ext"}, seen)
+			r.Len(received, 1)
+		})
+	}
+}
+
+func TestRestrictContextOfExternalPackage(t *testing.T) {
+	r := require.New(t)
+	var received []string
+	server := newEchoServer(&received)
+	defer server.Close()
+	c := cuex.NewCompilerWithInternalPackages()
+	setExternalPackage(c, newEchoPackage(server.URL))
+
+	cli := fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).WithObjects(
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "default"}},
+	).Build()
+	pCtx := process.NewContext(process.ContextData{Name: "app", Namespace: "default"})
+	pCtx.PushData(model.ContextStepSessionID, "step-1")
+	var seen providertypes.RuntimeParams
+	probe := func(ctx context.Context, value cue.Value, next types.ProviderInvoker) (cue.Value, error) {
+		seen = providertypes.RuntimeParamsFrom(ctx)
+		return next(ctx, value)
+	}
+	ctx := providertypes.WithRuntimeParams(context.Background(), providertypes.RuntimeParams{ProcessContext: pCtx})
+	ctx = providertypes.WithKubeClient(ctx, cli)
+	ctx = providertypes.WithInterceptors(ctx,
+		providertypes.RestrictContext(providertypes.ContextScope{Provider: "ext", Paths: []string{"context.name"}}), probe)
+
+	_, err := c.CompileString(ctx, `
+import "ext/echo"
+out: echo.#Echo & {$params: input: "world"}
+`)
+	r.NoError(err)
+	r.Len(received, 1)
+	// the external provider is called with the restricted context
+	r.Equal("app", seen.ProcessContext.GetData("name"))
+	r.Nil(seen.ProcessContext.GetData("namespace"))
+	r.True(kerrors.IsForbidden(seen.KubeClient.Get(ctx, client.ObjectKey{Namespace: "default", Name: "db"}, &corev1.Secret{})))
+}
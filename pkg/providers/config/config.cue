@@ -0,0 +1,26 @@
+// config.cue
+
+#Read: {
+	#do:       "read"
+	#provider: "config"
+
+	$params: {
+		// +usage=The kind of the config, either ConfigMap or Secret
+		kind: *"ConfigMap" | "Secret"
+		// +usage=The name of the config
+		name: string
+		// +usage=The namespace of the config, default to the namespace of the workflow
+		namespace?: string
+		// +usage=The keys to return, all the keys are returned if not specified
+		key?: [...string]
+	}
+
+	$returns?: {
+		// +usage=The data of the config, the data of the secret is decoded
+		data: [string]: string
+	}
+
+	// the data of the secret is redacted in the debug output
+	#sensitive: $params.kind == "Secret"
+	...
+}
@@ -0,0 +1,130 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	cuexruntime "github.com/kubevela/pkg/cue/cuex/runtime"
+
+	"github.com/kubevela/workflow/pkg/cue/model"
+	providertypes "github.com/kubevela/workflow/pkg/providers/types"
+)
+
+const (
+	// ProviderName is provider name for install.
+	ProviderName = "config"
+
+	// KindConfigMap is the kind of the configmap
+	KindConfigMap = "ConfigMap"
+	// KindSecret is the kind of the secret
+	KindSecret = "Secret"
+)
+
+// ReadVars .
+type ReadVars struct {
+	Kind      string   `json:"kind"`
+	Name      string   `json:"name"`
+	Namespace string   `json:"namespace,omitempty"`
+	Key       []string `json:"key,omitempty"`
+}
+
+// ReadReturnVars .
+type ReadReturnVars struct {
+	Data map[string]string `json:"data"`
+}
+
+// ReadParams .
+type ReadParams = providertypes.Params[ReadVars]
+
+// ReadReturns .
+type ReadReturns = providertypes.Returns[ReadReturnVars]
+
+// Read reads the data of the configmap or the secret, only the specified keys are returned if the keys are given.
+// The data of the secret is returned decoded.
+func Read(ctx context.Context, params *ReadParams) (*ReadReturns, error) {
+	vars := params.Params
+	namespace := vars.Namespace
+	if namespace == "" {
+		namespace = fmt.Sprint(params.ProcessContext.GetData(model.ContextNamespace))
+	}
+	key := client.ObjectKey{Namespace: namespace, Name: vars.Name}
+	data := map[string]string{}
+	switch vars.Kind {
+	case KindConfigMap:
+		cm := &corev1.ConfigMap{}
+		if err := params.KubeClient.Get(ctx, key, cm); err != nil {
+			return nil, fmt.Errorf("failed to get the configmap %s/%s: %w", namespace, vars.Name, err)
+		}
+		for k, v := range cm.Data {
+			data[k] = v
+		}
+	case KindSecret:
+		secret := &corev1.Secret{}
+		if err := params.KubeClient.Get(ctx, key, secret); err != nil {
+			return nil, fmt.Errorf("failed to get the secret %s/%s: %w", namespace, vars.Name, err)
+		}
+		for k, v := range secret.Data {
+			data[k] = string(v)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported kind %s, the supported kinds are: %s, %s", vars.Kind, KindConfigMap, KindSecret)
+	}
+	if len(vars.Key) > 0 {
+		projected := make(map[string]string, len(vars.Key))
+		for _, k := range vars.Key {
+			v, ok := data[k]
+			if !ok {
+				return nil, fmt.Errorf("key %s not found in %s %s/%s, the available keys are: %s", k, vars.Kind, namespace, vars.Name, availableKeys(data))
+			}
+			projected[k] = v
+		}
+		data = projected
+	}
+	return &ReadReturns{Returns: ReadReturnVars{Data: data}}, nil
+}
+
+func availableKeys(data map[string]string) string {
+	keys := make([]string, 0, len(data))
+	for k := range data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, ", ")
+}
+
+//go:embed config.cue
+var template string
+
+// GetTemplate returns the cue template.
+func GetTemplate() string {
+	return template
+}
+
+// GetProviders returns the cue providers.
+func GetProviders() map[string]cuexruntime.ProviderFn {
+	return map[string]cuexruntime.ProviderFn{
+		"read": providertypes.GenericProviderFn[ReadVars, ReadReturns](Read),
+	}
+}
@@ -0,0 +1,93 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/kubevela/workflow/pkg/cue/process"
+	providertypes "github.com/kubevela/workflow/pkg/providers/types"
+)
+
+func TestRead(t *testing.T) {
+	ctx := context.Background()
+	cli := fake.NewClientBuilder().WithObjects(
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "app-config", Namespace: "ns"},
+			Data:       map[string]string{"host": "example.com", "port": "8080"},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "app-secret", Namespace: "other"},
+			Data:       map[string][]byte{"username": []byte("admin"), "password": []byte("p@ss")},
+		},
+	).Build()
+	testCases := map[string]struct {
+		vars     ReadVars
+		expected map[string]string
+		err      string
+	}{
+		"configmap": {
+			vars:     ReadVars{Kind: KindConfigMap, Name: "app-config"},
+			expected: map[string]string{"host": "example.com", "port": "8080"},
+		},
+		"secret decoded": {
+			vars:     ReadVars{Kind: KindSecret, Name: "app-secret", Namespace: "other"},
+			expected: map[string]string{"username": "admin", "password": "p@ss"},
+		},
+		"key projection": {
+			vars:     ReadVars{Kind: KindSecret, Name: "app-secret", Namespace: "other", Key: []string{"password"}},
+			expected: map[string]string{"password": "p@ss"},
+		},
+		"missing key": {
+			vars: ReadVars{Kind: KindConfigMap, Name: "app-config", Key: []string{"host", "user"}},
+			err:  "key user not found in ConfigMap ns/app-config, the available keys are: host, port",
+		},
+		"not found": {
+			vars: ReadVars{Kind: KindSecret, Name: "app-secret"},
+			err:  "failed to get the secret ns/app-secret",
+		},
+		"unsupported kind": {
+			vars: ReadVars{Kind: "Pod", Name: "app-config"},
+			err:  "unsupported kind Pod, the supported kinds are: ConfigMap, Secret",
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			r := require.New(t)
+			res, err := Read(ctx, &ReadParams{
+				Params: tc.vars,
+				RuntimeParams: providertypes.RuntimeParams{
+					KubeClient:     cli,
+					ProcessContext: process.NewContext(process.ContextData{Namespace: "ns"}),
+				},
+			})
+			if tc.err != "" {
+				r.Error(err)
+				r.Contains(err.Error(), tc.err)
+				return
+			}
+			r.NoError(err)
+			r.Equal(tc.expected, res.Returns.Data)
+		})
+	}
+}
@@ -0,0 +1,38 @@
+// cost.cue
+
+#Estimate: {
+	#do:       "estimate"
+	#provider: "cost"
+
+	$params: {
+		// +usage=The rendered resources to estimate
+		resources: [...{...}]
+		// +usage=The monthly budget, the step fails if the estimate exceeds it
+		budget?: number
+		// +usage=The secret which contains the config of the pricing backend, the type of the backend is specified by the key backend
+		backend: {
+			// +usage=The name of the secret
+			name: string
+			// +usage=The namespace of the secret, default to the namespace of the workflow
+			namespace?: string
+		}
+	}
+
+	$returns?: {
+		// +usage=The currency of the estimate
+		currency?: string
+		// +usage=The total monthly cost of the resources
+		total: number
+		// +usage=The monthly cost of each resource
+		items?: [...{
+			apiVersion?: string
+			kind?:       string
+			name?:       string
+			namespace?:  string
+			monthly:     number
+		}]
+		// +usage=Whether the estimate is within the budget
+		withinBudget: bool
+	}
+	...
+}
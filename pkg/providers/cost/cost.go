@@ -0,0 +1,232 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cost
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	cuexruntime "github.com/kubevela/pkg/cue/cuex/runtime"
+
+	"github.com/kubevela/workflow/pkg/cue/model"
+	"github.com/kubevela/workflow/pkg/errors"
+	providertypes "github.com/kubevela/workflow/pkg/providers/types"
+)
+
+const (
+	// ProviderName is provider name for install.
+	ProviderName = "cost"
+	// BackendKey is the key of the backend type in the config secret.
+	BackendKey = "backend"
+	// DefaultBackend is the backend used if the type is not specified in the config secret.
+	DefaultBackend = "http"
+
+	// httpTimeout is the timeout of the requests to the pricing api
+	httpTimeout = 30 * time.Second
+	// maxResponseSize is the max size of the response of the pricing api
+	maxResponseSize = 1 << 20
+)
+
+// Estimate is the monthly cost estimate of the resources.
+type Estimate struct {
+	Currency string         `json:"currency,omitempty"`
+	Total    float64        `json:"total"`
+	Items    []ItemEstimate `json:"items,omitempty"`
+}
+
+// ItemEstimate is the monthly cost estimate of a resource.
+type ItemEstimate struct {
+	APIVersion string  `json:"apiVersion,omitempty"`
+	Kind       string  `json:"kind,omitempty"`
+	Name       string  `json:"name,omitempty"`
+	Namespace  string  `json:"namespace,omitempty"`
+	Monthly    float64 `json:"monthly"`
+}
+
+// Backend is the pricing backend which estimates the monthly cost of the rendered resources.
+type Backend interface {
+	Estimate(ctx context.Context, resources []*unstructured.Unstructured) (*Estimate, error)
+}
+
+// BackendFactory creates the backend from the data of the config secret.
+type BackendFactory func(config map[string]string) (Backend, error)
+
+var backends sync.Map
+
+func init() {
+	RegisterBackend(DefaultBackend, newHTTPBackend)
+}
+
+// RegisterBackend registers the factory of the pricing backend with the name.
+func RegisterBackend(name string, factory BackendFactory) {
+	backends.Store(name, factory)
+}
+
+// SecretRef is the reference of the backend config secret.
+type SecretRef struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// EstimateVars .
+type EstimateVars struct {
+	Resources []*unstructured.Unstructured `json:"resources"`
+	Budget    *float64                     `json:"budget,omitempty"`
+	Backend   SecretRef                    `json:"backend"`
+}
+
+// EstimateReturnVars .
+type EstimateReturnVars struct {
+	Estimate
+	WithinBudget bool `json:"withinBudget"`
+}
+
+// EstimateParams .
+type EstimateParams = providertypes.Params[EstimateVars]
+
+// EstimateReturns .
+type EstimateReturns = providertypes.Returns[EstimateReturnVars]
+
+// DoEstimate estimates the monthly cost of the resources with the pricing backend, and fails the step
+// if the estimate exceeds the budget.
+func DoEstimate(ctx context.Context, params *EstimateParams) (*EstimateReturns, error) {
+	vars := params.Params
+	namespace := vars.Backend.Namespace
+	if namespace == "" {
+		namespace = fmt.Sprint(params.ProcessContext.GetData(model.ContextNamespace))
+	}
+	secret := &corev1.Secret{}
+	if err := params.KubeClient.Get(ctx, client.ObjectKey{Namespace: namespace, Name: vars.Backend.Name}, secret); err != nil {
+		return nil, fmt.Errorf("failed to get the pricing backend config %s/%s: %w", namespace, vars.Backend.Name, err)
+	}
+	config := make(map[string]string, len(secret.Data))
+	for k, v := range secret.Data {
+		config[k] = string(v)
+	}
+	name := config[BackendKey]
+	if name == "" {
+		name = DefaultBackend
+	}
+	factory, ok := backends.Load(name)
+	if !ok {
+		return nil, fmt.Errorf("unknown pricing backend %s", name)
+	}
+	backend, err := factory.(BackendFactory)(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the pricing backend %s: %w", name, err)
+	}
+	estimate, err := backend.Estimate(ctx, vars.Resources)
+	if err != nil {
+		return nil, fmt.Errorf("failed to estimate the cost: %w", err)
+	}
+	if vars.Budget != nil && estimate.Total > *vars.Budget {
+		params.Action.Fail(fmt.Sprintf("The estimated monthly cost %s exceeds the budget %s", formatCost(estimate.Total, estimate.Currency), formatCost(*vars.Budget, estimate.Currency)))
+		return nil, errors.GenericActionError(errors.ActionTerminate)
+	}
+	return &EstimateReturns{
+		Returns: EstimateReturnVars{
+			Estimate:     *estimate,
+			WithinBudget: true,
+		},
+	}, nil
+}
+
+func formatCost(v float64, currency string) string {
+	if currency == "" {
+		return fmt.Sprintf("%.2f", v)
+	}
+	return fmt.Sprintf("%.2f %s", v, currency)
+}
+
+// httpBackend posts the resources to the pricing api and decodes the estimate from the response.
+type httpBackend struct {
+	endpoint string
+	token    string
+	client   *http.Client
+}
+
+func newHTTPBackend(config map[string]string) (Backend, error) {
+	endpoint := config["endpoint"]
+	if endpoint == "" {
+		return nil, fmt.Errorf("the endpoint of the pricing api is required")
+	}
+	return &httpBackend{endpoint: endpoint, token: config["token"], client: &http.Client{Timeout: httpTimeout}}, nil
+}
+
+// Estimate implements Backend.
+func (b *httpBackend) Estimate(ctx context.Context, resources []*unstructured.Unstructured) (*Estimate, error) {
+	body, err := json.Marshal(map[string]interface{}{"resources": resources})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, b.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if b.token != "" {
+		req.Header.Set("Authorization", "Bearer "+b.token)
+	}
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	//nolint:errcheck
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("unexpected status code %d from the pricing api: %s", resp.StatusCode, string(msg))
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxResponseSize {
+		return nil, fmt.Errorf("the response of the pricing api exceeds %d bytes", maxResponseSize)
+	}
+	estimate := &Estimate{}
+	if err := json.Unmarshal(data, estimate); err != nil {
+		return nil, fmt.Errorf("failed to decode the response of the pricing api: %w", err)
+	}
+	return estimate, nil
+}
+
+//go:embed cost.cue
+var template string
+
+// GetTemplate returns the cue template.
+func GetTemplate() string {
+	return template
+}
+
+// GetProviders returns the cue providers.
+func GetProviders() map[string]cuexruntime.ProviderFn {
+	return map[string]cuexruntime.ProviderFn{
+		"estimate": providertypes.GenericProviderFn[EstimateVars, EstimateReturns](DoEstimate),
+	}
+}
@@ -0,0 +1,167 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cost
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/kubevela/workflow/pkg/cue/process"
+	"github.com/kubevela/workflow/pkg/mock"
+	providertypes "github.com/kubevela/workflow/pkg/providers/types"
+)
+
+// mockBackend prices each resource with the price of its kind.
+type mockBackend struct {
+	prices map[string]float64
+}
+
+func (b *mockBackend) Estimate(_ context.Context, resources []*unstructured.Unstructured) (*Estimate, error) {
+	estimate := &Estimate{Currency: "USD"}
+	for _, res := range resources {
+		price, ok := b.prices[res.GetKind()]
+		if !ok {
+			return nil, fmt.Errorf("no price for %s", res.GetKind())
+		}
+		estimate.Items = append(estimate.Items, ItemEstimate{Kind: res.GetKind(), Name: res.GetName(), Monthly: price})
+		estimate.Total += price
+	}
+	return estimate, nil
+}
+
+func newResource(kind, name string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       kind,
+		"metadata":   map[string]interface{}{"name": name},
+	}}
+}
+
+func TestEstimate(t *testing.T) {
+	RegisterBackend("mock", func(config map[string]string) (Backend, error) {
+		return &mockBackend{prices: map[string]float64{"Deployment": 10, "Service": 2.5}}, nil
+	})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "Bearer large" {
+			_, _ = w.Write([]byte(`{"total": 1, "currency": "`))
+			_, _ = w.Write(bytes.Repeat([]byte("a"), maxResponseSize))
+			_, _ = w.Write([]byte(`"}`))
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer secret-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		body := struct {
+			Resources []*unstructured.Unstructured `json:"resources"`
+		}{}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(Estimate{Currency: "EUR", Total: float64(len(body.Resources)) * 3})
+	}))
+	defer server.Close()
+
+	cli := fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).WithObjects(
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "mock-pricing", Namespace: "default"},
+			Data:       map[string][]byte{BackendKey: []byte("mock")},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "http-pricing", Namespace: "vela-system"},
+			Data:       map[string][]byte{"endpoint": []byte(server.URL), "token": []byte("secret-token")},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "large-response", Namespace: "default"},
+			Data:       map[string][]byte{"endpoint": []byte(server.URL), "token": []byte("large")},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "bad-token", Namespace: "default"},
+			Data:       map[string][]byte{"endpoint": []byte(server.URL)},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "unknown", Namespace: "default"},
+			Data:       map[string][]byte{BackendKey: []byte("unknown")},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "no-endpoint", Namespace: "default"},
+			Data:       map[string][]byte{},
+		},
+	).Build()
+	pCtx := process.NewContext(process.ContextData{Namespace: "default"})
+	resources := []*unstructured.Unstructured{newResource("Deployment", "web"), newResource("Service", "web")}
+	estimate := func(act *mock.Action, backend SecretRef, budget *float64) (*EstimateReturns, error) {
+		return DoEstimate(context.Background(), &EstimateParams{
+			Params: EstimateVars{Resources: resources, Budget: budget, Backend: backend},
+			RuntimeParams: providertypes.RuntimeParams{
+				KubeClient:     cli,
+				ProcessContext: pCtx,
+				Action:         act,
+			},
+		})
+	}
+	budget := func(v float64) *float64 { return &v }
+	r := require.New(t)
+
+	act := &mock.Action{}
+	res, err := estimate(act, SecretRef{Name: "mock-pricing"}, budget(20))
+	r.NoError(err)
+	r.Equal(12.5, res.Returns.Total)
+	r.Equal("USD", res.Returns.Currency)
+	r.Equal([]ItemEstimate{{Kind: "Deployment", Name: "web", Monthly: 10}, {Kind: "Service", Name: "web", Monthly: 2.5}}, res.Returns.Items)
+	r.True(res.Returns.WithinBudget)
+	r.Equal("", act.Phase)
+
+	res, err = estimate(act, SecretRef{Name: "mock-pricing"}, nil)
+	r.NoError(err)
+	r.True(res.Returns.WithinBudget)
+
+	act = &mock.Action{}
+	_, err = estimate(act, SecretRef{Name: "mock-pricing"}, budget(10))
+	r.Error(err)
+	r.Equal("Fail", act.Phase)
+	r.Equal("The estimated monthly cost 12.50 USD exceeds the budget 10.00 USD", act.Msg)
+
+	res, err = estimate(&mock.Action{}, SecretRef{Name: "http-pricing", Namespace: "vela-system"}, budget(6))
+	r.NoError(err)
+	r.Equal(6.0, res.Returns.Total)
+	r.Equal("EUR", res.Returns.Currency)
+
+	_, err = estimate(&mock.Action{}, SecretRef{Name: "large-response"}, nil)
+	r.ErrorContains(err, "the response of the pricing api exceeds")
+	_, err = estimate(&mock.Action{}, SecretRef{Name: "bad-token"}, nil)
+	r.ErrorContains(err, "unexpected status code 401")
+	_, err = estimate(&mock.Action{}, SecretRef{Name: "unknown"}, nil)
+	r.ErrorContains(err, "unknown pricing backend unknown")
+	_, err = estimate(&mock.Action{}, SecretRef{Name: "no-endpoint"}, nil)
+	r.ErrorContains(err, "the endpoint of the pricing api is required")
+	_, err = estimate(&mock.Action{}, SecretRef{Name: "not-exist"}, nil)
+	r.Error(err)
+}
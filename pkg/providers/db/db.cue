@@ -0,0 +1,89 @@
+// db.cue
+
+#Migrate: {
+	#do:       "migrate"
+	#provider: "db"
+
+	$params: {
+		// +usage=The secret which contains the connection config of the database, the type of the database is specified by the key type
+		database: {
+			// +usage=The name of the secret
+			name: string
+			// +usage=The namespace of the secret, default to the namespace of the workflow
+			namespace?: string
+		}
+		// +usage=The configmap which contains the migration scripts named as <version>_<name>.sql
+		source: {
+			// +usage=The name of the configmap
+			name: string
+			// +usage=The namespace of the configmap, default to the namespace of the workflow
+			namespace?: string
+		}
+		// +usage=The version to migrate to, all the pending migrations are applied if not specified
+		targetVersion?: int
+		// +usage=The lease of the migration lock, after which the lock left by a crashed migration can be taken over, default to 10m. The lease is renewed before each migration, so it must be longer than the longest migration
+		lockTTL?: string
+	}
+
+	$returns?: {
+		// +usage=The version before the migration
+		previousVersion: int
+		// +usage=The version after the migration
+		version: int
+		// +usage=The applied migrations in order
+		applied: [...string]
+	}
+	...
+}
+
+#Statement: {
+	// +usage=The secret which contains the driver and the dsn of the database, and the optional placeholder which is $ for the $n placeholders
+	database: {
+		// +usage=The name of the secret
+		name: string
+		// +usage=The namespace of the secret, default to the namespace of the workflow
+		namespace?: string
+	}
+	// +usage=The driver which overrides the driver in the secret, e.g. postgres or mysql
+	driver?: string
+	// +usage=The sql statement with the placeholders of the driver, e.g. ? or $1, or the :name placeholders of the named args
+	sql: string
+	// +usage=The positional parameters, which are bound to the placeholders instead of interpolated into the sql
+	args?: [...]
+	// +usage=The named parameters bound to the :name placeholders, cannot be used together with the args
+	namedArgs?: [string]: _
+	// +usage=The timeout of the statement, default to 30s
+	timeout?: string
+}
+
+#Query: {
+	#do:       "query"
+	#provider: "db"
+
+	$params: {
+		#Statement
+		// +usage=The max number of the rows to return, default to 1000
+		maxRows?: int
+	}
+
+	$returns?: {
+		// +usage=The rows as maps of the column names
+		rows: [...{...}]
+		// +usage=Whether there are more rows than the max rows
+		truncated: bool
+	}
+	...
+}
+
+#Exec: {
+	#do:       "exec"
+	#provider: "db"
+
+	$params: #Statement
+
+	$returns?: {
+		// +usage=The number of the affected rows
+		rowsAffected: int
+	}
+	...
+}
@@ -0,0 +1,405 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	_ "embed"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	cuexruntime "github.com/kubevela/pkg/cue/cuex/runtime"
+
+	"github.com/kubevela/workflow/pkg/cue/model"
+	"github.com/kubevela/workflow/pkg/errors"
+	providertypes "github.com/kubevela/workflow/pkg/providers/types"
+)
+
+const (
+	// ProviderName is provider name for install.
+	ProviderName = "db"
+	// DatabaseTypeKey is the key of the database type in the config secret.
+	DatabaseTypeKey = "type"
+	// DefaultDatabaseType is the database type used if the type is not specified in the config secret.
+	DefaultDatabaseType = "sql"
+	// DefaultLockTTL is the lease of the migration lock if the lock ttl is not specified.
+	DefaultLockTTL = 10 * time.Minute
+)
+
+// Migration is a versioned migration script.
+type Migration struct {
+	Version int64
+	Name    string
+	Script  string
+}
+
+// Database is the database to migrate, the implementations must guarantee that the lock can only be held
+// by one owner at a time.
+type Database interface {
+	// AcquireLock acquires the migration lock for the owner with the lease of the ttl, it returns the current holder if
+	// the lock is held by others. The lock whose lease is expired can be taken over, in case the holder crashed. If
+	// the owner already holds the lock, the lease is renewed.
+	AcquireLock(ctx context.Context, owner string, ttl time.Duration) (acquired bool, holder string, err error)
+	// ReleaseLock releases the migration lock held by the owner.
+	ReleaseLock(ctx context.Context, owner string) error
+	// CurrentVersion returns the version of the last applied migration, 0 if no migration is applied.
+	CurrentVersion(ctx context.Context) (int64, error)
+	// Apply runs the migration and records its version.
+	Apply(ctx context.Context, migration Migration) error
+	Close() error
+}
+
+// DatabaseFactory creates the database from the data of the config secret.
+type DatabaseFactory func(config map[string]string) (Database, error)
+
+var databases sync.Map
+
+func init() {
+	RegisterDatabase(DefaultDatabaseType, newSQLDatabase)
+}
+
+// RegisterDatabase registers the factory of the database with the type.
+func RegisterDatabase(name string, factory DatabaseFactory) {
+	databases.Store(name, factory)
+}
+
+// ObjectRef is the reference of a secret or a configmap.
+type ObjectRef struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// MigrateVars .
+type MigrateVars struct {
+	// Database is the secret which contains the connection config of the database
+	Database ObjectRef `json:"database"`
+	// Source is the configmap which contains the migration scripts named as <version>_<name>.sql
+	Source ObjectRef `json:"source"`
+	// TargetVersion is the version to migrate to, all the migrations are applied if it is not set
+	TargetVersion *int64 `json:"targetVersion,omitempty"`
+	// LockTTL is the lease of the migration lock, after which the lock can be taken over by another migration
+	LockTTL string `json:"lockTTL,omitempty"`
+}
+
+// MigrateReturnVars .
+type MigrateReturnVars struct {
+	PreviousVersion int64    `json:"previousVersion"`
+	Version         int64    `json:"version"`
+	Applied         []string `json:"applied"`
+}
+
+// MigrateParams .
+type MigrateParams = providertypes.Params[MigrateVars]
+
+// MigrateReturns .
+type MigrateReturns = providertypes.Returns[MigrateReturnVars]
+
+// Migrate applies the pending migrations from the source in the order of the versions while holding the
+// migration lock, and fails the step if the lock is held by another migration.
+func Migrate(ctx context.Context, params *MigrateParams) (*MigrateReturns, error) {
+	vars := params.Params
+	ttl := DefaultLockTTL
+	if vars.LockTTL != "" {
+		d, err := time.ParseDuration(vars.LockTTL)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid lock ttl %q", vars.LockTTL)
+		}
+		ttl = d
+	}
+	namespace := fmt.Sprint(params.ProcessContext.GetData(model.ContextNamespace))
+	migrations, err := loadMigrations(ctx, params.KubeClient, vars.Source, namespace)
+	if err != nil {
+		return nil, err
+	}
+	database, err := openDatabase(ctx, params.KubeClient, vars.Database, namespace)
+	if err != nil {
+		return nil, err
+	}
+	//nolint:errcheck
+	defer database.Close()
+
+	owner := fmt.Sprintf("%s/%s/%v", namespace, params.ProcessContext.GetData(model.ContextName), params.ProcessContext.GetData(model.ContextStepSessionID))
+	acquired, holder, err := database.AcquireLock(ctx, owner, ttl)
+	if err != nil {
+		return nil, fmt.Errorf("failed to acquire the migration lock: %w", err)
+	}
+	if !acquired {
+		params.Action.Fail(fmt.Sprintf("The migration lock is held by %s", holder))
+		return nil, errors.GenericActionError(errors.ActionTerminate)
+	}
+	// the lease is renewed before each migration, and the migration is aborted if the lock has been taken over
+	// because a previous migration outlived the lease
+	renew := func() error {
+		acquired, holder, err := database.AcquireLock(ctx, owner, ttl)
+		if err != nil {
+			return fmt.Errorf("failed to renew the migration lock: %w", err)
+		}
+		if !acquired {
+			return fmt.Errorf("the migration lock is taken over by %s, the lock ttl %s is shorter than a migration", holder, ttl)
+		}
+		return nil
+	}
+	returns, err := migrate(ctx, database, migrations, vars.TargetVersion, renew)
+	if releaseErr := database.ReleaseLock(ctx, owner); releaseErr != nil && err == nil {
+		err = fmt.Errorf("failed to release the migration lock: %w", releaseErr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &MigrateReturns{Returns: *returns}, nil
+}
+
+func migrate(ctx context.Context, database Database, migrations []Migration, target *int64, renew func() error) (*MigrateReturnVars, error) {
+	current, err := database.CurrentVersion(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the current version: %w", err)
+	}
+	returns := &MigrateReturnVars{PreviousVersion: current, Version: current, Applied: []string{}}
+	for _, m := range migrations {
+		if m.Version <= current {
+			continue
+		}
+		if target != nil && m.Version > *target {
+			break
+		}
+		if err := renew(); err != nil {
+			return nil, err
+		}
+		if err := database.Apply(ctx, m); err != nil {
+			return nil, fmt.Errorf("failed to apply the migration %d_%s: %w", m.Version, m.Name, err)
+		}
+		returns.Version = m.Version
+		returns.Applied = append(returns.Applied, fmt.Sprintf("%d_%s", m.Version, m.Name))
+	}
+	return returns, nil
+}
+
+// loadMigrations loads the migrations from the configmap and sorts them by the versions.
+func loadMigrations(ctx context.Context, cli client.Client, ref ObjectRef, defaultNamespace string) ([]Migration, error) {
+	namespace := ref.Namespace
+	if namespace == "" {
+		namespace = defaultNamespace
+	}
+	cm := &corev1.ConfigMap{}
+	if err := cli.Get(ctx, client.ObjectKey{Namespace: namespace, Name: ref.Name}, cm); err != nil {
+		return nil, fmt.Errorf("failed to get the migration source %s/%s: %w", namespace, ref.Name, err)
+	}
+	migrations := make([]Migration, 0, len(cm.Data))
+	versions := map[int64]string{}
+	for key, script := range cm.Data {
+		name, ok := strings.CutSuffix(key, ".sql")
+		if !ok {
+			continue
+		}
+		v, name, ok := strings.Cut(name, "_")
+		version, err := strconv.ParseInt(v, 10, 64)
+		if !ok || err != nil || version <= 0 {
+			return nil, fmt.Errorf("invalid migration %s, the migrations must be named as <version>_<name>.sql", key)
+		}
+		if existing, ok := versions[version]; ok {
+			return nil, fmt.Errorf("duplicate migration version %d in %s and %s", version, existing, key)
+		}
+		versions[version] = key
+		migrations = append(migrations, Migration{Version: version, Name: name, Script: script})
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+func openDatabase(ctx context.Context, cli client.Client, ref ObjectRef, defaultNamespace string) (Database, error) {
+	config, err := loadConfig(ctx, cli, ref, defaultNamespace)
+	if err != nil {
+		return nil, err
+	}
+	name := config[DatabaseTypeKey]
+	if name == "" {
+		name = DefaultDatabaseType
+	}
+	factory, ok := databases.Load(name)
+	if !ok {
+		return nil, fmt.Errorf("unknown database type %s", name)
+	}
+	database, err := factory.(DatabaseFactory)(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to the database: %w", err)
+	}
+	return database, nil
+}
+
+// loadConfig reads the connection config of the database from the secret
+func loadConfig(ctx context.Context, cli client.Client, ref ObjectRef, defaultNamespace string) (map[string]string, error) {
+	namespace := ref.Namespace
+	if namespace == "" {
+		namespace = defaultNamespace
+	}
+	secret := &corev1.Secret{}
+	if err := cli.Get(ctx, client.ObjectKey{Namespace: namespace, Name: ref.Name}, secret); err != nil {
+		return nil, fmt.Errorf("failed to get the database config %s/%s: %w", namespace, ref.Name, err)
+	}
+	config := make(map[string]string, len(secret.Data))
+	for k, v := range secret.Data {
+		config[k] = string(v)
+	}
+	return config, nil
+}
+
+// sqlDatabase migrates the database with database/sql, the lock is a row in the lock table whose primary key
+// guarantees a single holder, and whose expiry allows the lock of a crashed holder to be taken over. The driver
+// must be linked into the binary, see drivers.go for the supported drivers.
+type sqlDatabase struct {
+	db          *sql.DB
+	placeholder func(i int) string
+}
+
+const (
+	sqlLockTable    = "workflow_migration_lock"
+	sqlVersionTable = "workflow_schema_migrations"
+)
+
+func newSQLDatabase(config map[string]string) (Database, error) {
+	driver, dsn := config["driver"], config["dsn"]
+	if driver == "" || dsn == "" {
+		return nil, fmt.Errorf("the driver and dsn of the database are required")
+	}
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		return nil, err
+	}
+	return &sqlDatabase{db: db, placeholder: placeholderOf(config)}, nil
+}
+
+// placeholderOf returns the placeholder of the parameters, which is $n if the placeholder of the config is $ or
+// the driver is postgres, otherwise ?
+func placeholderOf(config map[string]string) func(i int) string {
+	placeholder := config["placeholder"]
+	if placeholder == "" && (config["driver"] == "postgres" || config["driver"] == "pgx") {
+		placeholder = "$"
+	}
+	if placeholder == "$" {
+		return func(i int) string { return "$" + strconv.Itoa(i) }
+	}
+	return func(int) string { return "?" }
+}
+
+func (d *sqlDatabase) init(ctx context.Context) error {
+	for _, stmt := range []string{
+		"CREATE TABLE IF NOT EXISTS " + sqlLockTable + " (id INTEGER PRIMARY KEY, owner VARCHAR(512) NOT NULL, locked_at VARCHAR(64) NOT NULL, expires_at VARCHAR(64) NOT NULL)",
+		"CREATE TABLE IF NOT EXISTS " + sqlVersionTable + " (version BIGINT PRIMARY KEY, name VARCHAR(512) NOT NULL, applied_at VARCHAR(64) NOT NULL)",
+	} {
+		if _, err := d.db.ExecContext(ctx, stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AcquireLock implements Database.
+func (d *sqlDatabase) AcquireLock(ctx context.Context, owner string, ttl time.Duration) (bool, string, error) {
+	if err := d.init(ctx); err != nil {
+		return false, "", err
+	}
+	now := time.Now().UTC()
+	lockedAt, expiresAt := now.Format(time.RFC3339), now.Add(ttl).Format(time.RFC3339)
+	stmt := fmt.Sprintf("INSERT INTO %s (id, owner, locked_at, expires_at) VALUES (1, %s, %s, %s)", sqlLockTable, d.placeholder(1), d.placeholder(2), d.placeholder(3))
+	_, insertErr := d.db.ExecContext(ctx, stmt, owner, lockedAt, expiresAt)
+	if insertErr == nil {
+		return true, owner, nil
+	}
+	var holder, holderExpiresAt string
+	row := d.db.QueryRowContext(ctx, "SELECT owner, expires_at FROM "+sqlLockTable+" WHERE id = 1")
+	if err := row.Scan(&holder, &holderExpiresAt); err != nil {
+		return false, "", insertErr
+	}
+	if expiry, err := time.Parse(time.RFC3339, holderExpiresAt); holder != owner && err == nil && expiry.After(now) {
+		return false, holder, nil
+	}
+	// renew the lease of the lock held by the owner or take over the expired lock, the lock is neither renewed
+	// nor taken over if another owner has taken it over in the meantime
+	stmt = fmt.Sprintf("UPDATE %s SET owner = %s, locked_at = %s, expires_at = %s WHERE id = 1 AND owner = %s AND expires_at = %s",
+		sqlLockTable, d.placeholder(1), d.placeholder(2), d.placeholder(3), d.placeholder(4), d.placeholder(5))
+	result, err := d.db.ExecContext(ctx, stmt, owner, lockedAt, expiresAt, holder, holderExpiresAt)
+	if err != nil {
+		return false, "", err
+	}
+	if affected, err := result.RowsAffected(); err != nil || affected != 1 {
+		return false, holder, err
+	}
+	return true, owner, nil
+}
+
+// ReleaseLock implements Database.
+func (d *sqlDatabase) ReleaseLock(ctx context.Context, owner string) error {
+	_, err := d.db.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s WHERE id = 1 AND owner = %s", sqlLockTable, d.placeholder(1)), owner)
+	return err
+}
+
+// CurrentVersion implements Database.
+func (d *sqlDatabase) CurrentVersion(ctx context.Context) (int64, error) {
+	var version sql.NullInt64
+	if err := d.db.QueryRowContext(ctx, "SELECT MAX(version) FROM "+sqlVersionTable).Scan(&version); err != nil {
+		return 0, err
+	}
+	return version.Int64, nil
+}
+
+// Apply implements Database.
+func (d *sqlDatabase) Apply(ctx context.Context, migration Migration) error {
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, migration.Script); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	stmt := fmt.Sprintf("INSERT INTO %s (version, name, applied_at) VALUES (%s, %s, %s)", sqlVersionTable, d.placeholder(1), d.placeholder(2), d.placeholder(3))
+	if _, err := tx.ExecContext(ctx, stmt, migration.Version, migration.Name, time.Now().UTC().Format(time.RFC3339)); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// Close implements Database.
+func (d *sqlDatabase) Close() error {
+	return d.db.Close()
+}
+
+//go:embed db.cue
+var template string
+
+// GetTemplate returns the cue template.
+func GetTemplate() string {
+	return template
+}
+
+// GetProviders returns the cue providers.
+func GetProviders() map[string]cuexruntime.ProviderFn {
+	return map[string]cuexruntime.ProviderFn{
+		"migrate": providertypes.GenericProviderFn[MigrateVars, MigrateReturns](Migrate),
+		"query":   providertypes.GenericProviderFn[QueryVars, QueryReturns](Query),
+		"exec":    providertypes.GenericProviderFn[StatementVars, ExecReturns](Exec),
+	}
+}
@@ -0,0 +1,280 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/kubevela/workflow/pkg/cue/model"
+	providertypes "github.com/kubevela/workflow/pkg/providers/types"
+)
+
+const (
+	// DefaultMaxRows is the default max number of the rows returned by query
+	DefaultMaxRows = 1000
+	// DefaultQueryTimeout is the default timeout of query and exec
+	DefaultQueryTimeout = 30 * time.Second
+)
+
+// StatementVars .
+type StatementVars struct {
+	// Database is the secret which contains the driver and the dsn of the database
+	Database ObjectRef `json:"database"`
+	// Driver overrides the driver in the secret, e.g. postgres or mysql, the driver must be linked into the binary
+	Driver string `json:"driver,omitempty"`
+	SQL    string `json:"sql"`
+	// Args are the positional parameters bound to the placeholders of the driver, e.g. ? or $1
+	Args []interface{} `json:"args,omitempty"`
+	// NamedArgs are the parameters bound to the :name placeholders, which are rewritten to the placeholders
+	// of the driver, the args and the named args can not be used together
+	NamedArgs map[string]interface{} `json:"namedArgs,omitempty"`
+	Timeout   string                 `json:"timeout,omitempty"`
+}
+
+// QueryVars .
+type QueryVars struct {
+	StatementVars
+	// MaxRows is the max number of the rows returned, DefaultMaxRows is used if not set
+	MaxRows int `json:"maxRows,omitempty"`
+}
+
+// QueryReturnVars .
+type QueryReturnVars struct {
+	Rows []map[string]interface{} `json:"rows"`
+	// Truncated is true if there are more rows than the max rows
+	Truncated bool `json:"truncated"`
+}
+
+// QueryParams .
+type QueryParams = providertypes.Params[QueryVars]
+
+// QueryReturns .
+type QueryReturns = providertypes.Returns[QueryReturnVars]
+
+// ExecReturnVars .
+type ExecReturnVars struct {
+	RowsAffected int64 `json:"rowsAffected"`
+}
+
+// ExecParams .
+type ExecParams = providertypes.Params[StatementVars]
+
+// ExecReturns .
+type ExecReturns = providertypes.Returns[ExecReturnVars]
+
+// Query runs the query with the bound parameters and returns the rows as maps of the column names.
+func Query(ctx context.Context, params *QueryParams) (*QueryReturns, error) {
+	vars := params.Params
+	maxRows := vars.MaxRows
+	if maxRows == 0 {
+		maxRows = DefaultMaxRows
+	}
+	if maxRows < 0 {
+		return nil, fmt.Errorf("invalid max rows %d", maxRows)
+	}
+	st, err := prepareStatement(ctx, params.RuntimeParams, vars.StatementVars)
+	if err != nil {
+		return nil, err
+	}
+	defer st.db.Close() //nolint:errcheck
+	ctx, cancel := context.WithTimeout(ctx, st.timeout)
+	defer cancel()
+	rows, err := st.db.QueryContext(ctx, st.query, st.args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query: %w", err)
+	}
+	defer rows.Close() //nolint:errcheck
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	returns := QueryReturnVars{Rows: []map[string]interface{}{}}
+	for rows.Next() {
+		if len(returns.Rows) == maxRows {
+			returns.Truncated = true
+			break
+		}
+		values := make([]interface{}, len(columns))
+		pointers := make([]interface{}, len(columns))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+		if err := rows.Scan(pointers...); err != nil {
+			return nil, fmt.Errorf("failed to scan the row: %w", err)
+		}
+		row := make(map[string]interface{}, len(columns))
+		for i, column := range columns {
+			row[column] = convertValue(values[i])
+		}
+		returns.Rows = append(returns.Rows, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query: %w", err)
+	}
+	return &QueryReturns{Returns: returns}, nil
+}
+
+// Exec runs the statement with the bound parameters and returns the number of the affected rows.
+func Exec(ctx context.Context, params *ExecParams) (*ExecReturns, error) {
+	st, err := prepareStatement(ctx, params.RuntimeParams, params.Params)
+	if err != nil {
+		return nil, err
+	}
+	defer st.db.Close() //nolint:errcheck
+	ctx, cancel := context.WithTimeout(ctx, st.timeout)
+	defer cancel()
+	result, err := st.db.ExecContext(ctx, st.query, st.args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to exec: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	return &ExecReturns{Returns: ExecReturnVars{RowsAffected: affected}}, nil
+}
+
+type statement struct {
+	db      *sql.DB
+	query   string
+	args    []interface{}
+	timeout time.Duration
+}
+
+// prepareStatement opens the database and binds the parameters
+func prepareStatement(ctx context.Context, runtimeParams providertypes.RuntimeParams, vars StatementVars) (*statement, error) {
+	if strings.TrimSpace(vars.SQL) == "" {
+		return nil, fmt.Errorf("the sql is required")
+	}
+	if len(vars.Args) > 0 && len(vars.NamedArgs) > 0 {
+		return nil, fmt.Errorf("only one of args and namedArgs can be set")
+	}
+	st := &statement{query: vars.SQL, args: make([]interface{}, 0, len(vars.Args)), timeout: DefaultQueryTimeout}
+	if vars.Timeout != "" {
+		timeout, err := time.ParseDuration(vars.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timeout %s: %w", vars.Timeout, err)
+		}
+		st.timeout = timeout
+	}
+	config, err := loadConfig(ctx, runtimeParams.KubeClient, vars.Database, fmt.Sprint(runtimeParams.ProcessContext.GetData(model.ContextNamespace)))
+	if err != nil {
+		return nil, err
+	}
+	if typ := config[DatabaseTypeKey]; typ != "" && typ != DefaultDatabaseType {
+		return nil, fmt.Errorf("the database type %s does not support sql statements", typ)
+	}
+	if vars.Driver != "" {
+		config["driver"] = vars.Driver
+	}
+	for _, arg := range vars.Args {
+		st.args = append(st.args, convertArg(arg))
+	}
+	if len(vars.NamedArgs) > 0 {
+		if st.query, st.args, err = bindNamed(vars.SQL, vars.NamedArgs, placeholderOf(config)); err != nil {
+			return nil, err
+		}
+	}
+	if config["driver"] == "" || config["dsn"] == "" {
+		return nil, fmt.Errorf("the driver and dsn of the database are required")
+	}
+	if st.db, err = sql.Open(config["driver"], config["dsn"]); err != nil {
+		return nil, fmt.Errorf("failed to connect to the database: %w", err)
+	}
+	return st, nil
+}
+
+// bindNamed rewrites the :name placeholders to the placeholders of the driver and returns the parameters in
+// order, the quoted strings and identifiers and the :: casts of postgres are kept as is
+func bindNamed(query string, named map[string]interface{}, placeholder func(i int) string) (string, []interface{}, error) {
+	var b strings.Builder
+	var args []interface{}
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			end := strings.IndexByte(query[i+1:], c)
+			if end < 0 {
+				return "", nil, fmt.Errorf("unterminated quote at %d", i)
+			}
+			b.WriteString(query[i : i+end+2])
+			i += end + 1
+		case c == ':' && i+1 < len(query) && query[i+1] == ':':
+			b.WriteString("::")
+			i++
+		case c == ':' && i+1 < len(query) && isIdentStart(query[i+1]):
+			j := i + 1
+			for j < len(query) && isIdentPart(query[j]) {
+				j++
+			}
+			name := query[i+1 : j]
+			value, ok := named[name]
+			if !ok {
+				return "", nil, fmt.Errorf("the named arg %s is not set", name)
+			}
+			args = append(args, convertArg(value))
+			b.WriteString(placeholder(len(args)))
+			i = j - 1
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String(), args, nil
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+func isIdentPart(c byte) bool {
+	return isIdentStart(c) || '0' <= c && c <= '9'
+}
+
+// convertArg converts the JSON value to the parameter, the integral numbers are bound as integers and the
+// objects and arrays are bound as JSON
+func convertArg(v interface{}) interface{} {
+	switch value := v.(type) {
+	case float64:
+		if value == math.Trunc(value) && math.Abs(value) < 1<<53 {
+			return int64(value)
+		}
+		return value
+	case map[string]interface{}, []interface{}:
+		b, _ := json.Marshal(value)
+		return string(b)
+	default:
+		return value
+	}
+}
+
+// convertValue converts the scanned value to the JSON value
+func convertValue(v interface{}) interface{} {
+	switch value := v.(type) {
+	case []byte:
+		return string(value)
+	case time.Time:
+		return value.Format(time.RFC3339Nano)
+	default:
+		return value
+	}
+}
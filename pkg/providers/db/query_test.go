@@ -0,0 +1,210 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/kubevela/workflow/pkg/cue/process"
+	providertypes "github.com/kubevela/workflow/pkg/providers/types"
+)
+
+// fakeSQL is a database/sql driver with a users table, which records the statements and the bound arguments
+type fakeSQL struct {
+	mu         sync.Mutex
+	users      [][]driver.Value
+	statements []string
+	args       [][]driver.Value
+}
+
+var fakeSQLDriver = &fakeSQL{}
+
+func init() {
+	sql.Register("fakesql", fakeSQLDriver)
+}
+
+func (d *fakeSQL) Open(string) (driver.Conn, error) {
+	return &fakeConn{d: d}, nil
+}
+
+type fakeConn struct {
+	d *fakeSQL
+}
+
+func (c *fakeConn) Prepare(string) (driver.Stmt, error) { return nil, driver.ErrSkip }
+func (c *fakeConn) Close() error                        { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error)           { return nil, fmt.Errorf("not supported") }
+
+func (c *fakeConn) record(query string, args []driver.NamedValue) []driver.Value {
+	values := make([]driver.Value, len(args))
+	for i, arg := range args {
+		values[i] = arg.Value
+	}
+	c.d.statements = append(c.d.statements, query)
+	c.d.args = append(c.d.args, values)
+	return values
+}
+
+func (c *fakeConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	c.d.mu.Lock()
+	defer c.d.mu.Unlock()
+	values := c.record(query, args)
+	if !strings.HasPrefix(query, "INSERT INTO users (name, age) VALUES ") || len(values) != 2 {
+		return nil, fmt.Errorf("syntax error")
+	}
+	c.d.users = append(c.d.users, []driver.Value{int64(len(c.d.users) + 1), values[0], values[1]})
+	return driver.RowsAffected(1), nil
+}
+
+func (c *fakeConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	c.d.mu.Lock()
+	defer c.d.mu.Unlock()
+	values := c.record(query, args)
+	if !strings.HasPrefix(query, "SELECT id, name, age FROM users WHERE age >= ") || len(values) != 1 {
+		return nil, fmt.Errorf("syntax error")
+	}
+	rows := &fakeRows{}
+	for _, user := range c.d.users {
+		if user[2].(int64) >= values[0].(int64) {
+			rows.rows = append(rows.rows, user)
+		}
+	}
+	return rows, nil
+}
+
+type fakeRows struct {
+	rows [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string { return []string{"id", "name", "age"} }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	// the drivers may return the text columns as bytes
+	dest[1] = []byte(dest[1].(string))
+	r.rows = r.rows[1:]
+	return nil
+}
+
+func TestQueryAndExec(t *testing.T) {
+	r := require.New(t)
+	ctx := context.Background()
+	cli := fake.NewClientBuilder().WithObjects(
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "default"}, Data: map[string][]byte{"driver": []byte("fakesql"), "dsn": []byte("users")}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "pg", Namespace: "default"}, Data: map[string][]byte{"driver": []byte("fakesql"), "dsn": []byte("users"), "placeholder": []byte("$")}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "mock", Namespace: "default"}, Data: map[string][]byte{"type": []byte("mock")}},
+	).Build()
+	runtimeParams := providertypes.RuntimeParams{KubeClient: cli, ProcessContext: process.NewContext(process.ContextData{Namespace: "default"})}
+	exec := func(vars StatementVars) (*ExecReturns, error) {
+		return Exec(ctx, &ExecParams{Params: vars, RuntimeParams: runtimeParams})
+	}
+	query := func(vars QueryVars) (*QueryReturns, error) {
+		return Query(ctx, &QueryParams{Params: vars, RuntimeParams: runtimeParams})
+	}
+
+	res, err := exec(StatementVars{Database: ObjectRef{Name: "db"}, SQL: "INSERT INTO users (name, age) VALUES (?, ?)", Args: []interface{}{"alice", float64(30)}})
+	r.NoError(err)
+	r.Equal(int64(1), res.Returns.RowsAffected)
+	_, err = exec(StatementVars{
+		Database:  ObjectRef{Name: "pg"},
+		SQL:       "INSERT INTO users (name, age) VALUES (:name, :age)",
+		NamedArgs: map[string]interface{}{"name": "bob'); DROP TABLE users; --", "age": float64(17)},
+	})
+	r.NoError(err)
+	r.Equal("INSERT INTO users (name, age) VALUES ($1, $2)", fakeSQLDriver.statements[1])
+	r.Equal([]driver.Value{"bob'); DROP TABLE users; --", int64(17)}, fakeSQLDriver.args[1])
+	for i := 0; i < 2; i++ {
+		_, err = exec(StatementVars{Database: ObjectRef{Name: "db"}, SQL: "INSERT INTO users (name, age) VALUES (?, ?)", Args: []interface{}{"user" + strconv.Itoa(i), float64(40)}})
+		r.NoError(err)
+	}
+
+	rows, err := query(QueryVars{StatementVars: StatementVars{Database: ObjectRef{Name: "db"}, SQL: "SELECT id, name, age FROM users WHERE age >= ?", Args: []interface{}{float64(18)}}})
+	r.NoError(err)
+	r.Equal(QueryReturnVars{Rows: []map[string]interface{}{
+		{"id": int64(1), "name": "alice", "age": int64(30)},
+		{"id": int64(3), "name": "user0", "age": int64(40)},
+		{"id": int64(4), "name": "user1", "age": int64(40)},
+	}}, rows.Returns)
+	r.Equal([]driver.Value{int64(18)}, fakeSQLDriver.args[len(fakeSQLDriver.args)-1])
+
+	rows, err = query(QueryVars{StatementVars: StatementVars{Database: ObjectRef{Name: "db"}, SQL: "SELECT id, name, age FROM users WHERE age >= ?", Args: []interface{}{float64(18)}}, MaxRows: 2})
+	r.NoError(err)
+	r.Len(rows.Returns.Rows, 2)
+	r.True(rows.Returns.Truncated)
+
+	_, err = exec(StatementVars{Database: ObjectRef{Name: "db"}, SQL: "DELETE FROM users"})
+	r.EqualError(err, "failed to exec: syntax error")
+	_, err = exec(StatementVars{Database: ObjectRef{Name: "db"}, SQL: "INSERT INTO users (name, age) VALUES (:name, :age)", NamedArgs: map[string]interface{}{"name": "carol"}})
+	r.EqualError(err, "the named arg age is not set")
+	_, err = exec(StatementVars{Database: ObjectRef{Name: "db"}, SQL: "SELECT 1", Args: []interface{}{1}, NamedArgs: map[string]interface{}{"a": 1}})
+	r.EqualError(err, "only one of args and namedArgs can be set")
+	_, err = exec(StatementVars{Database: ObjectRef{Name: "mock"}, SQL: "SELECT 1"})
+	r.EqualError(err, "the database type mock does not support sql statements")
+}
+
+func TestPrepareStatementWithDrivers(t *testing.T) {
+	r := require.New(t)
+	cli := fake.NewClientBuilder().WithObjects(
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "pg", Namespace: "default"}, Data: map[string][]byte{"driver": []byte("postgres"), "dsn": []byte("postgres://localhost:5432/db")}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "mysql", Namespace: "default"}, Data: map[string][]byte{"dsn": []byte("user:pass@tcp(localhost:3306)/db")}},
+	).Build()
+	runtimeParams := providertypes.RuntimeParams{KubeClient: cli, ProcessContext: process.NewContext(process.ContextData{Namespace: "default"})}
+
+	st, err := prepareStatement(context.Background(), runtimeParams, StatementVars{Database: ObjectRef{Name: "pg"}, SQL: "SELECT 1"})
+	r.NoError(err)
+	r.IsType(&pq.Driver{}, st.db.Driver())
+	r.NoError(st.db.Close())
+	st, err = prepareStatement(context.Background(), runtimeParams, StatementVars{Database: ObjectRef{Name: "mysql"}, Driver: "mysql", SQL: "SELECT 1"})
+	r.NoError(err)
+	r.IsType(&mysql.MySQLDriver{}, st.db.Driver())
+	r.NoError(st.db.Close())
+}
+
+func TestBindNamed(t *testing.T) {
+	r := require.New(t)
+	query, args, err := bindNamed(`SELECT ':skip', "col:x", created_at::date FROM t WHERE id = :id AND (name = :name OR alias = :name)`,
+		map[string]interface{}{"id": float64(1), "name": "a", "unused": true}, placeholderOf(map[string]string{"driver": "postgres"}))
+	r.NoError(err)
+	r.Equal(`SELECT ':skip', "col:x", created_at::date FROM t WHERE id = $1 AND (name = $2 OR alias = $3)`, query)
+	r.Equal([]interface{}{int64(1), "a", "a"}, args)
+
+	query, _, err = bindNamed("SELECT * FROM t WHERE tags = :tags", map[string]interface{}{"tags": []interface{}{"a"}}, placeholderOf(map[string]string{}))
+	r.NoError(err)
+	r.Equal("SELECT * FROM t WHERE tags = ?", query)
+
+	_, _, err = bindNamed("SELECT 'unterminated", nil, placeholderOf(map[string]string{}))
+	r.EqualError(err, "unterminated quote at 7")
+}
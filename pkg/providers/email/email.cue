@@ -26,6 +26,24 @@
 			subject: string
 			// +usage=The body of the email
 			body: string
+			// +usage=The html body of the email, the body is sent as the plain text alternative if both are set
+			html?: string
+			// +usage=The content type of the body, default to text/plain if the html is set, otherwise text/html
+			contentType?: string
+			// +usage=The attachments of the email
+			attachments?: [...{
+				// +usage=The file name of the attachment
+				name: string
+				// +usage=The base64 encoded content of the attachment
+				content?: string
+				// +usage=The variable in the workflow context as the content of the attachment, the variables other than strings are encoded as JSON
+				contentFrom?: {
+					// +usage=The path of the variable, e.g. outputs.report
+					var: string
+				}
+				// +usage=The content type of the attachment, detected from the name if not set
+				contentType?: string
+			}]
 		}
 	}
 	// this provider has no returns
@@ -19,7 +19,10 @@ package email
 import (
 	"context"
 	_ "embed"
+	"encoding/base64"
 	"fmt"
+	"io"
+	"strings"
 	"sync"
 
 	"gopkg.in/gomail.v2"
@@ -49,6 +52,27 @@ type Sender struct {
 type Content struct {
 	Subject string `json:"subject"`
 	Body    string `json:"body"`
+	// HTML is the html body, the body is sent as the plain text alternative if both are set
+	HTML string `json:"html,omitempty"`
+	// ContentType is the content type of the body, default to text/plain if the html is set, otherwise text/html
+	ContentType string       `json:"contentType,omitempty"`
+	Attachments []Attachment `json:"attachments,omitempty"`
+}
+
+// Attachment is the attachment of email
+type Attachment struct {
+	Name string `json:"name"`
+	// Content is the base64 encoded content of the attachment
+	Content     string       `json:"content,omitempty"`
+	ContentFrom *ContentFrom `json:"contentFrom,omitempty"`
+	ContentType string       `json:"contentType,omitempty"`
+}
+
+// ContentFrom is the reference of the content in the workflow context.
+type ContentFrom struct {
+	// Var is the path of the variable in the workflow context, e.g. outputs.report, the variables other than
+	// strings are encoded as JSON
+	Var string `json:"var"`
 }
 
 // MailVars .
@@ -81,18 +105,15 @@ func Send(_ context.Context, params *MailParams) (res *any, err error) {
 			emailRoutine.Delete(id)
 			return nil, fmt.Errorf("failed to send email: %v", routine)
 		}
-	} else {
-		emailRoutine.Store(id, "initializing")
 	}
 
-	sender := params.Params.From
-	content := params.Params.Content
-	m := gomail.NewMessage()
-	m.SetAddressHeader("From", sender.Address, sender.Alias)
-	m.SetHeader("To", params.Params.To...)
-	m.SetHeader("Subject", content.Subject)
-	m.SetBody("text/html", content.Body)
+	m, err := newMessage(params.RuntimeParams, params.Params)
+	if err != nil {
+		return nil, err
+	}
+	emailRoutine.Store(id, "initializing")
 
+	sender := params.Params.From
 	dial := gomail.NewDialer(sender.Host, sender.Port, sender.Address, sender.Password)
 	go func() {
 		if routine, ok := emailRoutine.Load(id); ok && routine == "initializing" {
@@ -108,6 +129,79 @@ func Send(_ context.Context, params *MailParams) (res *any, err error) {
 	return nil, errors.GenericActionError(errors.ActionWait)
 }
 
+// newMessage builds the message, which is a multipart message if the html or the attachments are set
+func newMessage(runtimeParams providertypes.RuntimeParams, vars MailVars) (*gomail.Message, error) {
+	content := vars.Content
+	m := gomail.NewMessage()
+	m.SetAddressHeader("From", vars.From.Address, vars.From.Alias)
+	m.SetHeader("To", vars.To...)
+	m.SetHeader("Subject", content.Subject)
+	switch {
+	case content.HTML != "" && content.Body != "":
+		m.SetBody(contentTypeOr(content.ContentType, "text/plain"), content.Body)
+		m.AddAlternative("text/html", content.HTML)
+	case content.HTML != "":
+		m.SetBody("text/html", content.HTML)
+	default:
+		m.SetBody(contentTypeOr(content.ContentType, "text/html"), content.Body)
+	}
+	for i, attachment := range content.Attachments {
+		if attachment.Name == "" {
+			return nil, fmt.Errorf("the name of attachment #%d is required", i)
+		}
+		data, err := loadAttachment(runtimeParams, attachment)
+		if err != nil {
+			return nil, fmt.Errorf("invalid attachment %s: %w", attachment.Name, err)
+		}
+		settings := []gomail.FileSetting{gomail.SetCopyFunc(func(w io.Writer) error {
+			_, err := w.Write(data)
+			return err
+		})}
+		if attachment.ContentType != "" {
+			settings = append(settings, gomail.SetHeader(map[string][]string{"Content-Type": {attachment.ContentType}}))
+		}
+		m.Attach(attachment.Name, settings...)
+	}
+	return m, nil
+}
+
+func contentTypeOr(contentType, defaultType string) string {
+	if contentType == "" {
+		return defaultType
+	}
+	return contentType
+}
+
+// loadAttachment decodes the inline content or reads the variable from the workflow context
+func loadAttachment(runtimeParams providertypes.RuntimeParams, attachment Attachment) ([]byte, error) {
+	switch {
+	case attachment.Content != "" && attachment.ContentFrom != nil:
+		return nil, fmt.Errorf("only one of content and contentFrom can be set")
+	case attachment.ContentFrom != nil:
+		path := attachment.ContentFrom.Var
+		if path == "" {
+			return nil, fmt.Errorf("the var of contentFrom is required")
+		}
+		if runtimeParams.WorkflowContext == nil {
+			return nil, fmt.Errorf("the workflow context is not available")
+		}
+		value, err := runtimeParams.WorkflowContext.GetVar(strings.Split(path, ".")...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get the var %s from the workflow context: %w", path, err)
+		}
+		if s, err := value.String(); err == nil {
+			return []byte(s), nil
+		}
+		return value.MarshalJSON()
+	default:
+		data, err := base64.StdEncoding.DecodeString(attachment.Content)
+		if err != nil {
+			return nil, fmt.Errorf("the content must be base64 encoded: %w", err)
+		}
+		return data, nil
+	}
+}
+
 //go:embed email.cue
 var template string
 
@@ -17,16 +17,27 @@ limitations under the License.
 package email
 
 import (
+	"bufio"
 	"context"
+	"encoding/base64"
 	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"net/textproto"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
 	. "github.com/agiledragon/gomonkey/v2"
 	"github.com/stretchr/testify/require"
 	"gopkg.in/gomail.v2"
+	corev1 "k8s.io/api/core/v1"
 
+	wfContext "github.com/kubevela/workflow/pkg/context"
 	"github.com/kubevela/workflow/pkg/cue/model"
 	"github.com/kubevela/workflow/pkg/cue/process"
 	"github.com/kubevela/workflow/pkg/errors"
@@ -128,3 +139,145 @@ func TestSendEmail(t *testing.T) {
 		})
 	}
 }
+
+// startSMTPServer starts a stub SMTP server without TLS and auth, which sends the received messages to the channel
+func startSMTPServer(t *testing.T) (string, int, <-chan string) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = l.Close() })
+	messages := make(chan string, 1)
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close() //nolint:errcheck
+				tp := textproto.NewConn(conn)
+				_ = tp.PrintfLine("220 stub ESMTP")
+				for {
+					line, err := tp.ReadLine()
+					if err != nil {
+						return
+					}
+					switch cmd := strings.ToUpper(strings.SplitN(line, " ", 2)[0]); cmd {
+					case "DATA":
+						_ = tp.PrintfLine("354 go ahead")
+						data, err := tp.ReadDotBytes()
+						if err != nil {
+							return
+						}
+						messages <- string(data)
+						_ = tp.PrintfLine("250 OK")
+					case "QUIT":
+						_ = tp.PrintfLine("221 bye")
+						return
+					default:
+						_ = tp.PrintfLine("250 OK")
+					}
+				}
+			}()
+		}
+	}()
+	addr := l.Addr().(*net.TCPAddr)
+	return addr.IP.String(), addr.Port, messages
+}
+
+func TestSendEmailWithAttachments(t *testing.T) {
+	r := require.New(t)
+	ctx := context.Background()
+	host, port, messages := startSMTPServer(t)
+	pCtx := process.NewContext(process.ContextData{})
+	pCtx.PushData(model.ContextStepSessionID, "attachments-id")
+	wfCtx := new(wfContext.WorkflowContext)
+	r.NoError(wfCtx.LoadFromConfigMap(ctx, corev1.ConfigMap{Data: map[string]string{
+		wfContext.ConfigMapKeyVars: `outputs: summary: {passed: 3}`,
+	}}))
+	params := &MailParams{
+		Params: MailVars{
+			From: Sender{Address: "kubevela@example.com", Host: host, Port: port},
+			To:   []string{"user@example.com"},
+			Content: Content{
+				Subject: "Report",
+				Body:    "All the tests passed.",
+				HTML:    "<p>All the tests <b>passed</b>.</p>",
+				Attachments: []Attachment{
+					{Name: "report.csv", Content: base64.StdEncoding.EncodeToString([]byte("name,result\ntest,passed\n"))},
+					{Name: "summary", ContentFrom: &ContentFrom{Var: "outputs.summary"}, ContentType: "application/json"},
+				},
+			},
+		},
+		RuntimeParams: providertypes.RuntimeParams{ProcessContext: pCtx, Action: &mock.Action{}, WorkflowContext: wfCtx},
+	}
+	_, err := Send(ctx, params)
+	r.Equal(errors.GenericActionError(errors.ActionWait), err)
+
+	var raw string
+	select {
+	case raw = <-messages:
+	case <-time.After(10 * time.Second):
+		r.Fail("timeout waiting for the email")
+	}
+	r.Eventually(func() bool {
+		_, err := Send(ctx, params)
+		return err == nil
+	}, 10*time.Second, 100*time.Millisecond)
+
+	msg, err := mail.ReadMessage(strings.NewReader(raw))
+	r.NoError(err)
+	r.Equal("Report", msg.Header.Get("Subject"))
+	mediaType, mediaParams, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	r.NoError(err)
+	r.Equal("multipart/mixed", mediaType)
+	parts := readParts(t, msg.Body, mediaParams["boundary"])
+	r.Len(parts, 3)
+
+	mediaType, mediaParams, err = mime.ParseMediaType(parts[0].header.Get("Content-Type"))
+	r.NoError(err)
+	r.Equal("multipart/alternative", mediaType)
+	alternatives := readParts(t, strings.NewReader(parts[0].body), mediaParams["boundary"])
+	r.Len(alternatives, 2)
+	r.True(strings.HasPrefix(alternatives[0].header.Get("Content-Type"), "text/plain"))
+	r.Equal("All the tests passed.", alternatives[0].body)
+	r.True(strings.HasPrefix(alternatives[1].header.Get("Content-Type"), "text/html"))
+	r.Equal("<p>All the tests <b>passed</b>.</p>", alternatives[1].body)
+
+	r.Equal(`attachment; filename="report.csv"`, parts[1].header.Get("Content-Disposition"))
+	r.True(strings.HasPrefix(parts[1].header.Get("Content-Type"), "text/csv"))
+	r.Equal("name,result\ntest,passed\n", parts[1].body)
+	r.Equal(`attachment; filename="summary"`, parts[2].header.Get("Content-Disposition"))
+	r.Equal("application/json", parts[2].header.Get("Content-Type"))
+	r.JSONEq(`{"passed":3}`, parts[2].body)
+
+	params.Params.Content.Attachments = []Attachment{{Name: "invalid", Content: "not base64!"}}
+	_, err = Send(ctx, params)
+	r.ErrorContains(err, "invalid attachment invalid: the content must be base64 encoded")
+}
+
+type part struct {
+	header textproto.MIMEHeader
+	body   string
+}
+
+// readParts reads the multipart body, the quoted-printable parts are decoded by the multipart reader and the
+// base64 parts are decoded here
+func readParts(t *testing.T, body io.Reader, boundary string) []part {
+	r := require.New(t)
+	var parts []part
+	reader := multipart.NewReader(bufio.NewReader(body), boundary)
+	for {
+		p, err := reader.NextPart()
+		if err == io.EOF {
+			return parts
+		}
+		r.NoError(err)
+		b, err := io.ReadAll(p)
+		r.NoError(err)
+		if p.Header.Get("Content-Transfer-Encoding") == "base64" {
+			b, err = base64.StdEncoding.DecodeString(strings.ReplaceAll(string(b), "\r\n", ""))
+			r.NoError(err)
+		}
+		parts = append(parts, part{header: p.Header, body: string(b)})
+	}
+}
@@ -0,0 +1,28 @@
+// freeze.cue
+
+#Gate: {
+	#do:       "gate"
+	#provider: "freeze"
+
+	$params: {
+		// +usage=The configmap which contains the freeze windows, each window has the name, reason, start, end in RFC3339 and the environments it applies to
+		configMap: {
+			// +usage=The name of the configmap
+			name: string
+			// +usage=The namespace of the configmap, default to the namespace of the workflow
+			namespace?: string
+			// +usage=The key of the freeze windows in the configmap
+			key: *"windows" | string
+		}
+		// +usage=The environment to deploy to, only the windows apply to all the environments are checked if not specified
+		environment?: string
+		// +usage=Wait until the freeze window ends or fail the step immediately if the step is in a freeze window
+		policy: *"wait" | "fail"
+	}
+
+	$returns?: {
+		// +usage=Whether the deployment is frozen
+		frozen: bool
+	}
+	...
+}
@@ -0,0 +1,206 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package freeze
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	cuexruntime "github.com/kubevela/pkg/cue/cuex/runtime"
+
+	"github.com/kubevela/workflow/pkg/cue/model"
+	"github.com/kubevela/workflow/pkg/errors"
+	providertypes "github.com/kubevela/workflow/pkg/providers/types"
+)
+
+const (
+	// ProviderName is provider name for install.
+	ProviderName = "freeze"
+	// DefaultWindowsKey is the default key of the freeze windows in the configmap.
+	DefaultWindowsKey = "windows"
+)
+
+const (
+	// PolicyWait blocks the step until the freeze window ends.
+	PolicyWait = "wait"
+	// PolicyFail fails the step immediately if it is in a freeze window.
+	PolicyFail = "fail"
+)
+
+var now = time.Now
+
+// Window is a freeze window, the deployments to the environments are frozen in [start, end).
+type Window struct {
+	Name   string `json:"name,omitempty"`
+	Reason string `json:"reason,omitempty"`
+	Start  string `json:"start"`
+	End    string `json:"end"`
+	// Environments are the environments the window applies to, the window applies to all the environments if empty
+	Environments []string `json:"environments,omitempty"`
+}
+
+// ConfigMapRef is the reference of the configmap.
+type ConfigMapRef struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"`
+	Key       string `json:"key,omitempty"`
+}
+
+// GateVars .
+type GateVars struct {
+	ConfigMap   ConfigMapRef `json:"configMap"`
+	Environment string       `json:"environment,omitempty"`
+	Policy      string       `json:"policy,omitempty"`
+}
+
+// GateReturnVars .
+type GateReturnVars struct {
+	Frozen bool `json:"frozen"`
+}
+
+// GateParams .
+type GateParams = providertypes.Params[GateVars]
+
+// GateReturns .
+type GateReturns = providertypes.Returns[GateReturnVars]
+
+// Gate checks the current time against the freeze windows in the configmap, and waits until the active
+// window ends or fails the step according to the policy.
+func Gate(ctx context.Context, params *GateParams) (*GateReturns, error) {
+	vars := params.Params
+	policy := vars.Policy
+	if policy == "" {
+		policy = PolicyWait
+	}
+	if policy != PolicyWait && policy != PolicyFail {
+		return nil, fmt.Errorf("unknown freeze policy %s", policy)
+	}
+	windows, err := loadWindows(ctx, params.KubeClient, vars.ConfigMap, fmt.Sprint(params.ProcessContext.GetData(model.ContextNamespace)))
+	if err != nil {
+		return nil, err
+	}
+	active, end, err := activeWindow(windows, vars.Environment, now())
+	if err != nil {
+		return nil, err
+	}
+	if active == nil {
+		return &GateReturns{Returns: GateReturnVars{Frozen: false}}, nil
+	}
+	msg := freezeMessage(active, end)
+	if policy == PolicyFail {
+		params.Action.Fail(msg)
+		return nil, errors.GenericActionError(errors.ActionTerminate)
+	}
+	params.Action.Wait(msg)
+	return nil, errors.GenericActionError(errors.ActionWait)
+}
+
+func loadWindows(ctx context.Context, cli client.Client, ref ConfigMapRef, defaultNamespace string) ([]Window, error) {
+	namespace := ref.Namespace
+	if namespace == "" {
+		namespace = defaultNamespace
+	}
+	key := ref.Key
+	if key == "" {
+		key = DefaultWindowsKey
+	}
+	cm := &corev1.ConfigMap{}
+	if err := cli.Get(ctx, client.ObjectKey{Namespace: namespace, Name: ref.Name}, cm); err != nil {
+		return nil, fmt.Errorf("failed to get the freeze windows %s/%s: %w", namespace, ref.Name, err)
+	}
+	var windows []Window
+	if err := yaml.Unmarshal([]byte(cm.Data[key]), &windows); err != nil {
+		return nil, fmt.Errorf("invalid freeze windows in %s/%s: %w", namespace, ref.Name, err)
+	}
+	return windows, nil
+}
+
+// activeWindow returns the window applies to the environment at the time, the one ends the latest is
+// returned if there are multiple active windows.
+func activeWindow(windows []Window, env string, t time.Time) (*Window, time.Time, error) {
+	var active *Window
+	var activeEnd time.Time
+	for i, w := range windows {
+		start, err := time.Parse(time.RFC3339, w.Start)
+		if err != nil {
+			return nil, time.Time{}, fmt.Errorf("invalid start of freeze window %s: %w", windowName(w, i), err)
+		}
+		end, err := time.Parse(time.RFC3339, w.End)
+		if err != nil {
+			return nil, time.Time{}, fmt.Errorf("invalid end of freeze window %s: %w", windowName(w, i), err)
+		}
+		if !appliesTo(w, env) || t.Before(start) || !t.Before(end) {
+			continue
+		}
+		if active == nil || end.After(activeEnd) {
+			active, activeEnd = &windows[i], end
+		}
+	}
+	return active, activeEnd, nil
+}
+
+func appliesTo(w Window, env string) bool {
+	if len(w.Environments) == 0 {
+		return true
+	}
+	for _, e := range w.Environments {
+		if e == env {
+			return true
+		}
+	}
+	return false
+}
+
+func windowName(w Window, i int) string {
+	if w.Name != "" {
+		return w.Name
+	}
+	return fmt.Sprintf("#%d", i)
+}
+
+func freezeMessage(w *Window, end time.Time) string {
+	msg := fmt.Sprintf("Deployment is frozen until %s", end.UTC().Format(time.RFC3339))
+	if w.Name != "" {
+		msg = fmt.Sprintf("Deployment is frozen by window %s until %s", w.Name, end.UTC().Format(time.RFC3339))
+	}
+	if reason := strings.TrimSpace(w.Reason); reason != "" {
+		msg += ": " + reason
+	}
+	return msg
+}
+
+//go:embed freeze.cue
+var template string
+
+// GetTemplate returns the cue template.
+func GetTemplate() string {
+	return template
+}
+
+// GetProviders returns the cue providers.
+func GetProviders() map[string]cuexruntime.ProviderFn {
+	return map[string]cuexruntime.ProviderFn{
+		"gate": providertypes.GenericProviderFn[GateVars, GateReturns](Gate),
+	}
+}
@@ -0,0 +1,138 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package freeze
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/kubevela/workflow/pkg/cue/process"
+	"github.com/kubevela/workflow/pkg/errors"
+	"github.com/kubevela/workflow/pkg/mock"
+	providertypes "github.com/kubevela/workflow/pkg/providers/types"
+)
+
+func TestGate(t *testing.T) {
+	cli := fake.NewClientBuilder().WithObjects(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "freeze", Namespace: "default"},
+		Data: map[string]string{"windows": `
+- name: black-friday
+  reason: Black Friday traffic
+  start: "2026-11-27T00:00:00Z"
+  end: "2026-11-30T00:00:00Z"
+  environments: ["prod"]
+- name: year-end
+  start: "2026-12-24T00:00:00Z"
+  end: "2027-01-02T00:00:00Z"
+`},
+	}).Build()
+	pCtx := process.NewContext(process.ContextData{Name: "run", Namespace: "default"})
+	gate := func(at string, act *mock.Action, vars GateVars) (*GateReturns, error) {
+		current, err := time.Parse(time.RFC3339, at)
+		if err != nil {
+			return nil, err
+		}
+		origin := now
+		now = func() time.Time { return current }
+		defer func() { now = origin }()
+		vars.ConfigMap = ConfigMapRef{Name: "freeze"}
+		return Gate(context.Background(), &GateParams{Params: vars, RuntimeParams: providertypes.RuntimeParams{KubeClient: cli, Action: act, ProcessContext: pCtx}})
+	}
+
+	testCases := map[string]struct {
+		at      string
+		vars    GateVars
+		frozen  bool
+		phase   string
+		message string
+	}{
+		"out of freeze": {
+			at:   "2026-11-20T00:00:00Z",
+			vars: GateVars{Environment: "prod"},
+		},
+		"freeze of other environment": {
+			at:   "2026-11-28T00:00:00Z",
+			vars: GateVars{Environment: "staging"},
+		},
+		"window end is exclusive": {
+			at:   "2026-11-30T00:00:00Z",
+			vars: GateVars{Environment: "prod"},
+		},
+		"wait in freeze": {
+			at:      "2026-11-28T00:00:00Z",
+			vars:    GateVars{Environment: "prod"},
+			frozen:  true,
+			phase:   "Wait",
+			message: "Deployment is frozen by window black-friday until 2026-11-30T00:00:00Z: Black Friday traffic",
+		},
+		"fail in freeze": {
+			at:      "2026-12-25T00:00:00Z",
+			vars:    GateVars{Policy: PolicyFail},
+			frozen:  true,
+			phase:   "Fail",
+			message: "Deployment is frozen by window year-end until 2027-01-02T00:00:00Z",
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			r := require.New(t)
+			act := &mock.Action{}
+			res, err := gate(tc.at, act, tc.vars)
+			if !tc.frozen {
+				r.NoError(err)
+				r.False(res.Returns.Frozen)
+				r.Equal("", act.Phase)
+				return
+			}
+			if tc.phase == "Wait" {
+				r.Equal(errors.GenericActionError(errors.ActionWait), err)
+			} else {
+				r.Equal(errors.GenericActionError(errors.ActionTerminate), err)
+			}
+			r.Equal(tc.phase, act.Phase)
+			r.Equal(tc.message, act.Msg)
+		})
+	}
+
+	t.Run("unknown policy", func(t *testing.T) {
+		_, err := gate("2026-11-20T00:00:00Z", &mock.Action{}, GateVars{Policy: "skip"})
+		require.EqualError(t, err, "unknown freeze policy skip")
+	})
+}
+
+func TestActiveWindow(t *testing.T) {
+	r := require.New(t)
+	at := time.Date(2026, 12, 1, 0, 0, 0, 0, time.UTC)
+	windows := []Window{
+		{Name: "short", Start: "2026-11-30T00:00:00Z", End: "2026-12-02T00:00:00Z"},
+		{Name: "long", Start: "2026-11-30T00:00:00+08:00", End: "2026-12-10T00:00:00+08:00"},
+	}
+	active, end, err := activeWindow(windows, "", at)
+	r.NoError(err)
+	r.Equal("long", active.Name)
+	r.Equal("2026-12-09T16:00:00Z", end.UTC().Format(time.RFC3339))
+
+	_, _, err = activeWindow([]Window{{Start: "tomorrow", End: "2026-12-02T00:00:00Z"}}, "", at)
+	r.Error(err)
+	r.Contains(err.Error(), "invalid start of freeze window #0")
+}
@@ -0,0 +1,250 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package git
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"github.com/kubevela/workflow/pkg/cue/model"
+	providertypes "github.com/kubevela/workflow/pkg/providers/types"
+)
+
+var (
+	// WorkspaceDir is the directory which the repositories are cloned into
+	WorkspaceDir = filepath.Join(os.TempDir(), "workflow-git-workspace")
+	// WorkspaceTTL is the time after which the cloned and the cached repositories are removed if they are not
+	// cloned or fetched again
+	WorkspaceTTL = 24 * time.Hour
+)
+
+var (
+	commitPrefix = regexp.MustCompile(`^[0-9a-fA-F]{4,40}$`)
+	// scpLikeRepo matches the repositories like git@github.com:org/repo.git, which are cloned with ssh
+	scpLikeRepo = regexp.MustCompile(`^([^/@:]+@)?[^/@:]{2,}:`)
+)
+
+// allowedProtocols are the protocols which the repositories can be cloned with, the local repositories
+// and the transport helpers are rejected so that the files of the controller cannot be read.
+var allowedProtocols = []string{"http", "https", "ssh", "git"}
+
+// CloneVars .
+type CloneVars struct {
+	Repo string `json:"repo"`
+	// Ref is the branch, tag or commit to checkout, the default branch is used if not set
+	Ref string `json:"ref,omitempty"`
+	// Depth is the number of the commits to fetch, all the history is fetched if not set
+	Depth int `json:"depth,omitempty"`
+	// Path is the directory to clone into, it is under the directory of the namespace and the name of the
+	// workflow in WorkspaceDir so that the runs can not replace the repositories of each other
+	Path      string     `json:"path"`
+	SecretRef *SecretRef `json:"secretRef,omitempty"`
+}
+
+// CloneReturnVars .
+type CloneReturnVars struct {
+	// Commit is the resolved commit sha of the ref
+	Commit string `json:"commit"`
+	// Dir is the absolute directory of the cloned repository
+	Dir string `json:"dir"`
+}
+
+// CloneParams .
+type CloneParams = providertypes.Params[CloneVars]
+
+// CloneReturns .
+type CloneReturns = providertypes.Returns[CloneReturnVars]
+
+// Clone fetches the ref of the repository into the workspace and checks out the commit with a detached HEAD.
+// The credentials are passed with the environment variables, so they are neither stored in the cloned
+// repository nor exposed in the arguments.
+func Clone(ctx context.Context, params *CloneParams) (*CloneReturns, error) {
+	vars := params.Params
+	if vars.Repo == "" || vars.Path == "" {
+		return nil, fmt.Errorf("the repo and path are required")
+	}
+	for _, arg := range []string{vars.Repo, vars.Ref} {
+		if strings.HasPrefix(arg, "-") {
+			return nil, fmt.Errorf("invalid argument %s", arg)
+		}
+	}
+	if err := validateRepo(vars.Repo); err != nil {
+		return nil, err
+	}
+	if vars.Depth < 0 {
+		return nil, fmt.Errorf("invalid depth %d", vars.Depth)
+	}
+	if !filepath.IsLocal(vars.Path) {
+		return nil, fmt.Errorf("invalid path %s: must be a relative path inside the workspace", vars.Path)
+	}
+	// the protocols are also restricted in git, which covers the redirects of the remote
+	env := []string{"GIT_TERMINAL_PROMPT=0", "GIT_ALLOW_PROTOCOL=" + strings.Join(allowedProtocols, ":")}
+	if vars.SecretRef != nil {
+		authEnv, cleanup, err := loadAuth(ctx, params.KubeClient, *vars.SecretRef, fmt.Sprint(params.ProcessContext.GetData(model.ContextNamespace)))
+		if err != nil {
+			return nil, err
+		}
+		defer cleanup()
+		env = append(env, authEnv...)
+	}
+
+	cleanupExpired(WorkspaceDir, ".git", time.Now())
+	namespace, name := fmt.Sprint(params.ProcessContext.GetData(model.ContextNamespace)), fmt.Sprint(params.ProcessContext.GetData(model.ContextName))
+	if !filepath.IsLocal(namespace) || !filepath.IsLocal(name) || strings.ContainsRune(namespace+name, filepath.Separator) {
+		return nil, fmt.Errorf("invalid workflow %s/%s", namespace, name)
+	}
+	dir, err := filepath.Abs(filepath.Join(WorkspaceDir, namespace, name, vars.Path))
+	if err != nil {
+		return nil, err
+	}
+	defer lockDir(dir)()
+	if err := os.RemoveAll(dir); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return nil, err
+	}
+	if _, err := run(ctx, dir, env, "init", "--quiet"); err != nil {
+		return nil, fmt.Errorf("failed to init the repository: %w", err)
+	}
+	if err := fetchRef(ctx, dir, vars.Repo, vars.Ref, vars.Depth, env); err != nil {
+		return nil, err
+	}
+	out, err := run(ctx, dir, env, "rev-parse", "--verify", "HEAD")
+	if err != nil {
+		return nil, err
+	}
+	// the modification time of the directory tells when it is cloned last time for the cleanup
+	now := time.Now()
+	if err := os.Chtimes(dir, now, now); err != nil {
+		return nil, err
+	}
+	return &CloneReturns{Returns: CloneReturnVars{Commit: strings.TrimSpace(out), Dir: dir}}, nil
+}
+
+// fetchRef fetches the ref and checks it out. The abbreviated commits can not be fetched directly, so all the
+// branches and tags are fetched to resolve them if fetching the ref fails.
+func fetchRef(ctx context.Context, dir, repo, ref string, depth int, env []string) error {
+	args := []string{"fetch", "--quiet", "--no-tags"}
+	if depth > 0 {
+		args = append(args, fmt.Sprintf("--depth=%d", depth))
+	}
+	args = append(args, "--", repo)
+	if ref != "" {
+		args = append(args, ref)
+	}
+	_, err := run(ctx, dir, env, args...)
+	if err == nil {
+		if _, err := run(ctx, dir, env, "checkout", "--quiet", "--detach", "FETCH_HEAD"); err != nil {
+			return fmt.Errorf("failed to checkout %s: %w", ref, err)
+		}
+		return nil
+	}
+	if !commitPrefix.MatchString(ref) {
+		return fmt.Errorf("failed to fetch %s of %s: %w", ref, repo, err)
+	}
+	if _, err := run(ctx, dir, env, "fetch", "--quiet", "--no-tags", "--", repo,
+		"+refs/heads/*:refs/remotes/origin/*", "+refs/tags/*:refs/tags/*"); err != nil {
+		return fmt.Errorf("failed to fetch %s: %w", repo, err)
+	}
+	if _, err := run(ctx, dir, env, "checkout", "--quiet", "--detach", commit(ref)); err != nil {
+		return fmt.Errorf("failed to checkout %s: %w", ref, err)
+	}
+	return nil
+}
+
+// validateRepo rejects the local repositories and the transport helpers like ext::, only the urls with the
+// allowed protocols and the scp-like ssh repositories are accepted.
+func validateRepo(repo string) error {
+	if scheme, _, ok := strings.Cut(repo, "://"); ok {
+		for _, protocol := range allowedProtocols {
+			if strings.EqualFold(scheme, protocol) {
+				return nil
+			}
+		}
+		return fmt.Errorf("invalid repo %s: the protocol %s is not allowed", repo, scheme)
+	}
+	if !strings.Contains(repo, "::") && scpLikeRepo.MatchString(repo) {
+		return nil
+	}
+	return fmt.Errorf("invalid repo %s: only the remote repositories with %s or scp-like ssh are allowed", repo, strings.Join(allowedProtocols, ", "))
+}
+
+// lockDir locks the directory and returns the unlock function, it retries if the lock is removed by the
+// cleanup while waiting for it.
+func lockDir(dir string) func() {
+	for {
+		v, _ := repoLocks.LoadOrStore(dir, &sync.Mutex{})
+		mu := v.(*sync.Mutex)
+		mu.Lock()
+		if current, ok := repoLocks.Load(dir); ok && current == v {
+			return mu.Unlock
+		}
+		mu.Unlock()
+	}
+}
+
+// cleanupExpired removes the repositories under the root which are not used within the WorkspaceTTL, the
+// repositories are the directories containing the marker, and the ones being used are skipped.
+func cleanupExpired(root, marker string, now time.Time) {
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return
+	}
+	_ = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() || path == root {
+			return nil
+		}
+		if _, err := os.Stat(filepath.Join(path, marker)); err != nil {
+			return nil
+		}
+		if info, err := d.Info(); err == nil && now.Sub(info.ModTime()) > WorkspaceTTL {
+			removeClone(root, path)
+		}
+		// the repositories are not nested
+		return filepath.SkipDir
+	})
+}
+
+func removeClone(root, dir string) {
+	v, _ := repoLocks.LoadOrStore(dir, &sync.Mutex{})
+	mu := v.(*sync.Mutex)
+	if !mu.TryLock() {
+		return
+	}
+	defer mu.Unlock()
+	if err := os.RemoveAll(dir); err != nil {
+		klog.ErrorS(err, "Failed to remove the expired git repository", "dir", dir)
+		return
+	}
+	repoLocks.Delete(dir)
+	// the parents are removed if they become empty
+	for parent := filepath.Dir(dir); parent != root && strings.HasPrefix(parent, root); parent = filepath.Dir(parent) {
+		if os.Remove(parent) != nil {
+			break
+		}
+	}
+}
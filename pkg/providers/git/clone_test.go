@@ -0,0 +1,202 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package git
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kubevela/workflow/pkg/cue/process"
+	providertypes "github.com/kubevela/workflow/pkg/providers/types"
+)
+
+func TestClone(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	r := require.New(t)
+	origin := WorkspaceDir
+	WorkspaceDir = t.TempDir()
+	defer func() { WorkspaceDir = origin }()
+
+	src := t.TempDir()
+	git := func(dir string, args ...string) string {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com", "-c", "commit.gpgsign=false", "-c", "tag.gpgsign=false"}, args...)...)
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		r.NoError(err, string(out))
+		return strings.TrimSpace(string(out))
+	}
+	commit := func(msg string) string {
+		r.NoError(os.WriteFile(filepath.Join(src, "file.txt"), []byte(msg), 0600))
+		git(src, "add", "-A")
+		git(src, "commit", "--quiet", "-m", msg)
+		return git(src, "rev-parse", "HEAD")
+	}
+	git(src, "init", "--quiet", "--initial-branch=main")
+	first := commit("first")
+	git(src, "tag", "-a", "v1", "-m", "v1")
+	second := commit("second")
+	git(src, "checkout", "--quiet", "-b", "feature")
+	feature := commit("feature")
+	git(src, "checkout", "--quiet", "main")
+	third := commit("third")
+	// the local repositories are rejected, so the repository is served with the smart http of git
+	root := t.TempDir()
+	git(src, "clone", "--quiet", "--bare", src, filepath.Join(root, "repo.git"))
+	repo := serveRepo(t, root) + "/repo.git"
+
+	workspace := filepath.Join(WorkspaceDir, "default", "run")
+	cloneIn := func(namespace, name string, vars CloneVars) (*CloneReturnVars, error) {
+		pCtx := process.NewContext(process.ContextData{Name: name, Namespace: namespace})
+		res, err := Clone(context.Background(), &CloneParams{Params: vars, RuntimeParams: providertypes.RuntimeParams{ProcessContext: pCtx}})
+		if err != nil {
+			return nil, err
+		}
+		return &res.Returns, nil
+	}
+	clone := func(vars CloneVars) (*CloneReturnVars, error) {
+		return cloneIn("default", "run", vars)
+	}
+	content := func(dir string) string {
+		b, err := os.ReadFile(filepath.Join(dir, "file.txt"))
+		r.NoError(err)
+		return string(b)
+	}
+	detached := func(dir string) bool {
+		return exec.Command("git", "-C", dir, "symbolic-ref", "-q", "HEAD").Run() != nil
+	}
+
+	t.Run("default branch", func(t *testing.T) {
+		res, err := clone(CloneVars{Repo: repo, Path: "src"})
+		require.NoError(t, err)
+		require.Equal(t, third, res.Commit)
+		require.Equal(t, filepath.Join(workspace, "src"), res.Dir)
+		require.Equal(t, "third", content(res.Dir))
+	})
+
+	t.Run("shallow branch", func(t *testing.T) {
+		res, err := clone(CloneVars{Repo: repo, Ref: "feature", Depth: 1, Path: "src"})
+		require.NoError(t, err)
+		require.Equal(t, feature, res.Commit)
+		require.Equal(t, "feature", content(res.Dir))
+		require.Equal(t, "1", git(res.Dir, "rev-list", "--count", "HEAD"))
+		require.True(t, detached(res.Dir))
+	})
+
+	t.Run("annotated tag", func(t *testing.T) {
+		res, err := clone(CloneVars{Repo: repo, Ref: "v1", Path: "tag"})
+		require.NoError(t, err)
+		require.Equal(t, first, res.Commit)
+		require.Equal(t, "first", content(res.Dir))
+	})
+
+	t.Run("commit", func(t *testing.T) {
+		res, err := clone(CloneVars{Repo: repo, Ref: second, Depth: 1, Path: "sha"})
+		require.NoError(t, err)
+		require.Equal(t, second, res.Commit)
+		require.Equal(t, "second", content(res.Dir))
+		require.True(t, detached(res.Dir))
+	})
+
+	t.Run("abbreviated commit", func(t *testing.T) {
+		res, err := clone(CloneVars{Repo: repo, Ref: second[:8], Path: "sha"})
+		require.NoError(t, err)
+		require.Equal(t, second, res.Commit)
+		require.True(t, detached(res.Dir))
+	})
+
+	t.Run("unknown ref", func(t *testing.T) {
+		_, err := clone(CloneVars{Repo: repo, Ref: "unknown", Path: "src"})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to fetch unknown of "+repo)
+	})
+
+	t.Run("invalid path", func(t *testing.T) {
+		_, err := clone(CloneVars{Repo: repo, Path: "../outside"})
+		require.EqualError(t, err, "invalid path ../outside: must be a relative path inside the workspace")
+	})
+
+	t.Run("local repo", func(t *testing.T) {
+		for _, local := range []string{"file://" + filepath.Join(root, "repo.git"), filepath.Join(root, "repo.git"), "./repo.git", "ext::sh -c id"} {
+			_, err := clone(CloneVars{Repo: local, Path: "local"})
+			require.Error(t, err, local)
+			require.Contains(t, err.Error(), "invalid repo "+local)
+		}
+		_, err := os.Stat(filepath.Join(workspace, "local"))
+		require.True(t, os.IsNotExist(err))
+	})
+
+	t.Run("isolated workflows", func(t *testing.T) {
+		res, err := clone(CloneVars{Repo: repo, Ref: "feature", Path: "shared"})
+		require.NoError(t, err)
+		other, err := cloneIn("other", "run", CloneVars{Repo: repo, Path: "shared"})
+		require.NoError(t, err)
+		require.Equal(t, filepath.Join(WorkspaceDir, "other", "run", "shared"), other.Dir)
+		require.Equal(t, "feature", content(res.Dir))
+		require.Equal(t, "third", content(other.Dir))
+
+		_, err = cloneIn("..", "run", CloneVars{Repo: repo, Path: "shared"})
+		require.EqualError(t, err, "invalid workflow ../run")
+	})
+
+	t.Run("cleanup", func(t *testing.T) {
+		expired, err := clone(CloneVars{Repo: repo, Path: "nested/expired"})
+		require.NoError(t, err)
+		recent, err := clone(CloneVars{Repo: repo, Path: "recent"})
+		require.NoError(t, err)
+		past := time.Now().Add(-2 * WorkspaceTTL)
+		require.NoError(t, os.Chtimes(expired.Dir, past, past))
+		_, ok := repoLocks.Load(expired.Dir)
+		require.True(t, ok)
+
+		_, err = clone(CloneVars{Repo: repo, Path: "src"})
+		require.NoError(t, err)
+		_, err = os.Stat(filepath.Join(workspace, "nested"))
+		require.True(t, os.IsNotExist(err))
+		_, ok = repoLocks.Load(expired.Dir)
+		require.False(t, ok)
+		require.Equal(t, "third", content(recent.Dir))
+	})
+}
+
+func TestValidateRepo(t *testing.T) {
+	for repo, valid := range map[string]bool{
+		"https://github.com/kubevela/workflow.git": true,
+		"HTTP://example.com/repo.git":              true,
+		"ssh://git@github.com/kubevela/workflow":   true,
+		"git://example.com/repo.git":               true,
+		"git@github.com:kubevela/workflow.git":     true,
+		"github.com:kubevela/workflow.git":         true,
+		"file:///etc":                              false,
+		"/var/run/repo.git":                        false,
+		"repo.git":                                 false,
+		"../repo.git":                              false,
+		"C:/repo.git":                              false,
+		"ext::sh -c touch% /tmp/pwned":             false,
+		"fd::0":                                    false,
+	} {
+		require.Equal(t, valid, validateRepo(repo) == nil, repo)
+	}
+}
@@ -0,0 +1,63 @@
+// git.cue
+
+#Diff: {
+	#do:       "diff"
+	#provider: "git"
+
+	$params: {
+		// +usage=The url of the repository with the http, https, ssh or git protocol, the local repositories are not allowed
+		repo: string
+		// +usage=The base ref to compare, can be a branch, tag or commit
+		base: string
+		// +usage=The head ref to compare, can be a branch, tag or commit
+		head: string
+		// +usage=The path prefixes to filter the changed files, all the changed files are returned if not specified
+		paths?: [...string]
+		// +usage=The secret which contains the auth of the repository, with username and password, token, or ssh-privatekey and optional known_hosts
+		secretRef?: {
+			// +usage=The name of the secret
+			name: string
+			// +usage=The namespace of the secret, default to the namespace of the workflow
+			namespace?: string
+		}
+	}
+
+	$returns?: {
+		// +usage=The changed files between the base and the head
+		files: [...string]
+		// +usage=The path prefixes which contain changed files
+		paths: [...string]
+	}
+	...
+}
+
+#Clone: {
+	#do:       "clone"
+	#provider: "git"
+
+	$params: {
+		// +usage=The url of the repository with the http, https, ssh or git protocol, the local repositories are not allowed
+		repo: string
+		// +usage=The ref to checkout, can be a branch, tag or commit, the default branch is used if not specified
+		ref?: string
+		// +usage=The number of the commits to fetch for a shallow clone, all the history is fetched if not specified
+		depth?: int
+		// +usage=The directory to clone into under the workspace of the workflow in the controller, the existing directory is replaced and the directory is removed if it is not cloned again within a day
+		path: string
+		// +usage=The secret which contains the auth of the repository, with username and password, token, or ssh-privatekey and optional known_hosts
+		secretRef?: {
+			// +usage=The name of the secret
+			name: string
+			// +usage=The namespace of the secret, default to the namespace of the workflow
+			namespace?: string
+		}
+	}
+
+	$returns?: {
+		// +usage=The resolved commit sha of the ref
+		commit: string
+		// +usage=The absolute directory of the cloned repository
+		dir: string
+	}
+	...
+}
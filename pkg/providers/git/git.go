@@ -0,0 +1,277 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package git
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	_ "embed"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	cuexruntime "github.com/kubevela/pkg/cue/cuex/runtime"
+
+	"github.com/kubevela/workflow/pkg/cue/model"
+	providertypes "github.com/kubevela/workflow/pkg/providers/types"
+)
+
+const (
+	// ProviderName is provider name for install.
+	ProviderName = "git"
+)
+
+var (
+	// cacheDir is the directory of the cached repositories, the repositories are fetched incrementally
+	cacheDir = filepath.Join(os.TempDir(), "workflow-git-cache")
+	// repoLocks serializes the operations on the same cached repository
+	repoLocks sync.Map
+)
+
+// SecretRef is the reference of the secret which contains the auth of the repository, the secret can
+// contain username and password, token, or ssh-privatekey with an optional known_hosts.
+type SecretRef struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// DiffVars .
+type DiffVars struct {
+	Repo      string     `json:"repo"`
+	Base      string     `json:"base"`
+	Head      string     `json:"head"`
+	Paths     []string   `json:"paths,omitempty"`
+	SecretRef *SecretRef `json:"secretRef,omitempty"`
+}
+
+// DiffReturnVars .
+type DiffReturnVars struct {
+	// Files are the changed files between the refs
+	Files []string `json:"files"`
+	// Paths are the path prefixes which contain changed files
+	Paths []string `json:"paths"`
+}
+
+// DiffParams .
+type DiffParams = providertypes.Params[DiffVars]
+
+// DiffReturns .
+type DiffReturns = providertypes.Returns[DiffReturnVars]
+
+// Diff fetches the repository and returns the files changed between the base and the head refs. If the
+// paths are specified, only the files under the path prefixes are returned.
+func Diff(ctx context.Context, params *DiffParams) (*DiffReturns, error) {
+	vars := params.Params
+	if vars.Repo == "" || vars.Base == "" || vars.Head == "" {
+		return nil, fmt.Errorf("the repo, base and head are required")
+	}
+	for _, arg := range []string{vars.Repo, vars.Base, vars.Head} {
+		if strings.HasPrefix(arg, "-") {
+			return nil, fmt.Errorf("invalid argument %s", arg)
+		}
+	}
+	if err := validateRepo(vars.Repo); err != nil {
+		return nil, err
+	}
+	env := []string{"GIT_TERMINAL_PROMPT=0", "GIT_ALLOW_PROTOCOL=" + strings.Join(allowedProtocols, ":")}
+	if vars.SecretRef != nil {
+		authEnv, cleanup, err := loadAuth(ctx, params.KubeClient, *vars.SecretRef, fmt.Sprint(params.ProcessContext.GetData(model.ContextNamespace)))
+		if err != nil {
+			return nil, err
+		}
+		defer cleanup()
+		env = append(env, authEnv...)
+	}
+
+	cleanupExpired(cacheDir, "HEAD", time.Now())
+	dir, err := filepath.Abs(filepath.Join(cacheDir, repoKey(vars.Repo)))
+	if err != nil {
+		return nil, err
+	}
+	defer lockDir(dir)()
+	if err := fetch(ctx, dir, vars.Repo, env); err != nil {
+		return nil, err
+	}
+	// the modification time of the directory tells when it is fetched last time for the cleanup
+	now := time.Now()
+	if err := os.Chtimes(dir, now, now); err != nil {
+		return nil, err
+	}
+	out, err := run(ctx, dir, env, "diff", "--name-only", "--no-renames", commit(vars.Base), commit(vars.Head), "--")
+	if err != nil {
+		return nil, fmt.Errorf("failed to diff %s and %s: %w", vars.Base, vars.Head, err)
+	}
+	files, paths := filterFiles(strings.Split(strings.TrimSpace(out), "\n"), vars.Paths)
+	return &DiffReturns{Returns: DiffReturnVars{Files: files, Paths: paths}}, nil
+}
+
+func repoKey(repo string) string {
+	sum := sha256.Sum256([]byte(repo))
+	return hex.EncodeToString(sum[:8])
+}
+
+func commit(ref string) string {
+	return ref + "^{commit}"
+}
+
+// fetch initializes the cached bare repository if not exists and fetches all the branches and tags
+func fetch(ctx context.Context, dir, repo string, env []string) error {
+	if _, err := os.Stat(filepath.Join(dir, "HEAD")); err != nil {
+		if err := os.MkdirAll(dir, 0750); err != nil {
+			return err
+		}
+		if _, err := run(ctx, dir, env, "init", "--bare", "--quiet"); err != nil {
+			return fmt.Errorf("failed to init the repository cache: %w", err)
+		}
+	}
+	if _, err := run(ctx, dir, env, "fetch", "--quiet", "--no-tags", "--prune", "--", repo,
+		"+refs/heads/*:refs/heads/*", "+refs/tags/*:refs/tags/*"); err != nil {
+		return fmt.Errorf("failed to fetch %s: %w", repo, err)
+	}
+	return nil
+}
+
+func run(ctx context.Context, dir string, env []string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), env...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%w: %s", err, msg)
+		}
+		return "", err
+	}
+	return stdout.String(), nil
+}
+
+// filterFiles returns the files under the path prefixes and the prefixes which contain the files
+func filterFiles(files []string, prefixes []string) ([]string, []string) {
+	matched, paths := []string{}, []string{}
+	changed := map[string]bool{}
+	for _, file := range files {
+		if file == "" {
+			continue
+		}
+		if len(prefixes) == 0 {
+			matched = append(matched, file)
+			continue
+		}
+		match := false
+		for _, prefix := range prefixes {
+			if hasPathPrefix(file, prefix) {
+				match = true
+				changed[prefix] = true
+			}
+		}
+		if match {
+			matched = append(matched, file)
+		}
+	}
+	for _, prefix := range prefixes {
+		if changed[prefix] {
+			paths = append(paths, prefix)
+			// the duplicate prefixes are only returned once
+			delete(changed, prefix)
+		}
+	}
+	sort.Strings(matched)
+	return matched, paths
+}
+
+func hasPathPrefix(file, prefix string) bool {
+	prefix = strings.TrimSuffix(prefix, "/")
+	return prefix == "" || file == prefix || strings.HasPrefix(file, prefix+"/")
+}
+
+// loadAuth returns the environment variables to authenticate git with the secret
+func loadAuth(ctx context.Context, cli client.Client, ref SecretRef, defaultNamespace string) ([]string, func(), error) {
+	namespace := ref.Namespace
+	if namespace == "" {
+		namespace = defaultNamespace
+	}
+	secret := &corev1.Secret{}
+	if err := cli.Get(ctx, client.ObjectKey{Namespace: namespace, Name: ref.Name}, secret); err != nil {
+		return nil, nil, fmt.Errorf("failed to get the git secret %s/%s: %w", namespace, ref.Name, err)
+	}
+	noop := func() {}
+	if key := secret.Data[corev1.SSHAuthPrivateKey]; len(key) > 0 {
+		dir, err := os.MkdirTemp("", "workflow-git-ssh-")
+		if err != nil {
+			return nil, nil, err
+		}
+		cleanup := func() { _ = os.RemoveAll(dir) }
+		keyFile := filepath.Join(dir, "id")
+		if err := os.WriteFile(keyFile, key, 0600); err != nil {
+			cleanup()
+			return nil, nil, err
+		}
+		sshCmd := fmt.Sprintf("ssh -i %s -o IdentitiesOnly=yes", keyFile)
+		if knownHosts := secret.Data["known_hosts"]; len(knownHosts) > 0 {
+			hostsFile := filepath.Join(dir, "known_hosts")
+			if err := os.WriteFile(hostsFile, knownHosts, 0600); err != nil {
+				cleanup()
+				return nil, nil, err
+			}
+			sshCmd += fmt.Sprintf(" -o UserKnownHostsFile=%s -o StrictHostKeyChecking=yes", hostsFile)
+		} else {
+			sshCmd += " -o StrictHostKeyChecking=accept-new"
+		}
+		return []string{"GIT_SSH_COMMAND=" + sshCmd}, cleanup, nil
+	}
+	username, password := string(secret.Data[corev1.BasicAuthUsernameKey]), string(secret.Data[corev1.BasicAuthPasswordKey])
+	if token := string(secret.Data["token"]); token != "" {
+		password = token
+		if username == "" {
+			username = "git"
+		}
+	}
+	if password == "" {
+		return nil, noop, nil
+	}
+	// pass the header with the environment variables so that the credentials are not exposed in the arguments
+	header := "Authorization: Basic " + base64.StdEncoding.EncodeToString([]byte(username+":"+password))
+	return []string{"GIT_CONFIG_COUNT=1", "GIT_CONFIG_KEY_0=http.extraHeader", "GIT_CONFIG_VALUE_0=" + header}, noop, nil
+}
+
+//go:embed git.cue
+var template string
+
+// GetTemplate returns the cue template.
+func GetTemplate() string {
+	return template
+}
+
+// GetProviders returns the cue providers.
+func GetProviders() map[string]cuexruntime.ProviderFn {
+	return map[string]cuexruntime.ProviderFn{
+		"diff":  providertypes.GenericProviderFn[DiffVars, DiffReturns](Diff),
+		"clone": providertypes.GenericProviderFn[CloneVars, CloneReturns](Clone),
+	}
+}
@@ -0,0 +1,189 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package git
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http/cgi"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/kubevela/workflow/pkg/cue/process"
+	providertypes "github.com/kubevela/workflow/pkg/providers/types"
+)
+
+func TestDiff(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	r := require.New(t)
+	origin := cacheDir
+	cacheDir = t.TempDir()
+	defer func() { cacheDir = origin }()
+
+	root := t.TempDir()
+	repo := filepath.Join(root, "repo")
+	r.NoError(os.MkdirAll(repo, 0750))
+	git := func(args ...string) string {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com", "-c", "commit.gpgsign=false"}, args...)...)
+		cmd.Dir = repo
+		out, err := cmd.CombinedOutput()
+		r.NoError(err, string(out))
+		return strings.TrimSpace(string(out))
+	}
+	commit := func(msg string, files ...string) string {
+		for _, file := range files {
+			path := filepath.Join(repo, file)
+			r.NoError(os.MkdirAll(filepath.Dir(path), 0750))
+			r.NoError(os.WriteFile(path, []byte(msg), 0600))
+		}
+		git("add", "-A")
+		git("commit", "--quiet", "-m", msg)
+		return git("rev-parse", "HEAD")
+	}
+	git("init", "--quiet", "--initial-branch=main")
+	first := commit("init", "apps/api/main.go", "apps/web/index.html", "README.md")
+	git("tag", "v1")
+	commit("update api", "apps/api/main.go", "apps/api/handler.go")
+	commit("update docs", "docs/guide.md", "README.md")
+	git("checkout", "--quiet", "-b", "feature")
+	commit("update web", "apps/web/index.html")
+
+	url := serveRepo(t, root) + "/repo/.git"
+	pCtx := process.NewContext(process.ContextData{Name: "run", Namespace: "default"})
+	diff := func(vars DiffVars) (*DiffReturnVars, error) {
+		if vars.Repo == "" {
+			vars.Repo = url
+		}
+		res, err := Diff(context.Background(), &DiffParams{Params: vars, RuntimeParams: providertypes.RuntimeParams{ProcessContext: pCtx}})
+		if err != nil {
+			return nil, err
+		}
+		return &res.Returns, nil
+	}
+
+	t.Run("all changed files", func(t *testing.T) {
+		res, err := diff(DiffVars{Base: "v1", Head: "main"})
+		require.NoError(t, err)
+		require.Equal(t, []string{"README.md", "apps/api/handler.go", "apps/api/main.go", "docs/guide.md"}, res.Files)
+		require.Equal(t, []string{}, res.Paths)
+	})
+
+	t.Run("filter by paths", func(t *testing.T) {
+		res, err := diff(DiffVars{Base: first, Head: "feature", Paths: []string{"apps/api", "apps/web/", "apps/worker", "app"}})
+		require.NoError(t, err)
+		require.Equal(t, []string{"apps/api/handler.go", "apps/api/main.go", "apps/web/index.html"}, res.Files)
+		require.Equal(t, []string{"apps/api", "apps/web/"}, res.Paths)
+	})
+
+	t.Run("fetch new commits", func(t *testing.T) {
+		git("checkout", "--quiet", "main")
+		head := commit("add worker", "apps/worker/main.go")
+		res, err := diff(DiffVars{Base: "main~1", Head: head, Paths: []string{"apps"}})
+		require.NoError(t, err)
+		require.Equal(t, []string{"apps/worker/main.go"}, res.Files)
+		require.Equal(t, []string{"apps"}, res.Paths)
+	})
+
+	t.Run("unknown ref", func(t *testing.T) {
+		_, err := diff(DiffVars{Base: "v1", Head: "unknown"})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to diff v1 and unknown")
+	})
+
+	t.Run("invalid ref", func(t *testing.T) {
+		_, err := diff(DiffVars{Base: "--output=/tmp/x", Head: "main"})
+		require.EqualError(t, err, "invalid argument --output=/tmp/x")
+	})
+
+	t.Run("local repo", func(t *testing.T) {
+		for _, local := range []string{"file://" + repo, repo, "./repo", "ext::sh -c id"} {
+			_, err := diff(DiffVars{Repo: local, Base: "v1", Head: "main"})
+			require.Error(t, err, local)
+			require.Contains(t, err.Error(), "invalid repo "+local)
+		}
+	})
+
+	t.Run("cleanup", func(t *testing.T) {
+		expired := filepath.Join(cacheDir, "expired")
+		r.NoError(os.MkdirAll(expired, 0750))
+		r.NoError(os.WriteFile(filepath.Join(expired, "HEAD"), []byte("ref: refs/heads/main\n"), 0600))
+		past := time.Now().Add(-2 * WorkspaceTTL)
+		r.NoError(os.Chtimes(expired, past, past))
+
+		_, err := diff(DiffVars{Base: "v1", Head: "main"})
+		require.NoError(t, err)
+		_, err = os.Stat(expired)
+		require.True(t, os.IsNotExist(err))
+		_, err = os.Stat(filepath.Join(cacheDir, repoKey(url), "HEAD"))
+		require.NoError(t, err)
+	})
+}
+
+// serveRepo serves the repositories under the root with the smart http of git, since the local repositories
+// are rejected by the provider, and returns the url of the server.
+func serveRepo(t *testing.T, root string) string {
+	gitPath, err := exec.LookPath("git")
+	require.NoError(t, err)
+	server := httptest.NewServer(&cgi.Handler{
+		Path: gitPath,
+		Args: []string{"http-backend"},
+		Env:  []string{"GIT_PROJECT_ROOT=" + root, "GIT_HTTP_EXPORT_ALL=1"},
+	})
+	t.Cleanup(server.Close)
+	return server.URL
+}
+
+func TestLoadAuth(t *testing.T) {
+	r := require.New(t)
+	cli := fake.NewClientBuilder().WithObjects(
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "token", Namespace: "default"}, Data: map[string][]byte{"token": []byte("ghp_xxx")}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "ssh", Namespace: "ci"}, Data: map[string][]byte{corev1.SSHAuthPrivateKey: []byte("key")}},
+	).Build()
+
+	env, cleanup, err := loadAuth(context.Background(), cli, SecretRef{Name: "token"}, "default")
+	r.NoError(err)
+	cleanup()
+	r.Equal([]string{"GIT_CONFIG_COUNT=1", "GIT_CONFIG_KEY_0=http.extraHeader",
+		"GIT_CONFIG_VALUE_0=Authorization: Basic " + base64.StdEncoding.EncodeToString([]byte("git:ghp_xxx"))}, env)
+
+	env, cleanup, err = loadAuth(context.Background(), cli, SecretRef{Name: "ssh", Namespace: "ci"}, "default")
+	r.NoError(err)
+	r.Len(env, 1)
+	r.True(strings.HasPrefix(env[0], "GIT_SSH_COMMAND=ssh -i "))
+	keyFile := strings.Fields(env[0])[2]
+	key, err := os.ReadFile(keyFile)
+	r.NoError(err)
+	r.Equal("key", string(key))
+	cleanup()
+	_, err = os.Stat(keyFile)
+	r.True(os.IsNotExist(err))
+
+	_, _, err = loadAuth(context.Background(), cli, SecretRef{Name: "ssh"}, "default")
+	r.Error(err)
+}
@@ -0,0 +1,52 @@
+// grpc.cue
+
+#Call: {
+	#do:       "call"
+	#provider: "grpc"
+
+	$params: {
+		// +usage=The target of the server, e.g. greeter.default.svc:50051
+		target: string
+		// +usage=The full name of the service, e.g. helloworld.Greeter, can be omitted if the method is fully-qualified
+		service?: string
+		// +usage=The name of the method, or the fully-qualified method if the service is not specified, e.g. helloworld.Greeter/SayHello
+		method: string
+		// +usage=The request message in JSON, either an object or an encoded string
+		request?: {...} | string
+		// +usage=The metadata of the call
+		header?: [string]: string
+		// +usage=The base64 encoded FileDescriptorSet of the service and its dependencies, e.g. generated by protoc --include_imports -o, the server reflection is used if not specified
+		descriptorSet?: string
+		// +usage=The tls of the connection, the connection is plaintext if not specified, the values are PEM encoded or read from the secrets in the namespace of the workflow by default
+		tls?: {
+			// +usage=The client certificate
+			clientCert?: #TLSValue
+			// +usage=The client key
+			clientKey?: #TLSValue
+			// +usage=The ca bundle to verify the server certificate
+			caBundle?: #TLSValue
+			// +usage=Whether to skip verifying the server certificate
+			insecureSkipVerify?: bool
+		}
+		// +usage=The timeout of the call, default to 30s
+		timeout?: string
+	}
+
+	$returns?: {
+		// +usage=The response message in JSON
+		response: {...}
+		// +usage=The header metadata of the response
+		header?: [string]: [...string]
+	}
+	...
+}
+
+#TLSValue: {
+	value: string
+} | {
+	secretRef: {
+		name:       string
+		namespace?: string
+		key:        string
+	}
+}
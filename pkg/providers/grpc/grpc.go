@@ -0,0 +1,236 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package grpc
+
+import (
+	"context"
+	_ "embed"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	cuexruntime "github.com/kubevela/pkg/cue/cuex/runtime"
+
+	"github.com/kubevela/workflow/pkg/cue/model"
+	"github.com/kubevela/workflow/pkg/monitor/trace"
+	httpprovider "github.com/kubevela/workflow/pkg/providers/http"
+	providertypes "github.com/kubevela/workflow/pkg/providers/types"
+)
+
+const (
+	// ProviderName is provider name for install.
+	ProviderName = "grpc"
+	// DefaultTimeout is the timeout of the call if the timeout is not specified
+	DefaultTimeout = 30 * time.Second
+)
+
+// CallVars .
+type CallVars struct {
+	Target string `json:"target"`
+	// Service is the full name of the service, e.g. helloworld.Greeter, it can be omitted if the method
+	// is fully-qualified as helloworld.Greeter/SayHello
+	Service string `json:"service,omitempty"`
+	Method  string `json:"method"`
+	// Request is the request message in JSON, either an object or an encoded string
+	Request json.RawMessage   `json:"request,omitempty"`
+	Header  map[string]string `json:"header,omitempty"`
+	// DescriptorSet is the base64 encoded FileDescriptorSet which contains the service and its dependencies,
+	// e.g. generated by protoc --include_imports -o, the server reflection is used if not set
+	DescriptorSet string `json:"descriptorSet,omitempty"`
+	// TLS is the tls of the connection, the connection is plaintext if not set
+	TLS     *httpprovider.TLS `json:"tls,omitempty"`
+	Timeout string            `json:"timeout,omitempty"`
+}
+
+// CallReturnVars .
+type CallReturnVars struct {
+	Response interface{}         `json:"response"`
+	Header   map[string][]string `json:"header,omitempty"`
+}
+
+// CallParams .
+type CallParams = providertypes.Params[CallVars]
+
+// CallReturns .
+type CallReturns = providertypes.Returns[CallReturnVars]
+
+// Call invokes the unary method with the request in JSON and returns the response in JSON. The messages are
+// resolved with the descriptor set or the server reflection, and the non-OK status fails with its code and message.
+func Call(ctx context.Context, params *CallParams) (*CallReturns, error) {
+	vars := params.Params
+	if vars.Target == "" {
+		return nil, errors.New("the target is required")
+	}
+	service, method, err := parseMethod(vars.Service, vars.Method)
+	if err != nil {
+		return nil, err
+	}
+	timeout := DefaultTimeout
+	if vars.Timeout != "" {
+		if timeout, err = time.ParseDuration(vars.Timeout); err != nil {
+			return nil, fmt.Errorf("invalid timeout %s: %w", vars.Timeout, err)
+		}
+		if timeout <= 0 {
+			return nil, fmt.Errorf("invalid timeout %s: must be positive", vars.Timeout)
+		}
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	creds := insecure.NewCredentials()
+	if vars.TLS != nil {
+		config, err := httpprovider.NewTLSConfig(ctx, params.KubeClient, vars.TLS, fmt.Sprint(params.ProcessContext.GetData(model.ContextNamespace)))
+		if err != nil {
+			return nil, err
+		}
+		creds = credentials.NewTLS(config)
+	}
+	conn, err := grpc.DialContext(ctx, vars.Target, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial %s: %w", vars.Target, err)
+	}
+	defer conn.Close() //nolint:errcheck
+
+	var files *protoregistry.Files
+	if vars.DescriptorSet != "" {
+		files, err = parseDescriptorSet(vars.DescriptorSet)
+	} else {
+		files, err = resolveFiles(ctx, conn, service)
+	}
+	if err != nil {
+		return nil, err
+	}
+	desc, err := files.FindDescriptorByName(protoreflect.FullName(service))
+	if err != nil {
+		return nil, fmt.Errorf("service %s not found: %w", service, err)
+	}
+	serviceDesc, ok := desc.(protoreflect.ServiceDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a service", service)
+	}
+	methodDesc := serviceDesc.Methods().ByName(protoreflect.Name(method))
+	if methodDesc == nil {
+		return nil, fmt.Errorf("method %s not found in service %s", method, service)
+	}
+	if methodDesc.IsStreamingClient() || methodDesc.IsStreamingServer() {
+		return nil, fmt.Errorf("method %s/%s is streaming, only the unary methods are supported", service, method)
+	}
+
+	types := dynamicpb.NewTypes(files)
+	req := dynamicpb.NewMessage(methodDesc.Input())
+	if body, err := requestBody(vars.Request); err != nil {
+		return nil, err
+	} else if len(body) > 0 {
+		if err := (protojson.UnmarshalOptions{Resolver: types}).Unmarshal(body, req); err != nil {
+			return nil, fmt.Errorf("invalid request of %s: %w", methodDesc.Input().FullName(), err)
+		}
+	}
+	if len(vars.Header) > 0 {
+		ctx = metadata.NewOutgoingContext(ctx, metadata.New(vars.Header))
+	}
+	ctx = trace.AppendToOutgoingContext(ctx, params.ProcessContext)
+	resp := dynamicpb.NewMessage(methodDesc.Output())
+	var header metadata.MD
+	fullMethod := fmt.Sprintf("/%s/%s", service, method)
+	if err := conn.Invoke(ctx, fullMethod, req, resp, grpc.Header(&header)); err != nil {
+		st := status.Convert(err)
+		return nil, fmt.Errorf("gRPC call %s failed with code %s: %s", fullMethod, st.Code(), st.Message())
+	}
+	b, err := (protojson.MarshalOptions{Resolver: types}).Marshal(resp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode the response of %s: %w", fullMethod, err)
+	}
+	var response interface{}
+	if err := json.Unmarshal(b, &response); err != nil {
+		return nil, err
+	}
+	return &CallReturns{Returns: CallReturnVars{Response: response, Header: header}}, nil
+}
+
+// parseMethod returns the service and the method, the method can be fully-qualified as
+// [/]package.Service/Method if the service is not specified
+func parseMethod(service, method string) (string, string, error) {
+	if service == "" {
+		service, method, _ = strings.Cut(strings.TrimPrefix(method, "/"), "/")
+	}
+	if service == "" || method == "" || strings.Contains(method, "/") {
+		return "", "", errors.New("the method must be fully-qualified as package.Service/Method if the service is not specified")
+	}
+	return service, method, nil
+}
+
+// requestBody returns the JSON of the request, the request can be an encoded string
+func requestBody(request json.RawMessage) ([]byte, error) {
+	if len(request) == 0 || string(request) == "null" {
+		return nil, nil
+	}
+	if request[0] != '"' {
+		return request, nil
+	}
+	var s string
+	if err := json.Unmarshal(request, &s); err != nil {
+		return nil, err
+	}
+	return []byte(s), nil
+}
+
+func parseDescriptorSet(encoded string) (*protoregistry.Files, error) {
+	b, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("the descriptor set must be base64 encoded: %w", err)
+	}
+	set := &descriptorpb.FileDescriptorSet{}
+	if err := proto.Unmarshal(b, set); err != nil {
+		return nil, fmt.Errorf("invalid descriptor set: %w", err)
+	}
+	files, err := protodesc.NewFiles(set)
+	if err != nil {
+		return nil, fmt.Errorf("invalid descriptor set: %w", err)
+	}
+	return files, nil
+}
+
+//go:embed grpc.cue
+var template string
+
+// GetTemplate returns the cue template.
+func GetTemplate() string {
+	return template
+}
+
+// GetProviders returns the cue providers.
+func GetProviders() map[string]cuexruntime.ProviderFn {
+	return map[string]cuexruntime.ProviderFn{
+		"call": providertypes.GenericProviderFn[CallVars, CallReturns](Call),
+	}
+}
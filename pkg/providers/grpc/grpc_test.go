@@ -0,0 +1,177 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package grpc
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	testpb "google.golang.org/grpc/interop/grpc_testing"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/types/descriptorpb"
+	utilfeature "k8s.io/apiserver/pkg/util/feature"
+	featuregatetesting "k8s.io/component-base/featuregate/testing"
+
+	"github.com/kubevela/workflow/pkg/cue/model"
+	"github.com/kubevela/workflow/pkg/cue/process"
+	"github.com/kubevela/workflow/pkg/features"
+	providertypes "github.com/kubevela/workflow/pkg/providers/types"
+)
+
+type testServer struct {
+	testpb.UnimplementedTestServiceServer
+}
+
+func (testServer) UnaryCall(ctx context.Context, req *testpb.SimpleRequest) (*testpb.SimpleResponse, error) {
+	if s := req.GetResponseStatus(); s != nil {
+		return nil, status.Error(codes.Code(s.GetCode()), s.GetMessage())
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	_ = grpc.SetHeader(ctx, metadata.Pairs("x-server", "test"))
+	resp := &testpb.SimpleResponse{Payload: req.GetPayload()}
+	if users := md.Get("user"); len(users) > 0 {
+		resp.Username = users[0]
+	}
+	return resp, nil
+}
+
+func startServer(t *testing.T, withReflection bool) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	s := grpc.NewServer()
+	testpb.RegisterTestServiceServer(s, testServer{})
+	if withReflection {
+		reflection.Register(s)
+	}
+	go func() { _ = s.Serve(l) }()
+	t.Cleanup(s.Stop)
+	return l.Addr().String()
+}
+
+func TestCall(t *testing.T) {
+	r := require.New(t)
+	ctx := context.Background()
+	call := func(vars CallVars) (*CallReturns, error) {
+		return Call(ctx, &CallParams{Params: vars, RuntimeParams: providertypes.RuntimeParams{
+			ProcessContext: process.NewContext(process.ContextData{Namespace: "default"}),
+		}})
+	}
+	target := startServer(t, true)
+
+	res, err := call(CallVars{
+		Target:  target,
+		Method:  "grpc.testing.TestService/UnaryCall",
+		Request: json.RawMessage(`{"payload":{"body":"aGVsbG8="}}`),
+		Header:  map[string]string{"user": "alice"},
+	})
+	r.NoError(err)
+	r.Equal(map[string]interface{}{"payload": map[string]interface{}{"body": "aGVsbG8="}, "username": "alice"}, res.Returns.Response)
+	r.Equal([]string{"test"}, res.Returns.Header["x-server"])
+
+	_, err = call(CallVars{
+		Target:  target,
+		Method:  "/grpc.testing.TestService/UnaryCall",
+		Request: json.RawMessage(`{"responseStatus":{"code":5,"message":"user not found"}}`),
+	})
+	r.EqualError(err, "gRPC call /grpc.testing.TestService/UnaryCall failed with code NotFound: user not found")
+
+	_, err = call(CallVars{Target: target, Method: "grpc.testing.TestService/Unknown"})
+	r.EqualError(err, "method Unknown not found in service grpc.testing.TestService")
+	_, err = call(CallVars{Target: target, Method: "grpc.testing.TestService/StreamingOutputCall"})
+	r.EqualError(err, "method grpc.testing.TestService/StreamingOutputCall is streaming, only the unary methods are supported")
+	_, err = call(CallVars{Target: target, Method: "UnaryCall"})
+	r.EqualError(err, "the method must be fully-qualified as package.Service/Method if the service is not specified")
+	_, err = call(CallVars{Target: target, Method: "grpc.testing.TestService/UnaryCall", Request: json.RawMessage(`{"unknown":1}`)})
+	r.ErrorContains(err, "invalid request of grpc.testing.SimpleRequest")
+}
+
+func TestCallWithTraceContext(t *testing.T) {
+	r := require.New(t)
+	defer featuregatetesting.SetFeatureGateDuringTest(t, utilfeature.DefaultFeatureGate, features.EnableTracePropagation, true)()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	r.NoError(err)
+	var received metadata.MD
+	s := grpc.NewServer(grpc.UnaryInterceptor(func(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		received, _ = metadata.FromIncomingContext(ctx)
+		return handler(ctx, req)
+	}))
+	testpb.RegisterTestServiceServer(s, testServer{})
+	reflection.Register(s)
+	go func() { _ = s.Serve(l) }()
+	defer s.Stop()
+
+	pCtx := process.NewContext(process.ContextData{Namespace: "default", WorkflowName: "wf"})
+	pCtx.PushData(model.ContextStepName, "step")
+	pCtx.PushData(model.ContextSpanID, "abc.step")
+	call := func(header map[string]string) {
+		_, err := Call(context.Background(), &CallParams{
+			Params:        CallVars{Target: l.Addr().String(), Method: "grpc.testing.TestService/UnaryCall", Header: header},
+			RuntimeParams: providertypes.RuntimeParams{ProcessContext: pCtx},
+		})
+		r.NoError(err)
+	}
+	call(map[string]string{"user": "alice"})
+	r.Len(received.Get("traceparent"), 1)
+	r.Regexp("^00-[0-9a-f]{32}-[0-9a-f]{16}-01$", received.Get("traceparent")[0])
+	r.Equal([]string{"workflowName=wf,stepName=step"}, received.Get("baggage"))
+	r.Equal([]string{"alice"}, received.Get("user"))
+
+	// the metadata set by user should not be overridden
+	call(map[string]string{"traceparent": "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01"})
+	r.Equal([]string{"00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01"}, received.Get("traceparent"))
+}
+
+func TestCallWithDescriptorSet(t *testing.T) {
+	r := require.New(t)
+	ctx := context.Background()
+	call := func(vars CallVars) (*CallReturns, error) {
+		return Call(ctx, &CallParams{Params: vars, RuntimeParams: providertypes.RuntimeParams{
+			ProcessContext: process.NewContext(process.ContextData{Namespace: "default"}),
+		}})
+	}
+	target := startServer(t, false)
+
+	_, err := call(CallVars{Target: target, Service: "grpc.testing.TestService", Method: "UnaryCall"})
+	r.ErrorContains(err, "failed to resolve service grpc.testing.TestService with the server reflection")
+
+	set := &descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{
+		protodesc.ToFileDescriptorProto(testpb.File_grpc_testing_test_proto),
+		protodesc.ToFileDescriptorProto(testpb.File_grpc_testing_messages_proto),
+		protodesc.ToFileDescriptorProto(testpb.File_grpc_testing_empty_proto),
+	}}
+	b, err := proto.Marshal(set)
+	r.NoError(err)
+	res, err := call(CallVars{
+		Target:        target,
+		Service:       "grpc.testing.TestService",
+		Method:        "UnaryCall",
+		Request:       json.RawMessage(`"{\"payload\":{\"body\":\"aGk=\"}}"`),
+		DescriptorSet: base64.StdEncoding.EncodeToString(b),
+	})
+	r.NoError(err)
+	r.Equal(map[string]interface{}{"payload": map[string]interface{}{"body": "aGk="}}, res.Returns.Response)
+}
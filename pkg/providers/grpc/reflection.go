@@ -0,0 +1,163 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package grpc
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	reflectionv1 "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	reflectionv1alpha "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// fetchFunc returns the serialized file descriptors of the file which contains the symbol, or of the file name
+type fetchFunc func(symbol, filename string) ([][]byte, error)
+
+// resolveFiles resolves the file of the service and its dependencies with the server reflection, the v1alpha
+// reflection is used if the server does not support v1
+func resolveFiles(ctx context.Context, conn *grpc.ClientConn, service string) (*protoregistry.Files, error) {
+	files, err := resolveFilesWith(service, fetchV1(ctx, conn))
+	if status.Code(err) == codes.Unimplemented {
+		files, err = resolveFilesWith(service, fetchV1alpha(ctx, conn))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve service %s with the server reflection: %w", service, err)
+	}
+	return files, nil
+}
+
+func resolveFilesWith(service string, fetch fetchFunc) (*protoregistry.Files, error) {
+	fds := map[string]*descriptorpb.FileDescriptorProto{}
+	add := func(raws [][]byte) error {
+		for _, raw := range raws {
+			fd := &descriptorpb.FileDescriptorProto{}
+			if err := proto.Unmarshal(raw, fd); err != nil {
+				return fmt.Errorf("invalid file descriptor: %w", err)
+			}
+			if _, ok := fds[fd.GetName()]; !ok {
+				fds[fd.GetName()] = fd
+			}
+		}
+		return nil
+	}
+	raws, err := fetch(service, "")
+	if err != nil {
+		return nil, err
+	}
+	if err := add(raws); err != nil {
+		return nil, err
+	}
+	// the servers usually return the transitive dependencies, fetch the missing ones until all are resolved
+	for {
+		missing := ""
+		for _, fd := range fds {
+			for _, dep := range fd.GetDependency() {
+				if _, ok := fds[dep]; !ok {
+					missing = dep
+					break
+				}
+			}
+		}
+		if missing == "" {
+			break
+		}
+		raws, err := fetch("", missing)
+		if err != nil {
+			// the well-known types may not be served
+			global, gerr := protoregistry.GlobalFiles.FindFileByPath(missing)
+			if gerr != nil {
+				return nil, fmt.Errorf("failed to fetch the file %s: %w", missing, err)
+			}
+			fds[missing] = protodesc.ToFileDescriptorProto(global)
+			continue
+		}
+		if err := add(raws); err != nil {
+			return nil, err
+		}
+		if _, ok := fds[missing]; !ok {
+			return nil, fmt.Errorf("the file %s is not returned by the server", missing)
+		}
+	}
+	set := &descriptorpb.FileDescriptorSet{}
+	for _, fd := range fds {
+		set.File = append(set.File, fd)
+	}
+	return protodesc.NewFiles(set)
+}
+
+func fetchV1(ctx context.Context, conn *grpc.ClientConn) fetchFunc {
+	return func(symbol, filename string) ([][]byte, error) {
+		stream, err := reflectionv1.NewServerReflectionClient(conn).ServerReflectionInfo(ctx)
+		if err != nil {
+			return nil, err
+		}
+		defer stream.CloseSend() //nolint:errcheck
+		req := &reflectionv1.ServerReflectionRequest{}
+		if symbol != "" {
+			req.MessageRequest = &reflectionv1.ServerReflectionRequest_FileContainingSymbol{FileContainingSymbol: symbol}
+		} else {
+			req.MessageRequest = &reflectionv1.ServerReflectionRequest_FileByFilename{FileByFilename: filename}
+		}
+		if err := stream.Send(req); err != nil {
+			return nil, err
+		}
+		resp, err := stream.Recv()
+		if err != nil {
+			return nil, err
+		}
+		if e := resp.GetErrorResponse(); e != nil {
+			return nil, status.Error(codes.Code(e.GetErrorCode()), e.GetErrorMessage())
+		}
+		return resp.GetFileDescriptorResponse().GetFileDescriptorProto(), nil
+	}
+}
+
+func fetchV1alpha(ctx context.Context, conn *grpc.ClientConn) fetchFunc {
+	return func(symbol, filename string) ([][]byte, error) {
+		//nolint:staticcheck
+		stream, err := reflectionv1alpha.NewServerReflectionClient(conn).ServerReflectionInfo(ctx)
+		if err != nil {
+			return nil, err
+		}
+		defer stream.CloseSend() //nolint:errcheck
+		//nolint:staticcheck
+		req := &reflectionv1alpha.ServerReflectionRequest{}
+		if symbol != "" {
+			req.MessageRequest = &reflectionv1alpha.ServerReflectionRequest_FileContainingSymbol{FileContainingSymbol: symbol}
+		} else {
+			req.MessageRequest = &reflectionv1alpha.ServerReflectionRequest_FileByFilename{FileByFilename: filename}
+		}
+		if err := stream.Send(req); err != nil {
+			return nil, err
+		}
+		resp, err := stream.Recv()
+		if err != nil {
+			return nil, err
+		}
+		if e := resp.GetErrorResponse(); e != nil {
+			return nil, status.Error(codes.Code(e.GetErrorCode()), e.GetErrorMessage())
+		}
+		return resp.GetFileDescriptorResponse().GetFileDescriptorProto(), nil
+	}
+}
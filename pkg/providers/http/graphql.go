@@ -0,0 +1,119 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	wferrors "github.com/kubevela/workflow/pkg/errors"
+	providertypes "github.com/kubevela/workflow/pkg/providers/types"
+)
+
+// GraphQLVars is the vars for graphql request
+type GraphQLVars struct {
+	Endpoint      string                 `json:"endpoint"`
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName,omitempty"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+	Headers       map[string]string      `json:"headers,omitempty"`
+	Timeout       string                 `json:"timeout,omitempty"`
+	TLS           *TLS                   `json:"tls,omitempty"`
+}
+
+// GraphQLReturnVars is the vars for graphql response
+type GraphQLReturnVars struct {
+	Data       interface{} `json:"data"`
+	StatusCode int         `json:"statusCode"`
+}
+
+// GraphQLParams is the params for graphql request
+type GraphQLParams = providertypes.Params[GraphQLVars]
+
+// GraphQLReturns is the returns for graphql response
+type GraphQLReturns = providertypes.Returns[GraphQLReturnVars]
+
+type graphqlRequest struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName,omitempty"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+}
+
+type graphqlResponse struct {
+	Data   interface{}    `json:"data"`
+	Errors []graphqlError `json:"errors"`
+}
+
+type graphqlError struct {
+	Message string        `json:"message"`
+	Path    []interface{} `json:"path,omitempty"`
+}
+
+// GraphQL posts the graphql query to the endpoint, the step fails if the response contains errors.
+func GraphQL(ctx context.Context, params *GraphQLParams) (*GraphQLReturns, error) {
+	vars := params.Params
+	if vars.Query == "" {
+		return nil, errors.New("the graphql query is empty")
+	}
+	body, err := json.Marshal(graphqlRequest{Query: vars.Query, OperationName: vars.OperationName, Variables: vars.Variables})
+	if err != nil {
+		return nil, errors.Wrap(err, "encode graphql request")
+	}
+	header := map[string]string{"Content-Type": "application/json", "Accept": "application/json"}
+	for k, v := range vars.Headers {
+		header[k] = v
+	}
+	resp, err := runHTTP(ctx, &DoParams{
+		Params: RequestVars{
+			Method:  http.MethodPost,
+			URL:     vars.Endpoint,
+			Request: &Request{Timeout: vars.Timeout, Body: string(body), Header: header},
+			TLS:     vars.TLS,
+		},
+		RuntimeParams: params.RuntimeParams,
+	})
+	if err != nil {
+		return nil, err
+	}
+	result := new(graphqlResponse)
+	if err := json.Unmarshal([]byte(resp.Returns.Body), result); err != nil {
+		return nil, fmt.Errorf("parse graphql response with status code %d: %w", resp.Returns.StatusCode, err)
+	}
+	if len(result.Errors) > 0 {
+		messages := make([]string, 0, len(result.Errors))
+		for _, e := range result.Errors {
+			if len(e.Path) > 0 {
+				messages = append(messages, fmt.Sprintf("%s (path: %v)", e.Message, e.Path))
+				continue
+			}
+			messages = append(messages, e.Message)
+		}
+		params.Action.Fail(fmt.Sprintf("GraphQL request failed: %s", strings.Join(messages, "; ")))
+		return nil, wferrors.GenericActionError(wferrors.ActionTerminate)
+	}
+	return &GraphQLReturns{
+		Returns: GraphQLReturnVars{
+			Data:       result.Data,
+			StatusCode: resp.Returns.StatusCode,
+		},
+	}, nil
+}
@@ -0,0 +1,92 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kubevela/workflow/pkg/mock"
+	providertypes "github.com/kubevela/workflow/pkg/providers/types"
+)
+
+func TestGraphQL(t *testing.T) {
+	r := require.New(t)
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodPost || req.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"errors":[{"message":"unauthorized"}]}`))
+			return
+		}
+		body := new(graphqlRequest)
+		if err := json.NewDecoder(req.Body).Decode(body); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		switch body.Query {
+		case "query($id: ID!) { user(id: $id) { name } }":
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"data": map[string]interface{}{"user": map[string]interface{}{"name": "user-" + body.Variables["id"].(string)}},
+			})
+		case "not json":
+			_, _ = w.Write([]byte("internal error"))
+		default:
+			_, _ = w.Write([]byte(`{"data":null,"errors":[{"message":"field not found","path":["user","age"]},{"message":"invalid query"}]}`))
+		}
+	}))
+	defer s.Close()
+	graphql := func(act *mock.Action, vars GraphQLVars) (*GraphQLReturns, error) {
+		vars.Endpoint = s.URL
+		if vars.Headers == nil {
+			vars.Headers = map[string]string{"Authorization": "Bearer token"}
+		}
+		return GraphQL(context.Background(), &GraphQLParams{Params: vars, RuntimeParams: providertypes.RuntimeParams{Action: act}})
+	}
+
+	act := &mock.Action{}
+	res, err := graphql(act, GraphQLVars{
+		Query:     "query($id: ID!) { user(id: $id) { name } }",
+		Variables: map[string]interface{}{"id": "1"},
+	})
+	r.NoError(err)
+	r.Equal(http.StatusOK, res.Returns.StatusCode)
+	r.Equal(map[string]interface{}{"user": map[string]interface{}{"name": "user-1"}}, res.Returns.Data)
+	r.Equal("", act.Phase)
+
+	act = &mock.Action{}
+	_, err = graphql(act, GraphQLVars{Query: "{ user { age } }"})
+	r.Error(err)
+	r.Equal("Fail", act.Phase)
+	r.Equal("GraphQL request failed: field not found (path: [user age]); invalid query", act.Msg)
+
+	act = &mock.Action{}
+	_, err = graphql(act, GraphQLVars{Query: "{ user { name } }", Headers: map[string]string{}})
+	r.Error(err)
+	r.Equal("GraphQL request failed: unauthorized", act.Msg)
+
+	_, err = graphql(&mock.Action{}, GraphQLVars{Query: "not json"})
+	r.Error(err)
+	r.Contains(err.Error(), "parse graphql response with status code 200")
+	_, err = graphql(&mock.Action{}, GraphQLVars{})
+	r.Error(err)
+	r.Contains(err.Error(), "the graphql query is empty")
+}
@@ -24,6 +24,27 @@
 				limit:  int
 				period: string
 			}
+			// +usage=The limit in bytes of the response body, the request fails if exceeded, default to 10MiB
+			maxResponseBytes?: int
+			// +usage=The file under the response body directory of the controller which the response body is written to instead of the output
+			bodyFile?: string
+			// +usage=The url of the proxy, user and password can be set in the url, the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables are used if not set
+			proxy?: string
+			// +usage=The path of the unix domain socket to send the request through, e.g. /var/run/docker.sock, the url is still required to set the path of the request
+			unixSocket?: string
+			// +usage=The JSONPath of the fields to extract from the JSON response body into the outputs of the response, e.g. {name: "$.metadata.name"}
+			responseJSONPath?: [string]: string
+			// +usage=The retry of the request on the network errors and the retryable status codes
+			retry?: {
+				// +usage=The number of retries after the first attempt
+				count: int
+				// +usage=The interval before the first retry, which is doubled after each attempt
+				interval?: string
+				// +usage=The max interval between the retries
+				maxInterval?: string
+				// +usage=The retryable status codes, all the 5xx codes are retried if not set
+				statusCodes?: [...int]
+			}
 			...
 		}
 		// +usgae=The tls config of the request
@@ -31,6 +52,17 @@
 			secret:    string
 			namespace?: string
 		}
+		// +usage=The tls of the request with the client certificate, the values are PEM encoded or read from the secrets in the namespace of the workflow by default
+		tls?: {
+			// +usage=The client certificate
+			clientCert?: #TLSValue
+			// +usage=The client key
+			clientKey?: #TLSValue
+			// +usage=The ca bundle to verify the server certificate
+			caBundle?: #TLSValue
+			// +usage=Whether to skip verifying the server certificate
+			insecureSkipVerify?: bool
+		}
 	}
 
 	$returns?: {
@@ -44,12 +76,60 @@
 			trailer?: [string]: [...string]
 			// +usage=The status code of the response
 			statusCode: int
+			// +usage=The number of the attempts if the retry is configured
+			attempts?: int
+			// +usage=The path of the file which the body is written to if bodyFile is set
+			bodyFile?: string
+			// +usage=The size of the body written to the file
+			bodySize?: int
+			// +usage=The fields extracted from the response body by responseJSONPath
+			outputs?: {...}
 			...
 		}
 	}
 	...
 }
 
+#TLSValue: {
+	value: string
+} | {
+	secretRef: {
+		name:       string
+		namespace?: string
+		key:        string
+	}
+}
+
+#GraphQL: {
+	#do:       "graphql"
+	#provider: "http"
+
+	$params: {
+		// +usage=The url of the graphql endpoint
+		endpoint: string
+		// +usage=The graphql query or mutation
+		query: string
+		// +usage=The operation to execute if the query contains multiple operations
+		operationName?: string
+		// +usage=The variables of the query
+		variables?: {...}
+		// +usage=The headers of the request
+		headers?: [string]: string
+		// +usage=The timeout of the request
+		timeout?: string
+		// +usage=The tls of the request, same as the tls of the http request
+		tls?: {...}
+	}
+
+	$returns?: {
+		// +usage=The data of the response, the step fails if the response contains errors
+		data: _
+		// +usage=The status code of the response
+		statusCode: int
+	}
+	...
+}
+
 #HTTPGet: #HTTPDo & {method: "GET"}
 
 #HTTPPost: #HTTPDo & {method: "POST"}
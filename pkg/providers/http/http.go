@@ -22,19 +22,28 @@ import (
 	"crypto/x509"
 	_ "embed"
 	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"io"
+	"math/rand"
+	"net"
 	"net/http"
+	neturl "net/url"
+	"os"
+	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/pkg/errors"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/util/jsonpath"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	cuexruntime "github.com/kubevela/pkg/cue/cuex/runtime"
 
 	"github.com/kubevela/workflow/pkg/cue/model"
+	"github.com/kubevela/workflow/pkg/monitor/trace"
 	"github.com/kubevela/workflow/pkg/providers/legacy/http/ratelimiter"
 	providertypes "github.com/kubevela/workflow/pkg/providers/types"
 )
@@ -46,6 +55,15 @@ const (
 
 var (
 	rateLimiter *ratelimiter.RateLimiter
+
+	// DefaultMaxResponseBytes is the default limit of the response body
+	DefaultMaxResponseBytes int64 = 10 << 20
+	// ErrRequestTimeout is the error when the request exceeds the timeout
+	ErrRequestTimeout = errors.New("the request timed out")
+
+	// ResponseBodyDir is the directory which the response bodies can be written to, writing the body to
+	// files is disabled if empty
+	ResponseBodyDir string
 )
 
 func init() {
@@ -59,6 +77,28 @@ type Request struct {
 	Header      map[string]string `json:"header,omitempty"`
 	Trailer     map[string]string `json:"trailer,omitempty"`
 	RateLimiter *RateLimiter      `json:"rateLimiter,omitempty"`
+	Retry       *Retry            `json:"retry,omitempty"`
+	// MaxResponseBytes is the limit of the response body, DefaultMaxResponseBytes is used if not set
+	MaxResponseBytes int64 `json:"maxResponseBytes,omitempty"`
+	// BodyFile is the file under ResponseBodyDir which the response body is written to instead of the output
+	BodyFile string `json:"bodyFile,omitempty"`
+	// Proxy is the url of the proxy, the proxy environment variables are used if not set
+	Proxy string `json:"proxy,omitempty"`
+	// UnixSocket is the path of the unix domain socket which the request is sent through, the host of the url
+	// is only used as the Host header
+	UnixSocket string `json:"unixSocket,omitempty"`
+	// ResponseJSONPath is the map from the output field to the JSONPath extracting the field from the response body
+	ResponseJSONPath map[string]string `json:"responseJSONPath,omitempty"`
+}
+
+// Retry .
+type Retry struct {
+	// Count is the number of retries after the first attempt
+	Count       int    `json:"count"`
+	Interval    string `json:"interval,omitempty"`
+	MaxInterval string `json:"maxInterval,omitempty"`
+	// StatusCodes are the retryable status codes, all the 5xx codes are retried if empty
+	StatusCodes []int `json:"statusCodes,omitempty"`
 }
 
 // RateLimiter .
@@ -73,12 +113,34 @@ type TLSConfig struct {
 	Namespace string `json:"namespace"`
 }
 
+// TLS is the tls of the request with the client certificate.
+type TLS struct {
+	ClientCert         *TLSValue `json:"clientCert,omitempty"`
+	ClientKey          *TLSValue `json:"clientKey,omitempty"`
+	CABundle           *TLSValue `json:"caBundle,omitempty"`
+	InsecureSkipVerify bool      `json:"insecureSkipVerify,omitempty"`
+}
+
+// TLSValue is the PEM encoded value or the reference to a key of the secret.
+type TLSValue struct {
+	Value     string        `json:"value,omitempty"`
+	SecretRef *SecretKeyRef `json:"secretRef,omitempty"`
+}
+
+// SecretKeyRef .
+type SecretKeyRef struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"`
+	Key       string `json:"key"`
+}
+
 // RequestVars is the vars for http request
 type RequestVars struct {
 	Method    string     `json:"method"`
 	URL       string     `json:"url"`
 	Request   *Request   `json:"request,omitempty"`
 	TLSConfig *TLSConfig `json:"tls_config,omitempty"`
+	TLS       *TLS       `json:"tls,omitempty"`
 }
 
 // ResponseVars is the vars for http response
@@ -87,6 +149,14 @@ type ResponseVars struct {
 	Header     http.Header `json:"header,omitempty"`
 	Trailer    http.Header `json:"trailer,omitempty"`
 	StatusCode int         `json:"statusCode"`
+	// Attempts is the number of the attempts sent if the retry is configured
+	Attempts int `json:"attempts,omitempty"`
+	// BodyFile is the path of the file which the body is written to if the body file is requested
+	BodyFile string `json:"bodyFile,omitempty"`
+	// BodySize is the size of the body written to the body file
+	BodySize int64 `json:"bodySize,omitempty"`
+	// Outputs is the fields extracted from the body by responseJSONPath
+	Outputs map[string]interface{} `json:"outputs,omitempty"`
 }
 
 // DoParams is the params for http request
@@ -97,14 +167,74 @@ type DoReturns = providertypes.Returns[ResponseVars]
 
 // Do process http request.
 func Do(ctx context.Context, params *DoParams) (*DoReturns, error) {
-	return runHTTP(ctx, params)
+	request := params.Params.Request
+	if request == nil || len(request.ResponseJSONPath) == 0 {
+		return runHTTP(ctx, params)
+	}
+	if request.BodyFile != "" {
+		return nil, errors.New("responseJSONPath cannot be used with bodyFile")
+	}
+	resp, err := runHTTP(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Returns.Outputs, err = extractJSONPath(resp.Returns.Body, request.ResponseJSONPath); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// extractJSONPath extracts the fields from the JSON body, the paths can be either in the form of `{.a.b}`
+// or `$.a.b`, a single match is returned as is and multiple matches are returned as a list
+func extractJSONPath(body string, paths map[string]string) (map[string]interface{}, error) {
+	var data interface{}
+	if err := json.Unmarshal([]byte(body), &data); err != nil {
+		return nil, errors.Wrap(err, "parse the response body as JSON for responseJSONPath")
+	}
+	fields := make([]string, 0, len(paths))
+	for field := range paths {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	outputs := make(map[string]interface{}, len(paths))
+	for _, field := range fields {
+		expr := paths[field]
+		if !strings.HasPrefix(expr, "{") {
+			expr = "{" + strings.TrimPrefix(expr, "$") + "}"
+		}
+		j := jsonpath.New(field)
+		if err := j.Parse(expr); err != nil {
+			return nil, errors.WithMessagef(err, "invalid JSONPath %s of output %s", paths[field], field)
+		}
+		results, err := j.FindResults(data)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "extract output %s by JSONPath %s", field, paths[field])
+		}
+		var values []interface{}
+		for _, result := range results {
+			for _, v := range result {
+				values = append(values, v.Interface())
+			}
+		}
+		switch len(values) {
+		case 0:
+			return nil, fmt.Errorf("extract output %s by JSONPath %s: no value matched", field, paths[field])
+		case 1:
+			outputs[field] = values[0]
+		default:
+			outputs[field] = values
+		}
+	}
+	return outputs, nil
 }
 
 func runHTTP(ctx context.Context, params *DoParams) (*DoReturns, error) {
 	var (
 		err             error
 		header, trailer http.Header
-		reader          io.Reader
+		retry           *Retry
+		proxy           = http.ProxyFromEnvironment
+		unixSocket      string
 	)
 	defaultClient := &http.Client{
 		Transport: http.DefaultTransport,
@@ -112,11 +242,15 @@ func runHTTP(ctx context.Context, params *DoParams) (*DoReturns, error) {
 	}
 	method := params.Params.Method
 	url := params.Params.URL
+	req := &httpRequest{method: method, url: url, maxResponseBytes: DefaultMaxResponseBytes}
 	if request := params.Params.Request; request != nil {
 		if request.Timeout != "" {
 			timeout, err := time.ParseDuration(request.Timeout)
 			if err != nil {
-				return nil, fmt.Errorf("invalid timeout %s: %w", timeout, err)
+				return nil, fmt.Errorf("invalid timeout %s: %w", request.Timeout, err)
+			}
+			if timeout <= 0 {
+				return nil, fmt.Errorf("invalid timeout %s: must be positive", request.Timeout)
 			}
 			defaultClient.Timeout = timeout
 		}
@@ -129,7 +263,32 @@ func runHTTP(ctx context.Context, params *DoParams) (*DoReturns, error) {
 				return nil, errors.New("request exceeds the rate limiter")
 			}
 		}
-		reader = strings.NewReader(request.Body)
+		req.body = request.Body
+		if request.MaxResponseBytes < 0 {
+			return nil, fmt.Errorf("invalid maxResponseBytes %d", request.MaxResponseBytes)
+		}
+		if request.MaxResponseBytes > 0 {
+			req.maxResponseBytes = request.MaxResponseBytes
+		}
+		if request.BodyFile != "" {
+			if req.bodyFile, err = bodyFilePath(request.BodyFile); err != nil {
+				return nil, err
+			}
+		}
+		if request.Proxy != "" {
+			proxyURL, err := neturl.Parse(request.Proxy)
+			if err != nil || proxyURL.Host == "" {
+				return nil, fmt.Errorf("invalid proxy %s", redactProxy(request.Proxy))
+			}
+			proxy = http.ProxyURL(proxyURL)
+		}
+		if request.UnixSocket != "" {
+			if request.Proxy != "" {
+				return nil, errors.New("proxy and unixSocket cannot be set at the same time")
+			}
+			unixSocket, proxy = request.UnixSocket, nil
+		}
+		retry = request.Retry
 		header = parseHeaders(request.Header)
 		trailer = parseHeaders(request.Trailer)
 	}
@@ -138,12 +297,8 @@ func runHTTP(ctx context.Context, params *DoParams) (*DoReturns, error) {
 		header.Set("Content-Type", "application/json")
 	}
 
-	req, err := http.NewRequestWithContext(context.Background(), method, url, reader)
-	if err != nil {
-		return nil, err
-	}
-	req.Header = header
-	req.Trailer = trailer
+	trace.InjectHeaders(header, params.ProcessContext)
+	req.header, req.trailer = header, trailer
 
 	if params.Params.TLSConfig != nil {
 		if params.Params.TLSConfig.Namespace == "" {
@@ -153,22 +308,211 @@ func runHTTP(ctx context.Context, params *DoParams) (*DoReturns, error) {
 			defaultClient.Transport = tr
 		}
 	}
+	if params.Params.TLS != nil {
+		if params.Params.TLSConfig != nil {
+			return nil, errors.New("tls and tls_config cannot be set at the same time")
+		}
+		tr, err := getTLSTransport(ctx, params.KubeClient, params.Params.TLS, fmt.Sprint(params.ProcessContext.GetData(model.ContextNamespace)))
+		if err != nil {
+			return nil, err
+		}
+		defaultClient.Transport = tr
+	}
+	if tr, ok := defaultClient.Transport.(*http.Transport); ok {
+		// the default transport already uses the proxy environment variables
+		if tr == http.DefaultTransport && params.Params.Request != nil && (params.Params.Request.Proxy != "" || unixSocket != "") {
+			tr = tr.Clone()
+		}
+		if tr != http.DefaultTransport {
+			tr.Proxy = proxy
+			if unixSocket != "" {
+				tr.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+					var d net.Dialer
+					return d.DialContext(ctx, "unix", unixSocket)
+				}
+			}
+		}
+		defaultClient.Transport = tr
+	}
+	// the transports built for the request are discarded after it, so their idle connections are closed,
+	// the connections of the default transport are kept for the reuse
+	if defaultClient.Transport != http.DefaultTransport {
+		defer defaultClient.CloseIdleConnections()
+	}
 
-	resp, err := defaultClient.Do(req)
+	if retry == nil {
+		return req.do(ctx, defaultClient)
+	}
+	backoff, err := newRetryBackoff(retry)
 	if err != nil {
 		return nil, err
 	}
+	for attempt := 1; ; attempt++ {
+		resp, err := req.do(ctx, defaultClient)
+		var tooLarge *responseTooLargeError
+		if errors.As(err, &tooLarge) {
+			return nil, err
+		}
+		if err == nil && !retry.retryable(resp.Returns.StatusCode) {
+			resp.Returns.Attempts = attempt
+			return resp, nil
+		}
+		if attempt > retry.Count {
+			if err != nil {
+				return nil, errors.WithMessagef(err, "request failed after %d attempts", attempt)
+			}
+			return nil, fmt.Errorf("request failed after %d attempts: status code %d", attempt, resp.Returns.StatusCode)
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(backoff(attempt)):
+		}
+	}
+}
+
+type httpRequest struct {
+	method, url, body string
+	header, trailer   http.Header
+	maxResponseBytes  int64
+	bodyFile          string
+}
+
+type responseTooLargeError struct {
+	limit, read int64
+}
+
+func (e *responseTooLargeError) Error() string {
+	return fmt.Sprintf("the response body exceeds the limit of %d bytes, %d bytes read", e.limit, e.read)
+}
+
+func (r *httpRequest) do(ctx context.Context, cli *http.Client) (*DoReturns, error) {
+	req, err := http.NewRequestWithContext(ctx, r.method, r.url, strings.NewReader(r.body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header = r.header.Clone()
+	req.Trailer = r.trailer.Clone()
+	resp, err := cli.Do(req)
+	if err != nil {
+		return nil, wrapTimeout(ctx, cli, err)
+	}
 	//nolint:errcheck
 	defer resp.Body.Close()
-	b, _ := io.ReadAll(resp.Body)
+	// read one more byte to tell whether the limit is exceeded
+	body := io.LimitReader(resp.Body, r.maxResponseBytes+1)
+	returns := ResponseVars{
+		Header:     resp.Header,
+		Trailer:    resp.Trailer,
+		StatusCode: resp.StatusCode,
+	}
+	if r.bodyFile != "" {
+		n, err := writeBodyFile(r.bodyFile, body)
+		if err != nil {
+			_ = os.Remove(r.bodyFile)
+			return nil, wrapTimeout(ctx, cli, err)
+		}
+		if n > r.maxResponseBytes {
+			_ = os.Remove(r.bodyFile)
+			return nil, &responseTooLargeError{limit: r.maxResponseBytes, read: n}
+		}
+		returns.BodyFile, returns.BodySize = r.bodyFile, n
+		return &DoReturns{Returns: returns}, nil
+	}
+	b, err := io.ReadAll(body)
+	if err != nil {
+		return nil, wrapTimeout(ctx, cli, err)
+	}
+	if int64(len(b)) > r.maxResponseBytes {
+		return nil, &responseTooLargeError{limit: r.maxResponseBytes, read: int64(len(b))}
+	}
 	// parse response body and headers
-	return &DoReturns{
-		Returns: ResponseVars{
-			Body:       string(b),
-			Header:     resp.Header,
-			Trailer:    resp.Trailer,
-			StatusCode: resp.StatusCode,
-		},
+	returns.Body = string(b)
+	return &DoReturns{Returns: returns}, nil
+}
+
+// wrapTimeout wraps the error with ErrRequestTimeout if the timeout of the client is exceeded, the cancellation
+// of the context is returned as is
+func wrapTimeout(ctx context.Context, cli *http.Client, err error) error {
+	var netErr net.Error
+	if ctx.Err() == nil && errors.As(err, &netErr) && netErr.Timeout() {
+		return fmt.Errorf("%w after %s: %w", ErrRequestTimeout, cli.Timeout, err)
+	}
+	return err
+}
+
+// bodyFilePath returns the path of the body file under ResponseBodyDir
+func bodyFilePath(name string) (string, error) {
+	if ResponseBodyDir == "" {
+		return "", errors.New("writing the response body to file is disabled")
+	}
+	if !filepath.IsLocal(name) {
+		return "", fmt.Errorf("invalid body file %s: must be a relative path inside the response body dir", name)
+	}
+	return filepath.Join(ResponseBodyDir, name), nil
+}
+
+func writeBodyFile(path string, body io.Reader) (int64, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return 0, err
+	}
+	f, err := os.Create(filepath.Clean(path))
+	if err != nil {
+		return 0, err
+	}
+	n, err := io.Copy(f, body)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return n, err
+}
+
+func (r *Retry) retryable(statusCode int) bool {
+	if len(r.StatusCodes) == 0 {
+		return statusCode >= 500 && statusCode < 600
+	}
+	for _, code := range r.StatusCodes {
+		if code == statusCode {
+			return true
+		}
+	}
+	return false
+}
+
+// newRetryBackoff returns the exponential backoff of the retry, the interval is doubled after each attempt
+// up to the max interval, and a jitter of up to half of the interval is applied
+func newRetryBackoff(retry *Retry) (func(attempt int) time.Duration, error) {
+	if retry.Count < 0 {
+		return nil, fmt.Errorf("invalid retry count %d", retry.Count)
+	}
+	interval, maxInterval := time.Second, 30*time.Second
+	var err error
+	if retry.Interval != "" {
+		if interval, err = time.ParseDuration(retry.Interval); err != nil {
+			return nil, fmt.Errorf("invalid retry interval %s: %w", retry.Interval, err)
+		}
+	}
+	if retry.MaxInterval != "" {
+		if maxInterval, err = time.ParseDuration(retry.MaxInterval); err != nil {
+			return nil, fmt.Errorf("invalid retry max interval %s: %w", retry.MaxInterval, err)
+		}
+	}
+	if maxInterval < interval {
+		maxInterval = interval
+	}
+	return func(attempt int) time.Duration {
+		d := interval
+		for i := 1; i < attempt && d < maxInterval; i++ {
+			d *= 2
+		}
+		if d > maxInterval {
+			d = maxInterval
+		}
+		if half := int64(d / 2); half > 0 {
+			//nolint:gosec
+			d = time.Duration(half + rand.Int63n(half+1))
+		}
+		return d
 	}, nil
 }
 
@@ -222,6 +566,87 @@ func getTransport(ctx context.Context, cli client.Client, secretName, ns string)
 	return tr, nil
 }
 
+// getTLSTransport builds the transport with the tls of the request, the errors never contain the key material
+func getTLSTransport(ctx context.Context, cli client.Client, t *TLS, namespace string) (http.RoundTripper, error) {
+	config, err := NewTLSConfig(ctx, cli, t, namespace)
+	if err != nil {
+		return nil, err
+	}
+	tr := http.DefaultTransport.(*http.Transport).Clone()
+	tr.TLSClientConfig = config
+	return tr, nil
+}
+
+// NewTLSConfig builds the tls config with the client certificate, the values are read from the secrets in the
+// namespace by default. The errors never contain the key material.
+func NewTLSConfig(ctx context.Context, cli client.Client, t *TLS, namespace string) (*tls.Config, error) {
+	config := &tls.Config{
+		//nolint:gosec
+		InsecureSkipVerify: t.InsecureSkipVerify,
+	}
+	caBundle, err := loadTLSValue(ctx, cli, t.CABundle, namespace)
+	if err != nil {
+		return nil, errors.WithMessage(err, "load ca bundle")
+	}
+	if len(caBundle) > 0 {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caBundle) {
+			return nil, errors.New("invalid ca bundle: no PEM certificate found")
+		}
+		config.RootCAs = pool
+	}
+	if (t.ClientCert == nil) != (t.ClientKey == nil) {
+		return nil, errors.New("clientCert and clientKey must be set together")
+	}
+	if t.ClientCert != nil {
+		certData, err := loadTLSValue(ctx, cli, t.ClientCert, namespace)
+		if err != nil {
+			return nil, errors.WithMessage(err, "load client certificate")
+		}
+		keyData, err := loadTLSValue(ctx, cli, t.ClientKey, namespace)
+		if err != nil {
+			return nil, errors.WithMessage(err, "load client key")
+		}
+		cert, err := tls.X509KeyPair(certData, keyData)
+		if err != nil {
+			return nil, errors.WithMessage(err, "parse client keypair")
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	return config, nil
+}
+
+func loadTLSValue(ctx context.Context, cli client.Client, v *TLSValue, namespace string) ([]byte, error) {
+	if v == nil {
+		return nil, nil
+	}
+	if v.SecretRef == nil {
+		return []byte(v.Value), nil
+	}
+	ref := v.SecretRef
+	if ref.Namespace != "" {
+		namespace = ref.Namespace
+	}
+	secret := new(v1.Secret)
+	if err := cli.Get(ctx, client.ObjectKey{Namespace: namespace, Name: ref.Name}, secret); err != nil {
+		return nil, err
+	}
+	data, ok := secret.Data[ref.Key]
+	if !ok {
+		return nil, fmt.Errorf("key %s not found in secret %s/%s", ref.Key, namespace, ref.Name)
+	}
+	return data, nil
+}
+
+// redactProxy hides the password of the proxy url in the errors
+func redactProxy(proxy string) string {
+	u, err := neturl.Parse(proxy)
+	if err != nil {
+		return "<unparsable>"
+	}
+	return u.Redacted()
+}
+
 func parseHeaders(obj map[string]string) http.Header {
 	h := http.Header{}
 	for k, v := range obj {
@@ -241,6 +666,7 @@ func GetTemplate() string {
 // GetProviders returns the providers
 func GetProviders() map[string]cuexruntime.ProviderFn {
 	return map[string]cuexruntime.ProviderFn{
-		"do": providertypes.GenericProviderFn[RequestVars, DoReturns](Do),
+		"do":      providertypes.GenericProviderFn[RequestVars, DoReturns](Do),
+		"graphql": providertypes.GenericProviderFn[GraphQLVars, GraphQLReturns](GraphQL),
 	}
 }
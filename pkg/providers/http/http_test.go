@@ -18,23 +18,41 @@ package http
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	cryptorand "crypto/rand"
 	"crypto/tls"
 	"crypto/x509"
+	"crypto/x509/pkix"
 	"encoding/base64"
 	"encoding/json"
+	"encoding/pem"
 	"fmt"
 	"io"
+	"math/big"
 	"net"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/crossplane/crossplane-runtime/pkg/test"
 	"github.com/stretchr/testify/require"
 	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilfeature "k8s.io/apiserver/pkg/util/feature"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	featuregatetesting "k8s.io/component-base/featuregate/testing"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 
+	"github.com/kubevela/workflow/pkg/cue/model"
+	"github.com/kubevela/workflow/pkg/cue/process"
+	"github.com/kubevela/workflow/pkg/features"
 	"github.com/kubevela/workflow/pkg/providers/legacy/http/ratelimiter"
 	"github.com/kubevela/workflow/pkg/providers/legacy/http/testdata"
 	"github.com/kubevela/workflow/pkg/providers/types"
@@ -330,3 +348,477 @@ func newMockHttpsServer() *httptest.Server {
 	ts.StartTLS()
 	return ts
 }
+
+func TestHTTPDoWithTraceContext(t *testing.T) {
+	r := require.New(t)
+	defer featuregatetesting.SetFeatureGateDuringTest(t, utilfeature.DefaultFeatureGate, features.EnableTracePropagation, true)()
+	var received http.Header
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		received = req.Header.Clone()
+	}))
+	defer ts.Close()
+
+	pCtx := process.NewContext(process.ContextData{WorkflowName: "wf"})
+	pCtx.PushData(model.ContextStepName, "step")
+	pCtx.PushData(model.ContextSpanID, "abc.step")
+	_, err := Do(context.Background(), &DoParams{
+		Params:        RequestVars{Method: "GET", URL: ts.URL},
+		RuntimeParams: types.RuntimeParams{ProcessContext: pCtx},
+	})
+	r.NoError(err)
+	r.Regexp("^00-[0-9a-f]{32}-[0-9a-f]{16}-01$", received.Get("traceparent"))
+	r.Equal("workflowName=wf,stepName=step", received.Get("baggage"))
+
+	// the header set by user should not be overridden
+	_, err = Do(context.Background(), &DoParams{
+		Params: RequestVars{Method: "GET", URL: ts.URL, Request: &Request{
+			Header: map[string]string{"traceparent": "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01"},
+		}},
+		RuntimeParams: types.RuntimeParams{ProcessContext: pCtx},
+	})
+	r.NoError(err)
+	r.Equal("00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01", received.Get("traceparent"))
+}
+
+func TestHTTPDoWithRetry(t *testing.T) {
+	r := require.New(t)
+	var requests int32
+	failures := int32(2)
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		n := atomic.AddInt32(&requests, 1)
+		b, _ := io.ReadAll(req.Body)
+		if n <= atomic.LoadInt32(&failures) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write(b)
+	}))
+	defer s.Close()
+	do := func(retry *Retry) (*DoReturns, error) {
+		atomic.StoreInt32(&requests, 0)
+		return Do(context.Background(), &DoParams{
+			Params: RequestVars{
+				Method:  "POST",
+				URL:     s.URL,
+				Request: &Request{Body: "payload", Retry: retry},
+			},
+		})
+	}
+
+	res, err := do(&Retry{Count: 3, Interval: "10ms", MaxInterval: "20ms"})
+	r.NoError(err)
+	r.Equal(http.StatusOK, res.Returns.StatusCode)
+	r.Equal("payload", res.Returns.Body)
+	r.Equal(3, res.Returns.Attempts)
+
+	_, err = do(&Retry{Count: 1, Interval: "10ms"})
+	r.Error(err)
+	r.Equal("request failed after 2 attempts: status code 503", err.Error())
+	r.Equal(int32(2), atomic.LoadInt32(&requests))
+
+	// the status code not in the retryable codes is returned directly
+	res, err = do(&Retry{Count: 3, Interval: "10ms", StatusCodes: []int{http.StatusTooManyRequests}})
+	r.NoError(err)
+	r.Equal(http.StatusServiceUnavailable, res.Returns.StatusCode)
+	r.Equal(1, res.Returns.Attempts)
+
+	// without retry the first response is returned
+	res, err = do(nil)
+	r.NoError(err)
+	r.Equal(http.StatusServiceUnavailable, res.Returns.StatusCode)
+	r.Equal(0, res.Returns.Attempts)
+
+	// the network errors are retried
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	r.NoError(err)
+	addr := listener.Addr().String()
+	r.NoError(listener.Close())
+	_, err = Do(context.Background(), &DoParams{
+		Params: RequestVars{
+			Method:  "GET",
+			URL:     "http://" + addr,
+			Request: &Request{Retry: &Retry{Count: 2, Interval: "10ms"}},
+		},
+	})
+	r.Error(err)
+	r.Contains(err.Error(), "request failed after 3 attempts")
+	r.Contains(err.Error(), "connection refused")
+
+	_, err = do(&Retry{Count: 1, Interval: "invalid"})
+	r.Error(err)
+	r.Contains(err.Error(), "invalid retry interval")
+}
+
+func TestRetryBackoff(t *testing.T) {
+	r := require.New(t)
+	backoff, err := newRetryBackoff(&Retry{Count: 5, Interval: "100ms", MaxInterval: "300ms"})
+	r.NoError(err)
+	for attempt, max := range map[int]time.Duration{1: 100 * time.Millisecond, 2: 200 * time.Millisecond, 3: 300 * time.Millisecond, 5: 300 * time.Millisecond} {
+		for i := 0; i < 10; i++ {
+			d := backoff(attempt)
+			r.LessOrEqual(d, max)
+			r.GreaterOrEqual(d, max/2)
+		}
+	}
+	_, err = newRetryBackoff(&Retry{Count: -1})
+	r.Error(err)
+}
+
+func newTestCert(r *require.Assertions, name string, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey, []byte, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), cryptorand.Reader)
+	r.NoError(err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}
+	if parent == nil {
+		tmpl.IsCA = true
+		tmpl.BasicConstraintsValid = true
+		tmpl.KeyUsage |= x509.KeyUsageCertSign
+		parent, parentKey = tmpl, key
+	}
+	der, err := x509.CreateCertificate(cryptorand.Reader, tmpl, parent, &key.PublicKey, parentKey)
+	r.NoError(err)
+	cert, err := x509.ParseCertificate(der)
+	r.NoError(err)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	r.NoError(err)
+	return cert, key, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer})
+}
+
+func TestHTTPDoWithClientCert(t *testing.T) {
+	r := require.New(t)
+	ca, caKey, _, _ := newTestCert(r, "ca", nil, nil)
+	_, _, clientCert, clientKey := newTestCert(r, "client", ca, caKey)
+	_, _, rogueCert, rogueKey := newTestCert(r, "rogue", nil, nil)
+
+	s := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		_, _ = w.Write([]byte("hello " + req.TLS.PeerCertificates[0].Subject.CommonName))
+	}))
+	pool := x509.NewCertPool()
+	pool.AddCert(ca)
+	s.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: pool}
+	s.StartTLS()
+	defer s.Close()
+	serverCA := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: s.Certificate().Raw}))
+
+	cli := fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).WithObjects(&v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "client-tls", Namespace: "default"},
+		Data:       map[string][]byte{"tls.crt": clientCert, "tls.key": clientKey},
+	}).Build()
+	pCtx := process.NewContext(process.ContextData{Namespace: "default"})
+	do := func(t *TLS) (*DoReturns, error) {
+		return Do(context.Background(), &DoParams{
+			Params:        RequestVars{Method: "GET", URL: s.URL, TLS: t},
+			RuntimeParams: types.RuntimeParams{KubeClient: cli, ProcessContext: pCtx},
+		})
+	}
+
+	res, err := do(&TLS{
+		ClientCert: &TLSValue{SecretRef: &SecretKeyRef{Name: "client-tls", Key: "tls.crt"}},
+		ClientKey:  &TLSValue{SecretRef: &SecretKeyRef{Name: "client-tls", Namespace: "default", Key: "tls.key"}},
+		CABundle:   &TLSValue{Value: serverCA},
+	})
+	r.NoError(err)
+	r.Equal("hello client", res.Returns.Body)
+	r.NotContains(fmt.Sprint(res.Returns), string(clientKey))
+
+	res, err = do(&TLS{
+		ClientCert:         &TLSValue{Value: string(clientCert)},
+		ClientKey:          &TLSValue{Value: string(clientKey)},
+		InsecureSkipVerify: true,
+	})
+	r.NoError(err)
+	r.Equal("hello client", res.Returns.Body)
+
+	_, err = do(&TLS{
+		ClientCert: &TLSValue{Value: string(rogueCert)},
+		ClientKey:  &TLSValue{Value: string(rogueKey)},
+		CABundle:   &TLSValue{Value: serverCA},
+	})
+	r.Error(err)
+	r.Contains(err.Error(), "tls")
+	_, err = do(&TLS{CABundle: &TLSValue{Value: serverCA}})
+	r.Error(err)
+
+	for msg, t := range map[string]*TLS{
+		"parse client keypair":                          {ClientCert: &TLSValue{Value: string(clientCert)}, ClientKey: &TLSValue{Value: string(rogueKey)}},
+		"clientCert and clientKey must be set together": {ClientCert: &TLSValue{Value: string(clientCert)}},
+		"key absent not found in secret default/client-tls": {
+			ClientCert: &TLSValue{SecretRef: &SecretKeyRef{Name: "client-tls", Key: "absent"}},
+			ClientKey:  &TLSValue{Value: string(clientKey)},
+		},
+		"invalid ca bundle": {CABundle: &TLSValue{Value: "invalid"}},
+	} {
+		_, err = do(t)
+		r.Error(err, msg)
+		r.Contains(err.Error(), msg)
+		r.NotContains(err.Error(), string(clientKey))
+		r.NotContains(err.Error(), string(rogueKey))
+	}
+}
+
+func TestHTTPDoResponseLimit(t *testing.T) {
+	r := require.New(t)
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		_, _ = w.Write([]byte(strings.Repeat("x", 100)))
+	}))
+	defer s.Close()
+	do := func(request *Request) (*DoReturns, error) {
+		return Do(context.Background(), &DoParams{
+			Params: RequestVars{Method: "GET", URL: s.URL, Request: request},
+		})
+	}
+
+	res, err := do(&Request{MaxResponseBytes: 100})
+	r.NoError(err)
+	r.Len(res.Returns.Body, 100)
+	_, err = do(&Request{MaxResponseBytes: 99})
+	r.Error(err)
+	r.Equal("the response body exceeds the limit of 99 bytes, 100 bytes read", err.Error())
+	_, err = do(&Request{MaxResponseBytes: 10, Retry: &Retry{Count: 3}})
+	r.Error(err)
+	r.Contains(err.Error(), "exceeds the limit of 10 bytes")
+
+	defaultLimit := DefaultMaxResponseBytes
+	defer func() { DefaultMaxResponseBytes = defaultLimit }()
+	res, err = do(nil)
+	r.NoError(err)
+	r.Len(res.Returns.Body, 100)
+	DefaultMaxResponseBytes = 50
+	_, err = do(nil)
+	r.Error(err)
+	r.Contains(err.Error(), "exceeds the limit of 50 bytes")
+
+	_, err = do(&Request{BodyFile: "body.txt"})
+	r.Error(err)
+	r.Contains(err.Error(), "disabled")
+	ResponseBodyDir = t.TempDir()
+	defer func() { ResponseBodyDir = "" }()
+	res, err = do(&Request{BodyFile: "run/body.txt", MaxResponseBytes: 100})
+	r.NoError(err)
+	r.Empty(res.Returns.Body)
+	r.Equal(filepath.Join(ResponseBodyDir, "run", "body.txt"), res.Returns.BodyFile)
+	r.Equal(int64(100), res.Returns.BodySize)
+	b, err := os.ReadFile(res.Returns.BodyFile)
+	r.NoError(err)
+	r.Equal(strings.Repeat("x", 100), string(b))
+	_, err = do(&Request{BodyFile: "large.txt"})
+	r.Error(err)
+	r.Contains(err.Error(), "exceeds the limit of 50 bytes, 51 bytes read")
+	r.NoFileExists(filepath.Join(ResponseBodyDir, "large.txt"))
+	_, err = do(&Request{BodyFile: "../escape.txt"})
+	r.Error(err)
+	r.Contains(err.Error(), "invalid body file")
+}
+
+func TestHTTPDoWithProxy(t *testing.T) {
+	r := require.New(t)
+	var proxied, closed int32
+	p := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if auth := req.Header.Get("Proxy-Authorization"); auth != "" &&
+			auth != "Basic "+base64.StdEncoding.EncodeToString([]byte("user:pass")) {
+			w.WriteHeader(http.StatusProxyAuthRequired)
+			return
+		}
+		atomic.AddInt32(&proxied, 1)
+		_, _ = w.Write([]byte("proxied " + req.URL.String()))
+	}))
+	p.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateClosed {
+			atomic.AddInt32(&closed, 1)
+		}
+	}
+	p.Start()
+	defer p.Close()
+	var connected int32
+	s := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		_, _ = w.Write([]byte("direct"))
+	}))
+	s.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			atomic.AddInt32(&connected, 1)
+		}
+	}
+	s.Start()
+	defer s.Close()
+	do := func(url string, request *Request) (*DoReturns, error) {
+		return Do(context.Background(), &DoParams{
+			Params: RequestVars{Method: "GET", URL: url, Request: request},
+		})
+	}
+
+	res, err := do("http://backend.invalid/api?q=1", &Request{Proxy: p.URL})
+	r.NoError(err)
+	r.Equal("proxied http://backend.invalid/api?q=1", res.Returns.Body)
+	r.Equal(int32(1), atomic.LoadInt32(&proxied))
+	// the idle connection of the transport built for the request is not leaked
+	r.Eventually(func() bool { return atomic.LoadInt32(&closed) == 1 }, time.Second, 10*time.Millisecond)
+
+	authURL := strings.Replace(p.URL, "http://", "http://user:pass@", 1)
+	res, err = do("http://backend.invalid/auth", &Request{Proxy: authURL})
+	r.NoError(err)
+	r.Equal(http.StatusOK, res.Returns.StatusCode)
+	r.Equal(int32(2), atomic.LoadInt32(&proxied))
+	res, err = do("http://backend.invalid/auth", &Request{Proxy: strings.Replace(p.URL, "http://", "http://user:wrong@", 1)})
+	r.NoError(err)
+	r.Equal(http.StatusProxyAuthRequired, res.Returns.StatusCode)
+	r.Equal(int32(2), atomic.LoadInt32(&proxied))
+
+	// loopback addresses are never proxied by the proxy environment variables
+	res, err = do(s.URL, nil)
+	r.NoError(err)
+	r.Equal("direct", res.Returns.Body)
+	r.Equal(int32(2), atomic.LoadInt32(&proxied))
+	// the connections of the default transport are reused
+	_, err = do(s.URL, nil)
+	r.NoError(err)
+	r.Equal(int32(1), atomic.LoadInt32(&connected))
+
+	_, err = do(s.URL, &Request{Proxy: "://user:secret@proxy"})
+	r.Error(err)
+	r.NotContains(err.Error(), "secret")
+	_, err = do(s.URL, &Request{Proxy: "proxy.local"})
+	r.Error(err)
+	r.Contains(err.Error(), "invalid proxy")
+}
+
+func TestHTTPDoWithResponseJSONPath(t *testing.T) {
+	r := require.New(t)
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/text" {
+			_, _ = w.Write([]byte("not json"))
+			return
+		}
+		_, _ = w.Write([]byte(`{"metadata":{"name":"app","replicas":3},"items":[{"id":"a"},{"id":"b"}]}`))
+	}))
+	defer s.Close()
+	do := func(path string, paths map[string]string) (*DoReturns, error) {
+		return Do(context.Background(), &DoParams{
+			Params: RequestVars{Method: "GET", URL: s.URL + path, Request: &Request{ResponseJSONPath: paths}},
+		})
+	}
+
+	res, err := do("/", map[string]string{
+		"name":     "$.metadata.name",
+		"replicas": "{.metadata.replicas}",
+		"second":   "$.items[1].id",
+		"ids":      "$.items[*].id",
+	})
+	r.NoError(err)
+	r.Equal(map[string]interface{}{
+		"name":     "app",
+		"replicas": float64(3),
+		"second":   "b",
+		"ids":      []interface{}{"a", "b"},
+	}, res.Returns.Outputs)
+	r.NotEmpty(res.Returns.Body)
+
+	_, err = do("/", map[string]string{"name": "$.metadata.name", "missing": "$.metadata.namespace"})
+	r.Error(err)
+	r.Contains(err.Error(), "extract output missing by JSONPath $.metadata.namespace")
+	_, err = do("/", map[string]string{"third": "$.items[2].id"})
+	r.Error(err)
+	r.Contains(err.Error(), "extract output third by JSONPath $.items[2].id")
+	_, err = do("/", map[string]string{"bad": "$.items[?("})
+	r.Error(err)
+	r.Contains(err.Error(), "invalid JSONPath $.items[?( of output bad")
+	_, err = do("/text", map[string]string{"name": "$.metadata.name"})
+	r.Error(err)
+	r.Contains(err.Error(), "parse the response body as JSON")
+}
+
+func TestHTTPDoWithUnixSocket(t *testing.T) {
+	r := require.New(t)
+	// the temp dir of the test might exceed the length limit of the socket path
+	dir, err := os.MkdirTemp("", "http")
+	r.NoError(err)
+	defer os.RemoveAll(dir)
+	socket := filepath.Join(dir, "server.sock")
+	l, err := net.Listen("unix", socket)
+	r.NoError(err)
+	s := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/slow" {
+			time.Sleep(time.Second)
+		}
+		b, _ := io.ReadAll(req.Body)
+		_, _ = w.Write([]byte(fmt.Sprintf("%s %s %s %s %s", req.Method, req.Host, req.URL.RequestURI(), req.Header.Get("X-Token"), b)))
+	}), ReadHeaderTimeout: time.Second}
+	go func() { _ = s.Serve(l) }()
+	defer s.Close()
+	do := func(method, url string, request *Request) (*DoReturns, error) {
+		request.UnixSocket = socket
+		return Do(context.Background(), &DoParams{
+			Params: RequestVars{Method: method, URL: url, Request: request},
+		})
+	}
+
+	res, err := do("POST", "http://docker/containers/json?all=1", &Request{
+		Body:   "body",
+		Header: map[string]string{"X-Token": "token"},
+	})
+	r.NoError(err)
+	r.Equal(http.StatusOK, res.Returns.StatusCode)
+	r.Equal("POST docker /containers/json?all=1 token body", res.Returns.Body)
+
+	_, err = do("GET", "http://docker/slow", &Request{Timeout: "100ms"})
+	r.ErrorIs(err, ErrRequestTimeout)
+	_, err = do("GET", "http://docker/", &Request{Proxy: "http://proxy.local"})
+	r.Error(err)
+	r.Contains(err.Error(), "proxy and unixSocket cannot be set at the same time")
+	_, err = Do(context.Background(), &DoParams{
+		Params: RequestVars{Method: "GET", URL: "http://docker/", Request: &Request{UnixSocket: filepath.Join(dir, "none.sock")}},
+	})
+	r.Error(err)
+}
+
+func TestHTTPDoWithTimeout(t *testing.T) {
+	r := require.New(t)
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		delay, _ := time.ParseDuration(req.URL.Query().Get("delay"))
+		select {
+		case <-time.After(delay):
+		case <-req.Context().Done():
+			return
+		}
+		_, _ = w.Write([]byte("done"))
+	}))
+	defer s.Close()
+	do := func(ctx context.Context, delay string, request *Request) (*DoReturns, error) {
+		return Do(ctx, &DoParams{
+			Params: RequestVars{Method: "GET", URL: s.URL + "?delay=" + delay, Request: request},
+		})
+	}
+
+	res, err := do(context.Background(), "10ms", &Request{Timeout: "1s"})
+	r.NoError(err)
+	r.Equal("done", res.Returns.Body)
+	_, err = do(context.Background(), "1s", &Request{Timeout: "100ms"})
+	r.ErrorIs(err, ErrRequestTimeout)
+	r.Contains(err.Error(), "the request timed out after 100ms")
+	_, err = do(context.Background(), "1s", &Request{Timeout: "100ms", Retry: &Retry{Count: 1, Interval: "10ms"}})
+	r.ErrorIs(err, ErrRequestTimeout)
+	r.Contains(err.Error(), "request failed after 2 attempts")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		cancel()
+	}()
+	start := time.Now()
+	_, err = do(ctx, "5s", &Request{Timeout: "10s"})
+	r.ErrorIs(err, context.Canceled)
+	r.NotErrorIs(err, ErrRequestTimeout)
+	r.Less(time.Since(start), 5*time.Second)
+
+	_, err = do(context.Background(), "0s", &Request{Timeout: "-1s"})
+	r.Error(err)
+	r.Contains(err.Error(), "invalid timeout -1s")
+	_, err = do(context.Background(), "0s", &Request{Timeout: "1x"})
+	r.Error(err)
+	r.Contains(err.Error(), "invalid timeout 1x")
+}
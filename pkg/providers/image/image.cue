@@ -0,0 +1,30 @@
+// image.cue
+
+#Check: {
+	#do:       "check"
+	#provider: "image"
+
+	$params: {
+		// +usage=The image to check, such as nginx:1.25 or registry.example.com/app@sha256:...
+		image: string
+		// +usage=The secret of type kubernetes.io/dockerconfigjson which contains the credential of the registry
+		secretRef?: {
+			// +usage=The name of the secret
+			name: string
+			// +usage=The namespace of the secret, default to the namespace of the workflow
+			namespace?: string
+		}
+		// +usage=Whether to access the registry with http
+		insecure: *false | bool
+		// +usage=The timeout of checking the image, at most 1m
+		timeout: *"10s" | string
+	}
+
+	$returns?: {
+		// +usage=Whether the image exists in the registry
+		exists: bool
+		// +usage=The digest of the image manifest
+		digest?: string
+	}
+	...
+}
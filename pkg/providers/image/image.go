@@ -0,0 +1,347 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package image
+
+import (
+	"context"
+	_ "embed"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/distribution/reference"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	cuexruntime "github.com/kubevela/pkg/cue/cuex/runtime"
+
+	"github.com/kubevela/workflow/pkg/cue/model"
+	providertypes "github.com/kubevela/workflow/pkg/providers/types"
+)
+
+const (
+	// ProviderName is provider name for install.
+	ProviderName = "image"
+
+	defaultRegistry   = "docker.io"
+	dockerHubRegistry = "registry-1.docker.io"
+	defaultTimeout    = 10 * time.Second
+	// maxTimeout keeps the check below the reconcile timeout of the controller, which is 3m
+	maxTimeout = time.Minute
+	// maxTokenSize is the max size of the response of the registry token
+	maxTokenSize = 64 << 10
+)
+
+// manifestMediaTypes are the accepted media types of the manifest, including the image index.
+var manifestMediaTypes = []string{
+	"application/vnd.oci.image.index.v1+json",
+	"application/vnd.oci.image.manifest.v1+json",
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+}
+
+// SecretRef is the reference of the docker config secret.
+type SecretRef struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// CheckVars .
+type CheckVars struct {
+	Image     string     `json:"image"`
+	SecretRef *SecretRef `json:"secretRef,omitempty"`
+	Insecure  bool       `json:"insecure,omitempty"`
+	Timeout   string     `json:"timeout,omitempty"`
+}
+
+// CheckReturnVars .
+type CheckReturnVars struct {
+	Exists bool   `json:"exists"`
+	Digest string `json:"digest,omitempty"`
+}
+
+// CheckParams .
+type CheckParams = providertypes.Params[CheckVars]
+
+// CheckReturns .
+type CheckReturns = providertypes.Returns[CheckReturnVars]
+
+// Reference is the parsed reference of an image.
+type Reference struct {
+	Registry   string
+	Repository string
+	// Reference is the tag or the digest of the image
+	Reference string
+}
+
+// ParseReference parses the image into registry, repository and tag or digest.
+// The digest takes precedence over the tag if both of them are set.
+func ParseReference(image string) (*Reference, error) {
+	if image == "" {
+		return nil, fmt.Errorf("empty image")
+	}
+	named, err := reference.ParseNormalizedNamed(image)
+	if err != nil {
+		return nil, fmt.Errorf("invalid image %s: %w", image, err)
+	}
+	ref := &Reference{Registry: reference.Domain(named), Repository: reference.Path(named), Reference: "latest"}
+	if digested, ok := named.(reference.Digested); ok {
+		ref.Reference = digested.Digest().String()
+	} else if tagged, ok := named.(reference.Tagged); ok {
+		ref.Reference = tagged.Tag()
+	}
+	return ref, nil
+}
+
+// Check checks whether the image exists in the registry by requesting the manifest, and returns the digest of it.
+func Check(ctx context.Context, params *CheckParams) (*CheckReturns, error) {
+	vars := params.Params
+	ref, err := ParseReference(vars.Image)
+	if err != nil {
+		return nil, err
+	}
+	timeout := defaultTimeout
+	if vars.Timeout != "" {
+		if timeout, err = time.ParseDuration(vars.Timeout); err != nil {
+			return nil, fmt.Errorf("invalid timeout %s: %w", vars.Timeout, err)
+		}
+		if timeout <= 0 || timeout > maxTimeout {
+			return nil, fmt.Errorf("invalid timeout %s: must be positive and at most %s", vars.Timeout, maxTimeout)
+		}
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var username, password string
+	if vars.SecretRef != nil {
+		namespace := vars.SecretRef.Namespace
+		if namespace == "" {
+			namespace = fmt.Sprint(params.ProcessContext.GetData(model.ContextNamespace))
+		}
+		if username, password, err = getCredential(ctx, params.KubeClient, client.ObjectKey{Namespace: namespace, Name: vars.SecretRef.Name}, ref.Registry); err != nil {
+			return nil, err
+		}
+	}
+
+	c := &registryClient{client: &http.Client{Timeout: timeout}, username: username, password: password}
+	scheme := "https"
+	if vars.Insecure {
+		scheme = "http"
+	}
+	host := ref.Registry
+	if host == defaultRegistry {
+		host = dockerHubRegistry
+	}
+	resp, err := c.do(ctx, http.MethodHead, fmt.Sprintf("%s://%s/v2/%s/manifests/%s", scheme, host, ref.Repository, ref.Reference))
+	if err != nil {
+		return nil, err
+	}
+	//nolint:errcheck
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusOK:
+		digest := resp.Header.Get("Docker-Content-Digest")
+		if digest == "" && strings.Contains(ref.Reference, ":") {
+			digest = ref.Reference
+		}
+		return &CheckReturns{Returns: CheckReturnVars{Exists: true, Digest: digest}}, nil
+	case resp.StatusCode == http.StatusNotFound:
+		return &CheckReturns{Returns: CheckReturnVars{Exists: false}}, nil
+	default:
+		return nil, fmt.Errorf("failed to check image %s: unexpected status code %d", vars.Image, resp.StatusCode)
+	}
+}
+
+type registryClient struct {
+	client   *http.Client
+	username string
+	password string
+	token    string
+}
+
+// do sends the request to the registry, and retries with the bearer token if the registry requires it.
+func (c *registryClient) do(ctx context.Context, method, u string) (*http.Response, error) {
+	resp, err := c.send(ctx, method, u)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+	challenge := resp.Header.Get("WWW-Authenticate")
+	_ = resp.Body.Close()
+	if !strings.HasPrefix(strings.ToLower(challenge), "bearer ") {
+		return nil, fmt.Errorf("unauthorized to access %s", u)
+	}
+	if err := c.fetchToken(ctx, parseChallenge(challenge[len("bearer "):])); err != nil {
+		return nil, err
+	}
+	return c.send(ctx, method, u)
+}
+
+func (c *registryClient) send(ctx context.Context, method, u string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", strings.Join(manifestMediaTypes, ", "))
+	switch {
+	case c.token != "":
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	case c.username != "" || c.password != "":
+		req.SetBasicAuth(c.username, c.password)
+	}
+	return c.client.Do(req)
+}
+
+func (c *registryClient) fetchToken(ctx context.Context, challenge map[string]string) error {
+	realm, ok := challenge["realm"]
+	if !ok {
+		return fmt.Errorf("the realm of the auth challenge is missing")
+	}
+	u, err := url.Parse(realm)
+	if err != nil {
+		return fmt.Errorf("invalid realm %s: %w", realm, err)
+	}
+	query := u.Query()
+	for _, key := range []string{"service", "scope"} {
+		if v, ok := challenge[key]; ok {
+			query.Set(key, v)
+		}
+	}
+	u.RawQuery = query.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return err
+	}
+	if c.username != "" || c.password != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	//nolint:errcheck
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to get the registry token: unexpected status code %d", resp.StatusCode)
+	}
+	token := struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}{}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxTokenSize)).Decode(&token); err != nil {
+		return fmt.Errorf("failed to decode the registry token: %w", err)
+	}
+	c.token = token.Token
+	if c.token == "" {
+		c.token = token.AccessToken
+	}
+	if c.token == "" {
+		return fmt.Errorf("empty registry token")
+	}
+	return nil
+}
+
+// parseChallenge parses the parameters of the auth challenge like realm="...",service="..."
+func parseChallenge(s string) map[string]string {
+	params := map[string]string{}
+	for s != "" {
+		s = strings.TrimLeft(s, " ,")
+		i := strings.Index(s, "=")
+		if i < 0 {
+			break
+		}
+		key := strings.ToLower(strings.TrimSpace(s[:i]))
+		s = s[i+1:]
+		var value string
+		if strings.HasPrefix(s, `"`) {
+			end := strings.Index(s[1:], `"`)
+			if end < 0 {
+				value, s = s[1:], ""
+			} else {
+				value, s = s[1:end+1], s[end+2:]
+			}
+		} else if end := strings.Index(s, ","); end >= 0 {
+			value, s = s[:end], s[end:]
+		} else {
+			value, s = s, ""
+		}
+		params[key] = value
+	}
+	return params
+}
+
+// getCredential gets the credential of the registry from the docker config secret.
+func getCredential(ctx context.Context, cli client.Client, key client.ObjectKey, registry string) (string, string, error) {
+	secret := &corev1.Secret{}
+	if err := cli.Get(ctx, key, secret); err != nil {
+		return "", "", fmt.Errorf("failed to get the registry secret %s: %w", key, err)
+	}
+	data, ok := secret.Data[corev1.DockerConfigJsonKey]
+	if !ok {
+		return "", "", fmt.Errorf("the registry secret %s does not contain %s", key, corev1.DockerConfigJsonKey)
+	}
+	config := struct {
+		Auths map[string]struct {
+			Username string `json:"username"`
+			Password string `json:"password"`
+			Auth     string `json:"auth"`
+		} `json:"auths"`
+	}{}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return "", "", fmt.Errorf("failed to parse the registry secret %s: %w", key, err)
+	}
+	candidates := []string{registry, "https://" + registry, "http://" + registry}
+	if registry == defaultRegistry {
+		candidates = append(candidates, "https://index.docker.io/v1/", "index.docker.io", dockerHubRegistry)
+	}
+	for _, candidate := range candidates {
+		auth, ok := config.Auths[candidate]
+		if !ok {
+			continue
+		}
+		if auth.Auth != "" {
+			b, err := base64.StdEncoding.DecodeString(auth.Auth)
+			if err != nil {
+				return "", "", fmt.Errorf("failed to decode the auth of %s: %w", registry, err)
+			}
+			if username, password, ok := strings.Cut(string(b), ":"); ok {
+				return username, password, nil
+			}
+		}
+		return auth.Username, auth.Password, nil
+	}
+	return "", "", fmt.Errorf("the registry secret %s does not contain the credential of %s", key, registry)
+}
+
+//go:embed image.cue
+var template string
+
+// GetTemplate returns the cue template.
+func GetTemplate() string {
+	return template
+}
+
+// GetProviders returns the cue providers.
+func GetProviders() map[string]cuexruntime.ProviderFn {
+	return map[string]cuexruntime.ProviderFn{
+		"check": providertypes.GenericProviderFn[CheckVars, CheckReturns](Check),
+	}
+}
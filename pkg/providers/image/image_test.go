@@ -0,0 +1,155 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package image
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/kubevela/workflow/pkg/cue/process"
+	providertypes "github.com/kubevela/workflow/pkg/providers/types"
+)
+
+func TestParseReference(t *testing.T) {
+	const digest = "sha256:2d58ba7b7a0b0f4dbd0cbd3d7c6f5e1e1d6a5e4f1c5fd8f3b8f0f3d2c1b0a9e8"
+	testCases := map[string]struct {
+		expected *Reference
+		err      bool
+	}{
+		"nginx":                          {expected: &Reference{Registry: "docker.io", Repository: "library/nginx", Reference: "latest"}},
+		"nginx:1.25":                     {expected: &Reference{Registry: "docker.io", Repository: "library/nginx", Reference: "1.25"}},
+		"oamdev/vela-workflow:v1":        {expected: &Reference{Registry: "docker.io", Repository: "oamdev/vela-workflow", Reference: "v1"}},
+		"localhost:5000/app":             {expected: &Reference{Registry: "localhost:5000", Repository: "app", Reference: "latest"}},
+		"ghcr.io/org/app@" + digest:      {expected: &Reference{Registry: "ghcr.io", Repository: "org/app", Reference: digest}},
+		"ghcr.io/org/app:v1@" + digest:   {expected: &Reference{Registry: "ghcr.io", Repository: "org/app", Reference: digest}},
+		"registry.example.com/a/b/c:1.0": {expected: &Reference{Registry: "registry.example.com", Repository: "a/b/c", Reference: "1.0"}},
+		"":                               {err: true},
+		"app:":                           {err: true},
+		"ghcr.io/org/app@sha256:abc":     {err: true},
+		"Invalid/App":                    {err: true},
+	}
+	for image, tc := range testCases {
+		t.Run(image, func(t *testing.T) {
+			r := require.New(t)
+			ref, err := ParseReference(image)
+			if tc.err {
+				r.Error(err)
+				return
+			}
+			r.NoError(err)
+			r.Equal(tc.expected, ref)
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	const digest = "sha256:2d58ba7b7a0b0f4dbd0cbd3d7c6f5e1e1d6a5e4f1c5fd8f3b8f0f3d2c1b0a9e8"
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			if user, pass, ok := r.BasicAuth(); !ok || user != "user" || pass != "pass" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			if r.URL.Query().Get("scope") != "repository:app:pull" || r.URL.Query().Get("service") != "registry" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			_, _ = w.Write([]byte(`{"token":"secret-token"}`))
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer secret-token" {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="registry",scope="repository:app:pull"`, server.URL))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.Method != http.MethodHead || !strings.Contains(r.Header.Get("Accept"), "application/vnd.oci.image.index.v1+json") {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		switch r.URL.Path {
+		case "/v2/app/manifests/v1", "/v2/app/manifests/" + digest:
+			w.Header().Set("Docker-Content-Digest", digest)
+			w.WriteHeader(http.StatusOK)
+		case "/v2/app/manifests/slow":
+			time.Sleep(time.Second)
+			w.WriteHeader(http.StatusOK)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	host := strings.TrimPrefix(server.URL, "http://")
+
+	auth := base64.StdEncoding.EncodeToString([]byte("user:pass"))
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "registry", Namespace: "default"},
+		Type:       corev1.SecretTypeDockerConfigJson,
+		Data: map[string][]byte{
+			corev1.DockerConfigJsonKey: []byte(fmt.Sprintf(`{"auths":{"%s":{"auth":"%s"}}}`, host, auth)),
+		},
+	}
+	cli := fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).WithObjects(secret).Build()
+	pCtx := process.NewContext(process.ContextData{Namespace: "default"})
+	check := func(image string, secretRef *SecretRef, timeout string) (*CheckReturns, error) {
+		return Check(context.Background(), &CheckParams{
+			Params: CheckVars{Image: image, SecretRef: secretRef, Insecure: true, Timeout: timeout},
+			RuntimeParams: providertypes.RuntimeParams{
+				KubeClient:     cli,
+				ProcessContext: pCtx,
+			},
+		})
+	}
+	r := require.New(t)
+
+	res, err := check(host+"/app:v1", &SecretRef{Name: "registry"}, "")
+	r.NoError(err)
+	r.Equal(CheckReturnVars{Exists: true, Digest: digest}, res.Returns)
+
+	res, err = check(host+"/app@"+digest, &SecretRef{Name: "registry", Namespace: "default"}, "")
+	r.NoError(err)
+	r.Equal(CheckReturnVars{Exists: true, Digest: digest}, res.Returns)
+
+	res, err = check(host+"/app:absent", &SecretRef{Name: "registry"}, "")
+	r.NoError(err)
+	r.Equal(CheckReturnVars{Exists: false}, res.Returns)
+
+	_, err = check(host+"/app:v1", nil, "")
+	r.Error(err)
+	_, err = check(host+"/app:v1", &SecretRef{Name: "not-exist"}, "")
+	r.Error(err)
+	_, err = check(host+"/app:slow", &SecretRef{Name: "registry"}, "100ms")
+	r.ErrorIs(err, context.DeadlineExceeded)
+	_, err = check(host+"/app:v1", &SecretRef{Name: "registry"}, "invalid")
+	r.Error(err)
+	for _, timeout := range []string{"0s", "-1s", "1h"} {
+		_, err = check(host+"/app:v1", &SecretRef{Name: "registry"}, timeout)
+		r.EqualError(err, "invalid timeout "+timeout+": must be positive and at most 1m0s")
+	}
+}
@@ -0,0 +1,24 @@
+// jq.cue
+
+#Transform: {
+	#do:       "transform"
+	#provider: "jq"
+
+	$params: {
+		// +usage=The input of the query
+		input?: _
+		// +usage=The input from the workflow context, cannot be used together with the input
+		inputFrom?: {
+			// +usage=The path of the variable in the workflow context, e.g. outputs.report
+			var: string
+		}
+		// +usage=The jq query, the environment variables and the modules are not available to the query
+		query: string
+	}
+
+	$returns?: {
+		// +usage=The result of the query, the query must produce at most one result, wrap the query with [] to collect multiple results
+		result: _
+	}
+	...
+}
@@ -0,0 +1,157 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jq
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/itchyny/gojq"
+
+	cuexruntime "github.com/kubevela/pkg/cue/cuex/runtime"
+
+	providertypes "github.com/kubevela/workflow/pkg/providers/types"
+)
+
+const (
+	// ProviderName is provider name for install.
+	ProviderName = "jq"
+)
+
+// InputFrom is the reference of the input in the workflow context.
+type InputFrom struct {
+	// Var is the path of the variable in the workflow context, e.g. outputs.report
+	Var string `json:"var"`
+}
+
+// TransformVars .
+type TransformVars struct {
+	Input     json.RawMessage `json:"input,omitempty"`
+	InputFrom *InputFrom      `json:"inputFrom,omitempty"`
+	Query     string          `json:"query"`
+}
+
+// TransformReturnVars .
+type TransformReturnVars struct {
+	Result interface{} `json:"result"`
+}
+
+// TransformParams .
+type TransformParams = providertypes.Params[TransformVars]
+
+// TransformReturns .
+type TransformReturns = providertypes.Returns[TransformReturnVars]
+
+// Transform evaluates the query against the input, the query must produce at most one result, the result
+// is null if the query produces nothing.
+func Transform(ctx context.Context, params *TransformParams) (*TransformReturns, error) {
+	vars := params.Params
+	code, err := compile(vars.Query)
+	if err != nil {
+		return nil, err
+	}
+	input, err := loadInput(params.RuntimeParams, vars)
+	if err != nil {
+		return nil, err
+	}
+	var outputs []interface{}
+	iter := code.RunWithContext(ctx, input)
+	for len(outputs) <= 1 {
+		v, ok := iter.Next()
+		if !ok {
+			break
+		}
+		if err, ok := v.(error); ok {
+			return nil, fmt.Errorf("failed to evaluate the query: %w", err)
+		}
+		outputs = append(outputs, v)
+	}
+	if len(outputs) > 1 {
+		return nil, errors.New("the query produces more than one result, wrap the query with [] to collect the results into an array")
+	}
+	var result interface{}
+	if len(outputs) == 1 {
+		result = outputs[0]
+	}
+	return &TransformReturns{Returns: TransformReturnVars{Result: result}}, nil
+}
+
+// compile compiles the query without the access to the environment variables of the controller, the
+// modules and the extra inputs.
+func compile(query string) (*gojq.Code, error) {
+	parsed, err := gojq.Parse(query)
+	if err != nil {
+		var parseErr *gojq.ParseError
+		if errors.As(err, &parseErr) {
+			return nil, fmt.Errorf("invalid query at position %d: %w", parseErr.Offset, err)
+		}
+		return nil, fmt.Errorf("invalid query: %w", err)
+	}
+	code, err := gojq.Compile(parsed, gojq.WithEnvironLoader(func() []string { return nil }))
+	if err != nil {
+		return nil, fmt.Errorf("invalid query: %w", err)
+	}
+	return code, nil
+}
+
+func loadInput(runtimeParams providertypes.RuntimeParams, vars TransformVars) (interface{}, error) {
+	raw := []byte(vars.Input)
+	switch {
+	case len(vars.Input) > 0 && vars.InputFrom != nil:
+		return nil, errors.New("only one of input and inputFrom can be set")
+	case vars.InputFrom != nil:
+		if vars.InputFrom.Var == "" {
+			return nil, errors.New("the var of inputFrom is required")
+		}
+		if runtimeParams.WorkflowContext == nil {
+			return nil, errors.New("the workflow context is not available")
+		}
+		value, err := runtimeParams.WorkflowContext.GetVar(strings.Split(vars.InputFrom.Var, ".")...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get the var %s from the workflow context: %w", vars.InputFrom.Var, err)
+		}
+		if raw, err = value.MarshalJSON(); err != nil {
+			return nil, fmt.Errorf("failed to encode the var %s from the workflow context: %w", vars.InputFrom.Var, err)
+		}
+	case len(vars.Input) == 0:
+		return nil, nil
+	}
+	var input interface{}
+	if err := json.Unmarshal(raw, &input); err != nil {
+		return nil, fmt.Errorf("failed to decode the input: %w", err)
+	}
+	return input, nil
+}
+
+//go:embed jq.cue
+var template string
+
+// GetTemplate returns the cue template.
+func GetTemplate() string {
+	return template
+}
+
+// GetProviders returns the cue providers.
+func GetProviders() map[string]cuexruntime.ProviderFn {
+	return map[string]cuexruntime.ProviderFn{
+		"transform": providertypes.GenericProviderFn[TransformVars, TransformReturns](Transform),
+	}
+}
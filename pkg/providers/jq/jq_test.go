@@ -0,0 +1,148 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jq
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+
+	wfContext "github.com/kubevela/workflow/pkg/context"
+	providertypes "github.com/kubevela/workflow/pkg/providers/types"
+)
+
+func TestTransform(t *testing.T) {
+	ctx := context.Background()
+	wfCtx := new(wfContext.WorkflowContext)
+	require.NoError(t, wfCtx.LoadFromConfigMap(ctx, corev1.ConfigMap{
+		Data: map[string]string{
+			wfContext.ConfigMapKeyVars: `{"outputs": {"pods": [{"name": "a", "ready": true}, {"name": "b", "ready": false}]}}`,
+		},
+	}))
+	testCases := map[string]struct {
+		vars     TransformVars
+		expected string
+		err      string
+	}{
+		"object projection": {
+			vars: TransformVars{
+				Input: json.RawMessage(`{"metadata": {"name": "app", "namespace": "default"}, "spec": {"replicas": 2}}`),
+				Query: "{name: .metadata.name, replicas: .spec.replicas}",
+			},
+			expected: `{"name": "app", "replicas": 2}`,
+		},
+		"array mapping and filtering from context": {
+			vars: TransformVars{
+				InputFrom: &InputFrom{Var: "outputs.pods"},
+				Query:     "map(select(.ready) | .name)",
+			},
+			expected: `["a"]`,
+		},
+		"no result": {
+			vars: TransformVars{
+				Input: json.RawMessage(`[1, 2]`),
+				Query: ".[] | select(. > 5)",
+			},
+			expected: `null`,
+		},
+		"multiple results": {
+			vars: TransformVars{
+				Input: json.RawMessage(`[1, 2]`),
+				Query: ".[]",
+			},
+			err: "the query produces more than one result, wrap the query with [] to collect the results into an array",
+		},
+		"recursive descent and string interpolation": {
+			vars: TransformVars{
+				Input: json.RawMessage(`{"spec": {"containers": [{"image": "nginx"}, {"image": "envoy"}]}}`),
+				Query: `[.. | .image? // empty | "image: \(.)"]`,
+			},
+			expected: `["image: nginx", "image: envoy"]`,
+		},
+		"no environment variables": {
+			vars: TransformVars{
+				Input: json.RawMessage(`{}`),
+				Query: `[env, $ENV]`,
+			},
+			expected: `[{}, {}]`,
+		},
+		"malformed query": {
+			vars: TransformVars{
+				Input: json.RawMessage(`{}`),
+				Query: "{name: .metadata.name",
+			},
+			err: "invalid query at position 21: unexpected EOF",
+		},
+		"evaluation error": {
+			vars: TransformVars{
+				Input: json.RawMessage(`{"replicas": "two"}`),
+				Query: ".replicas + 1",
+			},
+			err: "failed to evaluate the query: cannot add: string (\"two\") and number (1)",
+		},
+		"both input and inputFrom": {
+			vars: TransformVars{
+				Input:     json.RawMessage(`{}`),
+				InputFrom: &InputFrom{Var: "outputs.pods"},
+				Query:     ".",
+			},
+			err: "only one of input and inputFrom can be set",
+		},
+		"missing var": {
+			vars: TransformVars{
+				InputFrom: &InputFrom{Var: "outputs.missing"},
+				Query:     ".",
+			},
+			err: "failed to get the var outputs.missing from the workflow context",
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			r := require.New(t)
+			res, err := Transform(ctx, &TransformParams{
+				Params: tc.vars,
+				RuntimeParams: providertypes.RuntimeParams{
+					WorkflowContext: wfCtx,
+				},
+			})
+			if tc.err != "" {
+				r.Error(err)
+				r.Contains(err.Error(), tc.err)
+				return
+			}
+			r.NoError(err)
+			actual, err := json.Marshal(res.Returns.Result)
+			r.NoError(err)
+			r.JSONEq(tc.expected, string(actual))
+		})
+	}
+}
+
+func TestTransformCanceled(t *testing.T) {
+	r := require.New(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	_, err := Transform(ctx, &TransformParams{Params: TransformVars{
+		Input: json.RawMessage(`0`),
+		Query: "last(repeat(.))",
+	}})
+	r.ErrorIs(err, context.DeadlineExceeded)
+}
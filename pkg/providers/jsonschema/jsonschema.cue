@@ -0,0 +1,36 @@
+// jsonschema.cue
+
+#Validate: {
+	#do:       "validate"
+	#provider: "jsonschema"
+
+	$params: {
+		// +usage=The instance to validate
+		instance: _
+		// +usage=The inline JSON Schema, draft-07 is used if the $schema is not set, only the $ref inside the schema can be resolved and the patterns use the RE2 syntax
+		schema?: _
+		// +usage=The reference of the JSON Schema stored in the configmap
+		schemaRef?: {
+			// +usage=The name of the configmap
+			name: string
+			// +usage=The namespace of the configmap, default to the namespace of the workflow
+			namespace?: string
+			// +usage=The key of the schema in the configmap
+			key: string
+		}
+		// +usage=Whether to fail the step if the instance is invalid
+		failOnInvalid: *true | bool
+	}
+
+	$returns?: {
+		// +usage=Whether the instance is valid against the schema
+		valid: bool
+		// +usage=The validation errors of the instance
+		errors: [...{
+			// +usage=The JSON pointer of the invalid value in the instance
+			path:    string
+			message: string
+		}]
+	}
+	...
+}
@@ -0,0 +1,139 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jsonschema
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	cuexruntime "github.com/kubevela/pkg/cue/cuex/runtime"
+
+	"github.com/kubevela/workflow/pkg/cue/model"
+	"github.com/kubevela/workflow/pkg/errors"
+	providertypes "github.com/kubevela/workflow/pkg/providers/types"
+)
+
+const (
+	// ProviderName is provider name for install.
+	ProviderName = "jsonschema"
+)
+
+// SchemaRef is the reference of the schema stored in the ConfigMap.
+type SchemaRef struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"`
+	Key       string `json:"key"`
+}
+
+// ValidateVars .
+type ValidateVars struct {
+	Instance      json.RawMessage `json:"instance"`
+	Schema        json.RawMessage `json:"schema,omitempty"`
+	SchemaRef     *SchemaRef      `json:"schemaRef,omitempty"`
+	FailOnInvalid *bool           `json:"failOnInvalid,omitempty"`
+}
+
+// ValidateReturnVars .
+type ValidateReturnVars struct {
+	Valid  bool              `json:"valid"`
+	Errors []ValidationError `json:"errors"`
+}
+
+// ValidateParams .
+type ValidateParams = providertypes.Params[ValidateVars]
+
+// ValidateReturns .
+type ValidateReturns = providertypes.Returns[ValidateReturnVars]
+
+// DoValidate validates the instance against the JSON Schema, which is inline or stored in the ConfigMap.
+func DoValidate(ctx context.Context, params *ValidateParams) (*ValidateReturns, error) {
+	vars := params.Params
+	raw := vars.Schema
+	if vars.SchemaRef != nil {
+		if len(raw) > 0 {
+			return nil, fmt.Errorf("schema and schemaRef cannot be set at the same time")
+		}
+		ref := vars.SchemaRef
+		namespace := ref.Namespace
+		if namespace == "" {
+			namespace = fmt.Sprint(params.ProcessContext.GetData(model.ContextNamespace))
+		}
+		cm := &corev1.ConfigMap{}
+		if err := params.KubeClient.Get(ctx, client.ObjectKey{Namespace: namespace, Name: ref.Name}, cm); err != nil {
+			return nil, fmt.Errorf("failed to get the schema from configmap %s/%s: %w", namespace, ref.Name, err)
+		}
+		data, ok := cm.Data[ref.Key]
+		if !ok {
+			return nil, fmt.Errorf("key %s is not found in configmap %s/%s", ref.Key, namespace, ref.Name)
+		}
+		raw = json.RawMessage(data)
+	}
+	if len(raw) == 0 {
+		return nil, fmt.Errorf("either schema or schemaRef must be set")
+	}
+	var schema, instance interface{}
+	if err := json.Unmarshal(raw, &schema); err != nil {
+		return nil, fmt.Errorf("failed to decode the schema: %w", err)
+	}
+	if len(vars.Instance) > 0 {
+		if err := json.Unmarshal(vars.Instance, &instance); err != nil {
+			return nil, fmt.Errorf("failed to decode the instance: %w", err)
+		}
+	}
+	validationErrors, err := Validate(schema, instance)
+	if err != nil {
+		return nil, err
+	}
+	if len(validationErrors) > 0 && (vars.FailOnInvalid == nil || *vars.FailOnInvalid) {
+		msgs := make([]string, 0, len(validationErrors))
+		for _, e := range validationErrors {
+			msgs = append(msgs, e.String())
+		}
+		params.Action.Fail(fmt.Sprintf("The instance is invalid against the schema: %s", strings.Join(msgs, "; ")))
+		return nil, errors.GenericActionError(errors.ActionTerminate)
+	}
+	if validationErrors == nil {
+		validationErrors = []ValidationError{}
+	}
+	return &ValidateReturns{
+		Returns: ValidateReturnVars{
+			Valid:  len(validationErrors) == 0,
+			Errors: validationErrors,
+		},
+	}, nil
+}
+
+//go:embed jsonschema.cue
+var template string
+
+// GetTemplate returns the cue template.
+func GetTemplate() string {
+	return template
+}
+
+// GetProviders returns the cue providers.
+func GetProviders() map[string]cuexruntime.ProviderFn {
+	return map[string]cuexruntime.ProviderFn{
+		"validate": providertypes.GenericProviderFn[ValidateVars, ValidateReturns](DoValidate),
+	}
+}
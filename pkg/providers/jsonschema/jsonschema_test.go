@@ -0,0 +1,90 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jsonschema
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/kubevela/workflow/pkg/cue/process"
+	"github.com/kubevela/workflow/pkg/mock"
+	providertypes "github.com/kubevela/workflow/pkg/providers/types"
+)
+
+func TestDoValidate(t *testing.T) {
+	const schema = `{"$schema":"http://json-schema.org/draft-07/schema#","type":"object","required":["name"],"properties":{"name":{"type":"string"},"replicas":{"type":"integer"}}}`
+	cli := fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).WithObjects(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "schemas", Namespace: "default"},
+		Data:       map[string]string{"app.json": schema},
+	}).Build()
+	pCtx := process.NewContext(process.ContextData{Namespace: "default"})
+	validate := func(act *mock.Action, vars ValidateVars) (*ValidateReturns, error) {
+		return DoValidate(context.Background(), &ValidateParams{
+			Params: vars,
+			RuntimeParams: providertypes.RuntimeParams{
+				KubeClient:     cli,
+				ProcessContext: pCtx,
+				Action:         act,
+			},
+		})
+	}
+	r := require.New(t)
+
+	act := &mock.Action{}
+	res, err := validate(act, ValidateVars{Instance: json.RawMessage(`{"name":"web","replicas":1}`), Schema: json.RawMessage(schema)})
+	r.NoError(err)
+	r.Equal(ValidateReturnVars{Valid: true, Errors: []ValidationError{}}, res.Returns)
+	r.Equal("", act.Phase)
+
+	act = &mock.Action{}
+	_, err = validate(act, ValidateVars{Instance: json.RawMessage(`{"name":"web","replicas":"1"}`), SchemaRef: &SchemaRef{Name: "schemas", Key: "app.json"}})
+	r.Error(err)
+	r.Equal("Fail", act.Phase)
+	r.Equal("The instance is invalid against the schema: /replicas: expected integer, but got string", act.Msg)
+
+	act = &mock.Action{}
+	_, err = validate(act, ValidateVars{Instance: json.RawMessage(`{"replicas":1}`), Schema: json.RawMessage(schema)})
+	r.Error(err)
+	r.Equal("Fail", act.Phase)
+	r.Equal("The instance is invalid against the schema: (root): missing properties: 'name'", act.Msg)
+
+	failOnInvalid := false
+	act = &mock.Action{}
+	res, err = validate(act, ValidateVars{Instance: json.RawMessage(`{"replicas":1.5}`), Schema: json.RawMessage(schema), FailOnInvalid: &failOnInvalid})
+	r.NoError(err)
+	r.Equal(ValidateReturnVars{Errors: []ValidationError{
+		{Path: "", Message: "missing properties: 'name'"},
+		{Path: "/replicas", Message: "expected integer, but got number"},
+	}}, res.Returns)
+	r.Equal("", act.Phase)
+
+	_, err = validate(&mock.Action{}, ValidateVars{Instance: json.RawMessage(`{}`)})
+	r.ErrorContains(err, "either schema or schemaRef must be set")
+	_, err = validate(&mock.Action{}, ValidateVars{Instance: json.RawMessage(`{}`), Schema: json.RawMessage(schema), SchemaRef: &SchemaRef{Name: "schemas", Key: "app.json"}})
+	r.ErrorContains(err, "cannot be set at the same time")
+	_, err = validate(&mock.Action{}, ValidateVars{Instance: json.RawMessage(`{}`), SchemaRef: &SchemaRef{Name: "schemas", Key: "not-exist"}})
+	r.ErrorContains(err, "key not-exist is not found")
+	_, err = validate(&mock.Action{}, ValidateVars{Instance: json.RawMessage(`{}`), SchemaRef: &SchemaRef{Name: "not-exist", Key: "app.json"}})
+	r.Error(err)
+}
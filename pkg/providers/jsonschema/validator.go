@@ -0,0 +1,101 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// schemaURL is the location of the schema in the compiler, the $ref to the other locations are rejected.
+const schemaURL = "inline:///schema.json"
+
+// ValidationError is the error of the instance at the path.
+type ValidationError struct {
+	// Path is the JSON pointer of the invalid value in the instance, it is empty for the root.
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+// String returns the error with the path.
+func (e ValidationError) String() string {
+	if e.Path == "" {
+		return "(root): " + e.Message
+	}
+	return e.Path + ": " + e.Message
+}
+
+// Validate validates the instance against the JSON Schema, the draft-07 is used if the $schema is not set,
+// and the instance and the schema are decoded json values. Only the $ref inside the schema can be resolved,
+// and the patterns use the RE2 syntax of Go. It returns the validation errors of the instance, or an error
+// if the schema is invalid.
+func Validate(schema, instance interface{}) ([]ValidationError, error) {
+	raw, err := json.Marshal(schema)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+	compiler := jsonschema.NewCompiler()
+	compiler.Draft = jsonschema.Draft7
+	compiler.AssertFormat = true
+	// the schemas are not loaded from the files or the network of the controller
+	compiler.LoadURL = func(s string) (io.ReadCloser, error) {
+		return nil, fmt.Errorf("only the $ref inside the schema is supported, cannot load %s", s)
+	}
+	if err := compiler.AddResource(schemaURL, bytes.NewReader(raw)); err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+	compiled, err := compiler.Compile(schemaURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+	err = compiled.Validate(instance)
+	var validationErr *jsonschema.ValidationError
+	if err == nil {
+		return nil, nil
+	} else if !errors.As(err, &validationErr) {
+		return nil, err
+	}
+	var validationErrors []ValidationError
+	collectErrors(validationErr, &validationErrors)
+	sort.SliceStable(validationErrors, func(i, j int) bool {
+		return validationErrors[i].Path < validationErrors[j].Path
+	})
+	return validationErrors, nil
+}
+
+// collectErrors collects the leaf errors, the others only tell which schemas the causes come from. The causes
+// of the alternatives are the mismatches of each alternative, so the error of the alternatives is collected instead.
+func collectErrors(err *jsonschema.ValidationError, validationErrors *[]ValidationError) {
+	switch {
+	case strings.HasSuffix(err.KeywordLocation, "/minContains"):
+		// the contains of draft-07 is reported as the minContains of 1
+		*validationErrors = append(*validationErrors, ValidationError{Path: err.InstanceLocation, Message: "no items match the contains schema"})
+	case len(err.Causes) == 0, strings.HasSuffix(err.KeywordLocation, "/anyOf"), strings.HasSuffix(err.KeywordLocation, "/oneOf"):
+		*validationErrors = append(*validationErrors, ValidationError{Path: err.InstanceLocation, Message: err.Message})
+	default:
+		for _, cause := range err.Causes {
+			collectErrors(cause, validationErrors)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jsonschema
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidate(t *testing.T) {
+	testCases := map[string]struct {
+		schema   string
+		instance string
+		expected []ValidationError
+		err      string
+	}{
+		"valid": {
+			schema:   `{"type":"object","required":["name"],"properties":{"name":{"type":"string","minLength":1},"replicas":{"type":"integer","minimum":1}}}`,
+			instance: `{"name":"web","replicas":3}`,
+		},
+		"type-mismatch": {
+			schema:   `{"type":"object","properties":{"replicas":{"type":"integer"},"ports":{"type":"array","items":{"type":"number"}}}}`,
+			instance: `{"replicas":"3","ports":[80,"443",1.5]}`,
+			expected: []ValidationError{
+				{Path: "/ports/1", Message: "expected number, but got string"},
+				{Path: "/replicas", Message: "expected integer, but got string"},
+			},
+		},
+		"required-field-missing": {
+			schema:   `{"type":"object","required":["name","image"],"properties":{"spec":{"required":["port"]}}}`,
+			instance: `{"image":"nginx","spec":{}}`,
+			expected: []ValidationError{
+				{Path: "", Message: "missing properties: 'name'"},
+				{Path: "/spec", Message: "missing properties: 'port'"},
+			},
+		},
+		"numbers-and-strings": {
+			schema:   `{"properties":{"a":{"multipleOf":0.5,"exclusiveMaximum":3},"b":{"maxLength":2,"pattern":"^[a-z]+$"},"c":{"format":"email"},"d":{"const":"x"},"e":{"enum":[1,2]}}}`,
+			instance: `{"a":3.2,"b":"abC","c":"not-an-email","d":"y","e":3}`,
+			expected: []ValidationError{
+				{Path: "/a", Message: "must be < 3 but found 3.2"},
+				{Path: "/a", Message: "3.2 not multipleOf 0.5"},
+				{Path: "/b", Message: "length must be <= 2, but got 3"},
+				{Path: "/b", Message: "does not match pattern '^[a-z]+$'"},
+				{Path: "/c", Message: "'not-an-email' is not valid 'email'"},
+				{Path: "/d", Message: `value must be "x"`},
+				{Path: "/e", Message: `value must be one of "1", "2"`},
+			},
+		},
+		"arrays": {
+			schema:   `{"items":[{"type":"string"}],"additionalItems":{"type":"integer"},"uniqueItems":true,"contains":{"const":"x"},"maxItems":3}`,
+			instance: `["a",1,1,"b"]`,
+			expected: []ValidationError{
+				{Path: "", Message: "maximum 3 items required, but found 4 items"},
+				{Path: "", Message: "items at index 1 and 2 are equal"},
+				{Path: "", Message: "no items match the contains schema"},
+				{Path: "/3", Message: "expected integer, but got string"},
+			},
+		},
+		"objects": {
+			schema:   `{"properties":{"a":{}},"patternProperties":{"^x-":{"type":"string"}},"additionalProperties":false,"propertyNames":{"maxLength":4},"dependencies":{"a":["b"]}}`,
+			instance: `{"a":1,"x-1":2,"other":3}`,
+			expected: []ValidationError{
+				{Path: "", Message: "additionalProperties 'other' not allowed"},
+				{Path: "", Message: "property 'b' is required, if 'a' property exists"},
+				{Path: "/other", Message: "length must be <= 4, but got 5"},
+				{Path: "/x-1", Message: "expected string, but got number"},
+			},
+		},
+		"combinators": {
+			schema:   `{"properties":{"a":{"anyOf":[{"type":"string"},{"type":"boolean"}]},"b":{"oneOf":[{"type":"integer"},{"minimum":0}]},"c":{"not":{"type":"null"}},"d":{"if":{"properties":{"kind":{"const":"svc"}}},"then":{"required":["port"]},"else":{"required":["image"]}}}}`,
+			instance: `{"a":1,"b":1,"c":null,"d":{"kind":"svc"}}`,
+			expected: []ValidationError{
+				{Path: "/a", Message: "anyOf failed"},
+				{Path: "/b", Message: "valid against schemas at indexes 0 and 1"},
+				{Path: "/c", Message: "not failed"},
+				{Path: "/d", Message: "missing properties: 'port'"},
+			},
+		},
+		"ref": {
+			schema:   `{"definitions":{"port":{"type":"integer","maximum":65535},"node":{"properties":{"child":{"$ref":"#/definitions/node"},"port":{"$ref":"#/definitions/port"}}}},"$ref":"#/definitions/node"}`,
+			instance: `{"port":1,"child":{"child":{"port":70000}}}`,
+			expected: []ValidationError{
+				{Path: "/child/child/port", Message: "must be <= 65535 but found 70000"},
+			},
+		},
+		"boolean-schema": {
+			schema:   `{"properties":{"a":true,"b":false}}`,
+			instance: `{"a":1,"b":2}`,
+			expected: []ValidationError{{Path: "/b", Message: "not allowed"}},
+		},
+		"escaped-path": {
+			schema:   `{"additionalProperties":{"type":"string"}}`,
+			instance: `{"a/b~c":1}`,
+			expected: []ValidationError{{Path: "/a~1b~0c", Message: "expected string, but got number"}},
+		},
+		"invalid-pattern": {
+			schema:   `{"pattern":"("}`,
+			instance: `"a"`,
+			err:      "'(' is not valid 'regex'",
+		},
+		"remote-ref": {
+			schema:   `{"$ref":"https://example.com/schema.json"}`,
+			instance: `{}`,
+			err:      "only the $ref inside the schema is supported, cannot load https://example.com/schema.json",
+		},
+		"file-ref": {
+			schema:   `{"$ref":"file:///etc/passwd"}`,
+			instance: `{}`,
+			err:      "only the $ref inside the schema is supported, cannot load file:///etc/passwd",
+		},
+		"draft-2020-12": {
+			schema:   `{"$schema":"https://json-schema.org/draft/2020-12/schema","prefixItems":[{"type":"string"}],"items":false}`,
+			instance: `["a",1]`,
+			expected: []ValidationError{{Path: "/1", Message: "not allowed"}},
+		},
+		"recursive-ref": {
+			schema:   `{"definitions":{"a":{"$ref":"#/definitions/a"}},"$ref":"#/definitions/a"}`,
+			instance: `{}`,
+			err:      "infinite loop",
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			r := require.New(t)
+			var schema, instance interface{}
+			r.NoError(json.Unmarshal([]byte(tc.schema), &schema))
+			r.NoError(json.Unmarshal([]byte(tc.instance), &instance))
+			errs, err := Validate(schema, instance)
+			if tc.err != "" {
+				r.ErrorContains(err, tc.err)
+				return
+			}
+			r.NoError(err)
+			r.Equal(tc.expected, errs)
+		})
+	}
+}
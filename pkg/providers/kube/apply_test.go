@@ -0,0 +1,122 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	providertypes "github.com/kubevela/workflow/pkg/providers/types"
+)
+
+// fakeServerSideApply simulates the server-side apply of the data of the configmaps, since the fake client
+// does not support the apply patches
+func fakeServerSideApply(owners map[string]string) interceptor.Funcs {
+	return interceptor.Funcs{
+		Patch: func(ctx context.Context, cli client.WithWatch, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+			if patch.Type() != client.Apply.Type() {
+				return cli.Patch(ctx, obj, patch, opts...)
+			}
+			patchOpts := &client.PatchOptions{}
+			patchOpts.ApplyOptions(opts)
+			manager, force := patchOpts.FieldManager, patchOpts.Force != nil && *patchOpts.Force
+			u := obj.(*unstructured.Unstructured)
+			data, _, _ := unstructured.NestedStringMap(u.Object, "data")
+			var causes []metav1.StatusCause
+			for key := range data {
+				if owner, ok := owners[key]; ok && owner != manager && !force {
+					causes = append(causes, metav1.StatusCause{
+						Type:    metav1.CauseTypeFieldManagerConflict,
+						Message: fmt.Sprintf("conflict with %q", owner),
+						Field:   ".data." + key,
+					})
+				}
+			}
+			if len(causes) > 0 {
+				return &errors.StatusError{ErrStatus: metav1.Status{
+					Status:  metav1.StatusFailure,
+					Code:    http.StatusConflict,
+					Reason:  metav1.StatusReasonConflict,
+					Details: &metav1.StatusDetails{Causes: causes},
+					Message: fmt.Sprintf("Apply failed with %d conflicts", len(causes)),
+				}}
+			}
+			for key := range data {
+				owners[key] = manager
+			}
+			u.SetManagedFields([]metav1.ManagedFieldsEntry{{Manager: manager, Operation: metav1.ManagedFieldsOperationApply}})
+			existing := u.DeepCopy()
+			if err := cli.Get(ctx, client.ObjectKeyFromObject(u), existing); err != nil {
+				if !errors.IsNotFound(err) {
+					return err
+				}
+				return cli.Create(ctx, u)
+			}
+			u.SetResourceVersion(existing.GetResourceVersion())
+			return cli.Update(ctx, u)
+		},
+	}
+}
+
+func TestServerSideApply(t *testing.T) {
+	r := require.New(t)
+	ctx := context.Background()
+	owners := map[string]string{"owned": "other-controller"}
+	cli := fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).WithInterceptorFuncs(fakeServerSideApply(owners)).Build()
+	apply := func(data map[string]interface{}, vars ResourceVars) (*ResourceReturns, error) {
+		vars.Resource = &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata":   map[string]interface{}{"name": "cfg", "resourceVersion": "1"},
+			"data":       data,
+		}}
+		vars.ServerSideApply = true
+		return Apply(ctx, &ResourceParams{Params: vars, RuntimeParams: providertypes.RuntimeParams{KubeClient: cli}})
+	}
+
+	res, err := apply(map[string]interface{}{"key": "value"}, ResourceVars{})
+	r.NoError(err)
+	r.Equal("workflow", res.Returns.Resource.GetManagedFields()[0].Manager)
+	r.Equal("workflow", owners["key"])
+
+	_, err = apply(map[string]interface{}{"key": "value"}, ResourceVars{FieldManager: "custom"})
+	r.Error(err)
+	r.Equal(`server-side apply of ConfigMap default/cfg by custom conflicts with the fields managed by others, set force to take the ownership: .data.key (conflict with "workflow")`, err.Error())
+
+	_, err = apply(map[string]interface{}{"owned": "mine"}, ResourceVars{FieldManager: "custom"})
+	r.Error(err)
+	r.Contains(err.Error(), `.data.owned (conflict with "other-controller")`)
+	res, err = apply(map[string]interface{}{"owned": "mine"}, ResourceVars{FieldManager: "custom", Force: true})
+	r.NoError(err)
+	r.Equal("custom", res.Returns.Resource.GetManagedFields()[0].Manager)
+	r.Equal("custom", owners["owned"])
+	cm := &corev1.ConfigMap{}
+	r.NoError(cli.Get(ctx, client.ObjectKey{Namespace: "default", Name: "cfg"}, cm))
+	r.Equal(map[string]string{"owned": "mine"}, cm.Data)
+}
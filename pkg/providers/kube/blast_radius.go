@@ -0,0 +1,111 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	wferrors "github.com/kubevela/workflow/pkg/errors"
+	providertypes "github.com/kubevela/workflow/pkg/providers/types"
+)
+
+// BlastRadiusVars .
+type BlastRadiusVars struct {
+	Cluster  string                       `json:"cluster,omitempty"`
+	Resource *unstructured.Unstructured   `json:"resource,omitempty"`
+	Filter   *ListFilter                  `json:"filter,omitempty"`
+	Targets  []*unstructured.Unstructured `json:"targets,omitempty"`
+	Limit    int                          `json:"limit"`
+}
+
+// BlastRadiusReturnVars .
+type BlastRadiusReturnVars struct {
+	Count int `json:"count"`
+	Limit int `json:"limit"`
+}
+
+// BlastRadiusParams .
+type BlastRadiusParams = providertypes.Params[BlastRadiusVars]
+
+// BlastRadiusReturns .
+type BlastRadiusReturns = providertypes.Returns[BlastRadiusReturnVars]
+
+// BlastRadius counts the resources that a destructive operation would affect and
+// fails the step if the count exceeds the limit.
+func BlastRadius(ctx context.Context, params *BlastRadiusParams) (*BlastRadiusReturns, error) {
+	vars := params.Params
+	if vars.Limit < 0 {
+		return nil, fmt.Errorf("invalid blast radius limit %d", vars.Limit)
+	}
+	countCtx := handleContext(ctx, vars.Cluster)
+	// the resources are counted once even if they are both listed and targeted, they are keyed by the group
+	// kind so that the same resource with different versions is not counted twice
+	affected := map[string]struct{}{}
+	addAffected := func(gk schema.GroupKind, namespace, name string) {
+		affected[fmt.Sprintf("%s/%s/%s", gk, namespace, name)] = struct{}{}
+	}
+	if vars.Resource != nil {
+		list := &unstructured.UnstructuredList{Object: map[string]interface{}{
+			"kind":       vars.Resource.GetKind(),
+			"apiVersion": vars.Resource.GetAPIVersion(),
+		}}
+		listOpts, err := vars.Filter.listOptions()
+		if err != nil {
+			return nil, err
+		}
+		if err := params.KubeClient.List(countCtx, list, listOpts...); err != nil {
+			return nil, err
+		}
+		for _, item := range list.Items {
+			addAffected(vars.Resource.GroupVersionKind().GroupKind(), item.GetNamespace(), item.GetName())
+		}
+	}
+	for _, target := range vars.Targets {
+		key := client.ObjectKeyFromObject(target)
+		if key.Namespace == "" {
+			if namespaced, err := params.KubeClient.IsObjectNamespaced(target); err != nil || namespaced {
+				key.Namespace = "default"
+			}
+		}
+		existing := new(unstructured.Unstructured)
+		existing.SetGroupVersionKind(target.GroupVersionKind())
+		if err := params.KubeClient.Get(countCtx, key, existing); err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+			return nil, err
+		}
+		addAffected(target.GroupVersionKind().GroupKind(), key.Namespace, key.Name)
+	}
+	count := len(affected)
+	if count > vars.Limit {
+		params.Action.Fail(fmt.Sprintf("Blast radius exceeded: the operation affects %d resources, while the limit is %d", count, vars.Limit))
+		return nil, wferrors.GenericActionError(wferrors.ActionTerminate)
+	}
+	return &BlastRadiusReturns{
+		Returns: BlastRadiusReturnVars{
+			Count: count,
+			Limit: vars.Limit,
+		},
+	}, nil
+}
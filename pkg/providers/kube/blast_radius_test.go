@@ -0,0 +1,156 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/kubevela/workflow/pkg/errors"
+	"github.com/kubevela/workflow/pkg/mock"
+	providertypes "github.com/kubevela/workflow/pkg/providers/types"
+)
+
+func TestBlastRadius(t *testing.T) {
+	var objs []client.Object
+	for i := 0; i < 3; i++ {
+		objs = append(objs, &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      fmt.Sprintf("cm-%d", i),
+				Namespace: "default",
+				Labels:    map[string]string{"app": "test"},
+			},
+		})
+	}
+	objs = append(objs, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team"}})
+	mapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{corev1.SchemeGroupVersion})
+	mapper.Add(corev1.SchemeGroupVersion.WithKind("ConfigMap"), meta.RESTScopeNamespace)
+	mapper.Add(corev1.SchemeGroupVersion.WithKind("Namespace"), meta.RESTScopeRoot)
+	cli := fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).WithRESTMapper(mapper).WithObjects(objs...).Build()
+	cm := func(name string) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata":   map[string]interface{}{"name": name, "namespace": "default"},
+		}}
+	}
+
+	testCases := map[string]struct {
+		vars        BlastRadiusVars
+		expected    int
+		expectedErr bool
+		expectedMsg string
+	}{
+		"selector within limit": {
+			vars: BlastRadiusVars{
+				Resource: cm(""),
+				Filter:   &ListFilter{Namespace: "default", MatchingLabels: map[string]string{"app": "test"}},
+				Limit:    3,
+			},
+			expected: 3,
+		},
+		"selector over limit": {
+			vars: BlastRadiusVars{
+				Resource: cm(""),
+				Filter:   &ListFilter{Namespace: "default", MatchingLabels: map[string]string{"app": "test"}},
+				Limit:    2,
+			},
+			expectedErr: true,
+			expectedMsg: "Blast radius exceeded: the operation affects 3 resources, while the limit is 2",
+		},
+		"targets ignore not found": {
+			vars: BlastRadiusVars{
+				Targets: []*unstructured.Unstructured{cm("cm-0"), cm("cm-1"), cm("not-exist")},
+				Limit:   2,
+			},
+			expected: 2,
+		},
+		"targets of cluster-scoped resources": {
+			vars: BlastRadiusVars{
+				Targets: []*unstructured.Unstructured{{Object: map[string]interface{}{
+					"apiVersion": "v1",
+					"kind":       "Namespace",
+					"metadata":   map[string]interface{}{"name": "team"},
+				}}, cm("cm-0")},
+				Limit: 2,
+			},
+			expected: 2,
+		},
+		"targets without namespace": {
+			vars: BlastRadiusVars{
+				Targets: []*unstructured.Unstructured{{Object: map[string]interface{}{
+					"apiVersion": "v1",
+					"kind":       "ConfigMap",
+					"metadata":   map[string]interface{}{"name": "cm-0"},
+				}}},
+				Limit: 1,
+			},
+			expected: 1,
+		},
+		"targets matched by selector are counted once": {
+			vars: BlastRadiusVars{
+				Resource: cm(""),
+				Filter:   &ListFilter{Namespace: "default", MatchingLabels: map[string]string{"app": "test"}},
+				Targets:  []*unstructured.Unstructured{cm("cm-0"), cm("cm-1"), cm("cm-1")},
+				Limit:    3,
+			},
+			expected: 3,
+		},
+		"targets over limit": {
+			vars: BlastRadiusVars{
+				Targets: []*unstructured.Unstructured{cm("cm-0"), cm("cm-1")},
+				Limit:   1,
+			},
+			expectedErr: true,
+			expectedMsg: "Blast radius exceeded: the operation affects 2 resources, while the limit is 1",
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			r := require.New(t)
+			act := &mock.Action{}
+			res, err := BlastRadius(context.Background(), &BlastRadiusParams{
+				Params: tc.vars,
+				RuntimeParams: providertypes.RuntimeParams{
+					KubeClient: cli,
+					Action:     act,
+				},
+			})
+			if tc.expectedErr {
+				_, ok := err.(errors.GenericActionError)
+				r.True(ok)
+				r.Equal("Fail", act.Phase)
+				r.Equal(tc.expectedMsg, act.Msg)
+				return
+			}
+			r.NoError(err)
+			r.Equal(tc.expected, res.Returns.Count)
+			r.Equal(tc.vars.Limit, res.Returns.Limit)
+		})
+	}
+}
@@ -0,0 +1,92 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	providertypes "github.com/kubevela/workflow/pkg/providers/types"
+)
+
+// ConfigHashVars .
+type ConfigHashVars struct {
+	Cluster   string                       `json:"cluster,omitempty"`
+	Resources []*unstructured.Unstructured `json:"resources"`
+}
+
+// ConfigHashReturnVars .
+type ConfigHashReturnVars struct {
+	Hash string `json:"hash"`
+}
+
+// ConfigHashParams .
+type ConfigHashParams = providertypes.Params[ConfigHashVars]
+
+// ConfigHashReturns .
+type ConfigHashReturns = providertypes.Returns[ConfigHashReturnVars]
+
+// ConfigHash computes a stable hash over the data of the referenced ConfigMaps and Secrets.
+func ConfigHash(ctx context.Context, params *ConfigHashParams) (*ConfigHashReturns, error) {
+	readCtx := handleContext(ctx, params.Params.Cluster)
+	h := sha256.New()
+	for _, ref := range params.Params.Resources {
+		gvk := ref.GroupVersionKind()
+		if gvk.Group != "" || (gvk.Kind != "ConfigMap" && gvk.Kind != "Secret") {
+			return nil, fmt.Errorf("unsupported resource %s for config hash, only ConfigMap and Secret are supported", gvk.String())
+		}
+		key := client.ObjectKeyFromObject(ref)
+		if key.Namespace == "" {
+			key.Namespace = "default"
+		}
+		obj := new(unstructured.Unstructured)
+		obj.SetGroupVersionKind(gvk)
+		if err := params.KubeClient.Get(readCtx, key, obj); err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(h, "%s/%s/%s\n", gvk.Kind, key.Namespace, key.Name)
+		fields := []string{"data", "binaryData"}
+		if gvk.Kind == "Secret" {
+			fields = []string{"data", "stringData"}
+		}
+		for _, field := range fields {
+			data, _, err := unstructured.NestedMap(obj.Object, field)
+			if err != nil {
+				return nil, err
+			}
+			keys := make([]string, 0, len(data))
+			for k := range data {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				fmt.Fprintf(h, "%s:%q=%q\n", field, k, fmt.Sprint(data[k]))
+			}
+		}
+	}
+	return &ConfigHashReturns{
+		Returns: ConfigHashReturnVars{
+			Hash: hex.EncodeToString(h.Sum(nil)),
+		},
+	}, nil
+}
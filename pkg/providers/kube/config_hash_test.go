@@ -0,0 +1,93 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	providertypes "github.com/kubevela/workflow/pkg/providers/types"
+)
+
+func TestConfigHash(t *testing.T) {
+	r := require.New(t)
+	ctx := context.Background()
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "cm", Namespace: "default"},
+		Data:       map[string]string{"a": "1", "b": "2", "c": "3"},
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "secret", Namespace: "default"},
+		Data:       map[string][]byte{"password": []byte("pwd")},
+	}
+	cli := fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).WithObjects(cm, secret).Build()
+	ref := func(kind, name string) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       kind,
+			"metadata":   map[string]interface{}{"name": name},
+		}}
+	}
+	hash := func(refs ...*unstructured.Unstructured) string {
+		res, err := ConfigHash(ctx, &ConfigHashParams{
+			Params:        ConfigHashVars{Resources: refs},
+			RuntimeParams: providertypes.RuntimeParams{KubeClient: cli},
+		})
+		r.NoError(err)
+		return res.Returns.Hash
+	}
+
+	origin := hash(ref("ConfigMap", "cm"), ref("Secret", "secret"))
+	r.Len(origin, 64)
+	for i := 0; i < 10; i++ {
+		r.Equal(origin, hash(ref("ConfigMap", "cm"), ref("Secret", "secret")))
+	}
+
+	// reordering the keys should not change the hash
+	cm.Data = map[string]string{"c": "3", "b": "2", "a": "1"}
+	r.NoError(cli.Update(ctx, cm))
+	r.Equal(origin, hash(ref("ConfigMap", "cm"), ref("Secret", "secret")))
+
+	// changing the data should change the hash
+	cm.Data["a"] = "changed"
+	r.NoError(cli.Update(ctx, cm))
+	changed := hash(ref("ConfigMap", "cm"), ref("Secret", "secret"))
+	r.NotEqual(origin, changed)
+	secret.Data["password"] = []byte("changed")
+	r.NoError(cli.Update(ctx, secret))
+	r.NotEqual(changed, hash(ref("ConfigMap", "cm"), ref("Secret", "secret")))
+
+	// the resources not found or not supported should fail
+	_, err := ConfigHash(ctx, &ConfigHashParams{
+		Params:        ConfigHashVars{Resources: []*unstructured.Unstructured{ref("ConfigMap", "not-found")}},
+		RuntimeParams: providertypes.RuntimeParams{KubeClient: cli},
+	})
+	r.Error(err)
+	_, err = ConfigHash(ctx, &ConfigHashParams{
+		Params:        ConfigHashVars{Resources: []*unstructured.Unstructured{ref("Pod", "pod")}},
+		RuntimeParams: providertypes.RuntimeParams{KubeClient: cli},
+	})
+	r.Error(err)
+	r.Contains(err.Error(), "unsupported resource")
+}
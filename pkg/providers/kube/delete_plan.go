@@ -0,0 +1,202 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	wferrors "github.com/kubevela/workflow/pkg/errors"
+	providertypes "github.com/kubevela/workflow/pkg/providers/types"
+)
+
+// deletionRanks orders the kinds which are depended on by the other resources, the kinds with higher
+// ranks are deleted later. The kinds not listed, e.g. the workloads and the custom resources, are deleted first.
+var deletionRanks = map[string]int{
+	"ConfigMap":                1,
+	"Secret":                   1,
+	"ServiceAccount":           1,
+	"Role":                     1,
+	"RoleBinding":              1,
+	"PersistentVolumeClaim":    1,
+	"ClusterRole":              2,
+	"ClusterRoleBinding":       2,
+	"PersistentVolume":         2,
+	"StorageClass":             2,
+	"Namespace":                3,
+	"CustomResourceDefinition": 4,
+}
+
+// DeletePlanVars .
+type DeletePlanVars struct {
+	Resources []*unstructured.Unstructured `json:"resources"`
+	Cluster   string                       `json:"cluster,omitempty"`
+}
+
+// DeletionWave is the resources deleted together in the deletion plan.
+type DeletionWave struct {
+	Resources []string `json:"resources"`
+}
+
+// DeletePlanReturnVars .
+type DeletePlanReturnVars struct {
+	Waves   []DeletionWave `json:"waves"`
+	Deleted int            `json:"deleted"`
+}
+
+// DeletePlanParams .
+type DeletePlanParams = providertypes.Params[DeletePlanVars]
+
+// DeletePlanReturns .
+type DeletePlanReturns = providertypes.Returns[DeletePlanReturnVars]
+
+// DeletePlan deletes the resources wave by wave in a safe order: the owned resources are deleted before
+// their owners, the namespaced resources before their namespace, and the workloads before the resources they
+// depend on. The step waits until all the resources of a wave are gone before deleting the next wave.
+func DeletePlan(ctx context.Context, params *DeletePlanParams) (*DeletePlanReturns, error) {
+	vars := params.Params
+	waves, err := planDeletion(vars.Resources)
+	if err != nil {
+		return nil, err
+	}
+	deleteCtx := handleContext(ctx, vars.Cluster)
+	handlers := getHandlers(params.RuntimeParams)
+	returns := DeletePlanReturnVars{Waves: make([]DeletionWave, 0, len(waves))}
+	for _, wave := range waves {
+		ids := make([]string, 0, len(wave))
+		for _, res := range wave {
+			ids = append(ids, resourceID(res))
+		}
+		returns.Waves = append(returns.Waves, DeletionWave{Resources: ids})
+	}
+	for i, wave := range waves {
+		for _, res := range wave {
+			existing := &unstructured.Unstructured{}
+			existing.SetGroupVersionKind(res.GroupVersionKind())
+			if err := params.KubeClient.Get(deleteCtx, client.ObjectKeyFromObject(res), existing); err != nil {
+				if errors.IsNotFound(err) {
+					continue
+				}
+				return nil, err
+			}
+			if existing.GetDeletionTimestamp() != nil {
+				continue
+			}
+			if err := handlers.Delete(deleteCtx, params.KubeClient, vars.Cluster, WorkflowResourceCreator, existing); err != nil && !errors.IsNotFound(err) {
+				return nil, fmt.Errorf("failed to delete %s: %w", resourceID(res), err)
+			}
+		}
+		remaining, err := remainingResources(deleteCtx, params.KubeClient, wave)
+		if err != nil {
+			return nil, err
+		}
+		if len(remaining) > 0 {
+			params.Action.Wait(fmt.Sprintf("Waiting for the deletion of wave %d/%d: %s", i+1, len(waves), strings.Join(remaining, ", ")))
+			return nil, wferrors.GenericActionError(wferrors.ActionWait)
+		}
+		returns.Deleted += len(wave)
+	}
+	return &DeletePlanReturns{Returns: returns}, nil
+}
+
+func remainingResources(ctx context.Context, cli client.Client, wave []*unstructured.Unstructured) ([]string, error) {
+	var remaining []string
+	for _, res := range wave {
+		existing := &unstructured.Unstructured{}
+		existing.SetGroupVersionKind(res.GroupVersionKind())
+		if err := cli.Get(ctx, client.ObjectKeyFromObject(res), existing); err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+			return nil, err
+		}
+		remaining = append(remaining, resourceID(res))
+	}
+	return remaining, nil
+}
+
+// planDeletion groups the resources into the ordered deletion waves.
+func planDeletion(resources []*unstructured.Unstructured) ([][]*unstructured.Unstructured, error) {
+	index := make(map[string]int, len(resources))
+	waves := make([]int, len(resources))
+	for i, res := range resources {
+		index[ownerKey(res.GetKind(), res.GetNamespace(), res.GetName())] = i
+		waves[i] = deletionRanks[res.GetKind()]
+	}
+	// edges from the resources to the resources which must be deleted after them
+	edges := make([][]int, len(resources))
+	for i, res := range resources {
+		if ns, ok := index[ownerKey("Namespace", "", res.GetNamespace())]; ok && res.GetNamespace() != "" {
+			edges[i] = append(edges[i], ns)
+		}
+		for _, owner := range res.GetOwnerReferences() {
+			// the owner is either in the same namespace or cluster-scoped
+			for _, key := range []string{ownerKey(owner.Kind, res.GetNamespace(), owner.Name), ownerKey(owner.Kind, "", owner.Name)} {
+				if j, ok := index[key]; ok && j != i {
+					edges[i] = append(edges[i], j)
+					break
+				}
+			}
+		}
+	}
+	for round := 0; ; round++ {
+		if round > len(resources) {
+			return nil, fmt.Errorf("failed to plan the deletion: the owner references of the resources are cyclic")
+		}
+		changed := false
+		for i := range resources {
+			for _, j := range edges[i] {
+				if waves[j] <= waves[i] {
+					waves[j], changed = waves[i]+1, true
+				}
+			}
+		}
+		if !changed {
+			break
+		}
+	}
+	order := make([]int, len(resources))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return waves[order[a]] < waves[order[b]] })
+	var planned [][]*unstructured.Unstructured
+	for k, i := range order {
+		if k == 0 || waves[i] != waves[order[k-1]] {
+			planned = append(planned, nil)
+		}
+		planned[len(planned)-1] = append(planned[len(planned)-1], resources[i])
+	}
+	return planned, nil
+}
+
+func ownerKey(kind, namespace, name string) string {
+	return kind + "/" + namespace + "/" + name
+}
+
+func resourceID(res *unstructured.Unstructured) string {
+	if res.GetNamespace() == "" {
+		return fmt.Sprintf("%s %s", res.GetKind(), res.GetName())
+	}
+	return fmt.Sprintf("%s %s/%s", res.GetKind(), res.GetNamespace(), res.GetName())
+}
@@ -0,0 +1,117 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	"github.com/kubevela/workflow/pkg/errors"
+	"github.com/kubevela/workflow/pkg/mock"
+	providertypes "github.com/kubevela/workflow/pkg/providers/types"
+)
+
+func TestDeletePlan(t *testing.T) {
+	r := require.New(t)
+	ctx := context.Background()
+	objs := []client.Object{
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "demo"}},
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "cfg", Namespace: "demo", Finalizers: []string{"test.oam.dev/protect"}}},
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "demo"}},
+		&appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{Name: "web-rs", Namespace: "demo", OwnerReferences: []metav1.OwnerReference{{
+			APIVersion: "apps/v1", Kind: "Deployment", Name: "web", UID: "web-uid",
+		}}}},
+		&rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: "reader"}},
+	}
+	var resources []*unstructured.Unstructured
+	for _, obj := range objs {
+		u, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+		r.NoError(err)
+		res := &unstructured.Unstructured{Object: u}
+		gvks, _, err := clientgoscheme.Scheme.ObjectKinds(obj)
+		r.NoError(err)
+		res.SetGroupVersionKind(gvks[0])
+		resources = append(resources, res)
+	}
+	var deleted []string
+	cli := fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).WithObjects(objs...).WithInterceptorFuncs(interceptor.Funcs{
+		Delete: func(ctx context.Context, cli client.WithWatch, obj client.Object, opts ...client.DeleteOption) error {
+			deleted = append(deleted, obj.GetObjectKind().GroupVersionKind().Kind+" "+obj.GetName())
+			return cli.Delete(ctx, obj, opts...)
+		},
+	}).Build()
+	deletePlan := func(act *mock.Action) (*DeletePlanReturns, error) {
+		return DeletePlan(ctx, &DeletePlanParams{
+			Params:        DeletePlanVars{Resources: resources},
+			RuntimeParams: providertypes.RuntimeParams{KubeClient: cli, Action: act},
+		})
+	}
+
+	act := &mock.Action{}
+	_, err := deletePlan(act)
+	r.Equal(errors.GenericActionError(errors.ActionWait), err)
+	r.Equal("Waiting for the deletion of wave 2/4: ConfigMap demo/cfg", act.Msg)
+	r.Equal([]string{"ReplicaSet web-rs", "ConfigMap cfg", "Deployment web"}, deleted)
+	ns := &corev1.Namespace{}
+	r.NoError(cli.Get(ctx, client.ObjectKey{Name: "demo"}, ns))
+
+	// the deletion is not issued again for the resources being deleted
+	_, err = deletePlan(&mock.Action{})
+	r.Equal(errors.GenericActionError(errors.ActionWait), err)
+	r.Len(deleted, 3)
+
+	cm := &corev1.ConfigMap{}
+	r.NoError(cli.Get(ctx, client.ObjectKey{Namespace: "demo", Name: "cfg"}, cm))
+	cm.Finalizers = nil
+	r.NoError(cli.Update(ctx, cm))
+	res, err := deletePlan(&mock.Action{})
+	r.NoError(err)
+	r.Equal([]string{"ReplicaSet web-rs", "ConfigMap cfg", "Deployment web", "ClusterRole reader", "Namespace demo"}, deleted)
+	r.Equal([]DeletionWave{
+		{Resources: []string{"ReplicaSet demo/web-rs"}},
+		{Resources: []string{"ConfigMap demo/cfg", "Deployment demo/web"}},
+		{Resources: []string{"ClusterRole reader"}},
+		{Resources: []string{"Namespace demo"}},
+	}, res.Returns.Waves)
+	r.Equal(5, res.Returns.Deleted)
+	r.Error(cli.Get(ctx, client.ObjectKey{Name: "demo"}, ns))
+}
+
+func TestPlanDeletionCycle(t *testing.T) {
+	a := &unstructured.Unstructured{}
+	a.SetKind("Foo")
+	a.SetName("a")
+	a.SetOwnerReferences([]metav1.OwnerReference{{Kind: "Foo", Name: "b"}})
+	b := &unstructured.Unstructured{}
+	b.SetKind("Foo")
+	b.SetName("b")
+	b.SetOwnerReferences([]metav1.OwnerReference{{Kind: "Foo", Name: "a"}})
+	_, err := planDeletion([]*unstructured.Unstructured{a, b})
+	require.ErrorContains(t, err, "cyclic")
+}
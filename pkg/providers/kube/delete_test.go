@@ -0,0 +1,90 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	providertypes "github.com/kubevela/workflow/pkg/providers/types"
+)
+
+func TestDeleteWithOptions(t *testing.T) {
+	r := require.New(t)
+	ctx := context.Background()
+	var deleteOpts *client.DeleteOptions
+	cli := fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).WithObjects(
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "a", Namespace: "default"}},
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "b", Namespace: "default"}},
+	).WithInterceptorFuncs(interceptor.Funcs{
+		Delete: func(ctx context.Context, cli client.WithWatch, obj client.Object, opts ...client.DeleteOption) error {
+			deleteOpts = &client.DeleteOptions{}
+			deleteOpts.ApplyOptions(opts)
+			return cli.Delete(ctx, obj, opts...)
+		},
+	}).Build()
+	del := func(name string, vars ResourceVars) (*ResourceReturns, error) {
+		vars.Resource = &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata":   map[string]interface{}{"name": name, "namespace": "default"},
+		}}
+		return Delete(ctx, &ResourceParams{Params: vars, RuntimeParams: providertypes.RuntimeParams{KubeClient: cli}})
+	}
+
+	res, err := del("a", ResourceVars{PropagationPolicy: "Foreground", GracePeriodSeconds: ptr.To(int64(30))})
+	r.NoError(err)
+	r.Nil(res)
+	r.Equal(ptr.To(metav1.DeletePropagationForeground), deleteOpts.PropagationPolicy)
+	r.Equal(ptr.To(int64(30)), deleteOpts.GracePeriodSeconds)
+
+	deleteOpts = nil
+	res, err = del("b", ResourceVars{})
+	r.NoError(err)
+	r.Nil(res)
+	r.NotNil(deleteOpts)
+	r.Nil(deleteOpts.PropagationPolicy)
+	r.Nil(deleteOpts.GracePeriodSeconds)
+	r.Error(cli.Get(ctx, client.ObjectKey{Namespace: "default", Name: "b"}, &corev1.ConfigMap{}))
+
+	res, err = del("a", ResourceVars{})
+	r.NoError(err)
+	r.Nil(res)
+	res, err = del("a", ResourceVars{PropagationPolicy: "Orphan"})
+	r.NoError(err)
+	r.Nil(res)
+	res, err = del("a", ResourceVars{IgnoreNotFound: ptr.To(false)})
+	r.NoError(err)
+	r.Contains(res.Returns.Error, "not found")
+
+	_, err = del("a", ResourceVars{PropagationPolicy: "Cascade"})
+	r.Error(err)
+	r.Contains(err.Error(), "invalid propagationPolicy Cascade")
+	_, err = del("a", ResourceVars{GracePeriodSeconds: ptr.To(int64(-1))})
+	r.Error(err)
+	r.Contains(err.Error(), "invalid gracePeriodSeconds -1")
+}
@@ -0,0 +1,184 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/remotecommand"
+	utilexec "k8s.io/client-go/util/exec"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/kubevela/pkg/util/singleton"
+
+	providertypes "github.com/kubevela/workflow/pkg/providers/types"
+)
+
+const (
+	// defaultExecTimeout is the default timeout of the command executed in the pod
+	defaultExecTimeout = 5 * time.Minute
+	// defaultExecMaxBytes is the default limit of the stdout and stderr captured from the command
+	defaultExecMaxBytes int64 = 1 << 20
+)
+
+// newExecutor returns the executor streaming the command in the pod, which authenticates with the kube
+// config of the step, or the shared one if the kube config is not overridden
+var newExecutor = func(cfg *rest.Config, namespace, name string, opts *corev1.PodExecOptions) (remotecommand.Executor, error) {
+	clientset, err := newClientset(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if cfg == nil {
+		cfg = singleton.KubeConfig.Get()
+	}
+	req := clientset.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Namespace(namespace).
+		Name(name).
+		SubResource("exec").
+		VersionedParams(opts, clientgoscheme.ParameterCodec)
+	return remotecommand.NewSPDYExecutor(cfg, "POST", req.URL())
+}
+
+// ExecVars .
+type ExecVars struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"`
+	// Container is the container to execute the command in, the default container of the pod is used if not set
+	Container string   `json:"container,omitempty"`
+	Command   []string `json:"command"`
+	Stdin     *string  `json:"stdin,omitempty"`
+	Timeout   string   `json:"timeout,omitempty"`
+	MaxBytes  int64    `json:"maxBytes,omitempty"`
+}
+
+// ExecReturnVars .
+type ExecReturnVars struct {
+	Stdout    string `json:"stdout"`
+	Stderr    string `json:"stderr"`
+	ExitCode  int    `json:"exitCode"`
+	Container string `json:"container,omitempty"`
+	// Truncated is true if the stdout or stderr exceeds the max bytes
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// ExecParams .
+type ExecParams = providertypes.Params[ExecVars]
+
+// ExecReturns .
+type ExecReturns = providertypes.Returns[ExecReturnVars]
+
+// Exec executes the command in the container of the running pod and returns the output and the exit code,
+// a non-zero exit code of the command is returned in the output instead of an error.
+func Exec(ctx context.Context, params *ExecParams) (*ExecReturns, error) {
+	vars := params.Params
+	if vars.Namespace == "" {
+		vars.Namespace = "default"
+	}
+	if len(vars.Command) == 0 {
+		return nil, fmt.Errorf("the command to execute in pod %s/%s is required", vars.Namespace, vars.Name)
+	}
+	if vars.MaxBytes < 0 {
+		return nil, fmt.Errorf("invalid maxBytes %d", vars.MaxBytes)
+	}
+	if vars.MaxBytes == 0 {
+		vars.MaxBytes = defaultExecMaxBytes
+	}
+	timeout := defaultExecTimeout
+	if vars.Timeout != "" {
+		d, err := time.ParseDuration(vars.Timeout)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid timeout %q", vars.Timeout)
+		}
+		timeout = d
+	}
+	pod := &corev1.Pod{}
+	if err := params.KubeClient.Get(ctx, client.ObjectKey{Namespace: vars.Namespace, Name: vars.Name}, pod); err != nil {
+		return nil, err
+	}
+	if pod.Status.Phase != corev1.PodRunning {
+		return nil, fmt.Errorf("pod %s/%s is not running, the phase is %s", vars.Namespace, vars.Name, pod.Status.Phase)
+	}
+	if vars.Container == "" {
+		vars.Container = defaultContainer(pod)
+	}
+	executor, err := newExecutor(params.KubeConfig, vars.Namespace, vars.Name, &corev1.PodExecOptions{
+		Container: vars.Container,
+		Command:   vars.Command,
+		Stdin:     vars.Stdin != nil,
+		Stdout:    true,
+		Stderr:    true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute the command in pod %s/%s: %w", vars.Namespace, vars.Name, err)
+	}
+	stdout, stderr := &limitedBuffer{limit: vars.MaxBytes}, &limitedBuffer{limit: vars.MaxBytes}
+	opts := remotecommand.StreamOptions{Stdout: stdout, Stderr: stderr}
+	if vars.Stdin != nil {
+		opts.Stdin = strings.NewReader(*vars.Stdin)
+	}
+	execCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	exitCode := 0
+	if err := executor.StreamWithContext(execCtx, opts); err != nil {
+		var exitErr utilexec.ExitError
+		switch {
+		case errors.As(err, &exitErr) && exitErr.Exited():
+			exitCode = exitErr.ExitStatus()
+		case ctx.Err() != nil:
+			return nil, fmt.Errorf("the command in pod %s/%s is cancelled: %w", vars.Namespace, vars.Name, ctx.Err())
+		case execCtx.Err() != nil:
+			return nil, fmt.Errorf("the command in pod %s/%s is timed out after %s", vars.Namespace, vars.Name, timeout)
+		default:
+			return nil, fmt.Errorf("failed to execute the command in pod %s/%s: %w", vars.Namespace, vars.Name, err)
+		}
+	}
+	return &ExecReturns{
+		Returns: ExecReturnVars{
+			Stdout:    stdout.String(),
+			Stderr:    stderr.String(),
+			ExitCode:  exitCode,
+			Container: vars.Container,
+			Truncated: stdout.truncated || stderr.truncated,
+		},
+	}, nil
+}
+
+// limitedBuffer keeps the first limit bytes written and discards the rest, so that the stream is not blocked
+type limitedBuffer struct {
+	bytes.Buffer
+	limit     int64
+	truncated bool
+}
+
+// Write implements io.Writer
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if remain := b.limit - int64(b.Len()); int64(len(p)) > remain {
+		b.truncated = true
+		b.Buffer.Write(p[:remain])
+		return len(p), nil
+	}
+	return b.Buffer.Write(p)
+}
@@ -0,0 +1,167 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/remotecommand"
+	utilexec "k8s.io/client-go/util/exec"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	providertypes "github.com/kubevela/workflow/pkg/providers/types"
+)
+
+type fakeExecutor struct {
+	stream func(ctx context.Context, opts remotecommand.StreamOptions) error
+}
+
+func (e *fakeExecutor) Stream(opts remotecommand.StreamOptions) error {
+	return e.StreamWithContext(context.Background(), opts)
+}
+
+func (e *fakeExecutor) StreamWithContext(ctx context.Context, opts remotecommand.StreamOptions) error {
+	return e.stream(ctx, opts)
+}
+
+func TestExec(t *testing.T) {
+	r := require.New(t)
+	pods := []*corev1.Pod{{
+		ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "default"},
+		Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "main"}, {Name: "sidecar"}}},
+		Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+	}, {
+		ObjectMeta: metav1.ObjectMeta{Name: "pending", Namespace: "default"},
+		Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "main"}}},
+		Status:     corev1.PodStatus{Phase: corev1.PodPending},
+	}}
+	cli := fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).WithObjects(pods[0], pods[1]).Build()
+	var execOpts *corev1.PodExecOptions
+	var stream func(ctx context.Context, opts remotecommand.StreamOptions) error
+	defer func(fn func(*rest.Config, string, string, *corev1.PodExecOptions) (remotecommand.Executor, error)) {
+		newExecutor = fn
+	}(newExecutor)
+	newExecutor = func(_ *rest.Config, namespace, name string, opts *corev1.PodExecOptions) (remotecommand.Executor, error) {
+		r.Equal("default", namespace)
+		r.Equal("db", name)
+		execOpts = opts
+		return &fakeExecutor{stream: stream}, nil
+	}
+	exec := func(ctx context.Context, vars ExecVars) (*ExecReturns, error) {
+		execOpts = nil
+		return Exec(ctx, &ExecParams{Params: vars, RuntimeParams: providertypes.RuntimeParams{KubeClient: cli}})
+	}
+
+	stream = func(ctx context.Context, opts remotecommand.StreamOptions) error {
+		r.Nil(opts.Stdin)
+		_, _ = opts.Stdout.Write([]byte("migrated"))
+		_, _ = opts.Stderr.Write([]byte("warning"))
+		return nil
+	}
+	res, err := exec(context.Background(), ExecVars{Name: "db", Command: []string{"sh", "-c", "echo 'a b' | migrate"}})
+	r.NoError(err)
+	r.Equal([]string{"sh", "-c", "echo 'a b' | migrate"}, execOpts.Command)
+	r.Equal("main", execOpts.Container)
+	r.False(execOpts.Stdin)
+	r.True(execOpts.Stdout)
+	r.True(execOpts.Stderr)
+	r.Equal(ExecReturnVars{Stdout: "migrated", Stderr: "warning", Container: "main"}, res.Returns)
+
+	stream = func(ctx context.Context, opts remotecommand.StreamOptions) error {
+		b, err := io.ReadAll(opts.Stdin)
+		r.NoError(err)
+		_, _ = opts.Stdout.Write(b)
+		return utilexec.CodeExitError{Err: errors.New("command terminated with exit code 3"), Code: 3}
+	}
+	res, err = exec(context.Background(), ExecVars{Name: "db", Container: "sidecar", Command: []string{"psql"}, Stdin: ptr.To("select 1;"), MaxBytes: 6})
+	r.NoError(err)
+	r.Equal("sidecar", execOpts.Container)
+	r.True(execOpts.Stdin)
+	r.Equal(ExecReturnVars{Stdout: "select", ExitCode: 3, Container: "sidecar", Truncated: true}, res.Returns)
+
+	stream = func(ctx context.Context, opts remotecommand.StreamOptions) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	_, err = exec(context.Background(), ExecVars{Name: "db", Command: []string{"sleep", "inf"}, Timeout: "10ms"})
+	r.EqualError(err, "the command in pod default/db is timed out after 10ms")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = exec(ctx, ExecVars{Name: "db", Command: []string{"sleep", "inf"}})
+	r.ErrorIs(err, context.Canceled)
+	r.Contains(err.Error(), "the command in pod default/db is cancelled")
+
+	stream = func(ctx context.Context, opts remotecommand.StreamOptions) error {
+		return errors.New("container not found")
+	}
+	_, err = exec(context.Background(), ExecVars{Name: "db", Command: []string{"ls"}})
+	r.EqualError(err, "failed to execute the command in pod default/db: container not found")
+
+	_, err = exec(context.Background(), ExecVars{Name: "pending", Command: []string{"ls"}})
+	r.EqualError(err, "pod default/pending is not running, the phase is Pending")
+	r.Nil(execOpts)
+	_, err = exec(context.Background(), ExecVars{Name: "db"})
+	r.EqualError(err, "the command to execute in pod default/db is required")
+	_, err = exec(context.Background(), ExecVars{Name: "db", Command: []string{"ls"}, Timeout: "soon"})
+	r.EqualError(err, `invalid timeout "soon"`)
+	_, err = exec(context.Background(), ExecVars{Name: "db", Command: []string{"ls"}, MaxBytes: -1})
+	r.EqualError(err, "invalid maxBytes -1")
+}
+
+func TestExecWithStepCredentials(t *testing.T) {
+	r := require.New(t)
+	var authorization string
+	var query url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if strings.HasSuffix(req.URL.Path, "/namespaces/default/pods/db/exec") {
+			authorization = req.Header.Get("Authorization")
+			query = req.URL.Query()
+		}
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "default"},
+		Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "main"}}},
+		Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+	cli := fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).WithObjects(pod).Build()
+	_, err := Exec(context.Background(), &ExecParams{
+		Params: ExecVars{Name: "db", Command: []string{"migrate", "--to", "v2"}},
+		RuntimeParams: providertypes.RuntimeParams{
+			KubeClient: cli,
+			KubeConfig: &rest.Config{Host: server.URL, BearerToken: "step-token"},
+		},
+	})
+	r.Error(err)
+	r.Equal("Bearer step-token", authorization)
+	r.Equal([]string{"migrate", "--to", "v2"}, query["command"])
+	r.Equal("main", query.Get("container"))
+}
@@ -0,0 +1,300 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/kubevela/pkg/util/k8s"
+
+	providertypes "github.com/kubevela/workflow/pkg/providers/types"
+)
+
+const (
+	// ExposeTypeIngress exposes the service with the Ingress
+	ExposeTypeIngress = "ingress"
+	// ExposeTypeGateway exposes the service with the Gateway and HTTPRoute of the Gateway API
+	ExposeTypeGateway = "gateway"
+
+	// AnnoExposeService is the annotation of the exposed service in the rendered resources, formatted as <service>:<port>
+	AnnoExposeService = "workflow.oam.dev/expose-service"
+
+	gatewayAPIVersion = "gateway.networking.k8s.io/v1"
+)
+
+// ExposeTLS .
+type ExposeTLS struct {
+	SecretName string `json:"secretName"`
+}
+
+// ExposeVars is the intent to expose the service
+type ExposeVars struct {
+	Name        string            `json:"name,omitempty"`
+	Namespace   string            `json:"namespace,omitempty"`
+	Type        string            `json:"type,omitempty"`
+	ClassName   string            `json:"className,omitempty"`
+	Host        string            `json:"host"`
+	Path        string            `json:"path,omitempty"`
+	Service     string            `json:"service"`
+	Port        int32             `json:"port"`
+	TLS         *ExposeTLS        `json:"tls,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+	Cluster     string            `json:"cluster,omitempty"`
+}
+
+// ExposeReturnVars .
+type ExposeReturnVars struct {
+	Resources []ObjectReference `json:"resources"`
+	URL       string            `json:"url"`
+	Address   string            `json:"address,omitempty"`
+}
+
+// ExposeParams .
+type ExposeParams = providertypes.Params[ExposeVars]
+
+// ExposeReturns .
+type ExposeReturns = providertypes.Returns[ExposeReturnVars]
+
+// Expose renders the Ingress, or the Gateway and HTTPRoute, from the intent and applies them. The address
+// is returned if it has been assigned to the Ingress or the Gateway.
+func Expose(ctx context.Context, params *ExposeParams) (*ExposeReturns, error) {
+	spec := params.Params
+	workloads, err := renderExpose(&spec)
+	if err != nil {
+		return nil, err
+	}
+	refs := make([]ObjectReference, 0, len(workloads))
+	for _, workload := range workloads {
+		for k, v := range params.RuntimeParams.Labels {
+			if err := k8s.AddLabel(workload, k, v); err != nil {
+				return nil, err
+			}
+		}
+		refs = append(refs, ObjectReference{
+			APIVersion: workload.GetAPIVersion(),
+			Kind:       workload.GetKind(),
+			Name:       workload.GetName(),
+			Namespace:  workload.GetNamespace(),
+		})
+	}
+	handlers := getHandlers(params.RuntimeParams)
+	deployCtx := handleContext(ctx, spec.Cluster)
+	if err := handlers.Apply(deployCtx, params.KubeClient, spec.Cluster, WorkflowResourceCreator, workloads...); err != nil {
+		return nil, err
+	}
+	scheme := "http"
+	if spec.TLS != nil {
+		scheme = "https"
+	}
+	return &ExposeReturns{
+		Returns: ExposeReturnVars{
+			Resources: refs,
+			URL:       fmt.Sprintf("%s://%s%s", scheme, spec.Host, spec.Path),
+			Address:   exposedAddress(deployCtx, params.KubeClient, workloads[0]),
+		},
+	}, nil
+}
+
+func validateExpose(spec *ExposeVars) error {
+	if spec.Type == "" {
+		spec.Type = ExposeTypeIngress
+	}
+	if spec.Type != ExposeTypeIngress && spec.Type != ExposeTypeGateway {
+		return fmt.Errorf("unknown expose type %s", spec.Type)
+	}
+	if spec.Name == "" {
+		spec.Name = spec.Service
+	}
+	if spec.Namespace == "" {
+		spec.Namespace = "default"
+	}
+	if spec.Path == "" {
+		spec.Path = "/"
+	}
+	if errs := validation.IsDNS1035Label(spec.Service); len(errs) > 0 {
+		return fmt.Errorf("invalid service name %q: %v", spec.Service, errs)
+	}
+	if errs := validation.IsDNS1123Subdomain(spec.Name); len(errs) > 0 {
+		return fmt.Errorf("invalid expose name %q: %v", spec.Name, errs)
+	}
+	if errs := validation.IsValidPortNum(int(spec.Port)); len(errs) > 0 {
+		return fmt.Errorf("invalid port %d of service %s: %v", spec.Port, spec.Service, errs)
+	}
+	host := spec.Host
+	if strings.HasPrefix(host, "*.") {
+		if errs := validation.IsWildcardDNS1123Subdomain(host); len(errs) > 0 {
+			return fmt.Errorf("invalid host %q: %v", host, errs)
+		}
+	} else if errs := validation.IsDNS1123Subdomain(host); len(errs) > 0 {
+		return fmt.Errorf("invalid host %q: %v", host, errs)
+	}
+	if !strings.HasPrefix(spec.Path, "/") {
+		return fmt.Errorf("invalid path %q, the path must be absolute", spec.Path)
+	}
+	if spec.TLS != nil {
+		if errs := validation.IsDNS1123Subdomain(spec.TLS.SecretName); len(errs) > 0 {
+			return fmt.Errorf("invalid tls secret name %q: %v", spec.TLS.SecretName, errs)
+		}
+	}
+	if spec.Type == ExposeTypeGateway && spec.ClassName == "" {
+		return fmt.Errorf("the className of the gateway %s is required", spec.Name)
+	}
+	return nil
+}
+
+func exposeAnnotations(spec *ExposeVars) map[string]string {
+	annotations := map[string]string{AnnoExposeService: fmt.Sprintf("%s:%d", spec.Service, spec.Port)}
+	if spec.Type == ExposeTypeIngress && spec.TLS != nil && spec.ClassName == "nginx" {
+		annotations["nginx.ingress.kubernetes.io/ssl-redirect"] = "true"
+	}
+	// the annotations specified by the user take precedence
+	for k, v := range spec.Annotations {
+		annotations[k] = v
+	}
+	return annotations
+}
+
+func renderExpose(spec *ExposeVars) ([]*unstructured.Unstructured, error) {
+	if err := validateExpose(spec); err != nil {
+		return nil, err
+	}
+	if spec.Type == ExposeTypeGateway {
+		return renderGateway(spec), nil
+	}
+	pathType := networkingv1.PathTypePrefix
+	ingress := &networkingv1.Ingress{
+		TypeMeta:   metav1.TypeMeta{APIVersion: networkingv1.SchemeGroupVersion.String(), Kind: "Ingress"},
+		ObjectMeta: metav1.ObjectMeta{Name: spec.Name, Namespace: spec.Namespace, Annotations: exposeAnnotations(spec)},
+		Spec: networkingv1.IngressSpec{
+			Rules: []networkingv1.IngressRule{{
+				Host: spec.Host,
+				IngressRuleValue: networkingv1.IngressRuleValue{HTTP: &networkingv1.HTTPIngressRuleValue{
+					Paths: []networkingv1.HTTPIngressPath{{
+						Path:     spec.Path,
+						PathType: &pathType,
+						Backend: networkingv1.IngressBackend{Service: &networkingv1.IngressServiceBackend{
+							Name: spec.Service,
+							Port: networkingv1.ServiceBackendPort{Number: spec.Port},
+						}},
+					}},
+				}},
+			}},
+		},
+	}
+	if spec.ClassName != "" {
+		ingress.Spec.IngressClassName = ptr.To(spec.ClassName)
+	}
+	if spec.TLS != nil {
+		ingress.Spec.TLS = []networkingv1.IngressTLS{{Hosts: []string{spec.Host}, SecretName: spec.TLS.SecretName}}
+	}
+	u, err := runtime.DefaultUnstructuredConverter.ToUnstructured(ingress)
+	if err != nil {
+		return nil, err
+	}
+	workload := &unstructured.Unstructured{Object: u}
+	unstructured.RemoveNestedField(workload.Object, "status")
+	unstructured.RemoveNestedField(workload.Object, "metadata", "creationTimestamp")
+	return []*unstructured.Unstructured{workload}, nil
+}
+
+// renderGateway renders the Gateway with the listeners of the host and the HTTPRoute attached to it, the
+// Gateway API is rendered as unstructured as its types are not vendored.
+func renderGateway(spec *ExposeVars) []*unstructured.Unstructured {
+	annotations := map[string]interface{}{}
+	for k, v := range exposeAnnotations(spec) {
+		annotations[k] = v
+	}
+	listeners := []interface{}{map[string]interface{}{
+		"name":     "http",
+		"protocol": "HTTP",
+		"port":     int64(80),
+		"hostname": spec.Host,
+	}}
+	if spec.TLS != nil {
+		listeners = append(listeners, map[string]interface{}{
+			"name":     "https",
+			"protocol": "HTTPS",
+			"port":     int64(443),
+			"hostname": spec.Host,
+			"tls": map[string]interface{}{
+				"mode":            "Terminate",
+				"certificateRefs": []interface{}{map[string]interface{}{"kind": "Secret", "name": spec.TLS.SecretName}},
+			},
+		})
+	}
+	gateway := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": gatewayAPIVersion,
+		"kind":       "Gateway",
+		"metadata":   map[string]interface{}{"name": spec.Name, "namespace": spec.Namespace, "annotations": annotations},
+		"spec": map[string]interface{}{
+			"gatewayClassName": spec.ClassName,
+			"listeners":        listeners,
+		},
+	}}
+	route := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": gatewayAPIVersion,
+		"kind":       "HTTPRoute",
+		"metadata":   map[string]interface{}{"name": spec.Name, "namespace": spec.Namespace, "annotations": annotations},
+		"spec": map[string]interface{}{
+			"parentRefs": []interface{}{map[string]interface{}{"name": spec.Name}},
+			"hostnames":  []interface{}{spec.Host},
+			"rules": []interface{}{map[string]interface{}{
+				"matches":     []interface{}{map[string]interface{}{"path": map[string]interface{}{"type": "PathPrefix", "value": spec.Path}}},
+				"backendRefs": []interface{}{map[string]interface{}{"name": spec.Service, "port": int64(spec.Port)}},
+			}},
+		},
+	}}
+	return []*unstructured.Unstructured{gateway, route}
+}
+
+// exposedAddress returns the address assigned to the Ingress or the Gateway, it is empty if the address
+// is not assigned yet or the resource cannot be read.
+func exposedAddress(ctx context.Context, cli client.Client, workload *unstructured.Unstructured) string {
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(workload.GroupVersionKind())
+	if err := cli.Get(ctx, client.ObjectKeyFromObject(workload), obj); err != nil {
+		return ""
+	}
+	var addresses []interface{}
+	if workload.GetKind() == "Gateway" {
+		addresses, _, _ = unstructured.NestedSlice(obj.Object, "status", "addresses")
+	} else {
+		addresses, _, _ = unstructured.NestedSlice(obj.Object, "status", "loadBalancer", "ingress")
+	}
+	for _, address := range addresses {
+		m, ok := address.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for _, key := range []string{"value", "ip", "hostname"} {
+			if v, ok := m[key].(string); ok && v != "" {
+				return v
+			}
+		}
+	}
+	return ""
+}
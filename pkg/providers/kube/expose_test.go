@@ -0,0 +1,169 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	providertypes "github.com/kubevela/workflow/pkg/providers/types"
+)
+
+func TestExpose(t *testing.T) {
+	ctx := context.Background()
+	assigned := &networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+		Status: networkingv1.IngressStatus{LoadBalancer: networkingv1.IngressLoadBalancerStatus{
+			Ingress: []networkingv1.IngressLoadBalancerIngress{{IP: "10.0.0.1"}},
+		}},
+	}
+	cli := fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).WithObjects(assigned).WithStatusSubresource(assigned).Build()
+	var applied []*unstructured.Unstructured
+	handlers := &providertypes.KubeHandlers{
+		Apply: func(ctx context.Context, cli client.Client, cluster, owner string, workloads ...*unstructured.Unstructured) error {
+			applied = workloads
+			return apply(ctx, cli, cluster, owner, workloads...)
+		},
+	}
+	expose := func(vars ExposeVars) (*ExposeReturns, error) {
+		return Expose(ctx, &ExposeParams{
+			Params:        vars,
+			RuntimeParams: providertypes.RuntimeParams{KubeClient: cli, KubeHandlers: handlers, Labels: map[string]string{"workflowrun.oam.dev/name": "run"}},
+		})
+	}
+
+	t.Run("ingress", func(t *testing.T) {
+		r := require.New(t)
+		res, err := expose(ExposeVars{Service: "web", Port: 8080, Host: "web.example.com", ClassName: "nginx", TLS: &ExposeTLS{SecretName: "web-tls"}})
+		r.NoError(err)
+		r.Equal(ExposeReturnVars{
+			Resources: []ObjectReference{{APIVersion: "networking.k8s.io/v1", Kind: "Ingress", Name: "web", Namespace: "default"}},
+			URL:       "https://web.example.com/",
+			Address:   "10.0.0.1",
+		}, res.Returns)
+		ingress := &networkingv1.Ingress{}
+		r.NoError(cli.Get(ctx, client.ObjectKey{Namespace: "default", Name: "web"}, ingress))
+		r.Equal(ptr.To("nginx"), ingress.Spec.IngressClassName)
+		r.Equal([]networkingv1.IngressTLS{{Hosts: []string{"web.example.com"}, SecretName: "web-tls"}}, ingress.Spec.TLS)
+		r.Len(ingress.Spec.Rules, 1)
+		rule := ingress.Spec.Rules[0]
+		r.Equal("web.example.com", rule.Host)
+		r.Equal("/", rule.HTTP.Paths[0].Path)
+		r.Equal(ptr.To(networkingv1.PathTypePrefix), rule.HTTP.Paths[0].PathType)
+		r.Equal(&networkingv1.IngressServiceBackend{Name: "web", Port: networkingv1.ServiceBackendPort{Number: 8080}}, rule.HTTP.Paths[0].Backend.Service)
+		r.Equal("web:8080", ingress.Annotations[AnnoExposeService])
+		r.Equal("true", ingress.Annotations["nginx.ingress.kubernetes.io/ssl-redirect"])
+		r.Equal("run", ingress.Labels["workflowrun.oam.dev/name"])
+	})
+
+	t.Run("ingress without address", func(t *testing.T) {
+		r := require.New(t)
+		res, err := expose(ExposeVars{Name: "api-public", Service: "api", Port: 80, Host: "*.example.com", Path: "/api", Annotations: map[string]string{AnnoExposeService: "custom"}})
+		r.NoError(err)
+		r.Equal("http://*.example.com/api", res.Returns.URL)
+		r.Empty(res.Returns.Address)
+		r.Equal("api-public", res.Returns.Resources[0].Name)
+		ingress := &networkingv1.Ingress{}
+		r.NoError(cli.Get(ctx, client.ObjectKey{Namespace: "default", Name: "api-public"}, ingress))
+		r.Nil(ingress.Spec.IngressClassName)
+		r.Empty(ingress.Spec.TLS)
+		r.Equal("custom", ingress.Annotations[AnnoExposeService])
+		r.NotContains(ingress.Annotations, "nginx.ingress.kubernetes.io/ssl-redirect")
+	})
+
+	t.Run("gateway", func(t *testing.T) {
+		r := require.New(t)
+		res, err := expose(ExposeVars{Type: ExposeTypeGateway, ClassName: "istio", Namespace: "prod", Service: "web", Port: 8080, Host: "web.example.com", TLS: &ExposeTLS{SecretName: "web-tls"}})
+		r.NoError(err)
+		r.Equal([]ObjectReference{
+			{APIVersion: "gateway.networking.k8s.io/v1", Kind: "Gateway", Name: "web", Namespace: "prod"},
+			{APIVersion: "gateway.networking.k8s.io/v1", Kind: "HTTPRoute", Name: "web", Namespace: "prod"},
+		}, res.Returns.Resources)
+		r.Equal("https://web.example.com/", res.Returns.URL)
+		r.Len(applied, 2)
+		gateway, route := applied[0], applied[1]
+		className, _, _ := unstructured.NestedString(gateway.Object, "spec", "gatewayClassName")
+		r.Equal("istio", className)
+		listeners, _, _ := unstructured.NestedSlice(gateway.Object, "spec", "listeners")
+		r.Len(listeners, 2)
+		r.Equal(map[string]interface{}{
+			"name":     "https",
+			"protocol": "HTTPS",
+			"port":     int64(443),
+			"hostname": "web.example.com",
+			"tls": map[string]interface{}{
+				"mode":            "Terminate",
+				"certificateRefs": []interface{}{map[string]interface{}{"kind": "Secret", "name": "web-tls"}},
+			},
+		}, listeners[1])
+		parentRefs, _, _ := unstructured.NestedSlice(route.Object, "spec", "parentRefs")
+		r.Equal([]interface{}{map[string]interface{}{"name": "web"}}, parentRefs)
+		rules, _, _ := unstructured.NestedSlice(route.Object, "spec", "rules")
+		r.Equal([]interface{}{map[string]interface{}{
+			"matches":     []interface{}{map[string]interface{}{"path": map[string]interface{}{"type": "PathPrefix", "value": "/"}}},
+			"backendRefs": []interface{}{map[string]interface{}{"name": "web", "port": int64(8080)}},
+		}}, rules)
+		r.Equal("web:8080", route.GetAnnotations()[AnnoExposeService])
+		r.Equal("run", route.GetLabels()["workflowrun.oam.dev/name"])
+	})
+
+	t.Run("gateway address", func(t *testing.T) {
+		r := require.New(t)
+		gateway := &unstructured.Unstructured{}
+		gateway.SetAPIVersion(gatewayAPIVersion)
+		gateway.SetKind("Gateway")
+		gateway.SetName("web")
+		gateway.SetNamespace("prod")
+		r.NoError(cli.Get(ctx, client.ObjectKeyFromObject(gateway), gateway))
+		r.NoError(unstructured.SetNestedSlice(gateway.Object, []interface{}{map[string]interface{}{"type": "Hostname", "value": "lb.example.com"}}, "status", "addresses"))
+		r.NoError(cli.Update(ctx, gateway))
+		res, err := expose(ExposeVars{Type: ExposeTypeGateway, ClassName: "istio", Namespace: "prod", Service: "web", Port: 8080, Host: "web.example.com"})
+		r.NoError(err)
+		r.Equal("lb.example.com", res.Returns.Address)
+		r.Equal("http://web.example.com/", res.Returns.URL)
+	})
+
+	t.Run("invalid intent", func(t *testing.T) {
+		r := require.New(t)
+		testCases := map[string]struct {
+			vars ExposeVars
+			err  string
+		}{
+			"bad host":       {vars: ExposeVars{Service: "web", Port: 80, Host: "https://web.example.com"}, err: `invalid host "https://web.example.com"`},
+			"bad wildcard":   {vars: ExposeVars{Service: "web", Port: 80, Host: "*.Example.com"}, err: `invalid host "*.Example.com"`},
+			"bad port":       {vars: ExposeVars{Service: "web", Port: 0, Host: "web.example.com"}, err: "invalid port 0 of service web"},
+			"bad service":    {vars: ExposeVars{Service: "Web", Port: 80, Host: "web.example.com"}, err: `invalid service name "Web"`},
+			"bad path":       {vars: ExposeVars{Service: "web", Port: 80, Host: "web.example.com", Path: "api"}, err: `invalid path "api"`},
+			"bad tls secret": {vars: ExposeVars{Service: "web", Port: 80, Host: "web.example.com", TLS: &ExposeTLS{}}, err: `invalid tls secret name ""`},
+			"unknown type":   {vars: ExposeVars{Type: "route", Service: "web", Port: 80, Host: "web.example.com"}, err: "unknown expose type route"},
+			"no class":       {vars: ExposeVars{Type: ExposeTypeGateway, Service: "web", Port: 80, Host: "web.example.com"}, err: "the className of the gateway web is required"},
+		}
+		for name, tc := range testCases {
+			_, err := expose(tc.vars)
+			r.ErrorContains(err, tc.err, name)
+		}
+	})
+}
@@ -11,6 +11,19 @@
 		value: {...}
 		// +usage=The patcher that will be applied to the resource, you can define the strategy of list merge through comments. Reference doc here: https://kubevela.io/docs/platform-engineers/traits/patch-trait#patch-in-workflow-step
 		patch?: {...}
+		// +usage=Whether to apply the resource with the server-side apply
+		serverSideApply?: bool
+		// +usage=The field manager of the server-side apply, default to workflow
+		fieldManager?: string
+		// +usage=Whether to take the ownership of the fields managed by others in the server-side apply
+		force?: bool
+		// +usage=Set the WorkflowRun as the owner of the resource, so that the resource is deleted with the WorkflowRun. The resource must be in the namespace of the WorkflowRun
+		ownerReference?: {
+			// +usage=Whether the WorkflowRun is the controller of the resource
+			controller?: bool
+			// +usage=Whether the WorkflowRun cannot be deleted before the resource, default to true
+			blockOwnerDeletion?: bool
+		}
 	}
 
 	$returns?: {
@@ -42,6 +55,154 @@
 	...
 }
 
+#PatchStatus: {
+	#do:       "patch-status"
+	#provider: "kube"
+
+	$params: {
+		// +usage=The cluster to use
+		cluster: *"" | string
+		// +usage=The resource to patch the status, the resource must have a status subresource
+		value: {...}
+		// +usage=The status to merge into the status subresource of the resource, the spec won't be touched
+		status: {...}
+	}
+
+	$returns?: {
+		// +usage=The status after patched
+		status: {...}
+	}
+	...
+}
+
+#PatchObject: {
+	#do:       "patch-object"
+	#provider: "kube"
+
+	$params: {
+		// +usage=The cluster to use
+		cluster: *"" | string
+		// +usage=The object to patch
+		target: {
+			apiVersion: string
+			kind:       string
+			name:       string
+			namespace:  *"" | string
+		}
+		// +usage=The type of the patch
+		type: *"merge" | "json" | "strategic"
+		// +usage=The patch body, a list of operations for the json patch
+		patch: {...} | [...{...}]
+	}
+
+	$returns?: {
+		// +usage=The object after patched
+		value: {...}
+	}
+	...
+}
+
+#WaitUntil: {
+	#do:       "wait-until"
+	#provider: "kube"
+
+	$params: {
+		// +usage=The cluster to use
+		cluster: *"" | string
+		// +usage=The object to wait for
+		target: {
+			apiVersion: string
+			kind:       string
+			name:       string
+			namespace:  *"" | string
+		}
+		// +usage=The CUE expression evaluated to bool, the object is referred as object, e.g. object.status.readyReplicas == object.spec.replicas
+		condition?: string
+		// +usage=The JSONPath of the field to check instead of the condition, e.g. {.status.conditions[?(@.type=="Available")].status}
+		jsonPath?: string
+		// +usage=The value that the field should match, any non-empty value other than false matches if not set
+		value?: string
+		// +usage=The interval to poll the object, the backoff of the workflow is used if not set. The pollInterval of the step takes precedence, and the pollBackoff of the step applies to the interval
+		interval?: string
+		// +usage=The timeout of the wait, the step fails with the last observed state if exceeded
+		timeout: *"5m" | string
+	}
+
+	$returns?: {
+		// +usage=The object when the condition holds
+		value: {...}
+	}
+	...
+}
+
+#GetLogs: {
+	#do:       "get-logs"
+	#provider: "kube"
+
+	$params: {
+		// +usage=The cluster to use
+		cluster: *"" | string
+		// +usage=The name of the pod
+		name: string
+		// +usage=The namespace of the pod
+		namespace: *"default" | string
+		// +usage=The container to get the logs of, the default container of the pod is used if not set
+		container?: string
+		// +usage=The number of the lines from the end of the logs to return
+		tailLines?: int
+		// +usage=Whether to return the logs of the previous terminated container
+		previous: *false | bool
+		// +usage=The max bytes of the logs to return, the logs are truncated if exceeded, default to 1MiB
+		maxBytes?: int
+	}
+
+	$returns?: {
+		// +usage=The logs of the container
+		logs: string
+		// +usage=The container the logs belong to
+		container?: string
+		// +usage=Whether the logs are truncated by maxBytes
+		truncated?: bool
+	}
+	...
+}
+
+#Exec: {
+	#do:       "exec"
+	#provider: "kube"
+
+	$params: {
+		// +usage=The name of the running pod
+		name: string
+		// +usage=The namespace of the pod
+		namespace: *"default" | string
+		// +usage=The container to execute the command in, the default container of the pod is used if not set
+		container?: string
+		// +usage=The command to execute, as the argv list which is not interpreted by a shell
+		command: [...string]
+		// +usage=The content passed to the stdin of the command
+		stdin?: string
+		// +usage=The timeout of the command, default to 5m
+		timeout?: string
+		// +usage=The max bytes of the stdout and stderr to return, the output is truncated if exceeded, default to 1MiB
+		maxBytes?: int
+	}
+
+	$returns?: {
+		// +usage=The stdout of the command
+		stdout: string
+		// +usage=The stderr of the command
+		stderr: string
+		// +usage=The exit code of the command
+		exitCode: int
+		// +usage=The container the command is executed in
+		container?: string
+		// +usage=Whether the stdout or stderr is truncated by maxBytes
+		truncated?: bool
+	}
+	...
+}
+
 #ApplyInParallel: {
 	#do:       "apply-in-parallel"
 	#provider: "kube"
@@ -60,6 +221,28 @@
 	...
 }
 
+#ApplyManifests: {
+	#do:       "apply-manifests"
+	#provider: "kube"
+
+	$params: {
+		// +usage=The cluster to use
+		cluster: *"" | string
+		// +usage=The manifests to apply in order. A map of named manifests, like the outputs of a definition, is applied in the order of the names. A multi-document YAML string is also accepted
+		value: [...{...}] | {[string]: {...}} | string
+		// +usage=Whether to roll the applied manifests back if any of the manifests fails
+		atomic: *false | bool
+	}
+
+	$returns?: {
+		// +usage=The resources after applied will be filled in this field in the applied order
+		value?: [...{...}]
+		// +usage=The resources after applied keyed by kind/namespace/name, the namespace is empty for the cluster-scoped resources
+		results?: [string]: {...}
+	}
+	...
+}
+
 #Read: {
 	#do:       "read"
 	#provider: "kube"
@@ -100,12 +283,28 @@
 			namespace: *"" | string
 			// +usage=The label selector to filter the resources
 			matchingLabels?: {...}
+			// +usage=The label selector in the string form to filter the resources, e.g. "app in (a,b),tier!=db", combined with matchingLabels
+			labelSelector?: string
+			// +usage=The field selector to filter the resources, e.g. "status.phase=Running"
+			fieldSelector?: string
 		}
+		// +usage=The max number of resources to return, all resources are returned if not specified
+		limit?: int
+		// +usage=The continue token returned by the previous list to continue with the next page, the limit must be set as well
+		continue?: string
+		// +usage=The alias of limit
+		pageSize?: int
+		// +usage=The page token returned by the previous list, the alias of continue in the page token format
+		token?: string
 	}
 
 	$returns?: {
 		// +usage=The listed resources will be filled in this field after the action is executed
 		values?: {...}
+		// +usage=The continue token of the next page, empty if it is the last page
+		continue?: string
+		// +usage=The continue token of the next page in the page token format, empty if it is the last page
+		token?: string
 		// +usage=The error message if the action failed
 		err?: string
 	}
@@ -140,6 +339,12 @@
 			// +usage=The label selector to filter the resources
 			matchingLabels?: {...}
 		}
+		// +usage=The propagation policy of the deletion of the dependents
+		propagationPolicy?: "Foreground" | "Background" | "Orphan"
+		// +usage=The grace period in seconds before the resource is deleted, 0 means deleting immediately
+		gracePeriodSeconds?: int
+		// +usage=Whether to treat deleting a missing resource as success
+		ignoreNotFound: *true | bool
 	}
 
 	$returns?: {
@@ -150,3 +355,399 @@
 	}
 	...
 }
+
+#BlastRadius: {
+	#do:       "blast-radius"
+	#provider: "kube"
+
+	$params: {
+		// +usage=The cluster to use
+		cluster: *"" | string
+		// +usage=The resource type to count, the resources matching the filter will be counted
+		resource?: {
+			// +usage=The api version of the resource
+			apiVersion: string
+			// +usage=The kind of the resource
+			kind: string
+		}
+		// +usage=The filter to count the resources
+		filter?: {
+			// +usage=The namespace to count the resources
+			namespace: *"" | string
+			// +usage=The label selector to filter the resources
+			matchingLabels?: {...}
+			// +usage=The label selector in the string form to filter the resources, combined with matchingLabels
+			labelSelector?: string
+			// +usage=The field selector to filter the resources
+			fieldSelector?: string
+		}
+		// +usage=The target resources to count, only the resources existing in the cluster will be counted
+		targets?: [...{...}]
+		// +usage=The max number of resources that the operation is allowed to affect
+		limit: int
+	}
+
+	$returns?: {
+		// +usage=The number of affected resources
+		count: int
+		// +usage=The configured limit
+		limit: int
+	}
+	...
+}
+
+#Snapshot: {
+	#do:       "snapshot"
+	#provider: "kube"
+
+	$params: {
+		// +usage=The cluster to use
+		cluster: *"" | string
+		// +usage=The resource to snapshot, the data of secrets will be redacted
+		value: {...}
+	}
+
+	$returns?: {
+		// +usage=The snapshot of the resource
+		value: {...}
+		// +usage=The error message if failed to read the resource
+		err?: string
+	}
+	...
+}
+
+#Restore: {
+	#do:       "restore"
+	#provider: "kube"
+
+	$params: {
+		// +usage=The cluster to use
+		cluster: *"" | string
+		// +usage=The snapshot to restore
+		value: {...}
+	}
+
+	$returns?: {
+		// +usage=The restored resource
+		value: {...}
+		// +usage=Whether the resource is recreated since it has been deleted after snapshot
+		recreated: bool
+	}
+	...
+}
+
+#ConfigHash: {
+	#do:       "config-hash"
+	#provider: "kube"
+
+	$params: {
+		// +usage=The cluster to use
+		cluster: *"" | string
+		// +usage=The ConfigMaps and Secrets to compute the hash over
+		resources: [...{
+			apiVersion: "v1"
+			kind:       "ConfigMap" | "Secret"
+			metadata: {
+				name:       string
+				namespace?: string
+				...
+			}
+			...
+		}]
+	}
+
+	$returns?: {
+		// +usage=The hash of the data, which can be stamped as an annotation of the pod template
+		hash: string
+	}
+	...
+}
+
+#Mutate: {
+	#do:       "mutate"
+	#provider: "kube"
+
+	$params: {
+		// +usage=The resources to mutate
+		resources: [...{...}]
+		// +usage=The mutation rules, which are applied to the matching resources in order
+		rules: [...{
+			// +usage=Select the resources to mutate, all resources are matched if not specified
+			match?: {
+				apiVersion?: string
+				kind?:       string
+				name?:       string
+				namespace?:  string
+				labels?: [string]: string
+			}
+			// +usage=The json patch operations to apply
+			patch: [...{
+				op:     "add" | "replace" | "remove"
+				path:   string
+				value?: _
+			}]
+		}]
+	}
+
+	$returns?: {
+		// +usage=The mutated resources
+		resources: [...{...}]
+	}
+	...
+}
+
+#Workload: {
+	#do:       "workload"
+	#provider: "kube"
+
+	$params: {
+		// +usage=The cluster to use
+		cluster: *"" | string
+		// +usage=The name of the workload, used as the name of the Deployment and Service
+		name: string
+		// +usage=The namespace of the workload
+		namespace: *"default" | string
+		// +usage=The image of the workload
+		image: string
+		// +usage=The replicas of the workload
+		replicas: *1 | int
+		// +usage=The ports to expose, the Service is only created if ports are specified
+		ports?: [...{
+			name?:     string
+			port:      int
+			protocol?: "TCP" | "UDP" | "SCTP"
+		}]
+		// +usage=The environment variables of the workload
+		env?: [string]: string
+		// +usage=The resources of the workload, used as both requests and limits
+		resources?: {
+			cpu?:    string
+			memory?: string
+		}
+	}
+
+	$returns?: {
+		// +usage=The references of the applied resources
+		resources: [...{
+			apiVersion: string
+			kind:       string
+			name:       string
+			namespace:  string
+		}]
+	}
+	...
+}
+
+#RBACReport: {
+	#do:       "rbac-report"
+	#provider: "kube"
+
+	$params: {
+		// +usage=The cluster to use
+		cluster: *"" | string
+		// +usage=The service account to compute the effective permissions for
+		serviceAccount: {
+			// +usage=The name of the service account
+			name: string
+			// +usage=The namespace of the service account
+			namespace: *"default" | string
+		}
+	}
+
+	$returns?: {
+		// +usage=The bindings granting permissions to the service account
+		bindings: [...{
+			kind:       string
+			name:       string
+			namespace?: string
+			roleRef: {
+				apiGroup: string
+				kind:     string
+				name:     string
+			}
+			// +usage=Whether the referenced role does not exist
+			missing?: bool
+		}]
+		// +usage=The permission matrix, the permission without namespace is granted cluster-wide
+		permissions: [...{
+			namespace?: string
+			apiGroup:   string
+			resource?:  string
+			resourceNames?: [...string]
+			nonResourceURL?: string
+			verbs: [...string]
+		}]
+	}
+	...
+}
+
+#Quota: {
+	#do:       "quota"
+	#provider: "kube"
+
+	$params: {
+		// +usage=The cluster to use
+		cluster: *"" | string
+		// +usage=The kind of the quota
+		kind: *"ResourceQuota" | "LimitRange"
+		// +usage=The name of the quota
+		name: string
+		// +usage=The namespace of the quota
+		namespace: *"default" | string
+		// +usage=The hard limits of the ResourceQuota, the step fails if the current usage exceeds them
+		hard?: [string]: string
+		// +usage=The limits of the LimitRange
+		limits?: [...{
+			// +usage=The type of the limit, such as Container, Pod or PersistentVolumeClaim
+			type: string
+			max?: [string]:            string
+			min?: [string]:            string
+			default?: [string]:        string
+			defaultRequest?: [string]: string
+		}]
+	}
+
+	$returns?: {
+		// +usage=The applied ResourceQuota or LimitRange
+		value: {...}
+		// +usage=The current usage of the existing ResourceQuota
+		used?: [string]: string
+	}
+	...
+}
+
+#PDB: {
+	#do:       "pdb"
+	#provider: "kube"
+
+	$params: {
+		// +usage=The cluster to use
+		cluster: *"" | string
+		// +usage=The name of the pdb
+		name: string
+		// +usage=The namespace of the pdb
+		namespace: *"default" | string
+		// +usage=The labels of the pods protected by the pdb
+		selector: [string]: string
+		// +usage=The minimum number or percentage of the pods that must be available, exclusive with maxUnavailable
+		minAvailable?: int | =~"^[0-9]+%$"
+		// +usage=The maximum number or percentage of the pods that can be unavailable, exclusive with minAvailable
+		maxUnavailable?: int | =~"^[0-9]+%$"
+	}
+
+	$returns?: {
+		// +usage=The applied PodDisruptionBudget
+		value: {...}
+		// +usage=The number of the existing pods matching the selector
+		matchedPods: int
+	}
+	...
+}
+
+#Rollback: {
+	#do:       "rollback"
+	#provider: "kube"
+
+	$params: {
+		// +usage=The cluster to use
+		cluster: *"" | string
+		// +usage=The workloads to roll back
+		workloads: [...{
+			// +usage=The kind of the workload
+			kind: "Deployment" | "StatefulSet"
+			// +usage=The name of the workload
+			name: string
+			// +usage=The namespace of the workload
+			namespace: *"default" | string
+			// +usage=The revision to roll back to, the previous revision is used if not specified
+			revision?: int
+		}]
+		// +usage=Whether to wait for the rollback to complete
+		wait: *false | bool
+	}
+
+	$returns?: {
+		// +usage=The rollback results of the workloads
+		results: [...{
+			kind:      string
+			name:      string
+			namespace: string
+			// +usage=The revision which the workload is rolled back to
+			targetRevision: int
+			// +usage=The resulting revision of the workload
+			revision: int
+		}]
+	}
+	...
+}
+
+#DeletePlan: {
+	#do:       "delete-plan"
+	#provider: "kube"
+
+	$params: {
+		// +usage=The cluster to use
+		cluster: *"" | string
+		// +usage=The resources to delete, they are deleted wave by wave in a dependency-aware order
+		resources: [...{...}]
+	}
+
+	$returns?: {
+		// +usage=The deletion waves in order
+		waves: [...{
+			// +usage=The resources deleted in the wave
+			resources: [...string]
+		}]
+		// +usage=The number of the deleted resources
+		deleted: int
+	}
+	...
+}
+
+#Expose: {
+	#do:       "expose"
+	#provider: "kube"
+
+	$params: {
+		// +usage=The cluster to use
+		cluster: *"" | string
+		// +usage=The name of the rendered resources, default to the name of the service
+		name?: string
+		// +usage=The namespace of the service
+		namespace: *"default" | string
+		// +usage=Expose the service with the Ingress, or the Gateway and HTTPRoute of the Gateway API
+		type: *"ingress" | "gateway"
+		// +usage=The class of the Ingress or the Gateway, required for the gateway type
+		className?: string
+		// +usage=The host to expose the service on, can be a wildcard host like *.example.com
+		host: string
+		// +usage=The path prefix to route to the service
+		path: *"/" | string
+		// +usage=The service to expose
+		service: string
+		// +usage=The port of the service
+		port: int
+		// +usage=The tls config of the host
+		tls?: {
+			// +usage=The secret which contains the certificate of the host
+			secretName: string
+		}
+		// +usage=The extra annotations of the rendered resources
+		annotations?: [string]: string
+	}
+
+	$returns?: {
+		// +usage=The references of the applied resources
+		resources: [...{
+			apiVersion: string
+			kind:       string
+			name:       string
+			namespace:  string
+		}]
+		// +usage=The url of the exposed service
+		url: string
+		// +usage=The address assigned to the Ingress or the Gateway, absent if not assigned yet
+		address?: string
+	}
+	...
+}
@@ -21,12 +21,15 @@ import (
 	_ "embed"
 	"encoding/json"
 	"fmt"
+	"strings"
 
 	"cuelang.org/go/cue"
 	"cuelang.org/go/cue/cuecontext"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
 	ktypes "k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
@@ -96,6 +99,37 @@ func apply(ctx context.Context, cli client.Client, _, _ string, workloads ...*un
 	return nil
 }
 
+// serverSideApply applies the workload with the server-side apply, the fields managed by other managers are
+// only overridden if force is set
+func serverSideApply(ctx context.Context, cli client.Client, workload *unstructured.Unstructured, fieldManager string, force bool) error {
+	if fieldManager == "" {
+		fieldManager = WorkflowResourceCreator
+	}
+	workload.SetManagedFields(nil)
+	workload.SetResourceVersion("")
+	opts := []client.PatchOption{client.FieldOwner(fieldManager)}
+	if force {
+		opts = append(opts, client.ForceOwnership)
+	}
+	err := cli.Patch(ctx, workload, client.Apply, opts...)
+	if err == nil || !errors.IsConflict(err) {
+		return err
+	}
+	var conflicts []string
+	if status, ok := err.(errors.APIStatus); ok && status.Status().Details != nil {
+		for _, cause := range status.Status().Details.Causes {
+			if cause.Type == metav1.CauseTypeFieldManagerConflict {
+				conflicts = append(conflicts, fmt.Sprintf("%s (%s)", cause.Field, cause.Message))
+			}
+		}
+	}
+	if len(conflicts) == 0 {
+		return err
+	}
+	return fmt.Errorf("server-side apply of %s %s/%s by %s conflicts with the fields managed by others, set force to take the ownership: %s",
+		workload.GetKind(), workload.GetNamespace(), workload.GetName(), fieldManager, strings.Join(conflicts, ", "))
+}
+
 // nolint:revive
 func delete(ctx context.Context, cli client.Client, _, _ string, manifest *unstructured.Unstructured) error {
 	return cli.Delete(ctx, manifest)
@@ -105,6 +139,35 @@ func delete(ctx context.Context, cli client.Client, _, _ string, manifest *unstr
 type ListFilter struct {
 	Namespace      string            `json:"namespace,omitempty"`
 	MatchingLabels map[string]string `json:"matchingLabels,omitempty"`
+	// LabelSelector and FieldSelector are the selectors in the string form, e.g. "app in (a,b),tier!=db"
+	LabelSelector string `json:"labelSelector,omitempty"`
+	FieldSelector string `json:"fieldSelector,omitempty"`
+}
+
+// listOptions returns the list options of the filter, the matching labels and the label selector are combined
+func (filter *ListFilter) listOptions() ([]client.ListOption, error) {
+	if filter == nil {
+		return nil, nil
+	}
+	opts := []client.ListOption{client.InNamespace(filter.Namespace)}
+	selector := labels.SelectorFromSet(filter.MatchingLabels)
+	if filter.LabelSelector != "" {
+		parsed, err := labels.Parse(filter.LabelSelector)
+		if err != nil {
+			return nil, fmt.Errorf("invalid label selector %q: %w", filter.LabelSelector, err)
+		}
+		requirements, _ := parsed.Requirements()
+		selector = selector.Add(requirements...)
+	}
+	opts = append(opts, client.MatchingLabelsSelector{Selector: selector})
+	if filter.FieldSelector != "" {
+		selector, err := fields.ParseSelector(filter.FieldSelector)
+		if err != nil {
+			return nil, fmt.Errorf("invalid field selector %q: %w", filter.FieldSelector, err)
+		}
+		opts = append(opts, client.MatchingFieldsSelector{Selector: selector})
+	}
+	return opts, nil
 }
 
 // ResourceVars .
@@ -112,6 +175,22 @@ type ResourceVars struct {
 	Resource *unstructured.Unstructured `json:"value"`
 	Filter   *ListFilter                `json:"filter,omitempty"`
 	Cluster  string                     `json:"cluster,omitempty"`
+	// Limit and Continue are only used in list
+	Limit    int64  `json:"limit,omitempty"`
+	Continue string `json:"continue,omitempty"`
+	// PageSize and Token are the aliases of Limit and Continue in the page token format
+	PageSize int    `json:"pageSize,omitempty"`
+	Token    string `json:"token,omitempty"`
+	// ServerSideApply, FieldManager and Force are only used in apply
+	ServerSideApply bool   `json:"serverSideApply,omitempty"`
+	FieldManager    string `json:"fieldManager,omitempty"`
+	Force           bool   `json:"force,omitempty"`
+	// OwnerReference is only used in apply
+	OwnerReference *OwnerReferenceOptions `json:"ownerReference,omitempty"`
+	// PropagationPolicy, GracePeriodSeconds and IgnoreNotFound are only used in delete
+	PropagationPolicy  string `json:"propagationPolicy,omitempty"`
+	GracePeriodSeconds *int64 `json:"gracePeriodSeconds,omitempty"`
+	IgnoreNotFound     *bool  `json:"ignoreNotFound,omitempty"`
 }
 
 // ResourceReturnVars .
@@ -148,8 +227,18 @@ func Apply(ctx context.Context, params *ResourceParams) (*ResourceReturns, error
 			return nil, err
 		}
 	}
+	setParentAnnotations(workload, params.ProcessContext)
 	deployCtx := handleContext(ctx, params.Params.Cluster)
-	if err := handlers.Apply(deployCtx, params.KubeClient, params.Params.Cluster, WorkflowResourceCreator, workload); err != nil {
+	if params.Params.OwnerReference != nil {
+		if err := setWorkflowRunOwner(ctx, params.KubeClient, params.Params.Cluster, workload, params.ProcessContext, params.Params.OwnerReference); err != nil {
+			return nil, err
+		}
+	}
+	if params.Params.ServerSideApply {
+		if err := serverSideApply(deployCtx, params.KubeClient, workload, params.Params.FieldManager, params.Params.Force); err != nil {
+			return nil, err
+		}
+	} else if err := handlers.Apply(deployCtx, params.KubeClient, params.Params.Cluster, WorkflowResourceCreator, workload); err != nil {
 		return nil, err
 	}
 	return &ResourceReturns{
@@ -184,6 +273,7 @@ func ApplyInParallel(ctx context.Context, params *ApplyInParallelParams) (*Apply
 		if workloads[i].GetNamespace() == "" {
 			workloads[i].SetNamespace("default")
 		}
+		setParentAnnotations(workloads[i], params.ProcessContext)
 	}
 	deployCtx := handleContext(ctx, params.Params.Cluster)
 	if err := handlers.Apply(deployCtx, params.KubeClient, params.Params.Cluster, WorkflowResourceCreator, workloads...); err != nil {
@@ -275,6 +365,8 @@ func Read(ctx context.Context, params *ResourceParams) (*ResourceReturns, error)
 // ListReturnVars .
 type ListReturnVars struct {
 	Resources *unstructured.UnstructuredList `json:"values"`
+	Continue  string                         `json:"continue,omitempty"`
+	Token     string                         `json:"token,omitempty"`
 	Error     string                         `json:"err,omitempty"`
 }
 
@@ -289,11 +381,22 @@ func List(ctx context.Context, params *ResourceParams) (*ListReturns, error) {
 		"apiVersion": workload.GetAPIVersion(),
 	}}
 
-	filter := params.Params.Filter
-	listOpts := []client.ListOption{
-		client.InNamespace(filter.Namespace),
-		client.MatchingLabels(filter.MatchingLabels),
+	listOpts, err := params.Params.Filter.listOptions()
+	if err != nil {
+		return nil, err
+	}
+	limit, cont := params.Params.Limit, params.Params.Continue
+	if limit <= 0 {
+		limit = int64(params.Params.PageSize)
 	}
+	if cont == "" {
+		token, err := providertypes.DecodePageToken(params.Params.Token)
+		if err != nil {
+			return nil, err
+		}
+		cont = token.Continue
+	}
+	listOpts = append(listOpts, client.Limit(limit), client.Continue(cont))
 	readCtx := handleContext(ctx, params.Params.Cluster)
 	if err := params.KubeClient.List(readCtx, list, listOpts...); err != nil {
 		return &ListReturns{
@@ -303,9 +406,13 @@ func List(ctx context.Context, params *ResourceParams) (*ListReturns, error) {
 			},
 		}, nil
 	}
+	next := list.GetContinue()
+	list.SetContinue("")
 	return &ListReturns{
 		Returns: ListReturnVars{
 			Resources: list,
+			Continue:  next,
+			Token:     providertypes.EncodePageToken(providertypes.PageToken{Continue: next}),
 		},
 	}, nil
 }
@@ -315,13 +422,18 @@ func Delete(ctx context.Context, params *ResourceParams) (*ResourceReturns, erro
 	workload := params.Params.Resource
 	handlers := getHandlers(params.RuntimeParams)
 	deleteCtx := handleContext(ctx, params.Params.Cluster)
+	deleteOpts, err := params.Params.deleteOptions()
+	if err != nil {
+		return nil, err
+	}
+	ignoreNotFound := params.Params.IgnoreNotFound == nil || *params.Params.IgnoreNotFound
 
 	if filter := params.Params.Filter; filter != nil {
 		labelSelector, err := metav1.LabelSelectorAsSelector(&metav1.LabelSelector{MatchLabels: filter.MatchingLabels})
 		if err != nil {
 			return nil, err
 		}
-		if err := params.KubeClient.DeleteAllOf(deleteCtx, workload, &client.DeleteAllOfOptions{ListOptions: client.ListOptions{Namespace: filter.Namespace, LabelSelector: labelSelector}}); err != nil {
+		if err := params.KubeClient.DeleteAllOf(deleteCtx, workload, &client.DeleteAllOfOptions{ListOptions: client.ListOptions{Namespace: filter.Namespace, LabelSelector: labelSelector}, DeleteOptions: *deleteOpts}); err != nil {
 			return &ResourceReturns{
 				Returns: ResourceReturnVars{
 					Resource: workload,
@@ -332,7 +444,13 @@ func Delete(ctx context.Context, params *ResourceParams) (*ResourceReturns, erro
 		return nil, nil
 	}
 
-	if err := handlers.Delete(deleteCtx, params.KubeClient, params.Params.Cluster, WorkflowResourceCreator, workload); err != nil {
+	// the delete handlers do not accept the options, so the client is used directly if any is set
+	if deleteOpts.GracePeriodSeconds != nil || deleteOpts.PropagationPolicy != nil {
+		err = params.KubeClient.Delete(deleteCtx, workload, deleteOpts)
+	} else {
+		err = handlers.Delete(deleteCtx, params.KubeClient, params.Params.Cluster, WorkflowResourceCreator, workload)
+	}
+	if err != nil && !(ignoreNotFound && errors.IsNotFound(err)) {
 		return &ResourceReturns{
 			Returns: ResourceReturnVars{
 				Resource: workload,
@@ -344,6 +462,21 @@ func Delete(ctx context.Context, params *ResourceParams) (*ResourceReturns, erro
 	return nil, nil
 }
 
+func (in ResourceVars) deleteOptions() (*client.DeleteOptions, error) {
+	opts := &client.DeleteOptions{GracePeriodSeconds: in.GracePeriodSeconds}
+	if in.GracePeriodSeconds != nil && *in.GracePeriodSeconds < 0 {
+		return nil, fmt.Errorf("invalid gracePeriodSeconds %d", *in.GracePeriodSeconds)
+	}
+	switch policy := metav1.DeletionPropagation(in.PropagationPolicy); policy {
+	case "":
+	case metav1.DeletePropagationForeground, metav1.DeletePropagationBackground, metav1.DeletePropagationOrphan:
+		opts.PropagationPolicy = &policy
+	default:
+		return nil, fmt.Errorf("invalid propagationPolicy %s, must be one of Foreground, Background and Orphan", in.PropagationPolicy)
+	}
+	return opts, nil
+}
+
 //go:embed kube.cue
 var template string
 
@@ -357,9 +490,27 @@ func GetProviders() map[string]cuexruntime.ProviderFn {
 	return map[string]cuexruntime.ProviderFn{
 		"apply":             providertypes.GenericProviderFn[ResourceVars, ResourceReturns](Apply),
 		"apply-in-parallel": providertypes.GenericProviderFn[ApplyInParallelVars, ApplyInParallelReturns](ApplyInParallel),
+		"apply-manifests":   providertypes.GenericProviderFn[ApplyManifestsVars, ApplyManifestsReturns](ApplyManifests),
 		"read":              providertypes.GenericProviderFn[ResourceVars, ResourceReturns](Read),
 		"list":              providertypes.GenericProviderFn[ResourceVars, ListReturns](List),
 		"delete":            providertypes.GenericProviderFn[ResourceVars, ResourceReturns](Delete),
 		"patch":             providertypes.NativeProviderFn(Patch),
+		"patch-status":      providertypes.GenericProviderFn[PatchStatusVars, PatchStatusReturns](PatchStatus),
+		"patch-object":      providertypes.GenericProviderFn[PatchObjectVars, ResourceReturns](PatchObject),
+		"wait-until":        providertypes.GenericProviderFn[WaitUntilVars, ResourceReturns](WaitUntil),
+		"get-logs":          providertypes.GenericProviderFn[GetLogsVars, GetLogsReturns](GetLogs),
+		"blast-radius":      providertypes.GenericProviderFn[BlastRadiusVars, BlastRadiusReturns](BlastRadius),
+		"snapshot":          providertypes.GenericProviderFn[SnapshotVars, ResourceReturns](Snapshot),
+		"restore":           providertypes.GenericProviderFn[SnapshotVars, RestoreReturns](Restore),
+		"config-hash":       providertypes.GenericProviderFn[ConfigHashVars, ConfigHashReturns](ConfigHash),
+		"mutate":            providertypes.GenericProviderFn[MutateVars, MutateReturns](Mutate),
+		"workload":          providertypes.GenericProviderFn[WorkloadVars, WorkloadReturns](Workload),
+		"rbac-report":       providertypes.GenericProviderFn[RBACReportVars, RBACReportReturns](RBACReport),
+		"quota":             providertypes.GenericProviderFn[QuotaVars, QuotaReturns](Quota),
+		"pdb":               providertypes.GenericProviderFn[PDBVars, PDBReturns](PDB),
+		"rollback":          providertypes.GenericProviderFn[RollbackVars, RollbackReturns](Rollback),
+		"delete-plan":       providertypes.GenericProviderFn[DeletePlanVars, DeletePlanReturns](DeletePlan),
+		"expose":            providertypes.GenericProviderFn[ExposeVars, ExposeReturns](Expose),
+		"exec":              providertypes.GenericProviderFn[ExecVars, ExecReturns](Exec),
 	}
 }
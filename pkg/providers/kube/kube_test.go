@@ -407,6 +407,49 @@ $params: {
 		}, time.Second*2, time.Millisecond*300).Should(BeNil())
 	})
 
+	It("server-side apply", func() {
+		ctx := context.Background()
+		cm := func(value string) *unstructured.Unstructured {
+			return &unstructured.Unstructured{Object: map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "ConfigMap",
+				"metadata":   map[string]interface{}{"name": "ssa"},
+				"data":       map[string]interface{}{"key": value},
+			}}
+		}
+		apply := func(value string, fieldManager string, force bool) (*ResourceReturns, error) {
+			return Apply(ctx, &ResourceParams{
+				Params: ResourceVars{
+					Resource:        cm(value),
+					ServerSideApply: true,
+					FieldManager:    fieldManager,
+					Force:           force,
+				},
+				RuntimeParams: providertypes.RuntimeParams{KubeClient: k8sClient},
+			})
+		}
+		res, err := apply("v1", "ssa-manager", false)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(res.Returns.Resource.GetManagedFields()).Should(HaveLen(1))
+		Expect(res.Returns.Resource.GetManagedFields()[0].Manager).Should(Equal("ssa-manager"))
+
+		_, err = apply("v2", "other-manager", false)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).Should(ContainSubstring(".data.key"))
+		Expect(err.Error()).Should(ContainSubstring("set force to take the ownership"))
+
+		res, err = apply("v2", "other-manager", true)
+		Expect(err).ToNot(HaveOccurred())
+		var managers []string
+		for _, entry := range res.Returns.Resource.GetManagedFields() {
+			managers = append(managers, entry.Manager)
+		}
+		Expect(managers).Should(ContainElement("other-manager"))
+		existing := &corev1.ConfigMap{}
+		Expect(k8sClient.Get(ctx, client.ObjectKey{Namespace: "default", Name: "ssa"}, existing)).Should(Succeed())
+		Expect(existing.Data["key"]).Should(Equal("v2"))
+	})
+
 	It("test error case", func() {
 		ctx := context.Background()
 		res, err := Read(ctx, &ResourceParams{
@@ -0,0 +1,241 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	providertypes "github.com/kubevela/workflow/pkg/providers/types"
+)
+
+// newPaginatedClient wraps the fake client, which ignores the limit and continue
+// options, to paginate the list like the api server
+func newPaginatedClient(cli client.WithWatch) client.Client {
+	return interceptor.NewClient(cli, interceptor.Funcs{
+		List: func(ctx context.Context, cli client.WithWatch, obj client.ObjectList, opts ...client.ListOption) error {
+			listOpts := &client.ListOptions{}
+			listOpts.ApplyOptions(opts)
+			limit, cont := int(listOpts.Limit), listOpts.Continue
+			listOpts.Limit, listOpts.Continue = 0, ""
+			if err := cli.List(ctx, obj, listOpts); err != nil {
+				return err
+			}
+			l := obj.(*unstructured.UnstructuredList)
+			start := 0
+			if cont != "" {
+				if _, err := fmt.Sscanf(cont, "%d", &start); err != nil || start > len(l.Items) {
+					return fmt.Errorf("invalid continue %q", cont)
+				}
+			}
+			end := len(l.Items)
+			if limit > 0 {
+				end = min(start+limit, len(l.Items))
+			}
+			if end < len(l.Items) {
+				l.SetContinue(fmt.Sprint(end))
+			}
+			l.Items = l.Items[start:end]
+			return nil
+		},
+	})
+}
+
+func TestListWithPagination(t *testing.T) {
+	var objs []client.Object
+	for i := 0; i < 5; i++ {
+		objs = append(objs, &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("cm-%d", i), Namespace: "default"},
+		})
+	}
+	list := func(cli client.Client, vars ResourceVars) *ListReturns {
+		r := require.New(t)
+		vars.Resource = &unstructured.Unstructured{Object: map[string]interface{}{"apiVersion": "v1", "kind": "ConfigMap"}}
+		vars.Filter = &ListFilter{Namespace: "default"}
+		res, err := List(context.Background(), &ResourceParams{
+			Params:        vars,
+			RuntimeParams: providertypes.RuntimeParams{KubeClient: cli},
+		})
+		r.NoError(err)
+		r.Empty(res.Returns.Error)
+		return res
+	}
+	pageThrough := func(cli client.Client, byToken bool) ([]string, int) {
+		var names []string
+		pages, next := 0, ""
+		for {
+			vars := ResourceVars{Limit: 2, Continue: next}
+			if byToken {
+				vars = ResourceVars{PageSize: 2, Token: next}
+			}
+			res := list(cli, vars)
+			pages++
+			require.LessOrEqual(t, len(res.Returns.Resources.Items), 2)
+			require.Empty(t, res.Returns.Resources.GetContinue())
+			for _, item := range res.Returns.Resources.Items {
+				names = append(names, item.GetName())
+			}
+			if next = res.Returns.Continue; byToken {
+				next = res.Returns.Token
+			}
+			if next == "" {
+				return names, pages
+			}
+		}
+	}
+
+	t.Run("paginate by limit and continue", func(t *testing.T) {
+		cli := newPaginatedClient(fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).WithObjects(objs...).Build())
+		names, pages := pageThrough(cli, false)
+		require.Equal(t, []string{"cm-0", "cm-1", "cm-2", "cm-3", "cm-4"}, names)
+		require.Equal(t, 3, pages)
+	})
+
+	t.Run("paginate by page size and token", func(t *testing.T) {
+		cli := newPaginatedClient(fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).WithObjects(objs...).Build())
+		names, pages := pageThrough(cli, true)
+		require.Equal(t, []string{"cm-0", "cm-1", "cm-2", "cm-3", "cm-4"}, names)
+		require.Equal(t, 3, pages)
+	})
+
+	t.Run("list all without limit", func(t *testing.T) {
+		cli := newPaginatedClient(fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).WithObjects(objs...).Build())
+		res := list(cli, ResourceVars{})
+		require.Len(t, res.Returns.Resources.Items, 5)
+		require.Empty(t, res.Returns.Continue)
+		require.Empty(t, res.Returns.Token)
+	})
+
+	t.Run("paginate by server", func(t *testing.T) {
+		cli := &test.MockClient{MockList: func(_ context.Context, obj client.ObjectList, opts ...client.ListOption) error {
+			listOpts := &client.ListOptions{}
+			listOpts.ApplyOptions(opts)
+			start := 0
+			if listOpts.Continue != "" {
+				_, _ = fmt.Sscanf(listOpts.Continue, "%d", &start)
+			}
+			end := min(start+int(listOpts.Limit), len(objs))
+			l := obj.(*unstructured.UnstructuredList)
+			for _, o := range objs[start:end] {
+				l.Items = append(l.Items, unstructured.Unstructured{Object: map[string]interface{}{
+					"apiVersion": "v1", "kind": "ConfigMap", "metadata": map[string]interface{}{"name": o.GetName()},
+				}})
+			}
+			if end < len(objs) {
+				l.SetContinue(fmt.Sprint(end))
+			}
+			return nil
+		}}
+		names, pages := pageThrough(cli, false)
+		require.Equal(t, []string{"cm-0", "cm-1", "cm-2", "cm-3", "cm-4"}, names)
+		require.Equal(t, 3, pages)
+	})
+
+	t.Run("invalid token", func(t *testing.T) {
+		_, err := List(context.Background(), &ResourceParams{
+			Params: ResourceVars{
+				Resource: &unstructured.Unstructured{Object: map[string]interface{}{"apiVersion": "v1", "kind": "ConfigMap"}},
+				Filter:   &ListFilter{},
+				PageSize: 2,
+				Token:    "invalid!",
+			},
+		})
+		require.ErrorContains(t, err, "invalid page token")
+	})
+}
+
+func TestListWithSelectors(t *testing.T) {
+	r := require.New(t)
+	var objs []client.Object
+	for i, app := range []string{"web", "web", "api", "db", "web"} {
+		objs = append(objs, &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      fmt.Sprintf("pod-%d", i),
+				Namespace: "default",
+				Labels:    map[string]string{"app": app, "tier": map[bool]string{true: "backend", false: "frontend"}[app != "web"]},
+			},
+			Status: corev1.PodStatus{Phase: map[bool]corev1.PodPhase{true: corev1.PodRunning, false: corev1.PodPending}[i%2 == 0]},
+		})
+	}
+	cli := newPaginatedClient(fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).WithObjects(objs...).
+		WithIndex(&corev1.Pod{}, "status.phase", func(obj client.Object) []string {
+			u, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+			if err != nil {
+				return nil
+			}
+			phase, _, _ := unstructured.NestedString(u, "status", "phase")
+			return []string{phase}
+		}).Build())
+	list := func(filter *ListFilter, limit int64, cont string) (*ListReturns, error) {
+		return List(context.Background(), &ResourceParams{
+			Params: ResourceVars{
+				Resource: &unstructured.Unstructured{Object: map[string]interface{}{"apiVersion": "v1", "kind": "Pod"}},
+				Filter:   filter,
+				Limit:    limit,
+				Continue: cont,
+			},
+			RuntimeParams: providertypes.RuntimeParams{KubeClient: cli},
+		})
+	}
+	names := func(res *ListReturns) []string {
+		var names []string
+		for _, item := range res.Returns.Resources.Items {
+			names = append(names, item.GetName())
+		}
+		return names
+	}
+
+	res, err := list(&ListFilter{Namespace: "default", LabelSelector: "app in (api,db)"}, 0, "")
+	r.NoError(err)
+	r.Equal([]string{"pod-2", "pod-3"}, names(res))
+	res, err = list(&ListFilter{Namespace: "default", MatchingLabels: map[string]string{"tier": "backend"}, LabelSelector: "app!=db"}, 0, "")
+	r.NoError(err)
+	r.Equal([]string{"pod-2"}, names(res))
+	res, err = list(&ListFilter{Namespace: "default", FieldSelector: "status.phase=Running"}, 0, "")
+	r.NoError(err)
+	r.Equal([]string{"pod-0", "pod-2", "pod-4"}, names(res))
+
+	var paged []string
+	res, err = list(&ListFilter{Namespace: "default", LabelSelector: "app=web"}, 2, "")
+	r.NoError(err)
+	paged = append(paged, names(res)...)
+	r.NotEmpty(res.Returns.Continue)
+	res, err = list(&ListFilter{Namespace: "default", LabelSelector: "app=web"}, 2, res.Returns.Continue)
+	r.NoError(err)
+	paged = append(paged, names(res)...)
+	r.Empty(res.Returns.Continue)
+	r.Equal([]string{"pod-0", "pod-1", "pod-4"}, paged)
+
+	_, err = list(&ListFilter{LabelSelector: "app in (web"}, 0, "")
+	r.Error(err)
+	r.Contains(err.Error(), `invalid label selector "app in (web"`)
+	_, err = list(&ListFilter{FieldSelector: "status.phase"}, 0, "")
+	r.Error(err)
+	r.Contains(err.Error(), `invalid field selector "status.phase"`)
+}
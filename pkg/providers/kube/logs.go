@@ -0,0 +1,147 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/kubevela/pkg/util/singleton"
+
+	providertypes "github.com/kubevela/workflow/pkg/providers/types"
+	"github.com/kubevela/workflow/pkg/utils"
+)
+
+const (
+	// defaultLogsMaxBytes is the default limit of the logs returned
+	defaultLogsMaxBytes int64 = 1 << 20
+	// annoDefaultContainer is the annotation of the default container of the pod used by kubectl
+	annoDefaultContainer = "kubectl.kubernetes.io/default-container"
+)
+
+// newClientset returns the clientset built from the kube config of the step, or the shared one if
+// the kube config is not overridden
+var newClientset = func(cfg *rest.Config) (kubernetes.Interface, error) {
+	if cfg == nil {
+		return singleton.StaticClient.Get(), nil
+	}
+	return kubernetes.NewForConfig(cfg)
+}
+
+// GetLogsVars .
+type GetLogsVars struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"`
+	// Container is the container to get the logs of, the default container of the pod is used if not set
+	Container string `json:"container,omitempty"`
+	TailLines *int64 `json:"tailLines,omitempty"`
+	Previous  bool   `json:"previous,omitempty"`
+	MaxBytes  int64  `json:"maxBytes,omitempty"`
+	Cluster   string `json:"cluster,omitempty"`
+}
+
+// GetLogsReturnVars .
+type GetLogsReturnVars struct {
+	Logs      string `json:"logs"`
+	Container string `json:"container,omitempty"`
+	// Truncated is true if the logs exceed the max bytes
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// GetLogsParams .
+type GetLogsParams = providertypes.Params[GetLogsVars]
+
+// GetLogsReturns .
+type GetLogsReturns = providertypes.Returns[GetLogsReturnVars]
+
+// GetLogs reads the logs of the container of the pod once, the logs are truncated to the max bytes.
+func GetLogs(ctx context.Context, params *GetLogsParams) (*GetLogsReturns, error) {
+	vars := params.Params
+	if vars.Namespace == "" {
+		vars.Namespace = "default"
+	}
+	if vars.MaxBytes < 0 {
+		return nil, fmt.Errorf("invalid maxBytes %d", vars.MaxBytes)
+	}
+	if vars.MaxBytes == 0 {
+		vars.MaxBytes = defaultLogsMaxBytes
+	}
+	if vars.TailLines != nil && *vars.TailLines < 0 {
+		return nil, fmt.Errorf("invalid tailLines %d", *vars.TailLines)
+	}
+	readCtx := handleContext(ctx, vars.Cluster)
+	if vars.Container == "" && params.KubeClient != nil {
+		pod := &corev1.Pod{}
+		if err := params.KubeClient.Get(readCtx, client.ObjectKey{Namespace: vars.Namespace, Name: vars.Name}, pod); err != nil {
+			return nil, err
+		}
+		vars.Container = defaultContainer(pod)
+	}
+	clientset, err := newClientset(params.KubeConfig)
+	if err != nil {
+		return nil, err
+	}
+	// read one more byte to tell whether the logs are truncated
+	limit := vars.MaxBytes + 1
+	logs, err := utils.GetLogsFromPod(ctx, clientset, params.KubeClient, vars.Name, vars.Namespace, vars.Cluster, &corev1.PodLogOptions{
+		Container:  vars.Container,
+		TailLines:  vars.TailLines,
+		Previous:   vars.Previous,
+		LimitBytes: &limit,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the logs of pod %s/%s: %w", vars.Namespace, vars.Name, err)
+	}
+	//nolint:errcheck
+	defer logs.Close()
+	b, err := io.ReadAll(io.LimitReader(logs, limit))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the logs of pod %s/%s: %w", vars.Namespace, vars.Name, err)
+	}
+	truncated := int64(len(b)) > vars.MaxBytes
+	if truncated {
+		b = b[:vars.MaxBytes]
+	}
+	return &GetLogsReturns{
+		Returns: GetLogsReturnVars{
+			Logs:      string(b),
+			Container: vars.Container,
+			Truncated: truncated,
+		},
+	}, nil
+}
+
+// defaultContainer returns the container annotated as the default one, or the first container of the pod
+func defaultContainer(pod *corev1.Pod) string {
+	if name := pod.GetAnnotations()[annoDefaultContainer]; name != "" {
+		for _, c := range pod.Spec.Containers {
+			if c.Name == name {
+				return name
+			}
+		}
+	}
+	if len(pod.Spec.Containers) > 0 {
+		return pod.Spec.Containers[0].Name
+	}
+	return ""
+}
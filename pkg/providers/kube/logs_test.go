@@ -0,0 +1,108 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	fakeclientset "k8s.io/client-go/kubernetes/fake"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	clienttesting "k8s.io/client-go/testing"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	providertypes "github.com/kubevela/workflow/pkg/providers/types"
+)
+
+func TestGetLogs(t *testing.T) {
+	r := require.New(t)
+	pods := []*corev1.Pod{{
+		ObjectMeta: metav1.ObjectMeta{Name: "job", Namespace: "default"},
+		Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "main"}, {Name: "sidecar"}}},
+	}, {
+		ObjectMeta: metav1.ObjectMeta{Name: "annotated", Namespace: "default", Annotations: map[string]string{annoDefaultContainer: "sidecar"}},
+		Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "main"}, {Name: "sidecar"}}},
+	}}
+	cli := fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).WithObjects(pods[0], pods[1]).Build()
+	// the fake clientset always returns "fake logs"
+	cs := fakeclientset.NewSimpleClientset()
+	var configs []*rest.Config
+	defer func(fn func(*rest.Config) (kubernetes.Interface, error)) { newClientset = fn }(newClientset)
+	newClientset = func(cfg *rest.Config) (kubernetes.Interface, error) {
+		configs = append(configs, cfg)
+		return cs, nil
+	}
+	var kubeConfig *rest.Config
+	getLogs := func(vars GetLogsVars) (*GetLogsReturns, *corev1.PodLogOptions, error) {
+		cs.ClearActions()
+		res, err := GetLogs(context.Background(), &GetLogsParams{Params: vars, RuntimeParams: providertypes.RuntimeParams{KubeClient: cli, KubeConfig: kubeConfig}})
+		var opts *corev1.PodLogOptions
+		for _, action := range cs.Actions() {
+			if action.GetSubresource() == "log" {
+				opts = action.(clienttesting.GenericAction).GetValue().(*corev1.PodLogOptions)
+			}
+		}
+		return res, opts, err
+	}
+
+	res, opts, err := getLogs(GetLogsVars{Name: "job"})
+	r.NoError(err)
+	r.Equal("fake logs", res.Returns.Logs)
+	r.Equal("main", res.Returns.Container)
+	r.False(res.Returns.Truncated)
+	r.Equal("main", opts.Container)
+	r.Nil(opts.TailLines)
+	r.Equal(defaultLogsMaxBytes+1, *opts.LimitBytes)
+
+	res, opts, err = getLogs(GetLogsVars{Name: "annotated"})
+	r.NoError(err)
+	r.Equal("sidecar", res.Returns.Container)
+	r.Equal("sidecar", opts.Container)
+
+	res, opts, err = getLogs(GetLogsVars{Name: "job", Container: "sidecar", TailLines: ptr.To(int64(10)), Previous: true, MaxBytes: 4})
+	r.NoError(err)
+	r.Equal("fake", res.Returns.Logs)
+	r.True(res.Returns.Truncated)
+	r.Equal("sidecar", opts.Container)
+	r.Equal(int64(10), *opts.TailLines)
+	r.True(opts.Previous)
+	r.Equal(int64(5), *opts.LimitBytes)
+	for _, cfg := range configs {
+		r.Nil(cfg)
+	}
+
+	// the logs are read with the kube config of the step
+	kubeConfig = &rest.Config{Host: "https://kube-api", BearerToken: "step-token"}
+	configs = nil
+	_, _, err = getLogs(GetLogsVars{Name: "job"})
+	r.NoError(err)
+	r.Len(configs, 1)
+	r.Equal("step-token", configs[0].BearerToken)
+	kubeConfig = nil
+
+	_, _, err = getLogs(GetLogsVars{Name: "missing"})
+	r.Error(err)
+	_, _, err = getLogs(GetLogsVars{Name: "job", TailLines: ptr.To(int64(-1))})
+	r.Error(err)
+	r.Contains(err.Error(), "invalid tailLines -1")
+}
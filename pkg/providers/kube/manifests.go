@@ -0,0 +1,208 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/kubevela/pkg/util/k8s"
+
+	providertypes "github.com/kubevela/workflow/pkg/providers/types"
+)
+
+// Manifests is an ordered list of manifests. It can be decoded from either a list, which
+// keeps the order of the items, a map of named manifests like the outputs (auxiliaries)
+// of a definition, which is ordered by the names, or a multi-document YAML string.
+type Manifests struct {
+	Names []string
+	Items []*unstructured.Unstructured
+}
+
+// UnmarshalJSON decodes the manifests from a list, a map of named manifests or a YAML string.
+func (m *Manifests) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '"' {
+		var docs string
+		if err := json.Unmarshal(data, &docs); err != nil {
+			return err
+		}
+		return m.decodeYAML(docs)
+	}
+	if len(data) > 0 && data[0] == '{' {
+		named := map[string]*unstructured.Unstructured{}
+		if err := json.Unmarshal(data, &named); err != nil {
+			return err
+		}
+		m.Names = make([]string, 0, len(named))
+		for name := range named {
+			m.Names = append(m.Names, name)
+		}
+		sort.Strings(m.Names)
+		m.Items = make([]*unstructured.Unstructured, 0, len(named))
+		for _, name := range m.Names {
+			m.Items = append(m.Items, named[name])
+		}
+		return nil
+	}
+	m.Names = nil
+	return json.Unmarshal(data, &m.Items)
+}
+
+func (m *Manifests) decodeYAML(docs string) error {
+	m.Names, m.Items = nil, nil
+	decoder := utilyaml.NewYAMLOrJSONDecoder(strings.NewReader(docs), 4096)
+	for i := 0; ; i++ {
+		obj := map[string]interface{}{}
+		if err := decoder.Decode(&obj); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return fmt.Errorf("invalid YAML manifest #%d: %w", i, err)
+		}
+		// skip the empty documents
+		if len(obj) == 0 {
+			i--
+			continue
+		}
+		m.Items = append(m.Items, &unstructured.Unstructured{Object: obj})
+	}
+}
+
+func (m *Manifests) name(i int) string {
+	if m.Names != nil {
+		return m.Names[i]
+	}
+	return fmt.Sprintf("#%d", i)
+}
+
+// ApplyManifestsVars .
+type ApplyManifestsVars struct {
+	Manifests Manifests `json:"value"`
+	Cluster   string    `json:"cluster,omitempty"`
+	// Atomic rolls the applied manifests back if any of the manifests fails
+	Atomic bool `json:"atomic,omitempty"`
+}
+
+// ApplyManifestsReturnVars .
+type ApplyManifestsReturnVars struct {
+	Resources []*unstructured.Unstructured `json:"value"`
+	// Results are the applied resources keyed by kind/namespace/name
+	Results map[string]*unstructured.Unstructured `json:"results"`
+}
+
+// ApplyManifestsParams .
+type ApplyManifestsParams = providertypes.Params[ApplyManifestsVars]
+
+// ApplyManifestsReturns .
+type ApplyManifestsReturns = providertypes.Returns[ApplyManifestsReturnVars]
+
+// ApplyManifests applies the manifests one by one in order, and stops at the first failure. The applied
+// manifests are rolled back in the reverse order on failure if atomic is set.
+func ApplyManifests(ctx context.Context, params *ApplyManifestsParams) (*ApplyManifestsReturns, error) {
+	manifests := params.Params.Manifests
+	handlers := getHandlers(params.RuntimeParams)
+	deployCtx := handleContext(ctx, params.Params.Cluster)
+	results := make(map[string]*unstructured.Unstructured, len(manifests.Items))
+	var applied []string
+	// the states before applied, nil if the resource did not exist
+	var previous []*unstructured.Unstructured
+	for i, workload := range manifests.Items {
+		if workload == nil {
+			return nil, fmt.Errorf("manifest %s is empty", manifests.name(i))
+		}
+		if workload.GetNamespace() == "" {
+			if namespaced, err := params.KubeClient.IsObjectNamespaced(workload); err != nil || namespaced {
+				workload.SetNamespace("default")
+			}
+		}
+		for k, v := range params.RuntimeParams.Labels {
+			if err := k8s.AddLabel(workload, k, v); err != nil {
+				return nil, err
+			}
+		}
+		key := ownerKey(workload.GetKind(), workload.GetNamespace(), workload.GetName())
+		var prev *unstructured.Unstructured
+		if params.Params.Atomic {
+			prev = new(unstructured.Unstructured)
+			prev.SetGroupVersionKind(workload.GroupVersionKind())
+			if err := params.KubeClient.Get(deployCtx, client.ObjectKeyFromObject(workload), prev); err != nil {
+				if !errors.IsNotFound(err) {
+					return nil, fmt.Errorf("failed to get manifest %s (%s) before applied: %w", manifests.name(i), key, err)
+				}
+				prev = nil
+			}
+		}
+		if err := handlers.Apply(deployCtx, params.KubeClient, params.Params.Cluster, WorkflowResourceCreator, workload); err != nil {
+			msg := fmt.Sprintf("failed to apply manifest %s (%s): %v", manifests.name(i), key, err)
+			switch {
+			case len(applied) == 0:
+				msg += ", no manifest was applied"
+			case !params.Params.Atomic:
+				msg += ", the applied manifests: " + strings.Join(applied, ", ")
+			default:
+				msg += ", " + rollbackManifests(deployCtx, params, handlers, manifests.Items[:len(applied)], previous)
+			}
+			return nil, fmt.Errorf("%s", msg)
+		}
+		results[key] = workload
+		applied = append(applied, key)
+		previous = append(previous, prev)
+	}
+	return &ApplyManifestsReturns{
+		Returns: ApplyManifestsReturnVars{
+			Resources: manifests.Items,
+			Results:   results,
+		},
+	}, nil
+}
+
+// rollbackManifests deletes the created resources and restores the updated ones in the reverse order
+func rollbackManifests(ctx context.Context, params *ApplyManifestsParams, handlers *providertypes.KubeHandlers, workloads, previous []*unstructured.Unstructured) string {
+	var rolledBack, failed []string
+	for i := len(workloads) - 1; i >= 0; i-- {
+		key := ownerKey(workloads[i].GetKind(), workloads[i].GetNamespace(), workloads[i].GetName())
+		var err error
+		if prev := previous[i]; prev == nil {
+			err = handlers.Delete(ctx, params.KubeClient, params.Params.Cluster, WorkflowResourceCreator, workloads[i])
+		} else {
+			prev.SetResourceVersion("")
+			prev.SetManagedFields(nil)
+			err = handlers.Apply(ctx, params.KubeClient, params.Params.Cluster, WorkflowResourceCreator, prev)
+		}
+		if err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %v", key, err))
+			continue
+		}
+		rolledBack = append(rolledBack, key)
+	}
+	msg := "the applied manifests are rolled back: " + strings.Join(rolledBack, ", ")
+	if len(failed) > 0 {
+		msg += ", failed to roll back " + strings.Join(failed, "; ")
+	}
+	return msg
+}
@@ -0,0 +1,164 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	providertypes "github.com/kubevela/workflow/pkg/providers/types"
+)
+
+func TestApplyManifests(t *testing.T) {
+	ctx := context.Background()
+	var applied []string
+	handlers := &providertypes.KubeHandlers{
+		Apply: func(ctx context.Context, cli client.Client, cluster, owner string, workloads ...*unstructured.Unstructured) error {
+			for _, workload := range workloads {
+				if workload.GetName() == "bad" {
+					return fmt.Errorf("rejected")
+				}
+				applied = append(applied, ownerKey(workload.GetKind(), workload.GetNamespace(), workload.GetName()))
+			}
+			return apply(ctx, cli, cluster, owner, workloads...)
+		},
+	}
+	testCases := map[string]struct {
+		value    string
+		expected []string
+		err      string
+	}{
+		"list keeps the order": {
+			value:    `[{"apiVersion":"v1","kind":"Namespace","metadata":{"name":"ns"}},{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"b","namespace":"ns"}},{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"a","namespace":"ns"}}]`,
+			expected: []string{"Namespace//ns", "ConfigMap/ns/b", "ConfigMap/ns/a"},
+		},
+		"named manifests are ordered by name": {
+			value:    `{"2-config":{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"b"}},"1-namespace":{"apiVersion":"v1","kind":"Namespace","metadata":{"name":"ns"}}}`,
+			expected: []string{"Namespace//ns", "ConfigMap/default/b"},
+		},
+		"multi-document yaml": {
+			value:    `"apiVersion: v1\nkind: Namespace\nmetadata:\n  name: ns\n---\n---\napiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: b\n  namespace: ns\n"`,
+			expected: []string{"Namespace//ns", "ConfigMap/ns/b"},
+		},
+		"stop at the first failure": {
+			value:    `[{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"a"}},{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"bad"}},{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"c"}}]`,
+			expected: []string{"ConfigMap/default/a"},
+			err:      "failed to apply manifest #1 (ConfigMap/default/bad): rejected, the applied manifests: ConfigMap/default/a",
+		},
+		"report the name of the failed manifest": {
+			value:    `{"first":{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"bad"}}}`,
+			expected: nil,
+			err:      "failed to apply manifest first (ConfigMap/default/bad): rejected, no manifest was applied",
+		},
+		"same name in different namespaces": {
+			value:    `[{"apiVersion":"v1","kind":"Namespace","metadata":{"name":"ns"}},{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"b"}},{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"b","namespace":"ns"}}]`,
+			expected: []string{"Namespace//ns", "ConfigMap/default/b", "ConfigMap/ns/b"},
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			r := require.New(t)
+			applied = nil
+			mapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{corev1.SchemeGroupVersion})
+			mapper.Add(corev1.SchemeGroupVersion.WithKind("ConfigMap"), meta.RESTScopeNamespace)
+			mapper.Add(corev1.SchemeGroupVersion.WithKind("Namespace"), meta.RESTScopeRoot)
+			cli := fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).WithRESTMapper(mapper).Build()
+			vars := ApplyManifestsVars{}
+			r.NoError(json.Unmarshal([]byte(fmt.Sprintf(`{"value":%s}`, tc.value)), &vars))
+			res, err := ApplyManifests(ctx, &ApplyManifestsParams{
+				Params: vars,
+				RuntimeParams: providertypes.RuntimeParams{
+					KubeClient:   cli,
+					KubeHandlers: handlers,
+					Labels:       map[string]string{"workflowrun.oam.dev/name": "run"},
+				},
+			})
+			r.Equal(tc.expected, applied)
+			if tc.err != "" {
+				r.EqualError(err, tc.err)
+				return
+			}
+			r.NoError(err)
+			r.Len(res.Returns.Resources, len(tc.expected))
+			r.Len(res.Returns.Results, len(tc.expected))
+			for i, resource := range res.Returns.Resources {
+				r.Equal(tc.expected[i], ownerKey(resource.GetKind(), resource.GetNamespace(), resource.GetName()))
+				r.Equal(resource, res.Returns.Results[tc.expected[i]])
+			}
+			cm := &corev1.ConfigMap{}
+			r.NoError(cli.Get(ctx, client.ObjectKey{Name: "b", Namespace: res.Returns.Resources[1].GetNamespace()}, cm))
+			r.Equal("run", cm.Labels["workflowrun.oam.dev/name"])
+		})
+	}
+}
+
+func TestApplyManifestsAtomic(t *testing.T) {
+	ctx := context.Background()
+	handlers := &providertypes.KubeHandlers{
+		Apply: func(ctx context.Context, cli client.Client, cluster, owner string, workloads ...*unstructured.Unstructured) error {
+			for _, workload := range workloads {
+				if workload.GetName() == "bad" {
+					return fmt.Errorf("rejected")
+				}
+			}
+			return apply(ctx, cli, cluster, owner, workloads...)
+		},
+		Delete: delete,
+	}
+	value := `[{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"a"},"data":{"key":"new"}},{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"b"}},{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"bad"}}]`
+	for _, atomic := range []bool{false, true} {
+		t.Run(fmt.Sprintf("atomic=%t", atomic), func(t *testing.T) {
+			r := require.New(t)
+			cli := fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).WithObjects(&corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: "a", Namespace: "default"},
+				Data:       map[string]string{"key": "old"},
+			}).Build()
+			vars := ApplyManifestsVars{Atomic: atomic}
+			r.NoError(json.Unmarshal([]byte(value), &vars.Manifests))
+			_, err := ApplyManifests(ctx, &ApplyManifestsParams{
+				Params:        vars,
+				RuntimeParams: providertypes.RuntimeParams{KubeClient: cli, KubeHandlers: handlers},
+			})
+			r.Error(err)
+			a := &corev1.ConfigMap{}
+			r.NoError(cli.Get(ctx, client.ObjectKey{Name: "a", Namespace: "default"}, a))
+			getB := cli.Get(ctx, client.ObjectKey{Name: "b", Namespace: "default"}, &corev1.ConfigMap{})
+			if !atomic {
+				r.EqualError(err, "failed to apply manifest #2 (ConfigMap/default/bad): rejected, the applied manifests: ConfigMap/default/a, ConfigMap/default/b")
+				r.Equal("new", a.Data["key"])
+				r.NoError(getB)
+				return
+			}
+			r.EqualError(err, "failed to apply manifest #2 (ConfigMap/default/bad): rejected, the applied manifests are rolled back: ConfigMap/default/b, ConfigMap/default/a")
+			r.Equal("old", a.Data["key"])
+			r.True(errors.IsNotFound(getB))
+		})
+	}
+}
@@ -0,0 +1,136 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	jsonpatch "github.com/evanphx/json-patch/v5"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	providertypes "github.com/kubevela/workflow/pkg/providers/types"
+)
+
+// MutationMatch selects the resources to mutate, the empty fields match all.
+type MutationMatch struct {
+	APIVersion string            `json:"apiVersion,omitempty"`
+	Kind       string            `json:"kind,omitempty"`
+	Name       string            `json:"name,omitempty"`
+	Namespace  string            `json:"namespace,omitempty"`
+	Labels     map[string]string `json:"labels,omitempty"`
+}
+
+// MutationOperation is a json patch operation, only add, replace and remove are supported.
+type MutationOperation struct {
+	Op   string `json:"op"`
+	Path string `json:"path"`
+	// Value is kept raw so that an explicit null is distinguished from the absent value
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// MutationRule .
+type MutationRule struct {
+	Match *MutationMatch      `json:"match,omitempty"`
+	Patch []MutationOperation `json:"patch"`
+}
+
+// MutateVars .
+type MutateVars struct {
+	Resources []*unstructured.Unstructured `json:"resources"`
+	Rules     []MutationRule               `json:"rules"`
+}
+
+// MutateReturnVars .
+type MutateReturnVars struct {
+	Resources []*unstructured.Unstructured `json:"resources"`
+}
+
+// MutateParams .
+type MutateParams = providertypes.Params[MutateVars]
+
+// MutateReturns .
+type MutateReturns = providertypes.Returns[MutateReturnVars]
+
+// Mutate applies the mutation rules to the matching resources in order.
+func Mutate(_ context.Context, params *MutateParams) (*MutateReturns, error) {
+	patches := make([]jsonpatch.Patch, len(params.Params.Rules))
+	for i, rule := range params.Params.Rules {
+		for _, op := range rule.Patch {
+			switch op.Op {
+			case "add", "replace", "remove":
+			default:
+				return nil, fmt.Errorf("unsupported operation %q in mutation rule %d", op.Op, i)
+			}
+		}
+		b, err := json.Marshal(rule.Patch)
+		if err != nil {
+			return nil, err
+		}
+		if patches[i], err = jsonpatch.DecodePatch(b); err != nil {
+			return nil, fmt.Errorf("invalid patch in mutation rule %d: %w", i, err)
+		}
+	}
+	opts := jsonpatch.NewApplyOptions()
+	opts.EnsurePathExistsOnAdd = true
+	opts.AllowMissingPathOnRemove = true
+	resources := make([]*unstructured.Unstructured, 0, len(params.Params.Resources))
+	for _, resource := range params.Params.Resources {
+		for i, rule := range params.Params.Rules {
+			if !rule.Match.matches(resource) {
+				continue
+			}
+			b, err := resource.MarshalJSON()
+			if err != nil {
+				return nil, err
+			}
+			if b, err = patches[i].ApplyWithOptions(b, opts); err != nil {
+				return nil, fmt.Errorf("failed to apply mutation rule %d to %s %s: %w", i, resource.GetKind(), resource.GetName(), err)
+			}
+			resource = &unstructured.Unstructured{}
+			if err := resource.UnmarshalJSON(b); err != nil {
+				return nil, err
+			}
+		}
+		resources = append(resources, resource)
+	}
+	return &MutateReturns{
+		Returns: MutateReturnVars{
+			Resources: resources,
+		},
+	}, nil
+}
+
+func (in *MutationMatch) matches(obj *unstructured.Unstructured) bool {
+	if in == nil {
+		return true
+	}
+	if (in.APIVersion != "" && in.APIVersion != obj.GetAPIVersion()) ||
+		(in.Kind != "" && in.Kind != obj.GetKind()) ||
+		(in.Name != "" && in.Name != obj.GetName()) ||
+		(in.Namespace != "" && in.Namespace != obj.GetNamespace()) {
+		return false
+	}
+	labels := obj.GetLabels()
+	for k, v := range in.Labels {
+		if labels[k] != v {
+			return false
+		}
+	}
+	return true
+}
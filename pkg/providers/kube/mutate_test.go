@@ -0,0 +1,131 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestMutate(t *testing.T) {
+	deploy := func(name string, labels map[string]interface{}) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "apps/v1",
+			"kind":       "Deployment",
+			"metadata":   map[string]interface{}{"name": name, "labels": labels},
+			"spec": map[string]interface{}{
+				"template": map[string]interface{}{
+					"spec": map[string]interface{}{
+						"containers": []interface{}{map[string]interface{}{"name": "main", "image": "nginx"}},
+					},
+				},
+			},
+		}}
+	}
+	cm := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata":   map[string]interface{}{"name": "cm"},
+	}}
+	sidecar := map[string]interface{}{"name": "sidecar", "image": "envoy"}
+
+	testCases := map[string]struct {
+		rules       []MutationRule
+		check       func(r *require.Assertions, resources []*unstructured.Unstructured)
+		expectedErr string
+	}{
+		"set labels": {
+			rules: []MutationRule{{
+				Patch: []MutationOperation{
+					{Op: "add", Path: "/metadata/labels/team", Value: json.RawMessage(`"infra"`)},
+					{Op: "remove", Path: "/metadata/labels/deprecated"},
+				},
+				Match: &MutationMatch{Kind: "Deployment"},
+			}},
+			check: func(r *require.Assertions, resources []*unstructured.Unstructured) {
+				r.Equal(map[string]string{"app": "a", "team": "infra"}, resources[0].GetLabels())
+				r.Equal(map[string]string{"app": "b", "team": "infra"}, resources[1].GetLabels())
+				r.Empty(resources[2].GetLabels())
+			},
+		},
+		"inject sidecar": {
+			rules: []MutationRule{{
+				Match: &MutationMatch{Kind: "Deployment", Labels: map[string]string{"app": "a"}},
+				Patch: []MutationOperation{{Op: "add", Path: "/spec/template/spec/containers/-", Value: json.RawMessage(`{"name":"sidecar","image":"envoy"}`)}},
+			}, {
+				Match: &MutationMatch{Name: "b"},
+				Patch: []MutationOperation{{Op: "replace", Path: "/spec/template/spec/containers/0/image", Value: json.RawMessage(`"nginx:latest"`)}},
+			}},
+			check: func(r *require.Assertions, resources []*unstructured.Unstructured) {
+				containers, _, _ := unstructured.NestedSlice(resources[0].Object, "spec", "template", "spec", "containers")
+				r.Len(containers, 2)
+				r.Equal(sidecar, containers[1])
+				containers, _, _ = unstructured.NestedSlice(resources[1].Object, "spec", "template", "spec", "containers")
+				r.Len(containers, 1)
+				r.Equal("nginx:latest", containers[0].(map[string]interface{})["image"])
+			},
+		},
+		"unsupported operation": {
+			rules:       []MutationRule{{Patch: []MutationOperation{{Op: "move", Path: "/metadata/name"}}}},
+			expectedErr: "unsupported operation",
+		},
+		"replace missing path": {
+			rules:       []MutationRule{{Patch: []MutationOperation{{Op: "replace", Path: "/spec/replicas", Value: json.RawMessage(`3`)}}}},
+			expectedErr: "failed to apply mutation rule 0",
+		},
+		"set null": {
+			rules: []MutationRule{{
+				Match: &MutationMatch{Kind: "Deployment"},
+				Patch: []MutationOperation{{Op: "replace", Path: "/spec/template", Value: json.RawMessage(`null`)}},
+			}},
+			check: func(r *require.Assertions, resources []*unstructured.Unstructured) {
+				spec, ok := resources[0].Object["spec"].(map[string]interface{})
+				r.True(ok)
+				template, exists := spec["template"]
+				r.True(exists)
+				r.Nil(template)
+			},
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			r := require.New(t)
+			res, err := Mutate(context.Background(), &MutateParams{
+				Params: MutateVars{
+					Resources: []*unstructured.Unstructured{
+						deploy("a", map[string]interface{}{"app": "a", "deprecated": "true"}),
+						deploy("b", map[string]interface{}{"app": "b"}),
+						cm.DeepCopy(),
+					},
+					Rules: tc.rules,
+				},
+			})
+			if tc.expectedErr != "" {
+				r.Error(err)
+				r.Contains(err.Error(), tc.expectedErr)
+				return
+			}
+			r.NoError(err)
+			r.Len(res.Returns.Resources, 3)
+			tc.check(r, res.Returns.Resources)
+		})
+	}
+}
@@ -0,0 +1,84 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube
+
+import (
+	"context"
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/kubevela/workflow/api/v1alpha1"
+	"github.com/kubevela/workflow/pkg/cue/model"
+	"github.com/kubevela/workflow/pkg/cue/process"
+)
+
+// OwnerReferenceOptions sets the WorkflowRun as the owner of the applied resource, so that the resource is
+// garbage collected with the WorkflowRun.
+type OwnerReferenceOptions struct {
+	Controller         *bool `json:"controller,omitempty"`
+	BlockOwnerDeletion *bool `json:"blockOwnerDeletion,omitempty"`
+}
+
+// setWorkflowRunOwner adds the owner reference of the current WorkflowRun to the workload, the owner reference
+// with the same uid is replaced. The workload must be in the same namespace and cluster as the WorkflowRun.
+func setWorkflowRunOwner(ctx context.Context, cli client.Client, cluster string, workload *unstructured.Unstructured, pCtx process.Context, opts *OwnerReferenceOptions) error {
+	if pCtx == nil {
+		return fmt.Errorf("the owner WorkflowRun is unknown")
+	}
+	name, _ := pCtx.GetData(model.ContextName).(string)
+	namespace, _ := pCtx.GetData(model.ContextNamespace).(string)
+	if name == "" || namespace == "" {
+		return fmt.Errorf("the owner WorkflowRun is unknown")
+	}
+	target := fmt.Sprintf("%s %s/%s", workload.GetKind(), workload.GetNamespace(), workload.GetName())
+	if cluster != "" && cluster != "local" {
+		return fmt.Errorf("cannot set the WorkflowRun as the owner of %s in cluster %s, the owner references across clusters are not supported", target, cluster)
+	}
+	if namespaced, err := cli.IsObjectNamespaced(workload); err == nil && !namespaced {
+		return fmt.Errorf("cannot set the WorkflowRun as the owner of the cluster-scoped %s %s", workload.GetKind(), workload.GetName())
+	}
+	if workload.GetNamespace() != namespace {
+		return fmt.Errorf("cannot set the WorkflowRun %s/%s as the owner of %s, the owner references across namespaces are not supported", namespace, name, target)
+	}
+	run := &v1alpha1.WorkflowRun{}
+	if err := cli.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, run); err != nil {
+		return fmt.Errorf("failed to get the owner WorkflowRun %s/%s: %w", namespace, name, err)
+	}
+	owner := metav1.OwnerReference{
+		APIVersion:         v1alpha1.SchemeGroupVersion.String(),
+		Kind:               v1alpha1.WorkflowRunKind,
+		Name:               run.Name,
+		UID:                run.UID,
+		Controller:         opts.Controller,
+		BlockOwnerDeletion: opts.BlockOwnerDeletion,
+	}
+	if owner.BlockOwnerDeletion == nil {
+		owner.BlockOwnerDeletion = ptr.To(true)
+	}
+	refs := []metav1.OwnerReference{owner}
+	for _, ref := range workload.GetOwnerReferences() {
+		if ref.UID != run.UID {
+			refs = append(refs, ref)
+		}
+	}
+	workload.SetOwnerReferences(refs)
+	return nil
+}
@@ -0,0 +1,108 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/kubevela/workflow/api/v1alpha1"
+	"github.com/kubevela/workflow/pkg/cue/process"
+	providertypes "github.com/kubevela/workflow/pkg/providers/types"
+)
+
+func TestApplyWithOwnerReference(t *testing.T) {
+	r := require.New(t)
+	ctx := context.Background()
+	scheme := runtime.NewScheme()
+	r.NoError(clientgoscheme.AddToScheme(scheme))
+	r.NoError(v1alpha1.AddToScheme(scheme))
+	mapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{corev1.SchemeGroupVersion, v1alpha1.SchemeGroupVersion})
+	mapper.Add(corev1.SchemeGroupVersion.WithKind("ConfigMap"), meta.RESTScopeNamespace)
+	mapper.Add(corev1.SchemeGroupVersion.WithKind("Namespace"), meta.RESTScopeRoot)
+	mapper.Add(v1alpha1.WorkflowRunGroupVersionKind, meta.RESTScopeNamespace)
+	cli := fake.NewClientBuilder().WithScheme(scheme).WithRESTMapper(mapper).WithObjects(&v1alpha1.WorkflowRun{
+		ObjectMeta: metav1.ObjectMeta{Name: "run", Namespace: "ns", UID: "run-uid"},
+	}).Build()
+	pCtx := process.NewContext(process.ContextData{Name: "run", Namespace: "ns"})
+	apply := func(kind, namespace, cluster string, opts *OwnerReferenceOptions, pCtx process.Context) (*ResourceReturns, error) {
+		resource := &unstructured.Unstructured{}
+		resource.SetAPIVersion("v1")
+		resource.SetKind(kind)
+		resource.SetName("child")
+		resource.SetNamespace(namespace)
+		resource.SetOwnerReferences([]metav1.OwnerReference{{APIVersion: "v1", Kind: "ConfigMap", Name: "other", UID: "other-uid"}})
+		return Apply(ctx, &ResourceParams{
+			Params:        ResourceVars{Resource: resource, Cluster: cluster, OwnerReference: opts},
+			RuntimeParams: providertypes.RuntimeParams{KubeClient: cli, ProcessContext: pCtx},
+		})
+	}
+
+	_, err := apply("ConfigMap", "ns", "", &OwnerReferenceOptions{}, pCtx)
+	r.NoError(err)
+	cm := &corev1.ConfigMap{}
+	r.NoError(cli.Get(ctx, client.ObjectKey{Namespace: "ns", Name: "child"}, cm))
+	r.Equal([]metav1.OwnerReference{{
+		APIVersion:         "core.oam.dev/v1alpha1",
+		Kind:               "WorkflowRun",
+		Name:               "run",
+		UID:                "run-uid",
+		BlockOwnerDeletion: ptr.To(true),
+	}, {APIVersion: "v1", Kind: "ConfigMap", Name: "other", UID: "other-uid"}}, cm.OwnerReferences)
+
+	res, err := apply("ConfigMap", "ns", "local", &OwnerReferenceOptions{Controller: ptr.To(true), BlockOwnerDeletion: ptr.To(false)}, pCtx)
+	r.NoError(err)
+	refs := res.Returns.Resource.GetOwnerReferences()
+	r.Len(refs, 2)
+	r.Equal(ptr.To(true), refs[0].Controller)
+	r.Equal(ptr.To(false), refs[0].BlockOwnerDeletion)
+
+	res, err = apply("ConfigMap", "ns", "", nil, pCtx)
+	r.NoError(err)
+	r.Len(res.Returns.Resource.GetOwnerReferences(), 1)
+
+	_, err = apply("ConfigMap", "default", "", &OwnerReferenceOptions{}, pCtx)
+	r.Error(err)
+	r.Equal("cannot set the WorkflowRun ns/run as the owner of ConfigMap default/child, the owner references across namespaces are not supported", err.Error())
+	_, err = apply("ConfigMap", "", "", &OwnerReferenceOptions{}, pCtx)
+	r.Error(err)
+	r.Contains(err.Error(), "across namespaces")
+	_, err = apply("Namespace", "", "", &OwnerReferenceOptions{}, pCtx)
+	r.Error(err)
+	r.Contains(err.Error(), "cannot set the WorkflowRun as the owner of the cluster-scoped Namespace child")
+	_, err = apply("ConfigMap", "ns", "managed", &OwnerReferenceOptions{}, pCtx)
+	r.Error(err)
+	r.Contains(err.Error(), "the owner references across clusters are not supported")
+	_, err = apply("ConfigMap", "ns", "", &OwnerReferenceOptions{}, process.NewContext(process.ContextData{Name: "missing", Namespace: "ns"}))
+	r.Error(err)
+	r.Contains(err.Error(), "failed to get the owner WorkflowRun ns/missing")
+	_, err = apply("ConfigMap", "ns", "", &OwnerReferenceOptions{}, nil)
+	r.Error(err)
+	r.Contains(err.Error(), "the owner WorkflowRun is unknown")
+}
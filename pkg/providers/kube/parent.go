@@ -0,0 +1,52 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/kubevela/workflow/api/v1alpha1"
+	"github.com/kubevela/workflow/pkg/cue/model"
+	"github.com/kubevela/workflow/pkg/cue/process"
+	"github.com/kubevela/workflow/pkg/types"
+)
+
+// setParentAnnotations records the current run and step in the child WorkflowRun applied by the step,
+// so the child run can correlate back with context.parent. The annotations set by the user are kept.
+func setParentAnnotations(workload *unstructured.Unstructured, pCtx process.Context) {
+	gvk := workload.GroupVersionKind()
+	if pCtx == nil || gvk.Group != v1alpha1.Group || gvk.Kind != v1alpha1.WorkflowRunKind {
+		return
+	}
+	annotations := workload.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	if _, ok := annotations[types.AnnotationParentWorkflowRun]; ok {
+		return
+	}
+	annotations[types.AnnotationParentWorkflowRun] = fmt.Sprintf("%v/%v", pCtx.GetData(model.ContextNamespace), pCtx.GetData(model.ContextName))
+	if step, ok := pCtx.GetData(model.ContextStepName).(string); ok && step != "" {
+		annotations[types.AnnotationParentStep] = step
+	}
+	if spanID, ok := pCtx.GetData(model.ContextSpanID).(string); ok && spanID != "" {
+		annotations[types.AnnotationParentSpanID] = spanID
+	}
+	workload.SetAnnotations(annotations)
+}
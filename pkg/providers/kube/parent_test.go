@@ -0,0 +1,87 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/kubevela/workflow/pkg/cue/model"
+	"github.com/kubevela/workflow/pkg/cue/process"
+	providertypes "github.com/kubevela/workflow/pkg/providers/types"
+	"github.com/kubevela/workflow/pkg/types"
+)
+
+func TestSetParentAnnotations(t *testing.T) {
+	pCtx := process.NewContext(process.ContextData{Name: "parent-run", Namespace: "ns"})
+	pCtx.PushData(model.ContextStepName, "deploy")
+	pCtx.PushData(model.ContextSpanID, "trace.1")
+	var applied []*unstructured.Unstructured
+	handlers := &providertypes.KubeHandlers{
+		Apply: func(_ context.Context, _ client.Client, _, _ string, workloads ...*unstructured.Unstructured) error {
+			applied = append(applied, workloads...)
+			return nil
+		},
+	}
+	resource := func(apiVersion, kind string, annotations map[string]string) *unstructured.Unstructured {
+		u := &unstructured.Unstructured{}
+		u.SetAPIVersion(apiVersion)
+		u.SetKind(kind)
+		u.SetName("child")
+		u.SetAnnotations(annotations)
+		return u
+	}
+
+	testCases := map[string]struct {
+		resource *unstructured.Unstructured
+		expected map[string]string
+	}{
+		"child workflow run": {
+			resource: resource("core.oam.dev/v1alpha1", "WorkflowRun", nil),
+			expected: map[string]string{
+				types.AnnotationParentWorkflowRun: "ns/parent-run",
+				types.AnnotationParentStep:        "deploy",
+				types.AnnotationParentSpanID:      "trace.1",
+			},
+		},
+		"parent set by the user": {
+			resource: resource("core.oam.dev/v1alpha1", "WorkflowRun", map[string]string{types.AnnotationParentWorkflowRun: "other/run"}),
+			expected: map[string]string{types.AnnotationParentWorkflowRun: "other/run"},
+		},
+		"other resources": {
+			resource: resource("v1", "ConfigMap", nil),
+			expected: nil,
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			r := require.New(t)
+			applied = nil
+			_, err := Apply(context.Background(), &ResourceParams{
+				Params:        ResourceVars{Resource: tc.resource},
+				RuntimeParams: providertypes.RuntimeParams{KubeHandlers: handlers, ProcessContext: pCtx},
+			})
+			r.NoError(err)
+			r.Len(applied, 1)
+			r.Equal(tc.expected, applied[0].GetAnnotations())
+		})
+	}
+}
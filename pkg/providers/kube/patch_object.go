@@ -0,0 +1,126 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	ktypes "k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	providertypes "github.com/kubevela/workflow/pkg/providers/types"
+)
+
+var patchTypes = map[string]ktypes.PatchType{
+	"merge":     ktypes.MergePatchType,
+	"json":      ktypes.JSONPatchType,
+	"strategic": ktypes.StrategicMergePatchType,
+}
+
+// PatchObjectVars .
+type PatchObjectVars struct {
+	Target  ObjectReference `json:"target"`
+	Type    string          `json:"type"`
+	Patch   json.RawMessage `json:"patch"`
+	Cluster string          `json:"cluster,omitempty"`
+}
+
+// PatchObjectParams .
+type PatchObjectParams = providertypes.Params[PatchObjectVars]
+
+// PatchObject patches the object in the cluster with the merge, json or strategic merge patch, the object is
+// patched in place without reading it first.
+func PatchObject(ctx context.Context, params *PatchObjectParams) (*ResourceReturns, error) {
+	vars := params.Params
+	patchType, ok := patchTypes[vars.Type]
+	if !ok {
+		return nil, fmt.Errorf("unsupported patch type %q, must be one of merge, json and strategic", vars.Type)
+	}
+	if patchType == ktypes.JSONPatchType {
+		if err := validateJSONPatch(vars.Patch); err != nil {
+			return nil, err
+		}
+	} else if !bytes.HasPrefix(bytes.TrimSpace(vars.Patch), []byte("{")) {
+		return nil, fmt.Errorf("invalid %s patch: must be an object", vars.Type)
+	}
+	obj := new(unstructured.Unstructured)
+	obj.SetAPIVersion(vars.Target.APIVersion)
+	obj.SetKind(vars.Target.Kind)
+	obj.SetName(vars.Target.Name)
+	obj.SetNamespace(vars.Target.Namespace)
+	if obj.GetNamespace() == "" {
+		obj.SetNamespace("default")
+	}
+	patchCtx := handleContext(ctx, vars.Cluster)
+	if err := params.KubeClient.Patch(patchCtx, obj, client.RawPatch(patchType, vars.Patch)); err != nil {
+		return nil, err
+	}
+	return &ResourceReturns{
+		Returns: ResourceReturnVars{
+			Resource: obj,
+		},
+	}, nil
+}
+
+// validateJSONPatch validates the structure of the json patch before sending it, so that the errors point to
+// the invalid operations
+func validateJSONPatch(body json.RawMessage) error {
+	var ops []map[string]json.RawMessage
+	if err := json.Unmarshal(body, &ops); err != nil {
+		return fmt.Errorf("invalid json patch: must be a list of operations: %w", err)
+	}
+	if len(ops) == 0 {
+		return fmt.Errorf("invalid json patch: no operation")
+	}
+	pointer := func(op map[string]json.RawMessage, field string) bool {
+		var s string
+		if err := json.Unmarshal(op[field], &s); err != nil {
+			return false
+		}
+		return s == "" || strings.HasPrefix(s, "/")
+	}
+	for i, op := range ops {
+		var kind string
+		_ = json.Unmarshal(op["op"], &kind)
+		var invalid []string
+		if !pointer(op, "path") {
+			invalid = append(invalid, "path")
+		}
+		switch kind {
+		case "add", "replace", "test":
+			if _, ok := op["value"]; !ok {
+				invalid = append(invalid, "value")
+			}
+		case "move", "copy":
+			if !pointer(op, "from") {
+				invalid = append(invalid, "from")
+			}
+		case "remove":
+		default:
+			return fmt.Errorf("invalid json patch operation %d: unsupported op %q, must be one of add, remove, replace, move, copy and test", i, kind)
+		}
+		if len(invalid) > 0 {
+			return fmt.Errorf("invalid json patch operation %d (%s): missing or invalid %s", i, kind, strings.Join(invalid, ", "))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	providertypes "github.com/kubevela/workflow/pkg/providers/types"
+)
+
+func TestPatchObject(t *testing.T) {
+	r := require.New(t)
+	ctx := context.Background()
+	cli := fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).WithObjects(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "cfg", Namespace: "default", Labels: map[string]string{"app": "demo"}},
+		Data:       map[string]string{"a": "1", "b": "2"},
+	}, &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "default"},
+		Spec: corev1.PodSpec{Containers: []corev1.Container{
+			{Name: "main", Image: "nginx:1.0"},
+			{Name: "sidecar", Image: "envoy:1.0"},
+		}},
+	}).Build()
+	patch := func(kind, name, patchType, body string) error {
+		_, err := PatchObject(ctx, &PatchObjectParams{
+			Params: PatchObjectVars{
+				Target: ObjectReference{APIVersion: "v1", Kind: kind, Name: name},
+				Type:   patchType,
+				Patch:  json.RawMessage(body),
+			},
+			RuntimeParams: providertypes.RuntimeParams{KubeClient: cli},
+		})
+		return err
+	}
+	getConfigMap := func() *corev1.ConfigMap {
+		cm := &corev1.ConfigMap{}
+		r.NoError(cli.Get(ctx, client.ObjectKey{Namespace: "default", Name: "cfg"}, cm))
+		return cm
+	}
+
+	r.NoError(patch("ConfigMap", "cfg", "merge", `{"data":{"a":"10","b":null,"c":"3"}}`))
+	r.Equal(map[string]string{"a": "10", "c": "3"}, getConfigMap().Data)
+	r.Equal(map[string]string{"app": "demo"}, getConfigMap().Labels)
+
+	r.NoError(patch("ConfigMap", "cfg", "json", `[{"op":"test","path":"/data/a","value":"10"},{"op":"remove","path":"/data/c"},{"op":"copy","from":"/data/a","path":"/data/d"}]`))
+	r.Equal(map[string]string{"a": "10", "d": "10"}, getConfigMap().Data)
+
+	r.NoError(patch("Pod", "pod", "strategic", `{"spec":{"containers":[{"name":"sidecar","image":"envoy:2.0"}]}}`))
+	pod := &corev1.Pod{}
+	r.NoError(cli.Get(ctx, client.ObjectKey{Namespace: "default", Name: "pod"}, pod))
+	r.Len(pod.Spec.Containers, 2)
+	r.Equal("nginx:1.0", pod.Spec.Containers[0].Image)
+	r.Equal("envoy:2.0", pod.Spec.Containers[1].Image)
+
+	err := patch("ConfigMap", "cfg", "json", `{"op":"remove","path":"/data/a"}`)
+	r.Error(err)
+	r.Contains(err.Error(), "invalid json patch: must be a list of operations")
+	err = patch("ConfigMap", "cfg", "json", `[{"op":"remove","path":"/data/a"},{"op":"add","path":"/data/e"}]`)
+	r.Error(err)
+	r.Equal("invalid json patch operation 1 (add): missing or invalid value", err.Error())
+	err = patch("ConfigMap", "cfg", "json", `[{"op":"upsert","path":"/data/a","value":"1"}]`)
+	r.Error(err)
+	r.Contains(err.Error(), `invalid json patch operation 0: unsupported op "upsert"`)
+	err = patch("ConfigMap", "cfg", "json", `[{"op":"move","path":"data/a"}]`)
+	r.Error(err)
+	r.Equal("invalid json patch operation 0 (move): missing or invalid path, from", err.Error())
+	err = patch("ConfigMap", "cfg", "json", `[]`)
+	r.Error(err)
+	r.Contains(err.Error(), "no operation")
+	err = patch("ConfigMap", "cfg", "merge", `[{"op":"remove","path":"/data/a"}]`)
+	r.Error(err)
+	r.Contains(err.Error(), "invalid merge patch: must be an object")
+	err = patch("ConfigMap", "cfg", "apply", `{}`)
+	r.Error(err)
+	r.Contains(err.Error(), `unsupported patch type "apply"`)
+	r.Equal(map[string]string{"a": "10", "d": "10"}, getConfigMap().Data)
+}
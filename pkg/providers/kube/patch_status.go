@@ -0,0 +1,81 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	ktypes "k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	providertypes "github.com/kubevela/workflow/pkg/providers/types"
+)
+
+// PatchStatusVars .
+type PatchStatusVars struct {
+	Resource *unstructured.Unstructured `json:"value"`
+	Status   map[string]interface{}     `json:"status"`
+	Cluster  string                     `json:"cluster,omitempty"`
+}
+
+// PatchStatusReturnVars .
+type PatchStatusReturnVars struct {
+	Status map[string]interface{} `json:"status"`
+}
+
+// PatchStatusParams .
+type PatchStatusParams = providertypes.Params[PatchStatusVars]
+
+// PatchStatusReturns .
+type PatchStatusReturns = providertypes.Returns[PatchStatusReturnVars]
+
+// PatchStatus merge patches the status subresource of the resource, the spec won't be touched.
+func PatchStatus(ctx context.Context, params *PatchStatusParams) (*PatchStatusReturns, error) {
+	workload := params.Params.Resource
+	if workload.GetNamespace() == "" {
+		workload.SetNamespace("default")
+	}
+	patchCtx := handleContext(ctx, params.Params.Cluster)
+	existing := new(unstructured.Unstructured)
+	existing.SetGroupVersionKind(workload.GroupVersionKind())
+	if err := params.KubeClient.Get(patchCtx, client.ObjectKeyFromObject(workload), existing); err != nil {
+		return nil, err
+	}
+	body, err := json.Marshal(map[string]interface{}{"status": params.Params.Status})
+	if err != nil {
+		return nil, err
+	}
+	if err := params.KubeClient.Status().Patch(patchCtx, existing, client.RawPatch(ktypes.MergePatchType, body)); err != nil {
+		if errors.IsNotFound(err) {
+			return nil, fmt.Errorf("%s %s/%s does not have a status subresource: %w", workload.GetKind(), workload.GetNamespace(), workload.GetName(), err)
+		}
+		return nil, err
+	}
+	status, _, err := unstructured.NestedMap(existing.Object, "status")
+	if err != nil {
+		return nil, err
+	}
+	return &PatchStatusReturns{
+		Returns: PatchStatusReturnVars{
+			Status: status,
+		},
+	}, nil
+}
@@ -0,0 +1,90 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/kubevela/workflow/api/v1alpha1"
+	providertypes "github.com/kubevela/workflow/pkg/providers/types"
+)
+
+func TestPatchStatus(t *testing.T) {
+	r := require.New(t)
+	ctx := context.Background()
+	scheme := runtime.NewScheme()
+	r.NoError(clientgoscheme.AddToScheme(scheme))
+	r.NoError(v1alpha1.AddToScheme(scheme))
+	run := &v1alpha1.WorkflowRun{
+		ObjectMeta: metav1.ObjectMeta{Name: "run", Namespace: "default"},
+		Spec: v1alpha1.WorkflowRunSpec{
+			WorkflowSpec: &v1alpha1.WorkflowSpec{Steps: []v1alpha1.WorkflowStep{{WorkflowStepBase: v1alpha1.WorkflowStepBase{Name: "step", Type: "suspend"}}}},
+		},
+		Status: v1alpha1.WorkflowRunStatus{Message: "origin", Suspend: true},
+	}
+	cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "cm", Namespace: "default"}}
+	cli := fake.NewClientBuilder().WithScheme(scheme).WithObjects(run, cm).WithStatusSubresource(run).Build()
+
+	res, err := PatchStatus(ctx, &PatchStatusParams{
+		Params: PatchStatusVars{
+			Resource: &unstructured.Unstructured{Object: map[string]interface{}{
+				"apiVersion": "core.oam.dev/v1alpha1",
+				"kind":       "WorkflowRun",
+				"metadata":   map[string]interface{}{"name": "run"},
+				"spec":       map[string]interface{}{"mode": map[string]interface{}{"steps": "DAG"}},
+			}},
+			Status: map[string]interface{}{"message": "patched", "status": "executing"},
+		},
+		RuntimeParams: providertypes.RuntimeParams{KubeClient: cli},
+	})
+	r.NoError(err)
+	r.Equal("patched", res.Returns.Status["message"])
+	r.Equal("executing", res.Returns.Status["status"])
+	r.Equal(true, res.Returns.Status["suspend"])
+
+	updated := &v1alpha1.WorkflowRun{}
+	r.NoError(cli.Get(ctx, client.ObjectKeyFromObject(run), updated))
+	r.Equal("patched", updated.Status.Message)
+	r.True(updated.Status.Suspend)
+	// the spec should not be touched
+	r.Nil(updated.Spec.Mode)
+	r.Len(updated.Spec.WorkflowSpec.Steps, 1)
+
+	_, err = PatchStatus(ctx, &PatchStatusParams{
+		Params: PatchStatusVars{
+			Resource: &unstructured.Unstructured{Object: map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "ConfigMap",
+				"metadata":   map[string]interface{}{"name": "cm"},
+			}},
+			Status: map[string]interface{}{"phase": "ready"},
+		},
+		RuntimeParams: providertypes.RuntimeParams{KubeClient: cli},
+	})
+	r.Error(err)
+	r.Contains(err.Error(), "does not have a status subresource")
+}
@@ -0,0 +1,163 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/kubevela/pkg/util/k8s"
+
+	providertypes "github.com/kubevela/workflow/pkg/providers/types"
+)
+
+// PDBVars is the availability intent of the PodDisruptionBudget, one of MinAvailable and MaxUnavailable
+// is required, which can be an absolute number or a percentage.
+type PDBVars struct {
+	Name           string              `json:"name"`
+	Namespace      string              `json:"namespace,omitempty"`
+	Selector       map[string]string   `json:"selector"`
+	MinAvailable   *intstr.IntOrString `json:"minAvailable,omitempty"`
+	MaxUnavailable *intstr.IntOrString `json:"maxUnavailable,omitempty"`
+	Cluster        string              `json:"cluster,omitempty"`
+}
+
+// PDBReturnVars .
+type PDBReturnVars struct {
+	Resource    *unstructured.Unstructured `json:"value"`
+	MatchedPods int                        `json:"matchedPods"`
+}
+
+// PDBParams .
+type PDBParams = providertypes.Params[PDBVars]
+
+// PDBReturns .
+type PDBReturns = providertypes.Returns[PDBReturnVars]
+
+// PDB renders the PodDisruptionBudget from the availability intent and applies it. It warns if no pods
+// match the selector or the budget allows no voluntary disruptions of the matched pods.
+func PDB(ctx context.Context, params *PDBParams) (*PDBReturns, error) {
+	spec := params.Params
+	if errs := validation.IsDNS1123Subdomain(spec.Name); len(errs) > 0 {
+		return nil, fmt.Errorf("invalid pdb name %q: %v", spec.Name, errs)
+	}
+	if spec.Namespace == "" {
+		spec.Namespace = "default"
+	}
+	if len(spec.Selector) == 0 {
+		return nil, fmt.Errorf("the selector of pdb %s is required", spec.Name)
+	}
+	if (spec.MinAvailable == nil) == (spec.MaxUnavailable == nil) {
+		return nil, fmt.Errorf("exactly one of minAvailable and maxUnavailable of pdb %s is required", spec.Name)
+	}
+	for field, value := range map[string]*intstr.IntOrString{"minAvailable": spec.MinAvailable, "maxUnavailable": spec.MaxUnavailable} {
+		if err := validateAvailability(value); err != nil {
+			return nil, fmt.Errorf("invalid %s of pdb %s: %w", field, spec.Name, err)
+		}
+	}
+	pdbCtx := handleContext(ctx, spec.Cluster)
+	pods := &corev1.PodList{}
+	if err := params.KubeClient.List(pdbCtx, pods, client.InNamespace(spec.Namespace), client.MatchingLabels(spec.Selector)); err != nil {
+		return nil, err
+	}
+
+	obj := &policyv1.PodDisruptionBudget{
+		TypeMeta:   metav1.TypeMeta{APIVersion: policyv1.SchemeGroupVersion.String(), Kind: "PodDisruptionBudget"},
+		ObjectMeta: metav1.ObjectMeta{Name: spec.Name, Namespace: spec.Namespace},
+		Spec: policyv1.PodDisruptionBudgetSpec{
+			Selector:       &metav1.LabelSelector{MatchLabels: spec.Selector},
+			MinAvailable:   spec.MinAvailable,
+			MaxUnavailable: spec.MaxUnavailable,
+		},
+	}
+	u, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return nil, err
+	}
+	workload := &unstructured.Unstructured{Object: u}
+	unstructured.RemoveNestedField(workload.Object, "status")
+	unstructured.RemoveNestedField(workload.Object, "metadata", "creationTimestamp")
+	for k, v := range params.RuntimeParams.Labels {
+		if err := k8s.AddLabel(workload, k, v); err != nil {
+			return nil, err
+		}
+	}
+	handlers := getHandlers(params.RuntimeParams)
+	if err := handlers.Apply(pdbCtx, params.KubeClient, spec.Cluster, WorkflowResourceCreator, workload); err != nil {
+		return nil, err
+	}
+
+	var warnings []string
+	selector := labels.SelectorFromSet(spec.Selector).String()
+	if count := len(pods.Items); count == 0 {
+		warnings = append(warnings, fmt.Sprintf("No pods in namespace %s match the selector %s of PodDisruptionBudget %s", spec.Namespace, selector, spec.Name))
+	} else if allowedDisruptions(spec, count) <= 0 {
+		warnings = append(warnings, fmt.Sprintf("PodDisruptionBudget %s/%s allows no voluntary disruptions of the %d pods matching %s", spec.Namespace, spec.Name, count, selector))
+	}
+	return &PDBReturns{
+		Returns: PDBReturnVars{
+			Resource:    workload,
+			MatchedPods: len(pods.Items),
+		},
+		Warnings: warnings,
+	}, nil
+}
+
+func validateAvailability(value *intstr.IntOrString) error {
+	if value == nil {
+		return nil
+	}
+	if value.Type == intstr.Int {
+		if value.IntVal < 0 {
+			return fmt.Errorf("%d must be non-negative", value.IntVal)
+		}
+		return nil
+	}
+	if !strings.HasSuffix(value.StrVal, "%") {
+		return fmt.Errorf("%q must be an integer or a percentage", value.StrVal)
+	}
+	percent, err := intstr.GetScaledValueFromIntOrPercent(value, 100, true)
+	if err != nil {
+		return err
+	}
+	if percent < 0 || percent > 100 {
+		return fmt.Errorf("%q must be between 0%% and 100%%", value.StrVal)
+	}
+	return nil
+}
+
+// allowedDisruptions returns the number of the pods that can be disrupted, the percentage is rounded up
+// to the number of pods in the same way as the disruption controller.
+func allowedDisruptions(spec PDBVars, count int) int {
+	if spec.MaxUnavailable != nil {
+		maxUnavailable, _ := intstr.GetScaledValueFromIntOrPercent(spec.MaxUnavailable, count, true)
+		return maxUnavailable
+	}
+	minAvailable, _ := intstr.GetScaledValueFromIntOrPercent(spec.MinAvailable, count, true)
+	return count - minAvailable
+}
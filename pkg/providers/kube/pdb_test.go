@@ -0,0 +1,121 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/kubevela/workflow/pkg/mock"
+	providertypes "github.com/kubevela/workflow/pkg/providers/types"
+)
+
+func TestPDB(t *testing.T) {
+	ctx := context.Background()
+	var pods []client.Object
+	for i := 0; i < 3; i++ {
+		pods = append(pods, &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("web-%d", i),
+			Namespace: "default",
+			Labels:    map[string]string{"app": "web"},
+		}})
+	}
+	cli := fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).WithObjects(pods...).Build()
+	pdb := func(vars PDBVars) (*PDBReturns, error) {
+		return PDB(ctx, &PDBParams{
+			Params:        vars,
+			RuntimeParams: providertypes.RuntimeParams{KubeClient: cli, Action: &mock.Action{}, Labels: map[string]string{"workflowrun.oam.dev/name": "run"}},
+		})
+	}
+	percent, absolute := intstr.FromString("50%"), intstr.FromInt32(2)
+
+	t.Run("percent intent", func(t *testing.T) {
+		r := require.New(t)
+		res, err := pdb(PDBVars{Name: "web", Selector: map[string]string{"app": "web"}, MinAvailable: &percent})
+		r.NoError(err)
+		r.Equal(3, res.Returns.MatchedPods)
+		r.Empty(res.Warnings)
+		r.Equal("PodDisruptionBudget", res.Returns.Resource.GetKind())
+		r.Equal("policy/v1", res.Returns.Resource.GetAPIVersion())
+		applied := &policyv1.PodDisruptionBudget{}
+		r.NoError(cli.Get(ctx, client.ObjectKey{Namespace: "default", Name: "web"}, applied))
+		r.Equal(&percent, applied.Spec.MinAvailable)
+		r.Nil(applied.Spec.MaxUnavailable)
+		r.Equal(map[string]string{"app": "web"}, applied.Spec.Selector.MatchLabels)
+		r.Equal("run", applied.Labels["workflowrun.oam.dev/name"])
+	})
+
+	t.Run("absolute intent", func(t *testing.T) {
+		r := require.New(t)
+		res, err := pdb(PDBVars{Name: "web-absolute", Namespace: "default", Selector: map[string]string{"app": "web"}, MaxUnavailable: &absolute})
+		r.NoError(err)
+		r.Empty(res.Warnings)
+		applied := &policyv1.PodDisruptionBudget{}
+		r.NoError(cli.Get(ctx, client.ObjectKey{Namespace: "default", Name: "web-absolute"}, applied))
+		r.Equal(&absolute, applied.Spec.MaxUnavailable)
+		r.Nil(applied.Spec.MinAvailable)
+	})
+
+	t.Run("warnings", func(t *testing.T) {
+		r := require.New(t)
+		res, err := pdb(PDBVars{Name: "api", Selector: map[string]string{"app": "api"}, MinAvailable: &absolute})
+		r.NoError(err)
+		r.Equal(0, res.Returns.MatchedPods)
+		r.Equal([]string{"No pods in namespace default match the selector app=api of PodDisruptionBudget api"}, res.Warnings)
+
+		all := intstr.FromString("100%")
+		res, err = pdb(PDBVars{Name: "web", Selector: map[string]string{"app": "web"}, MinAvailable: &all})
+		r.NoError(err)
+		r.Equal([]string{"PodDisruptionBudget default/web allows no voluntary disruptions of the 3 pods matching app=web"}, res.Warnings)
+
+		three := intstr.FromInt32(3)
+		res, err = pdb(PDBVars{Name: "web", Selector: map[string]string{"app": "web"}, MinAvailable: &three})
+		r.NoError(err)
+		r.Len(res.Warnings, 1)
+	})
+
+	t.Run("invalid intent", func(t *testing.T) {
+		r := require.New(t)
+		negative, overflow, invalid := intstr.FromInt32(-1), intstr.FromString("120%"), intstr.FromString("half")
+		testCases := map[string]struct {
+			vars PDBVars
+			err  string
+		}{
+			"no selector": {vars: PDBVars{Name: "web", MinAvailable: &percent}, err: "the selector of pdb web is required"},
+			"no intent":   {vars: PDBVars{Name: "web", Selector: map[string]string{"app": "web"}}, err: "exactly one of minAvailable and maxUnavailable"},
+			"both intent": {vars: PDBVars{Name: "web", Selector: map[string]string{"app": "web"}, MinAvailable: &percent, MaxUnavailable: &absolute}, err: "exactly one of minAvailable and maxUnavailable"},
+			"negative":    {vars: PDBVars{Name: "web", Selector: map[string]string{"app": "web"}, MinAvailable: &negative}, err: "invalid minAvailable of pdb web: -1 must be non-negative"},
+			"overflow":    {vars: PDBVars{Name: "web", Selector: map[string]string{"app": "web"}, MaxUnavailable: &overflow}, err: `invalid maxUnavailable of pdb web: "120%" must be between 0% and 100%`},
+			"not percent": {vars: PDBVars{Name: "web", Selector: map[string]string{"app": "web"}, MinAvailable: &invalid}, err: `"half" must be an integer or a percentage`},
+			"bad name":    {vars: PDBVars{Name: "Web", Selector: map[string]string{"app": "web"}, MinAvailable: &percent}, err: "invalid pdb name"},
+		}
+		for name, tc := range testCases {
+			_, err := pdb(tc.vars)
+			r.ErrorContains(err, tc.err, name)
+		}
+	})
+}
@@ -0,0 +1,187 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/kubevela/pkg/util/k8s"
+
+	wferrors "github.com/kubevela/workflow/pkg/errors"
+	providertypes "github.com/kubevela/workflow/pkg/providers/types"
+)
+
+// QuotaLimit is the simplified limit of a LimitRange
+type QuotaLimit struct {
+	Type           string            `json:"type"`
+	Max            map[string]string `json:"max,omitempty"`
+	Min            map[string]string `json:"min,omitempty"`
+	Default        map[string]string `json:"default,omitempty"`
+	DefaultRequest map[string]string `json:"defaultRequest,omitempty"`
+}
+
+// QuotaVars is the simplified spec of the ResourceQuota or LimitRange
+type QuotaVars struct {
+	Kind      string            `json:"kind,omitempty"`
+	Name      string            `json:"name"`
+	Namespace string            `json:"namespace,omitempty"`
+	Hard      map[string]string `json:"hard,omitempty"`
+	Limits    []QuotaLimit      `json:"limits,omitempty"`
+	Cluster   string            `json:"cluster,omitempty"`
+}
+
+// QuotaReturnVars .
+type QuotaReturnVars struct {
+	Resource *unstructured.Unstructured `json:"value"`
+	Used     map[string]string          `json:"used,omitempty"`
+}
+
+// QuotaParams .
+type QuotaParams = providertypes.Params[QuotaVars]
+
+// QuotaReturns .
+type QuotaReturns = providertypes.Returns[QuotaReturnVars]
+
+// Quota creates or updates the ResourceQuota or LimitRange from the simplified spec. The step fails
+// if the current usage of the existing ResourceQuota exceeds the new hard limits.
+func Quota(ctx context.Context, params *QuotaParams) (*QuotaReturns, error) {
+	spec := params.Params
+	if errs := validation.IsDNS1123Subdomain(spec.Name); len(errs) > 0 {
+		return nil, fmt.Errorf("invalid quota name %q: %v", spec.Name, errs)
+	}
+	if spec.Namespace == "" {
+		spec.Namespace = "default"
+	}
+	quotaCtx := handleContext(ctx, spec.Cluster)
+	var obj runtime.Object
+	var used map[string]string
+	switch spec.Kind {
+	case "", "ResourceQuota":
+		hard, err := parseResourceList(spec.Hard)
+		if err != nil {
+			return nil, fmt.Errorf("invalid hard of quota %s: %w", spec.Name, err)
+		}
+		existing := &corev1.ResourceQuota{}
+		if err := params.KubeClient.Get(quotaCtx, client.ObjectKey{Namespace: spec.Namespace, Name: spec.Name}, existing); err != nil && !errors.IsNotFound(err) {
+			return nil, err
+		}
+		if len(existing.Status.Used) > 0 {
+			used = map[string]string{}
+			for name, quantity := range existing.Status.Used {
+				used[string(name)] = quantity.String()
+			}
+		}
+		if conflicts := quotaConflicts(existing.Status.Used, hard); len(conflicts) > 0 {
+			params.Action.Fail(fmt.Sprintf("The current usage of ResourceQuota %s/%s exceeds the new quota: %s", spec.Namespace, spec.Name, strings.Join(conflicts, ", ")))
+			return nil, wferrors.GenericActionError(wferrors.ActionTerminate)
+		}
+		obj = &corev1.ResourceQuota{
+			TypeMeta:   metav1.TypeMeta{APIVersion: corev1.SchemeGroupVersion.String(), Kind: "ResourceQuota"},
+			ObjectMeta: metav1.ObjectMeta{Name: spec.Name, Namespace: spec.Namespace},
+			Spec:       corev1.ResourceQuotaSpec{Hard: hard},
+		}
+	case "LimitRange":
+		limitRange := &corev1.LimitRange{
+			TypeMeta:   metav1.TypeMeta{APIVersion: corev1.SchemeGroupVersion.String(), Kind: "LimitRange"},
+			ObjectMeta: metav1.ObjectMeta{Name: spec.Name, Namespace: spec.Namespace},
+		}
+		for _, limit := range spec.Limits {
+			item := corev1.LimitRangeItem{Type: corev1.LimitType(limit.Type)}
+			var err error
+			for _, l := range []struct {
+				field string
+				value map[string]string
+				list  *corev1.ResourceList
+			}{
+				{"max", limit.Max, &item.Max},
+				{"min", limit.Min, &item.Min},
+				{"default", limit.Default, &item.Default},
+				{"defaultRequest", limit.DefaultRequest, &item.DefaultRequest},
+			} {
+				if *l.list, err = parseResourceList(l.value); err != nil {
+					return nil, fmt.Errorf("invalid %s of limit range %s: %w", l.field, spec.Name, err)
+				}
+			}
+			limitRange.Spec.Limits = append(limitRange.Spec.Limits, item)
+		}
+		obj = limitRange
+	default:
+		return nil, fmt.Errorf("unsupported quota kind %s, only ResourceQuota and LimitRange are supported", spec.Kind)
+	}
+
+	u, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return nil, err
+	}
+	workload := &unstructured.Unstructured{Object: u}
+	unstructured.RemoveNestedField(workload.Object, "status")
+	unstructured.RemoveNestedField(workload.Object, "metadata", "creationTimestamp")
+	for k, v := range params.RuntimeParams.Labels {
+		if err := k8s.AddLabel(workload, k, v); err != nil {
+			return nil, err
+		}
+	}
+	handlers := getHandlers(params.RuntimeParams)
+	if err := handlers.Apply(quotaCtx, params.KubeClient, spec.Cluster, WorkflowResourceCreator, workload); err != nil {
+		return nil, err
+	}
+	return &QuotaReturns{
+		Returns: QuotaReturnVars{
+			Resource: workload,
+			Used:     used,
+		},
+	}, nil
+}
+
+func parseResourceList(in map[string]string) (corev1.ResourceList, error) {
+	if len(in) == 0 {
+		return nil, nil
+	}
+	list := corev1.ResourceList{}
+	for name, quantity := range in {
+		q, err := resource.ParseQuantity(quantity)
+		if err != nil {
+			return nil, fmt.Errorf("invalid quantity %q of %s: %w", quantity, name, err)
+		}
+		list[corev1.ResourceName(name)] = q
+	}
+	return list, nil
+}
+
+// quotaConflicts returns the resources whose usage exceeds the hard limits, sorted by the names.
+func quotaConflicts(used, hard corev1.ResourceList) []string {
+	var conflicts []string
+	for name, limit := range hard {
+		if usage, ok := used[name]; ok && usage.Cmp(limit) > 0 {
+			conflicts = append(conflicts, fmt.Sprintf("%s used %s, hard %s", name, usage.String(), limit.String()))
+		}
+	}
+	sort.Strings(conflicts)
+	return conflicts
+}
@@ -0,0 +1,124 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/kubevela/workflow/pkg/errors"
+	"github.com/kubevela/workflow/pkg/mock"
+	providertypes "github.com/kubevela/workflow/pkg/providers/types"
+)
+
+func TestQuota(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("create and update resource quota", func(t *testing.T) {
+		r := require.New(t)
+		cli := fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).WithStatusSubresource(&corev1.ResourceQuota{}).Build()
+		params := &QuotaParams{
+			Params:        QuotaVars{Name: "team", Namespace: "team-a", Hard: map[string]string{"cpu": "4", "pods": "10"}},
+			RuntimeParams: providertypes.RuntimeParams{KubeClient: cli, Action: &mock.Action{}, Labels: map[string]string{"workflowrun.oam.dev/name": "run"}},
+		}
+		res, err := Quota(ctx, params)
+		r.NoError(err)
+		r.Equal("ResourceQuota", res.Returns.Resource.GetKind())
+		r.Nil(res.Returns.Used)
+		quota := &corev1.ResourceQuota{}
+		r.NoError(cli.Get(ctx, client.ObjectKey{Namespace: "team-a", Name: "team"}, quota))
+		r.Equal(resource.MustParse("4"), quota.Spec.Hard[corev1.ResourceCPU])
+		r.Equal("run", quota.Labels["workflowrun.oam.dev/name"])
+
+		quota.Status.Used = corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("2"), corev1.ResourcePods: resource.MustParse("3")}
+		r.NoError(cli.Status().Update(ctx, quota))
+		params.Params.Hard = map[string]string{"cpu": "2", "pods": "5", "memory": "8Gi"}
+		res, err = Quota(ctx, params)
+		r.NoError(err)
+		r.Equal(map[string]string{"cpu": "2", "pods": "3"}, res.Returns.Used)
+		r.NoError(cli.Get(ctx, client.ObjectKey{Namespace: "team-a", Name: "team"}, quota))
+		r.Equal(resource.MustParse("5"), quota.Spec.Hard[corev1.ResourcePods])
+		r.Equal(resource.MustParse("8Gi"), quota.Spec.Hard[corev1.ResourceMemory])
+	})
+
+	t.Run("over usage conflict", func(t *testing.T) {
+		r := require.New(t)
+		existing := &corev1.ResourceQuota{
+			ObjectMeta: metav1.ObjectMeta{Name: "team", Namespace: "default"},
+			Spec:       corev1.ResourceQuotaSpec{Hard: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("4")}},
+			Status: corev1.ResourceQuotaStatus{Used: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("3"),
+				corev1.ResourceMemory: resource.MustParse("2Gi"),
+			}},
+		}
+		cli := fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).WithObjects(existing).Build()
+		act := &mock.Action{}
+		_, err := Quota(ctx, &QuotaParams{
+			Params:        QuotaVars{Name: "team", Hard: map[string]string{"cpu": "2", "memory": "1Gi"}},
+			RuntimeParams: providertypes.RuntimeParams{KubeClient: cli, Action: act},
+		})
+		r.Equal(errors.GenericActionError(errors.ActionTerminate), err)
+		r.Equal("Fail", act.Phase)
+		r.Equal("The current usage of ResourceQuota default/team exceeds the new quota: cpu used 3, hard 2, memory used 2Gi, hard 1Gi", act.Msg)
+		quota := &corev1.ResourceQuota{}
+		r.NoError(cli.Get(ctx, client.ObjectKey{Namespace: "default", Name: "team"}, quota))
+		r.Equal(resource.MustParse("4"), quota.Spec.Hard[corev1.ResourceCPU])
+	})
+
+	t.Run("create limit range", func(t *testing.T) {
+		r := require.New(t)
+		cli := fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).Build()
+		_, err := Quota(ctx, &QuotaParams{
+			Params: QuotaVars{Kind: "LimitRange", Name: "limits", Limits: []QuotaLimit{{
+				Type:           "Container",
+				Max:            map[string]string{"cpu": "2"},
+				Default:        map[string]string{"cpu": "500m", "memory": "256Mi"},
+				DefaultRequest: map[string]string{"cpu": "100m"},
+			}}},
+			RuntimeParams: providertypes.RuntimeParams{KubeClient: cli, Action: &mock.Action{}},
+		})
+		r.NoError(err)
+		limitRange := &corev1.LimitRange{}
+		r.NoError(cli.Get(ctx, client.ObjectKey{Namespace: "default", Name: "limits"}, limitRange))
+		r.Len(limitRange.Spec.Limits, 1)
+		r.Equal(corev1.LimitTypeContainer, limitRange.Spec.Limits[0].Type)
+		r.Equal(resource.MustParse("256Mi"), limitRange.Spec.Limits[0].Default[corev1.ResourceMemory])
+		r.Nil(limitRange.Spec.Limits[0].Min)
+	})
+
+	t.Run("invalid spec", func(t *testing.T) {
+		r := require.New(t)
+		cli := fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).Build()
+		for _, vars := range []QuotaVars{
+			{Name: "Invalid_Name"},
+			{Name: "team", Kind: "PriorityClass"},
+			{Name: "team", Hard: map[string]string{"cpu": "a lot"}},
+			{Name: "team", Kind: "LimitRange", Limits: []QuotaLimit{{Type: "Pod", Min: map[string]string{"cpu": "-x"}}}},
+		} {
+			_, err := Quota(ctx, &QuotaParams{Params: vars, RuntimeParams: providertypes.RuntimeParams{KubeClient: cli, Action: &mock.Action{}}})
+			r.Error(err)
+		}
+	})
+}
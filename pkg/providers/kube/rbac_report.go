@@ -0,0 +1,267 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	providertypes "github.com/kubevela/workflow/pkg/providers/types"
+)
+
+// RBACSubject is the service account to compute the permissions for.
+type RBACSubject struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// RBACReportVars .
+type RBACReportVars struct {
+	ServiceAccount RBACSubject `json:"serviceAccount"`
+	Cluster        string      `json:"cluster,omitempty"`
+}
+
+// RBACBinding is a binding granting permissions to the service account.
+type RBACBinding struct {
+	Kind      string         `json:"kind"`
+	Name      string         `json:"name"`
+	Namespace string         `json:"namespace,omitempty"`
+	RoleRef   rbacv1.RoleRef `json:"roleRef"`
+	// Missing indicates that the referenced role does not exist
+	Missing bool `json:"missing,omitempty"`
+}
+
+// RBACPermission is an entry of the permission matrix, an empty namespace means cluster-wide.
+type RBACPermission struct {
+	Namespace      string   `json:"namespace,omitempty"`
+	APIGroup       string   `json:"apiGroup"`
+	Resource       string   `json:"resource,omitempty"`
+	ResourceNames  []string `json:"resourceNames,omitempty"`
+	NonResourceURL string   `json:"nonResourceURL,omitempty"`
+	Verbs          []string `json:"verbs"`
+}
+
+// RBACReportReturnVars .
+type RBACReportReturnVars struct {
+	Bindings    []RBACBinding    `json:"bindings"`
+	Permissions []RBACPermission `json:"permissions"`
+}
+
+// RBACReportParams .
+type RBACReportParams = providertypes.Params[RBACReportVars]
+
+// RBACReportReturns .
+type RBACReportReturns = providertypes.Returns[RBACReportReturnVars]
+
+// RBACReport computes the effective permissions of the service account from the
+// RoleBindings and ClusterRoleBindings, the aggregated ClusterRoles are resolved
+// by their aggregation rules.
+func RBACReport(ctx context.Context, params *RBACReportParams) (*RBACReportReturns, error) {
+	sa := params.Params.ServiceAccount
+	if sa.Name == "" {
+		return nil, fmt.Errorf("the name of the service account is required")
+	}
+	if sa.Namespace == "" {
+		sa.Namespace = "default"
+	}
+	cli := params.KubeClient
+	reportCtx := handleContext(ctx, params.Params.Cluster)
+
+	clusterRoles := &rbacv1.ClusterRoleList{}
+	if err := cli.List(reportCtx, clusterRoles); err != nil {
+		return nil, err
+	}
+	crbs := &rbacv1.ClusterRoleBindingList{}
+	if err := cli.List(reportCtx, crbs); err != nil {
+		return nil, err
+	}
+	rbs := &rbacv1.RoleBindingList{}
+	if err := cli.List(reportCtx, rbs); err != nil {
+		return nil, err
+	}
+
+	matrix := newPermissionMatrix()
+	var bindings []RBACBinding
+	for _, crb := range crbs.Items {
+		if !bindsServiceAccount(crb.Subjects, "", sa) {
+			continue
+		}
+		binding := RBACBinding{Kind: "ClusterRoleBinding", Name: crb.Name, RoleRef: crb.RoleRef}
+		rules, found := resolveClusterRole(clusterRoles.Items, crb.RoleRef.Name)
+		binding.Missing = !found
+		matrix.add("", rules)
+		bindings = append(bindings, binding)
+	}
+	for _, rb := range rbs.Items {
+		if !bindsServiceAccount(rb.Subjects, rb.Namespace, sa) {
+			continue
+		}
+		binding := RBACBinding{Kind: "RoleBinding", Name: rb.Name, Namespace: rb.Namespace, RoleRef: rb.RoleRef}
+		var rules []rbacv1.PolicyRule
+		found := true
+		switch rb.RoleRef.Kind {
+		case "ClusterRole":
+			rules, found = resolveClusterRole(clusterRoles.Items, rb.RoleRef.Name)
+		default:
+			role := &rbacv1.Role{}
+			if err := cli.Get(reportCtx, client.ObjectKey{Namespace: rb.Namespace, Name: rb.RoleRef.Name}, role); err != nil {
+				if !errors.IsNotFound(err) {
+					return nil, err
+				}
+				found = false
+			}
+			rules = role.Rules
+		}
+		binding.Missing = !found
+		// non-resource urls are only granted by cluster role bindings
+		matrix.add(rb.Namespace, resourceRules(rules))
+		bindings = append(bindings, binding)
+	}
+	return &RBACReportReturns{
+		Returns: RBACReportReturnVars{
+			Bindings:    bindings,
+			Permissions: matrix.list(),
+		},
+	}, nil
+}
+
+func bindsServiceAccount(subjects []rbacv1.Subject, bindingNamespace string, sa RBACSubject) bool {
+	for _, subject := range subjects {
+		switch subject.Kind {
+		case rbacv1.ServiceAccountKind:
+			ns := subject.Namespace
+			if ns == "" {
+				ns = bindingNamespace
+			}
+			if subject.Name == sa.Name && ns == sa.Namespace {
+				return true
+			}
+		case rbacv1.GroupKind:
+			switch subject.Name {
+			case "system:serviceaccounts", "system:serviceaccounts:" + sa.Namespace, "system:authenticated":
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// resolveClusterRole returns the rules of the cluster role, the aggregated cluster roles are resolved recursively.
+func resolveClusterRole(clusterRoles []rbacv1.ClusterRole, name string) ([]rbacv1.PolicyRule, bool) {
+	visited := sets.New[string]()
+	var resolve func(role rbacv1.ClusterRole) []rbacv1.PolicyRule
+	resolve = func(role rbacv1.ClusterRole) []rbacv1.PolicyRule {
+		if visited.Has(role.Name) {
+			return nil
+		}
+		visited.Insert(role.Name)
+		rules := append([]rbacv1.PolicyRule{}, role.Rules...)
+		if role.AggregationRule == nil {
+			return rules
+		}
+		for _, selector := range role.AggregationRule.ClusterRoleSelectors {
+			s, err := metav1.LabelSelectorAsSelector(&selector)
+			if err != nil {
+				continue
+			}
+			for _, candidate := range clusterRoles {
+				if candidate.Name != role.Name && s.Matches(labels.Set(candidate.Labels)) {
+					rules = append(rules, resolve(candidate)...)
+				}
+			}
+		}
+		return rules
+	}
+	for _, role := range clusterRoles {
+		if role.Name == name {
+			return resolve(role), true
+		}
+	}
+	return nil, false
+}
+
+func resourceRules(rules []rbacv1.PolicyRule) []rbacv1.PolicyRule {
+	var filtered []rbacv1.PolicyRule
+	for _, rule := range rules {
+		if len(rule.Resources) > 0 {
+			filtered = append(filtered, rule)
+		}
+	}
+	return filtered
+}
+
+type permissionMatrix struct {
+	keys        []string
+	permissions map[string]*RBACPermission
+	verbs       map[string]sets.Set[string]
+}
+
+func newPermissionMatrix() *permissionMatrix {
+	return &permissionMatrix{permissions: map[string]*RBACPermission{}, verbs: map[string]sets.Set[string]{}}
+}
+
+func (m *permissionMatrix) add(namespace string, rules []rbacv1.PolicyRule) {
+	for _, rule := range rules {
+		var names []string
+		if len(rule.ResourceNames) > 0 {
+			names = append(names, rule.ResourceNames...)
+			sort.Strings(names)
+		}
+		for _, url := range rule.NonResourceURLs {
+			m.insert(RBACPermission{NonResourceURL: url}, rule.Verbs)
+		}
+		groups := rule.APIGroups
+		if len(rule.Resources) > 0 && len(groups) == 0 {
+			groups = []string{""}
+		}
+		for _, group := range groups {
+			for _, resource := range rule.Resources {
+				m.insert(RBACPermission{Namespace: namespace, APIGroup: group, Resource: resource, ResourceNames: names}, rule.Verbs)
+			}
+		}
+	}
+}
+
+func (m *permissionMatrix) insert(permission RBACPermission, verbs []string) {
+	key := strings.Join([]string{permission.Namespace, permission.APIGroup, permission.Resource, strings.Join(permission.ResourceNames, ","), permission.NonResourceURL}, "\x00")
+	if _, ok := m.permissions[key]; !ok {
+		m.keys = append(m.keys, key)
+		m.permissions[key] = &permission
+		m.verbs[key] = sets.New[string]()
+	}
+	m.verbs[key].Insert(verbs...)
+}
+
+func (m *permissionMatrix) list() []RBACPermission {
+	sort.Strings(m.keys)
+	permissions := make([]RBACPermission, 0, len(m.keys))
+	for _, key := range m.keys {
+		permission := *m.permissions[key]
+		permission.Verbs = sets.List(m.verbs[key])
+		permissions = append(permissions, permission)
+	}
+	return permissions
+}
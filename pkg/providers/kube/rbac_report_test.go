@@ -0,0 +1,114 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	providertypes "github.com/kubevela/workflow/pkg/providers/types"
+)
+
+func TestRBACReport(t *testing.T) {
+	r := require.New(t)
+	ctx := context.Background()
+	sa := rbacv1.Subject{Kind: rbacv1.ServiceAccountKind, Name: "deployer", Namespace: "ci"}
+	objects := []client.Object{
+		&rbacv1.ClusterRole{
+			ObjectMeta: metav1.ObjectMeta{Name: "aggregated"},
+			AggregationRule: &rbacv1.AggregationRule{ClusterRoleSelectors: []metav1.LabelSelector{
+				{MatchLabels: map[string]string{"rbac.example.com/aggregate-to-aggregated": "true"}},
+			}},
+		},
+		&rbacv1.ClusterRole{
+			ObjectMeta: metav1.ObjectMeta{Name: "view-pods", Labels: map[string]string{"rbac.example.com/aggregate-to-aggregated": "true"}},
+			Rules: []rbacv1.PolicyRule{
+				{APIGroups: []string{""}, Resources: []string{"pods", "pods/log"}, Verbs: []string{"get", "list"}},
+				{NonResourceURLs: []string{"/healthz"}, Verbs: []string{"get"}},
+			},
+		},
+		&rbacv1.ClusterRole{
+			ObjectMeta: metav1.ObjectMeta{Name: "edit-deployments"},
+			Rules: []rbacv1.PolicyRule{
+				{APIGroups: []string{"apps"}, Resources: []string{"deployments"}, Verbs: []string{"update", "get"}},
+				{NonResourceURLs: []string{"/metrics"}, Verbs: []string{"get"}},
+			},
+		},
+		&rbacv1.Role{
+			ObjectMeta: metav1.ObjectMeta{Name: "config", Namespace: "prod"},
+			Rules: []rbacv1.PolicyRule{
+				{Resources: []string{"configmaps"}, ResourceNames: []string{"b", "a"}, Verbs: []string{"get"}},
+				{APIGroups: []string{""}, Resources: []string{"configmaps"}, ResourceNames: []string{"a", "b"}, Verbs: []string{"patch"}},
+			},
+		},
+		&rbacv1.ClusterRoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "aggregated"},
+			Subjects:   []rbacv1.Subject{sa},
+			RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: "aggregated"},
+		},
+		&rbacv1.ClusterRoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "other"},
+			Subjects:   []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Name: "deployer", Namespace: "default"}},
+			RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: "edit-deployments"},
+		},
+		&rbacv1.RoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "deployments", Namespace: "prod"},
+			Subjects:   []rbacv1.Subject{sa},
+			RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: "edit-deployments"},
+		},
+		&rbacv1.RoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "config", Namespace: "prod"},
+			Subjects:   []rbacv1.Subject{{Kind: rbacv1.GroupKind, Name: "system:serviceaccounts:ci"}},
+			RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "Role", Name: "config"},
+		},
+		&rbacv1.RoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "missing", Namespace: "ci"},
+			Subjects:   []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Name: "deployer"}},
+			RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "Role", Name: "missing"},
+		},
+	}
+	cli := fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).WithObjects(objects...).Build()
+
+	res, err := RBACReport(ctx, &RBACReportParams{
+		Params:        RBACReportVars{ServiceAccount: RBACSubject{Name: "deployer", Namespace: "ci"}},
+		RuntimeParams: providertypes.RuntimeParams{KubeClient: cli},
+	})
+	r.NoError(err)
+	r.Equal([]RBACBinding{
+		{Kind: "ClusterRoleBinding", Name: "aggregated", RoleRef: rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: "aggregated"}},
+		{Kind: "RoleBinding", Name: "missing", Namespace: "ci", RoleRef: rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "Role", Name: "missing"}, Missing: true},
+		{Kind: "RoleBinding", Name: "config", Namespace: "prod", RoleRef: rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "Role", Name: "config"}},
+		{Kind: "RoleBinding", Name: "deployments", Namespace: "prod", RoleRef: rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: "edit-deployments"}},
+	}, res.Returns.Bindings)
+	r.Equal([]RBACPermission{
+		{APIGroup: "", NonResourceURL: "/healthz", Verbs: []string{"get"}},
+		{APIGroup: "", Resource: "pods", Verbs: []string{"get", "list"}},
+		{APIGroup: "", Resource: "pods/log", Verbs: []string{"get", "list"}},
+		{Namespace: "prod", APIGroup: "", Resource: "configmaps", ResourceNames: []string{"a", "b"}, Verbs: []string{"get", "patch"}},
+		{Namespace: "prod", APIGroup: "apps", Resource: "deployments", Verbs: []string{"get", "update"}},
+	}, res.Returns.Permissions)
+
+	_, err = RBACReport(ctx, &RBACReportParams{RuntimeParams: providertypes.RuntimeParams{KubeClient: cli}})
+	r.Error(err)
+}
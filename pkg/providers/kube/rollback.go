@@ -0,0 +1,279 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/kubevela/workflow/pkg/cue/model"
+	wferrors "github.com/kubevela/workflow/pkg/errors"
+	providertypes "github.com/kubevela/workflow/pkg/providers/types"
+)
+
+const (
+	// deploymentRevisionAnnotation is the revision annotation of the ReplicaSets of a Deployment
+	deploymentRevisionAnnotation = "deployment.kubernetes.io/revision"
+	rollbackStateKey             = "rollback"
+)
+
+// RollbackTarget is the workload to roll back, the previous revision is used if the revision is not set.
+type RollbackTarget struct {
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"`
+	Revision  int64  `json:"revision,omitempty"`
+}
+
+// RollbackVars .
+type RollbackVars struct {
+	Workloads []RollbackTarget `json:"workloads"`
+	Wait      bool             `json:"wait,omitempty"`
+	Cluster   string           `json:"cluster,omitempty"`
+}
+
+// RollbackResult is the result of the rollback of a workload.
+type RollbackResult struct {
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	// TargetRevision is the revision which the workload is rolled back to
+	TargetRevision int64 `json:"targetRevision"`
+	// Revision is the resulting revision of the workload after the rollback
+	Revision int64 `json:"revision"`
+}
+
+// RollbackReturnVars .
+type RollbackReturnVars struct {
+	Results []RollbackResult `json:"results"`
+}
+
+// RollbackParams .
+type RollbackParams = providertypes.Params[RollbackVars]
+
+// RollbackReturns .
+type RollbackReturns = providertypes.Returns[RollbackReturnVars]
+
+// Rollback rolls the Deployments or StatefulSets back to the specified or the previous revisions in the
+// revision history. The result of each workload is kept in the step context once it is rolled back, so that
+// the workloads are rolled back only once when the step waits for the rollouts to complete or is retried.
+func Rollback(ctx context.Context, params *RollbackParams) (*RollbackReturns, error) {
+	vars := params.Params
+	rollbackCtx := handleContext(ctx, vars.Cluster)
+	stepID := fmt.Sprint(params.ProcessContext.GetData(model.ContextStepSessionID))
+	var persisted []RollbackResult
+	if state := params.WorkflowContext.GetMutableValue(stepID, rollbackStateKey); state != "" {
+		if err := json.Unmarshal([]byte(state), &persisted); err != nil {
+			return nil, fmt.Errorf("failed to decode the rollback state: %w", err)
+		}
+	}
+	rolledBack := map[string]RollbackResult{}
+	for _, result := range persisted {
+		rolledBack[ownerKey(result.Kind, result.Namespace, result.Name)] = result
+	}
+	var results []RollbackResult
+	for _, target := range vars.Workloads {
+		if target.Namespace == "" {
+			target.Namespace = "default"
+		}
+		if result, ok := rolledBack[ownerKey(target.Kind, target.Namespace, target.Name)]; ok {
+			results = append(results, result)
+			continue
+		}
+		result, err := rollbackWorkload(rollbackCtx, params.KubeClient, target)
+		if err != nil {
+			return nil, err
+		}
+		if result == nil {
+			params.Action.Fail(fmt.Sprintf("The target revision of %s %s/%s is not found", target.Kind, target.Namespace, target.Name))
+			return nil, wferrors.GenericActionError(wferrors.ActionTerminate)
+		}
+		results = append(results, *result)
+		persisted = append(persisted, *result)
+		state, err := json.Marshal(persisted)
+		if err != nil {
+			return nil, err
+		}
+		params.WorkflowContext.SetMutableValue(string(state), stepID, rollbackStateKey)
+	}
+
+	if vars.Wait {
+		var pending []string
+		for _, result := range results {
+			done, err := rolloutCompleted(rollbackCtx, params.KubeClient, result)
+			if err != nil {
+				return nil, err
+			}
+			if !done {
+				pending = append(pending, fmt.Sprintf("%s %s/%s", result.Kind, result.Namespace, result.Name))
+			}
+		}
+		if len(pending) > 0 {
+			params.Action.Wait(fmt.Sprintf("Waiting for the rollback of %s to complete", strings.Join(pending, ", ")))
+			return nil, wferrors.GenericActionError(wferrors.ActionWait)
+		}
+	}
+	return &RollbackReturns{Returns: RollbackReturnVars{Results: results}}, nil
+}
+
+// rollbackWorkload rolls back the workload, it returns nil if the target revision is not found.
+func rollbackWorkload(ctx context.Context, cli client.Client, target RollbackTarget) (*RollbackResult, error) {
+	key := client.ObjectKey{Namespace: target.Namespace, Name: target.Name}
+	result := &RollbackResult{Kind: target.Kind, Name: target.Name, Namespace: target.Namespace}
+	switch target.Kind {
+	case "Deployment":
+		deploy := &appsv1.Deployment{}
+		if err := cli.Get(ctx, key, deploy); err != nil {
+			return nil, err
+		}
+		rsList := &appsv1.ReplicaSetList{}
+		if err := cli.List(ctx, rsList, client.InNamespace(target.Namespace)); err != nil {
+			return nil, err
+		}
+		revisions := map[int64]*appsv1.ReplicaSet{}
+		for i := range rsList.Items {
+			rs := &rsList.Items[i]
+			if !metav1.IsControlledBy(rs, deploy) {
+				continue
+			}
+			if revision, err := strconv.ParseInt(rs.Annotations[deploymentRevisionAnnotation], 10, 64); err == nil {
+				revisions[revision] = rs
+			}
+		}
+		revision, latest, ok := targetRevision(keys(revisions), target.Revision)
+		if !ok {
+			return nil, nil
+		}
+		template := revisions[revision].Spec.Template.DeepCopy()
+		labels := map[string]string{}
+		for k, v := range template.Labels {
+			if k != appsv1.DefaultDeploymentUniqueLabelKey {
+				labels[k] = v
+			}
+		}
+		template.Labels = labels
+		// replace the whole template like kubectl rollout undo, the merge patch keeps the fields missing in the revision
+		patch, err := json.Marshal([]map[string]interface{}{
+			{"op": "replace", "path": "/spec/template", "value": template},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err := cli.Patch(ctx, deploy, client.RawPatch(types.JSONPatchType, patch)); err != nil {
+			return nil, fmt.Errorf("failed to roll back Deployment %s/%s to revision %d: %w", target.Namespace, target.Name, revision, err)
+		}
+		result.TargetRevision, result.Revision = revision, latest+1
+	case "StatefulSet":
+		sts := &appsv1.StatefulSet{}
+		if err := cli.Get(ctx, key, sts); err != nil {
+			return nil, err
+		}
+		crList := &appsv1.ControllerRevisionList{}
+		if err := cli.List(ctx, crList, client.InNamespace(target.Namespace)); err != nil {
+			return nil, err
+		}
+		revisions := map[int64]*appsv1.ControllerRevision{}
+		for i := range crList.Items {
+			if cr := &crList.Items[i]; metav1.IsControlledBy(cr, sts) {
+				revisions[cr.Revision] = cr
+			}
+		}
+		revision, latest, ok := targetRevision(keys(revisions), target.Revision)
+		if !ok {
+			return nil, nil
+		}
+		if err := cli.Patch(ctx, sts, client.RawPatch(types.StrategicMergePatchType, revisions[revision].Data.Raw)); err != nil {
+			return nil, fmt.Errorf("failed to roll back StatefulSet %s/%s to revision %d: %w", target.Namespace, target.Name, revision, err)
+		}
+		result.TargetRevision, result.Revision = revision, latest+1
+	default:
+		return nil, fmt.Errorf("unsupported rollback kind %s, only Deployment and StatefulSet are supported", target.Kind)
+	}
+	return result, nil
+}
+
+// targetRevision returns the revision to roll back to and the latest revision, the previous revision
+// is used if the revision is not specified.
+func targetRevision(revisions []int64, revision int64) (int64, int64, bool) {
+	if len(revisions) == 0 {
+		return 0, 0, false
+	}
+	sort.Slice(revisions, func(i, j int) bool { return revisions[i] < revisions[j] })
+	latest := revisions[len(revisions)-1]
+	if revision == 0 {
+		if len(revisions) < 2 {
+			return 0, 0, false
+		}
+		return revisions[len(revisions)-2], latest, true
+	}
+	for _, r := range revisions {
+		if r == revision {
+			return revision, latest, true
+		}
+	}
+	return 0, 0, false
+}
+
+func keys[T any](m map[int64]T) []int64 {
+	ks := make([]int64, 0, len(m))
+	for k := range m {
+		ks = append(ks, k)
+	}
+	return ks
+}
+
+// rolloutCompleted checks whether the workload has rolled out the latest template.
+func rolloutCompleted(ctx context.Context, cli client.Client, result RollbackResult) (bool, error) {
+	key := client.ObjectKey{Namespace: result.Namespace, Name: result.Name}
+	switch result.Kind {
+	case "Deployment":
+		deploy := &appsv1.Deployment{}
+		if err := cli.Get(ctx, key, deploy); err != nil {
+			return false, err
+		}
+		replicas := int32(1)
+		if deploy.Spec.Replicas != nil {
+			replicas = *deploy.Spec.Replicas
+		}
+		status := deploy.Status
+		return status.ObservedGeneration >= deploy.Generation && status.UpdatedReplicas == replicas &&
+			status.Replicas == replicas && status.AvailableReplicas == replicas, nil
+	case "StatefulSet":
+		sts := &appsv1.StatefulSet{}
+		if err := cli.Get(ctx, key, sts); err != nil {
+			return false, err
+		}
+		replicas := int32(1)
+		if sts.Spec.Replicas != nil {
+			replicas = *sts.Spec.Replicas
+		}
+		status := sts.Status
+		return status.ObservedGeneration >= sts.Generation && status.UpdateRevision == status.CurrentRevision &&
+			status.UpdatedReplicas == replicas && status.ReadyReplicas == replicas, nil
+	default:
+		return false, fmt.Errorf("unsupported rollback kind %s", result.Kind)
+	}
+}
@@ -0,0 +1,243 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	wfContext "github.com/kubevela/workflow/pkg/context"
+	"github.com/kubevela/workflow/pkg/cue/model"
+	"github.com/kubevela/workflow/pkg/cue/process"
+	"github.com/kubevela/workflow/pkg/errors"
+	"github.com/kubevela/workflow/pkg/mock"
+	providertypes "github.com/kubevela/workflow/pkg/providers/types"
+)
+
+func TestRollback(t *testing.T) {
+	ctx := context.Background()
+	deploy := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default", UID: types.UID("deploy-uid"), Generation: 3},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: ptr.To(int32(2)),
+			Template: podTemplate("web", "nginx:3"),
+		},
+	}
+	var objs []client.Object
+	objs = append(objs, deploy)
+	for i, image := range []string{"nginx:1", "nginx:2", "nginx:3"} {
+		template := podTemplate("web", image)
+		template.Labels[appsv1.DefaultDeploymentUniqueLabelKey] = image
+		objs = append(objs, &appsv1.ReplicaSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            "web-" + string(rune('a'+i)),
+				Namespace:       "default",
+				Annotations:     map[string]string{deploymentRevisionAnnotation: string(rune('1' + i))},
+				OwnerReferences: []metav1.OwnerReference{controllerRef("Deployment", deploy.ObjectMeta)},
+			},
+			Spec: appsv1.ReplicaSetSpec{Template: template},
+		})
+	}
+	sts := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "default", UID: types.UID("sts-uid")},
+		Spec:       appsv1.StatefulSetSpec{Replicas: ptr.To(int32(1)), Template: podTemplate("db", "mysql:8")},
+	}
+	objs = append(objs, sts)
+	for i, image := range []string{"mysql:5", "mysql:8"} {
+		patch, err := json.Marshal(map[string]interface{}{"spec": map[string]interface{}{"template": podTemplate("db", image)}})
+		require.NoError(t, err)
+		objs = append(objs, &appsv1.ControllerRevision{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            "db-" + string(rune('a'+i)),
+				Namespace:       "default",
+				OwnerReferences: []metav1.OwnerReference{controllerRef("StatefulSet", sts.ObjectMeta)},
+			},
+			Data:     runtime.RawExtension{Raw: patch},
+			Revision: int64(i + 1),
+		})
+	}
+	cli := fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).WithObjects(objs...).Build()
+	rollback := func(stepID string, act *mock.Action, vars RollbackVars) (*RollbackReturns, error) {
+		pCtx := process.NewContext(process.ContextData{Name: "run", Namespace: "default"})
+		pCtx.PushData(model.ContextStepSessionID, stepID)
+		return Rollback(ctx, &RollbackParams{
+			Params: vars,
+			RuntimeParams: providertypes.RuntimeParams{
+				KubeClient:      cli,
+				Action:          act,
+				ProcessContext:  pCtx,
+				WorkflowContext: newWorkflowContextForTest(t),
+			},
+		})
+	}
+
+	t.Run("previous and specified revisions", func(t *testing.T) {
+		r := require.New(t)
+		res, err := rollback("bulk", &mock.Action{}, RollbackVars{Workloads: []RollbackTarget{
+			{Kind: "Deployment", Name: "web"},
+			{Kind: "StatefulSet", Name: "db", Namespace: "default", Revision: 1},
+		}})
+		r.NoError(err)
+		r.Equal([]RollbackResult{
+			{Kind: "Deployment", Name: "web", Namespace: "default", TargetRevision: 2, Revision: 4},
+			{Kind: "StatefulSet", Name: "db", Namespace: "default", TargetRevision: 1, Revision: 3},
+		}, res.Returns.Results)
+		updated := &appsv1.Deployment{}
+		r.NoError(cli.Get(ctx, client.ObjectKey{Namespace: "default", Name: "web"}, updated))
+		r.Equal("nginx:2", updated.Spec.Template.Spec.Containers[0].Image)
+		r.NotContains(updated.Spec.Template.Labels, appsv1.DefaultDeploymentUniqueLabelKey)
+		updatedSts := &appsv1.StatefulSet{}
+		r.NoError(cli.Get(ctx, client.ObjectKey{Namespace: "default", Name: "db"}, updatedSts))
+		r.Equal("mysql:5", updatedSts.Spec.Template.Spec.Containers[0].Image)
+	})
+
+	t.Run("replace the template", func(t *testing.T) {
+		r := require.New(t)
+		current := &appsv1.Deployment{}
+		r.NoError(cli.Get(ctx, client.ObjectKey{Namespace: "default", Name: "web"}, current))
+		current.Spec.Template = podTemplate("web", "nginx:3")
+		current.Spec.Template.Spec.NodeSelector = map[string]string{"zone": "a"}
+		current.Spec.Template.Annotations = map[string]string{"restartedAt": "now"}
+		r.NoError(cli.Update(ctx, current))
+		_, err := rollback("replace", &mock.Action{}, RollbackVars{Workloads: []RollbackTarget{{Kind: "Deployment", Name: "web", Revision: 2}}})
+		r.NoError(err)
+		r.NoError(cli.Get(ctx, client.ObjectKey{Namespace: "default", Name: "web"}, current))
+		r.Equal("nginx:2", current.Spec.Template.Spec.Containers[0].Image)
+		// the fields missing in the revision are removed
+		r.Empty(current.Spec.Template.Spec.NodeSelector)
+		r.Empty(current.Spec.Template.Annotations)
+	})
+
+	t.Run("retry after partial failure", func(t *testing.T) {
+		r := require.New(t)
+		wfCtx := newWorkflowContextForTest(t)
+		pCtx := process.NewContext(process.ContextData{Name: "run", Namespace: "default"})
+		pCtx.PushData(model.ContextStepSessionID, "partial")
+		params := &RollbackParams{
+			Params: RollbackVars{Workloads: []RollbackTarget{
+				{Kind: "Deployment", Name: "web", Revision: 1},
+				{Kind: "StatefulSet", Name: "cache", Revision: 1},
+			}},
+			RuntimeParams: providertypes.RuntimeParams{
+				KubeClient:      cli,
+				Action:          &mock.Action{},
+				ProcessContext:  pCtx,
+				WorkflowContext: wfCtx,
+			},
+		}
+		_, err := Rollback(ctx, params)
+		r.Error(err)
+		current := &appsv1.Deployment{}
+		r.NoError(cli.Get(ctx, client.ObjectKey{Namespace: "default", Name: "web"}, current))
+		r.Equal("nginx:1", current.Spec.Template.Spec.Containers[0].Image)
+
+		// the rolled back Deployment is skipped when the step is retried
+		current.Spec.Template = podTemplate("web", "nginx:3")
+		r.NoError(cli.Update(ctx, current))
+		params.Params.Workloads[1] = RollbackTarget{Kind: "StatefulSet", Name: "db", Revision: 2}
+		res, err := Rollback(ctx, params)
+		r.NoError(err)
+		r.Equal([]RollbackResult{
+			{Kind: "Deployment", Name: "web", Namespace: "default", TargetRevision: 1, Revision: 4},
+			{Kind: "StatefulSet", Name: "db", Namespace: "default", TargetRevision: 2, Revision: 3},
+		}, res.Returns.Results)
+		r.NoError(cli.Get(ctx, client.ObjectKey{Namespace: "default", Name: "web"}, current))
+		r.Equal("nginx:3", current.Spec.Template.Spec.Containers[0].Image)
+	})
+
+	t.Run("missing revision", func(t *testing.T) {
+		r := require.New(t)
+		act := &mock.Action{}
+		_, err := rollback("missing", act, RollbackVars{Workloads: []RollbackTarget{{Kind: "Deployment", Name: "web", Revision: 7}}})
+		r.Equal(errors.GenericActionError(errors.ActionTerminate), err)
+		r.Equal("Fail", act.Phase)
+		r.Equal("The target revision of Deployment default/web is not found", act.Msg)
+	})
+
+	t.Run("unsupported kind", func(t *testing.T) {
+		_, err := rollback("unsupported", &mock.Action{}, RollbackVars{Workloads: []RollbackTarget{{Kind: "DaemonSet", Name: "web"}}})
+		require.Error(t, err)
+	})
+
+	t.Run("wait for completion", func(t *testing.T) {
+		r := require.New(t)
+		wfCtx := newWorkflowContextForTest(t)
+		pCtx := process.NewContext(process.ContextData{Name: "run", Namespace: "default"})
+		pCtx.PushData(model.ContextStepSessionID, "wait")
+		params := &RollbackParams{
+			Params: RollbackVars{Workloads: []RollbackTarget{{Kind: "Deployment", Name: "web", Revision: 1}}, Wait: true},
+			RuntimeParams: providertypes.RuntimeParams{
+				KubeClient:      cli,
+				Action:          &mock.Action{},
+				ProcessContext:  pCtx,
+				WorkflowContext: wfCtx,
+			},
+		}
+		_, err := Rollback(ctx, params)
+		r.Equal(errors.GenericActionError(errors.ActionWait), err)
+		r.Equal("Wait", params.Action.(*mock.Action).Phase)
+		r.Equal("Waiting for the rollback of Deployment default/web to complete", params.Action.(*mock.Action).Msg)
+
+		current := &appsv1.Deployment{}
+		r.NoError(cli.Get(ctx, client.ObjectKey{Namespace: "default", Name: "web"}, current))
+		r.Equal("nginx:1", current.Spec.Template.Spec.Containers[0].Image)
+		// the rollback is not applied again when the step is re-executed
+		current.Spec.Template = podTemplate("web", "nginx:3")
+		r.NoError(cli.Update(ctx, current))
+		current.Status = appsv1.DeploymentStatus{ObservedGeneration: current.Generation, Replicas: 2, UpdatedReplicas: 2, AvailableReplicas: 2}
+		r.NoError(cli.Status().Update(ctx, current))
+
+		params.Action = &mock.Action{}
+		res, err := Rollback(ctx, params)
+		r.NoError(err)
+		r.Equal(int64(1), res.Returns.Results[0].TargetRevision)
+		r.NoError(cli.Get(ctx, client.ObjectKey{Namespace: "default", Name: "web"}, current))
+		r.Equal("nginx:3", current.Spec.Template.Spec.Containers[0].Image)
+	})
+}
+
+func podTemplate(app, image string) corev1.PodTemplateSpec {
+	return corev1.PodTemplateSpec{
+		ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": app}},
+		Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: app, Image: image}}},
+	}
+}
+
+func controllerRef(kind string, meta metav1.ObjectMeta) metav1.OwnerReference {
+	return metav1.OwnerReference{APIVersion: "apps/v1", Kind: kind, Name: meta.Name, UID: meta.UID, Controller: ptr.To(true)}
+}
+
+func newWorkflowContextForTest(t *testing.T) wfContext.Context {
+	wfCtx := new(wfContext.WorkflowContext)
+	require.NoError(t, wfCtx.LoadFromConfigMap(context.Background(), corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "workflow-run-context"},
+		Data:       map[string]string{},
+	}))
+	return wfCtx
+}
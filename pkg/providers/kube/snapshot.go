@@ -0,0 +1,141 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/kubevela/pkg/util/k8s"
+
+	providertypes "github.com/kubevela/workflow/pkg/providers/types"
+)
+
+const (
+	// AnnoWorkflowSnapshotRedacted is the annotation marks the sensitive data of the snapshot is redacted
+	AnnoWorkflowSnapshotRedacted = "workflow.oam.dev/snapshot-redacted"
+)
+
+// SnapshotVars .
+type SnapshotVars struct {
+	Resource *unstructured.Unstructured `json:"value"`
+	Cluster  string                     `json:"cluster,omitempty"`
+}
+
+// SnapshotParams .
+type SnapshotParams = providertypes.Params[SnapshotVars]
+
+// RestoreReturnVars .
+type RestoreReturnVars struct {
+	Resource  *unstructured.Unstructured `json:"value"`
+	Recreated bool                       `json:"recreated"`
+}
+
+// RestoreReturns .
+type RestoreReturns = providertypes.Returns[RestoreReturnVars]
+
+// Snapshot records the current state of the resource in cluster, the data of secrets will be redacted.
+func Snapshot(ctx context.Context, params *SnapshotParams) (*ResourceReturns, error) {
+	workload := params.Params.Resource
+	key := client.ObjectKeyFromObject(workload)
+	if key.Namespace == "" {
+		key.Namespace = "default"
+	}
+	readCtx := handleContext(ctx, params.Params.Cluster)
+	if err := params.KubeClient.Get(readCtx, key, workload); err != nil {
+		return &ResourceReturns{
+			Returns: ResourceReturnVars{
+				Resource: workload,
+				Error:    err.Error(),
+			},
+		}, nil
+	}
+	snapshot := workload.DeepCopy()
+	unstructured.RemoveNestedField(snapshot.Object, "status")
+	for _, field := range []string{"uid", "resourceVersion", "generation", "creationTimestamp", "managedFields", "selfLink"} {
+		unstructured.RemoveNestedField(snapshot.Object, "metadata", field)
+	}
+	if isSecret(snapshot) {
+		unstructured.RemoveNestedField(snapshot.Object, "data")
+		unstructured.RemoveNestedField(snapshot.Object, "stringData")
+		if err := k8s.AddAnnotation(snapshot, AnnoWorkflowSnapshotRedacted, "true"); err != nil {
+			return nil, err
+		}
+	}
+	return &ResourceReturns{
+		Returns: ResourceReturnVars{
+			Resource: snapshot,
+		},
+	}, nil
+}
+
+// Restore re-applies the snapshot of the resource, the resource will be recreated if it has been deleted.
+func Restore(ctx context.Context, params *SnapshotParams) (*RestoreReturns, error) {
+	snapshot := params.Params.Resource
+	if snapshot.GetNamespace() == "" {
+		snapshot.SetNamespace("default")
+	}
+	restoreCtx := handleContext(ctx, params.Params.Cluster)
+	redacted := snapshot.GetAnnotations()[AnnoWorkflowSnapshotRedacted] == "true"
+	if redacted {
+		unstructured.RemoveNestedField(snapshot.Object, "metadata", "annotations", AnnoWorkflowSnapshotRedacted)
+	}
+	existing := new(unstructured.Unstructured)
+	existing.SetGroupVersionKind(snapshot.GroupVersionKind())
+	if err := params.KubeClient.Get(restoreCtx, client.ObjectKeyFromObject(snapshot), existing); err != nil {
+		if !errors.IsNotFound(err) {
+			return nil, err
+		}
+		if redacted {
+			return nil, fmt.Errorf("cannot recreate %s %s/%s from a redacted snapshot", snapshot.GetKind(), snapshot.GetNamespace(), snapshot.GetName())
+		}
+		if err := params.KubeClient.Create(restoreCtx, snapshot); err != nil {
+			return nil, err
+		}
+		return &RestoreReturns{
+			Returns: RestoreReturnVars{
+				Resource:  snapshot,
+				Recreated: true,
+			},
+		}, nil
+	}
+	if redacted {
+		for _, field := range []string{"data", "stringData"} {
+			if v, ok := existing.Object[field]; ok {
+				snapshot.Object[field] = v
+			}
+		}
+	}
+	snapshot.SetResourceVersion(existing.GetResourceVersion())
+	if err := params.KubeClient.Update(restoreCtx, snapshot); err != nil {
+		return nil, err
+	}
+	return &RestoreReturns{
+		Returns: RestoreReturnVars{
+			Resource: snapshot,
+		},
+	}, nil
+}
+
+func isSecret(obj *unstructured.Unstructured) bool {
+	gvk := obj.GroupVersionKind()
+	return gvk.Group == "" && gvk.Kind == "Secret"
+}
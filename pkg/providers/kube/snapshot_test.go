@@ -0,0 +1,120 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	providertypes "github.com/kubevela/workflow/pkg/providers/types"
+)
+
+func TestSnapshotAndRestore(t *testing.T) {
+	r := require.New(t)
+	ctx := context.Background()
+	cli := fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).WithObjects(
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "cm", Namespace: "default"},
+			Data:       map[string]string{"key": "origin"},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "secret", Namespace: "default"},
+			Data:       map[string][]byte{"password": []byte("origin")},
+		},
+	).Build()
+	obj := func(kind, name string) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       kind,
+			"metadata":   map[string]interface{}{"name": name},
+		}}
+	}
+	snapshot := func(kind, name string) *unstructured.Unstructured {
+		res, err := Snapshot(ctx, &SnapshotParams{
+			Params:        SnapshotVars{Resource: obj(kind, name)},
+			RuntimeParams: providertypes.RuntimeParams{KubeClient: cli},
+		})
+		r.NoError(err)
+		r.Empty(res.Returns.Error)
+		return res.Returns.Resource
+	}
+	restore := func(snap *unstructured.Unstructured) (*RestoreReturns, error) {
+		return Restore(ctx, &SnapshotParams{
+			Params:        SnapshotVars{Resource: snap.DeepCopy()},
+			RuntimeParams: providertypes.RuntimeParams{KubeClient: cli},
+		})
+	}
+
+	// snapshot, modify and restore
+	cmSnapshot := snapshot("ConfigMap", "cm")
+	r.Empty(cmSnapshot.GetResourceVersion())
+	cm := &corev1.ConfigMap{}
+	r.NoError(cli.Get(ctx, client.ObjectKey{Name: "cm", Namespace: "default"}, cm))
+	cm.Data["key"] = "modified"
+	r.NoError(cli.Update(ctx, cm))
+	res, err := restore(cmSnapshot)
+	r.NoError(err)
+	r.False(res.Returns.Recreated)
+	r.NoError(cli.Get(ctx, client.ObjectKey{Name: "cm", Namespace: "default"}, cm))
+	r.Equal("origin", cm.Data["key"])
+
+	// restore the deleted resource
+	r.NoError(cli.Delete(ctx, cm))
+	res, err = restore(cmSnapshot)
+	r.NoError(err)
+	r.True(res.Returns.Recreated)
+	r.NoError(cli.Get(ctx, client.ObjectKey{Name: "cm", Namespace: "default"}, cm))
+	r.Equal("origin", cm.Data["key"])
+
+	// the data of secret should be redacted and kept when restoring
+	secretSnapshot := snapshot("Secret", "secret")
+	_, found, _ := unstructured.NestedFieldNoCopy(secretSnapshot.Object, "data")
+	r.False(found)
+	r.Equal("true", secretSnapshot.GetAnnotations()[AnnoWorkflowSnapshotRedacted])
+	secret := &corev1.Secret{}
+	r.NoError(cli.Get(ctx, client.ObjectKey{Name: "secret", Namespace: "default"}, secret))
+	secret.Labels = map[string]string{"modified": "true"}
+	r.NoError(cli.Update(ctx, secret))
+	_, err = restore(secretSnapshot)
+	r.NoError(err)
+	r.NoError(cli.Get(ctx, client.ObjectKey{Name: "secret", Namespace: "default"}, secret))
+	r.Empty(secret.Labels)
+	r.Empty(secret.Annotations)
+	r.Equal("origin", string(secret.Data["password"]))
+
+	// the deleted secret cannot be recreated from a redacted snapshot
+	r.NoError(cli.Delete(ctx, secret))
+	_, err = restore(secretSnapshot)
+	r.Error(err)
+	r.Contains(err.Error(), "redacted snapshot")
+
+	// snapshot the resource not found
+	notFound, err := Snapshot(ctx, &SnapshotParams{
+		Params:        SnapshotVars{Resource: obj("ConfigMap", "not-found")},
+		RuntimeParams: providertypes.RuntimeParams{KubeClient: cli},
+	})
+	r.NoError(err)
+	r.Contains(notFound.Returns.Error, "not found")
+}
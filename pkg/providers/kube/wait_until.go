@@ -0,0 +1,204 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/cuecontext"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/util/jsonpath"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	wfContext "github.com/kubevela/workflow/pkg/context"
+	"github.com/kubevela/workflow/pkg/cue/model"
+	wferrors "github.com/kubevela/workflow/pkg/errors"
+	providertypes "github.com/kubevela/workflow/pkg/providers/types"
+	"github.com/kubevela/workflow/pkg/types"
+)
+
+// waitUntilDeadlineKey is the key of the deadline of the wait in the step memory
+const waitUntilDeadlineKey = "wait-until-deadline"
+
+// WaitUntilVars .
+type WaitUntilVars struct {
+	Target ObjectReference `json:"target"`
+	// Condition is the CUE expression evaluated to bool, the object is referred as `object`
+	Condition string `json:"condition,omitempty"`
+	// JSONPath and Value check whether the field of the object matches the value, any non-empty value of the
+	// field other than false matches if the value is not set
+	JSONPath string `json:"jsonPath,omitempty"`
+	Value    string `json:"value,omitempty"`
+	// Interval is the interval to poll the object, the default backoff of the workflow is used if not set
+	Interval string `json:"interval,omitempty"`
+	Timeout  string `json:"timeout,omitempty"`
+	Cluster  string `json:"cluster,omitempty"`
+}
+
+// WaitUntilParams .
+type WaitUntilParams = providertypes.Params[WaitUntilVars]
+
+type waitCondition func(obj *unstructured.Unstructured) (bool, error)
+
+// WaitUntil checks the object once and waits for the next poll until the condition holds, the deadline is kept
+// in the step memory and the step fails with the last observed state of the object once it is exceeded.
+func WaitUntil(ctx context.Context, params *WaitUntilParams) (*ResourceReturns, error) {
+	vars := params.Params
+	timeout := 5 * time.Minute
+	var err error
+	if vars.Timeout != "" {
+		if timeout, err = time.ParseDuration(vars.Timeout); err != nil || timeout <= 0 {
+			return nil, fmt.Errorf("invalid timeout %s", vars.Timeout)
+		}
+	}
+	var interval time.Duration
+	if vars.Interval != "" {
+		if interval, err = time.ParseDuration(vars.Interval); err != nil || interval <= 0 {
+			return nil, fmt.Errorf("invalid interval %s", vars.Interval)
+		}
+	}
+	condition, err := newWaitCondition(vars)
+	if err != nil {
+		return nil, err
+	}
+	key := client.ObjectKey{Namespace: vars.Target.Namespace, Name: vars.Target.Name}
+	if key.Namespace == "" {
+		key.Namespace = "default"
+	}
+	stepID := fmt.Sprint(params.ProcessContext.GetData(model.ContextStepSessionID))
+	deadline, err := waitUntilDeadline(params.WorkflowContext, stepID, timeout)
+	if err != nil {
+		return nil, err
+	}
+	var last *unstructured.Unstructured
+	obj := new(unstructured.Unstructured)
+	obj.SetAPIVersion(vars.Target.APIVersion)
+	obj.SetKind(vars.Target.Kind)
+	if err := params.KubeClient.Get(handleContext(ctx, vars.Cluster), key, obj); err != nil {
+		if !errors.IsNotFound(err) {
+			return nil, err
+		}
+	} else {
+		last = obj
+		ok, err := condition(obj)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			params.WorkflowContext.DeleteMutableValue(stepID, waitUntilDeadlineKey)
+			return &ResourceReturns{Returns: ResourceReturnVars{Resource: obj}}, nil
+		}
+	}
+	if !time.Now().Before(deadline) {
+		params.WorkflowContext.DeleteMutableValue(stepID, waitUntilDeadlineKey)
+		params.Action.Fail(fmt.Sprintf("Timeout waiting for %s %s/%s after %s: %s", vars.Target.Kind, key.Namespace, key.Name, timeout, observedState(last)))
+		return nil, wferrors.GenericActionError(wferrors.ActionTerminate)
+	}
+	if poller, ok := params.Action.(types.Poller); ok && interval > 0 {
+		poller.PollInterval(interval)
+	}
+	params.Action.Wait(fmt.Sprintf("Waiting for %s %s/%s: %s", vars.Target.Kind, key.Namespace, key.Name, observedState(last)))
+	return nil, wferrors.GenericActionError(wferrors.ActionWait)
+}
+
+// waitUntilDeadline returns the deadline of the wait kept in the step memory, the deadline is set on the first check
+func waitUntilDeadline(wfCtx wfContext.Context, stepID string, timeout time.Duration) (time.Time, error) {
+	if v := wfCtx.GetMutableValue(stepID, waitUntilDeadlineKey); v != "" {
+		deadline, err := time.Parse(time.RFC3339Nano, v)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid deadline %s of the wait: %w", v, err)
+		}
+		return deadline, nil
+	}
+	deadline := time.Now().Add(timeout)
+	wfCtx.SetMutableValue(deadline.UTC().Format(time.RFC3339Nano), stepID, waitUntilDeadlineKey)
+	return deadline, nil
+}
+
+func newWaitCondition(vars WaitUntilVars) (waitCondition, error) {
+	switch {
+	case vars.Condition != "" && vars.JSONPath != "":
+		return nil, fmt.Errorf("condition and jsonPath cannot be set at the same time")
+	case vars.Condition != "":
+		cuectx := cuecontext.New()
+		v := cuectx.CompileString("object: _\nresult: " + vars.Condition)
+		if v.Err() != nil {
+			return nil, fmt.Errorf("invalid condition %s: %w", vars.Condition, v.Err())
+		}
+		return func(obj *unstructured.Unstructured) (bool, error) {
+			result := v.FillPath(cue.ParsePath("object"), obj.Object).LookupPath(cue.ParsePath("result"))
+			ok, err := result.Bool()
+			if err != nil {
+				// the referred fields may not exist yet
+				if !result.IsConcrete() {
+					return false, nil
+				}
+				return false, fmt.Errorf("invalid condition %s: %w", vars.Condition, err)
+			}
+			return ok, nil
+		}, nil
+	case vars.JSONPath != "":
+		j := jsonpath.New("waitUntil").AllowMissingKeys(true)
+		expr := vars.JSONPath
+		if !strings.HasPrefix(expr, "{") {
+			expr = "{" + strings.TrimPrefix(expr, "$") + "}"
+		}
+		if err := j.Parse(expr); err != nil {
+			return nil, fmt.Errorf("invalid jsonPath %s: %w", vars.JSONPath, err)
+		}
+		return func(obj *unstructured.Unstructured) (bool, error) {
+			results, err := j.FindResults(obj.Object)
+			if err != nil {
+				return false, nil //nolint:nilerr
+			}
+			for _, result := range results {
+				for _, r := range result {
+					s := fmt.Sprint(r.Interface())
+					if (vars.Value == "" && s != "" && s != "false") || (vars.Value != "" && s == vars.Value) {
+						return true, nil
+					}
+				}
+			}
+			return false, nil
+		}, nil
+	default:
+		return nil, fmt.Errorf("either condition or jsonPath must be set")
+	}
+}
+
+// observedState returns the status of the object, or only the generation if it does not have a status, so that
+// the data of the object like the secrets is never written into the step status
+func observedState(obj *unstructured.Unstructured) string {
+	if obj == nil {
+		return "the object is not found"
+	}
+	status, ok := obj.Object["status"]
+	if !ok {
+		return fmt.Sprintf("the condition is not met by generation %d", obj.GetGeneration())
+	}
+	b, err := json.Marshal(map[string]interface{}{"status": status})
+	if err != nil {
+		return err.Error()
+	}
+	return "last observed " + string(b)
+}
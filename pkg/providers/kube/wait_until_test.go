@@ -0,0 +1,154 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	"github.com/kubevela/workflow/pkg/cue/model"
+	"github.com/kubevela/workflow/pkg/cue/process"
+	"github.com/kubevela/workflow/pkg/errors"
+	"github.com/kubevela/workflow/pkg/mock"
+	providertypes "github.com/kubevela/workflow/pkg/providers/types"
+)
+
+func TestWaitUntil(t *testing.T) {
+	r := require.New(t)
+	deploy := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+		Spec:       appsv1.DeploymentSpec{Replicas: ptr.To(int32(3))},
+	}
+	// the deployment becomes ready after a few polls
+	newClient := func(readyAfter int) (client.Client, *int) {
+		polls := 0
+		cli := fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).WithObjects(deploy.DeepCopy()).WithInterceptorFuncs(interceptor.Funcs{
+			Get: func(ctx context.Context, cli client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+				polls++
+				if polls == readyAfter {
+					d := &appsv1.Deployment{}
+					if err := cli.Get(ctx, key, d); err != nil {
+						return err
+					}
+					d.Status.ReadyReplicas = 3
+					d.Status.Conditions = []appsv1.DeploymentCondition{{Type: appsv1.DeploymentAvailable, Status: "True"}}
+					if err := cli.Status().Update(ctx, d); err != nil {
+						return err
+					}
+				}
+				return cli.Get(ctx, key, obj, opts...)
+			},
+		}).Build()
+		return cli, &polls
+	}
+	newRuntimeParams := func(cli client.Client, act *mock.Action) providertypes.RuntimeParams {
+		pCtx := process.NewContext(process.ContextData{Name: "run", Namespace: "default"})
+		pCtx.PushData(model.ContextStepSessionID, "step-1")
+		return providertypes.RuntimeParams{KubeClient: cli, Action: act, ProcessContext: pCtx, WorkflowContext: newWorkflowContextForTest(t)}
+	}
+	waitUntil := func(ctx context.Context, runtimeParams providertypes.RuntimeParams, vars WaitUntilVars) (*ResourceReturns, error) {
+		vars.Target = ObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Name: "web"}
+		return WaitUntil(ctx, &WaitUntilParams{Params: vars, RuntimeParams: runtimeParams})
+	}
+
+	// the object is checked once in each reconcile and the step waits until the condition holds
+	cli, polls := newClient(3)
+	act := &mock.Action{}
+	runtimeParams := newRuntimeParams(cli, act)
+	for i := 0; i < 2; i++ {
+		_, err := waitUntil(context.Background(), runtimeParams, WaitUntilVars{Condition: "object.status.readyReplicas == object.spec.replicas", Interval: "2s"})
+		r.Equal(errors.GenericActionError(errors.ActionWait), err)
+		r.Equal("Wait", act.Phase)
+		r.Equal(2*time.Second, act.Interval)
+		r.Equal("Waiting for Deployment default/web: last observed {\"status\":{}}", act.Msg)
+		r.Equal(i+1, *polls)
+	}
+	r.NotEmpty(runtimeParams.WorkflowContext.GetMutableValue("step-1", waitUntilDeadlineKey))
+	res, err := waitUntil(context.Background(), runtimeParams, WaitUntilVars{Condition: "object.status.readyReplicas == object.spec.replicas"})
+	r.NoError(err)
+	r.Equal(3, *polls)
+	r.Equal(int64(3), res.Returns.Resource.Object["status"].(map[string]interface{})["readyReplicas"])
+	r.Empty(runtimeParams.WorkflowContext.GetMutableValue("step-1", waitUntilDeadlineKey))
+
+	cli, _ = newClient(1)
+	_, err = waitUntil(context.Background(), newRuntimeParams(cli, &mock.Action{}), WaitUntilVars{JSONPath: `{.status.conditions[?(@.type=="Available")].status}`, Value: "True"})
+	r.NoError(err)
+
+	// the deadline is kept across the reconciles and the step fails with the last observed state once exceeded
+	cli, _ = newClient(-1)
+	act = &mock.Action{}
+	runtimeParams = newRuntimeParams(cli, act)
+	_, err = waitUntil(context.Background(), runtimeParams, WaitUntilVars{Condition: "object.status.readyReplicas == 3", Timeout: "50ms"})
+	r.Equal(errors.GenericActionError(errors.ActionWait), err)
+	r.NotEmpty(runtimeParams.WorkflowContext.GetMutableValue("step-1", waitUntilDeadlineKey))
+	time.Sleep(60 * time.Millisecond)
+	_, err = waitUntil(context.Background(), runtimeParams, WaitUntilVars{Condition: "object.status.readyReplicas == 3", Timeout: "50ms"})
+	r.Equal(errors.GenericActionError(errors.ActionTerminate), err)
+	r.Equal("Fail", act.Phase)
+	r.Equal("Timeout waiting for Deployment default/web after 50ms: last observed {\"status\":{}}", act.Msg)
+
+	runtimeParams = newRuntimeParams(fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).Build(), act)
+	_, err = waitUntil(context.Background(), runtimeParams, WaitUntilVars{Condition: "object.status.readyReplicas == 3"})
+	r.Equal(errors.GenericActionError(errors.ActionWait), err)
+	r.Equal("Waiting for Deployment default/web: the object is not found", act.Msg)
+
+	_, err = waitUntil(context.Background(), newRuntimeParams(cli, &mock.Action{}), WaitUntilVars{Condition: "object.metadata.name"})
+	r.Error(err)
+	r.Contains(err.Error(), "invalid condition object.metadata.name")
+	_, err = waitUntil(context.Background(), newRuntimeParams(cli, &mock.Action{}), WaitUntilVars{Condition: "object.spec.replicas =="})
+	r.Error(err)
+	r.Contains(err.Error(), "invalid condition")
+	_, err = waitUntil(context.Background(), newRuntimeParams(cli, &mock.Action{}), WaitUntilVars{})
+	r.Error(err)
+	r.Contains(err.Error(), "either condition or jsonPath must be set")
+	_, err = waitUntil(context.Background(), newRuntimeParams(cli, &mock.Action{}), WaitUntilVars{Condition: "true", Interval: "0s"})
+	r.EqualError(err, "invalid interval 0s")
+}
+
+func TestWaitUntilWithoutStatus(t *testing.T) {
+	r := require.New(t)
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "token", Namespace: "default", Generation: 2},
+		Data:       map[string][]byte{"token": []byte("secret-value")},
+	}
+	cli := fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).WithObjects(secret).Build()
+	pCtx := process.NewContext(process.ContextData{Name: "run", Namespace: "default"})
+	pCtx.PushData(model.ContextStepSessionID, "step-1")
+	act := &mock.Action{}
+	_, err := WaitUntil(context.Background(), &WaitUntilParams{
+		Params: WaitUntilVars{
+			Target:    ObjectReference{APIVersion: "v1", Kind: "Secret", Name: "token"},
+			Condition: `object.metadata.labels.ready == "true"`,
+		},
+		RuntimeParams: providertypes.RuntimeParams{KubeClient: cli, Action: act, ProcessContext: pCtx, WorkflowContext: newWorkflowContextForTest(t)},
+	})
+	r.Equal(errors.GenericActionError(errors.ActionWait), err)
+	// the data of the object is not written into the step status
+	r.Equal("Waiting for Secret default/token: the condition is not met by generation 2", act.Msg)
+	r.Zero(act.Interval)
+}
@@ -0,0 +1,232 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/utils/ptr"
+
+	"github.com/kubevela/pkg/util/k8s"
+
+	providertypes "github.com/kubevela/workflow/pkg/providers/types"
+)
+
+const (
+	// LabelWorkloadName is the label of the workload name in the rendered resources
+	LabelWorkloadName = "app.kubernetes.io/name"
+)
+
+// WorkloadPort .
+type WorkloadPort struct {
+	Name     string `json:"name,omitempty"`
+	Port     int32  `json:"port"`
+	Protocol string `json:"protocol,omitempty"`
+}
+
+// WorkloadResources .
+type WorkloadResources struct {
+	CPU    string `json:"cpu,omitempty"`
+	Memory string `json:"memory,omitempty"`
+}
+
+// WorkloadVars is the simplified spec of the workload
+type WorkloadVars struct {
+	Name      string             `json:"name"`
+	Namespace string             `json:"namespace,omitempty"`
+	Image     string             `json:"image"`
+	Replicas  *int32             `json:"replicas,omitempty"`
+	Ports     []WorkloadPort     `json:"ports,omitempty"`
+	Env       map[string]string  `json:"env,omitempty"`
+	Resources *WorkloadResources `json:"resources,omitempty"`
+	Cluster   string             `json:"cluster,omitempty"`
+}
+
+// ObjectReference .
+type ObjectReference struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Name       string `json:"name"`
+	Namespace  string `json:"namespace"`
+}
+
+// WorkloadReturnVars .
+type WorkloadReturnVars struct {
+	Resources []ObjectReference `json:"resources"`
+}
+
+// WorkloadParams .
+type WorkloadParams = providertypes.Params[WorkloadVars]
+
+// WorkloadReturns .
+type WorkloadReturns = providertypes.Returns[WorkloadReturnVars]
+
+// Workload renders the Deployment and Service from the simplified spec and applies them.
+func Workload(ctx context.Context, params *WorkloadParams) (*WorkloadReturns, error) {
+	workloads, err := renderWorkload(params.Params)
+	if err != nil {
+		return nil, err
+	}
+	refs := make([]ObjectReference, 0, len(workloads))
+	for _, workload := range workloads {
+		for k, v := range params.RuntimeParams.Labels {
+			if err := k8s.AddLabel(workload, k, v); err != nil {
+				return nil, err
+			}
+		}
+		refs = append(refs, ObjectReference{
+			APIVersion: workload.GetAPIVersion(),
+			Kind:       workload.GetKind(),
+			Name:       workload.GetName(),
+			Namespace:  workload.GetNamespace(),
+		})
+	}
+	handlers := getHandlers(params.RuntimeParams)
+	deployCtx := handleContext(ctx, params.Params.Cluster)
+	if err := handlers.Apply(deployCtx, params.KubeClient, params.Params.Cluster, WorkflowResourceCreator, workloads...); err != nil {
+		return nil, err
+	}
+	return &WorkloadReturns{
+		Returns: WorkloadReturnVars{
+			Resources: refs,
+		},
+	}, nil
+}
+
+func validateWorkload(spec WorkloadVars) error {
+	if errs := validation.IsDNS1035Label(spec.Name); len(errs) > 0 {
+		return fmt.Errorf("invalid workload name %q: %v", spec.Name, errs)
+	}
+	if spec.Image == "" {
+		return fmt.Errorf("the image of workload %s is required", spec.Name)
+	}
+	if spec.Replicas != nil && *spec.Replicas < 0 {
+		return fmt.Errorf("invalid replicas %d of workload %s", *spec.Replicas, spec.Name)
+	}
+	for _, port := range spec.Ports {
+		if errs := validation.IsValidPortNum(int(port.Port)); len(errs) > 0 {
+			return fmt.Errorf("invalid port %d of workload %s: %v", port.Port, spec.Name, errs)
+		}
+		switch corev1.Protocol(port.Protocol) {
+		case "", corev1.ProtocolTCP, corev1.ProtocolUDP, corev1.ProtocolSCTP:
+		default:
+			return fmt.Errorf("invalid protocol %s of workload %s", port.Protocol, spec.Name)
+		}
+	}
+	return nil
+}
+
+func renderWorkload(spec WorkloadVars) ([]*unstructured.Unstructured, error) {
+	if err := validateWorkload(spec); err != nil {
+		return nil, err
+	}
+	if spec.Namespace == "" {
+		spec.Namespace = "default"
+	}
+	labels := map[string]string{LabelWorkloadName: spec.Name}
+	container := corev1.Container{
+		Name:  spec.Name,
+		Image: spec.Image,
+	}
+	envNames := make([]string, 0, len(spec.Env))
+	for name := range spec.Env {
+		envNames = append(envNames, name)
+	}
+	sort.Strings(envNames)
+	for _, name := range envNames {
+		container.Env = append(container.Env, corev1.EnvVar{Name: name, Value: spec.Env[name]})
+	}
+	if res := spec.Resources; res != nil {
+		list := corev1.ResourceList{}
+		for name, quantity := range map[corev1.ResourceName]string{corev1.ResourceCPU: res.CPU, corev1.ResourceMemory: res.Memory} {
+			if quantity == "" {
+				continue
+			}
+			q, err := resource.ParseQuantity(quantity)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s %q of workload %s: %w", name, quantity, spec.Name, err)
+			}
+			list[name] = q
+		}
+		container.Resources = corev1.ResourceRequirements{Requests: list, Limits: list}
+	}
+	var servicePorts []corev1.ServicePort
+	for i, port := range spec.Ports {
+		name := port.Name
+		if name == "" {
+			name = fmt.Sprintf("port-%d", port.Port)
+		}
+		protocol := corev1.Protocol(port.Protocol)
+		if protocol == "" {
+			protocol = corev1.ProtocolTCP
+		}
+		container.Ports = append(container.Ports, corev1.ContainerPort{Name: name, ContainerPort: port.Port, Protocol: protocol})
+		servicePorts = append(servicePorts, corev1.ServicePort{Name: name, Port: port.Port, TargetPort: intstr.FromInt32(port.Port), Protocol: protocol})
+		if i == 0 && protocol == corev1.ProtocolTCP {
+			probe := &corev1.Probe{ProbeHandler: corev1.ProbeHandler{TCPSocket: &corev1.TCPSocketAction{Port: intstr.FromInt32(port.Port)}}}
+			container.ReadinessProbe = probe
+			container.LivenessProbe = probe.DeepCopy()
+			container.LivenessProbe.InitialDelaySeconds = 10
+		}
+	}
+	replicas := spec.Replicas
+	if replicas == nil {
+		replicas = ptr.To[int32](1)
+	}
+	objs := []runtime.Object{&appsv1.Deployment{
+		TypeMeta:   metav1.TypeMeta{APIVersion: appsv1.SchemeGroupVersion.String(), Kind: "Deployment"},
+		ObjectMeta: metav1.ObjectMeta{Name: spec.Name, Namespace: spec.Namespace, Labels: labels},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: replicas,
+			Selector: &metav1.LabelSelector{MatchLabels: labels},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec:       corev1.PodSpec{Containers: []corev1.Container{container}},
+			},
+		},
+	}}
+	if len(servicePorts) > 0 {
+		objs = append(objs, &corev1.Service{
+			TypeMeta:   metav1.TypeMeta{APIVersion: corev1.SchemeGroupVersion.String(), Kind: "Service"},
+			ObjectMeta: metav1.ObjectMeta{Name: spec.Name, Namespace: spec.Namespace, Labels: labels},
+			Spec:       corev1.ServiceSpec{Selector: labels, Ports: servicePorts},
+		})
+	}
+	workloads := make([]*unstructured.Unstructured, 0, len(objs))
+	for _, obj := range objs {
+		u, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+		if err != nil {
+			return nil, err
+		}
+		workload := &unstructured.Unstructured{Object: u}
+		unstructured.RemoveNestedField(workload.Object, "status")
+		unstructured.RemoveNestedField(workload.Object, "metadata", "creationTimestamp")
+		unstructured.RemoveNestedField(workload.Object, "spec", "template", "metadata", "creationTimestamp")
+		workloads = append(workloads, workload)
+	}
+	return workloads, nil
+}
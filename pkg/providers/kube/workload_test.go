@@ -0,0 +1,110 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	providertypes "github.com/kubevela/workflow/pkg/providers/types"
+)
+
+func TestWorkload(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("minimal spec", func(t *testing.T) {
+		r := require.New(t)
+		cli := fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).Build()
+		res, err := Workload(ctx, &WorkloadParams{
+			Params:        WorkloadVars{Name: "app", Image: "nginx"},
+			RuntimeParams: providertypes.RuntimeParams{KubeClient: cli},
+		})
+		r.NoError(err)
+		r.Equal([]ObjectReference{{APIVersion: "apps/v1", Kind: "Deployment", Name: "app", Namespace: "default"}}, res.Returns.Resources)
+
+		deploy := &appsv1.Deployment{}
+		r.NoError(cli.Get(ctx, client.ObjectKey{Name: "app", Namespace: "default"}, deploy))
+		r.Equal(int32(1), *deploy.Spec.Replicas)
+		r.Equal(map[string]string{LabelWorkloadName: "app"}, deploy.Spec.Selector.MatchLabels)
+		r.Equal(map[string]string{LabelWorkloadName: "app"}, deploy.Spec.Template.Labels)
+		r.Len(deploy.Spec.Template.Spec.Containers, 1)
+		r.Equal("nginx", deploy.Spec.Template.Spec.Containers[0].Image)
+		r.Nil(deploy.Spec.Template.Spec.Containers[0].ReadinessProbe)
+		r.Error(cli.Get(ctx, client.ObjectKey{Name: "app", Namespace: "default"}, &corev1.Service{}))
+	})
+
+	t.Run("full spec", func(t *testing.T) {
+		r := require.New(t)
+		cli := fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).Build()
+		res, err := Workload(ctx, &WorkloadParams{
+			Params: WorkloadVars{
+				Name:      "app",
+				Namespace: "prod",
+				Image:     "nginx:1.25",
+				Replicas:  ptr.To[int32](3),
+				Ports:     []WorkloadPort{{Name: "http", Port: 80}, {Port: 53, Protocol: "UDP"}},
+				Env:       map[string]string{"B": "2", "A": "1"},
+				Resources: &WorkloadResources{CPU: "500m", Memory: "128Mi"},
+			},
+			RuntimeParams: providertypes.RuntimeParams{KubeClient: cli, Labels: map[string]string{"workflowrun.oam.dev/name": "run"}},
+		})
+		r.NoError(err)
+		r.Len(res.Returns.Resources, 2)
+		r.Equal(ObjectReference{APIVersion: "v1", Kind: "Service", Name: "app", Namespace: "prod"}, res.Returns.Resources[1])
+
+		deploy := &appsv1.Deployment{}
+		r.NoError(cli.Get(ctx, client.ObjectKey{Name: "app", Namespace: "prod"}, deploy))
+		r.Equal(int32(3), *deploy.Spec.Replicas)
+		r.Equal("run", deploy.Labels["workflowrun.oam.dev/name"])
+		container := deploy.Spec.Template.Spec.Containers[0]
+		r.Equal([]corev1.EnvVar{{Name: "A", Value: "1"}, {Name: "B", Value: "2"}}, container.Env)
+		r.Equal(resource.MustParse("500m"), container.Resources.Limits[corev1.ResourceCPU])
+		r.Equal(resource.MustParse("128Mi"), container.Resources.Requests[corev1.ResourceMemory])
+		r.Len(container.Ports, 2)
+		r.Equal("port-53", container.Ports[1].Name)
+		r.Equal(int32(80), container.ReadinessProbe.TCPSocket.Port.IntVal)
+		r.Equal(int32(80), container.LivenessProbe.TCPSocket.Port.IntVal)
+
+		svc := &corev1.Service{}
+		r.NoError(cli.Get(ctx, client.ObjectKey{Name: "app", Namespace: "prod"}, svc))
+		r.Equal(map[string]string{LabelWorkloadName: "app"}, svc.Spec.Selector)
+		r.Len(svc.Spec.Ports, 2)
+		r.Equal(corev1.ProtocolUDP, svc.Spec.Ports[1].Protocol)
+	})
+
+	t.Run("invalid spec", func(t *testing.T) {
+		for name, spec := range map[string]WorkloadVars{
+			"invalid workload name": {Name: "App_1", Image: "nginx"},
+			"image of workload":     {Name: "app"},
+			"invalid port":          {Name: "app", Image: "nginx", Ports: []WorkloadPort{{Port: 70000}}},
+			"invalid protocol":      {Name: "app", Image: "nginx", Ports: []WorkloadPort{{Port: 80, Protocol: "HTTP"}}},
+			"invalid cpu":           {Name: "app", Image: "nginx", Resources: &WorkloadResources{CPU: "a lot"}},
+		} {
+			_, err := Workload(ctx, &WorkloadParams{Params: spec})
+			require.ErrorContains(t, err, name)
+		}
+	})
+}
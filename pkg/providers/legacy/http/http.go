@@ -35,6 +35,7 @@ import (
 	cuexruntime "github.com/kubevela/pkg/cue/cuex/runtime"
 
 	"github.com/kubevela/workflow/pkg/cue/model"
+	"github.com/kubevela/workflow/pkg/monitor/trace"
 	"github.com/kubevela/workflow/pkg/providers/legacy/http/ratelimiter"
 	providertypes "github.com/kubevela/workflow/pkg/providers/types"
 )
@@ -135,6 +136,7 @@ func runHTTP(ctx context.Context, params *DoParams) (*ResponseVars, error) {
 		header.Set("Content-Type", "application/json")
 	}
 
+	trace.InjectHeaders(header, params.ProcessContext)
 	req, err := http.NewRequestWithContext(context.Background(), method, url, reader)
 	if err != nil {
 		return nil, err
@@ -33,8 +33,13 @@ import (
 	"github.com/crossplane/crossplane-runtime/pkg/test"
 	"github.com/stretchr/testify/require"
 	v1 "k8s.io/api/core/v1"
+	utilfeature "k8s.io/apiserver/pkg/util/feature"
+	featuregatetesting "k8s.io/component-base/featuregate/testing"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
+	"github.com/kubevela/workflow/pkg/cue/model"
+	"github.com/kubevela/workflow/pkg/cue/process"
+	"github.com/kubevela/workflow/pkg/features"
 	"github.com/kubevela/workflow/pkg/providers/legacy/http/ratelimiter"
 	"github.com/kubevela/workflow/pkg/providers/legacy/http/testdata"
 	"github.com/kubevela/workflow/pkg/providers/types"
@@ -260,6 +265,27 @@ func runMockServer(shutdown chan struct{}) {
 	}
 }
 
+func TestHTTPDoWithTraceContext(t *testing.T) {
+	r := require.New(t)
+	defer featuregatetesting.SetFeatureGateDuringTest(t, utilfeature.DefaultFeatureGate, features.EnableTracePropagation, true)()
+	var received http.Header
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		received = req.Header.Clone()
+	}))
+	defer ts.Close()
+
+	pCtx := process.NewContext(process.ContextData{WorkflowName: "wf"})
+	pCtx.PushData(model.ContextStepName, "step")
+	pCtx.PushData(model.ContextSpanID, "abc.step")
+	_, err := Do(context.Background(), &DoParams{
+		Params:        RequestVars{Method: "GET", URL: ts.URL},
+		RuntimeParams: types.RuntimeParams{ProcessContext: pCtx},
+	})
+	r.NoError(err)
+	r.Regexp("^00-[0-9a-f]{32}-[0-9a-f]{16}-01$", received.Get("traceparent"))
+	r.Equal("workflowName=wf,stepName=step", received.Get("baggage"))
+}
+
 func TestHTTPSDo(t *testing.T) {
 	ctx := context.Background()
 	s := newMockHttpsServer()
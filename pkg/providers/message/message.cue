@@ -0,0 +1,16 @@
+// message.cue
+
+#Message: {
+	#do:       "message"
+	#provider: "message"
+
+	$params: {
+		// +usage=The message shown in the step status, note that the message might be overridden by the next message
+		text: string
+		// +usage=The level of the message, the warning message is recorded into the warnings of the step as well
+		level: *"info" | "warning"
+		// +usage=Whether to show the message in the workflow status as well
+		workflow: *false | bool
+	}
+	...
+}
@@ -0,0 +1,90 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package message
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+
+	cuexruntime "github.com/kubevela/pkg/cue/cuex/runtime"
+
+	providertypes "github.com/kubevela/workflow/pkg/providers/types"
+	"github.com/kubevela/workflow/pkg/types"
+)
+
+const (
+	// ProviderName is provider name for install.
+	ProviderName = "message"
+
+	// LevelInfo is the level of the message which is only shown in the status
+	LevelInfo = "info"
+	// LevelWarning is the level of the message which is recorded as a warning of the step as well
+	LevelWarning = "warning"
+)
+
+// MessageVars .
+type MessageVars struct {
+	Text     string `json:"text"`
+	Level    string `json:"level,omitempty"`
+	Workflow bool   `json:"workflow,omitempty"`
+}
+
+// MessageParams .
+type MessageParams = providertypes.Params[MessageVars]
+
+// Message writes the text into the step status, the text is written into the workflow status as well if
+// the workflow is set. Note that the message will be overwritten by the next message.
+func Message(_ context.Context, params *MessageParams) (*any, error) {
+	vars := params.Params
+	if vars.Text == "" {
+		return nil, fmt.Errorf("the text of the message is required")
+	}
+	switch vars.Level {
+	case "", LevelInfo:
+	case LevelWarning:
+		if warner, ok := params.Action.(types.Warner); ok {
+			warner.Warn(vars.Text)
+		}
+	default:
+		return nil, fmt.Errorf("invalid message level %s, the supported levels are: %s, %s", vars.Level, LevelInfo, LevelWarning)
+	}
+	params.Action.Message(vars.Text)
+	if vars.Workflow {
+		messenger, ok := params.Action.(types.WorkflowMessenger)
+		if !ok {
+			return nil, fmt.Errorf("the workflow message is not supported by the step")
+		}
+		messenger.WorkflowMessage(vars.Text)
+	}
+	return nil, nil
+}
+
+//go:embed message.cue
+var template string
+
+// GetTemplate returns the cue template.
+func GetTemplate() string {
+	return template
+}
+
+// GetProviders returns the cue providers.
+func GetProviders() map[string]cuexruntime.ProviderFn {
+	return map[string]cuexruntime.ProviderFn{
+		"message": providertypes.GenericProviderFn[MessageVars, any](Message),
+	}
+}
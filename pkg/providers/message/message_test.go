@@ -0,0 +1,73 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package message
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kubevela/workflow/pkg/mock"
+	providertypes "github.com/kubevela/workflow/pkg/providers/types"
+)
+
+func TestMessage(t *testing.T) {
+	ctx := context.Background()
+	testCases := map[string]struct {
+		vars     MessageVars
+		expected mock.Action
+		err      string
+	}{
+		"info": {
+			vars:     MessageVars{Text: "3/5 replicas are ready"},
+			expected: mock.Action{Phase: "Fail", Msg: "3/5 replicas are ready"},
+		},
+		"warning": {
+			vars:     MessageVars{Text: "the quota is almost exhausted", Level: LevelWarning},
+			expected: mock.Action{Phase: "Fail", Msg: "the quota is almost exhausted", Warnings: []string{"the quota is almost exhausted"}},
+		},
+		"workflow": {
+			vars:     MessageVars{Text: "promoted to prod", Workflow: true},
+			expected: mock.Action{Phase: "Fail", Msg: "promoted to prod", WorkflowMsg: "promoted to prod"},
+		},
+		"empty text": {
+			vars: MessageVars{},
+			err:  "the text of the message is required",
+		},
+		"invalid level": {
+			vars: MessageVars{Text: "msg", Level: "debug"},
+			err:  "invalid message level debug, the supported levels are: info, warning",
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			r := require.New(t)
+			act := &mock.Action{}
+			_, err := Message(ctx, &MessageParams{
+				Params:        tc.vars,
+				RuntimeParams: providertypes.RuntimeParams{Action: act},
+			})
+			if tc.err != "" {
+				r.EqualError(err, tc.err)
+				return
+			}
+			r.NoError(err)
+			r.Equal(tc.expected, *act)
+		})
+	}
+}
@@ -0,0 +1,135 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	prommodel "github.com/prometheus/common/model"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	providertypes "github.com/kubevela/workflow/pkg/providers/types"
+)
+
+const defaultCustomMetricHelp = "Custom metric emitted by the workflow steps."
+
+// Registerer is the registerer of the custom metrics emitted by the steps, the metrics are exposed on the
+// metrics endpoint of the controller by default.
+var Registerer prometheus.Registerer = metrics.Registry
+
+var customMetrics = struct {
+	sync.Mutex
+	collectors map[string]*customMetric
+}{collectors: map[string]*customMetric{}}
+
+type customMetric struct {
+	kind   string
+	labels []string
+	vec    interface{}
+}
+
+// CustomMetricVars .
+type CustomMetricVars struct {
+	Name   string            `json:"name"`
+	Help   string            `json:"help,omitempty"`
+	Labels map[string]string `json:"labels,omitempty"`
+	Value  *float64          `json:"value,omitempty"`
+}
+
+// CustomMetricParams .
+type CustomMetricParams = providertypes.Params[CustomMetricVars]
+
+// Counter adds the value to the counter with the labels, the value is default to 1. The counter is registered
+// on the first call, the following calls with the same name must use the same label names.
+func Counter(_ context.Context, params *CustomMetricParams) (*any, error) {
+	vars := params.Params
+	value := 1.0
+	if vars.Value != nil {
+		value = *vars.Value
+	}
+	if value < 0 {
+		return nil, fmt.Errorf("invalid value %v for counter %s, the counter can only be increased", value, vars.Name)
+	}
+	vec, err := getCustomMetric(vars, "counter", func(opts prometheus.Opts, labels []string) prometheus.Collector {
+		return prometheus.NewCounterVec(prometheus.CounterOpts(opts), labels)
+	})
+	if err != nil {
+		return nil, err
+	}
+	vec.(*prometheus.CounterVec).With(vars.Labels).Add(value)
+	return nil, nil
+}
+
+// Gauge sets the gauge with the labels to the value. The gauge is registered on the first call, the following
+// calls with the same name must use the same label names.
+func Gauge(_ context.Context, params *CustomMetricParams) (*any, error) {
+	vars := params.Params
+	if vars.Value == nil {
+		return nil, fmt.Errorf("the value of gauge %s is required", vars.Name)
+	}
+	vec, err := getCustomMetric(vars, "gauge", func(opts prometheus.Opts, labels []string) prometheus.Collector {
+		return prometheus.NewGaugeVec(prometheus.GaugeOpts(opts), labels)
+	})
+	if err != nil {
+		return nil, err
+	}
+	vec.(*prometheus.GaugeVec).With(vars.Labels).Set(*vars.Value)
+	return nil, nil
+}
+
+// getCustomMetric returns the registered collector of the metric, the collector is created and registered
+// if the metric is not registered yet
+func getCustomMetric(vars CustomMetricVars, kind string, newCollector func(prometheus.Opts, []string) prometheus.Collector) (interface{}, error) {
+	if !prommodel.IsValidMetricName(prommodel.LabelValue(vars.Name)) {
+		return nil, fmt.Errorf("invalid metric name %q, the name must match %s", vars.Name, prommodel.MetricNameRE.String())
+	}
+	labels := make([]string, 0, len(vars.Labels))
+	for label := range vars.Labels {
+		if !prommodel.LabelName(label).IsValid() || strings.HasPrefix(label, prommodel.ReservedLabelPrefix) {
+			return nil, fmt.Errorf("invalid label name %q for metric %s", label, vars.Name)
+		}
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+
+	customMetrics.Lock()
+	defer customMetrics.Unlock()
+	if m, ok := customMetrics.collectors[vars.Name]; ok {
+		if m.kind != kind {
+			return nil, fmt.Errorf("metric %s is already registered as a %s", vars.Name, m.kind)
+		}
+		if strings.Join(m.labels, ",") != strings.Join(labels, ",") {
+			return nil, fmt.Errorf("metric %s is already registered with the labels [%s], got [%s]", vars.Name, strings.Join(m.labels, ", "), strings.Join(labels, ", "))
+		}
+		return m.vec, nil
+	}
+	help := vars.Help
+	if help == "" {
+		help = defaultCustomMetricHelp
+	}
+	collector := newCollector(prometheus.Opts{Name: vars.Name, Help: help}, labels)
+	if err := Registerer.Register(collector); err != nil {
+		return nil, fmt.Errorf("failed to register metric %s: %w", vars.Name, err)
+	}
+	customMetrics.collectors[vars.Name] = &customMetric{kind: kind, labels: labels, vec: collector}
+	return collector, nil
+}
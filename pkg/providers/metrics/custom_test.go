@@ -0,0 +1,129 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"context"
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCustomMetrics(t *testing.T) {
+	r := require.New(t)
+	ctx := context.Background()
+	registry := prometheus.NewRegistry()
+	originalRegisterer := Registerer
+	Registerer = registry
+	defer func() { Registerer = originalRegisterer }()
+	value := func(v float64) *float64 { return &v }
+
+	_, err := Counter(ctx, &CustomMetricParams{Params: CustomMetricVars{
+		Name:   "deployments_promoted_total",
+		Help:   "The number of the promoted deployments.",
+		Labels: map[string]string{"app": "web", "env": "prod"},
+	}})
+	r.NoError(err)
+	_, err = Counter(ctx, &CustomMetricParams{Params: CustomMetricVars{
+		Name:   "deployments_promoted_total",
+		Labels: map[string]string{"env": "prod", "app": "web"},
+		Value:  value(2),
+	}})
+	r.NoError(err)
+	_, err = Counter(ctx, &CustomMetricParams{Params: CustomMetricVars{
+		Name:   "deployments_promoted_total",
+		Labels: map[string]string{"app": "api", "env": "prod"},
+	}})
+	r.NoError(err)
+	_, err = Gauge(ctx, &CustomMetricParams{Params: CustomMetricVars{
+		Name:  "canary_weight",
+		Value: value(20),
+	}})
+	r.NoError(err)
+	_, err = Gauge(ctx, &CustomMetricParams{Params: CustomMetricVars{
+		Name:  "canary_weight",
+		Value: value(50),
+	}})
+	r.NoError(err)
+
+	scraped := scrape(t, registry)
+	r.Contains(scraped, "# HELP deployments_promoted_total The number of the promoted deployments.\n")
+	r.Contains(scraped, "# TYPE deployments_promoted_total counter\n")
+	r.Contains(scraped, `deployments_promoted_total{app="web",env="prod"} 3`+"\n")
+	r.Contains(scraped, `deployments_promoted_total{app="api",env="prod"} 1`+"\n")
+	r.Contains(scraped, "# TYPE canary_weight gauge\n")
+	r.Contains(scraped, "canary_weight 50\n")
+
+	testCases := map[string]struct {
+		fn   func(context.Context, *CustomMetricParams) (*any, error)
+		vars CustomMetricVars
+		err  string
+	}{
+		"invalid name": {
+			fn:   Counter,
+			vars: CustomMetricVars{Name: "deployments-promoted"},
+			err:  `invalid metric name "deployments-promoted"`,
+		},
+		"invalid label": {
+			fn:   Counter,
+			vars: CustomMetricVars{Name: "steps_total", Labels: map[string]string{"__name": "x"}},
+			err:  `invalid label name "__name" for metric steps_total`,
+		},
+		"negative counter": {
+			fn:   Counter,
+			vars: CustomMetricVars{Name: "steps_total", Value: value(-1)},
+			err:  "invalid value -1 for counter steps_total, the counter can only be increased",
+		},
+		"gauge without value": {
+			fn:   Gauge,
+			vars: CustomMetricVars{Name: "queue_size"},
+			err:  "the value of gauge queue_size is required",
+		},
+		"different kind": {
+			fn:   Gauge,
+			vars: CustomMetricVars{Name: "deployments_promoted_total", Value: value(1)},
+			err:  "metric deployments_promoted_total is already registered as a counter",
+		},
+		"different labels": {
+			fn:   Counter,
+			vars: CustomMetricVars{Name: "deployments_promoted_total", Labels: map[string]string{"app": "web"}},
+			err:  "metric deployments_promoted_total is already registered with the labels [app, env], got [app]",
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			_, err := tc.fn(ctx, &CustomMetricParams{Params: tc.vars})
+			require.Error(t, err)
+			require.Contains(t, err.Error(), tc.err)
+		})
+	}
+}
+
+func scrape(t *testing.T, registry *prometheus.Registry) string {
+	server := httptest.NewServer(promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+	defer server.Close()
+	resp, err := server.Client().Get(server.URL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return string(b)
+}
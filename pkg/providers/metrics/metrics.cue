@@ -19,3 +19,31 @@
 	}
 	...
 }
+
+#CustomMetric: {
+	name:    string
+	help?:   string
+	labels?: [string]: string
+}
+
+#Counter: {
+	#do:       "counter"
+	#provider: "metrics"
+
+	$params: {
+		#CustomMetric
+		value?: number
+	}
+	...
+}
+
+#Gauge: {
+	#do:       "gauge"
+	#provider: "metrics"
+
+	$params: {
+		#CustomMetric
+		value: number
+	}
+	...
+}
@@ -226,5 +226,7 @@ func GetTemplate() string {
 func GetProviders() map[string]cuexruntime.ProviderFn {
 	return map[string]cuexruntime.ProviderFn{
 		"promCheck": providertypes.GenericProviderFn[PromVars, PromReturns](PromCheck),
+		"counter":   providertypes.GenericProviderFn[CustomMetricVars, any](Counter),
+		"gauge":     providertypes.GenericProviderFn[CustomMetricVars, any](Gauge),
 	}
 }
@@ -0,0 +1,66 @@
+// multicluster.cue
+
+#Apply: {
+	#do:       "apply"
+	#provider: "multicluster"
+
+	$params: {
+		// +usage=The cluster to apply the resource to, use local for the local cluster
+		cluster: string
+		// +usage=The resource to apply
+		value: {...}
+	}
+
+	$returns?: {
+		// +usage=The resource after applied will be filled in this field after the action is executed
+		value?: {...}
+		// +usage=The error message if the action failed
+		err?: string
+	}
+	...
+}
+
+#Read: {
+	#do:       "read"
+	#provider: "multicluster"
+
+	$params: {
+		// +usage=The cluster to read the resource from, use local for the local cluster
+		cluster: string
+		// +usage=The resource to read
+		value: {...}
+	}
+
+	$returns?: {
+		// +usage=The read resource will be filled in this field after the action is executed
+		value?: {...}
+		// +usage=The error message if the action failed
+		err?: string
+	}
+	...
+}
+
+#Delete: {
+	#do:       "delete"
+	#provider: "multicluster"
+
+	$params: {
+		// +usage=The cluster to delete the resource from, use local for the local cluster
+		cluster: string
+		// +usage=The resource to delete
+		value: {
+			apiVersion: string
+			kind:       string
+			metadata: {
+				name:      string
+				namespace: *"default" | string
+			}
+		}
+	}
+
+	$returns?: {
+		// +usage=The error message if the action failed
+		err?: string
+	}
+	...
+}
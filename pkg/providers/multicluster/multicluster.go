@@ -0,0 +1,126 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package multicluster
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	cuexruntime "github.com/kubevela/pkg/cue/cuex/runtime"
+	"github.com/kubevela/pkg/multicluster"
+
+	"github.com/kubevela/workflow/pkg/providers/kube"
+	providertypes "github.com/kubevela/workflow/pkg/providers/types"
+)
+
+const (
+	// ProviderName is provider name for install.
+	ProviderName = "multicluster"
+	// LabelClusterCredentialType is the label of the secrets of the managed clusters registered in the cluster gateway
+	LabelClusterCredentialType = "cluster.core.oam.dev/cluster-credential-type"
+)
+
+// ClusterSecretNamespace is the namespace of the secrets of the managed clusters
+var ClusterSecretNamespace = "vela-system"
+
+// ResourceParams .
+type ResourceParams = providertypes.Params[kube.ResourceVars]
+
+// ListClusters returns the names of the managed clusters registered in the cluster gateway, the local cluster
+// is not included.
+func ListClusters(ctx context.Context, cli client.Client) ([]string, error) {
+	secrets := &corev1.SecretList{}
+	if err := cli.List(multicluster.WithCluster(ctx, multicluster.Local), secrets, client.InNamespace(ClusterSecretNamespace), client.HasLabels{LabelClusterCredentialType}); err != nil {
+		return nil, fmt.Errorf("failed to list the clusters: %w", err)
+	}
+	clusters := make([]string, 0, len(secrets.Items))
+	for _, secret := range secrets.Items {
+		clusters = append(clusters, secret.Name)
+	}
+	sort.Strings(clusters)
+	return clusters, nil
+}
+
+// resolveCluster checks the cluster is known and returns the cluster used by the kube provider, which is
+// empty for the local cluster
+func resolveCluster(ctx context.Context, params *ResourceParams) error {
+	cluster := params.Params.Cluster
+	if cluster == "" {
+		return fmt.Errorf("the cluster is not specified, use %s for the local cluster", multicluster.Local)
+	}
+	if multicluster.IsLocal(cluster) {
+		params.Params.Cluster = ""
+		return nil
+	}
+	clusters, err := ListClusters(ctx, params.KubeClient)
+	if err != nil {
+		return err
+	}
+	for _, c := range clusters {
+		if c == cluster {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown cluster %s, the known clusters are: %s", cluster, strings.Join(append([]string{multicluster.Local}, clusters...), ", "))
+}
+
+// Apply applies the resource to the cluster.
+func Apply(ctx context.Context, params *ResourceParams) (*kube.ResourceReturns, error) {
+	if err := resolveCluster(ctx, params); err != nil {
+		return nil, err
+	}
+	return kube.Apply(ctx, params)
+}
+
+// Read reads the resource from the cluster.
+func Read(ctx context.Context, params *ResourceParams) (*kube.ResourceReturns, error) {
+	if err := resolveCluster(ctx, params); err != nil {
+		return nil, err
+	}
+	return kube.Read(ctx, params)
+}
+
+// Delete deletes the resource from the cluster.
+func Delete(ctx context.Context, params *ResourceParams) (*kube.ResourceReturns, error) {
+	if err := resolveCluster(ctx, params); err != nil {
+		return nil, err
+	}
+	return kube.Delete(ctx, params)
+}
+
+//go:embed multicluster.cue
+var template string
+
+// GetTemplate returns the cue template.
+func GetTemplate() string {
+	return template
+}
+
+// GetProviders returns the cue providers.
+func GetProviders() map[string]cuexruntime.ProviderFn {
+	return map[string]cuexruntime.ProviderFn{
+		"apply":  providertypes.GenericProviderFn[kube.ResourceVars, kube.ResourceReturns](Apply),
+		"read":   providertypes.GenericProviderFn[kube.ResourceVars, kube.ResourceReturns](Read),
+		"delete": providertypes.GenericProviderFn[kube.ResourceVars, kube.ResourceReturns](Delete),
+	}
+}
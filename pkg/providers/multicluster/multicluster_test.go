@@ -0,0 +1,119 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package multicluster
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	"github.com/kubevela/pkg/multicluster"
+
+	"github.com/kubevela/workflow/pkg/providers/kube"
+	providertypes "github.com/kubevela/workflow/pkg/providers/types"
+)
+
+func TestMulticlusterResource(t *testing.T) {
+	r := require.New(t)
+	ctx := context.Background()
+	clusterSecret := func(name string) *corev1.Secret {
+		return &corev1.Secret{ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: ClusterSecretNamespace,
+			Labels:    map[string]string{LabelClusterCredentialType: "X509Certificate"},
+		}}
+	}
+	var clusters []string
+	record := func(ctx context.Context, obj client.Object) {
+		if _, ok := obj.(*unstructured.Unstructured); ok {
+			cluster, _ := multicluster.ClusterFrom(ctx)
+			clusters = append(clusters, cluster)
+		}
+	}
+	cli := fake.NewClientBuilder().WithObjects(
+		clusterSecret("cluster-b"),
+		clusterSecret("cluster-a"),
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: ClusterSecretNamespace}},
+	).WithInterceptorFuncs(interceptor.Funcs{
+		Get: func(ctx context.Context, cli client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+			record(ctx, obj)
+			return cli.Get(ctx, key, obj, opts...)
+		},
+		Create: func(ctx context.Context, cli client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+			record(ctx, obj)
+			return cli.Create(ctx, obj, opts...)
+		},
+		Delete: func(ctx context.Context, cli client.WithWatch, obj client.Object, opts ...client.DeleteOption) error {
+			record(ctx, obj)
+			return cli.Delete(ctx, obj, opts...)
+		},
+	}).Build()
+	newParams := func(cluster string) *ResourceParams {
+		resource := &unstructured.Unstructured{}
+		resource.SetAPIVersion("v1")
+		resource.SetKind("ConfigMap")
+		resource.SetName("test")
+		resource.SetNamespace("default")
+		return &ResourceParams{
+			Params:        kube.ResourceVars{Resource: resource, Cluster: cluster},
+			RuntimeParams: providertypes.RuntimeParams{KubeClient: cli},
+		}
+	}
+
+	clusters = nil
+	_, err := Apply(ctx, newParams("cluster-a"))
+	r.NoError(err)
+	r.NotEmpty(clusters)
+	for _, cluster := range clusters {
+		r.Equal("cluster-a", cluster)
+	}
+
+	clusters = nil
+	res, err := Read(ctx, newParams("cluster-a"))
+	r.NoError(err)
+	r.Equal("test", res.Returns.Resource.GetName())
+	r.Equal([]string{"cluster-a"}, clusters)
+
+	clusters = nil
+	_, err = Delete(ctx, newParams("cluster-a"))
+	r.NoError(err)
+	r.Contains(clusters, "cluster-a")
+	r.NotContains(clusters, "")
+
+	clusters = nil
+	_, err = Apply(ctx, newParams(multicluster.Local))
+	r.NoError(err)
+	r.NotEmpty(clusters)
+	for _, cluster := range clusters {
+		r.Equal("", cluster)
+	}
+
+	clusters = nil
+	_, err = Apply(ctx, newParams("cluster-c"))
+	r.EqualError(err, "unknown cluster cluster-c, the known clusters are: local, cluster-a, cluster-b")
+	r.Empty(clusters)
+
+	_, err = Read(ctx, newParams(""))
+	r.EqualError(err, "the cluster is not specified, use local for the local cluster")
+}
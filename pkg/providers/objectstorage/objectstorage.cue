@@ -0,0 +1,108 @@
+// objectstorage.cue
+
+#Storage: {
+	// +usage=The url of the S3 compatible object storage, e.g. https://s3.us-west-2.amazonaws.com or http://minio.minio:9000, https is used if the scheme is not specified
+	endpoint: string
+	// +usage=The bucket of the objects
+	bucket: string
+	// +usage=The region used to sign the requests, default to us-east-1
+	region?: string
+	// +usage=The secret which contains the accessKeyID, secretAccessKey and the optional sessionToken, the requests are anonymous if not set
+	secretRef?: {
+		// +usage=The name of the secret
+		name: string
+		// +usage=The namespace of the secret, default to the namespace of the workflow
+		namespace?: string
+	}
+}
+
+#Get: {
+	#do:       "get"
+	#provider: "objectstorage"
+
+	$params: {
+		#Storage
+		// +usage=The key of the object
+		key: string
+		// +usage=The limit in bytes of the object, the step fails if exceeded, default to 10MiB
+		maxBytes?: int
+	}
+
+	$returns?: {
+		// +usage=The content of the object
+		content: string
+		// +usage=The size in bytes of the object
+		size: int
+		// +usage=The content type of the object
+		contentType?: string
+		// +usage=The etag of the object
+		etag?: string
+	}
+	...
+}
+
+#Put: {
+	#do:       "put"
+	#provider: "objectstorage"
+
+	$params: {
+		#Storage
+		// +usage=The key of the object
+		key: string
+		// +usage=The inline content of the object
+		content?: string
+		// +usage=The variable in the workflow context as the content of the object, the variables other than strings are encoded as JSON
+		contentFrom?: {
+			// +usage=The path of the variable, e.g. outputs.report
+			var: string
+		}
+		// +usage=The content type of the object
+		contentType?: string
+	}
+
+	$returns?: {
+		// +usage=The size in bytes of the written object
+		size: int
+		// +usage=The etag of the written object
+		etag?: string
+	}
+	...
+}
+
+#List: {
+	#do:       "list"
+	#provider: "objectstorage"
+
+	$params: {
+		#Storage
+		// +usage=The prefix of the keys to list
+		prefix?: string
+		// +usage=The max number of the objects to return, default to 1000
+		maxKeys?: int
+	}
+
+	$returns?: {
+		// +usage=The objects sorted by the keys
+		objects: [...{
+			key:           string
+			size:          int
+			etag?:         string
+			lastModified?: string
+		}]
+		// +usage=Whether there are more objects than the max keys
+		truncated: bool
+	}
+	...
+}
+
+#Delete: {
+	#do:       "delete"
+	#provider: "objectstorage"
+
+	$params: {
+		#Storage
+		// +usage=The key of the object
+		key: string
+	}
+	...
+}
@@ -0,0 +1,444 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package objectstorage
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	cuexruntime "github.com/kubevela/pkg/cue/cuex/runtime"
+
+	"github.com/kubevela/workflow/pkg/cue/model"
+	providertypes "github.com/kubevela/workflow/pkg/providers/types"
+)
+
+const (
+	// ProviderName is provider name for install.
+	ProviderName = "objectstorage"
+	// DefaultRegion is the region used to sign the requests if the region is not specified
+	DefaultRegion = "us-east-1"
+	// DefaultMaxKeys is the default max number of the objects returned by list
+	DefaultMaxKeys = 1000
+
+	accessKeyIDKey     = "accessKeyID"
+	secretAccessKeyKey = "secretAccessKey"
+	sessionTokenKey    = "sessionToken"
+)
+
+var (
+	// DefaultMaxObjectBytes is the default limit of the object read by get
+	DefaultMaxObjectBytes int64 = 10 << 20
+
+	httpClient = &http.Client{Timeout: time.Minute}
+)
+
+// SecretRef is the reference of the secret which contains the accessKeyID, secretAccessKey and the optional
+// sessionToken of the object storage.
+type SecretRef struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// Storage is the bucket of the S3 compatible object storage, the requests are sent in the path style so that
+// the storages like MinIO work without the virtual hosted buckets.
+type Storage struct {
+	// Endpoint is the url of the object storage, https is used if the scheme is not specified
+	Endpoint  string     `json:"endpoint"`
+	Bucket    string     `json:"bucket"`
+	Region    string     `json:"region,omitempty"`
+	SecretRef *SecretRef `json:"secretRef,omitempty"`
+}
+
+// GetVars .
+type GetVars struct {
+	Storage
+	Key string `json:"key"`
+	// MaxBytes is the limit of the object, DefaultMaxObjectBytes is used if not set
+	MaxBytes int64 `json:"maxBytes,omitempty"`
+}
+
+// GetReturnVars .
+type GetReturnVars struct {
+	Content     string `json:"content"`
+	Size        int64  `json:"size"`
+	ContentType string `json:"contentType,omitempty"`
+	ETag        string `json:"etag,omitempty"`
+}
+
+// GetParams .
+type GetParams = providertypes.Params[GetVars]
+
+// GetReturns .
+type GetReturns = providertypes.Returns[GetReturnVars]
+
+// ContentFrom is the reference of the content in the workflow context.
+type ContentFrom struct {
+	// Var is the path of the variable in the workflow context, e.g. outputs.report, the variables other than
+	// strings are encoded as JSON
+	Var string `json:"var"`
+}
+
+// PutVars .
+type PutVars struct {
+	Storage
+	Key         string       `json:"key"`
+	Content     *string      `json:"content,omitempty"`
+	ContentFrom *ContentFrom `json:"contentFrom,omitempty"`
+	ContentType string       `json:"contentType,omitempty"`
+}
+
+// PutReturnVars .
+type PutReturnVars struct {
+	Size int64  `json:"size"`
+	ETag string `json:"etag,omitempty"`
+}
+
+// PutParams .
+type PutParams = providertypes.Params[PutVars]
+
+// PutReturns .
+type PutReturns = providertypes.Returns[PutReturnVars]
+
+// ListVars .
+type ListVars struct {
+	Storage
+	Prefix string `json:"prefix,omitempty"`
+	// MaxKeys is the max number of the objects returned, DefaultMaxKeys is used if not set
+	MaxKeys int `json:"maxKeys,omitempty"`
+}
+
+// Object is an object in the bucket.
+type Object struct {
+	Key          string `json:"key"`
+	Size         int64  `json:"size"`
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"lastModified,omitempty"`
+}
+
+// ListReturnVars .
+type ListReturnVars struct {
+	Objects []Object `json:"objects"`
+	// Truncated is true if there are more objects than the max keys
+	Truncated bool `json:"truncated"`
+}
+
+// ListParams .
+type ListParams = providertypes.Params[ListVars]
+
+// ListReturns .
+type ListReturns = providertypes.Returns[ListReturnVars]
+
+// DeleteVars .
+type DeleteVars struct {
+	Storage
+	Key string `json:"key"`
+}
+
+// DeleteParams .
+type DeleteParams = providertypes.Params[DeleteVars]
+
+// Get reads the object from the bucket, the step fails if the object exceeds the max bytes.
+func Get(ctx context.Context, params *GetParams) (*GetReturns, error) {
+	vars := params.Params
+	if vars.Key == "" {
+		return nil, errors.New("the key is required")
+	}
+	maxBytes := vars.MaxBytes
+	if maxBytes == 0 {
+		maxBytes = DefaultMaxObjectBytes
+	}
+	if maxBytes < 0 {
+		return nil, fmt.Errorf("invalid max bytes %d", maxBytes)
+	}
+	resp, err := do(ctx, params.RuntimeParams, vars.Storage, http.MethodGet, vars.Key, nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close() //nolint:errcheck
+	if resp.ContentLength > maxBytes {
+		return nil, fmt.Errorf("the object %s exceeds the limit of %d bytes, the size is %d bytes", vars.Key, maxBytes, resp.ContentLength)
+	}
+	b, err := io.ReadAll(io.LimitReader(resp.Body, maxBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the object %s: %w", vars.Key, err)
+	}
+	if int64(len(b)) > maxBytes {
+		return nil, fmt.Errorf("the object %s exceeds the limit of %d bytes", vars.Key, maxBytes)
+	}
+	return &GetReturns{Returns: GetReturnVars{
+		Content:     string(b),
+		Size:        int64(len(b)),
+		ContentType: resp.Header.Get("Content-Type"),
+		ETag:        strings.Trim(resp.Header.Get("ETag"), `"`),
+	}}, nil
+}
+
+// Put writes the inline content or the variable in the workflow context to the object.
+func Put(ctx context.Context, params *PutParams) (*PutReturns, error) {
+	vars := params.Params
+	if vars.Key == "" {
+		return nil, errors.New("the key is required")
+	}
+	var content []byte
+	switch {
+	case vars.Content != nil && vars.ContentFrom != nil:
+		return nil, errors.New("only one of content and contentFrom can be set")
+	case vars.Content != nil:
+		content = []byte(*vars.Content)
+	case vars.ContentFrom != nil:
+		var err error
+		if content, err = loadContent(params.RuntimeParams, *vars.ContentFrom); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, errors.New("either content or contentFrom is required")
+	}
+	header := http.Header{}
+	if vars.ContentType != "" {
+		header.Set("Content-Type", vars.ContentType)
+	}
+	resp, err := do(ctx, params.RuntimeParams, vars.Storage, http.MethodPut, vars.Key, nil, header, content)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close() //nolint:errcheck
+	return &PutReturns{Returns: PutReturnVars{
+		Size: int64(len(content)),
+		ETag: strings.Trim(resp.Header.Get("ETag"), `"`),
+	}}, nil
+}
+
+// List lists the objects with the prefix in the bucket, the pages are fetched until the max keys is reached.
+func List(ctx context.Context, params *ListParams) (*ListReturns, error) {
+	vars := params.Params
+	maxKeys := vars.MaxKeys
+	if maxKeys == 0 {
+		maxKeys = DefaultMaxKeys
+	}
+	if maxKeys < 0 {
+		return nil, fmt.Errorf("invalid max keys %d", maxKeys)
+	}
+	returns := ListReturnVars{Objects: []Object{}}
+	token := ""
+	for {
+		query := url.Values{"list-type": {"2"}, "max-keys": {strconv.Itoa(maxKeys - len(returns.Objects))}}
+		if vars.Prefix != "" {
+			query.Set("prefix", vars.Prefix)
+		}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+		page, err := listPage(ctx, params.RuntimeParams, vars.Storage, query)
+		if err != nil {
+			return nil, err
+		}
+		for _, content := range page.Contents {
+			returns.Objects = append(returns.Objects, Object{
+				Key:          content.Key,
+				Size:         content.Size,
+				ETag:         strings.Trim(content.ETag, `"`),
+				LastModified: content.LastModified,
+			})
+		}
+		returns.Truncated = page.IsTruncated
+		if !page.IsTruncated || page.NextContinuationToken == "" || len(returns.Objects) >= maxKeys {
+			break
+		}
+		token = page.NextContinuationToken
+	}
+	return &ListReturns{Returns: returns}, nil
+}
+
+// Delete deletes the object from the bucket, deleting a non-existent object succeeds.
+func Delete(ctx context.Context, params *DeleteParams) (*any, error) {
+	vars := params.Params
+	if vars.Key == "" {
+		return nil, errors.New("the key is required")
+	}
+	resp, err := do(ctx, params.RuntimeParams, vars.Storage, http.MethodDelete, vars.Key, nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return nil, resp.Body.Close()
+}
+
+type listBucketResult struct {
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+	Contents              []struct {
+		Key          string `xml:"Key"`
+		LastModified string `xml:"LastModified"`
+		ETag         string `xml:"ETag"`
+		Size         int64  `xml:"Size"`
+	} `xml:"Contents"`
+}
+
+func listPage(ctx context.Context, runtimeParams providertypes.RuntimeParams, storage Storage, query url.Values) (*listBucketResult, error) {
+	resp, err := do(ctx, runtimeParams, storage, http.MethodGet, "", query, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close() //nolint:errcheck
+	result := &listBucketResult{}
+	if err := xml.NewDecoder(resp.Body).Decode(result); err != nil {
+		return nil, fmt.Errorf("failed to decode the objects of bucket %s: %w", storage.Bucket, err)
+	}
+	return result, nil
+}
+
+// do sends the signed request to the object, or to the bucket if the key is empty
+func do(ctx context.Context, runtimeParams providertypes.RuntimeParams, storage Storage, method, key string, query url.Values, header http.Header, body []byte) (*http.Response, error) {
+	if storage.Endpoint == "" || storage.Bucket == "" {
+		return nil, errors.New("the endpoint and bucket are required")
+	}
+	endpoint := storage.Endpoint
+	if !strings.Contains(endpoint, "://") {
+		endpoint = "https://" + endpoint
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid endpoint %s", storage.Endpoint)
+	}
+	segments := []string{strings.TrimSuffix(u.Path, "/"), storage.Bucket}
+	if key != "" {
+		segments = append(segments, strings.Split(key, "/")...)
+	}
+	u.Path = strings.Join(segments, "/")
+	for i := 1; i < len(segments); i++ {
+		segments[i] = uriEncode(segments[i])
+	}
+	u.RawPath = strings.Join(segments, "/")
+	u.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	if storage.SecretRef != nil {
+		creds, err := loadCredentials(ctx, runtimeParams, *storage.SecretRef)
+		if err != nil {
+			return nil, err
+		}
+		region := storage.Region
+		if region == "" {
+			region = DefaultRegion
+		}
+		sign(req, creds, region, hashHex(body), time.Now())
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		defer resp.Body.Close() //nolint:errcheck
+		return nil, storageError(resp, method, storage.Bucket, key)
+	}
+	return resp, nil
+}
+
+// storageError returns the error with the code and the message of the S3 error response
+func storageError(resp *http.Response, method, bucket, key string) error {
+	target := "bucket " + bucket
+	if key != "" {
+		target = fmt.Sprintf("object %s in bucket %s", key, bucket)
+	}
+	var body struct {
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	}
+	b, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if err := xml.Unmarshal(b, &body); err != nil || body.Code == "" {
+		return fmt.Errorf("failed to %s %s: status code %d", method, target, resp.StatusCode)
+	}
+	return fmt.Errorf("failed to %s %s: %s: %s", method, target, body.Code, body.Message)
+}
+
+func loadCredentials(ctx context.Context, runtimeParams providertypes.RuntimeParams, ref SecretRef) (credentials, error) {
+	namespace := ref.Namespace
+	if namespace == "" {
+		namespace = fmt.Sprint(runtimeParams.ProcessContext.GetData(model.ContextNamespace))
+	}
+	secret := &corev1.Secret{}
+	if err := runtimeParams.KubeClient.Get(ctx, client.ObjectKey{Namespace: namespace, Name: ref.Name}, secret); err != nil {
+		return credentials{}, fmt.Errorf("failed to get the object storage secret %s/%s: %w", namespace, ref.Name, err)
+	}
+	creds := credentials{
+		accessKeyID:     string(secret.Data[accessKeyIDKey]),
+		secretAccessKey: string(secret.Data[secretAccessKeyKey]),
+		sessionToken:    string(secret.Data[sessionTokenKey]),
+	}
+	if creds.accessKeyID == "" || creds.secretAccessKey == "" {
+		return credentials{}, fmt.Errorf("the object storage secret %s/%s must contain %s and %s", namespace, ref.Name, accessKeyIDKey, secretAccessKeyKey)
+	}
+	return creds, nil
+}
+
+// loadContent reads the variable from the workflow context
+func loadContent(runtimeParams providertypes.RuntimeParams, from ContentFrom) ([]byte, error) {
+	if from.Var == "" {
+		return nil, errors.New("the var of contentFrom is required")
+	}
+	if runtimeParams.WorkflowContext == nil {
+		return nil, errors.New("the workflow context is not available")
+	}
+	value, err := runtimeParams.WorkflowContext.GetVar(strings.Split(from.Var, ".")...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the var %s from the workflow context: %w", from.Var, err)
+	}
+	if s, err := value.String(); err == nil {
+		return []byte(s), nil
+	}
+	b, err := value.MarshalJSON()
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode the var %s: %w", from.Var, err)
+	}
+	return b, nil
+}
+
+//go:embed objectstorage.cue
+var template string
+
+// GetTemplate returns the cue template.
+func GetTemplate() string {
+	return template
+}
+
+// GetProviders returns the cue providers.
+func GetProviders() map[string]cuexruntime.ProviderFn {
+	return map[string]cuexruntime.ProviderFn{
+		"get":    providertypes.GenericProviderFn[GetVars, GetReturns](Get),
+		"put":    providertypes.GenericProviderFn[PutVars, PutReturns](Put),
+		"list":   providertypes.GenericProviderFn[ListVars, ListReturns](List),
+		"delete": providertypes.GenericProviderFn[DeleteVars, any](Delete),
+	}
+}
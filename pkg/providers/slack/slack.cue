@@ -0,0 +1,40 @@
+// slack.cue
+
+#SecretKeyRef: {
+	// +usage=The name of the secret
+	name: string
+	// +usage=The namespace of the secret, default to the namespace of the workflow
+	namespace?: string
+	// +usage=The key in the secret
+	key?: string
+}
+
+#Post: {
+	#do:       "post"
+	#provider: "slack"
+
+	$params: {
+		// +usage=The secret which contains the url of the incoming webhook, the key defaults to url
+		webhookURL?: #SecretKeyRef
+		// +usage=The secret which contains the bot token, the key defaults to token
+		token?: #SecretKeyRef
+		// +usage=The channel to post to, required with the token
+		channel?: string
+		// +usage=The text of the message, which is the fallback of the blocks
+		text?: string
+		// +usage=The blocks of the message, see https://api.slack.com/block-kit
+		blocks?: [...{...}]
+		// +usage=The legacy attachments of the message
+		attachments?: [...{...}]
+	}
+
+	$returns?: {
+		// +usage=Whether the message is posted
+		ok: bool
+		// +usage=The channel of the message, only returned with the token
+		channel?: string
+		// +usage=The timestamp of the message, only returned with the token
+		ts?: string
+	}
+	...
+}
@@ -0,0 +1,223 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package slack
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	cuexruntime "github.com/kubevela/pkg/cue/cuex/runtime"
+
+	"github.com/kubevela/workflow/pkg/cue/model"
+	providertypes "github.com/kubevela/workflow/pkg/providers/types"
+)
+
+const (
+	// ProviderName is provider name for install.
+	ProviderName = "slack"
+
+	defaultWebhookURLKey = "url"
+	defaultTokenKey      = "token"
+)
+
+var (
+	// PostMessageURL is the url of the chat.postMessage API of Slack used with the token
+	PostMessageURL = "https://slack.com/api/chat.postMessage"
+
+	httpClient = &http.Client{Timeout: 30 * time.Second}
+)
+
+// SecretKeyRef is the reference of the key in the secret.
+type SecretKeyRef struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"`
+	Key       string `json:"key,omitempty"`
+}
+
+// PostVars .
+type PostVars struct {
+	// WebhookURL is the secret which contains the incoming webhook url, the key defaults to url
+	WebhookURL *SecretKeyRef `json:"webhookURL,omitempty"`
+	// Token is the secret which contains the bot token, the key defaults to token
+	Token       *SecretKeyRef     `json:"token,omitempty"`
+	Channel     string            `json:"channel,omitempty"`
+	Text        string            `json:"text,omitempty"`
+	Blocks      []json.RawMessage `json:"blocks,omitempty"`
+	Attachments []json.RawMessage `json:"attachments,omitempty"`
+}
+
+// PostReturnVars .
+type PostReturnVars struct {
+	OK bool `json:"ok"`
+	// Channel and TS are the channel and the timestamp of the message, which are only returned with the token
+	Channel string `json:"channel,omitempty"`
+	TS      string `json:"ts,omitempty"`
+}
+
+// PostParams .
+type PostParams = providertypes.Params[PostVars]
+
+// PostReturns .
+type PostReturns = providertypes.Returns[PostReturnVars]
+
+type message struct {
+	Channel     string            `json:"channel,omitempty"`
+	Text        string            `json:"text,omitempty"`
+	Blocks      []json.RawMessage `json:"blocks,omitempty"`
+	Attachments []json.RawMessage `json:"attachments,omitempty"`
+}
+
+// Post posts the message to the incoming webhook, or to the channel with the bot token.
+func Post(ctx context.Context, params *PostParams) (*PostReturns, error) {
+	vars := params.Params
+	if vars.Text == "" && len(vars.Blocks) == 0 && len(vars.Attachments) == 0 {
+		return nil, errors.New("one of text, blocks and attachments is required")
+	}
+	namespace := fmt.Sprint(params.ProcessContext.GetData(model.ContextNamespace))
+	msg := message{Text: vars.Text, Blocks: vars.Blocks, Attachments: vars.Attachments}
+	switch {
+	case vars.WebhookURL != nil && vars.Token != nil:
+		return nil, errors.New("only one of webhookURL and token can be set")
+	case vars.WebhookURL != nil:
+		webhookURL, err := loadSecret(ctx, params.KubeClient, *vars.WebhookURL, defaultWebhookURLKey, namespace)
+		if err != nil {
+			return nil, err
+		}
+		return postWebhook(ctx, webhookURL, msg)
+	case vars.Token != nil:
+		if vars.Channel == "" {
+			return nil, errors.New("the channel is required to post with the token")
+		}
+		token, err := loadSecret(ctx, params.KubeClient, *vars.Token, defaultTokenKey, namespace)
+		if err != nil {
+			return nil, err
+		}
+		msg.Channel = vars.Channel
+		return postMessage(ctx, token, msg)
+	default:
+		return nil, errors.New("either webhookURL or token is required")
+	}
+}
+
+// postWebhook posts to the incoming webhook, which responds ok or the error code in the body
+func postWebhook(ctx context.Context, webhookURL string, msg message) (*PostReturns, error) {
+	status, body, err := send(ctx, webhookURL, "", msg)
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("failed to post the slack message: status code %d: %s", status, strings.TrimSpace(string(body)))
+	}
+	return &PostReturns{Returns: PostReturnVars{OK: true}}, nil
+}
+
+// postMessage posts with the chat.postMessage API, which responds ok false with the error code on failure
+func postMessage(ctx context.Context, token string, msg message) (*PostReturns, error) {
+	status, body, err := send(ctx, PostMessageURL, token, msg)
+	if err != nil {
+		return nil, err
+	}
+	resp := struct {
+		OK      bool   `json:"ok"`
+		Error   string `json:"error"`
+		Channel string `json:"channel"`
+		TS      string `json:"ts"`
+	}{}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("failed to post the slack message: status code %d: invalid response: %w", status, err)
+	}
+	if !resp.OK {
+		return nil, fmt.Errorf("failed to post the slack message: %s", resp.Error)
+	}
+	return &PostReturns{Returns: PostReturnVars{OK: true, Channel: resp.Channel, TS: resp.TS}}, nil
+}
+
+func send(ctx context.Context, target, token string, msg message) (int, []byte, error) {
+	b, err := json.Marshal(msg)
+	if err != nil {
+		return 0, nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(b))
+	if err != nil {
+		// the url is the secret of the webhook, which must not be exposed in the error
+		return 0, nil, errors.New("failed to post the slack message: invalid url")
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return 0, nil, fmt.Errorf("failed to post the slack message: %w", err)
+	}
+	defer resp.Body.Close() //nolint:errcheck
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to read the slack response: %w", err)
+	}
+	return resp.StatusCode, body, nil
+}
+
+func loadSecret(ctx context.Context, cli client.Client, ref SecretKeyRef, defaultKey, defaultNamespace string) (string, error) {
+	namespace, key := ref.Namespace, ref.Key
+	if namespace == "" {
+		namespace = defaultNamespace
+	}
+	if key == "" {
+		key = defaultKey
+	}
+	secret := &corev1.Secret{}
+	if err := cli.Get(ctx, client.ObjectKey{Namespace: namespace, Name: ref.Name}, secret); err != nil {
+		return "", fmt.Errorf("failed to get the slack secret %s/%s: %w", namespace, ref.Name, err)
+	}
+	value := strings.TrimSpace(string(secret.Data[key]))
+	if value == "" {
+		return "", fmt.Errorf("the key %s is not found in the slack secret %s/%s", key, namespace, ref.Name)
+	}
+	return value, nil
+}
+
+//go:embed slack.cue
+var template string
+
+// GetTemplate returns the cue template.
+func GetTemplate() string {
+	return template
+}
+
+// GetProviders returns the cue providers.
+func GetProviders() map[string]cuexruntime.ProviderFn {
+	return map[string]cuexruntime.ProviderFn{
+		"post": providertypes.GenericProviderFn[PostVars, PostReturns](Post),
+	}
+}
@@ -0,0 +1,120 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/kubevela/workflow/pkg/cue/process"
+	providertypes "github.com/kubevela/workflow/pkg/providers/types"
+)
+
+func TestPost(t *testing.T) {
+	r := require.New(t)
+	ctx := context.Background()
+	var received []map[string]interface{}
+	var auth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		b, _ := io.ReadAll(req.Body)
+		payload := map[string]interface{}{}
+		if err := json.Unmarshal(b, &payload); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte("invalid_payload"))
+			return
+		}
+		received = append(received, payload)
+		auth = req.Header.Get("Authorization")
+		switch req.URL.Path {
+		case "/services/hook":
+			_, _ = w.Write([]byte("ok"))
+		case "/api/chat.postMessage":
+			if payload["channel"] != "#deploy" {
+				_, _ = w.Write([]byte(`{"ok":false,"error":"channel_not_found"}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"ok":true,"channel":"C123","ts":"1700000000.000100"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte("no_service"))
+		}
+	}))
+	defer server.Close()
+	origin := PostMessageURL
+	PostMessageURL = server.URL + "/api/chat.postMessage"
+	defer func() { PostMessageURL = origin }()
+
+	cli := fake.NewClientBuilder().WithObjects(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "slack", Namespace: "ns"},
+		Data: map[string][]byte{
+			"url":     []byte(server.URL + "/services/hook"),
+			"missing": []byte(server.URL + "/services/missing"),
+			"token":   []byte("xoxb-token"),
+		},
+	}).Build()
+	post := func(vars PostVars) (*PostReturns, error) {
+		return Post(ctx, &PostParams{Params: vars, RuntimeParams: providertypes.RuntimeParams{
+			KubeClient:     cli,
+			ProcessContext: process.NewContext(process.ContextData{Namespace: "ns"}),
+		}})
+	}
+
+	res, err := post(PostVars{WebhookURL: &SecretKeyRef{Name: "slack"}, Text: "Deployed"})
+	r.NoError(err)
+	r.Equal(PostReturnVars{OK: true}, res.Returns)
+	r.Equal(map[string]interface{}{"text": "Deployed"}, received[0])
+	r.Empty(auth)
+
+	blocks := []json.RawMessage{
+		json.RawMessage(`{"type":"header","text":{"type":"plain_text","text":"Deployed"}}`),
+		json.RawMessage(`{"type":"section","fields":[{"type":"mrkdwn","text":"*Env:* prod"}]}`),
+	}
+	res, err = post(PostVars{Token: &SecretKeyRef{Name: "slack", Namespace: "ns"}, Channel: "#deploy", Text: "Deployed", Blocks: blocks})
+	r.NoError(err)
+	r.Equal(PostReturnVars{OK: true, Channel: "C123", TS: "1700000000.000100"}, res.Returns)
+	r.Equal("Bearer xoxb-token", auth)
+	r.Equal("#deploy", received[1]["channel"])
+	r.Equal([]interface{}{
+		map[string]interface{}{"type": "header", "text": map[string]interface{}{"type": "plain_text", "text": "Deployed"}},
+		map[string]interface{}{"type": "section", "fields": []interface{}{map[string]interface{}{"type": "mrkdwn", "text": "*Env:* prod"}}},
+	}, received[1]["blocks"])
+
+	_, err = post(PostVars{Token: &SecretKeyRef{Name: "slack"}, Channel: "#unknown", Text: "Deployed"})
+	r.EqualError(err, "failed to post the slack message: channel_not_found")
+	_, err = post(PostVars{WebhookURL: &SecretKeyRef{Name: "slack", Key: "missing"}, Text: "Deployed"})
+	r.EqualError(err, "failed to post the slack message: status code 404: no_service")
+	_, err = post(PostVars{WebhookURL: &SecretKeyRef{Name: "slack", Key: "unknown"}, Text: "Deployed"})
+	r.EqualError(err, "the key unknown is not found in the slack secret ns/slack")
+	_, err = post(PostVars{Token: &SecretKeyRef{Name: "slack"}, Text: "Deployed"})
+	r.EqualError(err, "the channel is required to post with the token")
+	_, err = post(PostVars{Text: "Deployed"})
+	r.EqualError(err, "either webhookURL or token is required")
+
+	server.Close()
+	_, err = post(PostVars{WebhookURL: &SecretKeyRef{Name: "slack"}, Text: "Deployed"})
+	r.Error(err)
+	r.NotContains(err.Error(), "/services/hook")
+}
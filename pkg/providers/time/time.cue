@@ -29,3 +29,33 @@
 	}
 	...
 }
+
+#NextCron: {
+	#do:       "nextCron"
+	#provider: "time"
+
+	$params: {
+		cron:      string
+		from?:     string
+		timezone?: string
+		count?:    int
+	}
+
+	$returns?: {
+		next:  string
+		times: [...string]
+	}
+	...
+}
+
+// #Sleep blocks the step for the duration, which blocks the reconciliation of the workflow as well,
+// the duration is capped at 1m by default, use suspend with the duration for the long delays.
+#Sleep: {
+	#do:       "sleep"
+	#provider: "time"
+
+	$params: {
+		duration: string
+	}
+	...
+}
@@ -20,8 +20,11 @@ import (
 	"context"
 	_ "embed"
 	"fmt"
+	"strings"
 	"time"
 
+	"github.com/robfig/cron/v3"
+
 	cuexruntime "github.com/kubevela/pkg/cue/cuex/runtime"
 	providertypes "github.com/kubevela/workflow/pkg/providers/types"
 )
@@ -96,6 +99,143 @@ func Date(_ context.Context, params *DateParams) (*DateReturns, error) {
 	}, nil
 }
 
+const maxCronCount = 100
+
+// MaxSleepDuration is the max duration of the sleep. The sleep blocks the reconciliation of the workflow, so
+// suspend with the duration is preferred for the long delays. It should be kept below the reconcile timeout of
+// the controller, which is 3m, and the sleep is rejected anyway if it cannot finish before the reconcile times out.
+var MaxSleepDuration = time.Minute
+
+var cronFields = []string{"minute", "hour", "day of month", "month", "day of week"}
+
+// NextCronVars .
+type NextCronVars struct {
+	Cron     string `json:"cron"`
+	From     string `json:"from,omitempty"`
+	Timezone string `json:"timezone,omitempty"`
+	Count    int    `json:"count,omitempty"`
+}
+
+// NextCronReturnVars .
+type NextCronReturnVars struct {
+	Next  string   `json:"next"`
+	Times []string `json:"times"`
+}
+
+// NextCronParams .
+type NextCronParams = providertypes.Params[NextCronVars]
+
+// NextCronReturns .
+type NextCronReturns = providertypes.Returns[NextCronReturnVars]
+
+// NextCron computes the next fire times of the standard cron expression after the from time in the timezone
+func NextCron(_ context.Context, params *NextCronParams) (*NextCronReturns, error) {
+	vars := params.Params
+	loc := time.UTC
+	if vars.Timezone != "" {
+		l, err := time.LoadLocation(vars.Timezone)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timezone %s: %w", vars.Timezone, err)
+		}
+		loc = l
+	}
+	from := time.Now()
+	if vars.From != "" {
+		t, err := time.Parse(time.RFC3339, vars.From)
+		if err != nil {
+			return nil, fmt.Errorf("invalid from time %s, the time must be in RFC3339 format: %w", vars.From, err)
+		}
+		from = t
+	}
+	count := vars.Count
+	if count == 0 {
+		count = 1
+	}
+	if count < 0 || count > maxCronCount {
+		return nil, fmt.Errorf("invalid count %d, the count must be between 1 and %d", count, maxCronCount)
+	}
+	schedule, err := parseCron(vars.Cron)
+	if err != nil {
+		return nil, err
+	}
+	times := make([]string, 0, count)
+	next := from.In(loc)
+	for i := 0; i < count; i++ {
+		next = schedule.Next(next)
+		if next.IsZero() {
+			return nil, fmt.Errorf("the cron expression %q never fires after %s", vars.Cron, from.Format(time.RFC3339))
+		}
+		times = append(times, next.Format(time.RFC3339))
+	}
+	return &NextCronReturns{
+		Returns: NextCronReturnVars{
+			Next:  times[0],
+			Times: times,
+		},
+	}, nil
+}
+
+// parseCron parses the standard cron expression, the invalid field is named in the error
+func parseCron(expr string) (cron.Schedule, error) {
+	expr = strings.TrimSpace(expr)
+	if expr == "" {
+		return nil, fmt.Errorf("empty cron expression")
+	}
+	if strings.HasPrefix(expr, "TZ=") || strings.HasPrefix(expr, "CRON_TZ=") {
+		return nil, fmt.Errorf("invalid cron expression %q: use the timezone parameter instead of the TZ prefix", expr)
+	}
+	schedule, err := cron.ParseStandard(expr)
+	if err == nil {
+		return schedule, nil
+	}
+	if strings.HasPrefix(expr, "@") {
+		return nil, fmt.Errorf("invalid cron expression %q: %w", expr, err)
+	}
+	fields := strings.Fields(expr)
+	if len(fields) != len(cronFields) {
+		return nil, fmt.Errorf("invalid cron expression %q: expected %d fields (%s), found %d", expr, len(cronFields), strings.Join(cronFields, ", "), len(fields))
+	}
+	for i, field := range fields {
+		probe := []string{"*", "*", "*", "*", "*"}
+		probe[i] = field
+		if _, fieldErr := cron.ParseStandard(strings.Join(probe, " ")); fieldErr != nil {
+			return nil, fmt.Errorf("invalid cron expression %q: the %s field %q is invalid: %w", expr, cronFields[i], field, fieldErr)
+		}
+	}
+	return nil, fmt.Errorf("invalid cron expression %q: %w", expr, err)
+}
+
+// SleepVars .
+type SleepVars struct {
+	Duration string `json:"duration"`
+}
+
+// SleepParams .
+type SleepParams = providertypes.Params[SleepVars]
+
+// Sleep blocks the step for the duration, it returns immediately with the error once the context is cancelled.
+func Sleep(ctx context.Context, params *SleepParams) (*any, error) {
+	duration, err := time.ParseDuration(params.Params.Duration)
+	if err != nil || duration < 0 {
+		return nil, fmt.Errorf("invalid sleep duration %s", params.Params.Duration)
+	}
+	if duration > MaxSleepDuration {
+		return nil, fmt.Errorf("the sleep duration %s exceeds the max duration %s, use suspend with the duration for the long delays", duration, MaxSleepDuration)
+	}
+	if deadline, ok := ctx.Deadline(); ok && time.Now().Add(duration).After(deadline) {
+		return nil, fmt.Errorf("the sleep duration %s exceeds the remaining time %s of the reconciliation, use suspend with the duration for the long delays", duration, time.Until(deadline).Round(time.Second))
+	}
+	start := time.Now()
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return nil, fmt.Errorf("the sleep is cancelled after %s: %w", time.Since(start).Round(time.Millisecond), ctx.Err())
+	case <-timer.C:
+		return nil, nil
+	}
+}
+
 //go:embed time.cue
 var template string
 
@@ -109,5 +249,7 @@ func GetProviders() map[string]cuexruntime.ProviderFn {
 	return map[string]cuexruntime.ProviderFn{
 		"timestamp": providertypes.GenericProviderFn[TimestampVars, TimestampReturns](Timestamp),
 		"date":      providertypes.GenericProviderFn[DateVars, DateReturns](Date),
+		"nextCron":  providertypes.GenericProviderFn[NextCronVars, NextCronReturns](NextCron),
+		"sleep":     providertypes.GenericProviderFn[SleepVars, any](Sleep),
 	}
 }
@@ -20,6 +20,7 @@ import (
 	"context"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/require"
@@ -129,3 +130,131 @@ func TestDate(t *testing.T) {
 		})
 	}
 }
+
+func TestNextCron(t *testing.T) {
+	ctx := context.Background()
+	testcases := map[string]struct {
+		from        NextCronVars
+		expected    []string
+		expectedErr string
+	}{
+		"test next fire time of a standard expression": {
+			from: NextCronVars{
+				Cron: "30 2 * * *",
+				From: "2021-11-07T01:47:51Z",
+			},
+			expected: []string{"2021-11-07T02:30:00Z"},
+		},
+		"test next fire times with count": {
+			from: NextCronVars{
+				Cron:  "0 9 * * 1-5",
+				From:  "2021-11-05T10:00:00Z",
+				Count: 3,
+			},
+			expected: []string{"2021-11-08T09:00:00Z", "2021-11-09T09:00:00Z", "2021-11-10T09:00:00Z"},
+		},
+		"test next fire time with descriptor": {
+			from: NextCronVars{
+				Cron: "@monthly",
+				From: "2021-11-07T01:47:51Z",
+			},
+			expected: []string{"2021-12-01T00:00:00Z"},
+		},
+		"test next fire time in timezone": {
+			from: NextCronVars{
+				Cron:     "0 9 * * *",
+				From:     "2021-11-07T00:47:51Z",
+				Timezone: "Asia/Shanghai",
+			},
+			expected: []string{"2021-11-07T09:00:00+08:00"},
+		},
+		"test next fire time across daylight saving time": {
+			from: NextCronVars{
+				Cron:     "0 9 * * *",
+				From:     "2021-11-06T14:00:00Z",
+				Timezone: "America/New_York",
+				Count:    2,
+			},
+			expected: []string{"2021-11-07T09:00:00-05:00", "2021-11-08T09:00:00-05:00"},
+		},
+		"test invalid field": {
+			from: NextCronVars{
+				Cron: "0 25 * * *",
+			},
+			expectedErr: `invalid cron expression "0 25 * * *": the hour field "25" is invalid`,
+		},
+		"test invalid number of fields": {
+			from: NextCronVars{
+				Cron: "0 0 * *",
+			},
+			expectedErr: `invalid cron expression "0 0 * *": expected 5 fields (minute, hour, day of month, month, day of week), found 4`,
+		},
+		"test invalid timezone": {
+			from: NextCronVars{
+				Cron:     "0 0 * * *",
+				Timezone: "Mars/Olympus",
+			},
+			expectedErr: "invalid timezone Mars/Olympus",
+		},
+		"test never fires": {
+			from: NextCronVars{
+				Cron: "0 0 30 2 *",
+				From: "2021-11-07T01:47:51Z",
+			},
+			expectedErr: `the cron expression "0 0 30 2 *" never fires after 2021-11-07T01:47:51Z`,
+		},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			r := require.New(t)
+			res, err := NextCron(ctx, &NextCronParams{
+				Params: tc.from,
+			})
+			if tc.expectedErr != "" {
+				r.Error(err)
+				r.Contains(err.Error(), tc.expectedErr)
+				return
+			}
+			r.NoError(err)
+			r.Equal(tc.expected[0], res.Returns.Next)
+			r.Equal(tc.expected, res.Returns.Times)
+		})
+	}
+}
+
+func TestSleep(t *testing.T) {
+	r := require.New(t)
+	ctx := context.Background()
+
+	start := time.Now()
+	_, err := Sleep(ctx, &SleepParams{Params: SleepVars{Duration: "100ms"}})
+	r.NoError(err)
+	r.GreaterOrEqual(time.Since(start), 100*time.Millisecond)
+
+	cancelCtx, cancel := context.WithCancel(ctx)
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		cancel()
+	}()
+	start = time.Now()
+	_, err = Sleep(cancelCtx, &SleepParams{Params: SleepVars{Duration: "1m"}})
+	r.Error(err)
+	r.True(errors.Is(err, context.Canceled))
+	r.Contains(err.Error(), "the sleep is cancelled after")
+	r.Less(time.Since(start), 5*time.Second)
+
+	_, err = Sleep(ctx, &SleepParams{Params: SleepVars{Duration: "invalid"}})
+	r.EqualError(err, "invalid sleep duration invalid")
+
+	_, err = Sleep(ctx, &SleepParams{Params: SleepVars{Duration: "1h"}})
+	r.EqualError(err, "the sleep duration 1h0m0s exceeds the max duration 1m0s, use suspend with the duration for the long delays")
+
+	// the sleep that cannot finish before the reconciliation times out is rejected
+	timeoutCtx, cancelTimeout := context.WithTimeout(ctx, 10*time.Second)
+	defer cancelTimeout()
+	start = time.Now()
+	_, err = Sleep(timeoutCtx, &SleepParams{Params: SleepVars{Duration: "30s"}})
+	r.EqualError(err, "the sleep duration 30s exceeds the remaining time 10s of the reconciliation, use suspend with the duration for the long delays")
+	r.Less(time.Since(start), time.Second)
+}
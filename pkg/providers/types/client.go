@@ -0,0 +1,116 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package types
+
+import (
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/utils/lru"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/kubevela/pkg/util/singleton"
+)
+
+// ClientRateLimit is the client-side rate limit of the kube client used by providers.
+// Note that it only throttles the requests sent to the api-server, the rate limiter of
+// the http provider is applied separately to each request url.
+type ClientRateLimit struct {
+	QPS   float32
+	Burst int
+}
+
+// IsZero returns true if the rate limit is not configured
+func (in ClientRateLimit) IsZero() bool {
+	return in.QPS <= 0 && in.Burst <= 0
+}
+
+// DefaultClientRateLimit returns the rate limit of the default kube client, the defaults of client-go are
+// used for the values not set
+func DefaultClientRateLimit() ClientRateLimit {
+	cfg := singleton.KubeConfig.Get()
+	limit := ClientRateLimit{QPS: cfg.QPS, Burst: cfg.Burst}
+	if limit.QPS <= 0 {
+		limit.QPS = rest.DefaultQPS
+	}
+	if limit.Burst <= 0 {
+		limit.Burst = rest.DefaultBurst
+	}
+	return limit
+}
+
+// maxRateLimitedClients is the max number of the cached kube clients with the rate limits, the least recently
+// used one is evicted if exceeded
+const maxRateLimitedClients = 256
+
+var (
+	rateLimitedClients = lru.New(maxRateLimitedClients)
+	newKubeClient      = func(cfg *rest.Config) (client.Client, error) {
+		return client.New(cfg, client.Options{
+			Scheme: scheme.Scheme,
+			Mapper: singleton.RESTMapper.Get(),
+		})
+	}
+)
+
+type rateLimitedClient struct {
+	limit ClientRateLimit
+	cli   client.Client
+}
+
+// KubeClientWithRateLimit returns the kube client of the workflow run that shares the config of the
+// default kube client with the rate limit overridden. The clients are cached by the uid of the run, so
+// that each run is throttled by its own rate limiter, and the client is rebuilt if the rate limit of
+// the run changes. At most maxRateLimitedClients clients are kept, the client of the run should be
+// released by ReleaseKubeClientWithRateLimit once the run is finished.
+func KubeClientWithRateLimit(uid string, limit ClientRateLimit) (client.Client, error) {
+	if cached, ok := rateLimitedClients.Get(uid); ok && cached.(rateLimitedClient).limit == limit {
+		return cached.(rateLimitedClient).cli, nil
+	}
+	cfg := rest.CopyConfig(singleton.KubeConfig.Get())
+	if limit.QPS > 0 {
+		cfg.QPS = limit.QPS
+	}
+	if limit.Burst > 0 {
+		cfg.Burst = limit.Burst
+	}
+	cli, err := newKubeClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+	rateLimitedClients.Add(uid, rateLimitedClient{limit: limit, cli: cli})
+	return cli, nil
+}
+
+// ReleaseKubeClientWithRateLimit removes the cached kube client of the workflow run.
+func ReleaseKubeClientWithRateLimit(uid string) {
+	rateLimitedClients.Remove(uid)
+}
+
+// KubeConfigWithToken returns the copy of the default kube config which authenticates with the
+// bearer token only.
+func KubeConfigWithToken(token string) *rest.Config {
+	cfg := rest.AnonymousClientConfig(singleton.KubeConfig.Get())
+	cfg.BearerToken = token
+	return cfg
+}
+
+// KubeClientWithToken returns the kube client that shares the config of the default kube client
+// but authenticates with the bearer token only. The client is not cached, so that the token is
+// discarded with the client.
+func KubeClientWithToken(token string) (client.Client, error) {
+	return newKubeClient(KubeConfigWithToken(token))
+}
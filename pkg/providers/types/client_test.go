@@ -0,0 +1,118 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package types
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/kubevela/pkg/util/singleton"
+
+	"github.com/kubevela/workflow/pkg/utils"
+)
+
+func TestKubeClientWithRateLimit(t *testing.T) {
+	r := require.New(t)
+	originNew := newKubeClient
+	defer func() {
+		newKubeClient = originNew
+	}()
+	utils.SetKubeConfigForTest(t, &rest.Config{Host: "https://kube-api", QPS: 5, Burst: 10})
+	var configs []*rest.Config
+	newKubeClient = func(cfg *rest.Config) (client.Client, error) {
+		configs = append(configs, cfg)
+		return fake.NewClientBuilder().Build(), nil
+	}
+
+	cli, err := KubeClientWithRateLimit("run-a", ClientRateLimit{QPS: 100, Burst: 200})
+	r.NoError(err)
+	r.Len(configs, 1)
+	r.Equal(float32(100), configs[0].QPS)
+	r.Equal(200, configs[0].Burst)
+	r.Equal("https://kube-api", configs[0].Host)
+	// the shared config should not be modified
+	r.Equal(float32(5), singleton.KubeConfig.Get().QPS)
+
+	// the client should be cached by the run
+	cached, err := KubeClientWithRateLimit("run-a", ClientRateLimit{QPS: 100, Burst: 200})
+	r.NoError(err)
+	r.Same(cli, cached)
+	r.Len(configs, 1)
+
+	// the runs with the same rate limit do not share the client
+	other, err := KubeClientWithRateLimit("run-b", ClientRateLimit{QPS: 100, Burst: 200})
+	r.NoError(err)
+	r.NotSame(cli, other)
+	r.Len(configs, 2)
+
+	// the client is rebuilt if the rate limit changes, and only the configured value is overridden
+	_, err = KubeClientWithRateLimit("run-a", ClientRateLimit{Burst: 50})
+	r.NoError(err)
+	r.Len(configs, 3)
+	r.Equal(float32(5), configs[2].QPS)
+	r.Equal(50, configs[2].Burst)
+
+	// the released client is not reused
+	ReleaseKubeClientWithRateLimit("run-b")
+	_, err = KubeClientWithRateLimit("run-b", ClientRateLimit{QPS: 100, Burst: 200})
+	r.NoError(err)
+	r.Len(configs, 4)
+
+	// the cached clients are bounded
+	for i := 0; i < maxRateLimitedClients+5; i++ {
+		_, err = KubeClientWithRateLimit(fmt.Sprintf("run-%d", i), ClientRateLimit{QPS: 1})
+		r.NoError(err)
+	}
+	r.Equal(maxRateLimitedClients, rateLimitedClients.Len())
+}
+
+func TestKubeClientWithToken(t *testing.T) {
+	r := require.New(t)
+	originNew := newKubeClient
+	defer func() {
+		newKubeClient = originNew
+	}()
+	utils.SetKubeConfigForTest(t, &rest.Config{
+		Host:            "https://kube-api",
+		BearerToken:     "controller-token",
+		TLSClientConfig: rest.TLSClientConfig{CAData: []byte("ca"), CertData: []byte("cert"), KeyData: []byte("key")},
+	})
+	var configs []*rest.Config
+	newKubeClient = func(cfg *rest.Config) (client.Client, error) {
+		configs = append(configs, cfg)
+		return fake.NewClientBuilder().Build(), nil
+	}
+
+	_, err := KubeClientWithToken("step-token")
+	r.NoError(err)
+	_, err = KubeClientWithToken("step-token")
+	r.NoError(err)
+	// the clients with token should not be cached
+	r.Len(configs, 2)
+	r.Equal("https://kube-api", configs[0].Host)
+	r.Equal("step-token", configs[0].BearerToken)
+	r.Equal([]byte("ca"), configs[0].CAData)
+	// the credentials of the controller should not be inherited
+	r.Empty(configs[0].CertData)
+	r.Empty(configs[0].KeyData)
+	r.Equal("controller-token", singleton.KubeConfig.Get().BearerToken)
+}
@@ -0,0 +1,65 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package types
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+)
+
+// PageToken is the resumption token of the paginated provider results, the providers
+// return the token to continue with the next page, or an empty token for the last page.
+type PageToken struct {
+	// Continue is the continue token returned by the server
+	Continue string `json:"continue,omitempty"`
+	// Offset is the offset of the next page if the results are paginated by the provider
+	Offset int `json:"offset,omitempty"`
+}
+
+// IsZero returns true if there is no more page
+func (in PageToken) IsZero() bool {
+	return in.Continue == "" && in.Offset == 0
+}
+
+// EncodePageToken encodes the page token to an opaque string
+func EncodePageToken(token PageToken) string {
+	if token.IsZero() {
+		return ""
+	}
+	b, _ := json.Marshal(token)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// DecodePageToken decodes the page token from the opaque string
+func DecodePageToken(s string) (PageToken, error) {
+	token := PageToken{}
+	if s == "" {
+		return token, nil
+	}
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return token, fmt.Errorf("invalid page token: %w", err)
+	}
+	if err := json.Unmarshal(b, &token); err != nil {
+		return token, fmt.Errorf("invalid page token: %w", err)
+	}
+	if token.Offset < 0 {
+		return token, fmt.Errorf("invalid page token: negative offset %d", token.Offset)
+	}
+	return token, nil
+}
@@ -0,0 +1,169 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package types
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"cuelang.org/go/cue"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+
+	wfContext "github.com/kubevela/workflow/pkg/context"
+	"github.com/kubevela/workflow/pkg/cue/model"
+	"github.com/kubevela/workflow/pkg/cue/process"
+	"github.com/kubevela/workflow/pkg/types"
+)
+
+const (
+	// ContextScopePrefix is the prefix of the allowed paths of the process context
+	ContextScopePrefix = "context."
+	// VarsScopePrefix is the prefix of the allowed paths of the workflow vars
+	VarsScopePrefix = "vars."
+	// KubeSecretsScope is the path which allows the kube client of the provider to access the secrets
+	KubeSecretsScope = "kube.secrets"
+)
+
+// ContextScope is the allowlist of the context which an untrusted provider may read.
+type ContextScope struct {
+	// Provider is the name of the provider like "custom", or the provider function like "custom.do"
+	Provider string
+	// Paths are the allowed paths, like "context.name" or "context.*" for the process context, and
+	// "vars.app.image" for the workflow vars. The secrets in the process context are excluded unless
+	// their paths are allowed explicitly. The step session id is always visible, and the provider can only
+	// access the mutable and memory values of the workflow context under it. The kube client of the provider
+	// cannot access the secrets unless "kube.secrets" is allowed, while the kube config used to talk to the
+	// api-server without the client, such as streaming the pod logs, is not scoped.
+	Paths []string
+}
+
+// RestrictContext returns the interceptor which renders the restricted context for the providers in the scopes,
+// the scope of the provider function takes precedence over the one of the provider. The providers out of the
+// scopes see the full context.
+func RestrictContext(scopes ...ContextScope) types.ProviderInterceptor {
+	index := make(map[string]ContextScope, len(scopes))
+	for _, scope := range scopes {
+		index[scope.Provider] = scope
+	}
+	return func(ctx context.Context, value cue.Value, next types.ProviderInvoker) (cue.Value, error) {
+		provider, _ := value.LookupPath(cue.ParsePath("#provider")).String()
+		do, _ := value.LookupPath(cue.ParsePath("#do")).String()
+		scope, ok := index[provider+"."+do]
+		if !ok {
+			if scope, ok = index[provider]; !ok {
+				return next(ctx, value)
+			}
+		}
+		var contextPaths, varsPaths []string
+		allowSecrets := false
+		for _, path := range scope.Paths {
+			switch {
+			case path == KubeSecretsScope:
+				allowSecrets = true
+			case strings.HasPrefix(path, ContextScopePrefix):
+				contextPaths = append(contextPaths, strings.TrimPrefix(path, ContextScopePrefix))
+			case strings.HasPrefix(path, VarsScopePrefix):
+				varsPaths = append(varsPaths, strings.TrimPrefix(path, VarsScopePrefix))
+			}
+		}
+		params := RuntimeParamsFrom(ctx)
+		var stepID string
+		if params.ProcessContext != nil {
+			stepID, _ = params.ProcessContext.GetData(model.ContextStepSessionID).(string)
+			// the step session id is always visible so that the provider can address the memory of the step
+			contextPaths = append(contextPaths, model.ContextStepSessionID)
+			ctx = context.WithValue(ctx, ProcessContextKey, process.Restrict(params.ProcessContext, contextPaths))
+		}
+		if params.WorkflowContext != nil {
+			ctx = context.WithValue(ctx, WorkflowContextKey, wfContext.Restrict(params.WorkflowContext, varsPaths, stepID))
+		}
+		if !allowSecrets && params.KubeClient != nil {
+			ctx = context.WithValue(ctx, KubeClientKey, &noSecretsClient{Client: params.KubeClient})
+		}
+		return next(ctx, value)
+	}
+}
+
+// noSecretsClient is the kube client which refuses to access the secrets
+type noSecretsClient struct {
+	client.Client
+}
+
+func (c *noSecretsClient) check(obj runtime.Object) error {
+	gvk, err := apiutil.GVKForObject(obj, c.Scheme())
+	if err != nil {
+		return err
+	}
+	if gvk.Group == "" && (gvk.Kind == "Secret" || gvk.Kind == "SecretList") {
+		return kerrors.NewForbidden(corev1.Resource("secrets"), "", fmt.Errorf("the provider is not allowed to access the secrets"))
+	}
+	return nil
+}
+
+func (c *noSecretsClient) Get(ctx context.Context, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+	if err := c.check(obj); err != nil {
+		return err
+	}
+	return c.Client.Get(ctx, key, obj, opts...)
+}
+
+func (c *noSecretsClient) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	if err := c.check(list); err != nil {
+		return err
+	}
+	return c.Client.List(ctx, list, opts...)
+}
+
+func (c *noSecretsClient) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	if err := c.check(obj); err != nil {
+		return err
+	}
+	return c.Client.Create(ctx, obj, opts...)
+}
+
+func (c *noSecretsClient) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	if err := c.check(obj); err != nil {
+		return err
+	}
+	return c.Client.Update(ctx, obj, opts...)
+}
+
+func (c *noSecretsClient) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	if err := c.check(obj); err != nil {
+		return err
+	}
+	return c.Client.Patch(ctx, obj, patch, opts...)
+}
+
+func (c *noSecretsClient) Delete(ctx context.Context, obj client.Object, opts ...client.DeleteOption) error {
+	if err := c.check(obj); err != nil {
+		return err
+	}
+	return c.Client.Delete(ctx, obj, opts...)
+}
+
+func (c *noSecretsClient) DeleteAllOf(ctx context.Context, obj client.Object, opts ...client.DeleteAllOfOption) error {
+	if err := c.check(obj); err != nil {
+		return err
+	}
+	return c.Client.DeleteAllOf(ctx, obj, opts...)
+}
@@ -0,0 +1,171 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package types
+
+import (
+	"context"
+	"testing"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/cuecontext"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/kubevela/pkg/util/singleton"
+
+	wfContext "github.com/kubevela/workflow/pkg/context"
+	"github.com/kubevela/workflow/pkg/cue/model"
+	"github.com/kubevela/workflow/pkg/cue/process"
+)
+
+func TestRestrictContext(t *testing.T) {
+	r := require.New(t)
+	singleton.KubeClient.Set(fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).WithObjects(
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "default"}},
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "conf", Namespace: "default"}},
+	).Build())
+	wfCtx, err := wfContext.NewContext(context.Background(), "default", "restrict", nil)
+	r.NoError(err)
+	r.NoError(wfCtx.SetVar(cuecontext.New().CompileString(`{
+	app: {image: "nginx", password: "pass"}
+	outputs: {token: "tok"}
+}`)))
+	wfCtx.SetMutableValue("other", "other-step", "phase")
+	wfCtx.SetValueInMemory("other", "other-step", "phase")
+	pCtx := process.NewContext(process.ContextData{Name: "app", Namespace: "default"})
+	pCtx.PushData(model.ContextStepSessionID, "step-1")
+	pCtx.SetParameters(map[string]interface{}{"image": "nginx", "password": "pass"})
+	pCtx.(interface {
+		InsertSecrets(string, []process.RequiredSecrets)
+	}).InsertSecrets("", []process.RequiredSecrets{{ContextName: "db", Data: map[string]interface{}{"password": "db-pass"}}})
+
+	var seen RuntimeParams
+	fn := GenericProviderFn[any, Returns[any]](func(_ context.Context, params *Params[any]) (*Returns[any], error) {
+		seen = params.RuntimeParams
+		return &Returns[any]{}, nil
+	})
+	call := func(provider, do string, scopes ...ContextScope) {
+		ctx := WithRuntimeParams(context.Background(), RuntimeParams{WorkflowContext: wfCtx, ProcessContext: pCtx})
+		ctx = WithInterceptors(ctx, RestrictContext(scopes...))
+		v := cuecontext.New().CompileString(`$params: {}`)
+		v = v.FillPath(cue.ParsePath("#provider"), provider).FillPath(cue.ParsePath("#do"), do)
+		_, err := fn.Call(ctx, v)
+		r.NoError(err)
+	}
+	scopes := []ContextScope{
+		{Provider: "custom", Paths: []string{"context.name", "context.parameter.image", "vars.app.image"}},
+		{Provider: "custom.all", Paths: []string{"context.*"}},
+		{Provider: "custom.secret", Paths: []string{"context.*", "context.db", KubeSecretsScope}},
+	}
+
+	call("custom", "peek", scopes...)
+	r.Equal("app", seen.ProcessContext.GetData("name"))
+	r.Nil(seen.ProcessContext.GetData("namespace"))
+	r.Equal(map[string]interface{}{"image": "nginx"}, seen.ProcessContext.GetData("parameter"))
+	r.Nil(seen.ProcessContext.GetData("db"))
+	image, err := seen.WorkflowContext.GetVar("app", "image")
+	r.NoError(err)
+	s, err := image.String()
+	r.NoError(err)
+	r.Equal("nginx", s)
+	app, err := seen.WorkflowContext.GetVar("app")
+	r.NoError(err)
+	r.False(app.LookupPath(cue.ParsePath("password")).Exists())
+	_, err = seen.WorkflowContext.GetVar("app", "password")
+	r.ErrorContains(err, "var app.password is not allowed")
+	_, err = seen.WorkflowContext.GetVar("outputs")
+	r.ErrorContains(err, "var outputs is not allowed")
+	root, err := seen.WorkflowContext.GetVar()
+	r.NoError(err)
+	r.False(root.LookupPath(cue.ParsePath("outputs")).Exists())
+	r.Error(seen.WorkflowContext.SetVar(cuecontext.New().CompileString(`"x"`), "outputs", "token"))
+	r.Empty(seen.WorkflowContext.GetStore().Data)
+	// the mutable and memory values are scoped to the step
+	r.Equal("step-1", seen.ProcessContext.GetData(model.ContextStepSessionID))
+	r.Empty(seen.WorkflowContext.GetMutableValue("other-step", "phase"))
+	seen.WorkflowContext.SetMutableValue("hacked", "other-step", "phase")
+	seen.WorkflowContext.DeleteMutableValue("other-step", "phase")
+	r.Equal("other", wfCtx.GetMutableValue("other-step", "phase"))
+	_, ok := seen.WorkflowContext.GetValueInMemory("other-step", "phase")
+	r.False(ok)
+	seen.WorkflowContext.SetValueInMemory("hacked", "other-step", "phase")
+	seen.WorkflowContext.DeleteValueInMemory("other-step", "phase")
+	r.Equal(0, seen.WorkflowContext.IncreaseCountValueInMemory("other-step", "count"))
+	r.Equal(0, seen.WorkflowContext.IncreaseCountValueInMemory("other-step", "count"))
+	v, ok := wfCtx.GetValueInMemory("other-step", "phase")
+	r.True(ok)
+	r.Equal("other", v)
+	seen.WorkflowContext.SetMutableValue("running", "step-1", "phase")
+	r.Equal("running", seen.WorkflowContext.GetMutableValue("step-1", "phase"))
+	r.Equal("running", wfCtx.GetMutableValue("step-1", "phase"))
+	seen.WorkflowContext.SetValueInMemory("running", "step-1", "phase")
+	v, ok = seen.WorkflowContext.GetValueInMemory("step-1", "phase")
+	r.True(ok)
+	r.Equal("running", v)
+	seen.WorkflowContext.IncreaseCountValueInMemory("step-1", "count")
+	r.Equal(1, wfCtx.IncreaseCountValueInMemory("step-1", "count"))
+	seen.WorkflowContext.DeleteMutableValue("step-1", "phase")
+	r.Empty(wfCtx.GetMutableValue("step-1", "phase"))
+	r.ErrorContains(seen.WorkflowContext.Commit(context.Background()), "commit is not allowed")
+	r.Equal(wfCtx.StoreRef(), seen.WorkflowContext.StoreRef())
+	// the restricted context cannot modify the origin context
+	seen.ProcessContext.GetData("parameter").(map[string]interface{})["image"] = "hacked"
+	r.Equal("nginx", pCtx.GetData("parameter").(map[string]interface{})["image"])
+
+	// the secrets are excluded unless allowed explicitly
+	call("custom", "all", scopes...)
+	r.Equal("default", seen.ProcessContext.GetData("namespace"))
+	r.NotNil(seen.ProcessContext.GetData("parameter"))
+	r.Nil(seen.ProcessContext.GetData("db"))
+	r.Nil(seen.ProcessContext.GetData(model.ContextSecretVersions))
+	_, err = seen.WorkflowContext.GetVar()
+	r.ErrorContains(err, "the root vars are not allowed")
+	call("custom", "secret", scopes...)
+	r.Equal(map[string]interface{}{"password": "db-pass"}, seen.ProcessContext.GetData("db"))
+
+	// the kube client cannot access the secrets unless allowed explicitly
+	ctx := context.Background()
+	secretKey := client.ObjectKey{Namespace: "default", Name: "db"}
+	r.NoError(seen.KubeClient.Get(ctx, secretKey, &corev1.Secret{}))
+	call("custom", "peek", scopes...)
+	r.NoError(seen.KubeClient.Get(ctx, client.ObjectKey{Namespace: "default", Name: "conf"}, &corev1.ConfigMap{}))
+	r.True(kerrors.IsForbidden(seen.KubeClient.Get(ctx, secretKey, &corev1.Secret{})))
+	r.True(kerrors.IsForbidden(seen.KubeClient.List(ctx, &corev1.SecretList{})))
+	u := &unstructured.Unstructured{}
+	u.SetAPIVersion("v1")
+	u.SetKind("Secret")
+	r.True(kerrors.IsForbidden(seen.KubeClient.Get(ctx, secretKey, u)))
+	ul := &unstructured.UnstructuredList{}
+	ul.SetAPIVersion("v1")
+	ul.SetKind("SecretList")
+	r.True(kerrors.IsForbidden(seen.KubeClient.List(ctx, ul)))
+	r.True(kerrors.IsForbidden(seen.KubeClient.Create(ctx, &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "new", Namespace: "default"}})))
+	r.True(kerrors.IsForbidden(seen.KubeClient.Patch(ctx, &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "default"}}, client.Merge)))
+	r.True(kerrors.IsForbidden(seen.KubeClient.Delete(ctx, &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "default"}})))
+
+	// the providers out of the scopes see the full context
+	call("kube", "apply", scopes...)
+	r.Equal(pCtx, seen.ProcessContext)
+	r.Equal(wfCtx, seen.WorkflowContext)
+	r.Equal(singleton.KubeClient.Get(), seen.KubeClient)
+}
@@ -22,8 +22,10 @@ import (
 
 	"cuelang.org/go/cue"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/rest"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
+	cuexruntime "github.com/kubevela/pkg/cue/cuex/runtime"
 	"github.com/kubevela/pkg/util/singleton"
 	wfContext "github.com/kubevela/workflow/pkg/context"
 	"github.com/kubevela/workflow/pkg/cue/process"
@@ -46,6 +48,10 @@ const (
 	KubeHandlersKey ContextKey = "kubeHandlers"
 	// KubeClientKey is the key for kube client.
 	KubeClientKey ContextKey = "kubeClient"
+	// KubeConfigKey is the key for kube config.
+	KubeConfigKey ContextKey = "kubeConfig"
+	// InterceptorsKey is the key for provider interceptors.
+	InterceptorsKey ContextKey = "interceptors"
 )
 
 // Dispatcher is a client for apply resources.
@@ -69,6 +75,10 @@ type RuntimeParams struct {
 	Labels          map[string]string
 	KubeHandlers    *KubeHandlers
 	KubeClient      client.Client
+	// KubeConfig is the config the kube client is built from if it is overridden for the step, providers
+	// that talk to the api-server without the kube client, such as streaming the pod logs, should build
+	// their clients from it. The default kube config is used if it is nil.
+	KubeConfig *rest.Config
 }
 
 // Params is the input parameters of a provider.
@@ -80,6 +90,33 @@ type Params[T any] struct {
 // Returns is the returns of a provider.
 type Returns[T any] struct {
 	Returns T `json:"$returns"`
+	// Warnings are the non-fatal issues of the provider, which are surfaced into the step status
+	// without failing the step.
+	Warnings []string `json:"-"`
+}
+
+// GetWarnings returns the warnings of the provider.
+func (r *Returns[T]) GetWarnings() []string {
+	return r.Warnings
+}
+
+type warningsGetter interface {
+	GetWarnings() []string
+}
+
+// recordWarnings records the warnings of the provider returns to the action if it supports warnings.
+func recordWarnings(action types.Action, ret any) {
+	getter, ok := ret.(warningsGetter)
+	if !ok {
+		return
+	}
+	warner, ok := action.(types.Warner)
+	if !ok {
+		return
+	}
+	for _, warning := range getter.GetWarnings() {
+		warner.Warn(warning)
+	}
 }
 
 // GenericProviderFn is the provider function
@@ -88,6 +125,10 @@ type GenericProviderFn[T any, U any] func(context.Context, *Params[T]) (*U, erro
 // Call marshal value into json and decode into underlying function input
 // parameters, then fill back the returned output value
 func (fn GenericProviderFn[T, U]) Call(ctx context.Context, value cue.Value) (cue.Value, error) {
+	return intercept(ctx, value, fn.call)
+}
+
+func (fn GenericProviderFn[T, U]) call(ctx context.Context, value cue.Value) (cue.Value, error) {
 	type p struct {
 		Params T `json:"$params"`
 	}
@@ -106,6 +147,7 @@ func (fn GenericProviderFn[T, U]) Call(ctx context.Context, value cue.Value) (cu
 	if err != nil {
 		return value, err
 	}
+	recordWarnings(runtimeParams.Action, ret)
 	return value.FillPath(cue.ParsePath(""), ret), nil
 }
 
@@ -121,6 +163,10 @@ type LegacyGenericProviderFn[T any, U any] func(context.Context, *LegacyParams[T
 // Call marshal value into json and decode into underlying function input
 // parameters, then fill back the returned output value
 func (fn LegacyGenericProviderFn[T, U]) Call(ctx context.Context, value cue.Value) (cue.Value, error) {
+	return intercept(ctx, value, fn.call)
+}
+
+func (fn LegacyGenericProviderFn[T, U]) call(ctx context.Context, value cue.Value) (cue.Value, error) {
 	params := new(T)
 	bs, err := value.MarshalJSON()
 	if err != nil {
@@ -136,6 +182,7 @@ func (fn LegacyGenericProviderFn[T, U]) Call(ctx context.Context, value cue.Valu
 	if err != nil {
 		return value, err
 	}
+	recordWarnings(runtimeParams.Action, ret)
 	return value.FillPath(cue.ParsePath(""), ret), nil
 }
 
@@ -145,8 +192,10 @@ type NativeProviderFn func(context.Context, *Params[cue.Value]) (cue.Value, erro
 // Call marshal value into json and decode into underlying function input
 // parameters, then fill back the returned output value
 func (fn NativeProviderFn) Call(ctx context.Context, value cue.Value) (cue.Value, error) {
-	runtimeParams := RuntimeParamsFrom(ctx)
-	return fn(ctx, &Params[cue.Value]{Params: value, RuntimeParams: runtimeParams})
+	return intercept(ctx, value, func(ctx context.Context, value cue.Value) (cue.Value, error) {
+		runtimeParams := RuntimeParamsFrom(ctx)
+		return fn(ctx, &Params[cue.Value]{Params: value, RuntimeParams: runtimeParams})
+	})
 }
 
 // LegacyNativeProviderFn is the legacy native provider function
@@ -155,8 +204,21 @@ type LegacyNativeProviderFn func(context.Context, *LegacyParams[cue.Value]) (cue
 // Call marshal value into json and decode into underlying function input
 // parameters, then fill back the returned output value
 func (fn LegacyNativeProviderFn) Call(ctx context.Context, value cue.Value) (cue.Value, error) {
-	runtimeParams := RuntimeParamsFrom(ctx)
-	return fn(ctx, &LegacyParams[cue.Value]{Params: value, RuntimeParams: runtimeParams})
+	return intercept(ctx, value, func(ctx context.Context, value cue.Value) (cue.Value, error) {
+		runtimeParams := RuntimeParamsFrom(ctx)
+		return fn(ctx, &LegacyParams[cue.Value]{Params: value, RuntimeParams: runtimeParams})
+	})
+}
+
+// ExternalProviderFn is the provider function of the external package, which is called through the interceptors
+// like the provider functions of the internal packages
+type ExternalProviderFn struct {
+	cuexruntime.ProviderFn
+}
+
+// Call invokes the underlying provider function through the interceptors in ctx
+func (fn ExternalProviderFn) Call(ctx context.Context, value cue.Value) (cue.Value, error) {
+	return intercept(ctx, value, fn.ProviderFn.Call)
 }
 
 // WithLabelParams returns a copy of parent in which the labels value is set
@@ -164,6 +226,33 @@ func WithLabelParams(parent context.Context, labels map[string]string) context.C
 	return context.WithValue(parent, LabelsKey, labels)
 }
 
+// WithKubeClient returns a copy of parent in which the kube client value is set
+func WithKubeClient(parent context.Context, cli client.Client) context.Context {
+	return context.WithValue(parent, KubeClientKey, cli)
+}
+
+// WithKubeConfig returns a copy of parent in which the kube config value is set
+func WithKubeConfig(parent context.Context, cfg *rest.Config) context.Context {
+	return context.WithValue(parent, KubeConfigKey, cfg)
+}
+
+// WithInterceptors returns a copy of parent in which the provider interceptors value is set
+func WithInterceptors(parent context.Context, interceptors ...types.ProviderInterceptor) context.Context {
+	return context.WithValue(parent, InterceptorsKey, interceptors)
+}
+
+// intercept invokes the provider through the interceptors in ctx, the first interceptor is the outermost one
+func intercept(ctx context.Context, value cue.Value, invoker types.ProviderInvoker) (cue.Value, error) {
+	interceptors, _ := ctx.Value(InterceptorsKey).([]types.ProviderInterceptor)
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		interceptor, next := interceptors[i], invoker
+		invoker = func(ctx context.Context, value cue.Value) (cue.Value, error) {
+			return interceptor(ctx, value, next)
+		}
+	}
+	return invoker(ctx, value)
+}
+
 // WithRuntimeParams returns a copy of parent in which the runtime params value is set
 func WithRuntimeParams(parent context.Context, params RuntimeParams) context.Context {
 	ctx := context.WithValue(parent, WorkflowContextKey, params.WorkflowContext)
@@ -195,5 +284,8 @@ func RuntimeParamsFrom(ctx context.Context) RuntimeParams {
 	} else {
 		params.KubeClient = singleton.KubeClient.Get()
 	}
+	if kubeConfig, ok := ctx.Value(KubeConfigKey).(*rest.Config); ok {
+		params.KubeConfig = kubeConfig
+	}
 	return params
 }
@@ -0,0 +1,89 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package types
+
+import (
+	"context"
+	"testing"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/cuecontext"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kubevela/workflow/pkg/types"
+)
+
+type echoVars struct {
+	Message  string `json:"message"`
+	Injected string `json:"injected,omitempty"`
+}
+
+type echoReturns = Returns[echoVars]
+
+func TestProviderInterceptors(t *testing.T) {
+	r := require.New(t)
+	var calls []string
+	var labels map[string]string
+	fn := GenericProviderFn[echoVars, echoReturns](func(_ context.Context, params *Params[echoVars]) (*echoReturns, error) {
+		calls = append(calls, "provider")
+		labels = params.Labels
+		return &echoReturns{Returns: params.Params}, nil
+	})
+	inject := func(ctx context.Context, value cue.Value, next types.ProviderInvoker) (cue.Value, error) {
+		calls = append(calls, "inject")
+		ctx = WithLabelParams(ctx, map[string]string{"injected": "true"})
+		return next(ctx, value.FillPath(cue.ParsePath("$params.injected"), "token"))
+	}
+	record := func(ctx context.Context, value cue.Value, next types.ProviderInvoker) (cue.Value, error) {
+		calls = append(calls, "record")
+		return next(ctx, value)
+	}
+	shortCircuit := func(_ context.Context, value cue.Value, _ types.ProviderInvoker) (cue.Value, error) {
+		calls = append(calls, "short-circuit")
+		return value.FillPath(cue.ParsePath("$returns.message"), "cached"), nil
+	}
+	newValue := func() cue.Value {
+		return cuecontext.New().CompileString(`$params: message: "hello"`)
+	}
+
+	ctx := WithInterceptors(context.Background(), record, inject)
+	v, err := fn.Call(ctx, newValue())
+	r.NoError(err)
+	injected, err := v.LookupPath(cue.ParsePath("$returns.injected")).String()
+	r.NoError(err)
+	r.Equal("token", injected)
+	r.Equal(map[string]string{"injected": "true"}, labels)
+	r.Equal([]string{"record", "inject", "provider"}, calls)
+
+	calls = nil
+	ctx = WithInterceptors(context.Background(), record, shortCircuit, inject)
+	v, err = fn.Call(ctx, newValue())
+	r.NoError(err)
+	message, err := v.LookupPath(cue.ParsePath("$returns.message")).String()
+	r.NoError(err)
+	r.Equal("cached", message)
+	r.Equal([]string{"record", "short-circuit"}, calls)
+
+	calls = nil
+	native := NativeProviderFn(func(_ context.Context, params *Params[cue.Value]) (cue.Value, error) {
+		calls = append(calls, "provider")
+		return params.Params, nil
+	})
+	_, err = native.Call(WithInterceptors(context.Background(), record), newValue())
+	r.NoError(err)
+	r.Equal([]string{"record", "provider"}, calls)
+}
@@ -15,6 +15,24 @@
 	...
 }
 
+#MergePatch: {
+	#do:       "merge-patch"
+	#provider: "util"
+
+	$params: {
+		// +usage=The base to apply the merge patches to
+		value: *{} | _
+		// +usage=The JSON merge patches (RFC 7386) applied in sequence, the null in patches deletes the field
+		patches: [..._]
+	}
+
+	$returns?: {
+		// +usage=The result after all the patches are applied
+		result: _
+	}
+	...
+}
+
 #ConvertString: {
 	#do:       "string"
 	#provider: "util"
@@ -23,6 +23,7 @@ import (
 	"fmt"
 
 	"cuelang.org/go/cue"
+	jsonpatch "github.com/evanphx/json-patch/v5"
 	"k8s.io/klog/v2"
 
 	cuexruntime "github.com/kubevela/pkg/cue/cuex/runtime"
@@ -62,6 +63,46 @@ func PatchK8sObject(_ context.Context, params *providertypes.Params[cue.Value])
 	return params.Params.FillPath(value.FieldPath("$returns", "result"), params.Params.Context().CompileBytes(workload)), nil
 }
 
+// MergePatchVars is the vars for merge patch
+type MergePatchVars struct {
+	Base    json.RawMessage   `json:"value"`
+	Patches []json.RawMessage `json:"patches"`
+}
+
+// MergePatchReturnVars is the returns for merge patch
+type MergePatchReturnVars struct {
+	Result any `json:"result"`
+}
+
+// MergePatchParams .
+type MergePatchParams = providertypes.Params[MergePatchVars]
+
+// MergePatchReturns .
+type MergePatchReturns = providertypes.Returns[MergePatchReturnVars]
+
+// MergePatch applies the JSON merge patches (RFC 7386) to the base in sequence, the null in patches deletes the field.
+func MergePatch(_ context.Context, params *MergePatchParams) (*MergePatchReturns, error) {
+	doc := []byte(params.Params.Base)
+	if len(doc) == 0 {
+		doc = []byte("{}")
+	}
+	for i, patch := range params.Params.Patches {
+		var err error
+		if doc, err = jsonpatch.MergePatch(doc, patch); err != nil {
+			return nil, fmt.Errorf("failed to apply merge patch %d: %w", i, err)
+		}
+	}
+	var result any
+	if err := json.Unmarshal(doc, &result); err != nil {
+		return nil, err
+	}
+	return &MergePatchReturns{
+		Returns: MergePatchReturnVars{
+			Result: result,
+		},
+	}, nil
+}
+
 // StringVars .
 type StringVars struct {
 	Byte []byte `json:"bt"`
@@ -191,6 +232,7 @@ func GetTemplate() string {
 func GetProviders() map[string]cuexruntime.ProviderFn {
 	return map[string]cuexruntime.ProviderFn{
 		"patch-k8s-object": providertypes.NativeProviderFn(PatchK8sObject),
+		"merge-patch":      providertypes.GenericProviderFn[MergePatchVars, MergePatchReturns](MergePatch),
 		"string":           providertypes.GenericProviderFn[StringVars, StringReturns](String),
 		"log":              providertypes.GenericProviderFn[LogVars, any](Log),
 	}
@@ -145,6 +145,64 @@ spec: template: metadata: name: "test-patchStrategy"`,
 	}
 }
 
+func TestMergePatch(t *testing.T) {
+	ctx := context.Background()
+	testCases := map[string]struct {
+		base     string
+		patches  []string
+		expected string
+		err      bool
+	}{
+		"override": {
+			base:     `{"image":"nginx:1.24","replicas":1}`,
+			patches:  []string{`{"image":"nginx:1.25"}`, `{"replicas":3}`},
+			expected: `{"image":"nginx:1.25","replicas":3}`,
+		},
+		"null deletes the field": {
+			base:     `{"image":"nginx","debug":true,"env":{"A":"1","B":"2"}}`,
+			patches:  []string{`{"debug":null}`, `{"env":{"A":null}}`},
+			expected: `{"image":"nginx","env":{"B":"2"}}`,
+		},
+		"nested merge": {
+			base:     `{"spec":{"template":{"labels":{"app":"a"},"ports":[80,443]}}}`,
+			patches:  []string{`{"spec":{"template":{"labels":{"tier":"web"},"ports":[8080]}}}`, `{"spec":{"strategy":{"type":"Recreate"}}}`},
+			expected: `{"spec":{"strategy":{"type":"Recreate"},"template":{"labels":{"app":"a","tier":"web"},"ports":[8080]}}}`,
+		},
+		"later patch overrides the earlier one": {
+			patches:  []string{`{"a":{"b":1}}`, `{"a":null}`, `{"a":"c"}`},
+			expected: `{"a":"c"}`,
+		},
+		"non-object patch replaces the base": {
+			base:     `{"a":1}`,
+			patches:  []string{`["x"]`},
+			expected: `["x"]`,
+		},
+		"invalid patch": {
+			base:    `{"a":1}`,
+			patches: []string{`{"a":`},
+			err:     true,
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			r := require.New(t)
+			vars := MergePatchVars{Base: json.RawMessage(tc.base)}
+			for _, patch := range tc.patches {
+				vars.Patches = append(vars.Patches, json.RawMessage(patch))
+			}
+			res, err := MergePatch(ctx, &MergePatchParams{Params: vars})
+			if tc.err {
+				r.Error(err)
+				return
+			}
+			r.NoError(err)
+			b, err := json.Marshal(res.Returns.Result)
+			r.NoError(err)
+			r.JSONEq(tc.expected, string(b))
+		})
+	}
+}
+
 func TestConvertString(t *testing.T) {
 	ctx := context.Background()
 	testCases := map[string]struct {
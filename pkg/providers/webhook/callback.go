@@ -0,0 +1,123 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	goerrors "errors"
+	"io"
+	"net/http"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// maxCallbackBodySize limits the body of a callback to keep the store under the size limit of ConfigMap.
+	maxCallbackBodySize = 512 * 1024
+	// maxCallbackStoreSize limits the total size of the entries in the store, leaving room for the metadata
+	// within the 1MB size limit of ConfigMap.
+	maxCallbackStoreSize = 900 * 1024
+)
+
+var (
+	errCallbackNotFound  = goerrors.New("callback not found")
+	errCallbackDuplicate = goerrors.New("callback has already been received")
+	errCallbackStoreFull = goerrors.New("too many callbacks are waiting to be consumed")
+)
+
+type callbackHandler struct {
+	cli client.Client
+}
+
+// NewCallbackHandler returns the http handler which receives the callbacks of the webhook.wait steps
+// with the path /callbacks/{namespace}/{name}/{token}.
+func NewCallbackHandler(cli client.Client) http.Handler {
+	return &callbackHandler{cli: cli}
+}
+
+func (h *callbackHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, CallbackPath), "/")
+	if !strings.HasPrefix(r.URL.Path, CallbackPath) || len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+		http.Error(w, errCallbackNotFound.Error(), http.StatusNotFound)
+		return
+	}
+	namespace, name, token := parts[0], parts[1], parts[2]
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxCallbackBodySize+1))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(body) > maxCallbackBodySize {
+		http.Error(w, "callback body too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	err = h.receive(r.Context(), namespace, name, token, string(body))
+	switch {
+	case err == nil:
+		w.WriteHeader(http.StatusOK)
+	case goerrors.Is(err, errCallbackNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case goerrors.Is(err, errCallbackDuplicate):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case goerrors.Is(err, errCallbackStoreFull):
+		http.Error(w, err.Error(), http.StatusInsufficientStorage)
+	default:
+		klog.ErrorS(err, "failed to receive the webhook callback", "namespace", namespace, "name", name)
+		http.Error(w, "failed to receive the callback", http.StatusInternalServerError)
+	}
+}
+
+func (h *callbackHandler) receive(ctx context.Context, namespace, name, token, body string) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		cm := &corev1.ConfigMap{}
+		if err := h.cli.Get(ctx, client.ObjectKey{Namespace: namespace, Name: GenerateStoreName(name)}, cm); err != nil {
+			if kerrors.IsNotFound(err) {
+				return errCallbackNotFound
+			}
+			return err
+		}
+		if _, ok := cm.Data[pendingKeyPrefix+token]; !ok {
+			return errCallbackNotFound
+		}
+		if _, ok := cm.Data[receivedKeyPrefix+token]; ok {
+			return errCallbackDuplicate
+		}
+		if storeSize(cm.Data)+len(receivedKeyPrefix+token)+len(body) > maxCallbackStoreSize {
+			return errCallbackStoreFull
+		}
+		cm.Data[receivedKeyPrefix+token] = body
+		return h.cli.Update(ctx, cm)
+	})
+}
+
+func storeSize(data map[string]string) int {
+	size := 0
+	for k, v := range data {
+		size += len(k) + len(v)
+	}
+	return size
+}
@@ -0,0 +1,186 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha1" //nolint:gosec
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/kubevela/workflow/pkg/cue/model"
+	providertypes "github.com/kubevela/workflow/pkg/providers/types"
+)
+
+const defaultSecretKey = "secret"
+
+var (
+	// signatureAlgorithms are the algorithms of the HMAC signature and the default headers, which follow
+	// the webhooks of GitHub
+	signatureAlgorithms = map[string]struct {
+		hash   func() hash.Hash
+		header string
+	}{
+		"sha1":   {hash: sha1.New, header: "X-Hub-Signature"},
+		"sha256": {hash: sha256.New, header: "X-Hub-Signature-256"},
+	}
+
+	httpClient = &http.Client{}
+	// defaultSendTimeout is the timeout of the outgoing webhook if the timeout is not set
+	defaultSendTimeout = 30 * time.Second
+)
+
+// SecretKeyRef is the reference of the key in the secret.
+type SecretKeyRef struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"`
+	Key       string `json:"key,omitempty"`
+}
+
+// SendVars .
+type SendVars struct {
+	URL    string            `json:"url"`
+	Method string            `json:"method,omitempty"`
+	Body   string            `json:"body"`
+	Header map[string]string `json:"header,omitempty"`
+	// Secret is the secret which contains the key to sign the body, the body is not signed if not set
+	Secret *SecretKeyRef `json:"secret,omitempty"`
+	// SignatureHeader is the header of the signature, default to X-Hub-Signature-256 for sha256 and
+	// X-Hub-Signature for sha1
+	SignatureHeader string `json:"signatureHeader,omitempty"`
+	// Algorithm is the hash algorithm of the HMAC, sha1 or sha256, default to sha256
+	Algorithm string `json:"algorithm,omitempty"`
+	Timeout   string `json:"timeout,omitempty"`
+}
+
+// SendReturnVars .
+type SendReturnVars struct {
+	StatusCode int    `json:"statusCode"`
+	Body       string `json:"body"`
+	// Signature is the value of the signature header, e.g. sha256=<hex digest>
+	Signature string `json:"signature,omitempty"`
+}
+
+// SendParams .
+type SendParams = providertypes.Params[SendVars]
+
+// SendReturns .
+type SendReturns = providertypes.Returns[SendReturnVars]
+
+// Send sends the outgoing webhook. If the secret is set, the HMAC of the body is set in the signature header as
+// <algorithm>=<hex digest>, which is computed over the exact bytes of the body sent.
+func Send(ctx context.Context, params *SendParams) (*SendReturns, error) {
+	vars := params.Params
+	if vars.URL == "" {
+		return nil, fmt.Errorf("the url is required")
+	}
+	method := vars.Method
+	if method == "" {
+		method = http.MethodPost
+	}
+	timeout := defaultSendTimeout
+	if vars.Timeout != "" {
+		var err error
+		if timeout, err = time.ParseDuration(vars.Timeout); err != nil || timeout <= 0 {
+			return nil, fmt.Errorf("invalid timeout %s, please use the positive duration like 30s or 1m", vars.Timeout)
+		}
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	body := []byte(vars.Body)
+	req, err := http.NewRequestWithContext(ctx, method, vars.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range vars.Header {
+		req.Header.Set(k, v)
+	}
+
+	returns := SendReturnVars{}
+	if vars.Secret != nil {
+		algorithm := vars.Algorithm
+		if algorithm == "" {
+			algorithm = "sha256"
+		}
+		alg, ok := signatureAlgorithms[algorithm]
+		if !ok {
+			return nil, fmt.Errorf("unsupported signature algorithm %s, the supported algorithms are sha1 and sha256", algorithm)
+		}
+		key, err := loadSecretKey(ctx, params.KubeClient, *vars.Secret, fmt.Sprint(params.ProcessContext.GetData(model.ContextNamespace)))
+		if err != nil {
+			return nil, err
+		}
+		header := vars.SignatureHeader
+		if header == "" {
+			header = alg.header
+		}
+		returns.Signature = sign(alg.hash, key, body, algorithm)
+		req.Header.Set(header, returns.Signature)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close() //nolint:errcheck
+	b, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("the webhook responded with status code %d: %s", resp.StatusCode, string(b))
+	}
+	returns.StatusCode, returns.Body = resp.StatusCode, string(b)
+	return &SendReturns{Returns: returns}, nil
+}
+
+// sign returns the HMAC signature of the body prefixed with the algorithm, e.g. sha256=<hex digest>.
+func sign(h func() hash.Hash, key, body []byte, algorithm string) string {
+	mac := hmac.New(h, key)
+	mac.Write(body)
+	return algorithm + "=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func loadSecretKey(ctx context.Context, cli client.Client, ref SecretKeyRef, defaultNamespace string) ([]byte, error) {
+	namespace, key := ref.Namespace, ref.Key
+	if namespace == "" {
+		namespace = defaultNamespace
+	}
+	if key == "" {
+		key = defaultSecretKey
+	}
+	secret := &corev1.Secret{}
+	if err := cli.Get(ctx, client.ObjectKey{Namespace: namespace, Name: ref.Name}, secret); err != nil {
+		return nil, fmt.Errorf("failed to get the webhook secret %s/%s: %w", namespace, ref.Name, err)
+	}
+	value, ok := secret.Data[key]
+	if !ok || len(value) == 0 {
+		return nil, fmt.Errorf("the key %s is not found in the webhook secret %s/%s", key, namespace, ref.Name)
+	}
+	return value, nil
+}
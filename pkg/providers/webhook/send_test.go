@@ -0,0 +1,119 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1" //nolint:gosec
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/kubevela/workflow/pkg/cue/process"
+	providertypes "github.com/kubevela/workflow/pkg/providers/types"
+)
+
+func TestSend(t *testing.T) {
+	r := require.New(t)
+	ctx := context.Background()
+	var header http.Header
+	var body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		b, _ := io.ReadAll(req.Body)
+		header, body = req.Header, string(b)
+		if req.URL.Path == "/slow" {
+			time.Sleep(200 * time.Millisecond)
+		}
+		if req.URL.Path == "/reject" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte("invalid signature"))
+			return
+		}
+		_, _ = w.Write([]byte("received"))
+	}))
+	defer server.Close()
+	cli := fake.NewClientBuilder().WithObjects(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "hook", Namespace: "ns"},
+		Data:       map[string][]byte{"secret": []byte("It's a Secret to Everybody"), "token": []byte("token")},
+	}).Build()
+	send := func(vars SendVars) (*SendReturns, error) {
+		return Send(ctx, &SendParams{Params: vars, RuntimeParams: providertypes.RuntimeParams{
+			KubeClient:     cli,
+			ProcessContext: process.NewContext(process.ContextData{Namespace: "ns"}),
+		}})
+	}
+
+	// the example in the documents of validating the GitHub webhook deliveries
+	res, err := send(SendVars{URL: server.URL, Body: "Hello, World!", Secret: &SecretKeyRef{Name: "hook"}})
+	r.NoError(err)
+	expected := "sha256=757107ea0eb2509fc211221cce984b8a37570b6d7586c22c46f4379c8b043e17"
+	r.Equal(SendReturnVars{StatusCode: http.StatusOK, Body: "received", Signature: expected}, res.Returns)
+	r.Equal(expected, header.Get("X-Hub-Signature-256"))
+	r.Equal("application/json", header.Get("Content-Type"))
+	r.Equal("Hello, World!", body)
+
+	payload := `{"ref":"refs/heads/main","commits":[{"id":"abc"}]}`
+	res, err = send(SendVars{
+		URL:             server.URL,
+		Body:            payload,
+		Secret:          &SecretKeyRef{Name: "hook", Namespace: "ns", Key: "token"},
+		Algorithm:       "sha1",
+		SignatureHeader: "X-Gitlab-Signature",
+		Header:          map[string]string{"X-Event": "push"},
+	})
+	r.NoError(err)
+	mac := hmac.New(sha1.New, []byte("token"))
+	mac.Write([]byte(payload))
+	r.Equal("sha1="+hex.EncodeToString(mac.Sum(nil)), header.Get("X-Gitlab-Signature"))
+	r.Equal(res.Returns.Signature, header.Get("X-Gitlab-Signature"))
+	r.Empty(header.Get("X-Hub-Signature"))
+	r.Equal("push", header.Get("X-Event"))
+	r.Equal(payload, body)
+
+	res, err = send(SendVars{URL: server.URL, Body: payload})
+	r.NoError(err)
+	r.Empty(res.Returns.Signature)
+	r.Empty(header.Get("X-Hub-Signature-256"))
+
+	_, err = send(SendVars{URL: server.URL + "/reject", Body: payload, Secret: &SecretKeyRef{Name: "hook"}})
+	r.EqualError(err, "the webhook responded with status code 401: invalid signature")
+	_, err = send(SendVars{URL: server.URL, Body: payload, Secret: &SecretKeyRef{Name: "hook"}, Algorithm: "md5"})
+	r.EqualError(err, "unsupported signature algorithm md5, the supported algorithms are sha1 and sha256")
+	_, err = send(SendVars{URL: server.URL, Body: payload, Secret: &SecretKeyRef{Name: "hook", Key: "missing"}})
+	r.EqualError(err, "the key missing is not found in the webhook secret ns/hook")
+
+	for _, timeout := range []string{"0s", "-1s", "invalid"} {
+		_, err = send(SendVars{URL: server.URL, Body: payload, Timeout: timeout})
+		r.EqualError(err, "invalid timeout "+timeout+", please use the positive duration like 30s or 1m")
+	}
+	_, err = send(SendVars{URL: server.URL + "/slow", Body: payload, Timeout: "50ms"})
+	r.ErrorIs(err, context.DeadlineExceeded)
+	origin := defaultSendTimeout
+	defaultSendTimeout = 50 * time.Millisecond
+	t.Cleanup(func() { defaultSendTimeout = origin })
+	_, err = send(SendVars{URL: server.URL + "/slow", Body: payload})
+	r.ErrorIs(err, context.DeadlineExceeded)
+}
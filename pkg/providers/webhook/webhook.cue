@@ -0,0 +1,62 @@
+// webhook.cue
+
+#Wait: {
+	#do:       "wait"
+	#provider: "webhook"
+
+	$params: {
+		// +usage=The timeout of waiting for the callback, the step will fail after the timeout. Wait forever if not specified.
+		timeout?: string
+	}
+
+	$returns?: {
+		// +usage=The token of the callback
+		token: string
+		// +usage=The url of the callback, only set if the callback base url is specified in the controller
+		url?: string
+		// +usage=The body of the callback
+		body: string
+	}
+	...
+}
+
+#Send: {
+	#do:       "send"
+	#provider: "webhook"
+
+	$params: {
+		// +usage=The url of the webhook
+		url: string
+		// +usage=The method of the request
+		method: *"POST" | "PUT" | "PATCH"
+		// +usage=The body of the request, the signature is computed over the exact bytes of the body
+		body: string
+		// +usage=The header of the request, the content type defaults to application/json
+		header?: [string]: string
+		// +usage=The secret which contains the key to sign the body with HMAC, the body is not signed if not set
+		secret?: {
+			// +usage=The name of the secret
+			name: string
+			// +usage=The namespace of the secret, default to the namespace of the workflow
+			namespace?: string
+			// +usage=The key in the secret, default to secret
+			key?: string
+		}
+		// +usage=The header of the signature, default to X-Hub-Signature-256 for sha256 and X-Hub-Signature for sha1
+		signatureHeader?: string
+		// +usage=The hash algorithm of the HMAC
+		algorithm: *"sha256" | "sha1"
+		// +usage=The timeout of the request, default to 30s
+		timeout?: string
+	}
+
+	$returns?: {
+		// +usage=The status code of the response
+		statusCode: int
+		// +usage=The body of the response
+		body: string
+		// +usage=The signature sent in the signature header, e.g. sha256=<hex digest>
+		signature?: string
+	}
+	...
+}
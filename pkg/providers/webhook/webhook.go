@@ -0,0 +1,225 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"crypto/rand"
+	_ "embed"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	cuexruntime "github.com/kubevela/pkg/cue/cuex/runtime"
+
+	"github.com/kubevela/workflow/api/v1alpha1"
+	"github.com/kubevela/workflow/pkg/cue/model"
+	"github.com/kubevela/workflow/pkg/errors"
+	providertypes "github.com/kubevela/workflow/pkg/providers/types"
+)
+
+const (
+	// ProviderName is provider name for install.
+	ProviderName = "webhook"
+	// CallbackPath is the path prefix of the callback endpoint.
+	CallbackPath = "/callbacks/"
+
+	pendingKeyPrefix  = "pending."
+	receivedKeyPrefix = "received."
+	tokenKey          = "token"
+	createdAtKey      = "createdAt"
+	bodyKey           = "body"
+)
+
+// CallbackBaseURL is the external base url of the callback endpoint, which is used to
+// generate the callback url for the steps. Only the token is returned if it is empty.
+var CallbackBaseURL string
+
+// WaitVars .
+type WaitVars struct {
+	Timeout string `json:"timeout,omitempty"`
+}
+
+// WaitReturnVars .
+type WaitReturnVars struct {
+	Token string `json:"token"`
+	URL   string `json:"url,omitempty"`
+	Body  string `json:"body"`
+}
+
+// WaitParams .
+type WaitParams = providertypes.Params[WaitVars]
+
+// WaitReturns .
+type WaitReturns = providertypes.Returns[WaitReturnVars]
+
+// Wait registers a pending callback for the step and waits until the callback endpoint
+// receives the request with the matching token.
+func Wait(ctx context.Context, params *WaitParams) (*WaitReturns, error) {
+	pCtx := params.ProcessContext
+	wfCtx := params.WorkflowContext
+	act := params.Action
+	stepID := fmt.Sprint(pCtx.GetData(model.ContextStepSessionID))
+	name := fmt.Sprint(pCtx.GetData(model.ContextName))
+	namespace := fmt.Sprint(pCtx.GetData(model.ContextNamespace))
+
+	token := wfCtx.GetMutableValue(stepID, ProviderName, tokenKey)
+	if token == "" {
+		b := make([]byte, 16)
+		if _, err := rand.Read(b); err != nil {
+			return nil, fmt.Errorf("failed to generate callback token: %w", err)
+		}
+		token = hex.EncodeToString(b)
+		if err := register(ctx, params.KubeClient, namespace, name, token, fmt.Sprint(pCtx.GetData(model.ContextStepName))); err != nil {
+			return nil, fmt.Errorf("failed to register callback: %w", err)
+		}
+		wfCtx.SetMutableValue(token, stepID, ProviderName, tokenKey)
+		wfCtx.SetMutableValue(time.Now().Format(time.RFC3339), stepID, ProviderName, createdAtKey)
+	}
+	callbackURL := CallbackURL(namespace, name, token)
+	// createdAt is removed once the callback is consumed, the body is then kept in the step
+	// so that the step can be re-executed after the entries are removed from the store
+	if wfCtx.GetMutableValue(stepID, ProviderName, createdAtKey) == "" {
+		return &WaitReturns{Returns: WaitReturnVars{Token: token, URL: callbackURL, Body: wfCtx.GetMutableValue(stepID, ProviderName, bodyKey)}}, nil
+	}
+
+	cm := &corev1.ConfigMap{}
+	if err := params.KubeClient.Get(ctx, client.ObjectKey{Namespace: namespace, Name: GenerateStoreName(name)}, cm); err != nil {
+		return nil, err
+	}
+	if body, ok := cm.Data[receivedKeyPrefix+token]; ok {
+		if err := release(ctx, params.KubeClient, namespace, name, token); err != nil {
+			return nil, fmt.Errorf("failed to release callback: %w", err)
+		}
+		wfCtx.SetMutableValue(body, stepID, ProviderName, bodyKey)
+		wfCtx.DeleteMutableValue(stepID, ProviderName, createdAtKey)
+		return &WaitReturns{Returns: WaitReturnVars{Token: token, URL: callbackURL, Body: body}}, nil
+	}
+
+	if params.Params.Timeout != "" {
+		d, err := time.ParseDuration(params.Params.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse timeout %s: %w", params.Params.Timeout, err)
+		}
+		createdAt, err := time.Parse(time.RFC3339, wfCtx.GetMutableValue(stepID, ProviderName, createdAtKey))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse the creation time of the callback: %w", err)
+		}
+		if time.Now().After(createdAt.Add(d)) {
+			if err := release(ctx, params.KubeClient, namespace, name, token); err != nil {
+				return nil, fmt.Errorf("failed to release callback: %w", err)
+			}
+			wfCtx.DeleteMutableValue(stepID, ProviderName, tokenKey)
+			wfCtx.DeleteMutableValue(stepID, ProviderName, createdAtKey)
+			act.Fail("Timeout waiting for the webhook callback")
+			return nil, errors.GenericActionError(errors.ActionTerminate)
+		}
+	}
+
+	act.Wait("Waiting for the webhook callback")
+	return nil, errors.GenericActionError(errors.ActionWait)
+}
+
+// CallbackURL returns the callback url of the token, it is empty if CallbackBaseURL is not set.
+func CallbackURL(namespace, name, token string) string {
+	if CallbackBaseURL == "" {
+		return ""
+	}
+	return strings.TrimSuffix(CallbackBaseURL, "/") + CallbackPath + url.PathEscape(namespace) + "/" + url.PathEscape(name) + "/" + token
+}
+
+// GenerateStoreName generates the config map name which stores the callbacks of the workflow run.
+func GenerateStoreName(name string) string {
+	return fmt.Sprintf("workflow-%s-callbacks", name)
+}
+
+// register adds the pending entry of the token to the store, it retries if the store is updated or created
+// by another step at the same time.
+func register(ctx context.Context, cli client.Client, namespace, name, token, stepName string) error {
+	return retry.OnError(retry.DefaultRetry, func(err error) bool {
+		return kerrors.IsConflict(err) || kerrors.IsAlreadyExists(err)
+	}, func() error {
+		cm := &corev1.ConfigMap{}
+		err := cli.Get(ctx, client.ObjectKey{Namespace: namespace, Name: GenerateStoreName(name)}, cm)
+		if err == nil {
+			if cm.Data == nil {
+				cm.Data = map[string]string{}
+			}
+			cm.Data[pendingKeyPrefix+token] = stepName
+			return cli.Update(ctx, cm)
+		}
+		if !kerrors.IsNotFound(err) {
+			return err
+		}
+		// the store must be owned by the workflow run, otherwise it is never garbage collected
+		run := &v1alpha1.WorkflowRun{}
+		if err := cli.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, run); err != nil {
+			return fmt.Errorf("failed to get workflow run %s: %w", name, err)
+		}
+		cm = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            GenerateStoreName(name),
+				Namespace:       namespace,
+				OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(run, v1alpha1.WorkflowRunGroupVersionKind)},
+			},
+			Data: map[string]string{pendingKeyPrefix + token: stepName},
+		}
+		return cli.Create(ctx, cm)
+	})
+}
+
+// release removes the pending and received entries of the token from the store.
+func release(ctx context.Context, cli client.Client, namespace, name, token string) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		cm := &corev1.ConfigMap{}
+		if err := cli.Get(ctx, client.ObjectKey{Namespace: namespace, Name: GenerateStoreName(name)}, cm); err != nil {
+			return client.IgnoreNotFound(err)
+		}
+		_, pending := cm.Data[pendingKeyPrefix+token]
+		_, received := cm.Data[receivedKeyPrefix+token]
+		if !pending && !received {
+			return nil
+		}
+		delete(cm.Data, pendingKeyPrefix+token)
+		delete(cm.Data, receivedKeyPrefix+token)
+		return cli.Update(ctx, cm)
+	})
+}
+
+//go:embed webhook.cue
+var template string
+
+// GetTemplate returns the template
+func GetTemplate() string {
+	return template
+}
+
+// GetProviders returns the provider
+func GetProviders() map[string]cuexruntime.ProviderFn {
+	return map[string]cuexruntime.ProviderFn{
+		"wait": providertypes.GenericProviderFn[WaitVars, WaitReturns](Wait),
+		"send": providertypes.GenericProviderFn[SendVars, SendReturns](Send),
+	}
+}
@@ -0,0 +1,273 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	"github.com/kubevela/workflow/api/v1alpha1"
+	wfContext "github.com/kubevela/workflow/pkg/context"
+	"github.com/kubevela/workflow/pkg/cue/model"
+	"github.com/kubevela/workflow/pkg/cue/process"
+	"github.com/kubevela/workflow/pkg/errors"
+	providertypes "github.com/kubevela/workflow/pkg/providers/types"
+)
+
+func TestWait(t *testing.T) {
+	r := require.New(t)
+	ctx := context.Background()
+	run := &v1alpha1.WorkflowRun{ObjectMeta: metav1.ObjectMeta{Name: "run", Namespace: "default"}}
+	cli := fake.NewClientBuilder().WithScheme(newSchemeForTest(t)).WithObjects(run).Build()
+
+	CallbackBaseURL = "https://workflow.example.com/"
+	defer func() { CallbackBaseURL = "" }()
+	pCtx := process.NewContext(process.ContextData{Name: "run", Namespace: "default"})
+	pCtx.PushData(model.ContextStepSessionID, "step-id")
+	pCtx.PushData(model.ContextStepName, "step")
+	wfCtx := newWorkflowContextForTest(t)
+	act := &mockAction{}
+	params := &WaitParams{
+		Params: WaitVars{Timeout: "1h"},
+		RuntimeParams: providertypes.RuntimeParams{
+			Action:          act,
+			WorkflowContext: wfCtx,
+			ProcessContext:  pCtx,
+			KubeClient:      cli,
+		},
+	}
+
+	_, err := Wait(ctx, params)
+	r.Equal(errors.GenericActionError(errors.ActionWait), err)
+	r.True(act.wait)
+	token := wfCtx.GetMutableValue("step-id", ProviderName, tokenKey)
+	r.Len(token, 32)
+	r.Equal("Waiting for the webhook callback", act.msg)
+	cm := &corev1.ConfigMap{}
+	r.NoError(cli.Get(ctx, client.ObjectKey{Namespace: "default", Name: "workflow-run-callbacks"}, cm))
+	r.Equal("step", cm.Data["pending."+token])
+	r.Equal("run", cm.OwnerReferences[0].Name)
+
+	// the token should be kept in the next reconcile
+	_, err = Wait(ctx, params)
+	r.Equal(errors.GenericActionError(errors.ActionWait), err)
+	r.Equal(token, wfCtx.GetMutableValue("step-id", ProviderName, tokenKey))
+
+	handler := NewCallbackHandler(cli)
+	callback := func(method, path, body string) int {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return rec.Code
+	}
+	r.Equal(http.StatusMethodNotAllowed, callback(http.MethodGet, "/callbacks/default/run/"+token, ""))
+	r.Equal(http.StatusNotFound, callback(http.MethodPost, "/callbacks/default/run/invalid", ""))
+	r.Equal(http.StatusNotFound, callback(http.MethodPost, "/callbacks/default/other/"+token, ""))
+	r.Equal(http.StatusNotFound, callback(http.MethodPost, "/callbacks/default/run", ""))
+	r.Equal(http.StatusOK, callback(http.MethodPost, "/callbacks/default/run/"+token, `{"approved":true}`))
+	r.Equal(http.StatusConflict, callback(http.MethodPost, "/callbacks/default/run/"+token, `{"approved":false}`))
+
+	res, err := Wait(ctx, params)
+	r.NoError(err)
+	r.Equal(token, res.Returns.Token)
+	r.Equal("https://workflow.example.com/callbacks/default/run/"+token, res.Returns.URL)
+	r.Equal(`{"approved":true}`, res.Returns.Body)
+	// the entries are removed once the callback is consumed
+	r.NoError(cli.Get(ctx, client.ObjectKey{Namespace: "default", Name: "workflow-run-callbacks"}, cm))
+	r.Empty(cm.Data)
+	r.Equal(http.StatusNotFound, callback(http.MethodPost, "/callbacks/default/run/"+token, `{"approved":false}`))
+	res, err = Wait(ctx, params)
+	r.NoError(err)
+	r.Equal(`{"approved":true}`, res.Returns.Body)
+}
+
+func TestRegisterConflict(t *testing.T) {
+	r := require.New(t)
+	ctx := context.Background()
+	run := &v1alpha1.WorkflowRun{ObjectMeta: metav1.ObjectMeta{Name: "run", Namespace: "default"}}
+	// the first create and update conflict with the other steps registering at the same time
+	created, updated := false, false
+	cli := fake.NewClientBuilder().WithScheme(newSchemeForTest(t)).WithObjects(run).WithInterceptorFuncs(interceptor.Funcs{
+		Create: func(ctx context.Context, cli client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+			if !created {
+				created = true
+				other := &corev1.ConfigMap{
+					ObjectMeta: metav1.ObjectMeta{Name: obj.GetName(), Namespace: obj.GetNamespace()},
+					Data:       map[string]string{"pending.other": "other"},
+				}
+				r.NoError(cli.Create(ctx, other))
+				return kerrors.NewAlreadyExists(corev1.Resource("configmaps"), obj.GetName())
+			}
+			return cli.Create(ctx, obj, opts...)
+		},
+		Update: func(ctx context.Context, cli client.WithWatch, obj client.Object, opts ...client.UpdateOption) error {
+			if !updated {
+				updated = true
+				return kerrors.NewConflict(corev1.Resource("configmaps"), obj.GetName(), nil)
+			}
+			return cli.Update(ctx, obj, opts...)
+		},
+	}).Build()
+
+	r.NoError(register(ctx, cli, "default", "run", "a", "step-a"))
+	r.True(created)
+	r.True(updated)
+	cm := &corev1.ConfigMap{}
+	r.NoError(cli.Get(ctx, client.ObjectKey{Namespace: "default", Name: "workflow-run-callbacks"}, cm))
+	r.Equal(map[string]string{"pending.other": "other", "pending.a": "step-a"}, cm.Data)
+}
+
+func TestCallbackStoreFull(t *testing.T) {
+	r := require.New(t)
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "workflow-run-callbacks", Namespace: "default"},
+		Data: map[string]string{
+			"pending.a":  "step-a",
+			"received.a": strings.Repeat("a", maxCallbackBodySize),
+			"pending.b":  "step-b",
+		},
+	}
+	cli := fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).WithObjects(cm).Build()
+	handler := NewCallbackHandler(cli)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/callbacks/default/run/b", strings.NewReader(strings.Repeat("b", maxCallbackBodySize))))
+	r.Equal(http.StatusInsufficientStorage, rec.Code)
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/callbacks/default/run/b", strings.NewReader("b")))
+	r.Equal(http.StatusOK, rec.Code)
+}
+
+func TestWaitWithoutWorkflowRun(t *testing.T) {
+	r := require.New(t)
+	ctx := context.Background()
+	cli := fake.NewClientBuilder().WithScheme(newSchemeForTest(t)).Build()
+	pCtx := process.NewContext(process.ContextData{Name: "run", Namespace: "default"})
+	pCtx.PushData(model.ContextStepSessionID, "step-id")
+	wfCtx := newWorkflowContextForTest(t)
+	_, err := Wait(ctx, &WaitParams{
+		RuntimeParams: providertypes.RuntimeParams{
+			Action:          &mockAction{},
+			WorkflowContext: wfCtx,
+			ProcessContext:  pCtx,
+			KubeClient:      cli,
+		},
+	})
+	r.Error(err)
+	r.Contains(err.Error(), "failed to get workflow run run")
+	r.Empty(wfCtx.GetMutableValue("step-id", ProviderName, tokenKey))
+	cms := &corev1.ConfigMapList{}
+	r.NoError(cli.List(ctx, cms))
+	r.Empty(cms.Items)
+}
+
+func TestWaitTimeout(t *testing.T) {
+	r := require.New(t)
+	ctx := context.Background()
+	run := &v1alpha1.WorkflowRun{ObjectMeta: metav1.ObjectMeta{Name: "run", Namespace: "default"}}
+	cli := fake.NewClientBuilder().WithScheme(newSchemeForTest(t)).WithObjects(run).Build()
+	pCtx := process.NewContext(process.ContextData{Name: "run", Namespace: "default"})
+	pCtx.PushData(model.ContextStepSessionID, "step-id")
+	pCtx.PushData(model.ContextStepName, "step")
+	wfCtx := newWorkflowContextForTest(t)
+	act := &mockAction{}
+	params := &WaitParams{
+		Params: WaitVars{Timeout: "1m"},
+		RuntimeParams: providertypes.RuntimeParams{
+			Action:          act,
+			WorkflowContext: wfCtx,
+			ProcessContext:  pCtx,
+			KubeClient:      cli,
+		},
+	}
+	_, err := Wait(ctx, params)
+	r.Equal(errors.GenericActionError(errors.ActionWait), err)
+	token := wfCtx.GetMutableValue("step-id", ProviderName, tokenKey)
+	r.Equal("Waiting for the webhook callback", act.msg)
+	r.NotContains(act.msg, token)
+
+	wfCtx.SetMutableValue(time.Now().Add(-2*time.Minute).Format(time.RFC3339), "step-id", ProviderName, createdAtKey)
+	_, err = Wait(ctx, params)
+	r.Equal(errors.GenericActionError(errors.ActionTerminate), err)
+	r.True(act.failed)
+	r.Equal("Timeout waiting for the webhook callback", act.msg)
+	// the pending entry is removed once the callback expires
+	cm := &corev1.ConfigMap{}
+	r.NoError(cli.Get(ctx, client.ObjectKey{Namespace: "default", Name: "workflow-run-callbacks"}, cm))
+	r.Empty(cm.Data)
+	r.Empty(wfCtx.GetMutableValue("step-id", ProviderName, tokenKey))
+
+	params.Params.Timeout = "invalid"
+	_, err = Wait(ctx, params)
+	r.Error(err)
+}
+
+type mockAction struct {
+	wait   bool
+	failed bool
+	msg    string
+}
+
+func (act *mockAction) GetStatus() v1alpha1.StepStatus {
+	return v1alpha1.StepStatus{}
+}
+
+func (act *mockAction) Suspend(string) {}
+
+func (act *mockAction) Resume(string) {}
+
+func (act *mockAction) Terminate(string) {}
+
+func (act *mockAction) Wait(msg string) {
+	act.wait = true
+	act.msg = msg
+}
+
+func (act *mockAction) Fail(msg string) {
+	act.failed = true
+	act.msg = msg
+}
+
+func (act *mockAction) Message(string) {}
+
+func newSchemeForTest(t *testing.T) *runtime.Scheme {
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, v1alpha1.AddToScheme(scheme))
+	return scheme
+}
+
+func newWorkflowContextForTest(t *testing.T) wfContext.Context {
+	wfCtx := new(wfContext.WorkflowContext)
+	require.NoError(t, wfCtx.LoadFromConfigMap(context.Background(), corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "workflow-run-context"},
+		Data:       map[string]string{},
+	}))
+	return wfCtx
+}
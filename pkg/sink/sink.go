@@ -0,0 +1,81 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sink
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/kubevela/workflow/pkg/types"
+)
+
+const defaultWebhookTimeout = 5 * time.Second
+
+// Noop is the output sink which drops the outputs.
+type Noop struct{}
+
+// Send implements types.OutputSink.
+func (Noop) Send(context.Context, types.StepOutput) error {
+	return nil
+}
+
+// Webhook is the output sink which posts the outputs of each step to the url as json.
+type Webhook struct {
+	url    string
+	client *http.Client
+}
+
+// NewWebhook creates the webhook output sink, the request to the url times out after the timeout,
+// which defaults to 5s.
+func NewWebhook(url string, timeout time.Duration) (*Webhook, error) {
+	if url == "" {
+		return nil, fmt.Errorf("the url of the webhook output sink is required")
+	}
+	if timeout <= 0 {
+		timeout = defaultWebhookTimeout
+	}
+	return &Webhook{url: url, client: &http.Client{Timeout: timeout}}, nil
+}
+
+// Send implements types.OutputSink.
+func (w *Webhook) Send(ctx context.Context, output types.StepOutput) error {
+	body, err := json.Marshal(output)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	//nolint:errcheck
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("unexpected status code %d from the output sink: %s", resp.StatusCode, string(msg))
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sink
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kubevela/workflow/api/v1alpha1"
+	"github.com/kubevela/workflow/pkg/types"
+)
+
+func TestWebhook(t *testing.T) {
+	r := require.New(t)
+	var received []types.StepOutput
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		output := types.StepOutput{}
+		require.NoError(t, json.NewDecoder(req.Body).Decode(&output))
+		received = append(received, output)
+	}))
+	defer srv.Close()
+
+	_, err := NewWebhook("", 0)
+	r.Error(err)
+	sink, err := NewWebhook(srv.URL, 0)
+	r.NoError(err)
+	output := types.StepOutput{
+		WorkflowRun: "run",
+		Namespace:   "default",
+		StepID:      "step-id",
+		StepName:    "step",
+		Phase:       v1alpha1.WorkflowStepPhaseSucceeded,
+		Outputs:     map[string]interface{}{"image": "nginx"},
+	}
+	r.NoError(sink.Send(context.Background(), output))
+	r.Equal([]types.StepOutput{output}, received)
+
+	sink, err = NewWebhook(srv.URL+"/fail", 0)
+	r.NoError(err)
+	r.ErrorContains(sink.Send(context.Background(), output), "unexpected status code 500")
+	r.NoError(Noop{}.Send(context.Background(), output))
+}
@@ -17,6 +17,8 @@ limitations under the License.
 */
 
 import (
+	"time"
+
 	"github.com/kubevela/pkg/cue/cuex"
 
 	monitorContext "github.com/kubevela/pkg/monitor/context"
@@ -46,6 +48,8 @@ type executor struct {
 	failedAfterRetries bool
 	wait               bool
 	skip               bool
+	workflowMessage    string
+	pollInterval       time.Duration
 
 	tracer monitorContext.Context
 }
@@ -111,6 +115,34 @@ func (exec *executor) Message(message string) {
 	}
 }
 
+// Warn records the non-fatal warning to step status without failing the step, the duplicated
+// warnings and the warnings beyond the limit are dropped.
+func (exec *executor) Warn(message string) {
+	if message == "" || len(exec.wfStatus.Warnings) >= types.MaxWorkflowStepWarnings {
+		return
+	}
+	for _, warning := range exec.wfStatus.Warnings {
+		if warning == message {
+			return
+		}
+	}
+	exec.wfStatus.Warnings = append(exec.wfStatus.Warnings, message)
+}
+
+// WorkflowMessage writes message to workflow status, note that the message will be overwritten by the next message.
+func (exec *executor) WorkflowMessage(message string) {
+	if message != "" {
+		exec.workflowMessage = message
+	}
+}
+
+// PollInterval sets the interval to poll the step if it is waiting.
+func (exec *executor) PollInterval(interval time.Duration) {
+	if interval > 0 {
+		exec.pollInterval = interval
+	}
+}
+
 func (exec *executor) Skip(message string) {
 	exec.skip = true
 	exec.wfStatus.Phase = v1alpha1.WorkflowStepPhaseSkipped
@@ -151,6 +183,20 @@ func (exec *executor) checkErrorTimes(ctx wfContext.Context) {
 	}
 }
 
+// stepAttempt returns the current attempt of the step from the failed times recorded by checkErrorTimes,
+// the first failure is recorded as 0 so the attempt after it is 2.
+func stepAttempt(ctx wfContext.Context, id string) int {
+	v, ok := ctx.GetValueInMemory(types.ContextPrefixFailedTimes, id)
+	if !ok {
+		return 1
+	}
+	times, ok := v.(int)
+	if !ok {
+		return 1
+	}
+	return times + 2
+}
+
 func (exec *executor) operation() *types.Operation {
 	return &types.Operation{
 		Suspend:            exec.suspend,
@@ -158,6 +204,8 @@ func (exec *executor) operation() *types.Operation {
 		Waiting:            exec.wait,
 		Skip:               exec.skip,
 		FailedAfterRetries: exec.failedAfterRetries,
+		WorkflowMessage:    exec.workflowMessage,
+		PollInterval:       exec.pollInterval,
 	}
 }
 
@@ -0,0 +1,60 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package custom
+
+import (
+	"context"
+	"fmt"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/kubevela/workflow/api/v1alpha1"
+	providertypes "github.com/kubevela/workflow/pkg/providers/types"
+)
+
+const defaultTokenExpirationSeconds int64 = 600
+
+var newTokenKubeClient = providertypes.KubeClientWithToken
+
+// withStepCredentials requests the bound service account token of the step via TokenRequest, and returns
+// the context in which the kube client and the kube config of the providers authenticate with the token.
+func withStepCredentials(ctx context.Context, cli client.Client, namespace string, credentials *v1alpha1.StepCredentials) (context.Context, error) {
+	expiration := ptr.Deref(credentials.ExpirationSeconds, defaultTokenExpirationSeconds)
+	sa := &corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: credentials.ServiceAccountName, Namespace: namespace}}
+	req := &authenticationv1.TokenRequest{
+		Spec: authenticationv1.TokenRequestSpec{
+			Audiences:         credentials.Audiences,
+			ExpirationSeconds: &expiration,
+		},
+	}
+	if err := cli.SubResource("token").Create(ctx, sa, req); err != nil {
+		return nil, fmt.Errorf("failed to request the token of service account %s/%s: %w", namespace, credentials.ServiceAccountName, err)
+	}
+	if req.Status.Token == "" {
+		return nil, fmt.Errorf("empty token is returned for service account %s/%s", namespace, credentials.ServiceAccountName)
+	}
+	stepCli, err := newTokenKubeClient(req.Status.Token)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the kube client with the step credentials: %w", err)
+	}
+	ctx = providertypes.WithKubeConfig(ctx, providertypes.KubeConfigWithToken(req.Status.Token))
+	return providertypes.WithKubeClient(ctx, stepCli), nil
+}
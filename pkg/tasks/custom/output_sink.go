@@ -0,0 +1,62 @@
+/*
+Copyright 2022 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package custom
+
+import (
+	"fmt"
+
+	monitorContext "github.com/kubevela/pkg/monitor/context"
+
+	"github.com/kubevela/workflow/api/v1alpha1"
+	wfContext "github.com/kubevela/workflow/pkg/context"
+	"github.com/kubevela/workflow/pkg/cue/model"
+	"github.com/kubevela/workflow/pkg/cue/process"
+	"github.com/kubevela/workflow/pkg/types"
+)
+
+// sendOutputs streams the outputs of the finished step to the sink, the failures of the sink are only
+// logged so that the step is not affected.
+func sendOutputs(ctx monitorContext.Context, sink types.OutputSink, wfCtx wfContext.Context, pCtx process.Context, step v1alpha1.WorkflowStep, status v1alpha1.StepStatus) {
+	output := types.StepOutput{
+		StepID:   status.ID,
+		StepName: step.Name,
+		StepType: step.Type,
+		Phase:    status.Phase,
+	}
+	if pCtx != nil {
+		output.WorkflowRun = fmt.Sprint(pCtx.GetData(model.ContextName))
+		output.Namespace = fmt.Sprint(pCtx.GetData(model.ContextNamespace))
+	}
+	for _, o := range step.Outputs {
+		v, err := wfCtx.GetVar(o.Name)
+		if err != nil {
+			continue
+		}
+		var data interface{}
+		if err := v.Decode(&data); err != nil {
+			ctx.Error(err, "failed to decode the output for the sink", "output", o.Name)
+			continue
+		}
+		if output.Outputs == nil {
+			output.Outputs = make(map[string]interface{}, len(step.Outputs))
+		}
+		output.Outputs[o.Name] = data
+	}
+	if err := sink.Send(ctx.GetContext(), output); err != nil {
+		ctx.Error(err, "failed to send the outputs to the sink")
+	}
+}
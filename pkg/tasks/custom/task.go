@@ -65,7 +65,7 @@ type taskRunner struct {
 	name         string
 	run          func(ctx wfContext.Context, options *types.TaskRunOptions) (v1alpha1.StepStatus, *types.Operation, error)
 	checkPending func(ctx monitorContext.Context, wfCtx wfContext.Context, stepStatus map[string]v1alpha1.StepStatus) (bool, v1alpha1.StepStatus)
-	fillContext  func(ctx monitorContext.Context, processCtx process.Context) types.ContextDataResetter
+	fillContext  func(ctx monitorContext.Context, processCtx process.Context, extra ...process.StepMetaKV) types.ContextDataResetter
 }
 
 // Name return step name.
@@ -121,18 +121,18 @@ func (t *TaskLoader) makeTaskGenerator(templ string) (types.TaskGenerator, error
 				t.runOptionsProcess(options)
 			}
 
-			resetter := tRunner.fillContext(ctx, options.PCtx)
+			resetter := tRunner.fillContext(ctx, options.PCtx, process.WithAttempt(stepAttempt(wfCtx, exec.wfStatus.ID)))
 			defer resetter(options.PCtx)
 			basicVal, _ := MakeBasicValue(ctx, options.Compiler, wfStep.Properties, options.PCtx)
 
 			return CheckPending(wfCtx, wfStep, exec.wfStatus.ID, stepStatus, basicVal)
 		}
-		tRunner.fillContext = func(ctx monitorContext.Context, processCtx process.Context) types.ContextDataResetter {
-			metas := []process.StepMetaKV{
+		tRunner.fillContext = func(ctx monitorContext.Context, processCtx process.Context, extra ...process.StepMetaKV) types.ContextDataResetter {
+			metas := append([]process.StepMetaKV{
 				process.WithName(wfStep.Name),
 				process.WithSessionID(exec.wfStatus.ID),
 				process.WithSpanID(ctx.GetID()),
-			}
+			}, extra...)
 			manager := process.NewStepRunTimeMeta()
 			manager.Fill(processCtx, metas)
 			return func(processCtx process.Context) {
@@ -159,7 +159,7 @@ func (t *TaskLoader) makeTaskGenerator(templ string) (types.TaskGenerator, error
 			if t.runOptionsProcess != nil {
 				t.runOptionsProcess(options)
 			}
-			resetter := tRunner.fillContext(tracer, options.PCtx)
+			resetter := tRunner.fillContext(tracer, options.PCtx, process.WithAttempt(stepAttempt(wfCtx, exec.wfStatus.ID)))
 			defer resetter(options.PCtx)
 
 			ctx := providertypes.WithRuntimeParams(tracer.GetContext(), providertypes.RuntimeParams{
@@ -167,6 +167,9 @@ func (t *TaskLoader) makeTaskGenerator(templ string) (types.TaskGenerator, error
 				ProcessContext:  options.PCtx,
 				Action:          exec,
 			})
+			if len(options.Interceptors) > 0 {
+				ctx = providertypes.WithInterceptors(ctx, options.Interceptors...)
+			}
 
 			basicVal, err := MakeBasicValue(tracer, options.Compiler, wfStep.Properties, options.PCtx)
 			if err != nil {
@@ -198,6 +201,9 @@ func (t *TaskLoader) makeTaskGenerator(templ string) (types.TaskGenerator, error
 						return
 					}
 				}
+				if status := exec.status(); options.OutputSink != nil && types.IsStepFinish(status.Phase, status.Reason) {
+					sendOutputs(tracer, options.OutputSink, wfCtx, options.PCtx, wfStep, status)
+				}
 			}()
 
 			for _, hook := range options.PreCheckHooks {
@@ -224,6 +230,15 @@ func (t *TaskLoader) makeTaskGenerator(templ string) (types.TaskGenerator, error
 				}
 			}
 
+			if wfStep.Credentials != nil {
+				namespace := fmt.Sprint(options.PCtx.GetData(model.ContextNamespace))
+				if ctx, err = withStepCredentials(ctx, providertypes.RuntimeParamsFrom(ctx).KubeClient, namespace, wfStep.Credentials); err != nil {
+					tracer.Error(err, "request step credentials")
+					exec.err(wfCtx, true, err, types.StatusReasonExecute)
+					return exec.status(), exec.operation(), nil
+				}
+			}
+
 			if status, ok := options.StepStatus[wfStep.Name]; ok {
 				exec.stepStatus = status
 			}
@@ -269,6 +284,35 @@ func ValidateIfValue(ctx wfContext.Context, step v1alpha1.WorkflowStep, stepStat
 	return check, nil
 }
 
+// ValidateDependencyCondition evaluates the condition of the conditional dependency against the live context,
+// an error is returned if the condition can not be resolved to a concrete bool value. The error is an
+// IncompleteConditionError if the condition references the values which are not available yet.
+func ValidateDependencyCondition(ctx wfContext.Context, step v1alpha1.WorkflowStep, depend v1alpha1.ConditionalDependency, stepStatus map[string]v1alpha1.StepStatus, basicVal cue.Value) (bool, error) {
+	s, _ := util.ToString(basicVal)
+	template := fmt.Sprintf("if: %s\n%s\n%s\n%s", depend.If, getInputsTemplate(ctx, step, basicVal), buildValueForStatus(ctx, stepStatus), s)
+	v := cuecontext.New().CompileString(template).LookupPath(cue.ParsePath("if"))
+	active, err := v.Bool()
+	if err == nil {
+		return active, nil
+	}
+	// the type errors are concrete, while the references to the missing values are not
+	if !v.IsConcrete() {
+		err = IncompleteConditionError{err}
+	}
+	return false, errors.WithMessage(err, "invalid dependency condition")
+}
+
+// IncompleteConditionError is the error of the dependency condition which references the values that are not
+// available yet.
+type IncompleteConditionError struct {
+	error
+}
+
+// Unwrap returns the error of the evaluation.
+func (e IncompleteConditionError) Unwrap() error {
+	return e.error
+}
+
 func buildValueForStatus(_ wfContext.Context, stepStatus map[string]v1alpha1.StepStatus) string {
 	statusMap := make(map[string]interface{})
 	for name, ss := range stepStatus {
@@ -359,7 +403,22 @@ func NewTaskLoader(lt LoadTaskTemplate, logLevel int, pCtx process.Context, comp
 	}
 }
 
-// CheckPending checks whether to pending task run
+// dependenciesFinished returns whether all the dependencies of the step, including the conditional ones, are finished
+func dependenciesFinished(step v1alpha1.WorkflowStep, stepStatus map[string]v1alpha1.StepStatus) bool {
+	names := append([]string{}, step.DependsOn...)
+	for _, depend := range step.ConditionalDependsOn {
+		names = append(names, depend.Name)
+	}
+	for _, name := range names {
+		if status, ok := stepStatus[name]; !ok || !types.IsStepFinish(status.Phase, status.Reason) {
+			return false
+		}
+	}
+	return true
+}
+
+// CheckPending checks whether to pending task run, the returned status is failed if the step can never run
+// because the condition of its dependency is invalid
 func CheckPending(ctx wfContext.Context, step v1alpha1.WorkflowStep, id string, stepStatus map[string]v1alpha1.StepStatus, basicValue cue.Value) (bool, v1alpha1.StepStatus) {
 	pStatus := v1alpha1.StepStatus{
 		Phase: v1alpha1.WorkflowStepPhasePending,
@@ -377,6 +436,29 @@ func CheckPending(ctx wfContext.Context, step v1alpha1.WorkflowStep, id string,
 			return true, pStatus
 		}
 	}
+	for _, depend := range step.ConditionalDependsOn {
+		pStatus.Message = fmt.Sprintf("Pending on DependsOn: %s", depend.Name)
+		active, err := ValidateDependencyCondition(ctx, step, depend, stepStatus, basicValue)
+		if err != nil {
+			// the condition may reference the data which is not available yet, re-evaluate it in the next schedule
+			// until the dependencies are finished, after which the condition can never be resolved
+			var incomplete IncompleteConditionError
+			if errors.As(err, &incomplete) && !dependenciesFinished(step, stepStatus) {
+				pStatus.Message = fmt.Sprintf("Pending on the condition of DependsOn %s: %s", depend.Name, err.Error())
+				return true, pStatus
+			}
+			pStatus.Phase = v1alpha1.WorkflowStepPhaseFailed
+			pStatus.Reason = types.StatusReasonDependsOn
+			pStatus.Message = fmt.Sprintf("Failed to evaluate the condition of DependsOn %s: %s", depend.Name, err.Error())
+			return false, pStatus
+		}
+		if !active {
+			continue
+		}
+		if status, ok := stepStatus[depend.Name]; !ok || !types.IsStepFinish(status.Phase, status.Reason) {
+			return true, pStatus
+		}
+	}
 	for _, input := range step.Inputs {
 		pStatus.Message = fmt.Sprintf("Pending on Input: %s", input.From)
 		if _, err := ctx.GetVar(strings.Split(input.From, ".")...); err != nil {
@@ -20,6 +20,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"testing"
 
 	"cuelang.org/go/cue"
@@ -27,10 +28,16 @@ import (
 	"github.com/crossplane/crossplane-runtime/pkg/test"
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/require"
+	authenticationv1 "k8s.io/api/authentication/v1"
 	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/rest"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
 	"sigs.k8s.io/yaml"
 
 	cuexv1alpha1 "github.com/kubevela/pkg/apis/cue/v1alpha1"
@@ -42,10 +49,12 @@ import (
 
 	"github.com/kubevela/workflow/api/v1alpha1"
 	wfContext "github.com/kubevela/workflow/pkg/context"
+	"github.com/kubevela/workflow/pkg/cue/model"
 	"github.com/kubevela/workflow/pkg/cue/process"
 	"github.com/kubevela/workflow/pkg/providers"
 	providertypes "github.com/kubevela/workflow/pkg/providers/types"
 	"github.com/kubevela/workflow/pkg/types"
+	"github.com/kubevela/workflow/pkg/utils"
 )
 
 func TestTaskLoader(t *testing.T) {
@@ -350,6 +359,139 @@ func TestPendingInputCheck(t *testing.T) {
 	r.Equal(p, false)
 }
 
+func TestContextAttempt(t *testing.T) {
+	r := require.New(t)
+	var attempts []int
+	compiler := cuex.NewCompilerWithInternalPackages(
+		pkgruntime.Must(cuexruntime.NewInternalPackage("test", "", map[string]cuexruntime.ProviderFn{
+			"attempt": providertypes.GenericProviderFn[map[string]int, any](func(ctx context.Context, params *providertypes.Params[map[string]int]) (*any, error) {
+				attempts = append(attempts, params.Params["attempt"])
+				return nil, errors.New("execute error")
+			}),
+		})),
+	)
+	pCtx := process.NewContext(process.ContextData{
+		Name:      "app",
+		Namespace: "default",
+	})
+	tasksLoader := NewTaskLoader(func(_ context.Context, name string) (string, error) {
+		return `
+process: {
+	#provider: "test"
+	#do: "attempt"
+	$params: attempt: context.attempt
+}
+`, nil
+	}, 0, pCtx, compiler)
+	step := v1alpha1.WorkflowStep{
+		WorkflowStepBase: v1alpha1.WorkflowStepBase{
+			Name: "retry",
+			Type: "attempt",
+		},
+	}
+	gen, err := tasksLoader.GetTaskGenerator(context.Background(), step.Type)
+	r.NoError(err)
+	runner, err := gen(step, &types.TaskGeneratorOptions{ID: "retry-id"})
+	r.NoError(err)
+	wfCtx := newWorkflowContextForTest(t)
+	for i := 0; i < 3; i++ {
+		status, _, err := runner.Run(wfCtx, &types.TaskRunOptions{})
+		r.NoError(err)
+		r.Equal(v1alpha1.WorkflowStepPhaseFailed, status.Phase)
+	}
+	r.Equal([]int{1, 2, 3}, attempts)
+	r.Nil(pCtx.GetData(model.ContextStepAttempt))
+}
+
+type recordingSink struct {
+	outputs []types.StepOutput
+	err     error
+}
+
+func (s *recordingSink) Send(_ context.Context, output types.StepOutput) error {
+	s.outputs = append(s.outputs, output)
+	return s.err
+}
+
+func TestOutputSink(t *testing.T) {
+	r := require.New(t)
+	compiler := cuex.NewCompilerWithInternalPackages(
+		pkgruntime.Must(cuexruntime.NewInternalPackage("test", "", map[string]cuexruntime.ProviderFn{
+			"ok": providertypes.LegacyGenericProviderFn[any, any](func(ctx context.Context, val *providertypes.LegacyParams[any]) (*any, error) {
+				return nil, nil
+			}),
+			"wait": providertypes.LegacyGenericProviderFn[any, any](func(ctx context.Context, val *providertypes.LegacyParams[any]) (*any, error) {
+				val.RuntimeParams.Action.Wait("I am waiting")
+				return nil, nil
+			}),
+		})),
+	)
+	pCtx := process.NewContext(process.ContextData{
+		Name:      "app",
+		Namespace: "default",
+	})
+	tasksLoader := NewTaskLoader(mockLoadTemplate, 0, pCtx, compiler)
+	wfCtx := newWorkflowContextForTest(t)
+	steps := []v1alpha1.WorkflowStep{
+		{
+			WorkflowStepBase: v1alpha1.WorkflowStepBase{
+				Name:    "first",
+				Type:    "ok",
+				Outputs: v1alpha1.StepOutputs{{Name: "firstName", ValueFrom: "name"}},
+			},
+		},
+		{
+			WorkflowStepBase: v1alpha1.WorkflowStepBase{
+				Name: "wait",
+				Type: "wait",
+			},
+		},
+		{
+			WorkflowStepBase: v1alpha1.WorkflowStepBase{
+				Name:    "second",
+				Type:    "ok",
+				Outputs: v1alpha1.StepOutputs{{Name: "secondName", ValueFrom: "name"}},
+			},
+		},
+	}
+	sink := &recordingSink{}
+	for i, step := range steps {
+		gen, err := tasksLoader.GetTaskGenerator(context.Background(), step.Type)
+		r.NoError(err)
+		run, err := gen(step, &types.TaskGeneratorOptions{ID: step.Name + "-id"})
+		r.NoError(err)
+		if i == len(steps)-1 {
+			// the failures of the sink do not fail the step
+			sink.err = errors.New("sink is unavailable")
+		}
+		status, _, err := run.Run(wfCtx, &types.TaskRunOptions{OutputSink: sink})
+		r.NoError(err)
+		if step.Name != "wait" {
+			r.Equal(v1alpha1.WorkflowStepPhaseSucceeded, status.Phase)
+		}
+	}
+	r.Equal([]types.StepOutput{
+		{
+			WorkflowRun: "app",
+			Namespace:   "default",
+			StepID:      "first-id",
+			StepName:    "first",
+			StepType:    "ok",
+			Phase:       v1alpha1.WorkflowStepPhaseSucceeded,
+			Outputs:     map[string]interface{}{"firstName": "app"},
+		},
+		{
+			WorkflowRun: "app",
+			Namespace:   "default",
+			StepID:      "second-id",
+			StepName:    "second",
+			StepType:    "ok",
+			Phase:       v1alpha1.WorkflowStepPhaseSucceeded,
+			Outputs:     map[string]interface{}{"secondName": "app"},
+		},
+	}, sink.outputs)
+}
+
 func TestPendingDependsOnCheck(t *testing.T) {
 	wfCtx := newWorkflowContextForTest(t)
 	r := require.New(t)
@@ -381,6 +523,91 @@ func TestPendingDependsOnCheck(t *testing.T) {
 	r.Equal(p, false)
 }
 
+func TestPendingConditionalDependsOnCheck(t *testing.T) {
+	wfCtx := newWorkflowContextForTest(t)
+	pCtx := process.NewContext(process.ContextData{
+		Name:      "app",
+		Namespace: "default",
+	})
+	tasksLoader := NewTaskLoader(mockLoadTemplate, 0, pCtx, providers.DefaultCompiler.Get())
+	logCtx := monitorContext.NewTraceContext(context.Background(), "test-app")
+	newRunner := func(t *testing.T, properties string, depends ...v1alpha1.ConditionalDependency) types.TaskRunner {
+		step := v1alpha1.WorkflowStep{
+			WorkflowStepBase: v1alpha1.WorkflowStepBase{
+				Name:                 "pending",
+				Type:                 "ok",
+				ConditionalDependsOn: depends,
+				Properties:           &runtime.RawExtension{Raw: []byte(properties)},
+			},
+		}
+		gen, err := tasksLoader.GetTaskGenerator(context.Background(), step.Type)
+		require.NoError(t, err)
+		run, err := gen(step, &types.TaskGeneratorOptions{})
+		require.NoError(t, err)
+		return run
+	}
+	succeeded := map[string]v1alpha1.StepStatus{
+		"depend": {Phase: v1alpha1.WorkflowStepPhaseSucceeded},
+	}
+
+	t.Run("included", func(t *testing.T) {
+		r := require.New(t)
+		run := newRunner(t, `{"waitForDepend":true}`, v1alpha1.ConditionalDependency{Name: "depend", If: "parameter.waitForDepend"})
+		p, status := run.Pending(logCtx, wfCtx, nil)
+		r.True(p)
+		r.Equal("Pending on DependsOn: depend", status.Message)
+		p, _ = run.Pending(logCtx, wfCtx, succeeded)
+		r.False(p)
+	})
+
+	t.Run("excluded", func(t *testing.T) {
+		r := require.New(t)
+		run := newRunner(t, `{"waitForDepend":false}`, v1alpha1.ConditionalDependency{Name: "depend", If: "parameter.waitForDepend"})
+		p, _ := run.Pending(logCtx, wfCtx, nil)
+		r.False(p)
+	})
+
+	t.Run("condition not resolvable yet", func(t *testing.T) {
+		r := require.New(t)
+		run := newRunner(t, `{}`, v1alpha1.ConditionalDependency{Name: "depend", If: "status.build.succeeded"})
+		p, status := run.Pending(logCtx, wfCtx, nil)
+		r.True(p)
+		r.Contains(status.Message, "Pending on the condition of DependsOn depend")
+		p, _ = run.Pending(logCtx, wfCtx, map[string]v1alpha1.StepStatus{
+			"build":  {Phase: v1alpha1.WorkflowStepPhaseFailed},
+			"depend": {Phase: v1alpha1.WorkflowStepPhaseRunning},
+		})
+		r.False(p)
+		p, _ = run.Pending(logCtx, wfCtx, map[string]v1alpha1.StepStatus{
+			"build":  {Phase: v1alpha1.WorkflowStepPhaseSucceeded},
+			"depend": {Phase: v1alpha1.WorkflowStepPhaseRunning},
+		})
+		r.True(p)
+	})
+
+	t.Run("condition not resolvable after the dependencies finished", func(t *testing.T) {
+		r := require.New(t)
+		run := newRunner(t, `{}`, v1alpha1.ConditionalDependency{Name: "depend", If: "status.build.succeeded"})
+		p, status := run.Pending(logCtx, wfCtx, succeeded)
+		r.False(p)
+		r.Equal(v1alpha1.WorkflowStepPhaseFailed, status.Phase)
+		r.Equal(types.StatusReasonDependsOn, status.Reason)
+		r.Equal("pending", status.Name)
+		r.Contains(status.Message, "Failed to evaluate the condition of DependsOn depend")
+	})
+
+	t.Run("invalid condition", func(t *testing.T) {
+		r := require.New(t)
+		run := newRunner(t, `{"name":"app"}`, v1alpha1.ConditionalDependency{Name: "depend", If: "parameter.name + 1"})
+		// the invalid condition fails the step even if the dependencies are not finished
+		p, status := run.Pending(logCtx, wfCtx, nil)
+		r.False(p)
+		r.Equal(v1alpha1.WorkflowStepPhaseFailed, status.Phase)
+		r.Equal(types.StatusReasonDependsOn, status.Reason)
+		r.Contains(status.Message, "Failed to evaluate the condition of DependsOn depend: invalid dependency condition")
+	})
+}
+
 func TestSkip(t *testing.T) {
 	r := require.New(t)
 	step := v1alpha1.WorkflowStep{
@@ -450,6 +677,205 @@ func TestTimeout(t *testing.T) {
 	r.Equal(status.Reason, types.StatusReasonTimeout)
 }
 
+func TestWarnings(t *testing.T) {
+	r := require.New(t)
+	type warnReturns = providertypes.Returns[map[string]string]
+	compiler := cuex.NewCompilerWithInternalPackages(
+		pkgruntime.Must(cuexruntime.NewInternalPackage("test", "", map[string]cuexruntime.ProviderFn{
+			"warn": providertypes.GenericProviderFn[any, warnReturns](func(ctx context.Context, params *providertypes.Params[any]) (*warnReturns, error) {
+				warnings := []string{"apps/v1beta1 is deprecated", "apps/v1beta1 is deprecated"}
+				for i := 0; i < types.MaxWorkflowStepWarnings; i++ {
+					warnings = append(warnings, fmt.Sprintf("warning-%d", i))
+				}
+				return &warnReturns{Returns: map[string]string{"result": "ok"}, Warnings: warnings}, nil
+			}),
+		})),
+	)
+	pCtx := process.NewContext(process.ContextData{
+		Name:      "app",
+		Namespace: "default",
+	})
+	tasksLoader := NewTaskLoader(func(_ context.Context, name string) (string, error) {
+		return `
+process: {
+	#provider: "test"
+	#do: "warn"
+	$params: {}
+}
+result: process.$returns.result
+`, nil
+	}, 0, pCtx, compiler)
+	step := v1alpha1.WorkflowStep{
+		WorkflowStepBase: v1alpha1.WorkflowStepBase{
+			Name: "warn",
+			Type: "warn",
+			Outputs: v1alpha1.StepOutputs{{
+				Name:      "result",
+				ValueFrom: "result",
+			}},
+		},
+	}
+	gen, err := tasksLoader.GetTaskGenerator(context.Background(), step.Type)
+	r.NoError(err)
+	runner, err := gen(step, &types.TaskGeneratorOptions{})
+	r.NoError(err)
+	wfCtx := newWorkflowContextForTest(t)
+	status, operation, err := runner.Run(wfCtx, &types.TaskRunOptions{})
+	r.NoError(err)
+	r.Equal(v1alpha1.WorkflowStepPhaseSucceeded, status.Phase)
+	r.Equal("", status.Reason)
+	r.False(operation.Waiting)
+	r.False(operation.Terminated)
+	r.Len(status.Warnings, types.MaxWorkflowStepWarnings)
+	r.Equal("apps/v1beta1 is deprecated", status.Warnings[0])
+	r.Equal("warning-0", status.Warnings[1])
+	r.Equal(fmt.Sprintf("warning-%d", types.MaxWorkflowStepWarnings-2), status.Warnings[types.MaxWorkflowStepWarnings-1])
+	v, err := wfCtx.GetVar("result")
+	r.NoError(err)
+	result, err := v.String()
+	r.NoError(err)
+	r.Equal("ok", result)
+}
+
+func TestMessage(t *testing.T) {
+	r := require.New(t)
+	wfCtx := newWorkflowContextForTest(t)
+	pCtx := process.NewContext(process.ContextData{
+		Name:      "app",
+		Namespace: "default",
+	})
+	tasksLoader := NewTaskLoader(func(_ context.Context, name string) (string, error) {
+		return `
+import "vela/message"
+
+progress: message.#Message & {
+	$params: {
+		text:     "3/5 replicas are ready"
+		level:    "warning"
+		workflow: true
+	}
+}
+`, nil
+	}, 0, pCtx, providers.DefaultCompiler.Get())
+	step := v1alpha1.WorkflowStep{
+		WorkflowStepBase: v1alpha1.WorkflowStepBase{
+			Name: "progress",
+			Type: "progress",
+		},
+	}
+	gen, err := tasksLoader.GetTaskGenerator(context.Background(), step.Type)
+	r.NoError(err)
+	runner, err := gen(step, &types.TaskGeneratorOptions{})
+	r.NoError(err)
+	status, operation, err := runner.Run(wfCtx, &types.TaskRunOptions{})
+	r.NoError(err)
+	r.Equal(v1alpha1.WorkflowStepPhaseSucceeded, status.Phase)
+	r.Equal("3/5 replicas are ready", status.Message)
+	r.Equal([]string{"3/5 replicas are ready"}, status.Warnings)
+	r.Equal("3/5 replicas are ready", operation.WorkflowMessage)
+}
+
+func TestStepCredentials(t *testing.T) {
+	r := require.New(t)
+	wfCtx := newWorkflowContextForTest(t)
+	originKubeClient := singleton.KubeClient.Get()
+	originNewClient := newTokenKubeClient
+	defer func() {
+		singleton.KubeClient.Set(originKubeClient)
+		newTokenKubeClient = originNewClient
+	}()
+	// the kube config of the step is derived from the shared config
+	utils.SetKubeConfigForTest(t, &rest.Config{Host: "https://kube-api", BearerToken: "controller-token"})
+
+	var tokenRequests []*authenticationv1.TokenRequest
+	singleton.KubeClient.Set(fakeclient.NewClientBuilder().WithInterceptorFuncs(interceptor.Funcs{
+		SubResourceCreate: func(ctx context.Context, _ client.Client, subResourceName string, obj client.Object, subResource client.Object, _ ...client.SubResourceCreateOption) error {
+			req, ok := subResource.(*authenticationv1.TokenRequest)
+			if !ok || subResourceName != "token" {
+				return fmt.Errorf("unexpected subresource %s", subResourceName)
+			}
+			if obj.GetName() != "deployer" || obj.GetNamespace() != "default" {
+				return kerrors.NewNotFound(corev1.Resource("serviceaccounts"), obj.GetName())
+			}
+			req.Status.Token = "token-" + strings.Join(req.Spec.Audiences, ",")
+			tokenRequests = append(tokenRequests, req.DeepCopy())
+			return nil
+		},
+	}).Build())
+	var tokens, configTokens []string
+	newTokenKubeClient = func(token string) (client.Client, error) {
+		tokens = append(tokens, token)
+		return fakeclient.NewClientBuilder().WithObjects(&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "visible-with-token", Namespace: "default"},
+		}).Build(), nil
+	}
+
+	compiler := cuex.NewCompilerWithInternalPackages(
+		pkgruntime.Must(cuexruntime.NewInternalPackage("test", "", map[string]cuexruntime.ProviderFn{
+			"get": providertypes.GenericProviderFn[any, providertypes.Returns[bool]](func(ctx context.Context, params *providertypes.Params[any]) (*providertypes.Returns[bool], error) {
+				if params.KubeConfig != nil {
+					configTokens = append(configTokens, params.KubeConfig.BearerToken)
+				}
+				err := params.KubeClient.Get(ctx, client.ObjectKey{Name: "visible-with-token", Namespace: "default"}, &corev1.ConfigMap{})
+				if err != nil {
+					return nil, err
+				}
+				return &providertypes.Returns[bool]{Returns: true}, nil
+			}),
+		})),
+	)
+	pCtx := process.NewContext(process.ContextData{
+		Name:      "app",
+		Namespace: "default",
+	})
+	tasksLoader := NewTaskLoader(func(_ context.Context, name string) (string, error) {
+		return `
+process: {
+	#provider: "test"
+	#do: "get"
+	$params: {}
+}
+`, nil
+	}, 0, pCtx, compiler)
+	run := func(credentials *v1alpha1.StepCredentials) v1alpha1.StepStatus {
+		step := v1alpha1.WorkflowStep{
+			WorkflowStepBase: v1alpha1.WorkflowStepBase{
+				Name:        "get",
+				Type:        "get",
+				Credentials: credentials,
+			},
+		}
+		gen, err := tasksLoader.GetTaskGenerator(context.Background(), step.Type)
+		r.NoError(err)
+		runner, err := gen(step, &types.TaskGeneratorOptions{ID: "step-credentials"})
+		r.NoError(err)
+		status, _, err := runner.Run(wfCtx, &types.TaskRunOptions{})
+		r.NoError(err)
+		return status
+	}
+
+	status := run(&v1alpha1.StepCredentials{ServiceAccountName: "deployer", Audiences: []string{"vault"}})
+	r.Equal(v1alpha1.WorkflowStepPhaseSucceeded, status.Phase)
+	r.Len(tokenRequests, 1)
+	r.Equal([]string{"vault"}, tokenRequests[0].Spec.Audiences)
+	r.Equal(int64(600), *tokenRequests[0].Spec.ExpirationSeconds)
+	r.Equal([]string{"token-vault"}, tokens)
+	// the kube config of the step authenticates with the token as well
+	r.Equal([]string{"token-vault"}, configTokens)
+
+	// the step without credentials uses the shared client
+	status = run(nil)
+	r.Equal(v1alpha1.WorkflowStepPhaseFailed, status.Phase)
+	r.Len(tokenRequests, 1)
+
+	status = run(&v1alpha1.StepCredentials{ServiceAccountName: "not-exist"})
+	r.Equal(v1alpha1.WorkflowStepPhaseFailed, status.Phase)
+	r.Equal(types.StatusReasonExecute, status.Reason)
+	r.Contains(status.Message, "failed to request the token of service account default/not-exist")
+	r.Len(tokens, 1)
+	r.Len(configTokens, 1)
+}
+
 func TestValidateIfValue(t *testing.T) {
 	ctx := newWorkflowContextForTest(t)
 	pCtx := process.NewContext(process.ContextData{
@@ -18,6 +18,7 @@ package types
 
 import (
 	"context"
+	"time"
 
 	"cuelang.org/go/cue"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -92,8 +93,17 @@ type TaskRunOptions struct {
 	StepStatus    map[string]v1alpha1.StepStatus
 	Engine        Engine
 	Compiler      *cuex.Compiler
+	Interceptors  []ProviderInterceptor
+	OutputSink    OutputSink
 }
 
+// ProviderInvoker invokes the provider with the value
+type ProviderInvoker func(ctx context.Context, value cue.Value) (cue.Value, error)
+
+// ProviderInterceptor intercepts the provider calls of the steps, the step context can be retrieved from ctx.
+// It can modify the value and ctx before invoking next, or short-circuit the call by returning without invoking next.
+type ProviderInterceptor func(ctx context.Context, value cue.Value, next ProviderInvoker) (cue.Value, error)
+
 // PreCheckResult is the result of pre check.
 type PreCheckResult struct {
 	Skip    bool
@@ -124,6 +134,10 @@ type Operation struct {
 	Waiting            bool
 	Skip               bool
 	FailedAfterRetries bool
+	// WorkflowMessage is the message written by the step into the workflow status
+	WorkflowMessage string
+	// PollInterval is the interval to poll the waiting step set by the providers
+	PollInterval time.Duration
 }
 
 // TaskGenerator will generate taskRunner.
@@ -159,6 +173,39 @@ type Action interface {
 	GetStatus() v1alpha1.StepStatus
 }
 
+// Warner is the action which records the non-fatal warnings of the providers into the step status.
+type Warner interface {
+	Warn(message string)
+}
+
+// WorkflowMessenger is the action which writes the messages of the providers into the workflow status.
+type WorkflowMessenger interface {
+	WorkflowMessage(message string)
+}
+
+// Poller is the action which sets the interval to poll the waiting step, the poll interval declared by the
+// step takes precedence.
+type Poller interface {
+	PollInterval(interval time.Duration)
+}
+
+// StepOutput is the outputs of a step which are streamed to the output sink once the step is finished.
+type StepOutput struct {
+	WorkflowRun string                     `json:"workflowRun"`
+	Namespace   string                     `json:"namespace"`
+	StepID      string                     `json:"stepID"`
+	StepName    string                     `json:"stepName"`
+	StepType    string                     `json:"stepType,omitempty"`
+	Phase       v1alpha1.WorkflowStepPhase `json:"phase"`
+	Outputs     map[string]interface{}     `json:"outputs,omitempty"`
+}
+
+// OutputSink receives the outputs of the steps as soon as they are finalized, e.g. to feed the real-time dashboards.
+// The errors of the sink are only logged and never fail the step.
+type OutputSink interface {
+	Send(ctx context.Context, output StepOutput) error
+}
+
 // Parameter defines a parameter for cli from capability template
 type Parameter struct {
 	Name     string      `json:"name"`
@@ -199,10 +246,14 @@ const (
 	ContextPrefixBackoffTimes = "backoff_times"
 	// ContextPrefixBackoffReason is the prefix that refer to the current backoff reason in workflow context config map
 	ContextPrefixBackoffReason = "backoff_reason"
+	// ContextPrefixPollInterval is the prefix that refer to the poll interval of the waiting step set by the providers in workflow context memory
+	ContextPrefixPollInterval = "poll_interval"
 	// ContextKeyLastExecuteTime is the key that refer to the last execute time in workflow context config map.
 	ContextKeyLastExecuteTime = "last_execute_time"
 	// ContextKeyNextExecuteTime is the key that refer to the next execute time in workflow context config map.
 	ContextKeyNextExecuteTime = "next_execute_time"
+	// ContextKeyWorkflowMessage is the key that refer to the workflow message written by the steps in workflow context config map.
+	ContextKeyWorkflowMessage = "workflow_message"
 	// ContextKeyLogConfig is key for log config.
 	ContextKeyLogConfig = "logConfig"
 )
@@ -228,6 +279,8 @@ const (
 var (
 	// MaxWorkflowStepErrorRetryTimes is the max retry times of the failed workflow step.
 	MaxWorkflowStepErrorRetryTimes = 10
+	// MaxWorkflowStepWarnings is the max number of the warnings retained in the status of a workflow step.
+	MaxWorkflowStepWarnings = 10
 	// MaxWorkflowWaitBackoffTime is the max time to wait before reconcile wait workflow again
 	MaxWorkflowWaitBackoffTime = 60
 	// MaxWorkflowFailedBackoffTime is the max time to wait before reconcile failed workflow again
@@ -255,6 +308,8 @@ const (
 	StatusReasonOutput = "Output"
 	// StatusReasonFailedAfterRetries is the reason of the workflow progress condition which is FailedAfterRetries.
 	StatusReasonFailedAfterRetries = "FailedAfterRetries"
+	// StatusReasonDependsOn is the reason of the workflow progress condition which is DependsOn.
+	StatusReasonDependsOn = "DependsOn"
 	// StatusReasonTimeout is the reason of the workflow progress condition which is Timeout.
 	StatusReasonTimeout = "Timeout"
 	// StatusReasonAction is the reason of the workflow progress condition which is Action.
@@ -264,6 +319,8 @@ const (
 const (
 	// MessageSuspendFailedAfterRetries is the message of failed after retries
 	MessageSuspendFailedAfterRetries = "The workflow suspends automatically because the failed times of steps have reached the limit"
+	// MessageInvalidProviderClientRateLimit is the message of the invalid rate limit annotations of the provider kube client
+	MessageInvalidProviderClientRateLimit = "The workflow is terminated because the rate limit of the provider kube client is invalid"
 )
 
 const (
@@ -271,6 +328,16 @@ const (
 	AnnotationWorkflowRunDebug = "workflowrun.oam.dev/debug"
 	// AnnotationControllerRequirement indicates the controller version that can process the workflow run
 	AnnotationControllerRequirement = "workflowrun.oam.dev/controller-version-require"
+	// AnnotationProviderKubeAPIQPS lowers the qps of the kube client used by the providers of the workflow run
+	AnnotationProviderKubeAPIQPS = "workflowrun.oam.dev/provider-kube-api-qps"
+	// AnnotationProviderKubeAPIBurst lowers the burst of the kube client used by the providers of the workflow run
+	AnnotationProviderKubeAPIBurst = "workflowrun.oam.dev/provider-kube-api-burst"
+	// AnnotationParentWorkflowRun is the annotation of the parent workflow run of a child run, the value is <namespace>/<name>
+	AnnotationParentWorkflowRun = "workflowrun.oam.dev/parent"
+	// AnnotationParentStep is the annotation of the parent step which created the child run
+	AnnotationParentStep = "workflowrun.oam.dev/parent-step"
+	// AnnotationParentSpanID is the annotation of the span id of the parent step which created the child run
+	AnnotationParentSpanID = "workflowrun.oam.dev/parent-span-id"
 )
 
 // IsStepFinish will decide whether step is finish.
@@ -24,7 +24,6 @@ import (
 
 	"cuelang.org/go/cue"
 	"cuelang.org/go/cue/ast"
-	"cuelang.org/go/cue/cuecontext"
 	"cuelang.org/go/cue/format"
 	corev1 "k8s.io/api/core/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
@@ -406,13 +405,21 @@ func CleanStatusFromStep(steps []v1alpha1.WorkflowStep, stepStatus []v1alpha1.Wo
 	if !found {
 		return nil, nil, fmt.Errorf("failed step %s not found", stepName)
 	}
-	if contextCM != nil && contextCM.Data != nil {
-		v := cuecontext.New().CompileString(contextCM.Data[wfContext.ConfigMapKeyVars])
+	if contextCM != nil && (contextCM.Data != nil || contextCM.BinaryData != nil) {
+		v, err := wfContext.LoadVars(contextCM)
+		if err != nil {
+			return nil, nil, err
+		}
 		s, err := clearContextVars(steps, v, stepName, dependency)
 		if err != nil {
 			return nil, nil, err
 		}
+		// the vars are persisted as the cue string, which are encoded with the codec again on the next commit
+		if contextCM.Data == nil {
+			contextCM.Data = map[string]string{}
+		}
 		contextCM.Data[wfContext.ConfigMapKeyVars] = s
+		delete(contextCM.BinaryData, wfContext.ConfigMapKeyVars)
 	}
 	return stepStatus, contextCM, nil
 }
@@ -489,6 +496,16 @@ func stringsContain(items []string, source string) bool {
 	return false
 }
 
+// withConditionalDependsOn merges the conditional dependencies into the dependencies, the conditions are
+// not evaluated so that all the possible dependents are restarted
+func withConditionalDependsOn(dependsOn []string, conditional []v1alpha1.ConditionalDependency) []string {
+	names := make([]string, 0, len(conditional))
+	for _, depend := range conditional {
+		names = append(names, depend.Name)
+	}
+	return mergeUniqueStringSlice(append([]string{}, dependsOn...), names)
+}
+
 func getStepDependency(steps []v1alpha1.WorkflowStep, stepName string, dag bool) []string {
 	if !dag {
 		dependency := make([]string, 0)
@@ -516,12 +533,12 @@ func getStepDependency(steps []v1alpha1.WorkflowStep, stepName string, dag bool)
 		for _, output := range step.Outputs {
 			stepOutputs[output.Name] = step.Name
 		}
-		dependsOn[step.Name] = step.DependsOn
+		dependsOn[step.Name] = withConditionalDependsOn(step.DependsOn, step.ConditionalDependsOn)
 		for _, sub := range step.SubSteps {
 			for _, output := range sub.Outputs {
 				stepOutputs[output.Name] = sub.Name
 			}
-			dependsOn[sub.Name] = sub.DependsOn
+			dependsOn[sub.Name] = withConditionalDependsOn(sub.DependsOn, sub.ConditionalDependsOn)
 		}
 	}
 	for _, step := range steps {
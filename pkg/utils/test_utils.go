@@ -18,12 +18,45 @@ package utils
 
 import (
 	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
 
 	"github.com/onsi/gomega/format"
 	"github.com/onsi/gomega/types"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/rest"
+
+	"github.com/kubevela/pkg/util/singleton"
 )
 
+// placeholderKubeConfig is loaded as the shared kube config if it is not loaded before the test, as the
+// default loader exits without a kube config.
+const placeholderKubeConfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: placeholder
+  cluster:
+    server: https://placeholder
+contexts:
+- name: placeholder
+  context:
+    cluster: placeholder
+current-context: placeholder
+`
+
+// SetKubeConfigForTest sets the shared kube config for the test, and restores the previous one after the test.
+func SetKubeConfigForTest(t *testing.T, cfg *rest.Config) {
+	path := filepath.Join(t.TempDir(), "kubeconfig")
+	if err := os.WriteFile(path, []byte(placeholderKubeConfig), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("KUBECONFIG", path)
+	origin := singleton.KubeConfig.Get()
+	t.Cleanup(func() { singleton.KubeConfig.Set(origin) })
+	singleton.KubeConfig.Set(cfg)
+}
+
 // JSONMarshal returns the JSON encoding
 func JSONMarshal(o interface{}) []byte {
 	j, _ := json.Marshal(o)
@@ -53,6 +53,7 @@ func (h *ValidatingHandler) ValidateWorkflow(ctx context.Context, wr *v1alpha1.W
 		if step.Timeout != "" {
 			errs = append(errs, h.ValidateTimeout(step.Name, step.Timeout)...)
 		}
+		errs = append(errs, h.ValidatePoll(step.WorkflowStepBase)...)
 		for _, sub := range step.SubSteps {
 			if sub.Name == "" {
 				errs = append(errs, field.Invalid(field.NewPath("spec", "workflowSpec", "steps", "subSteps", "name"), sub.Name, "empty step name"))
@@ -64,6 +65,7 @@ func (h *ValidatingHandler) ValidateWorkflow(ctx context.Context, wr *v1alpha1.W
 			if step.Timeout != "" {
 				errs = append(errs, h.ValidateTimeout(step.Name, step.Timeout)...)
 			}
+			errs = append(errs, h.ValidatePoll(sub)...)
 		}
 	}
 	return errs
@@ -78,3 +80,24 @@ func (h *ValidatingHandler) ValidateTimeout(name, timeout string) field.ErrorLis
 	}
 	return errs
 }
+
+// ValidatePoll validates the poll interval and poll backoff of steps
+func (h *ValidatingHandler) ValidatePoll(step v1alpha1.WorkflowStepBase) field.ErrorList {
+	var errs field.ErrorList
+	if step.PollInterval != "" {
+		if d, err := time.ParseDuration(step.PollInterval); err != nil || d <= 0 {
+			errs = append(errs, field.Invalid(field.NewPath("spec", "workflowSpec", "steps", "pollInterval"), step.Name, "invalid poll interval, please use the positive duration like 1s, 1m or 1h"))
+		}
+	}
+	if backoff := step.PollBackoff; backoff != nil {
+		if backoff.Factor < 0 {
+			errs = append(errs, field.Invalid(field.NewPath("spec", "workflowSpec", "steps", "pollBackoff", "factor"), step.Name, "the factor of poll backoff can not be negative"))
+		}
+		if backoff.MaxInterval != "" {
+			if _, err := time.ParseDuration(backoff.MaxInterval); err != nil {
+				errs = append(errs, field.Invalid(field.NewPath("spec", "workflowSpec", "steps", "pollBackoff", "maxInterval"), step.Name, "invalid max interval, please use the format like 1s, 1m or 1h"))
+			}
+		}
+	}
+	return errs
+}